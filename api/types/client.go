@@ -3,14 +3,76 @@ package types
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type ConnectorCreateOptions struct {
 	SkupperNamespace string
 	Name             string
 	Cost             int32
+	HostAliases      map[string]string
+	Proxy            LinkProxy
+}
+
+// LinkProxy is an HTTP proxy through which a link is made, using the
+// CONNECT method, where the site can only reach others through one. User
+// and Password are given if the proxy requires basic authentication.
+type LinkProxy struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+}
+
+func (p *LinkProxy) IsEmpty() bool {
+	return p.Host == ""
+}
+
+func (p *LinkProxy) Address() string {
+	return net.JoinHostPort(p.Host, p.Port)
+}
+
+// TokenCreateOptions restricts the use of a connection token: it cannot
+// be used to create a link after Expiry has elapsed, nor for more than
+// Uses links. Zero values impose no limit. If Site is set, only the site
+// with that id can use the token. Cost, if set, is the cost of links
+// created from the token unless they give their own. Type is either
+// TokenTypeCert, the default, for a token holding the certificate for the
+// link, or TokenTypeClaim for a claim redeemed for one when the link is
+// created.
+//
+// Site is named in the subject of the certificate issued for the link,
+// and is checked against that subject when a link is created from the
+// token, and by the issuing site when a claim is redeemed.
+//
+// Expiry and Uses are enforced by the issuing site only for a claim,
+// when it is redeemed. A cert token carries them as annotations that the
+// site using it checks, so they are advisory: anyone holding the token
+// can remove them, and the certificate is accepted regardless.
+type TokenCreateOptions struct {
+	Expiry time.Duration
+	Uses   int
+	Site   string
+	Cost   int32
+	Type   string
+}
+
+// OfflineBundleOptions configure a token exported as a self-contained
+// bundle for a site that cannot reach this one while the link is set up.
+// The addresses, as host:port, replace those the site advertises, for
+// when the link is made over a path this site cannot discover, such as a
+// tunnel or a NAT. The edge address defaults to the inter-router host.
+type OfflineBundleOptions struct {
+	TokenCreateOptions
+	InterRouterAddress string
+	EdgeAddress        string
 }
 
 type ConnectorRemoveOptions struct {
@@ -20,14 +82,49 @@ type ConnectorRemoveOptions struct {
 }
 
 type ConnectorInspectResponse struct {
-	SkupperNamespace string
-	Connector        *Connector
-	Connected        bool
+	SkupperNamespace  string
+	Connector         *Connector
+	Connected         bool
+	CertificateExpiry time.Time
+}
+
+// TokenInfo describes a secret of the site holding a token: either the
+// credentials of a link made with a token, or a request for a token
+// that the site controller has yet to fulfil
+type TokenInfo struct {
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Created time.Time `json:"created"`
+	// Expiry is when the token expires, if it was issued with an expiry
+	Expiry            time.Time `json:"expiry,omitempty"`
+	CertificateExpiry time.Time `json:"certificateExpiry,omitempty"`
+	// LastConnected is when the link was last seen active, as recorded
+	// by the service controller
+	LastConnected time.Time `json:"lastConnected,omitempty"`
+	Configured    bool      `json:"configured"`
+	Connected     bool      `json:"connected"`
+	Protected     bool      `json:"protected,omitempty"`
+}
+
+// LastUsed returns when the link was last seen active or, if it never
+// was, when it was created
+func (t *TokenInfo) LastUsed() time.Time {
+	if t.LastConnected.IsZero() {
+		return t.Created
+	}
+	return t.LastConnected
+}
+
+// IsStale returns true for tokens that are not in use and have not been
+// for the given time. Protected tokens are never stale.
+func (t *TokenInfo) IsStale(olderThan time.Duration, now time.Time) bool {
+	return !t.Protected && !t.Connected && now.Sub(t.LastUsed()) > olderThan
 }
 
 type SiteConfig struct {
-	Spec      SiteConfigSpec
-	Reference SiteConfigReference
+	Spec          SiteConfigSpec
+	Reference     SiteConfigReference
+	SchemaVersion string
 }
 
 type RouterLogConfig struct {
@@ -55,33 +152,229 @@ type SiteConfigSpec struct {
 	RouterMaxFrameSize     int
 	RouterMaxSessionFrames int
 	Annotations            map[string]string
+	Labels                 map[string]string
+	ImageVerification      ImageVerificationSpec
+	Watermarks             RouterWatermarks
+	EnableProfiling        bool
+	EnableMetricsApi       bool
+	IngressService         string
+	NodePorts              RouterNodePorts
+	UpdateStrategy         string
+	CanaryBakeTime         time.Duration
+	Hooks                  map[string]string
+	HostAliases            map[string]string
+	LinkDirection          string
+	IngressHost            string
+	LinkCertWarningDays    int
+	GatewayClass           string
+	CertificateProvider    string
+	CertificateIssuer      string
+	ConsoleHost            string
+	ConsoleTlsSecret       string
+	// SiteCaSecret and SiteServerSecret name existing secrets to use
+	// for the site CA and the certificate of the inter-router and edge
+	// listeners, rather than generating them
+	SiteCaSecret     string
+	SiteServerSecret string
+	ClusterDomain    string
+	// ClusterScoped sites can expose workloads in other namespaces
+	// of the cluster that are labelled as targets for the site
+	ClusterScoped bool
+	// ServiceSyncInterval is how often the site advertises its services
+	// to the rest of the network
+	ServiceSyncInterval time.Duration
+	// ImportOnDemand sites only create the services of other sites
+	// that have been imported explicitly, rather than all of them
+	ImportOnDemand bool
+	// RouterScheduling and ControllerScheduling constrain the nodes
+	// on which the pods of the router and controller are run
+	RouterScheduling     SchedulingSpec
+	ControllerScheduling SchedulingSpec
+	// RouterResources and ControllerResources are the cpu and memory
+	// requested for, and limits on, the router and controller
+	RouterResources     ResourcesSpec
+	ControllerResources ResourcesSpec
+	// LoadBalancerProvider is the cloud whose annotations make a load
+	// balancer internal; if not set, those of every supported cloud
+	// are applied, as each ignores those of the others
+	LoadBalancerProvider string
+	// LoadBalancerAnnotations are added to the services of load balancer
+	// ingress, replacing the cloud annotations for the same keys
+	LoadBalancerAnnotations map[string]string
+	// RouterEgress sets up the router pods for an egress gateway or
+	// static egress IP, and records the IPs the site's links leave from
+	RouterEgress EgressSpec
+}
+
+// DefaultServiceSyncInterval is how often a site advertises its services
+// unless it sets ServiceSyncInterval. Sites that have not been heard from
+// for MinServiceSyncAgeLimit, or three of their own intervals if longer,
+// have their services withdrawn.
+const (
+	DefaultServiceSyncInterval time.Duration = 5 * time.Second
+	MinServiceSyncAgeLimit     time.Duration = 60 * time.Second
+)
+
+// CheckServiceSyncInterval rejects intervals too short to be useful
+func (s *SiteConfigSpec) CheckServiceSyncInterval() error {
+	if s.ServiceSyncInterval < 0 || (s.ServiceSyncInterval > 0 && s.ServiceSyncInterval < time.Second) {
+		return fmt.Errorf("Invalid value for service-sync-interval: %s (must be at least 1s)", s.ServiceSyncInterval)
+	}
+	return nil
+}
+
+// CheckRouters validates the number of router replicas. Replicas of an
+// interior router mesh with each other, which edge routers cannot do.
+func (s *SiteConfigSpec) CheckRouters() error {
+	if s.Replicas < 0 {
+		return fmt.Errorf("Invalid value for routers: %d", s.Replicas)
+	}
+	if s.Replicas > 1 && s.RouterMode == string(TransportModeEdge) {
+		return fmt.Errorf("An edge site cannot have more than one router")
+	}
+	return nil
+}
+
+// CheckResources validates the cpu and memory quantities of the router
+// and controller
+func (s *SiteConfigSpec) CheckResources() error {
+	if err := checkResources("router", s.RouterResources); err != nil {
+		return err
+	}
+	return checkResources("controller", s.ControllerResources)
+}
+
+func checkResources(component string, resources ResourcesSpec) error {
+	values := []string{resources.Cpu, resources.Memory, resources.CpuLimit, resources.MemoryLimit}
+	for i, suffix := range []string{"cpu", "memory", "cpu-limit", "memory-limit"} {
+		if values[i] == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(values[i]); err != nil {
+			return fmt.Errorf("Invalid value for %s-%s: %s", component, suffix, err)
+		}
+	}
+	return nil
+}
+
+// DefaultLinkCertWarningDays is how many days before the certificates of
+// a link expire that the service controller warns of it, unless the site
+// sets LinkCertWarningDays
+const DefaultLinkCertWarningDays = 30
+
+// RouterNodePorts pins the ports on which the inter-router and edge
+// listeners are reached through the cluster nodes, so that firewall
+// rules can be provisioned in advance. The ports are node ports of the
+// load balancer service unless HostPort is set, in which case they are
+// host ports of the router pod.
+type RouterNodePorts struct {
+	InterRouter int
+	Edge        int
+	HostPort    bool
+}
+
+func (p *RouterNodePorts) IsEnabled() bool {
+	return p.InterRouter > 0 || p.Edge > 0
+}
+
+// RouterWatermarks are the levels of router memory use (as a resource
+// quantity), open connections and undelivered messages at which the
+// service controller raises an alarm. If ShedLoad is set, bridge
+// listeners are withdrawn while any watermark is exceeded.
+type RouterWatermarks struct {
+	Memory      string
+	Connections int
+	Undelivered int
+	ShedLoad    bool
+}
+
+func (w *RouterWatermarks) IsEnabled() bool {
+	return w.Memory != "" || w.Connections > 0 || w.Undelivered > 0
+}
+
+// ImageVerificationSpec configures verification of the cosign signatures
+// of the router and controller images. Either a public key or a keyless
+// identity and issuer may be supplied. In strict mode an image that
+// cannot be verified is not deployed.
+type ImageVerificationSpec struct {
+	PublicKey string
+	Identity  string
+	Issuer    string
+	Strict    bool
+}
+
+func (s *ImageVerificationSpec) IsEnabled() bool {
+	return s.PublicKey != "" || s.Identity != ""
+}
+
+// CheckImageVerification verifies that keyless verification names the
+// issuer of the certificate identity, which cosign requires
+func (s *SiteConfigSpec) CheckImageVerification() error {
+	if s.ImageVerification.PublicKey == "" && s.ImageVerification.Identity != "" && s.ImageVerification.Issuer == "" {
+		return fmt.Errorf("An image signature identity requires an image signature issuer")
+	}
+	return nil
 }
 
 const (
 	IngressRouteString        string = "route"
 	IngressLoadBalancerString string = "loadbalancer"
-	IngressNoneString         string = "none"
+	// IngressLoadBalancerInternalString requests a load balancer that is
+	// only reachable from within the cloud network of the cluster
+	IngressLoadBalancerInternalString string = "loadbalancer-internal"
+	IngressNoneString                 string = "none"
+	IngressKubernetesString           string = "ingress"
+	IngressGatewayString              string = "gateway"
 )
 
 func (s *SiteConfigSpec) IsIngressRoute() bool {
 	return s.Ingress == IngressRouteString
 }
+
+// IsIngressLoadBalancer returns true for either an external or an
+// internal load balancer
 func (s *SiteConfigSpec) IsIngressLoadBalancer() bool {
-	return s.Ingress == IngressLoadBalancerString
+	return s.Ingress == IngressLoadBalancerString || s.IsIngressLoadBalancerInternal()
+}
+func (s *SiteConfigSpec) IsIngressLoadBalancerInternal() bool {
+	return s.Ingress == IngressLoadBalancerInternalString
 }
 func (s *SiteConfigSpec) IsIngressNone() bool {
 	return s.Ingress == IngressNoneString
 }
+func (s *SiteConfigSpec) IsIngressKubernetes() bool {
+	return s.Ingress == IngressKubernetesString
+}
+func (s *SiteConfigSpec) IsIngressGateway() bool {
+	return s.Ingress == IngressGatewayString
+}
 
 func (s *SiteConfigSpec) IsConsoleIngressRoute() bool {
 	return s.getConsoleIngress() == IngressRouteString
 }
 func (s *SiteConfigSpec) IsConsoleIngressLoadBalancer() bool {
-	return s.getConsoleIngress() == IngressLoadBalancerString
+	return s.getConsoleIngress() == IngressLoadBalancerString || s.IsConsoleIngressLoadBalancerInternal()
+}
+func (s *SiteConfigSpec) IsConsoleIngressLoadBalancerInternal() bool {
+	return s.getConsoleIngress() == IngressLoadBalancerInternalString
 }
 func (s *SiteConfigSpec) IsConsoleIngressNone() bool {
 	return s.getConsoleIngress() == IngressNoneString
 }
+func (s *SiteConfigSpec) IsConsoleIngressKubernetes() bool {
+	return s.getConsoleIngress() == IngressKubernetesString
+}
+
+// ConsoleServesTls returns true if the controller serves the console over
+// HTTPS itself, which it does when given a certificate for it or when the
+// console is exposed through an Ingress with TLS passthrough. With
+// OpenShift authentication, TLS is served by the oauth proxy instead.
+func (s *SiteConfigSpec) ConsoleServesTls() bool {
+	if !s.EnableConsole || s.AuthMode == string(ConsoleAuthModeOpenshift) {
+		return false
+	}
+	return s.ConsoleTlsSecret != "" || s.IsConsoleIngressKubernetes()
+}
 func (s *SiteConfigSpec) getConsoleIngress() string {
 	if s.ConsoleIngress == "" {
 		return s.Ingress
@@ -90,20 +383,405 @@ func (s *SiteConfigSpec) getConsoleIngress() string {
 }
 
 func isValidIngress(ingress string) bool {
-	return ingress == "" || ingress == IngressRouteString || ingress == IngressLoadBalancerString || ingress == IngressNoneString
+	return ingress == "" || ingress == IngressRouteString || ingress == IngressLoadBalancerString || ingress == IngressLoadBalancerInternalString || ingress == IngressNoneString
 }
 
 func (s *SiteConfigSpec) CheckIngress() error {
+	if s.IsIngressKubernetes() {
+		if s.IngressHost == "" {
+			return fmt.Errorf("An ingress host is required with --ingress %s", IngressKubernetesString)
+		}
+		return nil
+	}
+	if s.IsIngressGateway() {
+		if s.IngressHost == "" {
+			return fmt.Errorf("An ingress host is required with --ingress %s", IngressGatewayString)
+		}
+		if s.GatewayClass == "" {
+			return fmt.Errorf("A gateway class is required with --ingress %s", IngressGatewayString)
+		}
+		return nil
+	}
 	if !isValidIngress(s.Ingress) {
 		return fmt.Errorf("Invalid value for ingress: %s", s.Ingress)
 	}
 	return nil
 }
 
+// CheckIngressService verifies that an existing service is only adopted
+// for ingress where the router would otherwise have used a load balancer
+func (s *SiteConfigSpec) CheckIngressService() error {
+	if s.IngressService == "" {
+		return nil
+	}
+	if s.RouterMode == string(TransportModeEdge) {
+		return fmt.Errorf("An ingress service cannot be used by an edge site")
+	}
+	if !s.IsIngressLoadBalancer() {
+		return fmt.Errorf("An ingress service can only be used with --ingress %s", IngressLoadBalancerString)
+	}
+	return nil
+}
+
+func (s *SiteConfigSpec) CheckNodePorts() error {
+	if !s.NodePorts.IsEnabled() {
+		return nil
+	}
+	if s.RouterMode == string(TransportModeEdge) {
+		return fmt.Errorf("Router ports cannot be pinned for an edge site")
+	}
+	for _, port := range []int{s.NodePorts.InterRouter, s.NodePorts.Edge} {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("Invalid port number: %d", port)
+		}
+	}
+	if s.NodePorts.InterRouter == s.NodePorts.Edge {
+		return fmt.Errorf("The inter-router and edge ports must differ")
+	}
+	if s.NodePorts.HostPort {
+		return nil
+	}
+	if s.IngressService != "" {
+		return fmt.Errorf("Node ports cannot be pinned on an adopted ingress service")
+	}
+	if !s.IsIngressLoadBalancer() {
+		return fmt.Errorf("Node ports can only be pinned with --ingress %s", IngressLoadBalancerString)
+	}
+	return nil
+}
+
+const (
+	LoadBalancerProviderAWS   string = "aws"
+	LoadBalancerProviderGCP   string = "gcp"
+	LoadBalancerProviderAzure string = "azure"
+)
+
+// CheckLoadBalancer verifies that the cloud provider and annotations are
+// only given where a load balancer is used for ingress
+func (s *SiteConfigSpec) CheckLoadBalancer() error {
+	switch s.LoadBalancerProvider {
+	case "", LoadBalancerProviderAWS, LoadBalancerProviderGCP, LoadBalancerProviderAzure:
+	default:
+		return fmt.Errorf("Invalid value for load-balancer-provider: %s (use %s, %s or %s)", s.LoadBalancerProvider, LoadBalancerProviderAWS, LoadBalancerProviderGCP, LoadBalancerProviderAzure)
+	}
+	if s.LoadBalancerProvider != "" && !s.IsIngressLoadBalancerInternal() && !s.IsConsoleIngressLoadBalancerInternal() {
+		return fmt.Errorf("A load balancer provider can only be given with --ingress %s", IngressLoadBalancerInternalString)
+	}
+	if len(s.LoadBalancerAnnotations) == 0 {
+		return nil
+	}
+	if !s.IsIngressLoadBalancer() && !s.IsConsoleIngressLoadBalancer() {
+		return fmt.Errorf("Load balancer annotations can only be given with --ingress %s or %s", IngressLoadBalancerString, IngressLoadBalancerInternalString)
+	}
+	for key := range s.LoadBalancerAnnotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("Invalid load balancer annotation %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// IsReservedKey returns true for the keys of annotations and labels that
+// belong to Kubernetes and its tooling, e.g. kubectl.kubernetes.io, or to
+// skupper itself, which the pods of a site cannot be given
+func IsReservedKey(key string) bool {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) < 2 {
+		return false
+	}
+	for _, domain := range []string{"kubernetes.io", "k8s.io", InternalQualifier} {
+		if parts[0] == domain || strings.HasSuffix(parts[0], "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAnnotations validates the annotations for the pods of the site
+func (s *SiteConfigSpec) CheckAnnotations() error {
+	for key := range s.Annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("Invalid annotation %q: %s", key, strings.Join(errs, "; "))
+		}
+		if IsReservedKey(key) {
+			return fmt.Errorf("Invalid annotation %q: the prefix is reserved; list it in %s to keep it off the pods", key, AnnotationExcludes)
+		}
+	}
+	return nil
+}
+
+// CheckLabels validates the labels for the pods of the site, which cannot
+// replace those the pods are selected by
+func (s *SiteConfigSpec) CheckLabels() error {
+	for key, value := range s.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("Invalid label %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("Invalid value for label %s: %s", key, strings.Join(errs, "; "))
+		}
+		if IsReservedKey(key) || strings.HasPrefix(key, BaseQualifier+"/") {
+			return fmt.Errorf("Invalid label %q: the prefix is reserved", key)
+		}
+		for _, reserved := range TransportSelectorLabels {
+			if key == reserved {
+				return fmt.Errorf("Label %s is reserved for selecting the pods of the site", key)
+			}
+		}
+	}
+	return nil
+}
+
+// TransportSelectorLabels are the labels the router pods are selected by,
+// which the labels for egress cannot replace
+var TransportSelectorLabels = []string{"application", "skupper.io/component"}
+
+// CheckEgress validates the annotations, labels and IPs for the egress
+// of the router
+func (s *SiteConfigSpec) CheckEgress() error {
+	for key := range s.RouterEgress.Annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("Invalid router egress annotation %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	for key, value := range s.RouterEgress.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("Invalid router egress label %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("Invalid value for router egress label %s: %s", key, strings.Join(errs, "; "))
+		}
+		for _, reserved := range TransportSelectorLabels {
+			if key == reserved {
+				return fmt.Errorf("Router egress label %s is reserved for selecting the router", key)
+			}
+		}
+	}
+	for _, ip := range s.RouterEgress.IPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("Invalid value for router-egress-ips: %s is not an IP address", ip)
+		}
+	}
+	return nil
+}
+
+const (
+	UpdateStrategyRolling string        = "rolling"
+	UpdateStrategyCanary  string        = "canary"
+	DefaultCanaryBakeTime time.Duration = 5 * time.Minute
+)
+
+// IsCanaryUpdate returns true if router updates are first rolled out to
+// a single canary replica. This only applies to routers with more than
+// one replica.
+func (s *SiteConfigSpec) IsCanaryUpdate() bool {
+	return s.UpdateStrategy == UpdateStrategyCanary
+}
+
+func (s *SiteConfigSpec) CheckUpdateStrategy() error {
+	if s.UpdateStrategy != "" && s.UpdateStrategy != UpdateStrategyRolling && s.UpdateStrategy != UpdateStrategyCanary {
+		return fmt.Errorf("Invalid value for update-strategy: %s", s.UpdateStrategy)
+	}
+	if s.CanaryBakeTime < 0 {
+		return fmt.Errorf("Invalid value for canary-bake-time: %s", s.CanaryBakeTime)
+	}
+	if s.CanaryBakeTime > 0 && s.UpdateStrategy != UpdateStrategyCanary {
+		return fmt.Errorf("A canary bake time can only be set with the %s update strategy", UpdateStrategyCanary)
+	}
+	return nil
+}
+
+const (
+	LinkDirectionBoth     string = "both"
+	LinkDirectionInbound  string = "inbound"
+	LinkDirectionOutbound string = "outbound"
+)
+
+// IsEdge returns true if the router of the site is an edge router
+func (s *SiteConfigSpec) IsEdge() bool {
+	return s.RouterMode == string(TransportModeEdge)
+}
+
+// AcceptsLinks returns false if the site never accepts links from other
+// sites, in which case it has no inter-router or edge listeners
+func (s *SiteConfigSpec) AcceptsLinks() bool {
+	return s.RouterMode != string(TransportModeEdge) && s.LinkDirection != LinkDirectionOutbound
+}
+
+// InitiatesLinks returns false if the site never creates links to other
+// sites
+func (s *SiteConfigSpec) InitiatesLinks() bool {
+	return s.LinkDirection != LinkDirectionInbound
+}
+
+func (s *SiteConfigSpec) CheckLinkDirection() error {
+	switch s.LinkDirection {
+	case "", LinkDirectionBoth:
+		return nil
+	case LinkDirectionInbound:
+		if s.RouterMode == string(TransportModeEdge) {
+			return fmt.Errorf("An edge site cannot accept links, so must be able to create them")
+		}
+		return nil
+	case LinkDirectionOutbound:
+		if s.Replicas > 1 {
+			return fmt.Errorf("The replicas of a router that does not accept links cannot link to each other")
+		}
+		if s.IngressService != "" || s.NodePorts.IsEnabled() || (s.Ingress != "" && !s.IsIngressNone()) {
+			return fmt.Errorf("A site that does not accept links cannot have ingress")
+		}
+		return nil
+	default:
+		return fmt.Errorf("Invalid value for link-direction: %s", s.LinkDirection)
+	}
+}
+
+const (
+	CertificateProviderSkupper     string = "skupper"
+	CertificateProviderCertManager string = "cert-manager"
+)
+
+func (s *SiteConfigSpec) IsCertManager() bool {
+	return s.CertificateProvider == CertificateProviderCertManager
+}
+
+func (s *SiteConfigSpec) CheckCertificateProvider() error {
+	switch s.CertificateProvider {
+	case "", CertificateProviderSkupper:
+		if s.CertificateIssuer != "" {
+			return fmt.Errorf("A certificate issuer can only be used with --certificate-provider %s", CertificateProviderCertManager)
+		}
+		return nil
+	case CertificateProviderCertManager:
+		return nil
+	default:
+		return fmt.Errorf("Invalid value for certificate-provider: %s", s.CertificateProvider)
+	}
+}
+
+// CheckSiteCertificates validates the existing secrets configured for
+// the site CA and server certificate. A server certificate not issued
+// by the site CA could not be verified by the sites it issues tokens to.
+func (s *SiteConfigSpec) CheckSiteCertificates() error {
+	if s.SiteCaSecret == "" && s.SiteServerSecret == "" {
+		return nil
+	}
+	if s.RouterMode == string(TransportModeEdge) {
+		return fmt.Errorf("An edge site has no site CA or server certificate")
+	}
+	if s.IsCertManager() {
+		return fmt.Errorf("Existing site certificates cannot be used with --certificate-provider %s", CertificateProviderCertManager)
+	}
+	if s.SiteServerSecret != "" && s.SiteCaSecret == "" {
+		return fmt.Errorf("An existing site server certificate can only be used with the existing CA that issued it (see --site-ca-secret)")
+	}
+	return nil
+}
+
+const (
+	HookPreUpdate  string = "pre-update"
+	HookPostUpdate string = "post-update"
+	HookPreExpose  string = "pre-expose"
+	HookPostExpose string = "post-expose"
+	// A hook is either a webhook url or a reference to a ConfigMap
+	// holding a Job template, with this prefix
+	HookJobPrefix string = "job:"
+)
+
+var ValidHooks = []string{HookPreUpdate, HookPostUpdate, HookPreExpose, HookPostExpose}
+
+func (s *SiteConfigSpec) CheckHooks() error {
+	for hook, ref := range s.Hooks {
+		valid := false
+		for _, h := range ValidHooks {
+			if hook == h {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("Invalid hook %q, must be one of %v", hook, ValidHooks)
+		}
+		if strings.HasPrefix(ref, HookJobPrefix) {
+			if strings.TrimPrefix(ref, HookJobPrefix) == "" {
+				return fmt.Errorf("No job template specified for %s hook", hook)
+			}
+		} else if !strings.HasPrefix(ref, "http://") && !strings.HasPrefix(ref, "https://") {
+			return fmt.Errorf("Invalid value for %s hook: %q must be a url or %s<configmap>", hook, ref, HookJobPrefix)
+		}
+	}
+	return nil
+}
+
+// CheckHostAliases verifies that each hostname is mapped to a valid IP
+// address
+func CheckHostAliases(aliases map[string]string) error {
+	for host, ip := range aliases {
+		if host == "" {
+			return fmt.Errorf("Host alias for %s has no hostname", ip)
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("Invalid IP address for host alias %s: %q", host, ip)
+		}
+	}
+	return nil
+}
+
+// CheckLinkProxy verifies that a proxy, if given, has a host and port
+func CheckLinkProxy(proxy LinkProxy) error {
+	if proxy.IsEmpty() {
+		if proxy.Port != "" || proxy.User != "" || proxy.Password != "" {
+			return fmt.Errorf("Proxy settings given without a proxy host")
+		}
+		return nil
+	}
+	port, err := strconv.Atoi(proxy.Port)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("Invalid value for proxy port: %q", proxy.Port)
+	}
+	if proxy.Password != "" && proxy.User == "" {
+		return fmt.Errorf("A proxy password can only be given with a proxy user")
+	}
+	return nil
+}
+
 func (s *SiteConfigSpec) CheckConsoleIngress() error {
-	if !isValidIngress(s.ConsoleIngress) {
+	if !isValidIngress(s.ConsoleIngress) && s.ConsoleIngress != IngressKubernetesString {
 		return fmt.Errorf("Invalid value for console-ingress: %s", s.ConsoleIngress)
 	}
+	if s.IsConsoleIngressKubernetes() && s.ConsoleHost == "" && s.IngressHost == "" {
+		return fmt.Errorf("A console host or ingress host is required with --console-ingress %s", IngressKubernetesString)
+	}
+	return nil
+}
+
+// Validate runs all the checks of the spec, so that a site is held to
+// the same rules whether it is defined by flags or by a file
+func (s *SiteConfigSpec) Validate() error {
+	checks := []func() error{
+		s.CheckIngress,
+		s.CheckConsoleIngress,
+		s.CheckIngressService,
+		s.CheckNodePorts,
+		s.CheckLoadBalancer,
+		s.CheckUpdateStrategy,
+		s.CheckServiceSyncInterval,
+		s.CheckRouters,
+		s.CheckResources,
+		s.CheckEgress,
+		s.CheckAnnotations,
+		s.CheckLabels,
+		s.CheckLinkDirection,
+		s.CheckCertificateProvider,
+		s.CheckSiteCertificates,
+		s.CheckHooks,
+		s.CheckImageVerification,
+		func() error { return CheckHostAliases(s.HostAliases) },
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -115,11 +793,11 @@ type SiteConfigReference struct {
 }
 
 type ServiceInterfaceCreateOptions struct {
-	Protocol   string
-	Address    string
-	Port       int
-	TargetPort int
-	Headless   bool
+	Protocol    string
+	Address     string
+	Ports       []int
+	TargetPorts []int
+	Headless    bool
 }
 
 type RouterInspectResponse struct {
@@ -128,15 +806,383 @@ type RouterInspectResponse struct {
 	ControllerVersion string
 	ExposedServices   int
 	ConsoleUrl        string
+	SelfTest          string
 }
 
-type VanClientInterface interface {
+// SiteInspectResponse gathers the state of a site, which is otherwise
+// spread across the configmaps, secrets and deployments that make it up
+type SiteInspectResponse struct {
+	Namespace  string
+	Config     *SiteConfig
+	Router     RouterConfigSummary
+	Links      []*Connector
+	Services   []*ServiceInterface
+	Versions   SiteVersions
+	Conditions []SiteCondition
+}
+
+// RouterConfigSummary describes the configuration of the router of a
+// site, as held in its configmap
+type RouterConfigSummary struct {
+	Id          string
+	Mode        string
+	SiteId      string
+	Listeners   []string
+	Connectors  []string
+	SslProfiles []string
+	TcpBridges  int
+	HttpBridges int
+}
+
+// SiteVersions holds the version recorded in the router config of a
+// site, against which updates are checked, and the versions of the
+// images its components are running
+type SiteVersions struct {
+	Site       string
+	Transport  string
+	Controller string
+}
+
+const (
+	SiteConditionConfigured       string = "Configured"
+	SiteConditionRouterReady      string = "RouterReady"
+	SiteConditionControllerReady  string = "ControllerReady"
+	SiteConditionUpdateInProgress string = "UpdateInProgress"
+)
+
+type SiteCondition struct {
+	Type    string
+	Status  bool
+	Message string
+}
+
+// Condition returns the condition of the given type, if the site has it
+func (s *SiteInspectResponse) Condition(conditionType string) *SiteCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+const (
+	ClientEventProgress string = "progress"
+	ClientEventRequest  string = "request"
+	ClientEventWarning  string = "warning"
+)
+
+// ClientEvent reports either the progress of a long running operation of
+// the client, as the step it has reached (of Steps, if that is known) and
+// the time spent on it so far, a request the client made of the cluster,
+// with the status and latency of the response, or a problem that did not
+// stop an operation but which the user should know about
+type ClientEvent struct {
+	Type      string
+	Operation string
+	Step      int
+	Steps     int
+	Message   string
+	Verb      string
+	Resource  string
+	Status    int
+	Err       error
+	Elapsed   time.Duration
+}
+
+type PeerStatus struct {
+	SiteId         string         `json:"site_id"`
+	SiteName       string         `json:"site_name,omitempty"`
+	Reachable      bool           `json:"reachable"`
+	LinkedLocally  bool           `json:"linked_locally"`
+	LinkedRemotely bool           `json:"linked_remotely"`
+	ClockSkew      *time.Duration `json:"clock_skew,omitempty"`
+	Observations   []string       `json:"observations,omitempty"`
+}
+
+// LinkProbeResult reports which sizes of probe message sent to a linked
+// site were echoed back
+type LinkProbeResult struct {
+	SiteId     string   `json:"site_id"`
+	SiteName   string   `json:"site_name,omitempty"`
+	Delivered  []int    `json:"delivered"`
+	Failed     []int    `json:"failed"`
+	Problem    string   `json:"problem,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// ServiceInterfaceListOptions selects the services returned by
+// ServiceInterfaceListWithOptions. Services are listed in address order;
+// if Limit is set, at most that many are returned along with a token to
+// pass as Continue to get the next page.
+type ServiceInterfaceListOptions struct {
+	Protocol string
+	Origin   string
+	Selector string
+	Limit    int
+	Continue string
+}
+
+// ServiceInterfaceRemoveOptions controls the removal of a service. Unless
+// Force is set, services marked as protected are not removed.
+type ServiceInterfaceRemoveOptions struct {
+	Force bool
+}
+
+// ServiceInterfaceUnbindOptions controls the removal of a target from a
+// service. If DeleteIfNoTargets is set, as when a service is unexposed,
+// the service is removed along with its last target, and unless Force is
+// set targets are not removed from services marked as protected.
+type ServiceInterfaceUnbindOptions struct {
+	DeleteIfNoTargets bool
+	Force             bool
+	// TargetNamespace is that of the target, if not the namespace of
+	// the site
+	TargetNamespace string
+}
+
+// ServiceInterfaceBindOptions controls the addition of a target to a
+// service. A TargetNamespace other than that of the site can only be
+// given for a cluster scoped site, and must be labelled with
+// TargetNamespaceLabel set to the namespace of the site.
+type ServiceInterfaceBindOptions struct {
+	TargetNamespace string
+}
+
+type ServiceInterfaceListResponse struct {
+	Items    []*ServiceInterface
+	Continue string
+}
+
+// AddressStats are the router's delivery statistics for the address
+// of a service, as seen by the router at this site
+type AddressStats struct {
+	Address                 string      `json:"address"`
+	Distribution            string      `json:"distribution"`
+	LocalConsumers          int         `json:"localConsumers"`
+	RemoteConsumers         int         `json:"remoteConsumers"`
+	DeliveriesIngress       int         `json:"deliveriesIngress"`
+	DeliveriesEgress        int         `json:"deliveriesEgress"`
+	DeliveriesTransit       int         `json:"deliveriesTransit"`
+	DeliveriesToContainer   int         `json:"deliveriesToContainer"`
+	DeliveriesFromContainer int         `json:"deliveriesFromContainer"`
+	Links                   []LinkStats `json:"links"`
+}
+
+// LinkStats are the delivery and flow control counts for a router link
+// attached to a service address
+type LinkStats struct {
+	Name            string `json:"name"`
+	Direction       string `json:"direction"`
+	Capacity        int    `json:"capacity"`
+	CreditAvailable int    `json:"creditAvailable"`
+	Deliveries      int    `json:"deliveries"`
+	Settled         int    `json:"settled"`
+	Unsettled       int    `json:"unsettled"`
+	Undelivered     int    `json:"undelivered"`
+}
+
+// DumpOptions limits the container logs collected by a debug dump.
+// LogsSince and LogLimitBytes apply to each container; zero means no
+// limit.
+type DumpOptions struct {
+	Version           string
+	KubeConfigPath    string
+	KubeConfigContext string
+	LogsSince         time.Duration
+	LogLimitBytes     int64
+}
+
+const (
+	FindingOk      string = "ok"
+	FindingWarning string = "warning"
+	FindingProblem string = "problem"
+)
+
+// A DebugReportFinding is the result of one of the checks made for a
+// debug report
+type DebugReportFinding struct {
+	Check      string `json:"check"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// DebugReport is a diagnosis of the site. The summary leaves out
+// hostnames, credentials and anything else that should not be shared
+// in an issue report.
+type DebugReport struct {
+	Summary  []string             `json:"summary"`
+	Findings []DebugReportFinding `json:"findings"`
+}
+
+func (r *DebugReport) Count(status string) int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+type ConsoleUser struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type RouterUpdateCheckResponse struct {
+	SiteVersion            string
+	LibraryVersion         string
+	SiteIsNewer            bool
+	VersionUpdate          bool
+	RenameRequired         bool
+	UpdateInProgress       bool
+	RouterImage            string
+	DesiredRouterImage     string
+	ControllerImage        string
+	DesiredControllerImage string
+	RouterImageUpdate      bool
+	ControllerImageUpdate  bool
+}
+
+func (r *RouterUpdateCheckResponse) UpdateAvailable() bool {
+	return !r.SiteIsNewer && (r.VersionUpdate || r.RenameRequired || r.UpdateInProgress || r.RouterImageUpdate || r.ControllerImageUpdate)
+}
+
+// RouterUpdateChange is a single change that an update would make. Action
+// is one of create, update, delete or run; Kind and Name identify the
+// resource (or hook) and Detail says what changes about it.
+type RouterUpdateChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (c RouterUpdateChange) String() string {
+	s := c.Action + " " + c.Kind + "/" + c.Name
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// RouterUpdatePlan lists, in the order they would be made, the changes
+// that RouterUpdateVersionInNamespace would make to a site
+type RouterUpdatePlan struct {
+	Namespace   string               `json:"namespace"`
+	FromVersion string               `json:"fromVersion"`
+	ToVersion   string               `json:"toVersion"`
+	Changes     []RouterUpdateChange `json:"changes"`
+}
+
+type ComponentProvenance struct {
+	Component    string
+	Image        string
+	Digests      []string
+	SBOM         string
+	Attestations string
+}
+
+// SiteSpec is the complete declarative definition of a site applied by
+// SiteApply. Config is the data of the site's skupper-site ConfigMap.
+// Links and services not listed are removed from the site.
+type SiteSpec struct {
+	Config   map[string]string  `json:"config"`
+	Links    []SiteSpecLink     `json:"links,omitempty"`
+	Services []ServiceInterface `json:"services,omitempty"`
+}
+
+// SiteSpecLink is a link created from the token in TokenFile
+type SiteSpecLink struct {
+	Name      string `json:"name"`
+	TokenFile string `json:"tokenFile"`
+	Cost      int32  `json:"cost,omitempty"`
+}
+
+// SiteApplyResult lists the changes made by SiteApply, each as
+// <kind>/<name>
+type SiteApplyResult struct {
+	Created []string
+	Updated []string
+	Deleted []string
+}
+
+// Changed returns true if SiteApply made any change to the site
+func (r *SiteApplyResult) Changed() bool {
+	return len(r.Created) > 0 || len(r.Updated) > 0 || len(r.Deleted) > 0
+}
+
+// A gateway runs a router outside of the cluster, e.g. on a VM, that
+// attaches to the site as an edge so that processes reachable from its
+// host can be exposed as services. Its definition is kept in the site,
+// in a ConfigMap named with the prefix.
+const (
+	GatewayPrefix      string = "skupper-gateway-"
+	GatewayTypeService string = "service"
+	GatewayTypePodman  string = "podman"
+)
+
+// GatewayBinding is a service whose targets are reached from the host of
+// a gateway, at Host on the TargetPorts corresponding to its Ports
+type GatewayBinding struct {
+	Address     string `json:"address"`
+	Protocol    string `json:"protocol"`
+	Host        string `json:"host"`
+	Ports       []int  `json:"ports"`
+	TargetPorts []int  `json:"targetPorts"`
+}
+
+// GatewayInspectResponse describes a gateway, the endpoint of the site it
+// connects to and the services bound to it
+type GatewayInspectResponse struct {
+	Name      string           `json:"name"`
+	Type      string           `json:"type"`
+	Url       string           `json:"url"`
+	Connected bool             `json:"connected"`
+	Bindings  []GatewayBinding `json:"bindings"`
+}
+
+// IsValidGatewayType returns true for the ways a gateway can be run
+func IsValidGatewayType(gatewayType string) bool {
+	return gatewayType == GatewayTypeService || gatewayType == GatewayTypePodman
+}
+
+// The platforms a site can be run on. A site on podman runs its router
+// in a container on a host without Kubernetes.
+const (
+	PlatformKubernetes string = "kubernetes"
+	PlatformPodman     string = "podman"
+)
+
+// IsValidPlatform returns true for the platforms a site can be run on
+func IsValidPlatform(platform string) bool {
+	return platform == PlatformKubernetes || platform == PlatformPodman
+}
+
+// SiteManager creates, configures and removes the site in a namespace,
+// and reports on the site and its router
+type SiteManager interface {
+	SiteConfigCreate(ctx context.Context, spec SiteConfigSpec) (*SiteConfig, error)
+	SiteConfigUpdate(ctx context.Context, spec SiteConfigSpec) ([]string, error)
+	SiteConfigInspect(ctx context.Context, input *corev1.ConfigMap) (*SiteConfig, error)
+	SiteConfigParse(ctx context.Context, data []byte) (*SiteConfig, error)
+	SiteConfigRemove(ctx context.Context) error
+	SiteApply(ctx context.Context, spec SiteSpec) (*SiteApplyResult, error)
 	RouterCreate(ctx context.Context, options SiteConfig) error
 	RouterInspect(ctx context.Context) (*RouterInspectResponse, error)
 	RouterInspectNamespace(ctx context.Context, namespace string) (*RouterInspectResponse, error)
+	SiteInspect(ctx context.Context, namespace string) (*SiteInspectResponse, error)
 	RouterRemove(ctx context.Context) error
-	RouterUpdateVersion(ctx context.Context, hup bool) (bool, error)
-	RouterUpdateVersionInNamespace(ctx context.Context, hup bool, namespace string) (bool, error)
+	NetworkStatus(ctx context.Context) (*NetworkStatus, error)
+	GetIngressDefault() string
+}
+
+// LinkManager issues the tokens with which other sites link to this one,
+// and creates and removes the links from this site to others
+type LinkManager interface {
 	ConnectorCreateFromFile(ctx context.Context, secretFile string, options ConnectorCreateOptions) (*corev1.Secret, error)
 	ConnectorCreateSecretFromFile(ctx context.Context, secretFile string, options ConnectorCreateOptions) (*corev1.Secret, error)
 	ConnectorCreate(ctx context.Context, secret *corev1.Secret, options ConnectorCreateOptions) error
@@ -145,20 +1191,76 @@ type VanClientInterface interface {
 	ConnectorRemove(ctx context.Context, options ConnectorRemoveOptions) error
 	ConnectorTokenCreate(ctx context.Context, subject string, namespace string) (*corev1.Secret, bool, error)
 	ConnectorTokenCreateFile(ctx context.Context, subject string, secretFile string) error
+	ConnectorTokenCreateWithOptions(ctx context.Context, subject string, namespace string, options TokenCreateOptions) (*corev1.Secret, bool, error)
+	ConnectorTokenCreateFileWithOptions(ctx context.Context, subject string, secretFile string, options TokenCreateOptions) error
+	TokenExportOffline(ctx context.Context, subject string, bundleFile string, options OfflineBundleOptions) error
+	LinkCreateFromBundle(ctx context.Context, bundleFile string, options ConnectorCreateOptions) (*corev1.Secret, error)
+	TokenList(ctx context.Context) ([]*TokenInfo, error)
+	TokenPrune(ctx context.Context, olderThan time.Duration) ([]string, error)
+	RouterPeerStatus(ctx context.Context) ([]PeerStatus, error)
+	RouterLinkProbe(ctx context.Context) ([]LinkProbeResult, error)
+}
+
+// ServiceManager defines the services of the network and binds them to
+// the workloads of the site that handle their requests
+type ServiceManager interface {
 	ServiceInterfaceCreate(ctx context.Context, service *ServiceInterface) error
 	ServiceInterfaceInspect(ctx context.Context, address string) (*ServiceInterface, error)
 	ServiceInterfaceList(ctx context.Context) ([]*ServiceInterface, error)
+	ServiceInterfaceListWithOptions(ctx context.Context, options ServiceInterfaceListOptions) (*ServiceInterfaceListResponse, error)
 	ServiceInterfaceRemove(ctx context.Context, address string) error
+	ServiceInterfaceRemoveWithOptions(ctx context.Context, address string, options ServiceInterfaceRemoveOptions) error
+	ServiceInterfaceStats(ctx context.Context, address string) (*AddressStats, error)
 	ServiceInterfaceUpdate(ctx context.Context, service *ServiceInterface) error
-	ServiceInterfaceBind(ctx context.Context, service *ServiceInterface, targetType string, targetName string, protocol string, targetPort int) error
+	ServiceInterfaceBind(ctx context.Context, service *ServiceInterface, targetType string, targetName string, protocol string, targetPorts []int) error
+	ServiceInterfaceBindWithOptions(ctx context.Context, service *ServiceInterface, targetType string, targetName string, protocol string, targetPorts []int, options ServiceInterfaceBindOptions) error
 	GetHeadlessServiceConfiguration(targetName string, protocol string, address string, port int) (*ServiceInterface, error)
 	ServiceInterfaceUnbind(ctx context.Context, targetType string, targetName string, address string, deleteIfNoTargets bool) error
-	SiteConfigCreate(ctx context.Context, spec SiteConfigSpec) (*SiteConfig, error)
-	SiteConfigUpdate(ctx context.Context, spec SiteConfigSpec) ([]string, error)
-	SiteConfigInspect(ctx context.Context, input *corev1.ConfigMap) (*SiteConfig, error)
-	SiteConfigRemove(ctx context.Context) error
+	ServiceInterfaceUnbindWithOptions(ctx context.Context, targetType string, targetName string, address string, options ServiceInterfaceUnbindOptions) error
+	ServiceInterfaceImport(ctx context.Context, address string) error
+	ServiceInterfaceUnimport(ctx context.Context, address string) error
+	ServiceInterfaceImportList(ctx context.Context) ([]string, error)
+	ServiceSyncNow(ctx context.Context) error
+}
+
+// UpdateManager updates the components of the site to the version of
+// the client, and rolls them back
+type UpdateManager interface {
+	RouterUpdateVersion(ctx context.Context, hup bool) (bool, error)
+	RouterUpdateVersionInNamespace(ctx context.Context, hup bool, namespace string) (bool, error)
+	RouterUpdateRollback(ctx context.Context, namespace string) (bool, error)
+	RouterUpdatePlan(ctx context.Context, hup bool, namespace string) (*RouterUpdatePlan, error)
+	RouterFinalizeLegacyUpdate(ctx context.Context, force bool) ([]string, error)
+	RouterCheckUpdate(ctx context.Context, namespace string) (*RouterUpdateCheckResponse, error)
+	GetVersion(component string, name string) string
+	ProvenanceInspect(ctx context.Context, includeAttestations bool) ([]*ComponentProvenance, error)
+}
+
+// VanClientInterface is the facade through which the whole of a site is
+// managed. Each of the managers it is composed of can be used, and mocked,
+// on its own by those that need only a part of it.
+type VanClientInterface interface {
+	SiteManager
+	LinkManager
+	ServiceManager
+	UpdateManager
+	MetricsApiRegister(ctx context.Context) error
+	MetricsApiUnregister(ctx context.Context) error
+	RotateCertificates(ctx context.Context) ([]string, error)
+	ConsoleUserCreate(ctx context.Context, name string, password string, role string) error
+	ConsoleUserList(ctx context.Context) ([]ConsoleUser, error)
+	ConsoleUserRemove(ctx context.Context, name string) error
+	SkupperProfile(ctx context.Context, tarName string, duration time.Duration) error
 	SkupperDump(ctx context.Context, tarName string, version string, kubeConfigPath string, kubeConfigContext string) error
+	SkupperDumpWithOptions(ctx context.Context, tarName string, options DumpOptions) error
+	DebugDump(ctx context.Context, path string) error
+	SkupperDebugReport(ctx context.Context) (*DebugReport, error)
 	GetNamespace() string
-	GetVersion(component string, name string) string
-	GetIngressDefault() string
+	GatewayInit(ctx context.Context, name string, gatewayType string) (string, error)
+	GatewayBind(ctx context.Context, name string, binding GatewayBinding) error
+	GatewayExpose(ctx context.Context, name string, binding GatewayBinding) error
+	GatewayUnbind(ctx context.Context, name string, address string) error
+	GatewayRemove(ctx context.Context, name string) error
+	GatewayInspect(ctx context.Context, name string) (*GatewayInspectResponse, error)
+	GatewayList(ctx context.Context) ([]*GatewayInspectResponse, error)
 }
@@ -15,6 +15,9 @@ limitations under the License.
 package types
 
 import (
+	"encoding/json"
+	"strconv"
+
 	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -22,10 +25,12 @@ import (
 
 const (
 	// NamespaceDefault means the VAN is in the  skupper namespace which is applied when not specified by clients
-	NamespaceDefault    string = "skupper"
-	DefaultVanName      string = "skupper"
-	DefaultSiteName     string = "skupper-site"
-	ClusterLocalPostfix string = ".svc.cluster.local"
+	NamespaceDefault string = "skupper"
+	DefaultVanName   string = "skupper"
+	DefaultSiteName  string = "skupper-site"
+	// DefaultClusterDomain is the DNS domain of the cluster assumed when
+	// it is neither configured for the site nor can be detected
+	DefaultClusterDomain string = "cluster.local"
 )
 
 // TransportMode describes how a qdr is intended to be deployed, either interior or edge
@@ -70,8 +75,15 @@ var TransportPrometheusAnnotations = map[string]string{
 	"prometheus.io/scrape": "true",
 }
 
+var ControllerPrometheusAnnotations = map[string]string{
+	"prometheus.io/port":   "9091",
+	"prometheus.io/path":   "/metrics",
+	"prometheus.io/scrape": "true",
+}
+
 // Controller constants
 const (
+	ControllerMetricsPort        int32  = 9091
 	ControllerDeploymentName     string = "skupper-service-controller"
 	ControllerComponentName      string = "proxy-controller"
 	ControllerContainerName      string = "service-controller"
@@ -80,6 +92,47 @@ const (
 	ControllerRoleName           string = "skupper-service-controller"
 	ControllerConfigPath         string = "/etc/messaging/"
 	ControllerServiceName        string = "skupper"
+	// ControllerClusterRoleName is suffixed with the namespace of the
+	// site, as the roles and bindings with this name are outside it
+	ControllerClusterRoleName string = "skupper-service-controller-targets"
+)
+
+// The service controller can serve the custom metrics API through the
+// Kubernetes API aggregation layer, so that horizontal pod autoscalers
+// and monitoring that only talk to the apiserver can consume the link
+// health and traffic of the site
+const (
+	MetricsApiGroup         string = "custom.metrics.k8s.io"
+	MetricsApiVersion       string = "v1beta1"
+	MetricsApiServiceName   string = "skupper-metrics-api"
+	MetricsApiSecret        string = "skupper-metrics-api-certs"
+	MetricsApiPort          int32  = 8443
+	MetricsApiServicePort   int32  = 443
+	MetricsApiCertsPath     string = "/etc/metrics-api-certs/"
+	MetricsApiAuthConfigMap string = "extension-apiserver-authentication"
+	MetricsApiAuthNamespace string = "kube-system"
+)
+
+// A claim is a token holding, rather than the certificate for a link, a
+// password with which the site that issued it can be asked for one. The
+// service controller of the site redeems claims over HTTPS, checking the
+// record of the claim kept in the site, so that a token file does not
+// hold a long lived credential until the link is made.
+const (
+	TokenTypeClaim         string = "claim"
+	TokenTypeCert          string = "cert"
+	TypeClaimRecord        string = "token-claim-record"
+	TypeClaimRequest       string = "token-claim"
+	ClaimUrlAnnotation     string = BaseQualifier + "/url"
+	ClaimSubjectAnnotation string = BaseQualifier + "/claim-subject"
+	ClaimPasswordDataKey   string = "password"
+	ClaimCaCertDataKey     string = "ca.crt"
+	ClaimsServiceName      string = "skupper-claims"
+	ClaimsServerSecret     string = "skupper-claims-server"
+	ClaimsPortName         string = "claims"
+	ClaimsPort             int32  = 8081
+	ClaimsPath             string = "/claims/"
+	ClaimsCertsPath        string = "/etc/claims-certs/"
 )
 
 var ControllerPolicyRule = []rbacv1.PolicyRule{
@@ -88,6 +141,18 @@ var ControllerPolicyRule = []rbacv1.PolicyRule{
 		APIGroups: []string{""},
 		Resources: []string{"services", "configmaps", "pods"},
 	},
+	{
+		// get, list: the custom metrics API resolves label selectors on
+		// links by listing the secrets that match them, and the label of
+		// a link secret is read before it is deleted
+		// update: certificates of the site and of its links are renewed in
+		// place before they expire, without recreating the secrets
+		// delete: removing a link from the console deletes its secret, and
+		// only once the secret is seen to be labelled as a link token
+		Verbs:     []string{"get", "list", "update", "delete"},
+		APIGroups: []string{""},
+		Resources: []string{"secrets"},
+	},
 	{
 		Verbs:     []string{"get", "list", "watch", "create", "update", "delete"},
 		APIGroups: []string{"apps"},
@@ -105,6 +170,33 @@ var ControllerPolicyRule = []rbacv1.PolicyRule{
 	},
 }
 
+// ControllerClusterPolicyRule allows the controller of a cluster scoped
+// site to check which namespaces have opted in to having their targets
+// exposed by it
+var ControllerClusterPolicyRule = []rbacv1.PolicyRule{
+	{
+		Verbs:     []string{"get"},
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+	},
+}
+
+// ControllerTargetPolicyRule allows the controller of a cluster scoped
+// site to resolve targets in a namespace that has opted in, and is only
+// granted in that namespace
+var ControllerTargetPolicyRule = []rbacv1.PolicyRule{
+	{
+		Verbs:     []string{"get", "list", "watch"},
+		APIGroups: []string{""},
+		Resources: []string{"services", "pods"},
+	},
+	{
+		Verbs:     []string{"get", "list", "watch"},
+		APIGroups: []string{"apps"},
+		Resources: []string{"deployments", "statefulsets", "daemonsets"},
+	},
+}
+
 // Certifcates/Secrets constants
 const (
 	LocalClientSecret        string = "skupper-local-client"
@@ -114,6 +206,7 @@ const (
 	SiteCaSecret             string = "skupper-site-ca"
 	OauthConsoleSecret       string = "skupper-console-certs"
 	OauthRouterConsoleSecret string = "skupper-router-console-certs"
+	ConsoleTlsSecret         string = "skupper-console-tls"
 )
 
 // Skupper qualifiers
@@ -134,19 +227,39 @@ const (
 	SkupperTypeQualifier        string = BaseQualifier + "/type"
 	TypeProxyQualifier          string = InternalTypeQualifier + "=proxy"
 	TypeToken                   string = "connection-token"
+	TypeTokenRequest            string = "connection-token-request"
 	TypeTokenQualifier          string = BaseQualifier + "/type=connection-token"
 	TypeTokenRequestQualifier   string = BaseQualifier + "/type=connection-token-request"
 	TokenGeneratedBy            string = BaseQualifier + "/generated-by"
 	TokenCost                   string = BaseQualifier + "/cost"
+	TokenId                     string = BaseQualifier + "/token-id"
+	TokenExpiry                 string = BaseQualifier + "/token-expiry"
+	TokenMaxUses                string = BaseQualifier + "/token-uses"
+	TokenSiteId                 string = BaseQualifier + "/token-site-id"
+	TokenBundleChecksum         string = BaseQualifier + "/bundle-checksum"
+	LinkLastConnected           string = BaseQualifier + "/last-connected"
 	UpdatedAnnotation           string = InternalQualifier + "/updated"
 	AnnotationExcludes          string = BaseQualifier + "/exclude-annotations"
 	ComponentAnnotation         string = BaseQualifier + "/component"
+	SelfTestAnnotation          string = InternalQualifier + "/self-test"
+	IngressServiceAnnotation    string = InternalQualifier + "/ingress-service"
+	HostAliasesAnnotation       string = InternalQualifier + "/host-aliases"
+	ServiceAddressAnnotation    string = InternalQualifier + "/address"
+	LinkProxyAnnotation         string = BaseQualifier + "/proxy"
+	LinkProxyTargetAnnotation   string = InternalQualifier + "/proxy-target"
+	LinkProxyLinkAnnotation     string = InternalQualifier + "/proxy-link"
+	TypeLinkProxy               string = "link-proxy"
+	TypeGatewayDefinition       string = "gateway-definition"
+	CertificateSourceAnnotation string = InternalQualifier + "/certificate-source"
+	ProtectedAnnotation         string = BaseQualifier + "/protected"
+	TargetNamespaceLabel        string = BaseQualifier + "/targets-for"
 	RouterComponent             string = "router"
 )
 
 // Service Interface constants
 const (
 	ServiceInterfaceConfigMap string = "skupper-services"
+	ServiceImportsConfigMap   string = "skupper-service-imports"
 )
 
 // OpenShift constants
@@ -165,6 +278,9 @@ const (
 	ConsoleRouteName                       string = "skupper"
 	RouterConsoleRouteName                 string = "skupper-router-console"
 	RouterConsoleServiceName               string = "skupper-router-console"
+	ConsoleUsersSecret                     string = "skupper-console-users"
+	RouterConsoleUsersSecret               string = "skupper-router-console-users"
+	ConsoleTlsPath                         string = "/etc/console-tls/"
 )
 
 type ConsoleAuthMode string
@@ -186,6 +302,7 @@ const (
 	InterRouterListenerPort int32  = 55671
 	InterRouterRouteName    string = "skupper-inter-router"
 	InterRouterProfile      string = "skupper-internal"
+	RouterGatewayName       string = "skupper-router"
 )
 
 // Service Sync constants
@@ -193,6 +310,16 @@ const (
 	ServiceSyncAddress = "mc/$skupper-service-sync"
 )
 
+// Site config schema constants
+const (
+	SiteConfigVersionKey string = "api-version"
+	// SiteConfigVersion1 is the original schema, where the router mode and
+	// ingress could also be given through the 'edge' and 'cluster-local' keys
+	SiteConfigVersion1 string = "v1"
+	SiteConfigVersion2 string = "v2"
+	SiteConfigVersion  string = SiteConfigVersion2
+)
+
 // RouterSpec is the specification of VAN network with router, controller and assembly
 type RouterSpec struct {
 	Name           string          `json:"name,omitempty"`
@@ -228,6 +355,57 @@ type DeploymentSpec struct {
 	ServiceAccounts []*corev1.ServiceAccount `json:"serviceAccounts,omitempty"`
 	Services        []*corev1.Service        `json:"services,omitempty"`
 	Sidecars        []*corev1.Container      `json:"sidecars,omitempty"`
+	HostAliases     map[string]string        `json:"hostAliases,omitempty"`
+	Scheduling      SchedulingSpec           `json:"scheduling,omitempty"`
+	Resources       ResourcesSpec            `json:"resources,omitempty"`
+	// PodLabels are added to the pods along with the Labels they are
+	// selected by
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+}
+
+// SchedulingSpec constrains the nodes on which the pods of a component
+// are run. Affinity and AntiAffinity are the labels of pods that those
+// of the component must, or must not, share a node with.
+type SchedulingSpec struct {
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Affinity          map[string]string   `json:"affinity,omitempty"`
+	AntiAffinity      map[string]string   `json:"antiAffinity,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
+}
+
+func (s *SchedulingSpec) IsEmpty() bool {
+	return len(s.NodeSelector) == 0 && len(s.Affinity) == 0 && len(s.AntiAffinity) == 0 && len(s.Tolerations) == 0 && s.PriorityClassName == ""
+}
+
+// ResourcesSpec is the cpu and memory requested for the container of a
+// component, and the limits on its use, as quantities such as 500m or
+// 256Mi. Empty values are left to the cluster defaults.
+type ResourcesSpec struct {
+	Cpu         string `json:"cpu,omitempty"`
+	Memory      string `json:"memory,omitempty"`
+	CpuLimit    string `json:"cpuLimit,omitempty"`
+	MemoryLimit string `json:"memoryLimit,omitempty"`
+}
+
+func (r *ResourcesSpec) IsEmpty() bool {
+	return r.Cpu == "" && r.Memory == "" && r.CpuLimit == "" && r.MemoryLimit == ""
+}
+
+// EgressSpec ties the router pods to the egress gateway or static egress
+// IP through which the cluster sends their connections to other sites,
+// so that firewalls in front of those sites can allow them by source IP.
+// Annotations and Labels are added to the router pods only, for egress
+// policies that select pods by either. IPs are the source addresses the
+// connections leave from, recorded for whoever configures those firewalls.
+type EgressSpec struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	IPs         []string          `json:"ips,omitempty"`
+}
+
+func (e *EgressSpec) IsEmpty() bool {
+	return len(e.Annotations) == 0 && len(e.Labels) == 0 && len(e.IPs) == 0
 }
 
 // AssemblySpec for the links and connectors that form the VAN topology
@@ -319,21 +497,230 @@ type TransportConnectedSites struct {
 type ServiceInterface struct {
 	Address      string                   `json:"address"`
 	Protocol     string                   `json:"protocol"`
-	Port         int                      `json:"port"`
+	Ports        []ServicePort            `json:"ports"`
 	EventChannel bool                     `json:"eventchannel,omitempty"`
 	Aggregate    string                   `json:"aggregate,omitempty"`
 	Headless     *Headless                `json:"headless,omitempty"`
 	Targets      []ServiceInterfaceTarget `json:"targets"`
 	Origin       string                   `json:"origin,omitempty"`
+	HealthCheck  *HealthCheck             `json:"healthCheck,omitempty"`
+	Metadata     map[string]string        `json:"metadata,omitempty"`
+	IdlePolicy   *IdlePolicy              `json:"idlePolicy,omitempty"`
+	// TlsCredentials names a secret holding the tls.crt and tls.key
+	// with which the router terminates TLS for callers of the service
+	TlsCredentials string `json:"tlsCredentials,omitempty"`
+	// TlsTrust names a secret holding the ca.crt with which the router
+	// verifies the targets of the service when originating TLS to them
+	TlsTrust string `json:"tlsTrust,omitempty"`
+}
+
+// Matches the origin of services defined at the local site when listing
+// services
+const ServiceOriginLocal string = "local"
+
+// ServicePort is a port on which a service is exposed. The protocol
+// defaults to that of the service.
+type ServicePort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// PrimaryPort returns the first port of the service, or 0 if it has none
+// yet. Headless services are exposed on this port only.
+func (s ServiceInterface) PrimaryPort() int {
+	if len(s.Ports) == 0 {
+		return 0
+	}
+	return s.Ports[0].Port
+}
+
+// HasPort returns whether the service is exposed on the given port
+func (s ServiceInterface) HasPort(port int) bool {
+	for _, p := range s.Ports {
+		if p.Port == port {
+			return true
+		}
+	}
+	return false
 }
 
+// PortNumbers returns the ports of the service in order
+func (s ServiceInterface) PortNumbers() []int {
+	ports := []int{}
+	for _, p := range s.Ports {
+		ports = append(ports, p.Port)
+	}
+	return ports
+}
+
+// SamePorts returns whether two definitions of a service expose it on
+// the same ports with the same protocols
+func (s ServiceInterface) SamePorts(other *ServiceInterface) bool {
+	if len(s.Ports) != len(other.Ports) {
+		return false
+	}
+	for i, port := range s.Ports {
+		if port != other.Ports[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsProtected returns whether the service is marked as protected, so
+// that it is only removed when that is forced
+func (s ServiceInterface) IsProtected() bool {
+	return s.Metadata[ProtectedAnnotation] == "true"
+}
+
+// ProtocolFor returns the protocol used for the given port of the service
+func (s ServiceInterface) ProtocolFor(port ServicePort) string {
+	if port.Protocol == "" {
+		return s.Protocol
+	}
+	return port.Protocol
+}
+
+// AddressForPort returns the router address traffic for a port of the
+// service is sent to. The primary port uses the address of the service
+// itself, so that services with a single port are addressed as before.
+func (s ServiceInterface) AddressForPort(port int) string {
+	return AddressForPort(s.Address, s.PrimaryPort(), port)
+}
+
+// AddressForPort returns the router address for a port of the service
+// with the given address and primary port
+func AddressForPort(address string, primaryPort int, port int) string {
+	if port == primaryPort {
+		return address
+	}
+	return address + ":" + strconv.Itoa(port)
+}
+
+type serviceInterface ServiceInterface
+
+// The port of the primary port and the target port for it are also
+// encoded under the names used before services could have several
+// ports, so that sites running older versions can still read the
+// definitions of services synchronised from this site.
+type legacyServiceInterfaceTarget struct {
+	ServiceInterfaceTarget
+	TargetPort int `json:"targetPort,omitempty"`
+}
+
+type legacyServiceInterface struct {
+	serviceInterface
+	Port    int                            `json:"port,omitempty"`
+	Targets []legacyServiceInterfaceTarget `json:"targets"`
+}
+
+func (s ServiceInterface) MarshalJSON() ([]byte, error) {
+	encoded := legacyServiceInterface{
+		serviceInterface: serviceInterface(s),
+		Port:             s.PrimaryPort(),
+	}
+	if s.Targets != nil {
+		encoded.Targets = []legacyServiceInterfaceTarget{}
+	}
+	for _, t := range s.Targets {
+		encoded.Targets = append(encoded.Targets, legacyServiceInterfaceTarget{
+			ServiceInterfaceTarget: t,
+			TargetPort:             t.TargetPorts[s.PrimaryPort()],
+		})
+	}
+	return json.Marshal(encoded)
+}
+
+func (s *ServiceInterface) UnmarshalJSON(data []byte) error {
+	var decoded legacyServiceInterface
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*s = ServiceInterface(decoded.serviceInterface)
+	if len(s.Ports) == 0 && decoded.Port != 0 {
+		s.Ports = []ServicePort{{Port: decoded.Port}}
+	}
+	if decoded.Targets != nil {
+		s.Targets = []ServiceInterfaceTarget{}
+	}
+	for _, t := range decoded.Targets {
+		target := t.ServiceInterfaceTarget
+		if t.TargetPort != 0 && target.TargetPorts == nil && s.PrimaryPort() != 0 {
+			target.TargetPorts = map[int]int{s.PrimaryPort(): t.TargetPort}
+		}
+		s.Targets = append(s.Targets, target)
+	}
+	return nil
+}
+
+// ServiceInterfaceTarget identifies the pods or service to which traffic
+// for a service is forwarded. TargetPorts maps ports of the service to
+// the port on the target they are forwarded to where these differ.
 type ServiceInterfaceTarget struct {
-	Name       string `json:"name,omitempty"`
-	Selector   string `json:"selector,omitempty"`
-	TargetPort int    `json:"targetPort,omitempty"`
-	Service    string `json:"service,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	Selector    string      `json:"selector,omitempty"`
+	TargetPorts map[int]int `json:"targetPorts,omitempty"`
+	Service     string      `json:"service,omitempty"`
+	// Namespace is that of the target when it is not in the namespace
+	// of the site, which must then be cluster scoped
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Key identifies the target among those of a service
+func (t *ServiceInterfaceTarget) Key() string {
+	key := t.Selector
+	if key == "" {
+		key = t.Service
+	}
+	if t.Namespace != "" {
+		return t.Namespace + "/" + key
+	}
+	return key
+}
+
+// TargetPortFor returns the port on the target that the given port of
+// the service is forwarded to
+func (t *ServiceInterfaceTarget) TargetPortFor(port int) int {
+	if targetPort, ok := t.TargetPorts[port]; ok && targetPort != 0 {
+		return targetPort
+	}
+	return port
+}
+
+// HealthCheck describes how the service controller probes the targets
+// of a service. Targets failing the check are withheld from the router
+// regardless of their kubernetes readiness.
+type HealthCheck struct {
+	Protocol           string `json:"protocol"`
+	Path               string `json:"path,omitempty"`
+	Port               int    `json:"port,omitempty"`
+	IntervalSeconds    int    `json:"intervalSeconds,omitempty"`
+	TimeoutSeconds     int    `json:"timeoutSeconds,omitempty"`
+	HealthyThreshold   int    `json:"healthyThreshold,omitempty"`
+	UnhealthyThreshold int    `json:"unhealthyThreshold,omitempty"`
+	// Service is the name of the service whose status a grpc health
+	// check asks for; if empty, that of the server as a whole
+	Service string `json:"service,omitempty"`
 }
 
+const (
+	HealthCheckDefaultInterval           int = 10
+	HealthCheckDefaultTimeout            int = 1
+	HealthCheckDefaultHealthyThreshold   int = 1
+	HealthCheckDefaultUnhealthyThreshold int = 3
+)
+
+// IdlePolicy has the service controller of the site that defines a
+// service warn when no traffic for it has crossed the network for the
+// given number of days, and if Unexpose is set, remove the service.
+type IdlePolicy struct {
+	Days     int  `json:"days"`
+	Unexpose bool `json:"unexpose,omitempty"`
+}
+
+// Headless services expose each replica of a statefulset at an address
+// of its own. When binding a service to a statefulset, an empty Headless
+// is completed from the statefulset and its governing service.
 type Headless struct {
 	Name       string `json:"name"`
 	Size       int    `json:"size"`
@@ -353,3 +740,65 @@ func (a ByServiceInterfaceAddress) Less(i, j int) bool {
 func (a ByServiceInterfaceAddress) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
+
+// NetworkTopology is a declarative description of a set of sites and
+// the links between them. Each site's Config is the data of its
+// skupper-site ConfigMap.
+type NetworkTopology struct {
+	Pattern       string                 `json:"pattern,omitempty"`
+	Sites         []NetworkSite          `json:"sites"`
+	Links         []NetworkLink          `json:"links"`
+	TokenExchange []NetworkTokenExchange `json:"tokenExchange"`
+}
+
+type NetworkSite struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config"`
+}
+
+// NetworkLink is a link created by the From site to the To site
+type NetworkLink struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	Cost int    `json:"cost,omitempty"`
+}
+
+// NetworkTokenExchange is a token created at IssuedBy and used by
+// RedeemedBy to create the named link
+type NetworkTokenExchange struct {
+	Token      string `json:"token"`
+	Link       string `json:"link"`
+	IssuedBy   string `json:"issuedBy"`
+	RedeemedBy string `json:"redeemedBy"`
+}
+
+const (
+	NetworkPatternHubSpoke string = "hub-spoke"
+	NetworkPatternMesh     string = "mesh"
+	NetworkPatternChain    string = "chain"
+)
+
+// NetworkStatus is the state of each site in the network, as seen from
+// the site it was requested from
+type NetworkStatus struct {
+	Sites []NetworkSiteStatus `json:"sites"`
+}
+
+// NetworkSiteStatus describes a site, the sites it has links to, the
+// services exposed in it and the gateways attached to it
+type NetworkSiteStatus struct {
+	Id        string                 `json:"id"`
+	Name      string                 `json:"name,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Version   string                 `json:"version,omitempty"`
+	Edge      bool                   `json:"edge,omitempty"`
+	Links     []string               `json:"links"`
+	Services  []NetworkServiceStatus `json:"services"`
+	Gateways  []string               `json:"gateways"`
+}
+
+type NetworkServiceStatus struct {
+	Address  string `json:"address"`
+	Protocol string `json:"protocol"`
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestServiceInterfaceLegacyPort(t *testing.T) {
+	legacy := `{"address":"db","protocol":"tcp","port":5432,"targets":[{"name":"db","selector":"app=db","targetPort":15432}]}`
+	service := ServiceInterface{}
+	assert.NilError(t, json.Unmarshal([]byte(legacy), &service))
+	assert.DeepEqual(t, service, ServiceInterface{
+		Address:  "db",
+		Protocol: "tcp",
+		Ports:    []ServicePort{{Port: 5432}},
+		Targets: []ServiceInterfaceTarget{
+			{Name: "db", Selector: "app=db", TargetPorts: map[int]int{5432: 15432}},
+		},
+	})
+
+	service.Ports = append(service.Ports, ServicePort{Port: 8080, Protocol: "http"})
+	encoded, err := json.Marshal(service)
+	assert.NilError(t, err)
+	// older versions only read the primary port
+	decoded := map[string]interface{}{}
+	assert.NilError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, decoded["port"], float64(5432))
+	assert.Equal(t, decoded["targets"].([]interface{})[0].(map[string]interface{})["targetPort"], float64(15432))
+
+	roundTrip := ServiceInterface{}
+	assert.NilError(t, json.Unmarshal(encoded, &roundTrip))
+	assert.DeepEqual(t, roundTrip, service)
+	assert.Equal(t, roundTrip.AddressForPort(5432), "db")
+	assert.Equal(t, roundTrip.AddressForPort(8080), "db:8080")
+	assert.Equal(t, roundTrip.ProtocolFor(roundTrip.Ports[1]), "http")
+}
+
+func TestSiteConfigSpecValidate(t *testing.T) {
+	testcases := []struct {
+		doc           string
+		spec          SiteConfigSpec
+		expectedError string
+	}{
+		{
+			doc: "defaults",
+		},
+		{
+			doc:           "ingress",
+			spec:          SiteConfigSpec{Ingress: "ingress-nginx"},
+			expectedError: "Invalid value for ingress: ingress-nginx",
+		},
+		{
+			doc:           "update strategy",
+			spec:          SiteConfigSpec{UpdateStrategy: "blue-green"},
+			expectedError: "Invalid value for update-strategy: blue-green",
+		},
+		{
+			doc:           "certificate provider",
+			spec:          SiteConfigSpec{CertificateProvider: "vault"},
+			expectedError: "Invalid value for certificate-provider: vault",
+		},
+		{
+			doc:           "hooks",
+			spec:          SiteConfigSpec{Hooks: map[string]string{"pre-delete": "https://hooks.example.com"}},
+			expectedError: "Invalid hook \"pre-delete\"",
+		},
+		{
+			doc:           "host aliases",
+			spec:          SiteConfigSpec{HostAliases: map[string]string{"db.example.com": "not-an-ip"}},
+			expectedError: "Invalid IP address for host alias db.example.com",
+		},
+		{
+			doc:           "image verification",
+			spec:          SiteConfigSpec{ImageVerification: ImageVerificationSpec{Identity: "release@skupper.io"}},
+			expectedError: "An image signature identity requires an image signature issuer",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.doc, func(t *testing.T) {
+			err := c.spec.Validate()
+			if c.expectedError == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, c.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// The certificates a site issues to itself, and the CA that issues each
+var siteCredentials = []struct {
+	name string
+	ca   string
+}{
+	{types.LocalServerSecret, types.LocalCaSecret},
+	{types.LocalClientSecret, types.LocalCaSecret},
+	{types.MetricsApiSecret, types.LocalCaSecret},
+	{types.SiteServerSecret, types.SiteCaSecret},
+}
+
+// RotateCertificates renews the CAs of the site, and the certificates
+// they issue to the router and controller, once less than a third of
+// their lifetime remains. Those issued by cert-manager are left to it,
+// and those supplied when the site was created to the user. The CAs
+// keep their keys, so that certificates already issued to other sites
+// remain valid. If anything is renewed the router and controller are
+// restarted to load it. The names of the renewed secrets are returned.
+func (cli *VanClient) RotateCertificates(ctx context.Context) ([]string, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	now := time.Now()
+	secrets := cli.KubeClient.CoreV1().Secrets(cli.Namespace)
+	renewed := []string{}
+	cas := map[string]*corev1.Secret{}
+	renewedCas := map[string]bool{}
+	for _, name := range []string{types.LocalCaSecret, types.SiteCaSecret} {
+		ca, err := secrets.Get(name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			// edge sites have no site CA
+			continue
+		} else if err != nil {
+			return renewed, err
+		} else if isCertManaged(ca) || isUserSupplied(ca) {
+			continue
+		}
+		due, err := certs.NeedsRenewal(ca.Data["tls.crt"], now)
+		if err != nil {
+			return renewed, fmt.Errorf("Could not check CA %s: %s", name, err)
+		}
+		if due {
+			if ca, err = certs.RenewCASecret(ca); err != nil {
+				return renewed, fmt.Errorf("Could not renew CA %s: %s", name, err)
+			}
+			if ca, err = secrets.Update(ca); err != nil {
+				return renewed, err
+			}
+			certs.RecordSecretChange(certs.SecretUpdated)
+			renewed = append(renewed, name)
+			renewedCas[name] = true
+		}
+		cas[name] = ca
+	}
+	for _, credential := range siteCredentials {
+		ca, ok := cas[credential.ca]
+		if !ok {
+			continue
+		}
+		secret, err := secrets.Get(credential.name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return renewed, err
+		} else if isCertManaged(secret) || isUserSupplied(secret) {
+			continue
+		}
+		// the new CA certificate is distributed along with new
+		// certificates
+		due := renewedCas[credential.ca]
+		if !due {
+			due, err = certs.NeedsRenewal(secret.Data["tls.crt"], now)
+			if err != nil {
+				return renewed, fmt.Errorf("Could not check certificate %s: %s", credential.name, err)
+			}
+		}
+		if due {
+			if secret, err = certs.RenewSecret(secret, ca); err != nil {
+				return renewed, fmt.Errorf("Could not renew certificate %s: %s", credential.name, err)
+			}
+			if _, err = secrets.Update(secret); err != nil {
+				return renewed, err
+			}
+			certs.RecordSecretChange(certs.SecretUpdated)
+			renewed = append(renewed, credential.name)
+		}
+	}
+	if len(renewed) == 0 {
+		return renewed, nil
+	}
+	if err := cli.RouterRestart(ctx, cli.Namespace); err != nil {
+		return renewed, fmt.Errorf("Certificates renewed, but could not restart router: %s", err)
+	}
+	controller, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	if err == nil {
+		touch(controller)
+		_, err = cli.KubeClient.AppsV1().Deployments(cli.Namespace).Update(controller)
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return renewed, fmt.Errorf("Certificates renewed, but could not restart controller: %s", err)
+	}
+	return renewed, nil
+}
+
+// SiteCertificateExpiries returns when the certificates of the CAs of
+// the site, and those they issue to the router and controller, expire
+func (cli *VanClient) SiteCertificateExpiries(ctx context.Context) (map[string]time.Time, error) {
+	names := []string{types.LocalCaSecret, types.SiteCaSecret}
+	for _, credential := range siteCredentials {
+		names = append(names, credential.name)
+	}
+	expiries := map[string]time.Time{}
+	for _, name := range names {
+		secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if expiry, err := certs.GetCertificateExpiry(secret.Data["tls.crt"]); err == nil {
+			expiries[name] = expiry
+		}
+	}
+	return expiries, nil
+}
+
+func isCertManaged(secret *corev1.Secret) bool {
+	_, ok := secret.ObjectMeta.Annotations[kube.CertManagerCertificateAnnotation]
+	return ok
+}
+
+// isUserSupplied returns true if the secret is a copy of one supplied
+// when the site was created, which is left to whoever supplied it
+func isUserSupplied(secret *corev1.Secret) bool {
+	_, ok := secret.ObjectMeta.Annotations[types.CertificateSourceAnnotation]
+	return ok
+}
+
+// linkNeedsRenewal returns true if either the certificate of a link or
+// that of the CA it verifies the remote site with is due for renewal
+func linkNeedsRenewal(secret *corev1.Secret, now time.Time) bool {
+	for _, key := range []string{"tls.crt", "ca.crt"} {
+		if due, err := certs.NeedsRenewal(secret.Data[key], now); err == nil && due {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkCertificatesDue returns the links whose certificates are due for
+// renewal by the sites that issued them
+func (cli *VanClient) LinkCertificatesDue(ctx context.Context) ([]corev1.Secret, error) {
+	secrets, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).List(metav1.ListOptions{LabelSelector: types.TypeTokenQualifier})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	due := []corev1.Secret{}
+	for _, secret := range secrets.Items {
+		if linkNeedsRenewal(&secret, now) {
+			due = append(due, secret)
+		}
+	}
+	return due, nil
+}
+
+// SignLinkCertificateRenewal renews a certificate this site issued for a
+// link from another site
+func (cli *VanClient) SignLinkCertificateRenewal(ctx context.Context, request *certs.RenewalRequest) (*certs.RenewalResponse, error) {
+	ca, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.SiteCaSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return certs.SignRenewalRequest(request, ca, time.Now())
+}
+
+// RenewLinkCertificate stores the certificate renewed by the remote site
+// of a link along with the key it was requested for. The router must be
+// restarted to use it.
+func (cli *VanClient) RenewLinkCertificate(ctx context.Context, name string, key []byte, response *certs.RenewalResponse) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	secret.Data["tls.crt"] = response.Certificate
+	secret.Data["tls.key"] = key
+	secret.Data["ca.crt"] = response.CA
+	if _, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Update(secret); err != nil {
+		return err
+	}
+	certs.RecordSecretChange(certs.SecretUpdated)
+	return nil
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func certificateOf(t *testing.T, data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	assert.Assert(t, block != nil)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NilError(t, err)
+	return cert
+}
+
+func TestRenewCertificates(t *testing.T) {
+	now := time.Now()
+	ca := certs.GenerateCASecret("ca", "ca")
+	secret := certs.GenerateSecret("server", "server", "server.example.com,10.0.0.1", &ca)
+
+	due, err := certs.NeedsRenewal(secret.Data["tls.crt"], now)
+	assert.NilError(t, err)
+	assert.Assert(t, !due)
+	due, err = certs.NeedsRenewal(secret.Data["tls.crt"], now.Add(4*365*24*time.Hour))
+	assert.NilError(t, err)
+	assert.Assert(t, due)
+	_, err = certs.NeedsRenewal([]byte("garbage"), now)
+	assert.Assert(t, err != nil)
+
+	renewedCa, err := certs.RenewCASecret(&ca)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, renewedCa.Data["tls.key"], ca.Data["tls.key"])
+	// certificates issued before the CA was renewed remain valid
+	assert.NilError(t, certificateOf(t, secret.Data["tls.crt"]).CheckSignatureFrom(certificateOf(t, renewedCa.Data["tls.crt"])))
+
+	renewed, err := certs.RenewSecret(&secret, renewedCa)
+	assert.NilError(t, err)
+	assert.Assert(t, string(renewed.Data["tls.key"]) != string(secret.Data["tls.key"]))
+	assert.DeepEqual(t, renewed.Data["ca.crt"], renewedCa.Data["tls.crt"])
+	cert := certificateOf(t, renewed.Data["tls.crt"])
+	assert.NilError(t, cert.CheckSignatureFrom(certificateOf(t, ca.Data["tls.crt"])))
+	assert.Equal(t, cert.Subject.CommonName, "server")
+	assert.DeepEqual(t, cert.DNSNames, []string{"server.example.com"})
+	assert.Equal(t, len(cert.IPAddresses), 1)
+}
+
+func TestLinkCertificateRenewal(t *testing.T) {
+	now := time.Now()
+	ca := certs.GenerateCASecret("ca", "ca")
+	link := certs.GenerateSecret("conn1", "conn1", "", &ca)
+
+	request, key, err := certs.NewRenewalRequest(&link)
+	assert.NilError(t, err)
+	response, err := certs.SignRenewalRequest(request, &ca, now)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, response.CA, ca.Data["tls.crt"])
+	cert := certificateOf(t, response.Certificate)
+	assert.Equal(t, cert.Subject.CommonName, "conn1")
+	// the renewed certificate is for the key generated with the request
+	keyBlock, _ := pem.Decode(key)
+	privateKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cert.PublicKey, &privateKey.PublicKey)
+
+	other := certs.GenerateCASecret("other", "other")
+	_, err = certs.SignRenewalRequest(request, &other, now)
+	assert.ErrorContains(t, err, "not issued by this site")
+	_, err = certs.SignRenewalRequest(request, &ca, now.Add(6*365*24*time.Hour))
+	assert.ErrorContains(t, err, "expired")
+	forged := *request
+	forged.Signature = append([]byte{}, request.Signature...)
+	forged.Signature[0] ^= 0xff
+	_, err = certs.SignRenewalRequest(&forged, &ca, now)
+	assert.ErrorContains(t, err, "not signed by the holder")
+}
+
+func TestRotateCertificates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	secrets := cli.KubeClient.CoreV1().Secrets(cli.Namespace)
+	ca := certs.GenerateCASecret(types.LocalCaSecret, types.LocalCaSecret)
+	_, err = secrets.Create(&ca)
+	assert.NilError(t, err)
+	server := certs.GenerateSecret(types.LocalServerSecret, types.LocalTransportServiceName, types.LocalTransportServiceName, &ca)
+	_, err = secrets.Create(&server)
+	assert.NilError(t, err)
+
+	// nothing is due for a new site, so nothing is touched
+	renewed, err := cli.RotateCertificates(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(renewed), 0)
+	current, err := secrets.Get(types.LocalServerSecret, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, current.Data["tls.crt"], server.Data["tls.crt"])
+
+	due, err := cli.LinkCertificatesDue(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(due), 0)
+
+	cli.ReadOnly = true
+	_, err = cli.RotateCertificates(ctx)
+	assert.Equal(t, err, ErrReadOnly)
+}
+
+func TestSiteCertificateExpiries(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	issued := certs.Issuance()[certs.IssueCA].Issued
+	created := certs.SecretChanges()[certs.SecretCreated]
+	_, err = kube.NewCertAuthority(types.CertAuthority{Name: types.LocalCaSecret}, nil, cli.Namespace, cli.KubeClient)
+	assert.NilError(t, err)
+	assert.Equal(t, certs.Issuance()[certs.IssueCA].Issued, issued+1)
+	assert.Equal(t, certs.SecretChanges()[certs.SecretCreated], created+1)
+
+	expiries, err := cli.SiteCertificateExpiries(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(expiries), 1)
+	assert.Assert(t, expiries[types.LocalCaSecret].After(time.Now().Add(4*365*24*time.Hour)))
+}
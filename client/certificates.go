@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// certificateProvider returns the provider that issues the certificates
+// of the site. With cert-manager, only the site CA, which other sites
+// trust, and the certificates it signs are delegated; the local CA is
+// only ever trusted within the site. Existing secrets may instead be
+// used for the site CA and server certificate.
+func (cli *VanClient) certificateProvider(spec *types.SiteConfigSpec) (kube.CertificateProvider, error) {
+	if err := spec.CheckCertificateProvider(); err != nil {
+		return nil, err
+	}
+	if err := spec.CheckSiteCertificates(); err != nil {
+		return nil, err
+	}
+	if spec.SiteCaSecret != "" {
+		existing := map[string]string{types.SiteCaSecret: spec.SiteCaSecret}
+		if spec.SiteServerSecret != "" {
+			existing[types.SiteServerSecret] = spec.SiteServerSecret
+		}
+		return &kube.ExistingCertificates{
+			Provider: &kube.SelfSignedCertificates{Client: cli.KubeClient},
+			Client:   cli.KubeClient,
+			Secrets:  existing,
+		}, nil
+	}
+	if !spec.IsCertManager() {
+		return &kube.SelfSignedCertificates{Client: cli.KubeClient}, nil
+	}
+	if !cli.CertManager || cli.DynamicClient == nil {
+		return nil, fmt.Errorf("cert-manager not detected in the cluster for --certificate-provider %s", types.CertificateProviderCertManager)
+	}
+	issuer, err := kube.ParseIssuerReference(spec.CertificateIssuer)
+	if err != nil {
+		return nil, err
+	}
+	return &kube.CertManagerCertificates{
+		Client:        cli.KubeClient,
+		DynamicClient: cli.DynamicClient,
+		Issuer:        issuer,
+		CAs:           []string{types.SiteCaSecret},
+	}, nil
+}
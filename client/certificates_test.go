@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func TestParseIssuerReference(t *testing.T) {
+	testcases := []struct {
+		value         string
+		expected      *kube.IssuerReference
+		expectedError string
+	}{
+		{"", nil, ""},
+		{"vault", &kube.IssuerReference{Kind: "Issuer", Name: "vault"}, ""},
+		{"ClusterIssuer/corporate-ca", &kube.IssuerReference{Kind: "ClusterIssuer", Name: "corporate-ca"}, ""},
+		{"Secret/corporate-ca", nil, "Invalid issuer kind \"Secret\", must be Issuer or ClusterIssuer"},
+		{"Issuer/", nil, "No issuer name given in \"Issuer/\""},
+	}
+	for _, c := range testcases {
+		issuer, err := kube.ParseIssuerReference(c.value)
+		if c.expectedError == "" {
+			assert.NilError(t, err)
+			assert.DeepEqual(t, issuer, c.expected)
+		} else {
+			assert.Error(t, err, c.expectedError)
+		}
+	}
+}
+
+func TestRouterCreateWithCertManager(t *testing.T) {
+	ctx := context.Background()
+	spec := types.SiteConfigSpec{
+		SkupperName:       "skupper",
+		RouterMode:        string(types.TransportModeInterior),
+		Ingress:           types.IngressNoneString,
+		CertificateIssuer: "ClusterIssuer/corporate-ca",
+	}
+	assert.Error(t, spec.CheckCertificateProvider(), "A certificate issuer can only be used with --certificate-provider cert-manager")
+	spec.CertificateProvider = types.CertificateProviderCertManager
+	assert.Assert(t, spec.CheckCertificateProvider())
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, spec)
+	assert.Assert(t, err)
+	assert.Equal(t, siteConfig.Spec.CertificateProvider, types.CertificateProviderCertManager)
+	assert.Equal(t, siteConfig.Spec.CertificateIssuer, "ClusterIssuer/corporate-ca")
+	assert.Error(t, cli.RouterCreate(ctx, *siteConfig), "cert-manager not detected in the cluster for --certificate-provider cert-manager")
+
+	cli.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	cli.CertManager = true
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	certificates := cli.DynamicClient.Resource(kube.CertificateResource).Namespace("skupper")
+	ca, err := certificates.Get(types.SiteCaSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	isCA, _, _ := unstructured.NestedBool(ca.Object, "spec", "isCA")
+	assert.Assert(t, isCA)
+	issuer, _, _ := unstructured.NestedString(ca.Object, "spec", "issuerRef", "kind")
+	assert.Equal(t, issuer, "ClusterIssuer")
+	server, err := certificates.Get(types.SiteServerSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	issuer, _, _ = unstructured.NestedString(server.Object, "spec", "issuerRef", "name")
+	assert.Equal(t, issuer, types.SiteCaSecret)
+	hosts, _, _ := unstructured.NestedStringSlice(server.Object, "spec", "dnsNames")
+	assert.DeepEqual(t, hosts, []string{types.TransportServiceName + ".skupper"})
+	_, err = cli.DynamicClient.Resource(kube.IssuerResource).Namespace("skupper").Get(types.SiteCaSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+
+	// the local CA is only trusted within the site, so is generated as before
+	_, err = certificates.Get(types.LocalCaSecret, metav1.GetOptions{})
+	assert.Assert(t, err != nil)
+	_, err = cli.KubeClient.CoreV1().Secrets("skupper").Get(types.LocalClientSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+}
+
+func TestCheckSiteCertificates(t *testing.T) {
+	spec := types.SiteConfigSpec{RouterMode: string(types.TransportModeInterior)}
+	assert.Assert(t, spec.CheckSiteCertificates())
+	spec.SiteServerSecret = "my-server"
+	assert.ErrorContains(t, spec.CheckSiteCertificates(), "see --site-ca-secret")
+	spec.SiteCaSecret = "my-ca"
+	assert.Assert(t, spec.CheckSiteCertificates())
+	spec.CertificateProvider = types.CertificateProviderCertManager
+	assert.ErrorContains(t, spec.CheckSiteCertificates(), "cannot be used with --certificate-provider")
+	spec.CertificateProvider = ""
+	spec.RouterMode = string(types.TransportModeEdge)
+	assert.Error(t, spec.CheckSiteCertificates(), "An edge site has no site CA or server certificate")
+}
+
+func TestRouterCreateWithExistingCertificates(t *testing.T) {
+	testcases := []struct {
+		name          string
+		serverHosts   string
+		serverCA      bool
+		expectedError string
+	}{
+		{"valid", types.TransportServiceName + ".skupper", true, ""},
+		{"wrong-host", "elsewhere.example.com", true, "Certificate in secret my-server is not valid for skupper-router.skupper"},
+		{"wrong-ca", types.TransportServiceName + ".skupper", false, "Certificate in secret my-server was not issued by the CA in secret my-ca"},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			cli, err := newMockClient("skupper", "", "")
+			assert.Assert(t, err)
+			ca := certs.GenerateCASecret("my-ca", "my-ca")
+			issuer := &ca
+			if !c.serverCA {
+				other := certs.GenerateCASecret("other-ca", "other-ca")
+				issuer = &other
+			}
+			server := certs.GenerateSecret("my-server", types.TransportServiceName, c.serverHosts, issuer)
+			delete(server.Data, "ca.crt")
+			for _, secret := range []corev1.Secret{ca, server} {
+				_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Create(&secret)
+				assert.Assert(t, err)
+			}
+			siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+				SkupperName:      "skupper",
+				RouterMode:       string(types.TransportModeInterior),
+				EnableController: true,
+				Ingress:          types.IngressNoneString,
+				SiteCaSecret:     "my-ca",
+				SiteServerSecret: "my-server",
+			})
+			assert.Assert(t, err)
+			assert.Equal(t, siteConfig.Spec.SiteServerSecret, "my-server")
+			err = cli.RouterCreate(ctx, *siteConfig)
+			if c.expectedError != "" {
+				assert.ErrorContains(t, err, c.expectedError)
+				return
+			}
+			assert.Assert(t, err)
+			siteCa, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.SiteCaSecret, metav1.GetOptions{})
+			assert.Assert(t, err)
+			assert.DeepEqual(t, siteCa.Data["tls.crt"], ca.Data["tls.crt"])
+			assert.Equal(t, siteCa.ObjectMeta.Annotations[types.CertificateSourceAnnotation], "my-ca")
+			siteServer, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.SiteServerSecret, metav1.GetOptions{})
+			assert.Assert(t, err)
+			assert.DeepEqual(t, siteServer.Data["tls.crt"], server.Data["tls.crt"])
+			assert.DeepEqual(t, siteServer.Data["ca.crt"], ca.Data["tls.crt"])
+
+			// supplied certificates are never rotated
+			renewed, err := cli.RotateCertificates(ctx)
+			assert.Assert(t, err)
+			for _, name := range renewed {
+				assert.Assert(t, name != types.SiteCaSecret && name != types.SiteServerSecret)
+			}
+		})
+	}
+}
+
+func TestRouterCreateWithConsoleTlsSecret(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	cli.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	ca := certs.GenerateCASecret("console-ca", "console-ca")
+	console := certs.GenerateSecret("my-console", "other.example.com", "other.example.com", &ca)
+	_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Create(&console)
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		EnableConsole:    true,
+		Ingress:          types.IngressNoneString,
+		ConsoleIngress:   types.IngressKubernetesString,
+		ConsoleHost:      "console.example.com",
+		ConsoleTlsSecret: "my-console",
+	})
+	assert.Assert(t, err)
+	assert.ErrorContains(t, cli.RouterCreate(ctx, *siteConfig), "Certificate in secret my-console is not valid for console.example.com")
+}
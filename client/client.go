@@ -1,11 +1,15 @@
 package client
 
 import (
+	"fmt"
+
 	routev1client "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 	"github.com/skupperproject/skupper/api/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -13,22 +17,57 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/statestore"
 )
 
 var Version = "undefined"
 
 // A VAN Client manages orchestration and communications with the network components
 type VanClient struct {
-	Namespace   string
-	KubeClient  kubernetes.Interface
-	RouteClient *routev1client.RouteV1Client
-	RestConfig  *restclient.Config
+	Namespace     string
+	KubeClient    kubernetes.Interface
+	RouteClient   *routev1client.RouteV1Client
+	DynamicClient dynamic.Interface
+	RestConfig    *restclient.Config
+	ReadOnly      bool
+	// GatewayApi is true if the Gateway API resources used to expose
+	// the router are installed in the cluster
+	GatewayApi bool
+	// CertManager is true if the cert-manager resources used to issue
+	// certificates are installed in the cluster
+	CertManager bool
+	// StateStore, if set, returns the store for the bookkeeping records
+	// of the site in a namespace, which are otherwise kept in ConfigMaps
+	StateStore func(namespace string) statestore.Store
+	// Events, if set, is passed the progress of long running operations
+	// and, for clients created with an event handler in their options,
+	// each request made of the cluster
+	Events func(types.ClientEvent)
+	// Driver, if set, runs the site on a platform other than Kubernetes,
+	// in which case the client has no Kubernetes clients
+	Driver SiteDriver
+	// planner, if set, collects the changes made by a dry run of an
+	// update (see RouterUpdatePlan)
+	planner *updatePlanner
+	// VerifyImages is true if the client checks image signatures (see
+	// ClientOptions)
+	VerifyImages bool
 }
 
 func (cli *VanClient) GetNamespace() string {
 	return cli.Namespace
 }
 
+// stateStore returns the store for the bookkeeping records of the site
+// in the namespace. Records created in the default store are owned by
+// the given owners.
+func (cli *VanClient) stateStore(namespace string, owners []metav1.OwnerReference) statestore.Store {
+	if cli.StateStore != nil {
+		return cli.StateStore(namespace)
+	}
+	return statestore.NewConfigMapStore(namespace, cli.KubeClient, owners)
+}
+
 func (cli *VanClient) GetKubeClient() kubernetes.Interface {
 	return cli.KubeClient
 }
@@ -37,17 +76,71 @@ func (cli *VanClient) GetVersion(component string, name string) string {
 	return kube.GetComponentVersion(cli.Namespace, cli.KubeClient, component, name)
 }
 
+// ClientOptions control how a VanClient connects to the cluster. The
+// user agent and impersonation settings allow changes made through the
+// client to be attributed correctly in audit logs.
+type ClientOptions struct {
+	Namespace         string
+	Context           string
+	KubeConfigPath    string
+	ReadOnly          bool
+	UserAgent         string
+	ImpersonateUser   string
+	ImpersonateGroups []string
+	Events            func(types.ClientEvent)
+	// Platform is that of the site, which defaults to kubernetes. For
+	// sites on podman the namespace names the site on this host.
+	Platform string
+	// VerifyImages has the client check the signatures of the images it
+	// deploys, if the site asks for it. That needs the cosign binary and
+	// the key the site names, so it is only set by the CLI.
+	VerifyImages bool
+}
+
 func NewClient(namespace string, context string, kubeConfigPath string) (*VanClient, error) {
-	c := &VanClient{}
+	return NewClientWithOptions(ClientOptions{
+		Namespace:      namespace,
+		Context:        context,
+		KubeConfigPath: kubeConfigPath,
+	})
+}
+
+// NewClientReadOnly returns a client that will not make any changes.
+// All operations that would modify the site return ErrReadOnly. The only
+// commands it runs in the pods of the site are the router queries,
+// qdstat and qdmanage query.
+func NewClientReadOnly(namespace string, context string, kubeConfigPath string) (*VanClient, error) {
+	return NewClientWithOptions(ClientOptions{
+		Namespace:      namespace,
+		Context:        context,
+		KubeConfigPath: kubeConfigPath,
+		ReadOnly:       true,
+	})
+}
+
+func NewClientWithOptions(options ClientOptions) (*VanClient, error) {
+	c := &VanClient{
+		ReadOnly:     options.ReadOnly,
+		VerifyImages: options.VerifyImages,
+	}
+	if options.Platform != "" && !types.IsValidPlatform(options.Platform) {
+		return c, fmt.Errorf("Invalid platform %q, must be %s or %s", options.Platform, types.PlatformKubernetes, types.PlatformPodman)
+	}
+	if options.Platform == types.PlatformPodman {
+		c.Namespace = namespaceOrDefault(options.Namespace, types.DefaultVanName)
+		c.Events = options.Events
+		c.Driver = NewPodmanSiteDriver(c.Namespace)
+		return c, nil
+	}
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if kubeConfigPath != "" {
-		loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath}
+	if options.KubeConfigPath != "" {
+		loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: options.KubeConfigPath}
 	}
 	kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		loadingRules,
 		&clientcmd.ConfigOverrides{
-			CurrentContext: context,
+			CurrentContext: options.Context,
 		},
 	)
 	restconfig, err := kubeconfig.ClientConfig()
@@ -57,11 +150,32 @@ func NewClient(namespace string, context string, kubeConfigPath string) (*VanCli
 	restconfig.ContentConfig.GroupVersion = &schema.GroupVersion{Version: "v1"}
 	restconfig.APIPath = "/api"
 	restconfig.NegotiatedSerializer = serializer.WithoutConversionCodecFactory{CodecFactory: scheme.Codecs}
+	if options.ReadOnly {
+		restconfig.WrapTransport = readOnlyTransport
+	}
+	if options.Events != nil {
+		c.Events = options.Events
+		restconfig.Wrap(tracingTransport(options.Events))
+	}
+	restconfig.UserAgent = options.UserAgent
+	if restconfig.UserAgent == "" {
+		restconfig.UserAgent = "skupper/" + Version
+	}
+	if options.ImpersonateUser != "" {
+		restconfig.Impersonate = restclient.ImpersonationConfig{
+			UserName: options.ImpersonateUser,
+			Groups:   options.ImpersonateGroups,
+		}
+	}
 	c.RestConfig = restconfig
 	c.KubeClient, err = kubernetes.NewForConfig(restconfig)
 	if err != nil {
 		return c, err
 	}
+	c.DynamicClient, err = dynamic.NewForConfig(restconfig)
+	if err != nil {
+		return c, err
+	}
 	dc, err := discovery.NewDiscoveryClientForConfig(restconfig)
 	resources, err := dc.ServerResourcesForGroupVersion("route.openshift.io/v1")
 	if err == nil && len(resources.APIResources) > 0 {
@@ -70,14 +184,16 @@ func NewClient(namespace string, context string, kubeConfigPath string) (*VanCli
 			return c, err
 		}
 	}
+	c.GatewayApi = kube.IsGatewayApiInstalled(dc)
+	c.CertManager = kube.IsCertManagerInstalled(dc)
 
-	if namespace == "" {
+	if options.Namespace == "" {
 		c.Namespace, _, err = kubeconfig.Namespace()
 		if err != nil {
 			return c, err
 		}
 	} else {
-		c.Namespace = namespace
+		c.Namespace = options.Namespace
 	}
 
 	return c, nil
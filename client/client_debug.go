@@ -3,14 +3,17 @@ package client
 import (
 	"archive/tar"
 	"bytes"
-	"compress/gzip"
 	"context"
+	jsonencoding "encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -43,7 +46,7 @@ func writeTar(name string, data []byte, ts time.Time, tw *tar.Writer) error {
 	return nil
 }
 
-func (cli *VanClient) writeDeployment(name string, tw *tar.Writer) error {
+func (cli *VanClient) writeDeployment(name string, bundle *debugBundle) error {
 	var b bytes.Buffer
 	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
 
@@ -57,10 +60,10 @@ func (cli *VanClient) writeDeployment(name string, tw *tar.Writer) error {
 		return err
 	}
 
-	return writeTar(name+"-deployment.yaml", b.Bytes(), time.Now(), tw)
+	return bundle.add(name+"-deployment.yaml", b.Bytes())
 }
 
-func (cli *VanClient) writeConfigMap(name string, tw *tar.Writer) error {
+func (cli *VanClient) writeConfigMap(name string, bundle *debugBundle) error {
 	var b bytes.Buffer
 	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
 
@@ -74,34 +77,202 @@ func (cli *VanClient) writeConfigMap(name string, tw *tar.Writer) error {
 		return err
 	}
 
-	return writeTar(name+"-configmap.yaml", b.Bytes(), time.Now(), tw)
+	return bundle.add(name+"-configmap.yaml", b.Bytes())
 }
 
 func (cli *VanClient) SkupperDump(ctx context.Context, tarName string, version string, kubeConfigPath string, kubeConfigContext string) error {
+	return cli.SkupperDumpWithOptions(ctx, tarName, types.DumpOptions{
+		Version:           version,
+		KubeConfigPath:    kubeConfigPath,
+		KubeConfigContext: kubeConfigContext,
+	})
+}
+
+// writeTarFile copies the file into the archive without reading it all
+// into memory
+func writeTarFile(name string, path string, tw *tar.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// collectLog saves the output of fetch to a file in the staging
+// directory, unless a previous, interrupted dump already collected it,
+// and returns the path of the file. Partially written files are not
+// reused.
+func collectLog(staging string, name string, fetch func(w io.Writer) error) (string, error) {
+	path := filepath.Join(staging, name)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	partial := path + ".partial"
+	file, err := os.Create(partial)
+	if err != nil {
+		return "", err
+	}
+	err = fetch(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		return "", err
+	}
+	return path, os.Rename(partial, path)
+}
+
+func (cli *VanClient) writeLogs(podName string, container string, options types.DumpOptions, staging string, bundle *debugBundle) error {
+	name := podName + "-" + container + "-logs.txt"
+	logOpts := corev1.PodLogOptions{}
+	if options.LogsSince > 0 {
+		seconds := int64(options.LogsSince.Seconds())
+		logOpts.SinceSeconds = &seconds
+	}
+	if options.LogLimitBytes > 0 {
+		logOpts.LimitBytes = &options.LogLimitBytes
+	}
+	path, err := collectLog(staging, name, func(w io.Writer) error {
+		written, err := kube.StreamPodContainerLogs(podName, container, cli.Namespace, cli.KubeClient, logOpts, w)
+		if err == nil && options.LogLimitBytes > 0 && written >= options.LogLimitBytes {
+			_, err = fmt.Fprintf(w, "\n[log truncated at %d bytes]\n", options.LogLimitBytes)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bundle.addFile(name, path)
+}
+
+// routerEntityTypes are the management entities queried from each
+// router for a dump
+var routerEntityTypes = []string{
+	"router",
+	"connection",
+	"listener",
+	"connector",
+	"address",
+	"router.link",
+	"tcpListener",
+	"tcpConnector",
+	"httpListener",
+	"httpConnector",
+}
+
+func (cli *VanClient) writeRouterQueries(podName string, bundle *debugBundle) {
+	for _, entityType := range routerEntityTypes {
+		name := podName + "-qdmanage-" + strings.ReplaceAll(entityType, ".", "-") + ".json"
+		result, err := kube.ExecCommandInContainer([]string{"qdmanage", "query", "--type", entityType}, podName, "router", cli.Namespace, cli.KubeClient, cli.RestConfig)
+		if err != nil {
+			bundle.failed(name, err)
+			continue
+		}
+		bundle.add(name, result.Bytes())
+	}
+}
+
+func (cli *VanClient) writeSiteConfig(ctx context.Context, bundle *debugBundle) error {
+	config, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+	data, err := jsonencoding.MarshalIndent(config.Spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return bundle.add("site-config.json", data)
+}
+
+func (cli *VanClient) writeLinkStatus(ctx context.Context, bundle *debugBundle) error {
+	connectors, err := cli.ConnectorList(ctx)
+	if err != nil {
+		return err
+	}
+	lines := []string{fmt.Sprintf("%-20s %-50s %-10s %s", "NAME", "REMOTE", "CONNECTED", "CERTIFICATE EXPIRY")}
+	for _, connector := range connectors {
+		status, err := cli.ConnectorInspect(ctx, connector.Name)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%-20s %s", connector.Name, err))
+			continue
+		}
+		expiry := "unknown"
+		if !status.CertificateExpiry.IsZero() {
+			expiry = status.CertificateExpiry.Format(time.RFC3339)
+		}
+		lines = append(lines, fmt.Sprintf("%-20s %-50s %-10t %s", connector.Name, connector.Host+":"+connector.Port, status.Connected, expiry))
+	}
+	return bundle.add("link-status.txt", []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// DebugDump writes a bundle of everything collected by a dump, with the
+// default options, to path
+func (cli *VanClient) DebugDump(ctx context.Context, path string) error {
+	return cli.SkupperDumpWithOptions(ctx, path, types.DumpOptions{Version: Version})
+}
+
+// SkupperDumpWithOptions writes the dump to tarName. Container logs are
+// streamed to a staging directory alongside it before being added to
+// the archive, so that large logs are never held in memory; if the dump
+// is interrupted, running it again with the same file name reuses the
+// logs already collected. Anything that could not be collected is listed
+// in the manifest.txt of the archive.
+func (cli *VanClient) SkupperDumpWithOptions(ctx context.Context, tarName string, options types.DumpOptions) error {
+	if err := cli.checkCluster("Dumping the site"); err != nil {
+		return err
+	}
+	version := options.Version
+	kubeConfigPath := options.KubeConfigPath
+	kubeConfigContext := options.KubeConfigContext
 	configMaps := []string{"skupper-site", types.ServiceInterfaceConfigMap, types.TransportConfigMapName, "skupper-sasl-config"}
 	deployments := []string{"skupper-site-controller", "skupper-router", "skupper-service-controller"}
 	qdstatFlags := []string{"-g", "-c", "-l", "-n", "-e", "-a", "-m", "-p"}
 
+	staging := tarName + ".logs"
+	if err := os.MkdirAll(staging, 0700); err != nil {
+		return err
+	}
+
 	tarFile, err := os.Create(tarName)
 	if err != nil {
 		return err
 	}
+	defer tarFile.Close()
 
-	// compress tar
-	gz := gzip.NewWriter(tarFile)
-	defer gz.Close()
-	tw := tar.NewWriter(gz)
-	defer tw.Close()
+	bundle := newDebugBundle(tarFile)
 
 	kv, err := runCommand("kubectl", "version", "--short", "--kubeconfig="+kubeConfigPath, "--context="+kubeConfigContext)
 	if err == nil {
-		writeTar("k8s-versions.txt", kv, time.Now(), tw)
+		bundle.add("k8s-versions.txt", kv)
+	} else {
+		bundle.failed("k8s-versions.txt", err)
 	}
 
 	if cli.RouteClient != nil {
 		ocv, err := runCommand("oc", "version", "--kubeconfig="+kubeConfigPath, "--context="+kubeConfigContext)
 		if err == nil {
-			writeTar("oc-versions.txt", ocv, time.Now(), tw)
+			bundle.add("oc-versions.txt", ocv)
+		} else {
+			bundle.failed("oc-versions.txt", err)
 		}
 	}
 
@@ -111,11 +282,20 @@ func (cli *VanClient) SkupperDump(ctx context.Context, tarName string, version s
 		cversions = append(cversions, fmt.Sprintf("%-30s %s", "client version", version))
 		cversions = append(cversions, fmt.Sprintf("%-30s %s", "transport version", vir.TransportVersion))
 		cversions = append(cversions, fmt.Sprintf("%-30s %s\n", "controller version", vir.ControllerVersion))
-		writeTar("skupper-versions.txt", []byte(strings.Join(cversions, "\n")), time.Now(), tw)
+		bundle.add("skupper-versions.txt", []byte(strings.Join(cversions, "\n")))
+	} else {
+		bundle.failed("skupper-versions.txt", err)
+	}
+
+	if err := cli.writeSiteConfig(ctx, bundle); err != nil {
+		bundle.failed("site-config.json", err)
+	}
+	if err := cli.writeLinkStatus(ctx, bundle); err != nil {
+		bundle.failed("link-status.txt", err)
 	}
 
 	for i := range deployments {
-		err := cli.writeDeployment(deployments[i], tw)
+		err := cli.writeDeployment(deployments[i], bundle)
 		if err != nil {
 			return err
 		}
@@ -133,34 +313,48 @@ func (cli *VanClient) SkupperDump(ctx context.Context, tarName string, version s
 				if pod.Spec.Containers[container].Name == "router" {
 					// while we are here collect qdstats, logs will show these operations
 					for x := range qdstatFlags {
+						name := pod.Name + "-qdstat" + qdstatFlags[x] + ".txt"
 						qdr, err := kube.ExecCommandInContainer([]string{"qdstat", qdstatFlags[x]}, pod.Name, "router", cli.Namespace, cli.KubeClient, cli.RestConfig)
 						if err == nil {
-							writeTar(pod.Name+"-qdstat"+qdstatFlags[x]+".txt", qdr.Bytes(), time.Now(), tw)
+							bundle.add(name, qdr.Bytes())
 						} else {
-							continue
-
+							bundle.failed(name, err)
 						}
 					}
+					cli.writeRouterQueries(pod.Name, bundle)
 				} else if pod.Spec.Containers[container].Name == "service-controller" {
 					events, err := kube.ExecCommandInContainer([]string{"get", "events"}, pod.Name, "service-controller", cli.Namespace, cli.KubeClient, cli.RestConfig)
 					if err == nil {
-						writeTar(pod.Name+"-events.txt", events.Bytes(), time.Now(), tw)
+						bundle.add(pod.Name+"-events.txt", events.Bytes())
+					} else {
+						bundle.failed(pod.Name+"-events.txt", err)
+					}
+					// profiles are only available if profiling is enabled for the site
+					for _, profile := range []string{"heap", "goroutine"} {
+						if err := cli.writeProfile(pod.Name, profile, 0, bundle); err != nil {
+							bundle.failed(pod.Name+"-"+profile+".pprof", err)
+						}
 					}
 				}
 
-				log, err := kube.GetPodContainerLogs(pod.Name, pod.Spec.Containers[container].Name, cli.Namespace, cli.KubeClient)
-				if err == nil {
-					writeTar(pod.Name+"-"+pod.Spec.Containers[container].Name+"-logs.txt", []byte(log), time.Now(), tw)
+				err = cli.writeLogs(pod.Name, pod.Spec.Containers[container].Name, options, staging, bundle)
+				if err != nil {
+					fmt.Printf("Could not collect logs for %s in pod %s: %s", pod.Spec.Containers[container].Name, pod.Name, err)
+					fmt.Println()
+					bundle.failed(pod.Name+"-"+pod.Spec.Containers[container].Name+"-logs.txt", err)
 				}
 			}
 		}
 	}
 
 	for i := range configMaps {
-		err := cli.writeConfigMap(configMaps[i], tw)
+		err := cli.writeConfigMap(configMaps[i], bundle)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	if err := bundle.close(); err != nil {
+		return err
+	}
+	return os.RemoveAll(staging)
 }
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCollectLog(t *testing.T) {
+	staging, err := ioutil.TempDir("", "dump.tar.gz.logs")
+	assert.NilError(t, err)
+	defer os.RemoveAll(staging)
+
+	// an interrupted fetch leaves nothing behind to be reused
+	_, err = collectLog(staging, "router-logs.txt", func(w io.Writer) error {
+		fmt.Fprint(w, "partial")
+		return fmt.Errorf("connection reset")
+	})
+	assert.Error(t, err, "connection reset")
+	_, err = os.Stat(filepath.Join(staging, "router-logs.txt"))
+	assert.Assert(t, os.IsNotExist(err))
+
+	fetched := 0
+	fetch := func(w io.Writer) error {
+		fetched++
+		_, err := fmt.Fprint(w, "complete log")
+		return err
+	}
+	path, err := collectLog(staging, "router-logs.txt", fetch)
+	assert.NilError(t, err)
+	// a dump that is run again reuses what was already collected
+	_, err = collectLog(staging, "router-logs.txt", fetch)
+	assert.NilError(t, err)
+	assert.Equal(t, fetched, 1)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	assert.NilError(t, writeTarFile("router-logs.txt", path, tw))
+	assert.NilError(t, tw.Close())
+
+	tr := tar.NewReader(&archive)
+	hdr, err := tr.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, hdr.Name, "router-logs.txt")
+	content, err := ioutil.ReadAll(tr)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "complete log")
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// emit passes an event to the handler of the client, if it has one
+func (cli *VanClient) emit(event types.ClientEvent) {
+	if cli.Events != nil {
+		cli.Events(event)
+	}
+}
+
+const (
+	progressLoadBalancer string = "loadbalancer"
+	progressUpdate       string = "update"
+	progressCanary       string = "canary"
+)
+
+// progress reports the step reached by a long running operation, and
+// the time since it started if that is known
+func (cli *VanClient) progress(operation string, step int, steps int, message string, started time.Time) {
+	event := types.ClientEvent{
+		Type:      types.ClientEventProgress,
+		Operation: operation,
+		Step:      step,
+		Steps:     steps,
+		Message:   message,
+	}
+	if !started.IsZero() {
+		event.Elapsed = time.Since(started)
+	}
+	cli.emit(event)
+}
+
+// warn reports a problem that did not stop the operation
+func (cli *VanClient) warn(operation string, message string) {
+	cli.emit(types.ClientEvent{
+		Type:      types.ClientEventWarning,
+		Operation: operation,
+		Message:   message,
+	})
+}
+
+type tracingRoundTripper struct {
+	delegate http.RoundTripper
+	events   func(types.ClientEvent)
+}
+
+// Every request made of the cluster is reported once the response, or
+// the error, is received
+func (rt *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	resp, err := rt.delegate.RoundTrip(req)
+	event := types.ClientEvent{
+		Type:     types.ClientEventRequest,
+		Verb:     requestVerb(req),
+		Resource: requestResource(req.URL.Path),
+		Err:      err,
+		Elapsed:  time.Since(started),
+	}
+	if resp != nil {
+		event.Status = resp.StatusCode
+	}
+	rt.events(event)
+	return resp, err
+}
+
+func tracingTransport(events func(types.ClientEvent)) func(http.RoundTripper) http.RoundTripper {
+	return func(delegate http.RoundTripper) http.RoundTripper {
+		return &tracingRoundTripper{delegate: delegate, events: events}
+	}
+}
+
+func requestVerb(req *http.Request) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			return "watch"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	default:
+		return strings.ToLower(req.Method)
+	}
+}
+
+// requestResource returns the part of the path of a request to the API
+// server that identifies the resource, i.e. what follows the namespace
+// for namespaced resources and the group and version for others
+func requestResource(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if part == "namespaces" && i+2 < len(parts) {
+			return strings.Join(parts[i+2:], "/")
+		}
+	}
+	if len(parts) > 2 && parts[0] == "api" {
+		return strings.Join(parts[2:], "/")
+	} else if len(parts) > 3 && parts[0] == "apis" {
+		return strings.Join(parts[3:], "/")
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRequestResource(t *testing.T) {
+	testTable := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/namespaces/skupper/configmaps/skupper-site", "configmaps/skupper-site"},
+		{"/apis/apps/v1/namespaces/skupper/deployments", "deployments"},
+		{"/api/v1/namespaces/skupper/pods/router-1/exec", "pods/router-1/exec"},
+		{"/api/v1/namespaces/skupper", "namespaces/skupper"},
+		{"/apis/rbac.authorization.k8s.io/v1/clusterroles/skupper", "clusterroles/skupper"},
+		{"/version", "version"},
+	}
+	for _, test := range testTable {
+		assert.Equal(t, requestResource(test.path), test.expected, test.path)
+	}
+}
+
+func TestTracingTransport(t *testing.T) {
+	events := []types.ClientEvent{}
+	failure := errors.New("refused")
+	transport := tracingTransport(func(event types.ClientEvent) {
+		events = append(events, event)
+	})(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodDelete {
+			return nil, failure
+		}
+		return &http.Response{StatusCode: http.StatusNotFound}, nil
+	}))
+
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/skupper/secrets?watch=true", nil))
+	assert.NilError(t, err)
+	_, err = transport.RoundTrip(httptest.NewRequest(http.MethodDelete, "/api/v1/namespaces/skupper/secrets/skupper-site-ca", nil))
+	assert.Equal(t, err, failure)
+
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Type, types.ClientEventRequest)
+	assert.Equal(t, events[0].Verb, "watch")
+	assert.Equal(t, events[0].Resource, "secrets")
+	assert.Equal(t, events[0].Status, http.StatusNotFound)
+	assert.Equal(t, events[1].Verb, "delete")
+	assert.Equal(t, events[1].Resource, "secrets/skupper-site-ca")
+	assert.Equal(t, events[1].Err, failure)
+}
+
+func TestUpdateProgress(t *testing.T) {
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	events := []types.ClientEvent{}
+	cli.Events = func(event types.ClientEvent) {
+		events = append(events, event)
+	}
+	router := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: types.TransportDeploymentName}}
+	controller := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: types.ControllerDeploymentName}}
+	assert.NilError(t, cli.updateStarted("0.7.0", "skupper", nil, router, controller))
+	assert.NilError(t, cli.updateCheckpoint("skupper", updateCheckpointRouterUpdated))
+
+	assert.Equal(t, len(events), 2)
+	assert.DeepEqual(t, events[0], types.ClientEvent{
+		Type:      types.ClientEventProgress,
+		Operation: progressUpdate,
+		Step:      1,
+		Steps:     len(updateCheckpoints),
+		Message:   updateCheckpointStarted,
+	})
+	assert.Equal(t, events[1].Step, 4)
+	assert.Equal(t, events[1].Message, updateCheckpointRouterUpdated)
+}
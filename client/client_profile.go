@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func (cli *VanClient) writeProfile(podName string, name string, seconds int, bundle *debugBundle) error {
+	command := []string{"get", "profile", name}
+	if seconds > 0 {
+		command = append(command, "--seconds", strconv.Itoa(seconds))
+	}
+	profile, err := kube.ExecCommandInContainer(command, podName, types.ControllerContainerName, cli.Namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return fmt.Errorf("Could not retrieve %s profile from %s (is controller profiling enabled?): %w", name, podName, err)
+	}
+	return bundle.add(podName+"-"+name+".pprof", profile.Bytes())
+}
+
+// SkupperProfile captures cpu profiles over the given duration and heap
+// and goroutine profiles from the service controller, saving them in
+// a compressed tar file
+func (cli *VanClient) SkupperProfile(ctx context.Context, tarName string, duration time.Duration) error {
+	pods, err := kube.GetDeploymentPods(types.ControllerDeploymentName, "skupper.io/component="+types.ControllerComponentName, cli.Namespace, cli.KubeClient)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("No service controller pods found in %s", cli.Namespace)
+	}
+
+	tarFile, err := os.Create(tarName)
+	if err != nil {
+		return err
+	}
+	defer tarFile.Close()
+	bundle := newDebugBundle(tarFile)
+
+	for _, pod := range pods {
+		if err := cli.writeProfile(pod.Name, "profile", int(duration.Seconds()), bundle); err != nil {
+			return err
+		}
+		for _, name := range []string{"heap", "goroutine"} {
+			if err := cli.writeProfile(pod.Name, name, 0, bundle); err != nil {
+				return err
+			}
+		}
+	}
+	return bundle.close()
+}
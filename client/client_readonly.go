@@ -0,0 +1,49 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var ErrReadOnly = errors.New("Operation not permitted: client is read-only")
+
+type readOnlyRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+// Requests that could modify resources are rejected before they
+// reach the API server. Exec into pods is only allowed for the commands
+// that query the state of the router (see isReadOnlyExec).
+func (rt *readOnlyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return rt.delegate.RoundTrip(req)
+	case http.MethodPost:
+		if strings.HasSuffix(req.URL.Path, "/exec") && isReadOnlyExec(req.URL.Query()["command"]) {
+			return rt.delegate.RoundTrip(req)
+		}
+	}
+	return nil, ErrReadOnly
+}
+
+// isReadOnlyExec returns true if the command only reads the state of the
+// router: qdstat, or a qdmanage query. Any other command, including the
+// get tool of the service controller, which can also request actions
+// such as a service sync, is refused in read-only mode.
+func isReadOnlyExec(command []string) bool {
+	if len(command) == 0 {
+		return false
+	}
+	switch command[0] {
+	case "qdstat":
+		return true
+	case "qdmanage":
+		return len(command) > 1 && command[1] == "query"
+	}
+	return false
+}
+
+func readOnlyTransport(delegate http.RoundTripper) http.RoundTripper {
+	return &readOnlyRoundTripper{delegate: delegate}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestReadOnlyClient(t *testing.T) {
+	ctx := context.Background()
+	cli := &VanClient{
+		Namespace:  "skupper",
+		KubeClient: fake.NewSimpleClientset(),
+		ReadOnly:   true,
+	}
+
+	err := cli.RouterCreate(ctx, types.SiteConfig{})
+	assert.Equal(t, err, ErrReadOnly)
+	_, err = cli.SiteConfigCreate(ctx, types.SiteConfigSpec{})
+	assert.Equal(t, err, ErrReadOnly)
+	_, _, err = cli.ConnectorTokenCreate(ctx, "token", "")
+	assert.Equal(t, err, ErrReadOnly)
+	err = cli.ServiceInterfaceCreate(ctx, &types.ServiceInterface{Address: "tcp-go-echo", Protocol: "tcp", Ports: []types.ServicePort{{Port: 9090}}})
+	assert.Equal(t, err, ErrReadOnly)
+	_, err = cli.RouterUpdateVersion(ctx, false)
+	assert.Equal(t, err, ErrReadOnly)
+	err = cli.ServiceSyncNow(ctx)
+	assert.Equal(t, err, ErrReadOnly)
+
+	_, err = cli.SiteConfigInspect(ctx, nil)
+	assert.Assert(t, err)
+	services, err := cli.ServiceInterfaceList(ctx)
+	assert.Assert(t, err != ErrReadOnly)
+	assert.Equal(t, len(services), 0)
+}
+
+func TestReadOnlyTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	transport := readOnlyTransport(http.DefaultTransport)
+
+	testcases := []struct {
+		method  string
+		path    string
+		allowed bool
+	}{
+		{http.MethodGet, "/api/v1/namespaces/skupper/configmaps/skupper-site", true},
+		{http.MethodPost, "/api/v1/namespaces/skupper/pods/skupper-router-1/exec?command=qdstat&command=-c&container=router", true},
+		{http.MethodPost, "/api/v1/namespaces/skupper/pods/skupper-router-1/exec?command=qdmanage&command=query&command=--type&command=connection&container=router", true},
+		{http.MethodPost, "/api/v1/namespaces/skupper/pods/skupper-router-1/exec?command=qdmanage&command=update&command=--type&command=connection&container=router", false},
+		{http.MethodPost, "/api/v1/namespaces/skupper/pods/skupper-service-controller-1/exec?command=get&command=servicesync&container=service-controller", false},
+		{http.MethodPost, "/api/v1/namespaces/skupper/pods/skupper-router-1/exec?command=sh&container=router", false},
+		{http.MethodPost, "/api/v1/namespaces/skupper/pods/skupper-router-1/exec", false},
+		{http.MethodPost, "/api/v1/namespaces/skupper/configmaps", false},
+		{http.MethodPut, "/api/v1/namespaces/skupper/configmaps/skupper-site", false},
+		{http.MethodPatch, "/apis/apps/v1/namespaces/skupper/deployments/skupper-router", false},
+		{http.MethodDelete, "/api/v1/namespaces/skupper/secrets/skupper-site-ca", false},
+	}
+	for _, c := range testcases {
+		req, err := http.NewRequest(c.method, server.URL+c.path, nil)
+		assert.Assert(t, err)
+		resp, err := transport.RoundTrip(req)
+		if c.allowed {
+			assert.Assert(t, err)
+			resp.Body.Close()
+		} else {
+			assert.Equal(t, err, ErrReadOnly)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"flag"
+	"io/ioutil"
 	"os"
 	"sort"
 	"testing"
@@ -40,6 +41,45 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestNewClientWithOptions(t *testing.T) {
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	assert.Assert(t, err)
+	defer os.Remove(kubeconfig.Name())
+	_, err = kubeconfig.WriteString(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: http://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: from-context
+current-context: test
+`)
+	assert.Assert(t, err)
+	kubeconfig.Close()
+
+	cli, err := NewClientWithOptions(ClientOptions{
+		KubeConfigPath:    kubeconfig.Name(),
+		UserAgent:         "my-controller/1.0",
+		ImpersonateUser:   "tenant-a",
+		ImpersonateGroups: []string{"tenants"},
+	})
+	assert.Assert(t, err)
+	assert.Equal(t, cli.Namespace, "from-context")
+	assert.Equal(t, cli.RestConfig.UserAgent, "my-controller/1.0")
+	assert.Equal(t, cli.RestConfig.Impersonate.UserName, "tenant-a")
+	assert.DeepEqual(t, cli.RestConfig.Impersonate.Groups, []string{"tenants"})
+
+	cli, err = NewClient("skupper", "", kubeconfig.Name())
+	assert.Assert(t, err)
+	assert.Equal(t, cli.Namespace, "skupper")
+	assert.Equal(t, cli.RestConfig.UserAgent, "skupper/"+Version)
+	assert.Equal(t, cli.RestConfig.Impersonate.UserName, "")
+}
+
 var clusterRun = flag.Bool("use-cluster", false, "run tests against a configured cluster")
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"os"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+var resolvConfPath = "/etc/resolv.conf"
+
+// clusterDomain returns the DNS domain of the cluster for use in the
+// fully qualified names of the site's services: the one configured for
+// the site if any, else the one detected when running in a pod of the
+// cluster, else the kubernetes default
+func (cli *VanClient) clusterDomain(spec *types.SiteConfigSpec) string {
+	if spec != nil && spec.ClusterDomain != "" {
+		return spec.ClusterDomain
+	}
+	if domain := inClusterDomain(); domain != "" {
+		return domain
+	}
+	return types.DefaultClusterDomain
+}
+
+// clusterDomainInNamespace returns the DNS domain of the cluster for the
+// site in the namespace, honouring its site config
+func (cli *VanClient) clusterDomainInNamespace(ctx context.Context, namespace string) (string, error) {
+	siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	if err != nil {
+		return "", err
+	}
+	if siteConfig != nil {
+		return cli.clusterDomain(&siteConfig.Spec), nil
+	}
+	return cli.clusterDomain(nil), nil
+}
+
+// inClusterDomain reads the domain from the resolv.conf kubelet wrote,
+// when running in a pod
+func inClusterDomain() string {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return ""
+	}
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	return kube.ClusterDomainFromResolvConf(file)
+}
+
+// ClusterDomainRecord records the DNS domain of the cluster, as detected
+// from within a pod of it, in the site config of the namespace if that
+// has none, so that clients outside the cluster, which cannot detect it,
+// use it too. It returns the domain recorded, if any.
+func (cli *VanClient) ClusterDomainRecord(ctx context.Context, namespace string) (string, error) {
+	if cli.ReadOnly {
+		return "", ErrReadOnly
+	}
+	domain := inClusterDomain()
+	if domain == "" {
+		return "", nil
+	}
+	if namespace == "" {
+		namespace = cli.Namespace
+	}
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get("skupper-site", metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if _, ok := configmap.Data["cluster-domain"]; ok {
+		return "", nil
+	}
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data["cluster-domain"] = domain
+	if _, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Update(configmap); err != nil {
+		return "", err
+	}
+	return domain, nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func credentialHosts(van *types.RouterSpec, name string) []string {
+	for _, credential := range van.Credentials {
+		if credential.Name == name {
+			return credential.Hosts
+		}
+	}
+	return nil
+}
+
+func TestClusterDomain(t *testing.T) {
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	dir, err := ioutil.TempDir("", "cluster-domain")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "resolv.conf")
+	assert.Assert(t, ioutil.WriteFile(path, []byte("search skupper.svc.corp.example svc.corp.example corp.example\n"), 0644))
+	defer func(original string) {
+		resolvConfPath = original
+	}(resolvConfPath)
+	resolvConfPath = path
+
+	defer os.Setenv("KUBERNETES_SERVICE_HOST", os.Getenv("KUBERNETES_SERVICE_HOST"))
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	assert.Equal(t, cli.clusterDomain(nil), types.DefaultClusterDomain)
+
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	assert.Equal(t, cli.clusterDomain(nil), "corp.example")
+	assert.Equal(t, cli.clusterDomain(&types.SiteConfigSpec{ClusterDomain: "override.example"}), "override.example")
+
+	_, err = cli.SiteConfigCreate(context.Background(), types.SiteConfigSpec{SkupperName: "skupper", ClusterDomain: "site.example"})
+	assert.Assert(t, err)
+	domain, err := cli.clusterDomainInNamespace(context.Background(), "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, domain, "site.example")
+}
+
+func TestClusterDomainRecord(t *testing.T) {
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	dir, err := ioutil.TempDir("", "cluster-domain")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "resolv.conf")
+	assert.Assert(t, ioutil.WriteFile(path, []byte("search skupper.svc.corp.example svc.corp.example corp.example\n"), 0644))
+	defer func(original string) {
+		resolvConfPath = original
+	}(resolvConfPath)
+	resolvConfPath = path
+	defer os.Setenv("KUBERNETES_SERVICE_HOST", os.Getenv("KUBERNETES_SERVICE_HOST"))
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+
+	// nothing is recorded without a site config
+	domain, err := cli.ClusterDomainRecord(context.Background(), "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, domain, "")
+
+	_, err = cli.SiteConfigCreate(context.Background(), types.SiteConfigSpec{SkupperName: "skupper"})
+	assert.Assert(t, err)
+	domain, err = cli.ClusterDomainRecord(context.Background(), "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, domain, "corp.example")
+
+	// clients outside the cluster use the recorded domain
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	domain, err = cli.clusterDomainInNamespace(context.Background(), "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, domain, "corp.example")
+
+	// a domain already in the site config is kept
+	os.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	assert.Assert(t, ioutil.WriteFile(path, []byte("search skupper.svc.other.example svc.other.example other.example\n"), 0644))
+	domain, err = cli.ClusterDomainRecord(context.Background(), "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, domain, "")
+	domain, err = cli.clusterDomainInNamespace(context.Background(), "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, domain, "corp.example")
+}
+
+func TestClusterDomainCertificateHosts(t *testing.T) {
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	spec := types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		Ingress:          types.IngressNoneString,
+		EnableController: true,
+		EnableMetricsApi: true,
+	}
+	van := cli.GetRouterSpecFromOpts(spec, "site-id")
+	assert.DeepEqual(t, credentialHosts(van, types.LocalServerSecret), []string{types.LocalTransportServiceName, types.LocalTransportServiceName + ".skupper.svc.cluster.local"})
+
+	spec.ClusterDomain = "corp.example"
+	van = cli.GetRouterSpecFromOpts(spec, "site-id")
+	assert.DeepEqual(t, credentialHosts(van, types.LocalServerSecret), []string{types.LocalTransportServiceName, types.LocalTransportServiceName + ".skupper.svc.corp.example"})
+	assert.DeepEqual(t, credentialHosts(van, types.MetricsApiSecret), []string{types.MetricsApiServiceName + ".skupper.svc", types.MetricsApiServiceName + ".skupper.svc.corp.example"})
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// The roles letting the controller of a cluster scoped site resolve
+// targets in other namespaces are outside the namespace of the site, so
+// are named for it and removed explicitly rather than through owner
+// references. Only the namespaces themselves can be read cluster wide;
+// the targets are read through a role in each namespace they are in.
+func clusterScopedRbacName(namespace string) string {
+	return types.ControllerClusterRoleName + "-" + namespace
+}
+
+func (cli *VanClient) createClusterScopedRbac(namespace string) error {
+	name := clusterScopedRbacName(namespace)
+	labels := map[string]string{
+		"skupper.io/component": types.ControllerComponentName,
+	}
+	_, err := cli.KubeClient.RbacV1().ClusterRoles().Create(&rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Rules: types.ControllerClusterPolicyRule,
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Could not create cluster role for controller: %w", err)
+	}
+	_, err = cli.KubeClient.RbacV1().ClusterRoleBindings().Create(&rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      types.ControllerServiceAccountName,
+			Namespace: namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			Kind: "ClusterRole",
+			Name: name,
+		},
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Could not create cluster role binding for controller: %w", err)
+	}
+	return nil
+}
+
+// createTargetNamespaceRbac lets the controller of the site read the
+// targets in a namespace that has opted in to having them exposed by it
+func (cli *VanClient) createTargetNamespaceRbac(namespace string, target string) error {
+	name := clusterScopedRbacName(namespace)
+	labels := map[string]string{
+		"skupper.io/component": types.ControllerComponentName,
+	}
+	_, err := cli.KubeClient.RbacV1().Roles(target).Create(&rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Rules: types.ControllerTargetPolicyRule,
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Could not create role for controller in %s: %w", target, err)
+	}
+	_, err = cli.KubeClient.RbacV1().RoleBindings(target).Create(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      types.ControllerServiceAccountName,
+			Namespace: namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			Kind: "Role",
+			Name: name,
+		},
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Could not create role binding for controller in %s: %w", target, err)
+	}
+	return nil
+}
+
+func (cli *VanClient) removeClusterScopedRbac(namespace string) error {
+	name := clusterScopedRbacName(namespace)
+	targets, err := cli.KubeClient.CoreV1().Namespaces().List(metav1.ListOptions{
+		LabelSelector: types.TargetNamespaceLabel + "=" + namespace,
+	})
+	if err != nil {
+		return err
+	}
+	for _, target := range targets.Items {
+		err = cli.KubeClient.RbacV1().RoleBindings(target.Name).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		err = cli.KubeClient.RbacV1().Roles(target.Name).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	err = cli.KubeClient.RbacV1().ClusterRoleBindings().Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	err = cli.KubeClient.RbacV1().ClusterRoles().Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// checkTargetNamespace verifies that targets in the namespace can be
+// exposed by the site, and lets its controller read them
+func (cli *VanClient) checkTargetNamespace(ctx context.Context, namespace string) error {
+	if namespace == "" || namespace == cli.Namespace {
+		return nil
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if siteConfig == nil || !siteConfig.Spec.ClusterScoped {
+		return fmt.Errorf("Targets in other namespaces can only be exposed by a cluster scoped site")
+	}
+	if err := kube.CheckTargetNamespace(namespace, cli.Namespace, cli.KubeClient); err != nil {
+		return err
+	}
+	return cli.createTargetNamespaceRbac(cli.Namespace, namespace)
+}
@@ -6,7 +6,6 @@ import (
 	"io/ioutil"
 	"log"
 	"reflect"
-	"regexp"
 	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
@@ -20,28 +19,20 @@ import (
 	"github.com/skupperproject/skupper/api/types"
 	certs "github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
 func generateConnectorName(namespace string, cli kubernetes.Interface) string {
 	secrets, err := cli.CoreV1().Secrets(namespace).List(metav1.ListOptions{LabelSelector: "skupper.io/type=connection-token"})
-	max := 1
-	if err == nil {
-		connector_name_pattern := regexp.MustCompile("conn([0-9])+")
-		for _, s := range secrets.Items {
-			count := connector_name_pattern.FindStringSubmatch(s.ObjectMeta.Name)
-			if len(count) > 1 {
-				v, _ := strconv.Atoi(count[1])
-				if v >= max {
-					max = v + 1
-				}
-			}
-
-		}
-	} else {
+	if err != nil {
 		log.Fatal("Could not retrieve connection-token secrets:", err)
 	}
-	return "conn" + strconv.Itoa(max)
+	names := []string{}
+	for _, s := range secrets.Items {
+		names = append(names, s.ObjectMeta.Name)
+	}
+	return naming.NextConnectorName(names)
 }
 
 func secretFileAuthor(ctx context.Context, secretFile string) (author string, err error) {
@@ -72,6 +63,21 @@ func (cli *VanClient) isOwnToken(ctx context.Context, secretFile string) (bool,
 }
 
 func (cli *VanClient) ConnectorCreateFromFile(ctx context.Context, secretFile string, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if cli.Driver != nil {
+		token, err := readConnectionToken(secretFile)
+		if err != nil {
+			return nil, err
+		}
+		if IsClaim(token) {
+			if token, err = cli.redeemClaimInNamespace(ctx, token, "", options.Proxy); err != nil {
+				return nil, err
+			}
+		}
+		return cli.Driver.LinkCreate(ctx, token, options)
+	}
 	// Before doing any checks, make sure that Skupper is running.
 	if _, err := kube.GetDeployment(types.TransportDeploymentName, options.SkupperNamespace, cli.KubeClient); err != nil {
 		return nil, err
@@ -126,6 +132,9 @@ func (cli *VanClient) ConnectorCreateFromFile(ctx context.Context, secretFile st
 }
 
 func (cli *VanClient) ConnectorCreateSecretFromFile(ctx context.Context, secretFile string, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
 	yaml, err := ioutil.ReadFile(secretFile)
 	if err != nil {
 		fmt.Println("Could not read connection token", err.Error())
@@ -142,11 +151,60 @@ func (cli *VanClient) ConnectorCreateSecretFromFile(ctx context.Context, secretF
 		} else {
 			if options.Name == "" {
 				options.Name = generateConnectorName(options.SkupperNamespace, cli.KubeClient)
+			} else if err := naming.ValidateLinkName(options.Name); err != nil {
+				return nil, err
+			}
+			existing, err := cli.KubeClient.CoreV1().Secrets(options.SkupperNamespace).Get(options.Name, metav1.GetOptions{})
+			if err == nil {
+				if err := naming.CheckCollision("secret", existing, types.SkupperTypeQualifier, types.TypeToken); err != nil {
+					return nil, err
+				}
+			}
+			if err := cli.checkLinkDirection(ctx, options.SkupperNamespace, false); err != nil {
+				return nil, fmt.Errorf("Cannot create link %s: %w", options.Name, err)
+			}
+			if IsClaim(&secret) {
+				token, err := cli.redeemClaimInNamespace(ctx, &secret, options.SkupperNamespace, options.Proxy)
+				if err != nil {
+					return nil, err
+				}
+				secret = *token
+			}
+			if err := cli.verifyTokenSite(ctx, &secret, options.Name, options.SkupperNamespace); err != nil {
+				return nil, err
+			}
+			if err := cli.verifyTokenClaim(&secret, options.Name, options.SkupperNamespace); err != nil {
+				return nil, err
 			}
 			secret.ObjectMeta.Name = options.Name
 			secret.ObjectMeta.Labels = map[string]string{
 				"skupper.io/type": "connection-token",
 			}
+			if options.Cost < 0 {
+				return nil, fmt.Errorf("Invalid value for cost: %d, must not be negative", options.Cost)
+			}
+			if options.Cost > 0 {
+				// recorded so that the cost is kept if the link is recreated
+				if secret.ObjectMeta.Annotations == nil {
+					secret.ObjectMeta.Annotations = map[string]string{}
+				}
+				secret.ObjectMeta.Annotations[types.TokenCost] = strconv.Itoa(int(options.Cost))
+			}
+			if !options.Proxy.IsEmpty() {
+				if err := types.CheckLinkProxy(options.Proxy); err != nil {
+					return nil, err
+				}
+				setLinkProxy(&secret, options.Proxy)
+			}
+			if len(options.HostAliases) > 0 {
+				if err := types.CheckHostAliases(options.HostAliases); err != nil {
+					return nil, err
+				}
+				if secret.ObjectMeta.Annotations == nil {
+					secret.ObjectMeta.Annotations = map[string]string{}
+				}
+				secret.ObjectMeta.Annotations[types.HostAliasesAnnotation] = kube.FormatHostAliases(options.HostAliases)
+			}
 			secret.ObjectMeta.SetOwnerReferences([]metav1.OwnerReference{
 				kube.GetDeploymentOwnerReference(current),
 			})
@@ -164,9 +222,104 @@ func (cli *VanClient) ConnectorCreateSecretFromFile(ctx context.Context, secretF
 	}
 }
 
+// getLinkHostAliases returns the host aliases of the site together with
+// any given for the link
+func getLinkHostAliases(siteConfig *types.SiteConfig, secret *corev1.Secret, options types.ConnectorCreateOptions) (map[string]string, error) {
+	aliases := map[string]string{}
+	if siteConfig != nil {
+		for host, ip := range siteConfig.Spec.HostAliases {
+			aliases[host] = ip
+		}
+	}
+	if value, ok := secret.ObjectMeta.Annotations[types.HostAliasesAnnotation]; ok {
+		linkAliases, err := kube.ParseHostAliases(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid host aliases on %s: %s", secret.ObjectMeta.Name, err)
+		}
+		for host, ip := range linkAliases {
+			aliases[host] = ip
+		}
+	}
+	for host, ip := range options.HostAliases {
+		aliases[host] = ip
+	}
+	return aliases, nil
+}
+
+// LinkCost returns the cost recorded on the secret for a link, as given
+// when its token or the link was created, or zero if there is none
+func LinkCost(secret *corev1.Secret) (int32, error) {
+	value, ok := secret.ObjectMeta.Annotations[types.TokenCost]
+	if !ok {
+		return 0, nil
+	}
+	cost, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || cost < 0 {
+		return 0, fmt.Errorf("Invalid cost on %s: %q", secret.ObjectMeta.Name, value)
+	}
+	return int32(cost), nil
+}
+
+// getLinkCost returns the cost given for the link or, failing that, the
+// one recorded on its secret. Unless set, the router uses a cost of 1.
+func getLinkCost(secret *corev1.Secret, options types.ConnectorCreateOptions) (int32, error) {
+	if options.Cost < 0 {
+		return 0, fmt.Errorf("Invalid value for cost: %d, must not be negative", options.Cost)
+	}
+	if options.Cost > 0 {
+		return options.Cost, nil
+	}
+	cost, err := LinkCost(secret)
+	if err != nil {
+		return 0, err
+	}
+	if cost == 0 {
+		return 1, nil
+	}
+	return cost, nil
+}
+
+// linkProxy returns the proxy the link is made through, if any. A proxy
+// given for the link is recorded on its secret, for the service controller
+// that forwards the link to it.
+func (cli *VanClient) linkProxy(secret *corev1.Secret, options types.ConnectorCreateOptions) (types.LinkProxy, error) {
+	if options.Proxy.IsEmpty() {
+		return GetLinkProxy(secret)
+	}
+	if err := types.CheckLinkProxy(options.Proxy); err != nil {
+		return options.Proxy, err
+	}
+	if setLinkProxy(secret, options.Proxy) {
+		if _, err := cli.KubeClient.CoreV1().Secrets(options.SkupperNamespace).Update(secret); err != nil {
+			return options.Proxy, fmt.Errorf("Could not record proxy for link %s: %w", options.Name, err)
+		}
+	}
+	return options.Proxy, nil
+}
+
 func (cli *VanClient) ConnectorCreate(ctx context.Context, secret *corev1.Secret, options types.ConnectorCreateOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkLinkDirection(ctx, options.SkupperNamespace, false); err != nil {
+		return fmt.Errorf("Cannot create link %s: %w", options.Name, err)
+	}
+	if err := cli.verifyTokenSite(ctx, secret, options.Name, options.SkupperNamespace); err != nil {
+		return err
+	}
+	cost, err := getLinkCost(secret, options)
+	if err != nil {
+		return err
+	}
+	proxy, err := cli.linkProxy(secret, options)
+	if err != nil {
+		return err
+	}
+	if err := cli.claimToken(secret, options.Name, options.SkupperNamespace); err != nil {
+		return err
+	}
 
-	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, options.SkupperNamespace)
 		if err != nil {
 			return err
@@ -181,7 +334,7 @@ func (cli *VanClient) ConnectorCreate(ctx context.Context, secret *corev1.Secret
 		}
 		updated := false
 		//read annotations to get the host and port to connect to
-		profileName := options.Name + "-profile"
+		profileName := naming.SslProfileName(options.Name)
 		if _, ok := current.SslProfiles[profileName]; !ok {
 			current.AddSslProfile(qdr.SslProfile{
 				Name: profileName,
@@ -190,7 +343,7 @@ func (cli *VanClient) ConnectorCreate(ctx context.Context, secret *corev1.Secret
 		}
 		connector := qdr.Connector{
 			Name:       options.Name,
-			Cost:       options.Cost,
+			Cost:       cost,
 			SslProfile: profileName,
 		}
 		connector.SetMaxFrameSize(siteConfig.Spec.RouterMaxFrameSize)
@@ -204,6 +357,25 @@ func (cli *VanClient) ConnectorCreate(ctx context.Context, secret *corev1.Secret
 			connector.Port = secret.ObjectMeta.Annotations["inter-router-port"]
 			connector.Role = qdr.RoleInterRouter
 		}
+		aliases, err := getLinkHostAliases(siteConfig, secret, options)
+		if err != nil {
+			return err
+		}
+		if !proxy.IsEmpty() {
+			// the host is resolved by the proxy, so the router is
+			// pointed at the service controller, which forwards
+			// the link to it, instead
+			ip, err := cli.linkProxyService(options.SkupperNamespace, secret, connector.Host, connector.Port)
+			if err != nil {
+				return err
+			}
+			if ip == "" {
+				return fmt.Errorf("The service for the proxy of link %s has no cluster IP", options.Name)
+			}
+			aliases[connector.Host] = ip
+		}
+		// hosts without an alias are resolved by the router, in the
+		// cluster, and not here, where names may resolve differently
 		if existing, ok := current.Connectors[connector.Name]; ok {
 			if !reflect.DeepEqual(existing, connector) {
 				current.Connectors[connector.Name] = connector
@@ -219,14 +391,23 @@ func (cli *VanClient) ConnectorCreate(ctx context.Context, secret *corev1.Secret
 			if err != nil {
 				return err
 			}
+		}
+		deployment, err := kube.GetDeployment(types.TransportDeploymentName, options.SkupperNamespace, cli.KubeClient)
+		if err != nil {
+			return err
+		}
+		if updated {
 			//need to mount the secret so router can access certs and key
-			deployment, err := kube.GetDeployment(types.TransportDeploymentName, options.SkupperNamespace, cli.KubeClient)
 			kube.AppendSecretVolume(&deployment.Spec.Template.Spec.Volumes, &deployment.Spec.Template.Spec.Containers[0].VolumeMounts, connector.Name, "/etc/qpid-dispatch-certs/"+profileName+"/")
+		}
+		if kube.MergeHostAliases(&deployment.Spec.Template.Spec, aliases) {
+			updated = true
+		}
+		if updated {
 			_, err = cli.KubeClient.AppsV1().Deployments(options.SkupperNamespace).Update(deployment)
 			if err != nil {
 				return err
 			}
-			return err
 		}
 		return nil
 	})
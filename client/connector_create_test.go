@@ -10,6 +10,8 @@ import (
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/kube"
 	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var lightRed string = "\033[1;31m"
@@ -186,3 +188,61 @@ func configureSiteAndCreateRouter(t *testing.T, ctx context.Context, cli *VanCli
 	err = cli.RouterCreate(ctx, *siteConfig)
 	assert.Assert(t, err, "Unable to create %s VAN router", name)
 }
+
+func TestLinkHostAliases(t *testing.T) {
+	siteConfig := &types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			HostAliases: map[string]string{"a.example.com": "10.0.0.1", "b.example.com": "10.0.0.2"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "link1",
+			Annotations: map[string]string{
+				types.HostAliasesAnnotation: "b.example.com=10.0.0.3,c.example.com=10.0.0.4",
+			},
+		},
+	}
+	aliases, err := getLinkHostAliases(siteConfig, secret, types.ConnectorCreateOptions{
+		HostAliases: map[string]string{"d.example.com": "10.0.0.5"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, aliases, map[string]string{
+		"a.example.com": "10.0.0.1",
+		"b.example.com": "10.0.0.3",
+		"c.example.com": "10.0.0.4",
+		"d.example.com": "10.0.0.5",
+	})
+
+	secret.ObjectMeta.Annotations[types.HostAliasesAnnotation] = "c.example.com"
+	_, err = getLinkHostAliases(nil, secret, types.ConnectorCreateOptions{})
+	assert.ErrorContains(t, err, "Invalid host aliases on link1")
+}
+
+func TestLinkCost(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "link1",
+			Annotations: map[string]string{},
+		},
+	}
+	cost, err := getLinkCost(secret, types.ConnectorCreateOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, cost, int32(1))
+
+	secret.ObjectMeta.Annotations[types.TokenCost] = "5"
+	cost, err = getLinkCost(secret, types.ConnectorCreateOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, cost, int32(5))
+
+	cost, err = getLinkCost(secret, types.ConnectorCreateOptions{Cost: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, cost, int32(2))
+
+	_, err = getLinkCost(secret, types.ConnectorCreateOptions{Cost: -1})
+	assert.ErrorContains(t, err, "Invalid value for cost")
+
+	secret.ObjectMeta.Annotations[types.TokenCost] = "cheap"
+	_, err = getLinkCost(secret, types.ConnectorCreateOptions{})
+	assert.ErrorContains(t, err, "Invalid cost on link1")
+}
@@ -2,16 +2,64 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/kube"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
+// LinkCertificateExpiry returns when the first of the certificates a link
+// is made with expires: the certificate it presents and that of the
+// authority it verifies the remote site with. It is zero if neither can
+// be read.
+func LinkCertificateExpiry(secret *corev1.Secret) time.Time {
+	var earliest time.Time
+	for _, key := range []string{"tls.crt", "ca.crt"} {
+		expiry, err := certs.GetCertificateExpiry(secret.Data[key])
+		if err == nil && (earliest.IsZero() || expiry.Before(earliest)) {
+			earliest = expiry
+		}
+	}
+	return earliest
+}
+
+// LinkCertificateExpiries returns the expiry of the certificates of each
+// link in the site, keyed by the name of the link
+func (cli *VanClient) LinkCertificateExpiries(ctx context.Context) (map[string]time.Time, error) {
+	secrets, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).List(metav1.ListOptions{LabelSelector: types.TypeTokenQualifier})
+	if err != nil {
+		return nil, err
+	}
+	expiries := map[string]time.Time{}
+	for i := range secrets.Items {
+		if expiry := LinkCertificateExpiry(&secrets.Items[i]); !expiry.IsZero() {
+			expiries[secrets.Items[i].ObjectMeta.Name] = expiry
+		}
+	}
+	return expiries, nil
+}
+
 // ConnectorInspect VAN connector instance
 func (cli *VanClient) ConnectorInspect(ctx context.Context, name string) (*types.ConnectorInspectResponse, error) {
+	if cli.Driver != nil {
+		// the driver does not report whether links are connected
+		links, err := cli.Driver.LinkList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if link.Name == name {
+				return &types.ConnectorInspectResponse{Connector: link}, nil
+			}
+		}
+		return nil, fmt.Errorf("Link %s not found", name)
+	}
 	vci := &types.ConnectorInspectResponse{}
 
 	configmap, err := kube.GetConfigMap(types.TransportConfigMapName, cli.Namespace, cli.KubeClient)
@@ -43,7 +91,9 @@ func (cli *VanClient) ConnectorInspect(ctx context.Context, name string) (*types
 		Host: secret.ObjectMeta.Annotations[hostKey],
 		Port: secret.ObjectMeta.Annotations[portKey],
 		Role: string(role),
+		Cost: connectorCost(current, secret),
 	}
+	vci.CertificateExpiry = LinkCertificateExpiry(secret)
 
 	connections, err := qdr.GetConnections(cli.Namespace, cli.KubeClient, cli.RestConfig)
 	if err == nil {
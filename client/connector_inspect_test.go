@@ -3,9 +3,14 @@ package client
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/qdr"
 	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestConnectorInspectError(t *testing.T) {
@@ -64,3 +69,36 @@ func TestConnectorInspectNotFound(t *testing.T) {
 		assert.Error(t, err, c.expectedError, c.doc)
 	}
 }
+
+func TestLinkCertificateExpiry(t *testing.T) {
+	ca := certs.GenerateCASecret("ca", "ca")
+	secret := certs.GenerateSecret("conn1", "conn1", "", &ca)
+	expiry := LinkCertificateExpiry(&secret)
+	assert.Assert(t, expiry.After(time.Now()))
+	caExpiry, err := certs.GetCertificateExpiry(ca.Data["tls.crt"])
+	assert.NilError(t, err)
+	assert.Assert(t, !expiry.After(caExpiry))
+
+	assert.Assert(t, LinkCertificateExpiry(&corev1.Secret{}).IsZero())
+}
+
+func TestConnectorCost(t *testing.T) {
+	current := &qdr.RouterConfig{
+		Connectors: map[string]qdr.Connector{
+			"link1": {Name: "link1", Cost: 5},
+			"link2": {Name: "link2"},
+		},
+	}
+	secret := func(name string, cost string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{types.TokenCost: cost},
+			},
+		}
+	}
+	assert.Equal(t, connectorCost(current, secret("link1", "3")), int32(5))
+	assert.Equal(t, connectorCost(current, secret("link2", "3")), int32(1))
+	assert.Equal(t, connectorCost(current, secret("link3", "3")), int32(3))
+	assert.Equal(t, connectorCost(current, secret("link4", "")), int32(1))
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
@@ -11,6 +12,9 @@ import (
 )
 
 func (cli *VanClient) ConnectorList(ctx context.Context) ([]*types.Connector, error) {
+	if cli.Driver != nil {
+		return cli.Driver.LinkList(ctx)
+	}
 	var connectors []*types.Connector
 	configmap, err := kube.GetConfigMap(types.TransportConfigMapName, cli.Namespace, cli.KubeClient)
 	if err != nil {
@@ -20,7 +24,14 @@ func (cli *VanClient) ConnectorList(ctx context.Context) ([]*types.Connector, er
 	if err != nil {
 		return connectors, err
 	}
-	secrets, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).List(metav1.ListOptions{LabelSelector: "skupper.io/type=connection-token"})
+	return cli.connectorsForRouter(current, cli.Namespace)
+}
+
+// connectorsForRouter lists the links of the router with the given
+// config in the namespace
+func (cli *VanClient) connectorsForRouter(current *qdr.RouterConfig, namespace string) ([]*types.Connector, error) {
+	var connectors []*types.Connector
+	secrets, err := cli.KubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{LabelSelector: "skupper.io/type=connection-token"})
 	if err != nil {
 		return connectors, err
 	}
@@ -36,13 +47,29 @@ func (cli *VanClient) ConnectorList(ctx context.Context) ([]*types.Connector, er
 		hostKey = "inter-router-host"
 		portKey = "inter-router-port"
 	}
-	for _, s := range secrets.Items {
+	for i, s := range secrets.Items {
 		connectors = append(connectors, &types.Connector{
 			Name: s.ObjectMeta.Name,
 			Host: s.ObjectMeta.Annotations[hostKey],
 			Port: s.ObjectMeta.Annotations[portKey],
 			Role: string(role),
+			Cost: connectorCost(current, &secrets.Items[i]),
 		})
 	}
 	return connectors, nil
 }
+
+// connectorCost returns the cost the router has for the link, or that
+// recorded on its secret if the router has yet to be configured for it
+func connectorCost(current *qdr.RouterConfig, secret *corev1.Secret) int32 {
+	if connector, ok := current.Connectors[secret.ObjectMeta.Name]; ok {
+		if connector.Cost > 0 {
+			return connector.Cost
+		}
+		return 1
+	}
+	if cost, err := LinkCost(secret); err == nil && cost > 0 {
+		return cost
+	}
+	return 1
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 
 	"github.com/skupperproject/skupper/api/types"
@@ -12,6 +13,12 @@ import (
 )
 
 func (cli *VanClient) ConnectorRemove(ctx context.Context, options types.ConnectorRemoveOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.LinkRemove(ctx, options.Name)
+	}
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		deployment, err := kube.GetDeployment(types.TransportDeploymentName, options.SkupperNamespace, cli.KubeClient)
 		if err != nil {
@@ -40,7 +47,9 @@ func (cli *VanClient) ConnectorRemove(ctx context.Context, options types.Connect
 				return err
 			}
 			kube.RemoveSecretVolumeForDeployment(options.Name, deployment, 0)
-			kube.DeleteSecret(options.Name, options.SkupperNamespace, cli.KubeClient)
+			if err := cli.deleteLinkSecret(options.Name, options.SkupperNamespace); err != nil {
+				cli.warn("link", err.Error())
+			}
 			_, err = cli.KubeClient.AppsV1().Deployments(options.SkupperNamespace).Update(deployment)
 			return err
 		}
@@ -51,3 +60,17 @@ func (cli *VanClient) ConnectorRemove(ctx context.Context, options types.Connect
 	}
 	return nil
 }
+
+// deleteLinkSecret deletes the secret holding the credentials for a link,
+// refusing any secret not labelled as a link token so that a link name
+// cannot be used to delete other secrets in the namespace
+func (cli *VanClient) deleteLinkSecret(name string, namespace string) error {
+	secret, err := cli.KubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not delete secret for link %s: %w", name, err)
+	}
+	if secret.ObjectMeta.Labels[types.SkupperTypeQualifier] != types.TypeToken {
+		return fmt.Errorf("Not deleting secret %s as it is not labelled %s=%s", name, types.SkupperTypeQualifier, types.TypeToken)
+	}
+	return kube.DeleteSecret(name, namespace, cli.KubeClient)
+}
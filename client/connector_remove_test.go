@@ -14,7 +14,9 @@ import (
 	"gotest.tools/assert"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/skupperproject/skupper/api/types"
@@ -135,3 +137,31 @@ func TestConnectorRemove(t *testing.T) {
 		assert.Error(t, err, `secrets "`+c.connName+`" not found`, "Expect error when connector is removed")
 	}
 }
+
+func TestDeleteLinkSecret(t *testing.T) {
+	cli := &VanClient{
+		Namespace: "skupper",
+		KubeClient: fake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "link1",
+				Namespace: "skupper",
+				Labels:    map[string]string{types.SkupperTypeQualifier: types.TypeToken},
+			},
+		}, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      types.SiteCaSecret,
+				Namespace: "skupper",
+			},
+		}),
+	}
+
+	assert.Error(t, cli.deleteLinkSecret(types.SiteCaSecret, "skupper"), "Not deleting secret skupper-site-ca as it is not labelled skupper.io/type=connection-token")
+	_, err := cli.KubeClient.CoreV1().Secrets("skupper").Get(types.SiteCaSecret, metav1.GetOptions{})
+	assert.NilError(t, err)
+
+	assert.NilError(t, cli.deleteLinkSecret("link1", "skupper"))
+	_, err = cli.KubeClient.CoreV1().Secrets("skupper").Get("link1", metav1.GetOptions{})
+	assert.Assert(t, k8serrors.IsNotFound(err))
+
+	assert.ErrorContains(t, cli.deleteLinkSecret("link1", "skupper"), "Could not delete secret for link link1")
+}
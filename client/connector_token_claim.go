@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// TokenClaimsConfigMap records, for each token with limited uses or an
+// expiry, the links in the site that were created from it
+const TokenClaimsConfigMap string = "skupper-token-claims"
+
+var (
+	ErrTokenExpired = errors.New("token has expired")
+	ErrTokenClaimed = errors.New("token has already been used the maximum number of times")
+	ErrTokenSite    = errors.New("token was issued to a different site")
+)
+
+// IsTokenRejected returns true if the error is due to the token having
+// expired, having been used the maximum number of times or having been
+// issued to another site
+func IsTokenRejected(err error) bool {
+	return errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrTokenClaimed) || errors.Is(err, ErrTokenSite)
+}
+
+// checkTokenSite returns an error if the token is bound to a site other
+// than the one given. The binding is read from the certificate rather
+// than the annotation, as that is what the site that issued the token
+// checks when the link connects.
+func checkTokenSite(token *corev1.Secret, link string, siteId string) error {
+	bound, err := certs.GetCertificateSiteId(token.Data["tls.crt"])
+	if err != nil || bound == "" || bound == siteId {
+		// a token without a valid certificate is rejected by the
+		// router instead
+		return nil
+	}
+	return fmt.Errorf("Cannot create link %s: %w (%s)", link, ErrTokenSite, bound)
+}
+
+// verifyTokenSite checks that the token can be used by the site in the
+// namespace
+func (cli *VanClient) verifyTokenSite(ctx context.Context, token *corev1.Secret, link string, namespace string) error {
+	siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	if err != nil {
+		return err
+	}
+	siteId := ""
+	if siteConfig != nil {
+		siteId = siteConfig.Reference.UID
+	}
+	return checkTokenSite(token, link, siteId)
+}
+
+// tokenLimits returns the id, expiry and maximum uses of a token; a token
+// without an id is not limited. On a claim record these are the issuing
+// site's own; on a cert token they are advisory, as whoever holds the
+// token can remove them.
+func tokenLimits(token *corev1.Secret) (string, time.Time, int, error) {
+	id := token.ObjectMeta.Annotations[types.TokenId]
+	if id == "" {
+		return "", time.Time{}, 0, nil
+	}
+	var expiry time.Time
+	if value, ok := token.ObjectMeta.Annotations[types.TokenExpiry]; ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return "", time.Time{}, 0, fmt.Errorf("Invalid token expiry %q: %s", value, err)
+		}
+		expiry = t
+	}
+	uses := 0
+	if value, ok := token.ObjectMeta.Annotations[types.TokenMaxUses]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return "", time.Time{}, 0, fmt.Errorf("Invalid token uses %q", value)
+		}
+		uses = n
+	}
+	return id, expiry, uses, nil
+}
+
+func getTokenClaims(claims *corev1.ConfigMap, id string) []string {
+	if claims == nil || claims.Data[id] == "" {
+		return nil
+	}
+	return strings.Split(claims.Data[id], ",")
+}
+
+// checkTokenClaim returns an error if the token cannot be used for the
+// named link: a link that has already claimed the token may always use it
+// again, otherwise the token must not have expired or used up its claims
+func checkTokenClaim(token *corev1.Secret, link string, claims *corev1.ConfigMap, now time.Time) error {
+	id, expiry, uses, err := tokenLimits(token)
+	if err != nil || id == "" {
+		return err
+	}
+	claimants := getTokenClaims(claims, id)
+	for _, claimant := range claimants {
+		if claimant == link {
+			return nil
+		}
+	}
+	if !expiry.IsZero() && now.After(expiry) {
+		return fmt.Errorf("Cannot create link %s: %w (at %s)", link, ErrTokenExpired, expiry.Format(time.RFC3339))
+	}
+	if uses > 0 && len(claimants) >= uses {
+		return fmt.Errorf("Cannot create link %s: %w (%d)", link, ErrTokenClaimed, uses)
+	}
+	return nil
+}
+
+func (cli *VanClient) getTokenClaimsConfigMap(namespace string) (*corev1.ConfigMap, error) {
+	claims, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get(TokenClaimsConfigMap, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifyTokenClaim checks that the token can be used for the named link
+// in the namespace, without claiming it
+func (cli *VanClient) verifyTokenClaim(token *corev1.Secret, link string, namespace string) error {
+	if token.ObjectMeta.Annotations[types.TokenId] == "" {
+		return nil
+	}
+	claims, err := cli.getTokenClaimsConfigMap(namespace)
+	if err != nil {
+		return err
+	}
+	return checkTokenClaim(token, link, claims, time.Now())
+}
+
+// claimToken records the use of the token by the named link, returning an
+// error if the token cannot be used for it. Claims are never released, so
+// removing a link does not make its token usable again.
+func (cli *VanClient) claimToken(token *corev1.Secret, link string, namespace string) error {
+	id := token.ObjectMeta.Annotations[types.TokenId]
+	if id == "" {
+		return nil
+	}
+	claims, err := cli.getTokenClaimsConfigMap(namespace)
+	if err != nil {
+		return err
+	}
+	if err := checkTokenClaim(token, link, claims, time.Now()); err != nil {
+		return err
+	}
+	claimants := getTokenClaims(claims, id)
+	for _, claimant := range claimants {
+		if claimant == link {
+			return nil
+		}
+	}
+	claimants = append(claimants, link)
+	sort.Strings(claimants)
+	if claims == nil {
+		claims = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: TokenClaimsConfigMap,
+			},
+			Data: map[string]string{},
+		}
+		if deployment, err := kube.GetDeployment(types.TransportDeploymentName, namespace, cli.KubeClient); err == nil {
+			claims.ObjectMeta.OwnerReferences = []metav1.OwnerReference{kube.GetDeploymentOwnerReference(deployment)}
+		}
+		claims.Data[id] = strings.Join(claimants, ",")
+		_, err = cli.KubeClient.CoreV1().ConfigMaps(namespace).Create(claims)
+		return err
+	}
+	if claims.Data == nil {
+		claims.Data = map[string]string{}
+	}
+	claims.Data[id] = strings.Join(claimants, ",")
+	_, err = cli.KubeClient.CoreV1().ConfigMaps(namespace).Update(claims)
+	return err
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTokenClaims(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	configureSiteAndCreateRouter(t, ctx, cli, "public")
+
+	_, _, err = cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "skupper", types.TokenCreateOptions{Uses: -1})
+	assert.Error(t, err, "Token expiry and uses cannot be negative")
+
+	unlimited, _, err := cli.ConnectorTokenCreate(ctx, "conn1", "skupper")
+	assert.Assert(t, err)
+	_, ok := unlimited.ObjectMeta.Annotations[types.TokenId]
+	assert.Assert(t, !ok)
+
+	token, _, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "skupper", types.TokenCreateOptions{Expiry: time.Hour, Uses: 1})
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Annotations[types.TokenMaxUses], "1")
+	expiry, err := time.Parse(time.RFC3339, token.ObjectMeta.Annotations[types.TokenExpiry])
+	assert.Assert(t, err)
+	assert.Assert(t, expiry.After(time.Now().Add(59*time.Minute)))
+
+	options := types.ConnectorCreateOptions{Name: "link1", SkupperNamespace: "skupper"}
+	assert.Assert(t, cli.ConnectorCreate(ctx, token, options))
+	// the link that claimed the token can be recreated from it
+	assert.Assert(t, cli.ConnectorCreate(ctx, token, options))
+
+	options.Name = "link2"
+	err = cli.ConnectorCreate(ctx, token, options)
+	assert.Assert(t, errors.Is(err, ErrTokenClaimed), err)
+	assert.Assert(t, IsTokenRejected(err))
+
+	claims, err := cli.KubeClient.CoreV1().ConfigMaps("skupper").Get(TokenClaimsConfigMap, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, claims.Data, map[string]string{token.ObjectMeta.Annotations[types.TokenId]: "link1"})
+
+	// an expired token cannot be claimed by a new link
+	assert.Assert(t, checkTokenClaim(token, "link1", claims, expiry.Add(time.Minute)))
+	err = checkTokenClaim(unlimited, "link2", claims, expiry.Add(time.Minute))
+	assert.Assert(t, err)
+	token.ObjectMeta.Annotations[types.TokenMaxUses] = "2"
+	assert.Assert(t, checkTokenClaim(token, "link2", claims, expiry))
+	err = checkTokenClaim(token, "link2", claims, expiry.Add(time.Minute))
+	assert.Assert(t, errors.Is(err, ErrTokenExpired), err)
+}
+
+func TestTokenSiteBinding(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	configureSiteAndCreateRouter(t, ctx, cli, "public")
+
+	token, _, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "skupper", types.TokenCreateOptions{Site: "partner-a"})
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Annotations[types.TokenSiteId], "partner-a")
+	bound, err := certs.GetCertificateSiteId(token.Data["tls.crt"])
+	assert.Assert(t, err)
+	assert.Equal(t, bound, "partner-a")
+
+	assert.Assert(t, checkTokenSite(token, "link1", "partner-a"))
+	err = checkTokenSite(token, "link1", "partner-b")
+	assert.Assert(t, errors.Is(err, ErrTokenSite), err)
+	assert.Assert(t, IsTokenRejected(err))
+
+	// the site in this namespace is not partner-a
+	err = cli.ConnectorCreate(ctx, token, types.ConnectorCreateOptions{Name: "link1", SkupperNamespace: "skupper"})
+	assert.Assert(t, errors.Is(err, ErrTokenSite), err)
+
+	// removing the annotation does not remove the binding
+	delete(token.ObjectMeta.Annotations, types.TokenSiteId)
+	err = checkTokenSite(token, "link1", "partner-b")
+	assert.Assert(t, errors.Is(err, ErrTokenSite), err)
+
+	unbound, _, err := cli.ConnectorTokenCreate(ctx, "conn2", "skupper")
+	assert.Assert(t, err)
+	assert.Assert(t, checkTokenSite(unbound, "link1", "partner-b"))
+}
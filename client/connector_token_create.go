@@ -2,11 +2,16 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -14,6 +19,7 @@ import (
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/messages"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
@@ -47,7 +53,7 @@ func configureHostPortsFromRoutes(result *RouterHostPorts, cli *VanClient, names
 	} else {
 		interRouterRoute, err1 := cli.RouteClient.Routes(namespace).Get("skupper-inter-router", metav1.GetOptions{})
 		edgeRoute, err2 := cli.RouteClient.Routes(namespace).Get("skupper-edge", metav1.GetOptions{})
-		if err1 != nil && err2 != nil && errors.IsNotFound(err1) && errors.IsNotFound(err2) {
+		if err1 != nil && err2 != nil && kerrors.IsNotFound(err1) && kerrors.IsNotFound(err2) {
 			return false, nil
 		} else if err1 != nil {
 			return false, err1
@@ -73,37 +79,87 @@ func configureHostPorts(result *RouterHostPorts, cli *VanClient, namespace strin
 		return false
 	} else if ok {
 		return ok
-	} else {
-		service, err := cli.KubeClient.CoreV1().Services(namespace).Get(types.TransportServiceName, metav1.GetOptions{})
+	}
+	// Ingresses and gateways are only looked for when the site uses
+	// them, as the client may not be permitted to read them otherwise
+	siteConfig, err := cli.SiteConfigInspectInNamespace(context.Background(), nil, namespace)
+	if err != nil {
+		return false
+	}
+	if siteConfig != nil && siteConfig.Spec.IsIngressKubernetes() {
+		ok, err = configureHostPortsFromIngresses(result, cli, namespace)
 		if err != nil {
 			return false
-		} else {
-			if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
-				host := kube.GetLoadBalancerHostOrIp(service)
-				if host != "" {
-					result.Hosts = host
-					result.InterRouter.Host = host
-					result.InterRouter.Port = "55671"
-					result.Edge.Host = host
-					result.Edge.Port = "45671"
-					return true
-				} else {
-					fmt.Printf("LoadBalancer Host/IP not yet allocated for service %s, ", service.ObjectMeta.Name)
-				}
+		} else if ok {
+			return ok
+		}
+	}
+	if siteConfig != nil && siteConfig.Spec.IsIngressGateway() {
+		ok, err = configureHostPortsFromGateway(result, cli, namespace)
+		if err != nil {
+			return false
+		} else if ok {
+			return ok
+		}
+	}
+	service, err := kube.GetRouterIngressService(namespace, cli.KubeClient)
+	if err != nil {
+		return false
+	} else {
+		if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			host := kube.GetLoadBalancerHostOrIp(service)
+			if host != "" {
+				result.Hosts = host
+				result.InterRouter.Host = host
+				result.InterRouter.Port = "55671"
+				result.Edge.Host = host
+				result.Edge.Port = "45671"
+				return true
+			} else {
+				fmt.Printf("LoadBalancer Host/IP not yet allocated for service %s, ", service.ObjectMeta.Name)
 			}
-			result.LocalOnly = true
-			host := fmt.Sprintf("%s.%s", types.TransportServiceName, namespace)
-			result.Hosts = host
-			result.InterRouter.Host = host
-			result.InterRouter.Port = "55671"
-			result.Edge.Host = host
-			result.Edge.Port = "45671"
-			return true
 		}
+		result.LocalOnly = true
+		host := fmt.Sprintf("%s.%s", types.TransportServiceName, namespace)
+		result.Hosts = host
+		result.InterRouter.Host = host
+		result.InterRouter.Port = "55671"
+		result.Edge.Host = host
+		result.Edge.Port = "45671"
+		return true
 	}
 }
 
 func (cli *VanClient) ConnectorTokenCreate(ctx context.Context, subject string, namespace string) (*corev1.Secret, bool, error) {
+	return cli.ConnectorTokenCreateWithOptions(ctx, subject, namespace, types.TokenCreateOptions{})
+}
+
+// ConnectorTokenCreateWithOptions creates a token as ConnectorTokenCreate
+// does, limited to the expiry and number of uses in the options and, if
+// a site is given, to use by that site alone
+func (cli *VanClient) ConnectorTokenCreateWithOptions(ctx context.Context, subject string, namespace string, options types.TokenCreateOptions) (*corev1.Secret, bool, error) {
+	return cli.connectorTokenCreate(ctx, subject, namespace, options, nil)
+}
+
+// connectorTokenCreate creates a token for the endpoints of the site or,
+// if given, for the endpoints through which the site is reached instead
+func (cli *VanClient) connectorTokenCreate(ctx context.Context, subject string, namespace string, options types.TokenCreateOptions, endpoints *RouterHostPorts) (*corev1.Secret, bool, error) {
+	if cli.ReadOnly {
+		return nil, false, ErrReadOnly
+	}
+	if cli.Driver != nil {
+		secret, err := cli.Driver.TokenCreate(ctx, subject, options)
+		return secret, false, err
+	}
+	if options.Expiry < 0 || options.Uses < 0 {
+		return nil, false, fmt.Errorf("Token expiry and uses cannot be negative")
+	}
+	if options.Cost < 0 {
+		return nil, false, fmt.Errorf("Invalid value for cost: %d, must not be negative", options.Cost)
+	}
+	if options.Type != "" && options.Type != types.TokenTypeCert && options.Type != types.TokenTypeClaim {
+		return nil, false, fmt.Errorf("Invalid token type %q, must be %s or %s", options.Type, types.TokenTypeClaim, types.TokenTypeCert)
+	}
 	if namespace == "" {
 		namespace = cli.Namespace
 	}
@@ -119,6 +175,14 @@ func (cli *VanClient) ConnectorTokenCreate(ctx context.Context, subject string,
 	if current.IsEdge() {
 		return nil, false, fmt.Errorf("Edge configuration cannot accept connections")
 	}
+	// Store our siteID in the token, to prevent later self-connection.
+	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if siteConfig != nil && !siteConfig.Spec.AcceptsLinks() {
+		return nil, false, fmt.Errorf("Cannot create token: %w (link-direction is %s)", ErrLinksNotAccepted, siteConfig.Spec.LinkDirection)
+	}
 	//TODO: creat const for ca
 	caSecret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.SiteCaSecret, metav1.GetOptions{})
 	if err != nil {
@@ -126,21 +190,49 @@ func (cli *VanClient) ConnectorTokenCreate(ctx context.Context, subject string,
 	}
 	//get the host and port for inter-router and edge
 	var hostPorts RouterHostPorts
-	if !configureHostPorts(&hostPorts, cli, namespace) {
+	if endpoints != nil {
+		hostPorts = *endpoints
+	} else if !configureHostPorts(&hostPorts, cli, namespace) {
 		//TODO: return the actual error
 		return nil, false, fmt.Errorf("Could not determine host/ports for token")
 	}
-	secret := certs.GenerateSecret(subject, subject, hostPorts.Hosts, caSecret)
+	if options.Site != "" && siteConfig != nil && siteConfig.Reference.UID == options.Site {
+		return nil, false, fmt.Errorf("Cannot bind token to the site that generates it")
+	}
+	if options.Type == types.TokenTypeClaim {
+		claim, localOnly, err := cli.claimCreate(subject, namespace, options, siteConfig, caSecret, hostPorts)
+		if !errors.Is(err, errClaimsNotServed) {
+			return claim, localOnly, err
+		}
+		cli.warn("token", fmt.Sprintf("Creating a token of type %s instead of a claim, as %s", types.TokenTypeCert, err))
+		options.Type = types.TokenTypeCert
+	}
+	var secret corev1.Secret
+	if options.Site != "" {
+		secret = certs.GenerateSiteBoundSecret(subject, subject, options.Site, hostPorts.Hosts, caSecret)
+	} else {
+		secret = certs.GenerateSecret(subject, subject, hostPorts.Hosts, caSecret)
+	}
 	annotateConnectionToken(&secret, "inter-router", hostPorts.InterRouter.Host, hostPorts.InterRouter.Port)
 	annotateConnectionToken(&secret, "edge", hostPorts.Edge.Host, hostPorts.Edge.Port)
 	if secret.ObjectMeta.Labels == nil {
 		secret.ObjectMeta.Labels = map[string]string{}
 	}
 	secret.ObjectMeta.Labels[types.SkupperTypeQualifier] = types.TypeToken
-	// Store our siteID in the token, to prevent later self-connection.
-	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
-	if err != nil {
-		return nil, false, err
+	if options.Expiry > 0 {
+		secret.ObjectMeta.Annotations[types.TokenExpiry] = time.Now().Add(options.Expiry).UTC().Format(time.RFC3339)
+	}
+	if options.Uses > 0 {
+		secret.ObjectMeta.Annotations[types.TokenMaxUses] = strconv.Itoa(options.Uses)
+	}
+	if options.Expiry > 0 || options.Uses > 0 {
+		secret.ObjectMeta.Annotations[types.TokenId] = uuid.New().String()
+	}
+	if options.Site != "" {
+		secret.ObjectMeta.Annotations[types.TokenSiteId] = options.Site
+	}
+	if options.Cost > 0 {
+		secret.ObjectMeta.Annotations[types.TokenCost] = strconv.Itoa(int(options.Cost))
 	}
 	if siteConfig != nil {
 		secret.ObjectMeta.Annotations[types.TokenGeneratedBy] = siteConfig.Reference.UID
@@ -149,7 +241,14 @@ func (cli *VanClient) ConnectorTokenCreate(ctx context.Context, subject string,
 }
 
 func (cli *VanClient) ConnectorTokenCreateFile(ctx context.Context, subject string, secretFile string) error {
-	secret, localOnly, err := cli.ConnectorTokenCreate(ctx, subject, "")
+	return cli.ConnectorTokenCreateFileWithOptions(ctx, subject, secretFile, types.TokenCreateOptions{})
+}
+
+func (cli *VanClient) ConnectorTokenCreateFileWithOptions(ctx context.Context, subject string, secretFile string, options types.TokenCreateOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	secret, localOnly, err := cli.ConnectorTokenCreateWithOptions(ctx, subject, "", options)
 	if err == nil {
 		//generate yaml and save it to the specified path
 		s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
@@ -163,10 +262,12 @@ func (cli *VanClient) ConnectorTokenCreateFile(ctx context.Context, subject stri
 		} else {
 			var extra string
 			if localOnly {
-				extra = "(Note: token will only be valid for local cluster)"
+				extra = messages.Sprintf(messages.TokenLocalOnly)
+			}
+			if options.Type != types.TokenTypeClaim && (options.Expiry > 0 || options.Uses > 0) {
+				extra = strings.TrimSpace(extra + " " + messages.Sprintf(messages.TokenLimitsAdvisory, types.TokenTypeCert, types.TokenTypeClaim))
 			}
-			fmt.Printf("Connection token written to %s %s", secretFile, extra)
-			fmt.Println()
+			fmt.Println(messages.Sprintf(messages.TokenWritten, secretFile, extra))
 			return nil
 		}
 	} else {
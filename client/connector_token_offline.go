@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// bundleChecksum returns a digest of the certificates and annotations of
+// a bundle. It guards against bundles being truncated or altered while
+// they are carried between clusters by hand; it is not a signature.
+func bundleChecksum(secret *corev1.Secret) string {
+	h := sha256.New()
+	keys := []string{}
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "data:%s:%d:", key, len(secret.Data[key]))
+		h.Write(secret.Data[key])
+	}
+	keys = []string{}
+	for key := range secret.ObjectMeta.Annotations {
+		if key != types.TokenBundleChecksum {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "annotation:%s=%s\n", key, secret.ObjectMeta.Annotations[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// verifyBundle returns an error if the secret is not a bundle or does not
+// match its checksum
+func verifyBundle(secret *corev1.Secret) error {
+	checksum, ok := secret.ObjectMeta.Annotations[types.TokenBundleChecksum]
+	if !ok {
+		return fmt.Errorf("Not an offline bundle: no checksum found")
+	}
+	if checksum != bundleChecksum(secret) {
+		return fmt.Errorf("Offline bundle has been altered or is incomplete")
+	}
+	return nil
+}
+
+func parseBundleAddress(address string, defaultHost string, defaultPort int32) (HostPort, error) {
+	if address == "" {
+		return HostPort{Host: defaultHost, Port: strconv.Itoa(int(defaultPort))}, nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return HostPort{}, fmt.Errorf("Invalid address %q: %s", address, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return HostPort{}, fmt.Errorf("Invalid port in address %q", address)
+	}
+	return HostPort{Host: host, Port: port}, nil
+}
+
+// bundleEndpoints returns the endpoints given in the options, or nil if
+// the bundle is for the endpoints the site advertises
+func bundleEndpoints(options types.OfflineBundleOptions) (*RouterHostPorts, error) {
+	if options.InterRouterAddress == "" {
+		if options.EdgeAddress != "" {
+			return nil, fmt.Errorf("An edge address requires an inter-router address")
+		}
+		return nil, nil
+	}
+	interRouter, err := parseBundleAddress(options.InterRouterAddress, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	edge, err := parseBundleAddress(options.EdgeAddress, interRouter.Host, types.EdgeListenerPort)
+	if err != nil {
+		return nil, err
+	}
+	hosts := interRouter.Host
+	if edge.Host != interRouter.Host {
+		hosts = edge.Host + "," + interRouter.Host
+	}
+	return &RouterHostPorts{
+		InterRouter: interRouter,
+		Edge:        edge,
+		Hosts:       hosts,
+	}, nil
+}
+
+// TokenExportOffline writes a token to the file as a self-contained
+// bundle of the certificates and addresses needed to link to this site.
+// Nothing in the bundle needs to be fetched from this site when it is
+// used, so it can be carried to a cluster that can only reach this site
+// over the link itself.
+func (cli *VanClient) TokenExportOffline(ctx context.Context, subject string, bundleFile string, options types.OfflineBundleOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if options.Type == types.TokenTypeClaim {
+		return fmt.Errorf("An offline bundle cannot be a claim, as it is used without reaching this site")
+	}
+	endpoints, err := bundleEndpoints(options)
+	if err != nil {
+		return err
+	}
+	secret, _, err := cli.connectorTokenCreate(ctx, subject, "", options.TokenCreateOptions, endpoints)
+	if err != nil {
+		return err
+	}
+	secret.ObjectMeta.Annotations[types.TokenBundleChecksum] = bundleChecksum(secret)
+
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	out, err := os.Create(bundleFile)
+	if err != nil {
+		return fmt.Errorf("Could not write to file " + bundleFile + ": " + err.Error())
+	}
+	defer out.Close()
+	if err := s.Encode(secret, out); err != nil {
+		return fmt.Errorf("Could not write out offline bundle: " + err.Error())
+	}
+	return nil
+}
+
+// LinkCreateFromBundle creates a link from a bundle written by
+// TokenExportOffline, after checking that it arrived intact. For a site
+// managed by the site controller only the secret for the link is created,
+// as the controller creates the link from it.
+func (cli *VanClient) LinkCreateFromBundle(ctx context.Context, bundleFile string, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	yaml, err := ioutil.ReadFile(bundleFile)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read offline bundle: %w", err)
+	}
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	var secret corev1.Secret
+	if _, _, err := s.Decode(yaml, nil, &secret); err != nil {
+		return nil, fmt.Errorf("Could not parse offline bundle: %w", err)
+	}
+	if err := verifyBundle(&secret); err != nil {
+		return nil, err
+	}
+	siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, options.SkupperNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if siteConfig != nil && siteConfig.Spec.SiteControlled {
+		return cli.ConnectorCreateSecretFromFile(ctx, bundleFile, options)
+	}
+	return cli.ConnectorCreateFromFile(ctx, bundleFile, options)
+}
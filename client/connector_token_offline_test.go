@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestBundleEndpoints(t *testing.T) {
+	endpoints, err := bundleEndpoints(types.OfflineBundleOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, endpoints == nil)
+
+	endpoints, err = bundleEndpoints(types.OfflineBundleOptions{InterRouterAddress: "tunnel.example.com:8443"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *endpoints, RouterHostPorts{
+		InterRouter: HostPort{Host: "tunnel.example.com", Port: "8443"},
+		Edge:        HostPort{Host: "tunnel.example.com", Port: "45671"},
+		Hosts:       "tunnel.example.com",
+	})
+
+	endpoints, err = bundleEndpoints(types.OfflineBundleOptions{InterRouterAddress: "10.0.0.1:8443", EdgeAddress: "10.0.0.2:9443"})
+	assert.NilError(t, err)
+	assert.Equal(t, endpoints.Hosts, "10.0.0.2,10.0.0.1")
+
+	_, err = bundleEndpoints(types.OfflineBundleOptions{EdgeAddress: "10.0.0.2:9443"})
+	assert.Error(t, err, "An edge address requires an inter-router address")
+	_, err = bundleEndpoints(types.OfflineBundleOptions{InterRouterAddress: "tunnel.example.com:0"})
+	assert.Error(t, err, `Invalid port in address "tunnel.example.com:0"`)
+}
+
+func TestBundleChecksum(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	configureSiteAndCreateRouter(t, ctx, cli, "issuer")
+
+	endpoints, err := bundleEndpoints(types.OfflineBundleOptions{InterRouterAddress: "tunnel.example.com:8443"})
+	assert.NilError(t, err)
+	secret, _, err := cli.connectorTokenCreate(ctx, "offline", "", types.TokenCreateOptions{Uses: 1}, endpoints)
+	assert.NilError(t, err)
+	assert.Equal(t, secret.ObjectMeta.Annotations["inter-router-host"], "tunnel.example.com")
+	assert.Equal(t, secret.ObjectMeta.Annotations["inter-router-port"], "8443")
+	assert.Error(t, verifyBundle(secret), "Not an offline bundle: no checksum found")
+
+	secret.ObjectMeta.Annotations[types.TokenBundleChecksum] = bundleChecksum(secret)
+	assert.NilError(t, verifyBundle(secret))
+
+	// bundles altered in transit are rejected
+	altered := secret.DeepCopy()
+	altered.ObjectMeta.Annotations["inter-router-host"] = "other.example.com"
+	assert.Error(t, verifyBundle(altered), "Offline bundle has been altered or is incomplete")
+	altered = secret.DeepCopy()
+	altered.Data["tls.key"] = altered.Data["tls.key"][:10]
+	assert.Error(t, verifyBundle(altered), "Offline bundle has been altered or is incomplete")
+}
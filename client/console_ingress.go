@@ -0,0 +1,60 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// consoleHost returns the host the console is reached on through an
+// Ingress, which unless set explicitly is generated under the ingress
+// domain as the hosts of the router are
+func consoleHost(spec *types.SiteConfigSpec, namespace string) string {
+	if spec.ConsoleHost != "" {
+		return spec.ConsoleHost
+	}
+	return ingressHost(types.ConsoleRouteName, namespace, spec.IngressHost)
+}
+
+// consoleTlsSecret returns the secret holding the certificate the console
+// is served with, which is generated if none is configured
+func consoleTlsSecret(spec *types.SiteConfigSpec) string {
+	if spec.ConsoleTlsSecret != "" {
+		return spec.ConsoleTlsSecret
+	}
+	return types.ConsoleTlsSecret
+}
+
+// checkConsoleTlsSecret checks that an existing secret the console is to
+// be served with holds a certificate for the host it is reached on
+func (cli *VanClient) checkConsoleTlsSecret(spec *types.SiteConfigSpec, namespace string) error {
+	if spec.ConsoleTlsSecret == "" || !spec.ConsoleServesTls() {
+		return nil
+	}
+	secret, err := cli.KubeClient.CoreV1().Secrets(namespace).Get(spec.ConsoleTlsSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not retrieve console TLS secret %s: %w", spec.ConsoleTlsSecret, err)
+	}
+	hosts := []string{}
+	if spec.IsConsoleIngressKubernetes() {
+		hosts = append(hosts, consoleHost(spec, namespace))
+	}
+	return certs.ValidateServerCertificate(secret, hosts, nil, time.Now())
+}
+
+// createConsoleIngress creates an Ingress for the console that passes TLS
+// through to the controller, which serves it
+func (cli *VanClient) createConsoleIngress(spec *types.SiteConfigSpec, namespace string, ownerRefs []metav1.OwnerReference) error {
+	if cli.DynamicClient == nil {
+		return fmt.Errorf("Ingress resources are not supported by this client")
+	}
+	ingress := kube.NewIngressWithPassthrough(types.ConsoleRouteName, consoleHost(spec, namespace), types.ControllerServiceName, int(types.ConsoleDefaultServicePort))
+	ingress.SetOwnerReferences(ownerRefs)
+	_, err := kube.CreateIngress(ingress, namespace, cli.DynamicClient)
+	return err
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func TestConsoleServesTls(t *testing.T) {
+	spec := types.SiteConfigSpec{EnableConsole: true, AuthMode: string(types.ConsoleAuthModeInternal), Ingress: types.IngressLoadBalancerString}
+	assert.Assert(t, !spec.ConsoleServesTls())
+	spec.ConsoleTlsSecret = "my-cert"
+	assert.Assert(t, spec.ConsoleServesTls())
+	spec.AuthMode = string(types.ConsoleAuthModeOpenshift)
+	assert.Assert(t, !spec.ConsoleServesTls())
+
+	spec = types.SiteConfigSpec{EnableConsole: true, ConsoleIngress: types.IngressKubernetesString}
+	assert.Error(t, spec.CheckConsoleIngress(), "A console host or ingress host is required with --console-ingress ingress")
+	spec.ConsoleHost = "console.example.com"
+	assert.Assert(t, spec.CheckConsoleIngress())
+	assert.Assert(t, spec.ConsoleServesTls())
+}
+
+func TestRouterCreateWithConsoleIngress(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	cli.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		EnableConsole:    true,
+		AuthMode:         string(types.ConsoleAuthModeInternal),
+		User:             "admin",
+		Password:         "secret",
+		Ingress:          types.IngressNoneString,
+		ConsoleIngress:   types.IngressKubernetesString,
+		ConsoleHost:      "console.example.com",
+	})
+	assert.Assert(t, err)
+	assert.Equal(t, siteConfig.Spec.ConsoleHost, "console.example.com")
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	host, err := kube.GetIngressHost(types.ConsoleRouteName, "skupper", cli.DynamicClient)
+	assert.Assert(t, err)
+	assert.Equal(t, host, "console.example.com")
+	url, err := cli.getConsoleUrl()
+	assert.Assert(t, err)
+	assert.Equal(t, url, "https://console.example.com")
+
+	// with no certificate given, one is generated for the console host
+	secret, err := cli.KubeClient.CoreV1().Secrets("skupper").Get(types.ConsoleTlsSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Assert(t, len(secret.Data["tls.crt"]) > 0)
+	controller, err := cli.KubeClient.AppsV1().Deployments("skupper").Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	found := false
+	for _, env := range controller.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "METRICS_TLS" {
+			assert.Equal(t, env.Value, types.ConsoleTlsPath)
+			found = true
+		}
+	}
+	assert.Assert(t, found)
+}
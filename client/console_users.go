@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/auth"
+)
+
+func (cli *VanClient) checkInternalConsoleAuth(ctx context.Context) error {
+	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if siteConfig == nil {
+		return fmt.Errorf("Skupper is not enabled in namespace %s", cli.Namespace)
+	}
+	if siteConfig.Spec.AuthMode != types.ConsoleAuthModeInternal {
+		return fmt.Errorf("Console users can only be managed when console authentication is %s", types.ConsoleAuthModeInternal)
+	}
+	return nil
+}
+
+// ConsoleUserCreate adds a user to the console users secret, replacing
+// the password and role of any existing user of the same name
+func (cli *VanClient) ConsoleUserCreate(ctx context.Context, name string, password string, role string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if errs := validation.IsConfigMapKey(name); len(errs) > 0 {
+		return fmt.Errorf("Invalid console user name %q: %s", name, strings.Join(errs, "; "))
+	}
+	if password == "" {
+		return fmt.Errorf("A password is required for console user %s", name)
+	}
+	if role == "" {
+		role = auth.RoleAdmin
+	}
+	entry, err := auth.NewUserEntry(password, role)
+	if err != nil {
+		return err
+	}
+	if err := cli.checkInternalConsoleAuth(ctx); err != nil {
+		return err
+	}
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.ConsoleUsersSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not retrieve console users: %w", err)
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[name] = []byte(entry)
+	_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Update(secret)
+	if err != nil {
+		return err
+	}
+	return cli.updateRouterConsoleUser(name, password)
+}
+
+func (cli *VanClient) ConsoleUserList(ctx context.Context) ([]types.ConsoleUser, error) {
+	if err := cli.checkInternalConsoleAuth(ctx); err != nil {
+		return nil, err
+	}
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.ConsoleUsersSecret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return []types.ConsoleUser{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Could not retrieve console users: %w", err)
+	}
+	users := []types.ConsoleUser{}
+	for name, entry := range secret.Data {
+		users = append(users, types.ConsoleUser{
+			Name: name,
+			Role: auth.Role(string(entry)),
+		})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+	return users, nil
+}
+
+func (cli *VanClient) ConsoleUserRemove(ctx context.Context, name string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkInternalConsoleAuth(ctx); err != nil {
+		return err
+	}
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.ConsoleUsersSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not retrieve console users: %w", err)
+	}
+	if _, ok := secret.Data[name]; !ok {
+		return fmt.Errorf("No such console user %s", name)
+	}
+	delete(secret.Data, name)
+	_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Update(secret)
+	if err != nil {
+		return err
+	}
+	return cli.updateRouterConsoleUser(name, "")
+}
+
+// updateRouterConsoleUser sets the password the router console accepts
+// for a user, removing the user if the password is empty. The router
+// builds its sasldb from these, so they are held apart from the hashed
+// entries the service controller verifies.
+func (cli *VanClient) updateRouterConsoleUser(name string, password string) error {
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.RouterConsoleUsersSecret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("The router console users for %s are not yet set up, run 'skupper update' first", cli.Namespace)
+	} else if err != nil {
+		return fmt.Errorf("Could not retrieve router console users: %w", err)
+	}
+	if password == "" {
+		delete(secret.Data, name)
+	} else {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[name] = []byte(password)
+	}
+	_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Update(secret)
+	return err
+}
+
+// createRouterConsoleUsers sets up the router console users of a site
+// whose router read the console users secret directly, from the plain
+// passwords written by earlier versions
+func (cli *VanClient) createRouterConsoleUsers(namespace string) error {
+	users, err := cli.KubeClient.CoreV1().Secrets(namespace).Get(types.ConsoleUsersSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not retrieve console users: %w", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            types.RouterConsoleUsersSecret,
+			OwnerReferences: users.ObjectMeta.OwnerReferences,
+		},
+		Data: map[string][]byte{},
+	}
+	for name, entry := range users.Data {
+		if !auth.IsHashed(string(entry)) {
+			secret.Data[name] = entry
+		}
+	}
+	_, err = cli.KubeClient.CoreV1().Secrets(namespace).Create(secret)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/auth"
+)
+
+func TestConsoleUsers(t *testing.T) {
+	ctx := context.Background()
+	cli := &VanClient{
+		Namespace: "skupper",
+		KubeClient: fake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "skupper-site", Namespace: "skupper"},
+			Data:       map[string]string{"console-authentication": "internal"},
+		}, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: types.ConsoleUsersSecret, Namespace: "skupper"},
+			Data:       map[string][]byte{"admin": []byte("initial")},
+		}),
+	}
+
+	assert.Error(t, cli.ConsoleUserCreate(ctx, "alice", "wonderland", auth.RoleViewer), "The router console users for skupper are not yet set up, run 'skupper update' first")
+	assert.NilError(t, cli.createRouterConsoleUsers("skupper"))
+	assert.NilError(t, cli.ConsoleUserCreate(ctx, "alice", "wonderland", auth.RoleViewer))
+	assert.NilError(t, cli.ConsoleUserCreate(ctx, "bob", "builder", ""))
+	assert.ErrorContains(t, cli.ConsoleUserCreate(ctx, "carol/x", "secret", ""), `Invalid console user name "carol/x"`)
+	assert.Error(t, cli.ConsoleUserCreate(ctx, "carol", "", ""), "A password is required for console user carol")
+	assert.Error(t, cli.ConsoleUserCreate(ctx, "carol", "secret", "owner"), `Invalid console user role "owner", must be admin or viewer`)
+
+	users, err := cli.ConsoleUserList(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, users, []types.ConsoleUser{
+		{Name: "admin", Role: auth.RoleAdmin},
+		{Name: "alice", Role: auth.RoleViewer},
+		{Name: "bob", Role: auth.RoleAdmin},
+	})
+
+	secret, err := cli.KubeClient.CoreV1().Secrets("skupper").Get(types.ConsoleUsersSecret, metav1.GetOptions{})
+	assert.NilError(t, err)
+	role, ok := auth.Verify(string(secret.Data["alice"]), "wonderland")
+	assert.Assert(t, ok)
+	assert.Equal(t, role, auth.RoleViewer)
+	// the router reads the passwords themselves for its sasldb
+	routerUsers, err := cli.KubeClient.CoreV1().Secrets("skupper").Get(types.RouterConsoleUsersSecret, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, routerUsers.Data, map[string][]byte{"admin": []byte("initial"), "alice": []byte("wonderland"), "bob": []byte("builder")})
+
+	assert.NilError(t, cli.ConsoleUserRemove(ctx, "admin"))
+	assert.Error(t, cli.ConsoleUserRemove(ctx, "admin"), "No such console user admin")
+	users, err = cli.ConsoleUserList(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(users), 2)
+	routerUsers, err = cli.KubeClient.CoreV1().Secrets("skupper").Get(types.RouterConsoleUsersSecret, metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, ok = routerUsers.Data["admin"]
+	assert.Assert(t, !ok)
+}
+
+func TestConsoleUsersRequireInternalAuth(t *testing.T) {
+	cli := &VanClient{
+		Namespace: "skupper",
+		KubeClient: fake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "skupper-site", Namespace: "skupper"},
+			Data:       map[string]string{"console-authentication": "openshift"},
+		}),
+	}
+	_, err := cli.ConsoleUserList(context.Background())
+	assert.Error(t, err, "Console users can only be managed when console authentication is internal")
+}
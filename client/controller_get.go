@@ -0,0 +1,54 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func (cli *VanClient) controllerPod() (*corev1.Pod, error) {
+	pods, err := kube.GetDeploymentPods(types.ControllerDeploymentName, "skupper.io/component="+types.ControllerComponentName, cli.Namespace, cli.KubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("No service controller pods found in %s", cli.Namespace)
+	}
+	return &pods[0], nil
+}
+
+// controllerGet runs the get tool in the service controller for the
+// given path and decodes its json output into result
+func (cli *VanClient) controllerGet(path string, result interface{}) error {
+	pod, err := cli.controllerPod()
+	if err != nil {
+		return err
+	}
+	output, err := kube.ExecCommandInContainer([]string{"get", path, "-o", "json"}, pod.Name, types.ControllerContainerName, cli.Namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return fmt.Errorf("Could not retrieve %s from %s: %w", path, pod.Name, err)
+	}
+	err = json.Unmarshal(output.Bytes(), result)
+	if err != nil {
+		return fmt.Errorf("Could not parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// controllerRequest runs the get tool in the service controller to
+// request the action named by the given command
+func (cli *VanClient) controllerRequest(command string) error {
+	pod, err := cli.controllerPod()
+	if err != nil {
+		return err
+	}
+	_, err = kube.ExecCommandInContainer([]string{"get", command}, pod.Name, types.ControllerContainerName, cli.Namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return fmt.Errorf("Could not request %s from %s: %w", command, pod.Name, err)
+	}
+	return nil
+}
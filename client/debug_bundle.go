@@ -0,0 +1,79 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// debugBundle writes the files of a diagnostics bundle to a compressed
+// tar archive. Each file added, and each that could not be collected, is
+// recorded in a manifest written as the last file of the archive, so that
+// whoever reads the bundle can see what is missing from it and why.
+type debugBundle struct {
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	created  time.Time
+	files    []string
+	failures []string
+}
+
+func newDebugBundle(w io.Writer) *debugBundle {
+	gz := gzip.NewWriter(w)
+	return &debugBundle{
+		gz:      gz,
+		tw:      tar.NewWriter(gz),
+		created: time.Now(),
+	}
+}
+
+func (b *debugBundle) add(name string, data []byte) error {
+	if err := writeTar(name, data, time.Now(), b.tw); err != nil {
+		return err
+	}
+	b.files = append(b.files, name)
+	return nil
+}
+
+// addFile copies the file into the bundle without reading it all into
+// memory
+func (b *debugBundle) addFile(name string, path string) error {
+	if err := writeTarFile(name, path, b.tw); err != nil {
+		return err
+	}
+	b.files = append(b.files, name)
+	return nil
+}
+
+// failed records that the named file could not be collected
+func (b *debugBundle) failed(name string, err error) {
+	b.failures = append(b.failures, fmt.Sprintf("%s: %s", name, err))
+}
+
+func (b *debugBundle) manifest() []byte {
+	lines := []string{"created: " + b.created.Format(time.RFC3339), "", "files:"}
+	for _, name := range b.files {
+		lines = append(lines, "  "+name)
+	}
+	if len(b.failures) > 0 {
+		lines = append(lines, "", "not collected:")
+		for _, failure := range b.failures {
+			lines = append(lines, "  "+failure)
+		}
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// close writes the manifest and completes the archive
+func (b *debugBundle) close() error {
+	if err := writeTar("manifest.txt", b.manifest(), time.Now(), b.tw); err != nil {
+		return err
+	}
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gz.Close()
+}
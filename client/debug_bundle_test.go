@@ -0,0 +1,42 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDebugBundle(t *testing.T) {
+	var archive bytes.Buffer
+	bundle := newDebugBundle(&archive)
+	assert.NilError(t, bundle.add("site-config.json", []byte("{}")))
+	bundle.failed("router-qdmanage-link.json", fmt.Errorf("container not found"))
+	assert.NilError(t, bundle.close())
+
+	gz, err := gzip.NewReader(&archive)
+	assert.NilError(t, err)
+	tr := tar.NewReader(gz)
+	contents := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		data, err := ioutil.ReadAll(tr)
+		assert.NilError(t, err)
+		contents[hdr.Name] = string(data)
+	}
+	assert.Equal(t, contents["site-config.json"], "{}")
+	manifest, ok := contents["manifest.txt"]
+	assert.Assert(t, ok)
+	assert.Assert(t, strings.Contains(manifest, "files:\n  site-config.json\n"))
+	assert.Assert(t, strings.Contains(manifest, "not collected:\n  router-qdmanage-link.json: container not found\n"))
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+const certificateExpiryWarning time.Duration = 30 * 24 * time.Hour
+
+var reportCertificates = []string{
+	types.LocalCaSecret,
+	types.LocalServerSecret,
+	types.LocalClientSecret,
+	types.SiteCaSecret,
+	types.SiteServerSecret,
+}
+
+// SkupperDebugReport checks the site for common problems and returns
+// them with suggestions for what to do next
+func (cli *VanClient) SkupperDebugReport(ctx context.Context) (*types.DebugReport, error) {
+	report := &types.DebugReport{
+		Summary:  []string{},
+		Findings: []types.DebugReportFinding{},
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if siteConfig == nil {
+		report.Findings = append(report.Findings, types.DebugReportFinding{
+			Check:      "install",
+			Status:     types.FindingProblem,
+			Message:    fmt.Sprintf("Skupper is not installed in namespace %s", cli.Namespace),
+			Suggestion: "Run 'skupper init', or select the namespace skupper is installed in with --namespace",
+		})
+		return report, nil
+	}
+
+	router, err := kube.GetDeployment(types.TransportDeploymentName, cli.Namespace, cli.KubeClient)
+	report.Findings = append(report.Findings, checkDeploymentReady("router", router, err))
+	if siteConfig.Spec.EnableController {
+		controller, err := kube.GetDeployment(types.ControllerDeploymentName, cli.Namespace, cli.KubeClient)
+		report.Findings = append(report.Findings, checkDeploymentReady("service-controller", controller, err))
+	}
+
+	inspect, err := cli.RouterInspect(ctx)
+	if err == nil {
+		report.Summary = append(report.Summary,
+			fmt.Sprintf("site name: %s", inspect.Status.SiteName),
+			fmt.Sprintf("mode: %s", inspect.Status.Mode),
+			fmt.Sprintf("router version: %s", inspect.TransportVersion),
+			fmt.Sprintf("controller version: %s", inspect.ControllerVersion),
+			fmt.Sprintf("client version: %s", Version),
+			fmt.Sprintf("connected sites: %d", inspect.Status.ConnectedSites.Total),
+			fmt.Sprintf("exposed services: %d", inspect.ExposedServices),
+			fmt.Sprintf("ingress: %s", siteConfig.Spec.Ingress))
+	} else {
+		report.Findings = append(report.Findings, types.DebugReportFinding{
+			Check:      "status",
+			Status:     types.FindingWarning,
+			Message:    fmt.Sprintf("Could not retrieve site status: %s", err),
+			Suggestion: "Run 'skupper debug dump' and check the router and service-controller logs",
+		})
+	}
+
+	check, err := cli.RouterCheckUpdate(ctx, cli.Namespace)
+	if err == nil {
+		report.Findings = append(report.Findings, checkVersionSkew(check))
+	}
+
+	report.Findings = append(report.Findings, cli.checkIngress(&siteConfig.Spec)...)
+	report.Findings = append(report.Findings, cli.checkCertificates(time.Now())...)
+	report.Findings = append(report.Findings, cli.checkLinks(ctx)...)
+	return report, nil
+}
+
+func checkDeploymentReady(component string, deployment *appsv1.Deployment, err error) types.DebugReportFinding {
+	finding := types.DebugReportFinding{Check: component}
+	if err != nil {
+		finding.Status = types.FindingProblem
+		finding.Message = fmt.Sprintf("Could not retrieve %s deployment: %s", component, err)
+		finding.Suggestion = "Run 'skupper init' again, or 'skupper update' to recreate missing resources"
+		return finding
+	}
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if deployment.Status.ReadyReplicas < desired {
+		finding.Status = types.FindingProblem
+		finding.Message = fmt.Sprintf("%d of %d %s replicas ready", deployment.Status.ReadyReplicas, desired, component)
+		finding.Suggestion = fmt.Sprintf("Check the events and logs of the %s pods (kubectl describe pods -l skupper.io/component=%s)", component, component)
+		return finding
+	}
+	finding.Status = types.FindingOk
+	finding.Message = fmt.Sprintf("%d of %d %s replicas ready", deployment.Status.ReadyReplicas, desired, component)
+	return finding
+}
+
+func checkVersionSkew(check *types.RouterUpdateCheckResponse) types.DebugReportFinding {
+	finding := types.DebugReportFinding{Check: "version", Status: types.FindingOk}
+	if check.SiteIsNewer {
+		finding.Status = types.FindingWarning
+		finding.Message = fmt.Sprintf("Site version %s is newer than client version %s", check.SiteVersion, check.LibraryVersion)
+		finding.Suggestion = "Use a client of the same version as the site"
+	} else if check.UpdateAvailable() {
+		finding.Status = types.FindingWarning
+		finding.Message = fmt.Sprintf("Site version %s is older than client version %s", check.SiteVersion, check.LibraryVersion)
+		finding.Suggestion = "Run 'skupper update' to bring the site up to the client version"
+	} else {
+		finding.Message = fmt.Sprintf("Site and client are both at version %s", check.LibraryVersion)
+	}
+	return finding
+}
+
+func (cli *VanClient) checkIngress(spec *types.SiteConfigSpec) []types.DebugReportFinding {
+	findings := []types.DebugReportFinding{}
+	if !spec.AcceptsLinks() || spec.IsIngressNone() {
+		return findings
+	}
+	if spec.IsIngressRoute() {
+		if cli.RouteClient == nil {
+			findings = append(findings, types.DebugReportFinding{
+				Check:      "ingress",
+				Status:     types.FindingProblem,
+				Message:    "Ingress is configured to use routes, but the cluster does not support them",
+				Suggestion: "Reinstall with 'skupper init --ingress loadbalancer' or another ingress supported by the cluster",
+			})
+			return findings
+		}
+		_, err := cli.RouteClient.Routes(cli.Namespace).Get(types.InterRouterRouteName, metav1.GetOptions{})
+		if err != nil {
+			findings = append(findings, types.DebugReportFinding{
+				Check:      "ingress",
+				Status:     types.FindingProblem,
+				Message:    fmt.Sprintf("Route %s could not be retrieved: %s", types.InterRouterRouteName, err),
+				Suggestion: "Run 'skupper update' to recreate the routes for the site",
+			})
+		}
+		return findings
+	}
+	if spec.IsIngressKubernetes() && cli.DynamicClient != nil {
+		if _, err := kube.GetIngressHost(types.InterRouterRouteName, cli.Namespace, cli.DynamicClient); err != nil {
+			findings = append(findings, types.DebugReportFinding{
+				Check:      "ingress",
+				Status:     types.FindingProblem,
+				Message:    fmt.Sprintf("Ingress %s could not be retrieved: %s", types.InterRouterRouteName, err),
+				Suggestion: "Check that the cluster supports networking.k8s.io/v1 Ingress resources; if not, reinstall with a different --ingress",
+			})
+		}
+		return findings
+	}
+	if spec.IsIngressGateway() && cli.DynamicClient != nil {
+		if _, err := kube.GetTLSRouteHost(types.InterRouterRouteName, cli.Namespace, cli.DynamicClient); err != nil {
+			findings = append(findings, types.DebugReportFinding{
+				Check:      "ingress",
+				Status:     types.FindingProblem,
+				Message:    fmt.Sprintf("TLSRoute %s could not be retrieved: %s", types.InterRouterRouteName, err),
+				Suggestion: "Check that the Gateway API, including TLSRoute, is installed in the cluster; if not, reinstall with a different --ingress",
+			})
+		}
+		return findings
+	}
+	service, err := kube.GetRouterIngressService(cli.Namespace, cli.KubeClient)
+	if err != nil {
+		findings = append(findings, types.DebugReportFinding{
+			Check:      "ingress",
+			Status:     types.FindingProblem,
+			Message:    fmt.Sprintf("Router ingress service could not be retrieved: %s", err),
+			Suggestion: "Run 'skupper update' to recreate the services for the site",
+		})
+	} else if spec.IsIngressLoadBalancer() {
+		findings = append(findings, checkLoadBalancer(service))
+	}
+	return findings
+}
+
+func checkLoadBalancer(service *corev1.Service) types.DebugReportFinding {
+	finding := types.DebugReportFinding{Check: "ingress"}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		finding.Status = types.FindingProblem
+		finding.Message = fmt.Sprintf("Ingress is configured to use a loadbalancer, but service %s is of type %s", service.Name, service.Spec.Type)
+		finding.Suggestion = "Run 'skupper update' to restore the service"
+	} else if len(service.Status.LoadBalancer.Ingress) == 0 {
+		finding.Status = types.FindingProblem
+		finding.Message = fmt.Sprintf("Service %s has not been assigned an external address", service.Name)
+		finding.Suggestion = "Check that the cluster can provision loadbalancers; if not, reinstall with a different --ingress"
+	} else {
+		finding.Status = types.FindingOk
+		finding.Message = "Loadbalancer has an external address"
+	}
+	return finding
+}
+
+func (cli *VanClient) checkCertificates(now time.Time) []types.DebugReportFinding {
+	secrets := []corev1.Secret{}
+	for _, name := range reportCertificates {
+		secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(name, metav1.GetOptions{})
+		if err == nil {
+			secrets = append(secrets, *secret)
+		} else if !errors.IsNotFound(err) {
+			return []types.DebugReportFinding{{
+				Check:   "certificates",
+				Status:  types.FindingWarning,
+				Message: fmt.Sprintf("Could not retrieve certificates: %s", err),
+			}}
+		}
+	}
+	links, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).List(metav1.ListOptions{LabelSelector: types.TypeTokenQualifier})
+	if err == nil {
+		secrets = append(secrets, links.Items...)
+	}
+	findings := []types.DebugReportFinding{}
+	expired := 0
+	for _, secret := range secrets {
+		if data, ok := secret.Data["tls.crt"]; ok {
+			if finding := checkCertificate(secret.Name, data, now); finding != nil {
+				findings = append(findings, *finding)
+				expired++
+			}
+		}
+	}
+	if expired == 0 {
+		findings = append(findings, types.DebugReportFinding{
+			Check:   "certificates",
+			Status:  types.FindingOk,
+			Message: fmt.Sprintf("%d certificates checked, none expired or expiring soon", len(secrets)),
+		})
+	}
+	return findings
+}
+
+// checkCertificate returns a finding if the certificate has expired, or
+// will within the warning period
+func checkCertificate(name string, data []byte, now time.Time) *types.DebugReportFinding {
+	expiry, err := certs.GetCertificateExpiry(data)
+	if err != nil {
+		return &types.DebugReportFinding{
+			Check:   "certificates",
+			Status:  types.FindingWarning,
+			Message: fmt.Sprintf("Could not parse certificate in %s: %s", name, err),
+		}
+	}
+	if now.After(expiry) {
+		return &types.DebugReportFinding{
+			Check:      "certificates",
+			Status:     types.FindingProblem,
+			Message:    fmt.Sprintf("Certificate in %s expired on %s", name, expiry.Format(time.RFC3339)),
+			Suggestion: "Links using an expired certificate will fail; recreate the token or link, or reinstall the site to regenerate its certificates",
+		}
+	}
+	if expiry.Sub(now) < certificateExpiryWarning {
+		return &types.DebugReportFinding{
+			Check:      "certificates",
+			Status:     types.FindingWarning,
+			Message:    fmt.Sprintf("Certificate in %s expires on %s", name, expiry.Format(time.RFC3339)),
+			Suggestion: "Plan to regenerate the certificate before it expires",
+		}
+	}
+	return nil
+}
+
+func (cli *VanClient) checkLinks(ctx context.Context) []types.DebugReportFinding {
+	findings := []types.DebugReportFinding{}
+	connectors, err := cli.ConnectorList(ctx)
+	if err != nil {
+		return findings
+	}
+	for _, c := range connectors {
+		inspect, err := cli.ConnectorInspect(ctx, c.Name)
+		if err != nil || !inspect.Connected {
+			findings = append(findings, types.DebugReportFinding{
+				Check:      "links",
+				Status:     types.FindingWarning,
+				Message:    fmt.Sprintf("Link %s is not active", c.Name),
+				Suggestion: "Check that the site that issued the token is running and reachable from this cluster; 'skupper debug connectivity' can detect MTU problems",
+			})
+		}
+	}
+	if len(findings) == 0 && len(connectors) > 0 {
+		findings = append(findings, types.DebugReportFinding{
+			Check:   "links",
+			Status:  types.FindingOk,
+			Message: fmt.Sprintf("All %d links active", len(connectors)),
+		})
+	}
+	return findings
+}
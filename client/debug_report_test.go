@@ -0,0 +1,103 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+)
+
+func TestCheckCertificate(t *testing.T) {
+	data := certs.GenerateCACertificateData("skupper-site-ca", "skupper-site-ca")["tls.crt"]
+	expiry, err := certs.GetCertificateExpiry(data)
+	assert.NilError(t, err)
+
+	assert.Assert(t, checkCertificate("skupper-site-ca", data, time.Now()) == nil)
+
+	finding := checkCertificate("skupper-site-ca", data, expiry.Add(-24*time.Hour))
+	assert.Equal(t, finding.Status, types.FindingWarning)
+	assert.Equal(t, finding.Message, "Certificate in skupper-site-ca expires on "+expiry.Format(time.RFC3339))
+
+	finding = checkCertificate("skupper-site-ca", data, expiry.Add(time.Hour))
+	assert.Equal(t, finding.Status, types.FindingProblem)
+	assert.Equal(t, finding.Message, "Certificate in skupper-site-ca expired on "+expiry.Format(time.RFC3339))
+
+	finding = checkCertificate("mylink", []byte("not a certificate"), time.Now())
+	assert.Equal(t, finding.Status, types.FindingWarning)
+	assert.Equal(t, finding.Message, "Could not parse certificate in mylink: No certificate found")
+}
+
+func TestCheckVersionSkew(t *testing.T) {
+	testcases := []struct {
+		check   types.RouterUpdateCheckResponse
+		status  string
+		message string
+	}{
+		{types.RouterUpdateCheckResponse{SiteVersion: "0.5.0", LibraryVersion: "0.5.0"}, types.FindingOk, "Site and client are both at version 0.5.0"},
+		{types.RouterUpdateCheckResponse{SiteVersion: "0.6.0", LibraryVersion: "0.5.0", SiteIsNewer: true}, types.FindingWarning, "Site version 0.6.0 is newer than client version 0.5.0"},
+		{types.RouterUpdateCheckResponse{SiteVersion: "0.4.0", LibraryVersion: "0.5.0", VersionUpdate: true}, types.FindingWarning, "Site version 0.4.0 is older than client version 0.5.0"},
+	}
+	for _, c := range testcases {
+		finding := checkVersionSkew(&c.check)
+		assert.Equal(t, finding.Status, c.status)
+		assert.Equal(t, finding.Message, c.message)
+	}
+}
+
+func TestCheckLoadBalancer(t *testing.T) {
+	service := &corev1.Service{}
+	service.Name = types.TransportServiceName
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	assert.Equal(t, checkLoadBalancer(service).Status, types.FindingProblem)
+
+	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	finding := checkLoadBalancer(service)
+	assert.Equal(t, finding.Status, types.FindingProblem)
+	assert.Equal(t, finding.Message, "Service skupper-router has not been assigned an external address")
+
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	assert.Equal(t, checkLoadBalancer(service).Status, types.FindingOk)
+}
+
+func TestCheckIngressService(t *testing.T) {
+	router := &corev1.Service{}
+	router.Name = types.TransportServiceName
+	router.Namespace = "skupper"
+	router.Annotations = map[string]string{types.IngressServiceAnnotation: "router-lb"}
+	router.Spec.Type = corev1.ServiceTypeClusterIP
+	lb := &corev1.Service{}
+	lb.Name = "router-lb"
+	lb.Namespace = "skupper"
+	lb.Spec.Type = corev1.ServiceTypeLoadBalancer
+	lb.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+	cli := &VanClient{Namespace: "skupper", KubeClient: fake.NewSimpleClientset(router, lb)}
+
+	// the loadbalancer the router is exposed through is checked, not
+	// the router service itself
+	findings := cli.checkIngress(&types.SiteConfigSpec{Ingress: types.IngressLoadBalancerString})
+	assert.Equal(t, len(findings), 1)
+	assert.Equal(t, findings[0].Status, types.FindingOk)
+}
+
+func TestCheckDeploymentReady(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Replicas = &replicas
+	deployment.Status.ReadyReplicas = 1
+	finding := checkDeploymentReady("router", deployment, nil)
+	assert.Equal(t, finding.Status, types.FindingProblem)
+	assert.Equal(t, finding.Message, "1 of 2 router replicas ready")
+
+	deployment.Status.ReadyReplicas = 2
+	assert.Equal(t, checkDeploymentReady("router", deployment, nil).Status, types.FindingOk)
+
+	finding = checkDeploymentReady("router", nil, fmt.Errorf("not found"))
+	assert.Equal(t, finding.Status, types.FindingProblem)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// A FleetMember is a skupper site reachable through one of the
+// contexts defined in a kubeconfig file
+type FleetMember struct {
+	Context   string
+	Namespace string
+	Labels    map[string]string
+	Client    *VanClient
+	Error     error
+}
+
+func (cli *VanClient) siteLabels() (map[string]string, bool, error) {
+	cm, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get("skupper-site", metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return cm.ObjectMeta.Labels, true, nil
+}
+
+// NewFleet returns a member for every context in the kubeconfig that
+// has a skupper site whose labels match the supplied selector. Contexts
+// that could not be reached are returned with Error set so that callers
+// can report them.
+func NewFleet(namespace string, kubeConfigPath string, selector string) ([]*FleetMember, error) {
+	matcher, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeConfigPath != "" {
+		loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath}
+	}
+	config, err := loadingRules.Load()
+	if err != nil {
+		return nil, err
+	}
+	contexts := []string{}
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+
+	members := []*FleetMember{}
+	for _, context := range contexts {
+		cli, err := NewClient(namespace, context, kubeConfigPath)
+		if err != nil {
+			members = append(members, &FleetMember{Context: context, Namespace: namespace, Error: err})
+			continue
+		}
+		siteLabels, found, err := cli.siteLabels()
+		if err != nil {
+			members = append(members, &FleetMember{Context: context, Namespace: cli.Namespace, Error: err})
+			continue
+		}
+		if !found || !matcher.Matches(labels.Set(siteLabels)) {
+			continue
+		}
+		members = append(members, &FleetMember{
+			Context:   context,
+			Namespace: cli.Namespace,
+			Labels:    siteLabels,
+			Client:    cli,
+		})
+	}
+	return members, nil
+}
@@ -0,0 +1,634 @@
+package client
+
+import (
+	"context"
+	jsonencoding "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// The definition of a gateway in the site records how it is run, the
+// edge endpoint of the site it connects to and the services bound to it
+const (
+	gatewayTypeKey     string = "type"
+	gatewayUrlKey      string = "url"
+	gatewayBindingsKey string = "bindings"
+)
+
+const gatewaySslProfile string = "skupper-gateway"
+
+// The commands that run the router of a gateway on its host, replaced in
+// tests
+var (
+	gatewayLookPath = exec.LookPath
+	gatewayCommand  = runHostCommand
+)
+
+// runHostCommand runs a command on this host, returning its output in the
+// error if it fails
+func runHostCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %s: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func gatewayResourceName(name string) string {
+	return types.GatewayPrefix + name
+}
+
+// hostDataDir returns the path under which skupper keeps the files of
+// the routers it runs on this host
+func hostDataDir(elem ...string) (string, error) {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(append([]string{base, "skupper"}, elem...)...), nil
+}
+
+// gatewayDir is where the config and certificates of the router of a
+// gateway are kept on its host
+func gatewayDir(name string) (string, error) {
+	return hostDataDir("gateways", name)
+}
+
+// gatewayUnitFile is the systemd user unit that runs a gateway of type
+// service
+func gatewayUnitFile(name string) (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "systemd", "user", gatewayResourceName(name)+".service"), nil
+}
+
+func defaultGatewayName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "gateway"
+	}
+	return strings.ToLower(strings.Split(hostname, ".")[0])
+}
+
+// gatewayRouterConfig returns the config of the router of a gateway: an
+// edge router, without the metadata of a site, connected to the site at
+// the url and with a connector for each port of each service bound to it
+func gatewayRouterConfig(name string, url string, certDir string, bindings []types.GatewayBinding) (string, error) {
+	host, port, err := net.SplitHostPort(url)
+	if err != nil {
+		return "", fmt.Errorf("Invalid url for gateway %s: %s", name, err)
+	}
+	config := qdr.InitialConfig(gatewayResourceName(name), "", Version, true, 3)
+	config.AddSslProfile(qdr.SslProfile{
+		Name:           gatewaySslProfile,
+		CertFile:       filepath.Join(certDir, "tls.crt"),
+		PrivateKeyFile: filepath.Join(certDir, "tls.key"),
+		CaCertFile:     filepath.Join(certDir, "ca.crt"),
+	})
+	config.AddConnector(qdr.Connector{
+		Name:       "uplink",
+		Role:       qdr.RoleEdge,
+		Host:       host,
+		Port:       port,
+		SslProfile: gatewaySslProfile,
+	})
+	for _, binding := range bindings {
+		for i, servicePort := range binding.Ports {
+			address := types.AddressForPort(binding.Address, binding.Ports[0], servicePort)
+			name := address + "@" + binding.Host
+			targetPort := strconv.Itoa(binding.TargetPorts[i])
+			switch binding.Protocol {
+			case "http":
+				config.AddHttpConnector(qdr.HttpEndpoint{Name: name, Host: binding.Host, Port: targetPort, Address: address})
+			case "http2", "grpc":
+				config.AddHttpConnector(qdr.HttpEndpoint{Name: name, Host: binding.Host, Port: targetPort, Address: address, ProtocolVersion: qdr.HttpVersion2})
+			case "tcp":
+				config.AddTcpConnector(qdr.TcpEndpoint{Name: name, Host: binding.Host, Port: targetPort, Address: address})
+			default:
+				return "", fmt.Errorf("Unrecognised protocol for service %s: %s", binding.Address, binding.Protocol)
+			}
+		}
+	}
+	return qdr.MarshalRouterConfig(config)
+}
+
+func (cli *VanClient) getGatewayDefinition(name string) (*corev1.ConfigMap, error) {
+	definition, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(gatewayResourceName(name), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("Gateway %s not found", name)
+	} else if err != nil {
+		return nil, fmt.Errorf("Could not retrieve gateway %s: %w", name, err)
+	}
+	return definition, nil
+}
+
+func gatewayFromDefinition(definition *corev1.ConfigMap) (*types.GatewayInspectResponse, error) {
+	gateway := &types.GatewayInspectResponse{
+		Name:     strings.TrimPrefix(definition.ObjectMeta.Name, types.GatewayPrefix),
+		Type:     definition.Data[gatewayTypeKey],
+		Url:      definition.Data[gatewayUrlKey],
+		Bindings: []types.GatewayBinding{},
+	}
+	if data, ok := definition.Data[gatewayBindingsKey]; ok && data != "" {
+		if err := jsonencoding.Unmarshal([]byte(data), &gateway.Bindings); err != nil {
+			return nil, fmt.Errorf("Invalid bindings for gateway %s: %s", gateway.Name, err)
+		}
+	}
+	return gateway, nil
+}
+
+// GatewayInit sets up a gateway on this host and returns its name. The
+// name of a gateway defaults to that of the host, here and in the other
+// gateway operations. The router of the gateway is run as a systemd user
+// service or, with type podman, in a container, and connects to the site
+// with a certificate issued by it.
+func (cli *VanClient) GatewayInit(ctx context.Context, name string, gatewayType string) (string, error) {
+	if cli.ReadOnly {
+		return "", ErrReadOnly
+	}
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = defaultGatewayName()
+	}
+	if gatewayType == "" {
+		gatewayType = types.GatewayTypeService
+	}
+	if !types.IsValidGatewayType(gatewayType) {
+		return "", fmt.Errorf("Invalid gateway type %q, must be %s or %s", gatewayType, types.GatewayTypeService, types.GatewayTypePodman)
+	}
+	program := "qdrouterd"
+	if gatewayType == types.GatewayTypePodman {
+		program = "podman"
+	}
+	path, err := gatewayLookPath(program)
+	if err != nil {
+		return "", fmt.Errorf("A gateway of type %s needs %s on this host: %s", gatewayType, program, err)
+	}
+	_, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(gatewayResourceName(name), metav1.GetOptions{})
+	if err == nil {
+		return "", fmt.Errorf("Gateway %s already exists", name)
+	} else if !errors.IsNotFound(err) {
+		return "", err
+	}
+	owner, err := getRootObject(cli)
+	if err != nil {
+		return "", err
+	}
+	token, _, err := cli.ConnectorTokenCreate(ctx, gatewayResourceName(name), "")
+	if err != nil {
+		return "", fmt.Errorf("Could not issue a certificate for gateway %s: %w", name, err)
+	}
+	host := token.ObjectMeta.Annotations["edge-host"]
+	port := token.ObjectMeta.Annotations["edge-port"]
+	if host == "" || port == "" {
+		return "", fmt.Errorf("The site has no edge endpoint for gateway %s to connect to", name)
+	}
+	dir, err := gatewayDir(name)
+	if err != nil {
+		return "", err
+	}
+	// a gateway that cannot be started is not left half set up
+	started := false
+	fail := func(err error) (string, error) {
+		if rollbackErr := cli.removeFailedGateway(name, gatewayType, dir, started); rollbackErr != nil {
+			return "", fmt.Errorf("%s (could not roll back: %s)", err, rollbackErr)
+		}
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "certs"), 0700); err != nil {
+		return fail(err)
+	}
+	for _, file := range []string{"ca.crt", "tls.crt", "tls.key"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "certs", file), token.Data[file], 0600); err != nil {
+			return fail(fmt.Errorf("Could not write certificates for gateway %s: %w", name, err))
+		}
+	}
+	definition := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: gatewayResourceName(name),
+			Labels: map[string]string{
+				types.SkupperTypeQualifier: types.TypeGatewayDefinition,
+			},
+			OwnerReferences: []metav1.OwnerReference{*owner},
+		},
+		Data: map[string]string{
+			gatewayTypeKey:     gatewayType,
+			gatewayUrlKey:      net.JoinHostPort(host, port),
+			gatewayBindingsKey: "[]",
+		},
+	}
+	definition, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Create(definition)
+	if err != nil {
+		return fail(fmt.Errorf("Could not create gateway %s: %w", name, err))
+	}
+	gateway, err := gatewayFromDefinition(definition)
+	if err != nil {
+		return fail(err)
+	}
+	if err := writeGatewayConfig(gateway, dir); err != nil {
+		return fail(err)
+	}
+	started = true
+	if err := startGateway(name, gatewayType, path, dir); err != nil {
+		return fail(err)
+	}
+	return name, nil
+}
+
+// removeFailedGateway undoes what GatewayInit set up for a gateway it
+// could not start: the router, if starting it was attempted, the local
+// directory and the gateway definition
+func (cli *VanClient) removeFailedGateway(name string, gatewayType string, dir string, started bool) error {
+	errs := []string{}
+	if started {
+		if err := stopGateway(name, gatewayType); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		errs = append(errs, err.Error())
+	}
+	err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Delete(gatewayResourceName(name), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func gatewayConfigFile(dir string) string {
+	return filepath.Join(dir, "config", types.TransportConfigFile)
+}
+
+func writeGatewayConfig(gateway *types.GatewayInspectResponse, dir string) error {
+	config, err := gatewayRouterConfig(gateway.Name, gateway.Url, filepath.Join(dir, "certs"), gateway.Bindings)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "config"), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(gatewayConfigFile(dir), []byte(config), 0600); err != nil {
+		return fmt.Errorf("Could not write config for gateway %s: %w", gateway.Name, err)
+	}
+	return nil
+}
+
+func startGateway(name string, gatewayType string, path string, dir string) error {
+	if gatewayType == types.GatewayTypePodman {
+		return gatewayCommand(path, "run", "--detach", "--restart", "always", "--name", gatewayResourceName(name), "--network", "host",
+			"--volume", dir+":"+dir+":z",
+			"--env", types.TransportEnvConfig+"="+gatewayConfigFile(dir),
+			"--env", "QDROUTERD_CONF_TYPE=json",
+			GetRouterImageName())
+	}
+	unitFile, err := gatewayUnitFile(name)
+	if err != nil {
+		return err
+	}
+	unit := fmt.Sprintf(`[Unit]
+Description=Skupper gateway %s
+After=network-online.target
+
+[Service]
+ExecStart=%s -c %s
+Restart=always
+
+[Install]
+WantedBy=default.target
+`, name, path, gatewayConfigFile(dir))
+	if err := os.MkdirAll(filepath.Dir(unitFile), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(unitFile, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("Could not write service for gateway %s: %w", name, err)
+	}
+	if err := gatewayCommand("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return gatewayCommand("systemctl", "--user", "enable", "--now", filepath.Base(unitFile))
+}
+
+func restartGateway(name string, gatewayType string) error {
+	if gatewayType == types.GatewayTypePodman {
+		return gatewayCommand("podman", "restart", gatewayResourceName(name))
+	}
+	return gatewayCommand("systemctl", "--user", "restart", gatewayResourceName(name)+".service")
+}
+
+// stopGateway stops the router of a gateway and removes what was set up
+// on the host to run it
+func stopGateway(name string, gatewayType string) error {
+	if gatewayType == types.GatewayTypePodman {
+		return gatewayCommand("podman", "rm", "--force", gatewayResourceName(name))
+	}
+	unitFile, err := gatewayUnitFile(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(unitFile); os.IsNotExist(err) {
+		return nil
+	}
+	if err := gatewayCommand("systemctl", "--user", "disable", "--now", filepath.Base(unitFile)); err != nil {
+		return err
+	}
+	if err := os.Remove(unitFile); err != nil {
+		return err
+	}
+	return gatewayCommand("systemctl", "--user", "daemon-reload")
+}
+
+// updateGatewayBindings records the bindings of a gateway and, on the host
+// of the gateway, restarts its router with them. On other hosts only the
+// definition is changed, which the gateway picks up when next changed on
+// its host.
+func (cli *VanClient) updateGatewayBindings(definition *corev1.ConfigMap, gateway *types.GatewayInspectResponse) error {
+	sort.Slice(gateway.Bindings, func(i, j int) bool {
+		return gateway.Bindings[i].Address < gateway.Bindings[j].Address
+	})
+	data, err := jsonencoding.Marshal(gateway.Bindings)
+	if err != nil {
+		return err
+	}
+	definition.Data[gatewayBindingsKey] = string(data)
+	if _, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(definition); err != nil {
+		return fmt.Errorf("Could not update gateway %s: %w", gateway.Name, err)
+	}
+	dir, err := gatewayDir(gateway.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "certs")); os.IsNotExist(err) {
+		return nil
+	}
+	if err := writeGatewayConfig(gateway, dir); err != nil {
+		return err
+	}
+	return restartGateway(gateway.Name, gateway.Type)
+}
+
+// GatewayBind makes the targets of a service reachable through a gateway,
+// at the host and ports of the binding. The ports default to those of
+// the service and the target ports to the ports.
+func (cli *VanClient) GatewayBind(ctx context.Context, name string, binding types.GatewayBinding) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return err
+	}
+	if name == "" {
+		name = defaultGatewayName()
+	}
+	if binding.Host == "" {
+		return fmt.Errorf("A host is required to bind %s to gateway %s", binding.Address, name)
+	}
+	definition, err := cli.getGatewayDefinition(name)
+	if err != nil {
+		return err
+	}
+	gateway, err := gatewayFromDefinition(definition)
+	if err != nil {
+		return err
+	}
+	service, err := cli.ServiceInterfaceInspect(ctx, binding.Address)
+	if err != nil {
+		return err
+	}
+	if service == nil {
+		return fmt.Errorf("Service %s not found, it can be created with 'skupper gateway expose'", binding.Address)
+	}
+	binding.Protocol = service.Protocol
+	servicePorts := map[int]bool{}
+	for _, port := range service.Ports {
+		servicePorts[port.Port] = true
+	}
+	if len(binding.Ports) == 0 {
+		for _, port := range service.Ports {
+			binding.Ports = append(binding.Ports, port.Port)
+		}
+	}
+	if len(binding.Ports) == 0 || binding.Ports[0] != service.PrimaryPort() {
+		return fmt.Errorf("The ports bound to gateway %s must start with port %d of service %s", name, service.PrimaryPort(), binding.Address)
+	}
+	for _, port := range binding.Ports {
+		if !servicePorts[port] {
+			return fmt.Errorf("Service %s has no port %d", binding.Address, port)
+		}
+	}
+	if len(binding.TargetPorts) == 0 {
+		binding.TargetPorts = binding.Ports
+	}
+	if len(binding.TargetPorts) != len(binding.Ports) {
+		return fmt.Errorf("Service %s is bound to gateway %s on %d ports but %d target ports were given", binding.Address, name, len(binding.Ports), len(binding.TargetPorts))
+	}
+	bindings := []types.GatewayBinding{binding}
+	for _, existing := range gateway.Bindings {
+		if existing.Address != binding.Address {
+			bindings = append(bindings, existing)
+		}
+	}
+	gateway.Bindings = bindings
+	return cli.updateGatewayBindings(definition, gateway)
+}
+
+// GatewayExpose binds a service to a gateway as GatewayBind does, first
+// creating the service if it does not exist
+func (cli *VanClient) GatewayExpose(ctx context.Context, name string, binding types.GatewayBinding) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return err
+	}
+	if name == "" {
+		name = defaultGatewayName()
+	}
+	if _, err := cli.getGatewayDefinition(name); err != nil {
+		return err
+	}
+	service, err := cli.ServiceInterfaceInspect(ctx, binding.Address)
+	if err != nil {
+		return err
+	}
+	if service == nil {
+		if len(binding.Ports) == 0 {
+			return fmt.Errorf("A port is required to expose %s through gateway %s", binding.Address, name)
+		}
+		service = &types.ServiceInterface{
+			Address:  binding.Address,
+			Protocol: binding.Protocol,
+		}
+		if service.Protocol == "" {
+			service.Protocol = "tcp"
+		}
+		for _, port := range binding.Ports {
+			service.Ports = append(service.Ports, types.ServicePort{Port: port})
+		}
+		if err := cli.ServiceInterfaceCreate(ctx, service); err != nil {
+			return err
+		}
+	} else if binding.Protocol != "" && binding.Protocol != service.Protocol {
+		return fmt.Errorf("Service %s already exists with protocol %s", binding.Address, service.Protocol)
+	}
+	return cli.GatewayBind(ctx, name, binding)
+}
+
+// GatewayUnbind stops a gateway from reaching the targets of a service
+func (cli *VanClient) GatewayUnbind(ctx context.Context, name string, address string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return err
+	}
+	if name == "" {
+		name = defaultGatewayName()
+	}
+	definition, err := cli.getGatewayDefinition(name)
+	if err != nil {
+		return err
+	}
+	gateway, err := gatewayFromDefinition(definition)
+	if err != nil {
+		return err
+	}
+	bindings := []types.GatewayBinding{}
+	for _, binding := range gateway.Bindings {
+		if binding.Address != address {
+			bindings = append(bindings, binding)
+		}
+	}
+	if len(bindings) == len(gateway.Bindings) {
+		return fmt.Errorf("Service %s is not bound to gateway %s", address, name)
+	}
+	gateway.Bindings = bindings
+	return cli.updateGatewayBindings(definition, gateway)
+}
+
+// GatewayRemove stops the router of a gateway, if run on its host, and
+// removes the gateway from the site. The services bound to it are left
+// in place.
+func (cli *VanClient) GatewayRemove(ctx context.Context, name string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return err
+	}
+	if name == "" {
+		name = defaultGatewayName()
+	}
+	definition, err := cli.getGatewayDefinition(name)
+	if err != nil {
+		return err
+	}
+	dir, err := gatewayDir(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		if err := stopGateway(name, definition.Data[gatewayTypeKey]); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	if err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Delete(definition.ObjectMeta.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("Could not remove gateway %s: %w", name, err)
+	}
+	return nil
+}
+
+// gatewaysConnected returns the gateways attached to the site, or none
+// if the network status cannot be retrieved
+func (cli *VanClient) gatewaysConnected(ctx context.Context) map[string]bool {
+	connected := map[string]bool{}
+	status, err := cli.NetworkStatus(ctx)
+	if err != nil {
+		return connected
+	}
+	for _, site := range status.Sites {
+		for _, id := range site.Gateways {
+			connected[id] = true
+		}
+	}
+	return connected
+}
+
+// GatewayInspect describes a gateway, including whether its router is
+// attached to the network
+func (cli *VanClient) GatewayInspect(ctx context.Context, name string) (*types.GatewayInspectResponse, error) {
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = defaultGatewayName()
+	}
+	definition, err := cli.getGatewayDefinition(name)
+	if err != nil {
+		return nil, err
+	}
+	gateway, err := gatewayFromDefinition(definition)
+	if err != nil {
+		return nil, err
+	}
+	gateway.Connected = cli.gatewaysConnected(ctx)[definition.ObjectMeta.Name]
+	return gateway, nil
+}
+
+// GatewayList describes each gateway of the site
+func (cli *VanClient) GatewayList(ctx context.Context) ([]*types.GatewayInspectResponse, error) {
+	if err := cli.checkCluster("Attaching gateways"); err != nil {
+		return nil, err
+	}
+	definitions, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).List(metav1.ListOptions{
+		LabelSelector: types.SkupperTypeQualifier + "=" + types.TypeGatewayDefinition,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve gateways: %w", err)
+	}
+	gateways := []*types.GatewayInspectResponse{}
+	if len(definitions.Items) == 0 {
+		return gateways, nil
+	}
+	connected := cli.gatewaysConnected(ctx)
+	for i := range definitions.Items {
+		gateway, err := gatewayFromDefinition(&definitions.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		gateway.Connected = connected[definitions.Items[i].ObjectMeta.Name]
+		gateways = append(gateways, gateway)
+	}
+	return gateways, nil
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestGatewayRouterConfig(t *testing.T) {
+	data, err := gatewayRouterConfig("vm1", "skupper-edge.example.com:45671", "/gateway/certs", []types.GatewayBinding{
+		{Address: "db", Protocol: "tcp", Host: "localhost", Ports: []int{5432}, TargetPorts: []int{15432}},
+		{Address: "web", Protocol: "http", Host: "10.0.0.1", Ports: []int{8080, 8443}, TargetPorts: []int{80, 443}},
+	})
+	assert.Assert(t, err)
+	config, err := qdr.UnmarshalRouterConfig(data)
+	assert.Assert(t, err)
+	assert.Equal(t, config.Metadata.Id, "skupper-gateway-vm1")
+	assert.Assert(t, config.IsEdge())
+	assert.Equal(t, config.GetSiteMetadata().Id, "")
+	assert.Equal(t, config.Connectors["uplink"].Host, "skupper-edge.example.com")
+	assert.Equal(t, config.Connectors["uplink"].Port, "45671")
+	assert.Equal(t, config.SslProfiles[gatewaySslProfile].CaCertFile, "/gateway/certs/ca.crt")
+	assert.DeepEqual(t, config.Bridges.TcpConnectors["db@localhost"], qdr.TcpEndpoint{Name: "db@localhost", Host: "localhost", Port: "15432", Address: "db"})
+	assert.Equal(t, config.Bridges.HttpConnectors["web@10.0.0.1"].Port, "80")
+	assert.Equal(t, config.Bridges.HttpConnectors["web:8443@10.0.0.1"].Port, "443")
+
+	_, err = gatewayRouterConfig("vm1", "skupper-edge.example.com:45671", "/gateway/certs", []types.GatewayBinding{
+		{Address: "db", Protocol: "udp", Host: "localhost", Ports: []int{5432}, TargetPorts: []int{5432}},
+	})
+	assert.ErrorContains(t, err, "Unrecognised protocol for service db: udp")
+}
+
+func TestGateway(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "gateway")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	for _, env := range []string{"XDG_DATA_HOME", "XDG_CONFIG_HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Setenv(env, dir)
+	}
+	commands := []string{}
+	defer func(lookPath func(string) (string, error), command func(string, ...string) error) {
+		gatewayLookPath = lookPath
+		gatewayCommand = command
+	}(gatewayLookPath, gatewayCommand)
+	gatewayLookPath = func(file string) (string, error) {
+		return "/usr/sbin/" + file, nil
+	}
+	gatewayCommand = func(name string, args ...string) error {
+		commands = append(commands, filepath.Base(name)+" "+strings.Join(args, " "))
+		return nil
+	}
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	_, err = cli.GatewayInit(ctx, "vm1", "docker")
+	assert.ErrorContains(t, err, `Invalid gateway type "docker"`)
+	name, err := cli.GatewayInit(ctx, "vm1", "")
+	assert.Assert(t, err)
+	assert.Equal(t, name, "vm1")
+	_, err = cli.GatewayInit(ctx, "vm1", "")
+	assert.ErrorContains(t, err, "Gateway vm1 already exists")
+	assert.DeepEqual(t, commands, []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now skupper-gateway-vm1.service",
+	})
+	unit, err := ioutil.ReadFile(filepath.Join(dir, "systemd", "user", "skupper-gateway-vm1.service"))
+	assert.Assert(t, err)
+	assert.Assert(t, strings.Contains(string(unit), "ExecStart=/usr/sbin/qdrouterd -c "+filepath.Join(dir, "skupper", "gateways", "vm1", "config", types.TransportConfigFile)))
+	certs, err := ioutil.ReadDir(filepath.Join(dir, "skupper", "gateways", "vm1", "certs"))
+	assert.Assert(t, err)
+	assert.Equal(t, len(certs), 3)
+
+	// expose creates the service, bind requires it
+	err = cli.GatewayBind(ctx, "vm1", types.GatewayBinding{Address: "db", Host: "localhost"})
+	assert.ErrorContains(t, err, "Service db not found")
+	err = cli.GatewayExpose(ctx, "vm1", types.GatewayBinding{Address: "db", Host: "localhost", Ports: []int{5432}, TargetPorts: []int{15432}})
+	assert.Assert(t, err)
+	service, err := cli.ServiceInterfaceInspect(ctx, "db")
+	assert.Assert(t, err)
+	assert.Equal(t, service.Protocol, "tcp")
+	assert.Assert(t, cli.ServiceInterfaceCreate(ctx, &types.ServiceInterface{Address: "web", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}}))
+	err = cli.GatewayBind(ctx, "vm1", types.GatewayBinding{Address: "web", Host: "10.0.0.1", Ports: []int{9090}})
+	assert.ErrorContains(t, err, "must start with port 8080")
+	assert.Assert(t, cli.GatewayBind(ctx, "vm1", types.GatewayBinding{Address: "web", Host: "10.0.0.1"}))
+	assert.Equal(t, commands[len(commands)-1], "systemctl --user restart skupper-gateway-vm1.service")
+
+	gateway, err := cli.GatewayInspect(ctx, "vm1")
+	assert.Assert(t, err)
+	assert.Equal(t, gateway.Type, types.GatewayTypeService)
+	assert.Assert(t, !gateway.Connected)
+	assert.DeepEqual(t, gateway.Bindings, []types.GatewayBinding{
+		{Address: "db", Protocol: "tcp", Host: "localhost", Ports: []int{5432}, TargetPorts: []int{15432}},
+		{Address: "web", Protocol: "http", Host: "10.0.0.1", Ports: []int{8080}, TargetPorts: []int{8080}},
+	})
+	data, err := ioutil.ReadFile(filepath.Join(dir, "skupper", "gateways", "vm1", "config", types.TransportConfigFile))
+	assert.Assert(t, err)
+	config, err := qdr.UnmarshalRouterConfig(string(data))
+	assert.Assert(t, err)
+	assert.Equal(t, len(config.Bridges.TcpConnectors), 1)
+	assert.Equal(t, len(config.Bridges.HttpConnectors), 1)
+
+	assert.Assert(t, cli.GatewayUnbind(ctx, "vm1", "db"))
+	assert.ErrorContains(t, cli.GatewayUnbind(ctx, "vm1", "db"), "Service db is not bound to gateway vm1")
+	gateways, err := cli.GatewayList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, len(gateways), 1)
+	assert.Equal(t, len(gateways[0].Bindings), 1)
+
+	assert.Assert(t, cli.GatewayRemove(ctx, "vm1"))
+	assert.DeepEqual(t, commands[len(commands)-2:], []string{
+		"systemctl --user disable --now skupper-gateway-vm1.service",
+		"systemctl --user daemon-reload",
+	})
+	_, err = os.Stat(filepath.Join(dir, "skupper", "gateways", "vm1"))
+	assert.Assert(t, os.IsNotExist(err))
+	gateways, err = cli.GatewayList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, len(gateways), 0)
+	// the services are left in place
+	service, err = cli.ServiceInterfaceInspect(ctx, "db")
+	assert.Assert(t, err)
+	assert.Assert(t, service != nil)
+	assert.ErrorContains(t, cli.GatewayRemove(ctx, "vm1"), "Gateway vm1 not found")
+}
+
+func TestGatewayInitRollback(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "gateway")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	for _, env := range []string{"XDG_DATA_HOME", "XDG_CONFIG_HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Setenv(env, dir)
+	}
+	commands := []string{}
+	defer func(lookPath func(string) (string, error), command func(string, ...string) error) {
+		gatewayLookPath = lookPath
+		gatewayCommand = command
+	}(gatewayLookPath, gatewayCommand)
+	gatewayLookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+	gatewayCommand = func(name string, args ...string) error {
+		commands = append(commands, filepath.Base(name)+" "+strings.Join(args, " "))
+		if args[0] == "run" {
+			return fmt.Errorf("image not found")
+		}
+		return nil
+	}
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	_, err = cli.GatewayInit(ctx, "vm1", types.GatewayTypePodman)
+	assert.Error(t, err, "image not found")
+	assert.Equal(t, commands[len(commands)-1], "podman rm --force skupper-gateway-vm1")
+	_, err = os.Stat(filepath.Join(dir, "skupper", "gateways", "vm1"))
+	assert.Assert(t, os.IsNotExist(err))
+	gateways, err := cli.GatewayList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, len(gateways), 0)
+}
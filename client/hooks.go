@@ -0,0 +1,175 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	hookTimeout     time.Duration = 5 * time.Minute
+	hookJobTemplate string        = "job"
+)
+
+// The body posted to a webhook; the same values are passed to a hook
+// job as environment variables
+type HookEvent struct {
+	Hook      string `json:"hook"`
+	Namespace string `json:"namespace"`
+	Subject   string `json:"subject"`
+}
+
+func (cli *VanClient) getHooks(ctx context.Context, namespace string) (map[string]string, error) {
+	siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if siteConfig == nil {
+		return nil, nil
+	}
+	return siteConfig.Spec.Hooks, nil
+}
+
+// runHook calls the webhook or runs the job configured for the hook, if
+// any, returning an error if it did not complete successfully
+func (cli *VanClient) runHook(ctx context.Context, hooks map[string]string, hook string, namespace string, subject string) error {
+	ref, ok := hooks[hook]
+	if !ok {
+		return nil
+	}
+	if cli.planner != nil {
+		cli.planner.add("run", "hook", hook, ref)
+		return nil
+	}
+	event := HookEvent{
+		Hook:      hook,
+		Namespace: namespace,
+		Subject:   subject,
+	}
+	var err error
+	if strings.HasPrefix(ref, types.HookJobPrefix) {
+		err = cli.runHookJob(ctx, strings.TrimPrefix(ref, types.HookJobPrefix), event)
+	} else {
+		err = callWebhook(ctx, ref, event)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", hook, err)
+	}
+	return nil
+}
+
+func callWebhook(ctx context.Context, url string, event HookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("Webhook %s returned %s", url, response.Status)
+	}
+	return nil
+}
+
+func getHookJob(template *corev1.ConfigMap, event HookEvent) (*batchv1.Job, error) {
+	data, ok := template.Data[hookJobTemplate]
+	if !ok {
+		return nil, fmt.Errorf("ConfigMap %s has no %q key", template.Name, hookJobTemplate)
+	}
+	job := &batchv1.Job{}
+	err := yaml.Unmarshal([]byte(data), job)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid job template in %s: %s", template.Name, err)
+	}
+	job.ObjectMeta.Name = ""
+	job.ObjectMeta.GenerateName = template.Name + "-"
+	job.ObjectMeta.Namespace = ""
+	if job.ObjectMeta.Labels == nil {
+		job.ObjectMeta.Labels = map[string]string{}
+	}
+	job.ObjectMeta.Labels["skupper.io/hook"] = event.Hook
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	env := []corev1.EnvVar{
+		{Name: "SKUPPER_HOOK", Value: event.Hook},
+		{Name: "SKUPPER_NAMESPACE", Value: event.Namespace},
+		{Name: "SKUPPER_HOOK_SUBJECT", Value: event.Subject},
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, env...)
+	}
+	return job, nil
+}
+
+func jobFinished(job *batchv1.Job) (bool, error) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		if c.Type == batchv1.JobComplete {
+			return true, nil
+		}
+		if c.Type == batchv1.JobFailed {
+			return true, fmt.Errorf("Job %s failed: %s", job.Name, c.Message)
+		}
+	}
+	return false, nil
+}
+
+func (cli *VanClient) runHookJob(ctx context.Context, templateName string, event HookEvent) error {
+	template, err := kube.GetConfigMap(templateName, event.Namespace, cli.KubeClient)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("Job template %s not found", templateName)
+	} else if err != nil {
+		return err
+	}
+	job, err := getHookJob(template, event)
+	if err != nil {
+		return err
+	}
+	job, err = cli.KubeClient.BatchV1().Jobs(event.Namespace).Create(job)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	var jobErr error
+	err = utils.RetryWithContext(ctx, time.Second*2, func() (bool, error) {
+		current, err := cli.KubeClient.BatchV1().Jobs(event.Namespace).Get(job.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		done, err := jobFinished(current)
+		jobErr = err
+		return done, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Job %s did not complete: %s", job.Name, err)
+	}
+	return jobErr
+}
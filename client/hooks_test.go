@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestCheckHooks(t *testing.T) {
+	tests := []struct {
+		hooks map[string]string
+		err   string
+	}{
+		{nil, ""},
+		{map[string]string{types.HookPreUpdate: "https://cmdb.example.com/skupper", types.HookPostExpose: "job:warm-cache"}, ""},
+		{map[string]string{"pre-delete": "https://example.com"}, `Invalid hook "pre-delete", must be one of [pre-update post-update pre-expose post-expose]`},
+		{map[string]string{types.HookPreExpose: "job:"}, "No job template specified for pre-expose hook"},
+		{map[string]string{types.HookPreExpose: "my-job"}, `Invalid value for pre-expose hook: "my-job" must be a url or job:<configmap>`},
+	}
+	for _, test := range tests {
+		spec := types.SiteConfigSpec{Hooks: test.hooks}
+		err := spec.CheckHooks()
+		if test.err == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.Error(t, err, test.err)
+		}
+	}
+}
+
+func TestCallWebhook(t *testing.T) {
+	received := HookEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		if received.Subject == "rejected" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	cli := &VanClient{}
+	hooks := map[string]string{types.HookPreExpose: server.URL}
+	err := cli.runHook(context.Background(), hooks, types.HookPreExpose, "test", "myservice")
+	assert.NilError(t, err)
+	assert.Equal(t, received, HookEvent{Hook: types.HookPreExpose, Namespace: "test", Subject: "myservice"})
+
+	err = cli.runHook(context.Background(), hooks, types.HookPreExpose, "test", "rejected")
+	assert.ErrorContains(t, err, "pre-expose hook failed: Webhook "+server.URL+" returned 403 Forbidden")
+
+	// hooks that are not configured are skipped
+	err = cli.runHook(context.Background(), hooks, types.HookPostExpose, "test", "rejected")
+	assert.NilError(t, err)
+}
+
+func TestGetHookJob(t *testing.T) {
+	template := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "compliance-check",
+		},
+		Data: map[string]string{
+			"job": `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: ignored
+spec:
+  template:
+    spec:
+      containers:
+      - name: check
+        image: quay.io/example/check
+`,
+		},
+	}
+	job, err := getHookJob(template, HookEvent{Hook: types.HookPreUpdate, Namespace: "test", Subject: "1.0"})
+	assert.NilError(t, err)
+	assert.Equal(t, job.Name, "")
+	assert.Equal(t, job.GenerateName, "compliance-check-")
+	assert.Equal(t, job.Labels["skupper.io/hook"], types.HookPreUpdate)
+	assert.Equal(t, job.Spec.Template.Spec.RestartPolicy, corev1.RestartPolicyNever)
+	assert.DeepEqual(t, job.Spec.Template.Spec.Containers[0].Env, []corev1.EnvVar{
+		{Name: "SKUPPER_HOOK", Value: types.HookPreUpdate},
+		{Name: "SKUPPER_NAMESPACE", Value: "test"},
+		{Name: "SKUPPER_HOOK_SUBJECT", Value: "1.0"},
+	})
+
+	_, err = getHookJob(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "empty"}}, HookEvent{})
+	assert.Error(t, err, `ConfigMap empty has no "job" key`)
+}
+
+func TestJobFinished(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "hook-abc"}}
+	done, err := jobFinished(job)
+	assert.Assert(t, !done)
+	assert.NilError(t, err)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	done, err = jobFinished(job)
+	assert.Assert(t, done)
+	assert.NilError(t, err)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	done, err = jobFinished(job)
+	assert.Assert(t, done)
+	assert.Error(t, err, "Job hook-abc failed: BackoffLimitExceeded")
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+var cosignCommand = "cosign"
+
+func verifyImageSignature(image string, options types.ImageVerificationSpec) error {
+	args := []string{"verify"}
+	if options.PublicKey != "" {
+		args = append(args, "--key", options.PublicKey)
+	} else if options.Issuer == "" {
+		return fmt.Errorf("Could not verify signature for image %s: keyless verification needs the issuer of the certificate identity", image)
+	} else {
+		args = append(args, "--certificate-identity", options.Identity, "--certificate-oidc-issuer", options.Issuer)
+	}
+	args = append(args, image)
+	_, err := runCommand(cosignCommand, args...)
+	if err != nil {
+		return fmt.Errorf("Could not verify signature for image %s: %s", image, err)
+	}
+	return nil
+}
+
+// verifyImages checks the signatures of the supplied images when
+// verification is configured for the site. Failures are only reported
+// as warnings, through the events of the client, unless strict mode is
+// enabled. Clients that do not verify images skip the check, as the site
+// controller has no cosign and the key is a path on the host of the CLI.
+func (cli *VanClient) verifyImages(options types.ImageVerificationSpec, images ...string) error {
+	if !cli.VerifyImages || !options.IsEnabled() {
+		return nil
+	}
+	for _, image := range images {
+		err := verifyImageSignature(image, options)
+		if err != nil {
+			if options.Strict {
+				return err
+			}
+			cli.warn("verify", err.Error())
+		}
+	}
+	return nil
+}
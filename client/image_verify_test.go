@@ -0,0 +1,75 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestVerifyImages(t *testing.T) {
+	original := cosignCommand
+	defer func() { cosignCommand = original }()
+	cosignCommand = "./cosign-not-installed"
+
+	image := "quay.io/skupper/service-controller:test"
+	testcases := []struct {
+		doc           string
+		options       types.ImageVerificationSpec
+		skip          bool
+		expectedError string
+	}{
+		{
+			doc: "not configured",
+		},
+		{
+			doc:     "client does not verify",
+			options: types.ImageVerificationSpec{PublicKey: "cosign.pub", Strict: true},
+			skip:    true,
+		},
+		{
+			doc:     "warn only",
+			options: types.ImageVerificationSpec{PublicKey: "cosign.pub"},
+		},
+		{
+			doc:           "strict with key",
+			options:       types.ImageVerificationSpec{PublicKey: "cosign.pub", Strict: true},
+			expectedError: "Could not verify signature for image " + image,
+		},
+		{
+			doc:           "strict keyless",
+			options:       types.ImageVerificationSpec{Identity: "release@skupper.io", Issuer: "https://accounts.google.com", Strict: true},
+			expectedError: "Could not verify signature for image " + image,
+		},
+		{
+			doc:           "identity without issuer",
+			options:       types.ImageVerificationSpec{Identity: "release@skupper.io", Strict: true},
+			expectedError: "keyless verification needs the issuer of the certificate identity",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.doc, func(t *testing.T) {
+			warnings := []string{}
+			cli := &VanClient{
+				VerifyImages: !c.skip,
+				Events: func(event types.ClientEvent) {
+					assert.Equal(t, event.Type, types.ClientEventWarning)
+					warnings = append(warnings, event.Message)
+				},
+			}
+			err := cli.verifyImages(c.options, image)
+			if c.expectedError == "" {
+				assert.Assert(t, err)
+				if c.options.IsEnabled() && !c.skip {
+					assert.Equal(t, len(warnings), 1)
+					assert.Assert(t, strings.Contains(warnings[0], "Could not verify signature for image "+image), warnings[0])
+				} else {
+					assert.Equal(t, len(warnings), 0)
+				}
+			} else {
+				assert.ErrorContains(t, err, c.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+var (
+	ErrLinksNotAccepted  = errors.New("site does not accept links")
+	ErrLinksNotInitiated = errors.New("site does not create links")
+)
+
+// IsLinkDirectionRejected returns true if the error is due to the site
+// being restricted to links in the other direction
+func IsLinkDirectionRejected(err error) bool {
+	return errors.Is(err, ErrLinksNotAccepted) || errors.Is(err, ErrLinksNotInitiated)
+}
+
+// checkLinkDirection returns an error if the site in the namespace is
+// restricted to links in the other direction
+func (cli *VanClient) checkLinkDirection(ctx context.Context, namespace string, inbound bool) error {
+	siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	if err != nil || siteConfig == nil {
+		return err
+	}
+	if inbound && !siteConfig.Spec.AcceptsLinks() && siteConfig.Spec.RouterMode != string(types.TransportModeEdge) {
+		return fmt.Errorf("%w (link-direction is %s)", ErrLinksNotAccepted, siteConfig.Spec.LinkDirection)
+	}
+	if !inbound && !siteConfig.Spec.InitiatesLinks() {
+		return fmt.Errorf("%w (link-direction is %s)", ErrLinksNotInitiated, siteConfig.Spec.LinkDirection)
+	}
+	return nil
+}
+
+// checkHeadlessSupported returns an error for a headless service in a
+// site that does not accept links, as the proxies for headless services
+// link to the router of the site
+func (cli *VanClient) checkHeadlessSupported(ctx context.Context, service *types.ServiceInterface) error {
+	if service.Headless == nil {
+		return nil
+	}
+	if err := cli.checkLinkDirection(ctx, cli.Namespace, true); err != nil {
+		return fmt.Errorf("Headless services cannot be exposed: %w", err)
+	}
+	return nil
+}
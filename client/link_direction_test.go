@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestCheckLinkDirection(t *testing.T) {
+	spec := types.SiteConfigSpec{RouterMode: string(types.TransportModeInterior)}
+	assert.Assert(t, spec.CheckLinkDirection())
+	assert.Assert(t, spec.AcceptsLinks() && spec.InitiatesLinks())
+
+	spec.LinkDirection = "sideways"
+	assert.Error(t, spec.CheckLinkDirection(), "Invalid value for link-direction: sideways")
+
+	spec.LinkDirection = types.LinkDirectionInbound
+	assert.Assert(t, spec.CheckLinkDirection())
+	assert.Assert(t, spec.AcceptsLinks() && !spec.InitiatesLinks())
+	spec.RouterMode = string(types.TransportModeEdge)
+	assert.Error(t, spec.CheckLinkDirection(), "An edge site cannot accept links, so must be able to create them")
+
+	spec.RouterMode = string(types.TransportModeInterior)
+	spec.LinkDirection = types.LinkDirectionOutbound
+	spec.Ingress = types.IngressLoadBalancerString
+	assert.Error(t, spec.CheckLinkDirection(), "A site that does not accept links cannot have ingress")
+	spec.Ingress = types.IngressNoneString
+	assert.Assert(t, spec.CheckLinkDirection())
+	assert.Assert(t, !spec.AcceptsLinks() && spec.InitiatesLinks())
+	spec.Replicas = 2
+	assert.Error(t, spec.CheckLinkDirection(), "The replicas of a router that does not accept links cannot link to each other")
+}
+
+func TestOutboundSiteRouterSpec(t *testing.T) {
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	van := cli.GetRouterSpecFromOpts(types.SiteConfigSpec{
+		SkupperName:   "skupper",
+		RouterMode:    string(types.TransportModeInterior),
+		Ingress:       types.IngressNoneString,
+		LinkDirection: types.LinkDirectionOutbound,
+	}, "site-id")
+	config, err := qdr.UnmarshalRouterConfig(van.RouterConfig)
+	assert.Assert(t, err)
+	for _, listener := range config.Listeners {
+		assert.Assert(t, listener.Role != qdr.RoleInterRouter && listener.Role != qdr.RoleEdge, "unexpected listener %s", listener.Name)
+	}
+	for _, port := range van.Transport.Ports {
+		assert.Assert(t, port.Name != types.InterRouterRole && port.Name != types.EdgeRole, "unexpected port %s", port.Name)
+	}
+	for _, service := range van.Transport.Services {
+		assert.Assert(t, service.ObjectMeta.Name != types.TransportServiceName)
+	}
+}
+
+func configureSiteWithLinkDirection(t *testing.T, cli *VanClient, direction string) {
+	ctx := context.Background()
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:       "skupper",
+		RouterMode:        string(types.TransportModeInterior),
+		EnableController:  true,
+		EnableServiceSync: true,
+		Ingress:           types.IngressNoneString,
+		LinkDirection:     direction,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+}
+
+func TestLinkDirectionEnforced(t *testing.T) {
+	ctx := context.Background()
+
+	outbound, err := newMockClient("outbound", "", "")
+	assert.Assert(t, err)
+	configureSiteWithLinkDirection(t, outbound, types.LinkDirectionOutbound)
+	_, _, err = outbound.ConnectorTokenCreate(ctx, "conn1", "outbound")
+	assert.Assert(t, errors.Is(err, ErrLinksNotAccepted), err)
+	assert.Assert(t, IsLinkDirectionRejected(err))
+	err = outbound.ServiceInterfaceCreate(ctx, &types.ServiceInterface{
+		Address:  "db",
+		Protocol: "tcp",
+		Ports:    []types.ServicePort{{Port: 5432}},
+		Headless: &types.Headless{Name: "db", Size: 1, TargetPort: 5432},
+	})
+	assert.Assert(t, errors.Is(err, ErrLinksNotAccepted), err)
+
+	inbound, err := newMockClient("inbound", "", "")
+	assert.Assert(t, err)
+	configureSiteWithLinkDirection(t, inbound, types.LinkDirectionInbound)
+	token, _, err := inbound.ConnectorTokenCreate(ctx, "conn1", "inbound")
+	assert.Assert(t, err)
+
+	// an outbound site can link to an inbound one, but not the reverse
+	assert.Assert(t, outbound.ConnectorCreate(ctx, token, types.ConnectorCreateOptions{Name: "link1", SkupperNamespace: "outbound"}))
+	err = inbound.ConnectorCreate(ctx, token, types.ConnectorCreateOptions{Name: "link1", SkupperNamespace: "inbound"})
+	assert.Assert(t, errors.Is(err, ErrLinksNotInitiated), err)
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// The credentials for the proxy of a link are kept in its secret
+const (
+	linkProxyUserKey     string = "proxy-user"
+	linkProxyPasswordKey string = "proxy-password"
+)
+
+// LinkProxyServiceName is the service through which the router reaches
+// the service controller, which forwards the connections of the link to
+// its proxy
+func LinkProxyServiceName(link string) string {
+	return "skupper-proxy-" + link
+}
+
+// GetLinkProxy returns the proxy recorded on the secret of a link, which
+// is empty if the link does not use one
+func GetLinkProxy(secret *corev1.Secret) (types.LinkProxy, error) {
+	proxy := types.LinkProxy{}
+	address, ok := secret.ObjectMeta.Annotations[types.LinkProxyAnnotation]
+	if !ok || address == "" {
+		return proxy, nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return proxy, fmt.Errorf("Invalid proxy on %s: %s", secret.ObjectMeta.Name, err)
+	}
+	proxy.Host = host
+	proxy.Port = port
+	proxy.User = string(secret.Data[linkProxyUserKey])
+	proxy.Password = string(secret.Data[linkProxyPasswordKey])
+	if err := types.CheckLinkProxy(proxy); err != nil {
+		return proxy, fmt.Errorf("Invalid proxy on %s: %s", secret.ObjectMeta.Name, err)
+	}
+	return proxy, nil
+}
+
+// setLinkProxy records the proxy on the secret of a link, returning true
+// if that changed it
+func setLinkProxy(secret *corev1.Secret, proxy types.LinkProxy) bool {
+	current, err := GetLinkProxy(secret)
+	if err == nil && current == proxy {
+		return false
+	}
+	if secret.ObjectMeta.Annotations == nil {
+		secret.ObjectMeta.Annotations = map[string]string{}
+	}
+	secret.ObjectMeta.Annotations[types.LinkProxyAnnotation] = proxy.Address()
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	delete(secret.Data, linkProxyUserKey)
+	delete(secret.Data, linkProxyPasswordKey)
+	if proxy.User != "" {
+		secret.Data[linkProxyUserKey] = []byte(proxy.User)
+	}
+	if proxy.Password != "" {
+		secret.Data[linkProxyPasswordKey] = []byte(proxy.Password)
+	}
+	return true
+}
+
+// linkProxyService returns the cluster IP of the service through which the
+// router makes the link, creating it if needed. Its port is that of the
+// target, so that only the host of the link has to be resolved to the
+// service; the service controller sets the target port to the one it
+// forwards connections from.
+func (cli *VanClient) linkProxyService(namespace string, secret *corev1.Secret, host string, port string) (string, error) {
+	if net.ParseIP(host) != nil {
+		return "", fmt.Errorf("Link %s cannot use a proxy as the token gives an IP address rather than a host name", secret.ObjectMeta.Name)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("Invalid port for link %s: %q", secret.ObjectMeta.Name, port)
+	}
+	target := net.JoinHostPort(host, port)
+	name := LinkProxyServiceName(secret.ObjectMeta.Name)
+	services := cli.KubeClient.CoreV1().Services(namespace)
+	service, err := services.Get(name, metav1.GetOptions{})
+	if err == nil {
+		if service.ObjectMeta.Annotations[types.LinkProxyTargetAnnotation] != target || service.Spec.Ports[0].Port != int32(portNumber) {
+			service.ObjectMeta.Annotations[types.LinkProxyTargetAnnotation] = target
+			service.Spec.Ports[0].Port = int32(portNumber)
+			service, err = services.Update(service)
+			if err != nil {
+				return "", err
+			}
+		}
+		return service.Spec.ClusterIP, nil
+	} else if !errors.IsNotFound(err) {
+		return "", err
+	}
+	controller, err := kube.GetDeployment(types.ControllerDeploymentName, namespace, cli.KubeClient)
+	if err != nil {
+		return "", fmt.Errorf("Links through a proxy need the service controller: %w", err)
+	}
+	owner := secret
+	if owner.ObjectMeta.UID == "" {
+		// the garbage collector removes anything owned by an unknown uid
+		owner, err = cli.KubeClient.CoreV1().Secrets(namespace).Get(secret.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+	}
+	service = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				types.SkupperTypeQualifier: types.TypeLinkProxy,
+			},
+			Annotations: map[string]string{
+				types.LinkProxyTargetAnnotation: target,
+				types.LinkProxyLinkAnnotation:   secret.ObjectMeta.Name,
+			},
+			// removed along with the link
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "Secret",
+					Name:       owner.ObjectMeta.Name,
+					UID:        owner.ObjectMeta.UID,
+				},
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: controller.Spec.Selector.MatchLabels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "link",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(portNumber),
+					TargetPort: intstr.FromInt(portNumber),
+				},
+			},
+		},
+	}
+	service, err = services.Create(service)
+	if err != nil {
+		return "", fmt.Errorf("Could not create service for the proxy of link %s: %w", secret.ObjectMeta.Name, err)
+	}
+	return service.Spec.ClusterIP, nil
+}
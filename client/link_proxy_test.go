@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestCheckLinkProxy(t *testing.T) {
+	assert.NilError(t, types.CheckLinkProxy(types.LinkProxy{}))
+	assert.NilError(t, types.CheckLinkProxy(types.LinkProxy{Host: "proxy.example.com", Port: "3128", User: "alice", Password: "secret"}))
+	assert.ErrorContains(t, types.CheckLinkProxy(types.LinkProxy{Host: "proxy.example.com", Port: "http"}), "Invalid value for proxy port")
+	assert.ErrorContains(t, types.CheckLinkProxy(types.LinkProxy{Host: "proxy.example.com", Port: "3128", Password: "secret"}), "only be given with a proxy user")
+	assert.ErrorContains(t, types.CheckLinkProxy(types.LinkProxy{User: "alice"}), "without a proxy host")
+}
+
+func TestLinkProxy(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "link1"},
+	}
+	proxy, err := GetLinkProxy(secret)
+	assert.NilError(t, err)
+	assert.Assert(t, proxy.IsEmpty())
+
+	desired := types.LinkProxy{Host: "proxy.example.com", Port: "3128", User: "alice", Password: "secret"}
+	assert.Assert(t, setLinkProxy(secret, desired))
+	assert.Assert(t, !setLinkProxy(secret, desired))
+	assert.Equal(t, secret.ObjectMeta.Annotations[types.LinkProxyAnnotation], "proxy.example.com:3128")
+	proxy, err = GetLinkProxy(secret)
+	assert.NilError(t, err)
+	assert.Equal(t, proxy, desired)
+
+	// credentials that are no longer needed are removed
+	assert.Assert(t, setLinkProxy(secret, types.LinkProxy{Host: "proxy.example.com", Port: "8080"}))
+	_, ok := secret.Data[linkProxyPasswordKey]
+	assert.Assert(t, !ok)
+
+	secret.ObjectMeta.Annotations[types.LinkProxyAnnotation] = "proxy.example.com"
+	_, err = GetLinkProxy(secret)
+	assert.ErrorContains(t, err, "Invalid proxy on link1")
+}
+
+func TestLinkProxyService(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	_, err = cli.linkProxyService(cli.Namespace, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "link1"}}, "east.example.com", "55671")
+	assert.ErrorContains(t, err, "need the service controller")
+
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "link1", UID: "1234"},
+	})
+	assert.Assert(t, err)
+
+	_, err = cli.linkProxyService(cli.Namespace, secret, "10.0.0.1", "55671")
+	assert.ErrorContains(t, err, "IP address rather than a host name")
+	_, err = cli.linkProxyService(cli.Namespace, secret, "east.example.com", "55671")
+	assert.Assert(t, err)
+	service, err := cli.KubeClient.CoreV1().Services(cli.Namespace).Get(LinkProxyServiceName("link1"), metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, service.ObjectMeta.Annotations[types.LinkProxyTargetAnnotation], "east.example.com:55671")
+	assert.Equal(t, service.Spec.Ports[0].Port, int32(55671))
+	assert.Equal(t, service.Spec.Selector["skupper.io/component"], types.ControllerComponentName)
+	assert.Equal(t, string(service.ObjectMeta.OwnerReferences[0].UID), "1234")
+
+	_, err = cli.linkProxyService(cli.Namespace, secret, "east.example.com", "45671")
+	assert.Assert(t, err)
+	service, err = cli.KubeClient.CoreV1().Services(cli.Namespace).Get(LinkProxyServiceName("link1"), metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, service.ObjectMeta.Annotations[types.LinkProxyTargetAnnotation], "east.example.com:45671")
+	assert.Equal(t, service.Spec.Ports[0].Port, int32(45671))
+}
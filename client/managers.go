@@ -0,0 +1,55 @@
+package client
+
+import (
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// The VanClient is the facade through which the whole of a site is
+// managed, and so implements each of the managers it is composed of
+var (
+	_ types.VanClientInterface = &VanClient{}
+	_ types.SiteManager        = &VanClient{}
+	_ types.LinkManager        = &VanClient{}
+	_ types.ServiceManager     = &VanClient{}
+	_ types.UpdateManager      = &VanClient{}
+)
+
+// NewSiteManager returns a client that creates, configures and removes
+// the site in the namespace of the options
+func NewSiteManager(options ClientOptions) (types.SiteManager, error) {
+	cli, err := NewClientWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
+// NewLinkManager returns a client that issues tokens for the site in the
+// namespace of the options and links it to other sites
+func NewLinkManager(options ClientOptions) (types.LinkManager, error) {
+	cli, err := NewClientWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
+// NewServiceManager returns a client that defines and binds the services
+// of the site in the namespace of the options
+func NewServiceManager(options ClientOptions) (types.ServiceManager, error) {
+	cli, err := NewClientWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
+// NewUpdateManager returns a client that updates the site in the
+// namespace of the options
+func NewUpdateManager(options ClientOptions) (types.UpdateManager, error) {
+	cli, err := NewClientWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
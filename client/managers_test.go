@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestManagers(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "managers")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", dir)
+	defer func(lookPath func(string) (string, error), command func(string, ...string) error) {
+		podmanLookPath = lookPath
+		podmanCommand = command
+	}(podmanLookPath, podmanCommand)
+	podmanLookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+	podmanCommand = func(name string, args ...string) error {
+		return nil
+	}
+
+	sites, err := NewSiteManager(ClientOptions{Platform: "nomad"})
+	assert.ErrorContains(t, err, `Invalid platform "nomad"`)
+	assert.Assert(t, sites == nil)
+
+	// each manager works with the site on its own, as created by another
+	options := ClientOptions{Namespace: "east", Platform: types.PlatformPodman}
+	sites, err = NewSiteManager(options)
+	assert.Assert(t, err)
+	siteConfig, err := sites.SiteConfigCreate(ctx, types.SiteConfigSpec{IngressHost: "east.example.com"})
+	assert.Assert(t, err)
+	assert.Assert(t, sites.RouterCreate(ctx, *siteConfig))
+
+	links, err := NewLinkManager(options)
+	assert.Assert(t, err)
+	token, _, err := links.ConnectorTokenCreate(ctx, "west", "")
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-host"], "east.example.com")
+
+	services, err := NewServiceManager(options)
+	assert.Assert(t, err)
+	service := &types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}}
+	assert.Assert(t, services.ServiceInterfaceBind(ctx, service, HostTargetType, "localhost", "", []int{15432}))
+	found, err := services.ServiceInterfaceInspect(ctx, "db")
+	assert.Assert(t, err)
+	assert.Equal(t, found.Address, "db")
+
+	updates, err := NewUpdateManager(options)
+	assert.Assert(t, err)
+	assert.Assert(t, updates != nil)
+
+	router, err := sites.RouterInspect(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, router.Status.SiteName, "east")
+	assert.Assert(t, sites.SiteConfigRemove(ctx))
+	_, err = services.ServiceInterfaceList(ctx)
+	assert.ErrorContains(t, err, "Skupper not initialised in east")
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+var apiServiceResource = schema.GroupVersionResource{
+	Group:    "apiregistration.k8s.io",
+	Version:  "v1",
+	Resource: "apiservices",
+}
+
+// The APIService through which the aggregation layer proxies requests
+// for the custom metrics API
+var metricsApiServiceName = types.MetricsApiVersion + "." + types.MetricsApiGroup
+
+var metricsApiLabels = map[string]string{
+	"skupper.io/component": types.ControllerComponentName,
+}
+
+// The bindings that let the controller authenticate and authorize the
+// requests proxied to it are outside the namespace of the site, so are
+// qualified by it
+func (cli *VanClient) metricsApiBindingName() string {
+	return types.MetricsApiServiceName + "-" + cli.Namespace
+}
+
+// servedBy returns the namespace and name of the service an APIService
+// proxies requests to, which are empty if the apiserver handles them
+func servedBy(apiService *unstructured.Unstructured) (string, string) {
+	namespace, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "namespace")
+	name, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "name")
+	return namespace, name
+}
+
+// MetricsApiRegister registers the custom metrics API served by the
+// service controller of the site with the API aggregation layer. This
+// requires permission to manage cluster level resources, and only one
+// provider of the custom metrics API can be registered in a cluster.
+func (cli *VanClient) MetricsApiRegister(ctx context.Context) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.DynamicClient == nil {
+		return fmt.Errorf("Registering the metrics API is not supported by this client")
+	}
+	ca, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(types.LocalCaSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not retrieve the certificate authority of the site: %w", err)
+	}
+	apiService := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiServiceResource.GroupVersion().String(),
+			"kind":       "APIService",
+			"metadata": map[string]interface{}{
+				"name": metricsApiServiceName,
+			},
+			"spec": map[string]interface{}{
+				"group":   types.MetricsApiGroup,
+				"version": types.MetricsApiVersion,
+				"service": map[string]interface{}{
+					"namespace": cli.Namespace,
+					"name":      types.MetricsApiServiceName,
+					"port":      int64(types.MetricsApiServicePort),
+				},
+				"caBundle":             base64.StdEncoding.EncodeToString(ca.Data["tls.crt"]),
+				"groupPriorityMinimum": int64(100),
+				"versionPriority":      int64(100),
+			},
+		},
+	}
+	apiService.SetLabels(metricsApiLabels)
+	apiServices := cli.DynamicClient.Resource(apiServiceResource)
+	existing, err := apiServices.Get(metricsApiServiceName, metav1.GetOptions{})
+	if err == nil {
+		if namespace, name := servedBy(existing); namespace != cli.Namespace || name != types.MetricsApiServiceName {
+			return fmt.Errorf("The custom metrics API is already provided by service %s in %s", name, namespace)
+		}
+		apiService.SetResourceVersion(existing.GetResourceVersion())
+		_, err = apiServices.Update(apiService, metav1.UpdateOptions{})
+	} else if errors.IsNotFound(err) {
+		_, err = apiServices.Create(apiService, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("Could not register the metrics API: %w", err)
+	}
+
+	subjects := []rbacv1.Subject{{
+		Kind:      "ServiceAccount",
+		Name:      types.ControllerServiceAccountName,
+		Namespace: cli.Namespace,
+	}}
+	// the controller delegates authorization of requests to the
+	// apiserver
+	_, err = cli.KubeClient.RbacV1().ClusterRoleBindings().Create(&rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   cli.metricsApiBindingName(),
+			Labels: metricsApiLabels,
+		},
+		Subjects: subjects,
+		RoleRef: rbacv1.RoleRef{
+			Kind: "ClusterRole",
+			Name: "system:auth-delegator",
+		},
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Could not allow the controller to authorize requests: %w", err)
+	}
+	// the controller verifies that requests were proxied by the
+	// aggregation layer with the client CA it publishes
+	_, err = cli.KubeClient.RbacV1().RoleBindings(types.MetricsApiAuthNamespace).Create(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   cli.metricsApiBindingName(),
+			Labels: metricsApiLabels,
+		},
+		Subjects: subjects,
+		RoleRef: rbacv1.RoleRef{
+			Kind: "Role",
+			Name: "extension-apiserver-authentication-reader",
+		},
+	})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Could not allow the controller to authenticate requests: %w", err)
+	}
+	return nil
+}
+
+// MetricsApiUnregister removes the registration of the custom metrics API
+// made by MetricsApiRegister, if the API is provided by this site
+func (cli *VanClient) MetricsApiUnregister(ctx context.Context) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.DynamicClient == nil {
+		return fmt.Errorf("Registering the metrics API is not supported by this client")
+	}
+	apiServices := cli.DynamicClient.Resource(apiServiceResource)
+	existing, err := apiServices.Get(metricsApiServiceName, metav1.GetOptions{})
+	if err == nil {
+		if namespace, _ := servedBy(existing); namespace == cli.Namespace {
+			err = apiServices.Delete(metricsApiServiceName, &metav1.DeleteOptions{})
+		}
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("Could not unregister the metrics API: %w", err)
+	}
+	err = cli.KubeClient.RbacV1().ClusterRoleBindings().Delete(cli.metricsApiBindingName(), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	err = cli.KubeClient.RbacV1().RoleBindings(types.MetricsApiAuthNamespace).Delete(cli.metricsApiBindingName(), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
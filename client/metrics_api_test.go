@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestMetricsApiRegister(t *testing.T) {
+	cli, err := newMockClient("east", "", "")
+	assert.Assert(t, err)
+	cli.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	_, err = cli.KubeClient.CoreV1().Secrets("east").Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: types.LocalCaSecret},
+		Data:       map[string][]byte{"tls.crt": []byte("ca")},
+	})
+	assert.Assert(t, err)
+
+	assert.Assert(t, cli.MetricsApiRegister(context.Background()))
+	apiService, err := cli.DynamicClient.Resource(apiServiceResource).Get("v1beta1.custom.metrics.k8s.io", metav1.GetOptions{})
+	assert.Assert(t, err)
+	namespace, name := servedBy(apiService)
+	assert.Equal(t, namespace, "east")
+	assert.Equal(t, name, types.MetricsApiServiceName)
+	caBundle, _, _ := unstructured.NestedString(apiService.Object, "spec", "caBundle")
+	assert.Equal(t, caBundle, base64.StdEncoding.EncodeToString([]byte("ca")))
+	_, err = cli.KubeClient.RbacV1().ClusterRoleBindings().Get("skupper-metrics-api-east", metav1.GetOptions{})
+	assert.Assert(t, err)
+	_, err = cli.KubeClient.RbacV1().RoleBindings("kube-system").Get("skupper-metrics-api-east", metav1.GetOptions{})
+	assert.Assert(t, err)
+
+	// registering again is harmless
+	assert.Assert(t, cli.MetricsApiRegister(context.Background()))
+
+	// another site cannot take over the API, nor remove it
+	west := &VanClient{Namespace: "west", KubeClient: cli.KubeClient, DynamicClient: cli.DynamicClient}
+	_, err = west.KubeClient.CoreV1().Secrets("west").Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: types.LocalCaSecret},
+	})
+	assert.Assert(t, err)
+	assert.ErrorContains(t, west.MetricsApiRegister(context.Background()), "already provided by service skupper-metrics-api in east")
+	assert.Assert(t, west.MetricsApiUnregister(context.Background()))
+	_, err = cli.DynamicClient.Resource(apiServiceResource).Get("v1beta1.custom.metrics.k8s.io", metav1.GetOptions{})
+	assert.Assert(t, err)
+
+	assert.Assert(t, cli.MetricsApiUnregister(context.Background()))
+	_, err = cli.DynamicClient.Resource(apiServiceResource).Get("v1beta1.custom.metrics.k8s.io", metav1.GetOptions{})
+	assert.ErrorContains(t, err, "not found")
+	_, err = cli.KubeClient.RbacV1().ClusterRoleBindings().Get("skupper-metrics-api-east", metav1.GetOptions{})
+	assert.ErrorContains(t, err, "not found")
+}
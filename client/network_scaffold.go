@@ -0,0 +1,92 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+var networkPatterns = []string{types.NetworkPatternHubSpoke, types.NetworkPatternMesh, types.NetworkPatternChain}
+
+// NetworkScaffold returns a starting topology for the given number of
+// sites connected in one of the common patterns:
+//
+//	hub-spoke: every spoke links to a single hub
+//	mesh:      every site links to every site before it
+//	chain:     every site links to the one before it
+//
+// Sites that no other site links to are given no ingress.
+func NetworkScaffold(pattern string, sites int) (*types.NetworkTopology, error) {
+	if sites < 2 {
+		return nil, fmt.Errorf("A network needs at least 2 sites, not %d", sites)
+	}
+	names := []string{}
+	links := [][2]int{}
+	switch pattern {
+	case types.NetworkPatternHubSpoke:
+		names = append(names, "hub")
+		for i := 1; i < sites; i++ {
+			names = append(names, fmt.Sprintf("spoke-%d", i))
+			links = append(links, [2]int{i, 0})
+		}
+	case types.NetworkPatternMesh:
+		for i := 0; i < sites; i++ {
+			names = append(names, fmt.Sprintf("site-%d", i+1))
+			for j := 0; j < i; j++ {
+				links = append(links, [2]int{i, j})
+			}
+		}
+	case types.NetworkPatternChain:
+		for i := 0; i < sites; i++ {
+			names = append(names, fmt.Sprintf("site-%d", i+1))
+			if i > 0 {
+				links = append(links, [2]int{i, i - 1})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a valid pattern, choose one of %v", pattern, networkPatterns)
+	}
+
+	topology := &types.NetworkTopology{
+		Pattern:       pattern,
+		Sites:         []types.NetworkSite{},
+		Links:         []types.NetworkLink{},
+		TokenExchange: []types.NetworkTokenExchange{},
+	}
+	accepting := map[int]bool{}
+	for _, l := range links {
+		accepting[l[1]] = true
+	}
+	for i, name := range names {
+		ingress := types.IngressNoneString
+		if accepting[i] {
+			ingress = types.IngressLoadBalancerString
+		}
+		topology.Sites = append(topology.Sites, types.NetworkSite{
+			Name: name,
+			Config: map[string]string{
+				types.SiteConfigVersionKey: types.SiteConfigVersion,
+				"name":                     name,
+				"router-mode":              string(types.TransportModeInterior),
+				"ingress":                  ingress,
+			},
+		})
+	}
+	for _, l := range links {
+		from, to := names[l[0]], names[l[1]]
+		link := types.NetworkLink{
+			Name: from + "-to-" + to,
+			From: from,
+			To:   to,
+			Cost: 1,
+		}
+		topology.Links = append(topology.Links, link)
+		topology.TokenExchange = append(topology.TokenExchange, types.NetworkTokenExchange{
+			Token:      link.Name + ".yaml",
+			Link:       link.Name,
+			IssuedBy:   to,
+			RedeemedBy: from,
+		})
+	}
+	return topology, nil
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestNetworkScaffold(t *testing.T) {
+	links := func(topology *types.NetworkTopology) []string {
+		result := []string{}
+		for _, l := range topology.Links {
+			result = append(result, l.Name)
+		}
+		return result
+	}
+	ingress := func(topology *types.NetworkTopology) []string {
+		result := []string{}
+		for _, s := range topology.Sites {
+			result = append(result, s.Name+"="+s.Config["ingress"])
+		}
+		return result
+	}
+
+	topology, err := NetworkScaffold(types.NetworkPatternHubSpoke, 3)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, links(topology), []string{"spoke-1-to-hub", "spoke-2-to-hub"})
+	assert.DeepEqual(t, ingress(topology), []string{"hub=loadbalancer", "spoke-1=none", "spoke-2=none"})
+	assert.DeepEqual(t, topology.TokenExchange[1], types.NetworkTokenExchange{
+		Token:      "spoke-2-to-hub.yaml",
+		Link:       "spoke-2-to-hub",
+		IssuedBy:   "hub",
+		RedeemedBy: "spoke-2",
+	})
+
+	topology, err = NetworkScaffold(types.NetworkPatternMesh, 3)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, links(topology), []string{"site-2-to-site-1", "site-3-to-site-1", "site-3-to-site-2"})
+	assert.DeepEqual(t, ingress(topology), []string{"site-1=loadbalancer", "site-2=loadbalancer", "site-3=none"})
+
+	topology, err = NetworkScaffold(types.NetworkPatternChain, 3)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, links(topology), []string{"site-2-to-site-1", "site-3-to-site-2"})
+
+	_, err = NetworkScaffold("star", 3)
+	assert.Error(t, err, "star is not a valid pattern, choose one of [hub-spoke mesh chain]")
+	_, err = NetworkScaffold(types.NetworkPatternMesh, 1)
+	assert.Error(t, err, "A network needs at least 2 sites, not 1")
+}
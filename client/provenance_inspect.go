@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func downloadImageArtifact(kind string, digest string) string {
+	out, err := runCommand(cosignCommand, "download", kind, digest)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ProvenanceInspect reports the image digests running for each skupper
+// component. If includeAttestations is set, any SBOM and attestations
+// attached to those digests are retrieved with cosign.
+func (cli *VanClient) ProvenanceInspect(ctx context.Context, includeAttestations bool) ([]*types.ComponentProvenance, error) {
+	components := []struct {
+		component string
+		container string
+	}{
+		{types.TransportComponentName, types.TransportContainerName},
+		{types.ControllerComponentName, types.ControllerContainerName},
+	}
+	result := []*types.ComponentProvenance{}
+	for _, c := range components {
+		image, digests, err := kube.GetImageDigests(cli.Namespace, cli.KubeClient, c.component, c.container)
+		if err != nil {
+			return nil, err
+		}
+		if len(digests) == 0 {
+			continue
+		}
+		provenance := &types.ComponentProvenance{
+			Component: c.component,
+			Image:     image,
+			Digests:   digests,
+		}
+		if includeAttestations {
+			// pods are normally all running the same digest, so only
+			// the artifacts for the first are retrieved
+			provenance.SBOM = downloadImageArtifact("sbom", digests[0])
+			provenance.Attestations = downloadImageArtifact("attestation", digests[0])
+		}
+		result = append(result, provenance)
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestProvenanceInspect(t *testing.T) {
+	cli, err := newMockClient("skupper", "", "")
+	assert.Check(t, err)
+
+	pods := []struct {
+		name      string
+		component string
+		container string
+		imageID   string
+	}{
+		{"router-1", types.TransportComponentName, types.TransportContainerName, "docker-pullable://quay.io/skupper/qdrouterd@sha256:aaaa"},
+		{"router-2", types.TransportComponentName, types.TransportContainerName, "quay.io/skupper/qdrouterd@sha256:aaaa"},
+		{"router-3", types.TransportComponentName, types.TransportContainerName, "quay.io/skupper/qdrouterd@sha256:bbbb"},
+	}
+	for _, p := range pods {
+		_, err := cli.KubeClient.CoreV1().Pods("skupper").Create(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   p.name,
+				Labels: map[string]string{"skupper.io/component": p.component},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: p.container, Image: "quay.io/skupper/qdrouterd:main", ImageID: p.imageID},
+				},
+			},
+		})
+		assert.Assert(t, err)
+	}
+
+	result, err := cli.ProvenanceInspect(context.Background(), false)
+	assert.Assert(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result[0].Component, types.TransportComponentName)
+	assert.Equal(t, result[0].Image, "quay.io/skupper/qdrouterd:main")
+	assert.DeepEqual(t, result[0].Digests, []string{"quay.io/skupper/qdrouterd@sha256:aaaa", "quay.io/skupper/qdrouterd@sha256:bbbb"})
+	assert.Equal(t, result[0].SBOM, "")
+}
@@ -55,11 +55,14 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 
 	van.Controller.Image = GetServiceControllerImageDetails()
 	van.Controller.Replicas = 1
+	van.Controller.Scheduling = options.ControllerScheduling
+	van.Controller.Resources = options.ControllerResources
 	//TODO: change these to types constants
 	van.Controller.Labels = map[string]string{
 		"application":          "skupper",
 		"skupper.io/component": "proxy-controller",
 	}
+	van.Controller.PodLabels = options.Labels
 
 	envVars := []corev1.EnvVar{}
 	envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_NAMESPACE", Value: van.Namespace})
@@ -71,6 +74,35 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 	envVars = addRouterImageOverrideToEnv(envVars)
 	if !options.EnableServiceSync {
 		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_DISABLE_SERVICE_SYNC", Value: "true"})
+	} else if options.ServiceSyncInterval > 0 {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_SERVICE_SYNC_INTERVAL", Value: options.ServiceSyncInterval.String()})
+	}
+	if options.ImportOnDemand {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_IMPORT_ON_DEMAND", Value: "true"})
+	}
+	if options.EnableProfiling {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_ENABLE_PROFILING", Value: "true"})
+	}
+	if options.Watermarks.Memory != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_WATERMARK_MEMORY", Value: options.Watermarks.Memory})
+	}
+	if options.Watermarks.Connections > 0 {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_WATERMARK_CONNECTIONS", Value: strconv.Itoa(options.Watermarks.Connections)})
+	}
+	if options.Watermarks.Undelivered > 0 {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_WATERMARK_UNDELIVERED", Value: strconv.Itoa(options.Watermarks.Undelivered)})
+	}
+	if options.Watermarks.ShedLoad {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_WATERMARK_SHED_LOAD", Value: "true"})
+	}
+	if options.EnableMetricsApi {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_METRICS_API", Value: "true"})
+	}
+	if options.LinkCertWarningDays > 0 {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_LINK_CERT_WARNING_DAYS", Value: strconv.Itoa(options.LinkCertWarningDays)})
+	}
+	if options.AcceptsLinks() {
+		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_CLAIMS", Value: "true"})
 	}
 
 	sidecars := []*corev1.Container{}
@@ -87,11 +119,21 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 			kube.AppendSecretVolume(&volumes, &mounts[oauthProxy], types.OauthConsoleSecret, "/etc/tls/proxy-certs/")
 		} else if options.AuthMode == string(types.ConsoleAuthModeInternal) {
 			envVars = append(envVars, corev1.EnvVar{Name: "METRICS_USERS", Value: "/etc/console-users"})
-			kube.AppendSecretVolume(&volumes, &mounts[serviceController], "skupper-console-users", "/etc/console-users/")
+			kube.AppendSecretVolume(&volumes, &mounts[serviceController], types.ConsoleUsersSecret, "/etc/console-users/")
+		}
+		if options.ConsoleServesTls() {
+			envVars = append(envVars, corev1.EnvVar{Name: "METRICS_TLS", Value: types.ConsoleTlsPath})
+			kube.AppendSecretVolume(&volumes, &mounts[serviceController], consoleTlsSecret(&options), types.ConsoleTlsPath)
 		}
 	}
 	//mount secret needed for communication with router
 	kube.AppendSecretVolume(&volumes, &mounts[serviceController], types.LocalClientSecret, "/etc/messaging/")
+	if options.EnableMetricsApi {
+		kube.AppendSecretVolume(&volumes, &mounts[serviceController], types.MetricsApiSecret, types.MetricsApiCertsPath)
+	}
+	if options.AcceptsLinks() {
+		kube.AppendSecretVolume(&volumes, &mounts[serviceController], types.ClaimsServerSecret, types.ClaimsCertsPath)
+	}
 	van.Controller.EnvVar = envVars
 	van.Controller.Volumes = volumes
 	van.Controller.VolumeMounts = mounts
@@ -176,8 +218,14 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 				}
 				annotations = map[string]string{"service.alpha.openshift.io/serving-cert-secret-name": types.OauthConsoleSecret}
 			}
+			if options.ConsoleServesTls() {
+				termination = routev1.TLSTerminationPassthrough
+			}
 		} else if options.IsConsoleIngressLoadBalancer() {
 			svctype = corev1.ServiceTypeLoadBalancer
+			for key, value := range kube.LoadBalancerAnnotations(options.IsConsoleIngressLoadBalancerInternal(), options.LoadBalancerProvider, options.LoadBalancerAnnotations) {
+				annotations[key] = value
+			}
 		}
 		svcs = append(svcs, &corev1.Service{
 			TypeMeta: metav1.TypeMeta{
@@ -224,6 +272,85 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 		}
 		van.Controller.Routes = routes
 	}
+	if options.EnableMetricsApi {
+		van.Controller.Services = append(van.Controller.Services, &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: types.MetricsApiServiceName,
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: van.Controller.Labels,
+				Ports: []corev1.ServicePort{
+					{
+						Name:       "https",
+						Protocol:   "TCP",
+						Port:       types.MetricsApiServicePort,
+						TargetPort: intstr.FromInt(int(types.MetricsApiPort)),
+					},
+				},
+			},
+		})
+	}
+	if options.AcceptsLinks() {
+		// claims are redeemed through the same kind of ingress as
+		// links are made through
+		svcType := corev1.ServiceTypeClusterIP
+		annotations := map[string]string{}
+		if options.IngressService == "" && options.IsIngressLoadBalancer() {
+			svcType = corev1.ServiceTypeLoadBalancer
+			annotations = kube.LoadBalancerAnnotations(options.IsIngressLoadBalancerInternal(), options.LoadBalancerProvider, options.LoadBalancerAnnotations)
+		}
+		van.Controller.Services = append(van.Controller.Services, &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        types.ClaimsServiceName,
+				Annotations: annotations,
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: van.Controller.Labels,
+				Ports: []corev1.ServicePort{
+					{
+						Name:       types.ClaimsPortName,
+						Protocol:   "TCP",
+						Port:       types.ClaimsPort,
+						TargetPort: intstr.FromInt(int(types.ClaimsPort)),
+					},
+				},
+				Type: svcType,
+			},
+		})
+		if options.IsIngressRoute() {
+			van.Controller.Routes = append(van.Controller.Routes, &routev1.Route{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Route",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: types.ClaimsServiceName,
+				},
+				Spec: routev1.RouteSpec{
+					Path: "",
+					Port: &routev1.RoutePort{
+						TargetPort: intstr.FromString(types.ClaimsPortName),
+					},
+					To: routev1.RouteTargetReference{
+						Kind: "Service",
+						Name: types.ClaimsServiceName,
+					},
+					TLS: &routev1.TLSConfig{
+						Termination:                   routev1.TLSTerminationPassthrough,
+						InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone,
+					},
+				},
+			})
+		}
+	}
 }
 
 func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId string) *types.RouterSpec {
@@ -252,15 +379,38 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 
 	van.Transport.Image = GetRouterImageDetails()
 	van.Transport.Replicas = 1
+	if options.Replicas > 1 {
+		van.Transport.Replicas = options.Replicas
+	}
 	van.Transport.Labels = map[string]string{
 		"application":          types.TransportDeploymentName,
 		"skupper.io/component": types.TransportComponentName,
 	}
-	van.Transport.Annotations = types.TransportPrometheusAnnotations
-	van.Controller.Annotations = options.Annotations
+	van.Transport.Annotations = map[string]string{}
+	for key, value := range types.TransportPrometheusAnnotations {
+		van.Transport.Annotations[key] = value
+	}
+	van.Controller.Annotations = map[string]string{}
+	for key, value := range types.ControllerPrometheusAnnotations {
+		van.Controller.Annotations[key] = value
+	}
 	for key, value := range options.Annotations {
 		van.Transport.Annotations[key] = value
+		van.Controller.Annotations[key] = value
+	}
+	for key, value := range options.RouterEgress.Annotations {
+		van.Transport.Annotations[key] = value
+	}
+	van.Transport.PodLabels = map[string]string{}
+	for key, value := range options.Labels {
+		van.Transport.PodLabels[key] = value
+	}
+	for key, value := range options.RouterEgress.Labels {
+		van.Transport.PodLabels[key] = value
 	}
+	van.Transport.HostAliases = options.HostAliases
+	van.Transport.Scheduling = options.RouterScheduling
+	van.Transport.Resources = options.RouterResources
 
 	isEdge := options.RouterMode == string(types.TransportModeEdge)
 	routerConfig := qdr.InitialConfig(van.Name+"-${HOSTNAME}", siteId, Version, isEdge, 3)
@@ -322,7 +472,7 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			})
 		}
 	}
-	if !isEdge {
+	if options.AcceptsLinks() {
 		routerConfig.AddSslProfile(qdr.SslProfile{
 			Name: types.InterRouterProfile,
 		})
@@ -411,7 +561,7 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 		Name:          "http",
 		ContainerPort: types.TransportLivenessPort,
 	})
-	if !isEdge {
+	if options.AcceptsLinks() {
 		ports = append(ports, corev1.ContainerPort{
 			Name:          types.InterRouterRole,
 			ContainerPort: types.InterRouterListenerPort,
@@ -420,6 +570,9 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			Name:          types.EdgeRole,
 			ContainerPort: types.EdgeListenerPort,
 		})
+		if options.NodePorts.HostPort {
+			setHostPorts(ports, options.NodePorts)
+		}
 	}
 	van.Transport.Ports = ports
 
@@ -437,7 +590,7 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			mounts = append(mounts, []corev1.VolumeMount{})
 			kube.AppendSecretVolume(&volumes, &mounts[oauthProxy], types.OauthRouterConsoleSecret, "/etc/tls/proxy-certs/")
 		} else if options.AuthMode == string(types.ConsoleAuthModeInternal) {
-			kube.AppendSecretVolume(&volumes, &mounts[qdrouterd], "skupper-console-users", "/etc/qpid-dispatch/sasl-users/")
+			kube.AppendSecretVolume(&volumes, &mounts[qdrouterd], types.RouterConsoleUsersSecret, "/etc/qpid-dispatch/sasl-users/")
 			kube.AppendConfigVolume(&volumes, &mounts[qdrouterd], "skupper-sasl-config", "skupper-sasl-config", "/etc/sasl2/")
 		}
 	}
@@ -508,12 +661,16 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 	}
 	van.CertAuthoritys = cas
 
+	clusterDomain := options.ClusterDomain
+	if clusterDomain == "" {
+		clusterDomain = types.DefaultClusterDomain
+	}
 	credentials := []types.Credential{}
 	credentials = append(credentials, types.Credential{
 		CA:          types.LocalCaSecret,
 		Name:        types.LocalServerSecret,
 		Subject:     types.LocalTransportServiceName,
-		Hosts:       []string{types.LocalTransportServiceName, types.LocalTransportServiceName + "." + van.Namespace + ".svc.cluster.local"},
+		Hosts:       []string{types.LocalTransportServiceName, kube.QualifiedServiceName(types.LocalTransportServiceName, van.Namespace, clusterDomain)},
 		ConnectJson: false,
 		Post:        false,
 	})
@@ -527,7 +684,20 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 	})
 
 	if !isEdge {
-		if options.IsIngressNone() {
+		if options.IsIngressKubernetes() || options.IsIngressGateway() {
+			credentials = append(credentials, types.Credential{
+				CA:      types.SiteCaSecret,
+				Name:    types.SiteServerSecret,
+				Subject: types.TransportServiceName,
+				Hosts: []string{
+					types.TransportServiceName + "." + van.Namespace,
+					ingressHost(types.InterRouterRouteName, van.Namespace, options.IngressHost),
+					ingressHost(types.EdgeRouteName, van.Namespace, options.IngressHost),
+				},
+				ConnectJson: false,
+				Post:        false,
+			})
+		} else if options.IsIngressNone() {
 			credentials = append(credentials, types.Credential{
 				CA:          types.SiteCaSecret,
 				Name:        types.SiteServerSecret,
@@ -537,16 +707,62 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 				Post:        false,
 			})
 		} else {
+			hosts := []string{types.TransportServiceName + "." + van.Namespace}
+			if options.IngressService != "" {
+				hosts = append(hosts, options.IngressService+"."+van.Namespace)
+			}
 			credentials = append(credentials, types.Credential{
 				CA:          types.SiteCaSecret,
 				Name:        types.SiteServerSecret,
 				Subject:     types.TransportServiceName,
-				Hosts:       []string{types.TransportServiceName + "." + van.Namespace},
+				Hosts:       hosts,
 				ConnectJson: false,
 				Post:        true,
 			})
 		}
 	}
+	if options.EnableController && options.AcceptsLinks() {
+		// claimants verify the server against the name of the
+		// service, whatever host they reach it through
+		credentials = append(credentials, types.Credential{
+			CA:      types.SiteCaSecret,
+			Name:    types.ClaimsServerSecret,
+			Subject: types.ClaimsServiceName,
+			Hosts: []string{
+				types.ClaimsServiceName,
+				types.ClaimsServiceName + "." + van.Namespace,
+			},
+			ConnectJson: false,
+			Post:        false,
+		})
+	}
+	if options.EnableController && options.EnableMetricsApi {
+		credentials = append(credentials, types.Credential{
+			CA:      types.LocalCaSecret,
+			Name:    types.MetricsApiSecret,
+			Subject: types.MetricsApiServiceName,
+			Hosts: []string{
+				types.MetricsApiServiceName + "." + van.Namespace + ".svc",
+				kube.QualifiedServiceName(types.MetricsApiServiceName, van.Namespace, clusterDomain),
+			},
+			ConnectJson: false,
+			Post:        false,
+		})
+	}
+	if options.ConsoleServesTls() && options.ConsoleTlsSecret == "" {
+		host := consoleHost(&options, van.Namespace)
+		credentials = append(credentials, types.Credential{
+			CA:      types.LocalCaSecret,
+			Name:    types.ConsoleTlsSecret,
+			Subject: host,
+			Hosts: []string{
+				host,
+				types.ControllerServiceName + "." + van.Namespace,
+			},
+			ConnectJson: false,
+			Post:        false,
+		})
+	}
 	if options.AuthMode == string(types.ConsoleAuthModeInternal) {
 		userData := map[string][]byte{}
 		if options.User != "" {
@@ -554,7 +770,17 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 		}
 		credentials = append(credentials, types.Credential{
 			CA:          "",
-			Name:        "skupper-console-users",
+			Name:        types.ConsoleUsersSecret,
+			Subject:     "",
+			ConnectJson: false,
+			Data:        userData,
+			Post:        false,
+		})
+		// the router builds its sasldb from the passwords themselves, so
+		// cannot share the hashed entries of the console users
+		credentials = append(credentials, types.Credential{
+			CA:          "",
+			Name:        types.RouterConsoleUsersSecret,
 			Subject:     "",
 			ConnectJson: false,
 			Data:        userData,
@@ -636,10 +862,15 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			})
 		}
 	}
-	if !isEdge {
+	if options.AcceptsLinks() {
 		svcType := corev1.ServiceTypeClusterIP
-		if options.IsIngressLoadBalancer() {
+		annotations := map[string]string{}
+		if options.IngressService != "" {
+			// the adopted service provides the load balancer
+			annotations[types.IngressServiceAnnotation] = options.IngressService
+		} else if options.IsIngressLoadBalancer() {
 			svcType = corev1.ServiceTypeLoadBalancer
+			annotations = kube.LoadBalancerAnnotations(options.IsIngressLoadBalancerInternal(), options.LoadBalancerProvider, options.LoadBalancerAnnotations)
 		}
 		svcs = append(svcs, &corev1.Service{
 			TypeMeta: metav1.TypeMeta{
@@ -648,32 +879,22 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			},
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        types.TransportServiceName,
-				Annotations: map[string]string{},
+				Annotations: annotations,
 			},
 			Spec: corev1.ServiceSpec{
 				Selector: van.Transport.Labels,
-				Ports: []corev1.ServicePort{
-					{
-						Name:       "inter-router",
-						Protocol:   "TCP",
-						Port:       types.InterRouterListenerPort,
-						TargetPort: intstr.FromInt(int(types.InterRouterListenerPort)),
-					},
-					{
-						Name:       "edge",
-						Protocol:   "TCP",
-						Port:       types.EdgeListenerPort,
-						TargetPort: intstr.FromInt(int(types.EdgeListenerPort)),
-					},
-				},
-				Type: svcType,
+				Ports:    routerIngressPorts(),
+				Type:     svcType,
 			},
 		})
+		if !options.NodePorts.HostPort {
+			setNodePorts(svcs[len(svcs)-1].Spec.Ports, options.NodePorts)
+		}
 	}
 	van.Transport.Services = svcs
 
 	routes := []*routev1.Route{}
-	if !isEdge && options.IsIngressRoute() {
+	if options.AcceptsLinks() && options.IsIngressRoute() {
 		routes = append(routes, &routev1.Route{
 			TypeMeta: metav1.TypeMeta{
 				APIVersion: "v1",
@@ -755,12 +976,121 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 	return van
 }
 
+func routerIngressPorts() []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{
+			Name:       "inter-router",
+			Protocol:   "TCP",
+			Port:       types.InterRouterListenerPort,
+			TargetPort: intstr.FromInt(int(types.InterRouterListenerPort)),
+		},
+		{
+			Name:       "edge",
+			Protocol:   "TCP",
+			Port:       types.EdgeListenerPort,
+			TargetPort: intstr.FromInt(int(types.EdgeListenerPort)),
+		},
+	}
+}
+
+func setNodePorts(ports []corev1.ServicePort, pinned types.RouterNodePorts) bool {
+	changed := false
+	for i, port := range ports {
+		desired := int32(0)
+		if port.Port == types.InterRouterListenerPort {
+			desired = int32(pinned.InterRouter)
+		} else if port.Port == types.EdgeListenerPort {
+			desired = int32(pinned.Edge)
+		}
+		if desired != 0 && port.NodePort != desired {
+			ports[i].NodePort = desired
+			changed = true
+		}
+	}
+	return changed
+}
+
+func setHostPorts(ports []corev1.ContainerPort, pinned types.RouterNodePorts) bool {
+	changed := false
+	for i, port := range ports {
+		var desired int32
+		switch port.ContainerPort {
+		case types.InterRouterListenerPort:
+			desired = int32(pinned.InterRouter)
+		case types.EdgeListenerPort:
+			desired = int32(pinned.Edge)
+		default:
+			continue
+		}
+		if port.HostPort != desired {
+			ports[i].HostPort = desired
+			changed = true
+		}
+	}
+	return changed
+}
+
+// checkNodePortsAvailable returns an error if a pinned node port is
+// already allocated to a service other than the router's own
+func (cli *VanClient) checkNodePortsAvailable(namespace string, pinned types.RouterNodePorts) error {
+	services, err := cli.KubeClient.CoreV1().Services(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		fmt.Println("Could not check availability of node ports:", err.Error())
+		return nil
+	}
+	for _, svc := range services.Items {
+		if svc.ObjectMeta.Namespace == namespace && svc.ObjectMeta.Name == types.TransportServiceName {
+			continue
+		}
+		for _, port := range svc.Spec.Ports {
+			if port.NodePort != 0 && (int(port.NodePort) == pinned.InterRouter || int(port.NodePort) == pinned.Edge) {
+				return fmt.Errorf("Node port %d is already in use by service %s/%s", port.NodePort, svc.ObjectMeta.Namespace, svc.ObjectMeta.Name)
+			}
+		}
+	}
+	return nil
+}
+
 // RouterCreate instantiates a VAN (router and controller) deployment
 func (cli *VanClient) RouterCreate(ctx context.Context, options types.SiteConfig) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.RouterCreate(ctx, options)
+	}
+	if err := cli.checkGatewayIngress(&options.Spec); err != nil {
+		return err
+	}
 	// todo return error
 	if options.Spec.IsIngressRoute() && cli.RouteClient == nil {
 		return fmt.Errorf("OpenShift cluster not detected for --ingress type route")
 	}
+	if err := options.Spec.Validate(); err != nil {
+		return err
+	}
+	options.Spec.ClusterDomain = cli.clusterDomain(&options.Spec)
+	certificates, err := cli.certificateProvider(&options.Spec)
+	if err != nil {
+		return err
+	}
+	if options.Spec.NodePorts.IsEnabled() && !options.Spec.NodePorts.HostPort {
+		if err := cli.checkNodePortsAvailable(cli.Namespace, options.Spec.NodePorts); err != nil {
+			return err
+		}
+	}
+	if options.Spec.IngressService != "" {
+		service, err := kube.GetService(options.Spec.IngressService, cli.Namespace, cli.KubeClient)
+		if err != nil {
+			return fmt.Errorf("Could not retrieve ingress service %s: %w", options.Spec.IngressService, err)
+		}
+		if err := kube.CheckServicePorts(service, routerIngressPorts()); err != nil {
+			return err
+		}
+	}
+	if err := cli.checkConsoleTlsSecret(&options.Spec, cli.Namespace); err != nil {
+		return err
+	}
 
 	if options.Spec.EnableRouterConsole || options.Spec.EnableConsole {
 		if options.Spec.AuthMode == string(types.ConsoleAuthModeInternal) || options.Spec.AuthMode == "" {
@@ -781,6 +1111,14 @@ func (cli *VanClient) RouterCreate(ctx context.Context, options types.SiteConfig
 		}
 	}
 
+	images := []string{GetRouterImageName()}
+	if options.Spec.EnableController {
+		images = append(images, GetServiceControllerImageName())
+	}
+	if err := cli.verifyImages(options.Spec.ImageVerification, images...); err != nil {
+		return err
+	}
+
 	siteId := options.Reference.UID
 	if siteId == "" {
 		siteId = utils.RandomId(10)
@@ -791,7 +1129,6 @@ func (cli *VanClient) RouterCreate(ctx context.Context, options types.SiteConfig
 	if siteOwnerRef != nil {
 		ownerRefs = []metav1.OwnerReference{*siteOwnerRef}
 	}
-	var err error
 	if options.Spec.AuthMode == string(types.ConsoleAuthModeInternal) {
 		config := `
 pwcheck_method: auxprop
@@ -825,14 +1162,14 @@ sasldb_path: /tmp/qdrouterd.sasldb
 		}
 	}
 	for _, ca := range van.CertAuthoritys {
-		_, err = kube.NewCertAuthority(ca, siteOwnerRef, van.Namespace, cli.KubeClient)
+		err = certificates.NewCertAuthority(ca, siteOwnerRef, van.Namespace)
 		if err != nil {
 			return err
 		}
 	}
 	for _, cred := range van.Credentials {
 		if !cred.Post {
-			_, err = kube.NewSecret(cred, siteOwnerRef, van.Namespace, cli.KubeClient)
+			err = certificates.NewSecret(cred, siteOwnerRef, van.Namespace)
 			if err != nil {
 				return err
 			}
@@ -845,6 +1182,12 @@ sasldb_path: /tmp/qdrouterd.sasldb
 			return err
 		}
 	}
+	if options.Spec.IngressService != "" {
+		_, err = kube.AdoptService(options.Spec.IngressService, van.Transport.Labels, routerIngressPorts(), van.Namespace, cli.KubeClient)
+		if err != nil {
+			return fmt.Errorf("Could not adopt ingress service %s: %w", options.Spec.IngressService, err)
+		}
+	}
 	if options.Spec.IsIngressRoute() {
 		for _, rte := range van.Transport.Routes {
 			rte.ObjectMeta.OwnerReferences = ownerRefs
@@ -853,6 +1196,14 @@ sasldb_path: /tmp/qdrouterd.sasldb
 				return err
 			}
 		}
+	} else if options.Spec.IsIngressKubernetes() && options.Spec.AcceptsLinks() {
+		if err := cli.createRouterIngresses(&options.Spec, van.Namespace, ownerRefs); err != nil {
+			return err
+		}
+	} else if options.Spec.IsIngressGateway() && options.Spec.AcceptsLinks() {
+		if err := cli.createRouterGateway(&options.Spec, van.Namespace, ownerRefs); err != nil {
+			return err
+		}
 	}
 	dep, err := kube.NewTransportDeployment(van, siteOwnerRef, cli.KubeClient)
 	if err != nil {
@@ -881,19 +1232,21 @@ sasldb_path: /tmp/qdrouterd.sasldb
 					}
 
 				} else {
-					service, err := kube.GetService(types.TransportServiceName, van.Namespace, cli.KubeClient)
+					service, err := kube.GetRouterIngressService(van.Namespace, cli.KubeClient)
 					if err == nil {
 						host := kube.GetLoadBalancerHostOrIP(service)
+						started := time.Now()
 						for i := 0; host == "" && i < 120; i++ {
 							if i == 0 {
 								fmt.Println("Waiting for LoadBalancer IP or hostname...")
 							}
+							cli.progress(progressLoadBalancer, 0, 0, "Waiting for LoadBalancer IP or hostname of "+service.ObjectMeta.Name, started)
 							time.Sleep(time.Second)
-							service, err = kube.GetService(types.TransportServiceName, van.Namespace, cli.KubeClient)
+							service, err = kube.GetRouterIngressService(van.Namespace, cli.KubeClient)
 							host = kube.GetLoadBalancerHostOrIP(service)
 						}
 						if host == "" {
-							return fmt.Errorf("Failed to get LoadBalancer IP or Hostname for service %s", types.TransportServiceName)
+							return fmt.Errorf("Failed to get LoadBalancer IP or Hostname for service %s", service.ObjectMeta.Name)
 						} else {
 							cred.Hosts = append(cred.Hosts, host)
 							if len(host) < 64 {
@@ -902,7 +1255,7 @@ sasldb_path: /tmp/qdrouterd.sasldb
 						}
 					}
 				}
-				kube.NewSecret(cred, siteOwnerRef, van.Namespace, cli.KubeClient)
+				certificates.NewSecret(cred, siteOwnerRef, van.Namespace)
 			}
 		}
 	}
@@ -930,6 +1283,11 @@ sasldb_path: /tmp/qdrouterd.sasldb
 				return err
 			}
 		}
+		if options.Spec.ClusterScoped {
+			if err := cli.createClusterScopedRbac(van.Namespace); err != nil {
+				return err
+			}
+		}
 		for _, svc := range van.Controller.Services {
 			svc.ObjectMeta.OwnerReferences = ownerRefs
 			_, err = kube.CreateService(svc, van.Namespace, cli.KubeClient)
@@ -946,6 +1304,11 @@ sasldb_path: /tmp/qdrouterd.sasldb
 				}
 			}
 		}
+		if options.Spec.EnableConsole && options.Spec.IsConsoleIngressKubernetes() {
+			if err := cli.createConsoleIngress(&options.Spec, van.Namespace, ownerRefs); err != nil {
+				return err
+			}
+		}
 		_, err = kube.NewControllerDeployment(van, siteOwnerRef, cli.KubeClient)
 		if err != nil {
 			return err
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden files under testdata/golden")
+
+// goldenSiteConfigs is the matrix of site configurations whose generated
+// resources are recorded in testdata/golden. Add an entry here when a new
+// SiteConfig option changes what init creates.
+var goldenSiteConfigs = []struct {
+	name string
+	spec types.SiteConfigSpec
+}{
+	{
+		name: "interior-defaults",
+		spec: types.SiteConfigSpec{
+			SkupperName:       "golden",
+			RouterMode:        string(types.TransportModeInterior),
+			EnableController:  true,
+			EnableServiceSync: true,
+			Ingress:           types.IngressNoneString,
+		},
+	},
+	{
+		name: "interior-console-internal",
+		spec: types.SiteConfigSpec{
+			SkupperName:         "golden",
+			RouterMode:          string(types.TransportModeInterior),
+			EnableController:    true,
+			EnableServiceSync:   true,
+			EnableRouterConsole: true,
+			EnableConsole:       true,
+			AuthMode:            string(types.ConsoleAuthModeInternal),
+			User:                "admin",
+			Password:            "secret",
+			Ingress:             types.IngressNoneString,
+		},
+	},
+	{
+		name: "interior-console-unsecured",
+		spec: types.SiteConfigSpec{
+			SkupperName:         "golden",
+			RouterMode:          string(types.TransportModeInterior),
+			EnableController:    true,
+			EnableServiceSync:   true,
+			EnableRouterConsole: true,
+			EnableConsole:       true,
+			AuthMode:            string(types.ConsoleAuthModeUnsecured),
+			Ingress:             types.IngressNoneString,
+		},
+	},
+	{
+		name: "interior-no-controller",
+		spec: types.SiteConfigSpec{
+			SkupperName: "golden",
+			RouterMode:  string(types.TransportModeInterior),
+			Ingress:     types.IngressNoneString,
+		},
+	},
+	{
+		name: "edge",
+		spec: types.SiteConfigSpec{
+			SkupperName:       "golden",
+			RouterMode:        string(types.TransportModeEdge),
+			EnableController:  true,
+			EnableServiceSync: true,
+			Ingress:           types.IngressNoneString,
+		},
+	},
+}
+
+// renderSiteResources lists everything RouterCreate left in the namespace as
+// a single multi-document YAML stream in a stable order. Values that
+// differ on every run (generated certificates and keys, server assigned
+// metadata) are replaced so the output only changes when the templates do.
+func renderSiteResources(cli *VanClient) (string, error) {
+	ns := cli.Namespace
+	objects := []runtime.Object{}
+	deps, err := cli.KubeClient.AppsV1().Deployments(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range deps.Items {
+		deps.Items[i].Kind = "Deployment"
+		objects = append(objects, &deps.Items[i])
+	}
+	cms, err := cli.KubeClient.CoreV1().ConfigMaps(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range cms.Items {
+		cms.Items[i].Kind = "ConfigMap"
+		objects = append(objects, &cms.Items[i])
+	}
+	secrets, err := cli.KubeClient.CoreV1().Secrets(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		secret.Kind = "Secret"
+		for key := range secret.Data {
+			secret.Data[key] = []byte("redacted")
+		}
+		objects = append(objects, secret)
+	}
+	svcs, err := cli.KubeClient.CoreV1().Services(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range svcs.Items {
+		svcs.Items[i].Kind = "Service"
+		objects = append(objects, &svcs.Items[i])
+	}
+	sas, err := cli.KubeClient.CoreV1().ServiceAccounts(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range sas.Items {
+		sas.Items[i].Kind = "ServiceAccount"
+		objects = append(objects, &sas.Items[i])
+	}
+	roles, err := cli.KubeClient.RbacV1().Roles(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range roles.Items {
+		roles.Items[i].Kind = "Role"
+		objects = append(objects, &roles.Items[i])
+	}
+	bindings, err := cli.KubeClient.RbacV1().RoleBindings(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for i := range bindings.Items {
+		bindings.Items[i].Kind = "RoleBinding"
+		objects = append(objects, &bindings.Items[i])
+	}
+
+	docs := []string{}
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return "", err
+		}
+		accessor.SetResourceVersion("")
+		accessor.SetCreationTimestamp(metav1.Time{})
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(out))
+	}
+	sort.Strings(docs)
+	return strings.Join(docs, "---\n"), nil
+}
+
+func TestRouterCreateGolden(t *testing.T) {
+	for _, key := range []string{RouterImageEnvKey, RouterPullPolicyEnvKey, ServiceControllerImageEnvKey, ServiceControllerPullPolicyEnvKey} {
+		if value, ok := os.LookupEnv(key); ok {
+			os.Unsetenv(key)
+			defer os.Setenv(key, value)
+		}
+	}
+	for _, c := range goldenSiteConfigs {
+		t.Run(c.name, func(t *testing.T) {
+			cli, err := newMockClient("skupper", "", "")
+			assert.Assert(t, err)
+			err = cli.RouterCreate(context.Background(), types.SiteConfig{
+				Spec: c.spec,
+				Reference: types.SiteConfigReference{
+					UID: "00000000-0000-0000-0000-000000000000",
+				},
+			})
+			assert.Assert(t, err)
+			got, err := renderSiteResources(cli)
+			assert.Assert(t, err)
+
+			path := filepath.Join("testdata", "golden", c.name+".yaml")
+			if *updateGolden {
+				assert.Assert(t, os.MkdirAll(filepath.Dir(path), 0755))
+				assert.Assert(t, ioutil.WriteFile(path, []byte(got), 0644))
+				return
+			}
+			want, err := ioutil.ReadFile(path)
+			assert.Assert(t, err, "run 'go test ./client -run TestRouterCreateGolden -update-golden' to create it")
+			if diff := cmp.Diff(string(want), got); diff != "" {
+				t.Errorf("generated resources for %s differ from %s (-want +got):\n%s\nrerun with -update-golden if the change is intended", c.name, path, diff)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 )
@@ -62,8 +63,9 @@ func TestRouterCreateDefaults(t *testing.T) {
 				types.SiteCaSecret,
 				types.LocalServerSecret,
 				types.LocalClientSecret,
-				types.SiteServerSecret},
-			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName},
+				types.SiteServerSecret,
+				types.ClaimsServerSecret},
+			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ClaimsServiceName},
 			svcAccountsExpected: []string{types.TransportServiceAccountName, types.ControllerServiceAccountName},
 			opts: []cmp.Option{
 				trans,
@@ -93,8 +95,9 @@ func TestRouterCreateDefaults(t *testing.T) {
 				types.SiteCaSecret,
 				types.LocalServerSecret,
 				types.LocalClientSecret,
-				types.SiteServerSecret},
-			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, "skupper-router-console"},
+				types.SiteServerSecret,
+				types.ClaimsServerSecret},
+			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, types.ClaimsServiceName, "skupper-router-console"},
 			svcAccountsExpected: []string{types.TransportServiceAccountName, types.ControllerServiceAccountName},
 			opts: []cmp.Option{
 				trans,
@@ -125,8 +128,9 @@ func TestRouterCreateDefaults(t *testing.T) {
 				types.LocalServerSecret,
 				types.LocalClientSecret,
 				types.SiteServerSecret,
-				"skupper-console-users"},
-			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, "skupper-router-console"},
+				types.ClaimsServerSecret,
+				"skupper-console-users", "skupper-router-console-users"},
+			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, types.ClaimsServiceName, "skupper-router-console"},
 			svcAccountsExpected: []string{types.TransportServiceAccountName, types.ControllerServiceAccountName},
 			opts: []cmp.Option{
 				trans,
@@ -157,9 +161,10 @@ func TestRouterCreateDefaults(t *testing.T) {
 				types.LocalServerSecret,
 				types.LocalClientSecret,
 				types.SiteServerSecret,
+				types.ClaimsServerSecret,
 				types.OauthConsoleSecret,
 				types.OauthRouterConsoleSecret},
-			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, "skupper-router-console"},
+			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, types.ClaimsServiceName, "skupper-router-console"},
 			svcAccountsExpected: []string{types.TransportServiceAccountName, types.ControllerServiceAccountName},
 			opts: []cmp.Option{
 				trans,
@@ -339,3 +344,109 @@ func TestRouterCreateDefaults(t *testing.T) {
 		}
 	}
 }
+
+func TestPinnedRouterPorts(t *testing.T) {
+	spec := types.SiteConfigSpec{
+		RouterMode: string(types.TransportModeInterior),
+		Ingress:    types.IngressRouteString,
+		NodePorts:  types.RouterNodePorts{InterRouter: 30671, Edge: 30672},
+	}
+	assert.Error(t, spec.CheckNodePorts(), "Node ports can only be pinned with --ingress loadbalancer")
+	spec.NodePorts.HostPort = true
+	assert.Assert(t, spec.CheckNodePorts())
+	spec.NodePorts.HostPort = false
+	spec.Ingress = types.IngressLoadBalancerString
+	assert.Assert(t, spec.CheckNodePorts())
+	spec.NodePorts.Edge = 30671
+	assert.Error(t, spec.CheckNodePorts(), "The inter-router and edge ports must differ")
+	spec.NodePorts.Edge = 30672
+
+	ports := routerIngressPorts()
+	assert.Assert(t, setNodePorts(ports, spec.NodePorts))
+	assert.Equal(t, ports[0].NodePort, int32(30671))
+	assert.Equal(t, ports[1].NodePort, int32(30672))
+	assert.Assert(t, !setNodePorts(ports, spec.NodePorts))
+
+	containerPorts := []corev1.ContainerPort{
+		{Name: "amqps", ContainerPort: types.AmqpsDefaultPort},
+		{Name: types.InterRouterRole, ContainerPort: types.InterRouterListenerPort},
+		{Name: types.EdgeRole, ContainerPort: types.EdgeListenerPort},
+	}
+	assert.Assert(t, setHostPorts(containerPorts, spec.NodePorts))
+	assert.Equal(t, containerPorts[0].HostPort, int32(0))
+	assert.Equal(t, containerPorts[1].HostPort, int32(30671))
+	assert.Equal(t, containerPorts[2].HostPort, int32(30672))
+	assert.Assert(t, setHostPorts(containerPorts, types.RouterNodePorts{}))
+	assert.Equal(t, containerPorts[1].HostPort, int32(0))
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	_, err = cli.KubeClient.CoreV1().Services("other").Create(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: "other"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Port: 8080, NodePort: 30672}},
+		},
+	})
+	assert.Assert(t, err)
+	assert.Error(t, cli.checkNodePortsAvailable("skupper", spec.NodePorts), "Node port 30672 is already in use by service other/taken")
+	assert.Assert(t, cli.checkNodePortsAvailable("skupper", types.RouterNodePorts{InterRouter: 30671}))
+}
+
+func TestInternalLoadBalancer(t *testing.T) {
+	testcases := []struct {
+		spec          types.SiteConfigSpec
+		expectedError string
+	}{
+		{types.SiteConfigSpec{Ingress: types.IngressLoadBalancerInternalString}, ""},
+		{types.SiteConfigSpec{Ingress: types.IngressLoadBalancerInternalString, LoadBalancerProvider: "gcp"}, ""},
+		{types.SiteConfigSpec{Ingress: types.IngressRouteString, ConsoleIngress: types.IngressLoadBalancerInternalString, LoadBalancerProvider: "azure"}, ""},
+		{types.SiteConfigSpec{Ingress: types.IngressLoadBalancerString, LoadBalancerAnnotations: map[string]string{"example.com/tier": "gold"}}, ""},
+		{types.SiteConfigSpec{Ingress: types.IngressLoadBalancerInternalString, LoadBalancerProvider: "ibm"}, "Invalid value for load-balancer-provider: ibm (use aws, gcp or azure)"},
+		{types.SiteConfigSpec{Ingress: types.IngressLoadBalancerString, LoadBalancerProvider: "aws"}, "A load balancer provider can only be given with --ingress loadbalancer-internal"},
+		{types.SiteConfigSpec{Ingress: types.IngressRouteString, LoadBalancerAnnotations: map[string]string{"example.com/tier": "gold"}}, "Load balancer annotations can only be given with --ingress loadbalancer or loadbalancer-internal"},
+		{types.SiteConfigSpec{Ingress: types.IngressLoadBalancerString, LoadBalancerAnnotations: map[string]string{"bad key": "x"}}, "Invalid load balancer annotation \"bad key\": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')"},
+	}
+	for _, c := range testcases {
+		err := c.spec.CheckLoadBalancer()
+		if c.expectedError == "" {
+			assert.Assert(t, err)
+		} else {
+			assert.Error(t, err, c.expectedError)
+		}
+	}
+	spec := types.SiteConfigSpec{Ingress: types.IngressLoadBalancerInternalString}
+	assert.Assert(t, spec.IsIngressLoadBalancer())
+	assert.Assert(t, spec.CheckIngress())
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(context.Background(), types.SiteConfigSpec{
+		SkupperNamespace:     "skupper",
+		RouterMode:           string(types.TransportModeInterior),
+		Ingress:              types.IngressLoadBalancerInternalString,
+		LoadBalancerProvider: types.LoadBalancerProviderAWS,
+		LoadBalancerAnnotations: map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-scheme":  "",
+			"service.beta.kubernetes.io/aws-load-balancer-subnets": "subnet-a,subnet-b",
+		},
+	})
+	assert.Assert(t, err)
+	inspected, err := cli.SiteConfigInspect(context.Background(), nil)
+	assert.Assert(t, err)
+	assert.Equal(t, inspected.Spec.LoadBalancerProvider, types.LoadBalancerProviderAWS)
+	assert.DeepEqual(t, inspected.Spec.LoadBalancerAnnotations, siteConfig.Spec.LoadBalancerAnnotations)
+	van := cli.GetRouterSpecFromOpts(siteConfig.Spec, "site")
+	var service *corev1.Service
+	for _, svc := range van.Transport.Services {
+		if svc.ObjectMeta.Name == types.TransportServiceName {
+			service = svc
+		}
+	}
+	assert.Assert(t, service != nil)
+	assert.Equal(t, service.Spec.Type, corev1.ServiceTypeLoadBalancer)
+	assert.DeepEqual(t, service.ObjectMeta.Annotations, map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+		"service.beta.kubernetes.io/aws-load-balancer-subnets":  "subnet-a,subnet-b",
+	})
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// RouterPeerStatus asks the service controller to compare the view each
+// linked peer has of this site with the local view
+func (cli *VanClient) RouterPeerStatus(ctx context.Context) ([]types.PeerStatus, error) {
+	peers := []types.PeerStatus{}
+	err := cli.controllerGet("peers", &peers)
+	if err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// RouterLinkProbe asks the service controller to send probe messages of
+// increasing size over each link, to detect MTU problems
+func (cli *VanClient) RouterLinkProbe(ctx context.Context) ([]types.LinkProbeResult, error) {
+	results := []types.LinkProbeResult{}
+	err := cli.controllerGet("linkprobe", &results)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// NetworkStatus asks the service controller for the state of each site in
+// the network, the links between them and the services and gateways in
+// each
+func (cli *VanClient) NetworkStatus(ctx context.Context) (*types.NetworkStatus, error) {
+	if err := cli.checkCluster("Network status"); err != nil {
+		return nil, err
+	}
+	status := &types.NetworkStatus{}
+	err := cli.controllerGet("network", status)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ServiceSyncNow asks the service controller to advertise the services
+// of this site to the network immediately, and to ask every other site
+// to do the same, rather than waiting for the next sync interval
+func (cli *VanClient) ServiceSyncNow(ctx context.Context) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	return cli.controllerRequest("servicesync")
+}
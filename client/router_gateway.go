@@ -0,0 +1,84 @@
+package client
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// checkGatewayIngress returns an error when a site is configured to use
+// the Gateway API but its resources are not installed in the cluster
+func (cli *VanClient) checkGatewayIngress(spec *types.SiteConfigSpec) error {
+	if spec.IsIngressGateway() && (!cli.GatewayApi || cli.DynamicClient == nil) {
+		return fmt.Errorf("Gateway API not detected in the cluster for --ingress type gateway")
+	}
+	return nil
+}
+
+// createRouterGateway creates a Gateway with a listener for each of the
+// inter-router and edge endpoints of the router, and for the claims
+// endpoint of the service controller, and a TLSRoute for each that passes
+// TLS through to them
+func (cli *VanClient) createRouterGateway(spec *types.SiteConfigSpec, namespace string, ownerRefs []metav1.OwnerReference) error {
+	if cli.DynamicClient == nil {
+		return fmt.Errorf("Gateway API resources are not supported by this client")
+	}
+	type endpoint struct {
+		route    string
+		listener string
+		service  string
+		port     int
+	}
+	endpoints := []endpoint{
+		{types.InterRouterRouteName, types.InterRouterRole, types.TransportServiceName, int(types.InterRouterListenerPort)},
+		{types.EdgeRouteName, types.EdgeRole, types.TransportServiceName, int(types.EdgeListenerPort)},
+	}
+	if spec.EnableController {
+		endpoints = append(endpoints, endpoint{types.ClaimsServiceName, types.ClaimsPortName, types.ClaimsServiceName, int(types.ClaimsPort)})
+	}
+	listeners := []kube.GatewayListener{}
+	for _, endpoint := range endpoints {
+		listeners = append(listeners, kube.GatewayListener{
+			Name: endpoint.listener,
+			Host: ingressHost(endpoint.route, namespace, spec.IngressHost),
+		})
+	}
+	gateway := kube.NewPassthroughGateway(types.RouterGatewayName, spec.GatewayClass, listeners)
+	gateway.SetOwnerReferences(ownerRefs)
+	if _, err := kube.CreateGateway(gateway, namespace, cli.DynamicClient); err != nil {
+		return err
+	}
+	for _, endpoint := range endpoints {
+		route := kube.NewTLSRoute(endpoint.route, types.RouterGatewayName, endpoint.listener, ingressHost(endpoint.route, namespace, spec.IngressHost), endpoint.service, endpoint.port)
+		route.SetOwnerReferences(ownerRefs)
+		if _, err := kube.CreateTLSRoute(route, namespace, cli.DynamicClient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configureHostPortsFromGateway(result *RouterHostPorts, cli *VanClient, namespace string) (bool, error) {
+	if cli.DynamicClient == nil || !cli.GatewayApi {
+		return false, nil
+	}
+	interRouterHost, err1 := kube.GetTLSRouteHost(types.InterRouterRouteName, namespace, cli.DynamicClient)
+	edgeHost, err2 := kube.GetTLSRouteHost(types.EdgeRouteName, namespace, cli.DynamicClient)
+	if err1 != nil && err2 != nil && errors.IsNotFound(err1) && errors.IsNotFound(err2) {
+		return false, nil
+	} else if err1 != nil {
+		return false, err1
+	} else if err2 != nil {
+		return false, err2
+	}
+	result.Edge.Host = edgeHost
+	result.Edge.Port = "443"
+	result.InterRouter.Host = interRouterHost
+	result.InterRouter.Port = "443"
+	result.Hosts = edgeHost + "," + interRouterHost
+	return true, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func TestRouterCreateWithGateway(t *testing.T) {
+	ctx := context.Background()
+	spec := types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		Ingress:          types.IngressGatewayString,
+		IngressHost:      "apps.example.com",
+		EnableController: true,
+	}
+	assert.Error(t, spec.CheckIngress(), "A gateway class is required with --ingress gateway")
+	spec.GatewayClass = "example"
+	assert.Assert(t, spec.CheckIngress())
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	cli.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	cli.GatewayApi = true
+	siteConfig, err := cli.SiteConfigCreate(ctx, spec)
+	assert.Assert(t, err)
+	assert.Equal(t, siteConfig.Spec.Ingress, types.IngressGatewayString)
+	assert.Equal(t, siteConfig.Spec.GatewayClass, "example")
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	gateway, err := cli.DynamicClient.Resource(kube.GatewayResource).Namespace("skupper").Get(types.RouterGatewayName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	class, _, _ := unstructured.NestedString(gateway.Object, "spec", "gatewayClassName")
+	assert.Equal(t, class, "example")
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	assert.Equal(t, len(listeners), 3)
+	host, err := kube.GetTLSRouteHost(types.EdgeRouteName, "skupper", cli.DynamicClient)
+	assert.Assert(t, err)
+	assert.Equal(t, host, "skupper-edge-skupper.apps.example.com")
+	host, port, localOnly, err := cli.claimsEndpoint("skupper", siteConfig)
+	assert.Assert(t, err)
+	assert.Equal(t, host, "skupper-claims-skupper.apps.example.com")
+	assert.Equal(t, port, "443")
+	assert.Assert(t, !localOnly)
+
+	token, _, err := cli.ConnectorTokenCreate(ctx, "conn1", "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-host"], "skupper-inter-router-skupper.apps.example.com")
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-port"], "443")
+}
+
+func TestGatewayIngressNotInstalled(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	spec := types.SiteConfigSpec{
+		SkupperName:  "skupper",
+		RouterMode:   string(types.TransportModeInterior),
+		Ingress:      types.IngressGatewayString,
+		IngressHost:  "apps.example.com",
+		GatewayClass: "example",
+	}
+	_, err = cli.SiteConfigCreate(ctx, spec)
+	assert.Error(t, err, "Gateway API not detected in the cluster for --ingress type gateway")
+	err = cli.RouterCreate(ctx, types.SiteConfig{Spec: spec})
+	assert.Error(t, err, "Gateway API not detected in the cluster for --ingress type gateway")
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// ingressHost returns the host under the ingress domain through which the
+// named endpoint of the router is reached when Kubernetes Ingress
+// resources are used. As with routes, the namespace is included so that
+// sites in different namespaces can share an ingress domain.
+func ingressHost(name string, namespace string, domain string) string {
+	return fmt.Sprintf("%s-%s.%s", name, namespace, domain)
+}
+
+// createRouterIngresses creates an Ingress for each of the inter-router
+// and edge endpoints of the router, and for the claims endpoint of the
+// service controller, passing TLS through to them
+func (cli *VanClient) createRouterIngresses(spec *types.SiteConfigSpec, namespace string, ownerRefs []metav1.OwnerReference) error {
+	if cli.DynamicClient == nil {
+		return fmt.Errorf("Ingress resources are not supported by this client")
+	}
+	type endpoint struct {
+		name    string
+		service string
+		port    int
+	}
+	endpoints := []endpoint{
+		{types.InterRouterRouteName, types.TransportServiceName, int(types.InterRouterListenerPort)},
+		{types.EdgeRouteName, types.TransportServiceName, int(types.EdgeListenerPort)},
+	}
+	if spec.EnableController {
+		endpoints = append(endpoints, endpoint{types.ClaimsServiceName, types.ClaimsServiceName, int(types.ClaimsPort)})
+	}
+	for _, endpoint := range endpoints {
+		ingress := kube.NewIngressWithPassthrough(endpoint.name, ingressHost(endpoint.name, namespace, spec.IngressHost), endpoint.service, endpoint.port)
+		ingress.SetOwnerReferences(ownerRefs)
+		if _, err := kube.CreateIngress(ingress, namespace, cli.DynamicClient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configureHostPortsFromIngresses(result *RouterHostPorts, cli *VanClient, namespace string) (bool, error) {
+	if cli.DynamicClient == nil {
+		return false, nil
+	}
+	interRouterHost, err1 := kube.GetIngressHost(types.InterRouterRouteName, namespace, cli.DynamicClient)
+	edgeHost, err2 := kube.GetIngressHost(types.EdgeRouteName, namespace, cli.DynamicClient)
+	if err1 != nil && err2 != nil && errors.IsNotFound(err1) && errors.IsNotFound(err2) {
+		return false, nil
+	} else if err1 != nil {
+		return false, err1
+	} else if err2 != nil {
+		return false, err2
+	}
+	result.Edge.Host = edgeHost
+	result.Edge.Port = "443"
+	result.InterRouter.Host = interRouterHost
+	result.InterRouter.Port = "443"
+	result.Hosts = edgeHost + "," + interRouterHost
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+func TestRouterCreateWithIngress(t *testing.T) {
+	ctx := context.Background()
+	spec := types.SiteConfigSpec{
+		SkupperName: "skupper",
+		RouterMode:  string(types.TransportModeInterior),
+		Ingress:     types.IngressKubernetesString,
+	}
+	assert.Error(t, spec.CheckIngress(), "An ingress host is required with --ingress ingress")
+	spec.IngressHost = "apps.example.com"
+	assert.Assert(t, spec.CheckIngress())
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	cli.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	siteConfig, err := cli.SiteConfigCreate(ctx, spec)
+	assert.Assert(t, err)
+	siteConfig, err = cli.SiteConfigInspect(ctx, nil)
+	assert.Assert(t, err)
+	assert.Equal(t, siteConfig.Spec.IngressHost, "apps.example.com")
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	host, err := kube.GetIngressHost(types.InterRouterRouteName, "skupper", cli.DynamicClient)
+	assert.Assert(t, err)
+	assert.Equal(t, host, "skupper-inter-router-skupper.apps.example.com")
+	ingress, err := cli.DynamicClient.Resource(kube.IngressResource).Namespace("skupper").Get(types.EdgeRouteName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, ingress.GetAnnotations()["nginx.ingress.kubernetes.io/ssl-passthrough"], "true")
+
+	service, err := kube.GetService(types.TransportServiceName, "skupper", cli.KubeClient)
+	assert.Assert(t, err)
+	assert.Equal(t, service.Spec.Type, corev1.ServiceTypeClusterIP)
+
+	token, _, err := cli.ConnectorTokenCreate(ctx, "conn1", "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-host"], "skupper-inter-router-skupper.apps.example.com")
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-port"], "443")
+	assert.Equal(t, token.ObjectMeta.Annotations["edge-host"], "skupper-edge-skupper.apps.example.com")
+}
+
+func TestTokenCreateWithoutIngress(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	// a client not permitted to read ingresses
+	dc.PrependReactor("*", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(kube.IngressResource.GroupResource(), "", fmt.Errorf("not permitted"))
+	})
+	cli.DynamicClient = dc
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName: "skupper",
+		RouterMode:  string(types.TransportModeInterior),
+		Ingress:     types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	token, _, err := cli.ConnectorTokenCreate(ctx, "conn1", "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-host"], "skupper-router.skupper")
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
@@ -13,6 +14,14 @@ import (
 )
 
 func (cli *VanClient) getConsoleUrl() (string, error) {
+	if cli.DynamicClient != nil {
+		host, err := kube.GetIngressHost(types.ConsoleRouteName, cli.Namespace, cli.DynamicClient)
+		if err == nil {
+			return "https://" + host, nil
+		} else if !errors.IsNotFound(err) {
+			return "", err
+		}
+	}
 	if cli.RouteClient == nil {
 		service, err := cli.KubeClient.CoreV1().Services(cli.Namespace).Get(types.ControllerServiceName, metav1.GetOptions{})
 		if err != nil {
@@ -20,7 +29,12 @@ func (cli *VanClient) getConsoleUrl() (string, error) {
 		} else {
 			if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
 				host := kube.GetLoadBalancerHostOrIp(service)
-				return "http://" + host + ":8080", nil
+				scheme := "http://"
+				siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
+				if err == nil && siteConfig != nil && siteConfig.Spec.ConsoleServesTls() {
+					scheme = "https://"
+				}
+				return scheme + host + ":8080", nil
 			} else {
 				return "", nil
 			}
@@ -36,6 +50,9 @@ func (cli *VanClient) getConsoleUrl() (string, error) {
 }
 
 func (cli *VanClient) RouterInspect(ctx context.Context) (*types.RouterInspectResponse, error) {
+	if cli.Driver != nil {
+		return cli.Driver.RouterInspect(ctx)
+	}
 	return cli.RouterInspectNamespace(ctx, cli.Namespace)
 }
 
@@ -77,6 +94,10 @@ func (cli *VanClient) RouterInspectNamespace(ctx context.Context, namespace stri
 		} else {
 			vir.ExposedServices = len(vsis)
 		}
+		controller, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+		if err == nil {
+			vir.SelfTest = controller.ObjectMeta.Annotations[types.SelfTestAnnotation]
+		}
 		url, err := cli.getConsoleUrl()
 		if url != "" {
 			vir.ConsoleUrl = url
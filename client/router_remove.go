@@ -12,6 +12,12 @@ import (
 
 // RouterRemove delete a VAN (router and controller) deployment
 func (cli *VanClient) RouterRemove(ctx context.Context) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.RouterRemove(ctx)
+	}
 	err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Delete(types.TransportDeploymentName, &metav1.DeleteOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestCheckRouters(t *testing.T) {
+	spec := types.SiteConfigSpec{RouterMode: string(types.TransportModeInterior)}
+	assert.Assert(t, spec.CheckRouters())
+	spec.Replicas = 3
+	assert.Assert(t, spec.CheckRouters())
+	spec.Replicas = -1
+	assert.Error(t, spec.CheckRouters(), "Invalid value for routers: -1")
+	spec.Replicas = 2
+	spec.RouterMode = string(types.TransportModeEdge)
+	assert.Error(t, spec.CheckRouters(), "An edge site cannot have more than one router")
+}
+
+func TestRouterCreateWithReplicas(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+		Replicas:         3,
+	})
+	assert.Assert(t, err)
+	assert.Equal(t, siteConfig.Spec.Replicas, int32(3))
+	inspected, err := cli.SiteConfigInspect(ctx, nil)
+	assert.Assert(t, err)
+	assert.Equal(t, inspected.Spec.Replicas, int32(3))
+
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+	router, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, *router.Spec.Replicas, int32(3))
+	affinity := router.Spec.Template.Spec.Affinity
+	assert.Assert(t, affinity != nil && affinity.PodAntiAffinity != nil)
+	term := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+	assert.Equal(t, term.TopologyKey, "kubernetes.io/hostname")
+	assert.Equal(t, term.LabelSelector.MatchLabels["skupper.io/component"], types.TransportComponentName)
+	found := false
+	for _, env := range router.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "QDROUTERD_AUTO_MESH_DISCOVERY" {
+			found = env.Value == "QUERY"
+		}
+	}
+	assert.Assert(t, found, "replicas are not configured to discover each other")
+}
@@ -17,51 +17,111 @@ import (
 	routev1 "github.com/openshift/api/route/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/kube"
 	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/statestore"
 	"github.com/skupperproject/skupper/pkg/utils"
 )
 
 func (cli *VanClient) RouterUpdateVersion(ctx context.Context, hup bool) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
 	return cli.RouterUpdateVersionInNamespace(ctx, hup, cli.Namespace)
 }
 
-func (cli *VanClient) updateStarted(from string, namespace string, ownerrefs []metav1.OwnerReference) error {
-	cm := &corev1.ConfigMap{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "ConfigMap",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            "skupper-update-state",
-			OwnerReferences: ownerrefs,
-		},
+// updateStarted records the state of the site before an update changes
+// anything, so that an update that fails part way through can either be
+// resumed or rolled back (see RouterUpdateRollback)
+func (cli *VanClient) updateStarted(from string, namespace string, ownerrefs []metav1.OwnerReference, router *appsv1.Deployment, controller *appsv1.Deployment) error {
+	routerTemplate, err := encodePodTemplate(router)
+	if err != nil {
+		return err
+	}
+	controllerTemplate, err := encodePodTemplate(controller)
+	if err != nil {
+		return err
+	}
+	record := &statestore.Record{
+		Name:    updateStateConfigMapName,
+		Schema:  updateStateSchema,
+		Version: updateStateSchemaVersion,
 		Data: map[string]string{
-			"from": from,
+			updateStateFrom:               from,
+			updateStateCheckpoint:         updateCheckpointStarted,
+			updateStateRouterTemplate:     routerTemplate,
+			updateStateControllerTemplate: controllerTemplate,
 		},
 	}
-	_, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Create(cm)
-	if err != nil {
+	if err := cli.stateStore(namespace, ownerrefs).Create(record); err != nil {
 		return err
 	}
+	cli.updateProgress(updateCheckpointStarted)
 	return nil
 }
 
 func (cli *VanClient) updateCompleted(namespace string) error {
-	return cli.KubeClient.CoreV1().ConfigMaps(namespace).Delete("skupper-update-state", &metav1.DeleteOptions{})
+	return cli.stateStore(namespace, nil).Delete(updateStateConfigMapName)
 }
 
-func (cli *VanClient) isUpdating(namespace string) (bool, string, error) {
-	cm, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get("skupper-update-state", metav1.GetOptions{})
-	if errors.IsNotFound(err) {
-		return false, "", nil
+// getUpdateState returns the state recorded for an update in progress,
+// or nil if there is none
+func (cli *VanClient) getUpdateState(namespace string) (*statestore.Record, error) {
+	record, err := cli.stateStore(namespace, nil).Get(updateStateConfigMapName)
+	if err == statestore.ErrNotFound {
+		return nil, nil
 	} else if err != nil {
+		return nil, err
+	}
+	if err := record.Check(updateStateSchema, updateStateSchemaVersion); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (cli *VanClient) isUpdating(namespace string) (bool, string, error) {
+	record, err := cli.getUpdateState(namespace)
+	if err != nil || record == nil {
 		return false, "", err
 	}
-	return true, cm.Data["from"], nil
+	return true, record.Data[updateStateFrom], nil
 }
 
 func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bool, namespace string) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	if err := cli.checkCluster("Updating the site"); err != nil {
+		return false, err
+	}
+	hooks, err := cli.getHooks(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := hooks[types.HookPreUpdate]; ok {
+		check, err := cli.RouterCheckUpdate(ctx, namespace)
+		if err != nil {
+			return false, err
+		}
+		if hup || check.UpdateAvailable() {
+			err = cli.runHook(ctx, hooks, types.HookPreUpdate, namespace, Version)
+			if err != nil {
+				return false, fmt.Errorf("Update not applied: %w", err)
+			}
+		}
+	}
+	updated, err := cli.updateVersionInNamespace(ctx, hup, namespace)
+	if err == nil && updated {
+		err = cli.runHook(ctx, hooks, types.HookPostUpdate, namespace, Version)
+		if err != nil {
+			return true, fmt.Errorf("Update applied, but %w", err)
+		}
+	}
+	return updated, err
+}
+
+func (cli *VanClient) updateVersionInNamespace(ctx context.Context, hup bool, namespace string) (bool, error) {
 	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get(types.TransportConfigMapName, metav1.GetOptions{})
 	if err != nil {
 		return false, err
@@ -77,24 +137,38 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 		// site is newer than client library, cannot update
 		return false, fmt.Errorf("Site (%s) is newer than library (%s); cannot update", site.Version, Version)
 	}
+	err = cli.verifyUpdateImages(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
 	rename := false
 	inprogress, originalVersion, err := cli.isUpdating(namespace)
 	if err != nil {
 		return false, err
 	}
+	versionUpdate := utils.MoreRecentThanVersion(Version, site.Version) || (utils.EquivalentVersion(Version, site.Version) && Version != site.Version)
 	if inprogress {
 		rename = utils.LessRecentThanVersion(originalVersion, "0.5.0")
-	}
-	if utils.MoreRecentThanVersion(Version, site.Version) || (utils.EquivalentVersion(Version, site.Version) && Version != site.Version) {
-		if !inprogress && utils.LessRecentThanVersion(site.Version, "0.5.0") {
-			rename = true
-			err = cli.updateStarted(site.Version, namespace, configmap.ObjectMeta.OwnerReferences)
+	} else {
+		router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		controller, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		imageUpdate := router.Spec.Template.Spec.Containers[0].Image != GetRouterImageName() || controller.Spec.Template.Spec.Containers[0].Image != GetServiceControllerImageName()
+		if versionUpdate || imageUpdate || hup {
+			rename = versionUpdate && utils.LessRecentThanVersion(site.Version, "0.5.0")
+			err = cli.updateStarted(site.Version, namespace, configmap.ObjectMeta.OwnerReferences, router, controller)
 			if err != nil {
 				return false, err
 			}
 			inprogress = true
 		}
-
+	}
+	if versionUpdate {
 		// site is marked as older than library, need to update
 		updateSite = true
 
@@ -109,11 +183,19 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 		if err != nil {
 			return false, err
 		}
+		err = cli.updateCheckpoint(namespace, updateCheckpointSiteVersion)
+		if err != nil {
+			return false, err
+		}
 	}
 	usingRoutes := false
 	consoleUsesLoadbalancer := false
 	routerExposedAsIp := false
 	if rename {
+		clusterDomain, err := cli.clusterDomainInNamespace(ctx, namespace)
+		if err != nil {
+			return false, err
+		}
 		//create new resources (as copies of old ones)
 		// services
 		_, err = kube.CopyService("skupper-messaging", types.LocalTransportServiceName, map[string]string{}, namespace, cli.KubeClient)
@@ -163,7 +245,7 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 			CA:          types.LocalCaSecret,
 			Name:        types.LocalServerSecret,
 			Subject:     types.LocalTransportServiceName,
-			Hosts:       []string{types.LocalTransportServiceName, qualifiedServiceName(types.LocalTransportServiceName, namespace)},
+			Hosts:       []string{types.LocalTransportServiceName, kube.QualifiedServiceName(types.LocalTransportServiceName, namespace, clusterDomain)},
 			ConnectJson: false,
 		})
 		credentials = append(credentials, types.Credential{
@@ -182,7 +264,7 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 				return false, err
 			}
 		} else {
-			hosts, err := cli.getTransportHosts(namespace)
+			hosts, err := cli.getTransportHosts(namespace, clusterDomain)
 			if err != nil {
 				return false, err
 			}
@@ -337,6 +419,10 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 				return false, err
 			}
 		}
+		err = cli.updateCheckpoint(namespace, updateCheckpointResourcesCreated)
+		if err != nil {
+			return false, err
+		}
 	}
 
 	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
@@ -357,6 +443,14 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 
 		updateRouter = true
 	}
+	if kube.HasSecretVolume(&router.Spec.Template.Spec, types.ConsoleUsersSecret) {
+		err = cli.createRouterConsoleUsers(namespace)
+		if err != nil {
+			return false, err
+		}
+		kube.UpdateSecretVolume(&router.Spec.Template.Spec, types.ConsoleUsersSecret, types.RouterConsoleUsersSecret)
+		updateRouter = true
+	}
 	desiredRouterImage := GetRouterImageName()
 	if router.Spec.Template.Spec.Containers[0].Image != desiredRouterImage {
 		router.Spec.Template.Spec.Containers[0].Image = desiredRouterImage
@@ -368,11 +462,24 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 			touch(router)
 			updateRouter = true
 		}
-		_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(router)
+		siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+		if err != nil {
+			return false, err
+		}
+		// a dry run has no router pods to bake a canary in
+		if siteConfig != nil && usesCanaryUpdate(&siteConfig.Spec, router) && cli.planner == nil {
+			err = cli.canaryRouterUpdate(router, namespace, siteConfig.Spec.CanaryBakeTime)
+		} else {
+			_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(router)
+		}
 		if err != nil {
 			return false, err
 		}
-		if routerExposedAsIp {
+		err = cli.updateCheckpoint(namespace, updateCheckpointRouterUpdated)
+		if err != nil {
+			return false, err
+		}
+		if routerExposedAsIp && cli.planner == nil {
 			fmt.Println("Sites previously linked to this one will require new tokens")
 		}
 	}
@@ -408,10 +515,16 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 		if err != nil {
 			return false, err
 		}
-		if consoleUsesLoadbalancer {
+		err = cli.updateCheckpoint(namespace, updateCheckpointControllerUpdated)
+		if err != nil {
+			return false, err
+		}
+		if consoleUsesLoadbalancer && cli.planner == nil {
 			host := ""
+			started := time.Now()
 			for i := 0; host == "" && i < 120; i++ {
 				if i > 0 {
+					cli.progress(progressLoadBalancer, 0, 0, "Waiting for LoadBalancer IP or hostname of "+types.ControllerServiceName, started)
 					time.Sleep(time.Second)
 				}
 				service, err := kube.GetService(types.ControllerServiceName, namespace, cli.KubeClient)
@@ -427,7 +540,12 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 		}
 	}
 	if rename {
-		//delete old resources
+		//delete old resources; past this point the update can only be
+		//completed, not rolled back
+		err = cli.updateCheckpoint(namespace, updateCheckpointRemovingResources)
+		if err != nil {
+			return false, err
+		}
 		if cli.RouteClient != nil {
 			err = cli.RouteClient.Routes(namespace).Delete("skupper-controller", &metav1.DeleteOptions{})
 			if err != nil && !errors.IsNotFound(err) {
@@ -461,7 +579,9 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 		}
 		for _, secret := range secrets {
 			err = cli.KubeClient.CoreV1().Secrets(namespace).Delete(secret, &metav1.DeleteOptions{})
-			if err != nil && !errors.IsNotFound(err) {
+			if err == nil {
+				certs.RecordSecretChange(certs.SecretDeleted)
+			} else if !errors.IsNotFound(err) {
 				return false, err
 			}
 		}
@@ -502,11 +622,45 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 		if err != nil {
 			return true, err
 		}
+		cli.updateProgress(updateCheckpointCompleted)
 	}
 	return updateRouter || updateController || updateSite, nil
 }
 
+func (cli *VanClient) verifyUpdateImages(ctx context.Context, namespace string) error {
+	siteConfig, err := cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	if err != nil {
+		return err
+	}
+	if siteConfig == nil {
+		return nil
+	}
+	images := []string{}
+	deployments := []struct {
+		name    string
+		desired string
+	}{
+		{types.TransportDeploymentName, GetRouterImageName()},
+		{types.ControllerDeploymentName, GetServiceControllerImageName()},
+	}
+	for _, d := range deployments {
+		deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(d.name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if deployment.Spec.Template.Spec.Containers[0].Image != d.desired {
+			images = append(images, d.desired)
+		}
+	}
+	return cli.verifyImages(siteConfig.Spec.ImageVerification, images...)
+}
+
 func (cli *VanClient) RouterUpdateLogging(ctx context.Context, settings *corev1.ConfigMap, hup bool) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
 	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
 	if err != nil {
 		return false, err
@@ -543,6 +697,9 @@ func (cli *VanClient) RouterUpdateLogging(ctx context.Context, settings *corev1.
 }
 
 func (cli *VanClient) RouterUpdateDebugMode(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
 	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
 	if err != nil {
 		return false, err
@@ -568,6 +725,85 @@ func (cli *VanClient) RouterUpdateDebugMode(ctx context.Context, settings *corev
 
 }
 
+// RouterUpdateNodePorts applies the node or host ports pinned in the
+// site config to the router service or deployment
+func (cli *VanClient) RouterUpdateNodePorts(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
+	if err != nil {
+		return false, err
+	}
+	if err := siteConfig.Spec.CheckNodePorts(); err != nil {
+		return false, err
+	}
+	namespace := settings.ObjectMeta.Namespace
+	pinned := siteConfig.Spec.NodePorts
+	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	hostPorts := types.RouterNodePorts{}
+	if pinned.HostPort {
+		hostPorts = pinned
+	}
+	updated := false
+	if setHostPorts(router.Spec.Template.Spec.Containers[0].Ports, hostPorts) {
+		_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(router)
+		if err != nil {
+			return false, err
+		}
+		updated = true
+	}
+	if pinned.IsEnabled() && !pinned.HostPort {
+		service, err := kube.GetService(types.TransportServiceName, namespace, cli.KubeClient)
+		if err != nil {
+			return updated, err
+		}
+		if setNodePorts(service.Spec.Ports, pinned) {
+			if err := cli.checkNodePortsAvailable(namespace, pinned); err != nil {
+				return updated, err
+			}
+			_, err = cli.KubeClient.CoreV1().Services(namespace).Update(service)
+			if err != nil {
+				return updated, err
+			}
+			updated = true
+		}
+	}
+	return updated, nil
+}
+
+// RouterUpdateHostAliases adds any host aliases in the site config to
+// the router pods. Aliases are never removed here, as they may have
+// been added for individual links.
+func (cli *VanClient) RouterUpdateHostAliases(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
+	if err != nil {
+		return false, err
+	}
+	if len(siteConfig.Spec.HostAliases) == 0 {
+		return false, nil
+	}
+	namespace := settings.ObjectMeta.Namespace
+	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !kube.MergeHostAliases(&router.Spec.Template.Spec, siteConfig.Spec.HostAliases) {
+		return false, nil
+	}
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(router)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (cli *VanClient) updateAnnotationsOnDeployment(ctx context.Context, namespace string, name string, annotations map[string]string) (bool, error) {
 	deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
 	if err != nil {
@@ -585,6 +821,9 @@ func (cli *VanClient) updateAnnotationsOnDeployment(ctx context.Context, namespa
 }
 
 func (cli *VanClient) RouterUpdateAnnotations(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
 	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
 	if err != nil {
 		return false, err
@@ -600,6 +839,9 @@ func (cli *VanClient) RouterUpdateAnnotations(ctx context.Context, settings *cor
 	for key, value := range siteConfig.Spec.Annotations {
 		transportAnnotations[key] = value
 	}
+	for key, value := range siteConfig.Spec.RouterEgress.Annotations {
+		transportAnnotations[key] = value
+	}
 	updated, err = cli.updateAnnotationsOnDeployment(ctx, settings.ObjectMeta.Namespace, types.TransportDeploymentName, transportAnnotations)
 	if err != nil {
 		return updated, err
@@ -608,6 +850,9 @@ func (cli *VanClient) RouterUpdateAnnotations(ctx context.Context, settings *cor
 }
 
 func (cli *VanClient) RouterRestart(ctx context.Context, namespace string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
 	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
 	if err != nil {
 		return err
@@ -650,7 +895,7 @@ func (cli *VanClient) usingRoutes(namespace string) (bool, error) {
 	}
 }
 
-func (cli *VanClient) getTransportHosts(namespace string) ([]string, error) {
+func (cli *VanClient) getTransportHosts(namespace string, clusterDomain string) ([]string, error) {
 	hosts := []string{}
 	oldService, err := kube.GetService("skupper-internal", namespace, cli.KubeClient)
 	if err != nil {
@@ -658,8 +903,10 @@ func (cli *VanClient) getTransportHosts(namespace string) ([]string, error) {
 	}
 	if oldService.Spec.Type == corev1.ServiceTypeLoadBalancer {
 		host := ""
+		started := time.Now()
 		for i := 0; i < 120; i++ {
 			if i > 0 {
+				cli.progress(progressLoadBalancer, 0, 0, "Waiting for LoadBalancer IP or hostname of "+types.TransportServiceName, started)
 				time.Sleep(time.Second)
 			}
 			service, err := kube.GetService(types.TransportServiceName, namespace, cli.KubeClient)
@@ -671,6 +918,10 @@ func (cli *VanClient) getTransportHosts(namespace string) ([]string, error) {
 				hosts = append(hosts, host)
 				break
 			}
+			if cli.planner != nil {
+				// the copy made by a dry run is never assigned an address
+				break
+			}
 		}
 		host = kube.GetLoadBalancerHostOrIP(oldService)
 		if host != "" {
@@ -678,11 +929,7 @@ func (cli *VanClient) getTransportHosts(namespace string) ([]string, error) {
 		}
 	}
 	hosts = append(hosts, types.TransportServiceName)
-	hosts = append(hosts, qualifiedServiceName(types.TransportServiceName, namespace))
-	hosts = append(hosts, qualifiedServiceName("skupper-internal", namespace))
+	hosts = append(hosts, kube.QualifiedServiceName(types.TransportServiceName, namespace, clusterDomain))
+	hosts = append(hosts, kube.QualifiedServiceName("skupper-internal", namespace, clusterDomain))
 	return hosts, nil
 }
-
-func qualifiedServiceName(name string, namespace string) string {
-	return name + "." + namespace + ".svc.cluster.local"
-}
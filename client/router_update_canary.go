@@ -0,0 +1,252 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	canaryLabel          string        = "skupper.io/canary"
+	canaryReadyTimeout   time.Duration = 5 * time.Minute
+	canaryCheckInterval  time.Duration = 10 * time.Second
+	canaryDeploymentName string        = types.TransportDeploymentName + "-canary"
+)
+
+func usesCanaryUpdate(spec *types.SiteConfigSpec, router *appsv1.Deployment) bool {
+	return spec != nil && spec.IsCanaryUpdate() && router.Spec.Replicas != nil && *router.Spec.Replicas > 1
+}
+
+// canaryRouterUpdate applies the updated router deployment to a single
+// canary replica first, in place of one of the existing replicas. If
+// the canary keeps the links and bridges of the existing replicas for
+// the whole of the bake time, the update is applied to the rest;
+// otherwise the canary is removed and the replica restored.
+func (cli *VanClient) canaryRouterUpdate(router *appsv1.Deployment, namespace string, bakeTime time.Duration) error {
+	if bakeTime <= 0 {
+		bakeTime = types.DefaultCanaryBakeTime
+	}
+	replicas := *router.Spec.Replicas
+	err := cli.scaleRouter(namespace, replicas-1)
+	if err != nil {
+		return err
+	}
+	canary := newCanaryDeployment(router)
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Create(canary)
+	if err == nil {
+		fmt.Printf("Updating canary router replica; waiting %s before updating the remaining replicas", bakeTime)
+		fmt.Println()
+		err = cli.bakeCanary(namespace, bakeTime)
+	}
+	if err != nil {
+		if rollbackErr := cli.removeCanary(namespace, replicas); rollbackErr != nil {
+			return fmt.Errorf("Canary router update failed: %s (could not roll back: %s)", err, rollbackErr)
+		}
+		return fmt.Errorf("Canary router update failed, update rolled back: %s", err)
+	}
+	current, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	current.Spec.Template = router.Spec.Template
+	current.Spec.Replicas = &replicas
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(current)
+	if err != nil {
+		return err
+	}
+	_, err = kube.WaitDeploymentReadyReplicas(types.TransportDeploymentName, namespace, int(replicas), cli.KubeClient, canaryReadyTimeout, time.Second*5)
+	if err != nil {
+		fmt.Println("Router replicas not yet ready after update:", err.Error())
+	}
+	return kube.DeleteDeployment(canaryDeploymentName, namespace, cli.KubeClient)
+}
+
+func newCanaryDeployment(router *appsv1.Deployment) *appsv1.Deployment {
+	one := int32(1)
+	canary := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            canaryDeploymentName,
+			Labels:          map[string]string{},
+			OwnerReferences: router.ObjectMeta.OwnerReferences,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &one,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{},
+			},
+			Template: *router.Spec.Template.DeepCopy(),
+		},
+	}
+	for k, v := range router.ObjectMeta.Labels {
+		canary.ObjectMeta.Labels[k] = v
+	}
+	canary.ObjectMeta.Labels[canaryLabel] = "true"
+	if router.Spec.Selector != nil {
+		for k, v := range router.Spec.Selector.MatchLabels {
+			canary.Spec.Selector.MatchLabels[k] = v
+		}
+	}
+	canary.Spec.Selector.MatchLabels[canaryLabel] = "true"
+	if canary.Spec.Template.ObjectMeta.Labels == nil {
+		canary.Spec.Template.ObjectMeta.Labels = map[string]string{}
+	}
+	canary.Spec.Template.ObjectMeta.Labels[canaryLabel] = "true"
+	return canary
+}
+
+func (cli *VanClient) scaleRouter(namespace string, replicas int32) error {
+	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	router.Spec.Replicas = &replicas
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(router)
+	return err
+}
+
+func (cli *VanClient) removeCanary(namespace string, replicas int32) error {
+	err := kube.DeleteDeployment(canaryDeploymentName, namespace, cli.KubeClient)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return cli.scaleRouter(namespace, replicas)
+}
+
+func (cli *VanClient) bakeCanary(namespace string, bakeTime time.Duration) error {
+	_, err := kube.WaitDeploymentReady(canaryDeploymentName, namespace, cli.KubeClient, canaryReadyTimeout, time.Second*5)
+	if err != nil {
+		return fmt.Errorf("Canary router did not become ready: %s", err)
+	}
+	pod, err := cli.getCanaryPod(namespace)
+	if err != nil {
+		return err
+	}
+	restarts := routerRestarts(pod)
+	started := time.Now()
+	deadline := started.Add(bakeTime)
+	for {
+		cli.progress(progressCanary, 0, 0, "Checking canary router", started)
+		time.Sleep(canaryCheckInterval)
+		err = cli.checkCanary(namespace, pod.Name, restarts)
+		if err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return nil
+		}
+	}
+}
+
+func (cli *VanClient) getCanaryPod(namespace string) (*corev1.Pod, error) {
+	pods, err := cli.KubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: canaryLabel + "=true"})
+	if err != nil {
+		return nil, err
+	}
+	pod := kube.FirstReadyPod(pods.Items)
+	if pod == nil {
+		return nil, fmt.Errorf("No ready canary router pod found")
+	}
+	return pod, nil
+}
+
+func routerRestarts(pod *corev1.Pod) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "router" {
+			return status.RestartCount
+		}
+	}
+	return 0
+}
+
+// checkCanary compares the links and bridges of the canary with those of
+// one of the existing router replicas
+func (cli *VanClient) checkCanary(namespace string, canaryPodName string, restarts int32) error {
+	canary, err := cli.KubeClient.CoreV1().Pods(namespace).Get(canaryPodName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if !kube.IsPodReady(canary) {
+		return fmt.Errorf("Canary router pod %s is not ready", canaryPodName)
+	}
+	if routerRestarts(canary) > restarts {
+		return fmt.Errorf("Canary router pod %s restarted", canaryPodName)
+	}
+	pods, err := cli.KubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: "skupper.io/component=" + types.TransportComponentName + ",!" + canaryLabel})
+	if err != nil {
+		return err
+	}
+	baseline := kube.FirstReadyPod(pods.Items)
+	if baseline == nil {
+		// nothing to compare with, the canary being ready has to suffice
+		return nil
+	}
+	expectedLinks, err := qdr.GetConnectionsForPod(baseline.Name, namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return err
+	}
+	actualLinks, err := qdr.GetConnectionsForPod(canaryPodName, namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return fmt.Errorf("Could not query canary router: %s", err)
+	}
+	expectedBridges, err := qdr.GetBridgeNamesForPod(baseline.Name, namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return err
+	}
+	actualBridges, err := qdr.GetBridgeNamesForPod(canaryPodName, namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return fmt.Errorf("Could not query canary router: %s", err)
+	}
+	return compareCanary(expectedLinks, actualLinks, expectedBridges, actualBridges)
+}
+
+// compareCanary returns an error if the canary is missing any active
+// outgoing link or any bridge that the baseline replica has
+func compareCanary(expectedLinks []qdr.Connection, actualLinks []qdr.Connection, expectedBridges []string, actualBridges []string) error {
+	links := map[string]bool{}
+	for _, c := range actualLinks {
+		if isActiveLink(c) {
+			links[c.Host] = true
+		}
+	}
+	missing := []string{}
+	for _, c := range expectedLinks {
+		if isActiveLink(c) && !links[c.Host] {
+			missing = append(missing, c.Host)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("Canary router has no active link to %v", missing)
+	}
+	bridges := map[string]bool{}
+	for _, b := range actualBridges {
+		bridges[b] = true
+	}
+	for _, b := range expectedBridges {
+		if !bridges[b] {
+			missing = append(missing, b)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("Canary router is missing bridges %v", missing)
+	}
+	return nil
+}
+
+func isActiveLink(c qdr.Connection) bool {
+	return c.Active && c.Dir == qdr.DirectionOut && (c.Role == "inter-router" || c.Role == "edge")
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestCompareCanary(t *testing.T) {
+	link := func(host string, active bool) qdr.Connection {
+		return qdr.Connection{Host: host, Role: "inter-router", Dir: qdr.DirectionOut, Active: active}
+	}
+	incoming := qdr.Connection{Host: "10.0.0.9:34512", Role: "inter-router", Dir: "in", Active: true}
+	bridges := []string{"tcpListener/a:8080", "tcpConnector/a@10.0.0.5:8080"}
+
+	tests := []struct {
+		name            string
+		expectedLinks   []qdr.Connection
+		actualLinks     []qdr.Connection
+		expectedBridges []string
+		actualBridges   []string
+		err             string
+	}{
+		{
+			name:            "matching",
+			expectedLinks:   []qdr.Connection{link("site-b:55671", true), incoming},
+			actualLinks:     []qdr.Connection{link("site-b:55671", true)},
+			expectedBridges: bridges,
+			actualBridges:   bridges,
+		},
+		{
+			name:          "inactive on baseline",
+			expectedLinks: []qdr.Connection{link("site-b:55671", false)},
+			actualLinks:   []qdr.Connection{},
+		},
+		{
+			name:          "link down",
+			expectedLinks: []qdr.Connection{link("site-b:55671", true), link("site-c:55671", true)},
+			actualLinks:   []qdr.Connection{link("site-b:55671", true), link("site-c:55671", false)},
+			err:           "Canary router has no active link to [site-c:55671]",
+		},
+		{
+			name:            "bridge missing",
+			expectedBridges: bridges,
+			actualBridges:   bridges[:1],
+			err:             "Canary router is missing bridges [tcpConnector/a@10.0.0.5:8080]",
+		},
+	}
+	for _, test := range tests {
+		err := compareCanary(test.expectedLinks, test.actualLinks, test.expectedBridges, test.actualBridges)
+		if test.err == "" {
+			assert.NilError(t, err, test.name)
+		} else {
+			assert.Error(t, err, test.err, test.name)
+		}
+	}
+}
+
+func TestNewCanaryDeployment(t *testing.T) {
+	replicas := int32(3)
+	router := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   types.TransportDeploymentName,
+			Labels: map[string]string{"application": types.TransportDeploymentName},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"skupper.io/component": types.TransportComponentName},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"skupper.io/component": types.TransportComponentName},
+				},
+			},
+		},
+	}
+	canary := newCanaryDeployment(router)
+	assert.Equal(t, canary.Name, types.TransportDeploymentName+"-canary")
+	assert.Equal(t, *canary.Spec.Replicas, int32(1))
+	assert.Equal(t, canary.Spec.Selector.MatchLabels[canaryLabel], "true")
+	assert.Equal(t, canary.Spec.Selector.MatchLabels["skupper.io/component"], types.TransportComponentName)
+	assert.Equal(t, canary.Spec.Template.Labels[canaryLabel], "true")
+	assert.Equal(t, canary.Labels["application"], types.TransportDeploymentName)
+	_, ok := router.Spec.Template.Labels[canaryLabel]
+	assert.Assert(t, !ok, "router template should not be modified")
+
+	assert.Assert(t, usesCanaryUpdate(&types.SiteConfigSpec{UpdateStrategy: types.UpdateStrategyCanary}, router))
+	assert.Assert(t, !usesCanaryUpdate(&types.SiteConfigSpec{UpdateStrategy: types.UpdateStrategyRolling}, router))
+	replicas = 1
+	assert.Assert(t, !usesCanaryUpdate(&types.SiteConfigSpec{UpdateStrategy: types.UpdateStrategyCanary}, router))
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+// RouterCheckUpdate reports what RouterUpdateVersionInNamespace would
+// change for the site in the given namespace, without changing anything
+func (cli *VanClient) RouterCheckUpdate(ctx context.Context, namespace string) (*types.RouterUpdateCheckResponse, error) {
+	if namespace == "" {
+		namespace = cli.Namespace
+	}
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get(types.TransportConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	config, err := qdr.GetRouterConfigFromConfigMap(configmap)
+	if err != nil {
+		return nil, err
+	}
+	site := config.GetSiteMetadata()
+	result := &types.RouterUpdateCheckResponse{
+		SiteVersion:    site.Version,
+		LibraryVersion: Version,
+	}
+	if utils.LessRecentThanVersion(Version, site.Version) {
+		result.SiteIsNewer = true
+	} else if utils.MoreRecentThanVersion(Version, site.Version) || (utils.EquivalentVersion(Version, site.Version) && Version != site.Version) {
+		result.VersionUpdate = true
+		result.RenameRequired = utils.LessRecentThanVersion(site.Version, "0.5.0")
+	}
+	inprogress, originalVersion, err := cli.isUpdating(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if inprogress {
+		result.UpdateInProgress = true
+		result.RenameRequired = utils.LessRecentThanVersion(originalVersion, "0.5.0")
+	}
+
+	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result.RouterImage = router.Spec.Template.Spec.Containers[0].Image
+	result.DesiredRouterImage = GetRouterImageName()
+	result.RouterImageUpdate = result.RouterImage != result.DesiredRouterImage
+
+	controller, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result.ControllerImage = controller.Spec.Template.Spec.Containers[0].Image
+	result.DesiredControllerImage = GetServiceControllerImageName()
+	result.ControllerImageUpdate = result.ControllerImage != result.DesiredControllerImage
+
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestRouterCheckUpdate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Check(t, err)
+
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:       "skupper",
+			RouterMode:        string(types.TransportModeInterior),
+			EnableController:  true,
+			EnableServiceSync: true,
+			Ingress:           types.IngressNoneString,
+		},
+	})
+	assert.Check(t, err, "Unable to create VAN router")
+
+	result, err := cli.RouterCheckUpdate(ctx, "")
+	assert.Assert(t, err)
+	assert.Equal(t, result.SiteVersion, Version)
+	assert.Equal(t, result.LibraryVersion, Version)
+	assert.Assert(t, !result.UpdateAvailable())
+
+	router, err := cli.KubeClient.AppsV1().Deployments("skupper").Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	router.Spec.Template.Spec.Containers[0].Image = "quay.io/skupper/qdrouterd:old"
+	_, err = cli.KubeClient.AppsV1().Deployments("skupper").Update(router)
+	assert.Assert(t, err)
+
+	result, err = cli.RouterCheckUpdate(ctx, "skupper")
+	assert.Assert(t, err)
+	assert.Assert(t, result.RouterImageUpdate)
+	assert.Assert(t, !result.ControllerImageUpdate)
+	assert.Assert(t, !result.RenameRequired)
+	assert.Equal(t, result.RouterImage, "quay.io/skupper/qdrouterd:old")
+	assert.Assert(t, result.UpdateAvailable())
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+const legacyTransportServiceName string = "skupper-internal"
+
+func legacyTransportHosts(service *corev1.Service, namespace string, clusterDomain string) []string {
+	hosts := []string{
+		legacyTransportServiceName,
+		legacyTransportServiceName + "." + namespace,
+		legacyTransportServiceName + "." + namespace + ".svc",
+		kube.QualifiedServiceName(legacyTransportServiceName, namespace, clusterDomain),
+	}
+	if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != corev1.ClusterIPNone {
+		hosts = append(hosts, service.Spec.ClusterIP)
+	}
+	if host := kube.GetLoadBalancerHostOrIP(service); host != "" {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// RouterLegacyReferences returns the links, in any namespace visible to
+// the client, whose tokens still refer to the transport service in use
+// before 0.5.0. Links from sites in other clusters cannot be inspected.
+func (cli *VanClient) RouterLegacyReferences(ctx context.Context, namespace string) ([]string, error) {
+	service, err := kube.GetService(legacyTransportServiceName, namespace, cli.KubeClient)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	clusterDomain, err := cli.clusterDomainInNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	hosts := map[string]bool{}
+	for _, host := range legacyTransportHosts(service, namespace, clusterDomain) {
+		hosts[host] = true
+	}
+	tokens, err := cli.KubeClient.CoreV1().Secrets(metav1.NamespaceAll).List(metav1.ListOptions{LabelSelector: types.TypeTokenQualifier})
+	if err != nil {
+		return nil, fmt.Errorf("Could not inspect links: %w", err)
+	}
+	references := []string{}
+	for _, token := range tokens.Items {
+		for _, role := range []string{"inter-router", "edge"} {
+			host := token.ObjectMeta.Annotations[role+"-host"]
+			if host != "" && hosts[host] {
+				references = append(references, token.ObjectMeta.Namespace+"/"+token.ObjectMeta.Name)
+				break
+			}
+		}
+	}
+	return references, nil
+}
+
+// RouterFinalizeLegacyUpdate removes the resources that were retained
+// when the site was updated from a version prior to 0.5.0 and returns
+// their names. Unless force is set, nothing is removed while links
+// still refer to those resources.
+func (cli *VanClient) RouterFinalizeLegacyUpdate(ctx context.Context, force bool) ([]string, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	inprogress, _, err := cli.isUpdating(cli.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if inprogress {
+		return nil, fmt.Errorf("An update is still in progress; run 'skupper update' to complete it first")
+	}
+	if !force {
+		references, err := cli.RouterLegacyReferences(ctx, cli.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if len(references) > 0 {
+			return nil, fmt.Errorf("Legacy resources are still referenced by links: %s", strings.Join(references, ", "))
+		}
+	}
+	removed := []string{}
+	err = cli.KubeClient.CoreV1().Services(cli.Namespace).Delete(legacyTransportServiceName, &metav1.DeleteOptions{})
+	if err == nil {
+		removed = append(removed, "service/"+legacyTransportServiceName)
+	} else if !errors.IsNotFound(err) {
+		return removed, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestRouterFinalizeLegacyUpdate(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Check(t, err)
+
+	removed, err := cli.RouterFinalizeLegacyUpdate(ctx, false)
+	assert.Assert(t, err)
+	assert.Equal(t, len(removed), 0)
+
+	_, err = cli.KubeClient.CoreV1().Services("skupper").Create(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "skupper-internal"},
+	})
+	assert.Assert(t, err)
+	_, err = cli.KubeClient.CoreV1().Secrets("other").Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "conn1",
+			Labels: map[string]string{types.SkupperTypeQualifier: types.TypeToken},
+			Annotations: map[string]string{
+				"inter-router-host": "skupper-internal.skupper.svc.cluster.local",
+				"inter-router-port": "55671",
+			},
+		},
+	})
+	assert.Assert(t, err)
+
+	_, err = cli.RouterFinalizeLegacyUpdate(ctx, false)
+	assert.Error(t, err, "Legacy resources are still referenced by links: other/conn1")
+
+	removed, err = cli.RouterFinalizeLegacyUpdate(ctx, true)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, removed, []string{"service/skupper-internal"})
+	_, err = cli.KubeClient.CoreV1().Services("skupper").Get("skupper-internal", metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err))
+}
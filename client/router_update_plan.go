@@ -0,0 +1,344 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	routev1client "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// updatePlanner collects the changes an update makes when it is run as
+// a dry run
+type updatePlanner struct {
+	plan *types.RouterUpdatePlan
+}
+
+func (p *updatePlanner) add(action string, kind string, name string, detail string) {
+	p.plan.Changes = append(p.plan.Changes, types.RouterUpdateChange{
+		Action: action,
+		Kind:   kind,
+		Name:   name,
+		Detail: detail,
+	})
+}
+
+// record adds the change made by an action the update took against the
+// copy of the site's resources; previous is the resource as it was
+// before an update
+func (p *updatePlanner) record(action k8stesting.Action, previous runtime.Object) {
+	kind := strings.TrimSuffix(action.GetResource().Resource, "s")
+	switch action.GetVerb() {
+	case "create":
+		name := objectName(action.(k8stesting.CreateAction).GetObject())
+		if !isUpdateState(kind, name) {
+			p.add("create", kind, name, "")
+		}
+	case "update":
+		updated := action.(k8stesting.UpdateAction).GetObject()
+		name := objectName(updated)
+		if isUpdateState(kind, name) {
+			return
+		}
+		for _, detail := range describeUpdate(previous, updated) {
+			p.add("update", kind, name, detail)
+		}
+	case "delete":
+		name := action.(k8stesting.DeleteAction).GetName()
+		if !isUpdateState(kind, name) {
+			p.add("delete", kind, name, "")
+		}
+	}
+}
+
+// isUpdateState is true for the record the update keeps of its own
+// progress, which is not a change to the site
+func isUpdateState(kind string, name string) bool {
+	return kind == "configmap" && name == updateStateConfigMapName
+}
+
+func objectName(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetName()
+}
+
+func describeUpdate(previous runtime.Object, updated runtime.Object) []string {
+	details := []string{}
+	switch current := updated.(type) {
+	case *appsv1.Deployment:
+		original, ok := previous.(*appsv1.Deployment)
+		if !ok {
+			break
+		}
+		before := original.Spec.Template.Spec
+		after := current.Spec.Template.Spec
+		if before.ServiceAccountName != after.ServiceAccountName {
+			details = append(details, "service account "+after.ServiceAccountName)
+		}
+		if !reflect.DeepEqual(before.Volumes, after.Volumes) {
+			details = append(details, "renamed secrets")
+		}
+		for i, container := range after.Containers {
+			if i < len(before.Containers) && before.Containers[i].Image != container.Image {
+				details = append(details, fmt.Sprintf("image %s -> %s", before.Containers[i].Image, container.Image))
+			}
+		}
+		if len(details) == 0 {
+			details = append(details, "restart")
+		}
+	case *corev1.ConfigMap:
+		original, ok := previous.(*corev1.ConfigMap)
+		if !ok || current.ObjectMeta.Name != types.TransportConfigMapName {
+			break
+		}
+		before, err := qdr.GetRouterConfigFromConfigMap(original)
+		if err != nil {
+			break
+		}
+		after, err := qdr.GetRouterConfigFromConfigMap(current)
+		if err != nil {
+			break
+		}
+		if from, to := before.GetSiteMetadata().Version, after.GetSiteMetadata().Version; from != to {
+			details = append(details, fmt.Sprintf("site version %s -> %s", from, to))
+		}
+	}
+	if len(details) == 0 {
+		details = append(details, "")
+	}
+	return details
+}
+
+// dryRunClient returns a client that makes its changes to a copy of the
+// site's resources in the namespace, recording them in the planner, so
+// that an operation run with it shows what it would change
+func (cli *VanClient) dryRunClient(namespace string, planner *updatePlanner) (*VanClient, error) {
+	objects := []runtime.Object{}
+	configmaps, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range configmaps.Items {
+		objects = append(objects, &configmaps.Items[i])
+	}
+	secrets, err := cli.KubeClient.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		objects = append(objects, &secrets.Items[i])
+	}
+	services, err := cli.KubeClient.CoreV1().Services(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range services.Items {
+		objects = append(objects, &services.Items[i])
+	}
+	serviceAccounts, err := cli.KubeClient.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range serviceAccounts.Items {
+		objects = append(objects, &serviceAccounts.Items[i])
+	}
+	deployments, err := cli.KubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		objects = append(objects, &deployments.Items[i])
+	}
+	roles, err := cli.KubeClient.RbacV1().Roles(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles.Items {
+		objects = append(objects, &roles.Items[i])
+	}
+	rolebindings, err := cli.KubeClient.RbacV1().RoleBindings(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range rolebindings.Items {
+		objects = append(objects, &rolebindings.Items[i])
+	}
+
+	kubeClient := fake.NewSimpleClientset(objects...)
+	tracker := kubeClient.Tracker()
+	reaction := k8stesting.ObjectReaction(tracker)
+	kubeClient.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		var previous runtime.Object
+		if update, ok := action.(k8stesting.UpdateAction); ok && action.GetVerb() == "update" {
+			previous, _ = tracker.Get(action.GetResource(), action.GetNamespace(), objectName(update.GetObject()))
+		}
+		handled, obj, err := reaction(action)
+		if err == nil {
+			planner.record(action, previous)
+		}
+		return handled, obj, err
+	})
+
+	dryRun := &VanClient{
+		Namespace:   cli.Namespace,
+		KubeClient:  kubeClient,
+		GatewayApi:  cli.GatewayApi,
+		CertManager: cli.CertManager,
+		planner:     planner,
+	}
+	if cli.RouteClient != nil && cli.RestConfig != nil {
+		config := restclient.CopyConfig(cli.RestConfig)
+		config.Wrap(func(next http.RoundTripper) http.RoundTripper {
+			return &routePlanner{next: next, planner: planner}
+		})
+		dryRun.RouteClient, err = routev1client.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dryRun, nil
+}
+
+// routePlanner reads routes from the cluster but records, rather than
+// sends, any request that would change them
+type routePlanner struct {
+	next    http.RoundTripper
+	planner *updatePlanner
+}
+
+func (t *routePlanner) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method == http.MethodGet {
+		return t.next.RoundTrip(request)
+	}
+	route := &routev1.Route{}
+	if request.Body != nil {
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 && request.Method != http.MethodDelete {
+			if err := json.Unmarshal(body, route); err != nil {
+				return nil, err
+			}
+		}
+	}
+	switch request.Method {
+	case http.MethodPost:
+		existing, err := t.get(request, request.URL.Path+"/"+route.ObjectMeta.Name)
+		if err != nil || existing.StatusCode != http.StatusNotFound {
+			if err == nil && existing.StatusCode == http.StatusOK {
+				existing.Body.Close()
+				return statusResponse(request, http.StatusConflict, metav1.StatusReasonAlreadyExists), nil
+			}
+			return existing, err
+		}
+		existing.Body.Close()
+		t.planner.add("create", "route", route.ObjectMeta.Name, "")
+		return objectResponse(request, http.StatusCreated, route)
+	case http.MethodPut:
+		t.planner.add("update", "route", route.ObjectMeta.Name, "target "+route.Spec.To.Name)
+		return objectResponse(request, http.StatusOK, route)
+	case http.MethodDelete:
+		existing, err := t.get(request, request.URL.Path)
+		if err != nil || existing.StatusCode != http.StatusOK {
+			return existing, err
+		}
+		existing.Body.Close()
+		path := strings.Split(request.URL.Path, "/")
+		t.planner.add("delete", "route", path[len(path)-1], "")
+		return statusResponse(request, http.StatusOK, ""), nil
+	}
+	return nil, fmt.Errorf("Unexpected %s request for %s in dry run", request.Method, request.URL.Path)
+}
+
+func (t *routePlanner) get(request *http.Request, path string) (*http.Response, error) {
+	get := request.Clone(request.Context())
+	get.Method = http.MethodGet
+	get.Body = nil
+	get.ContentLength = 0
+	get.URL.Path = path
+	get.URL.RawQuery = ""
+	return t.next.RoundTrip(get)
+}
+
+func objectResponse(request *http.Request, code int, obj interface{}) (*http.Response, error) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    request,
+	}, nil
+}
+
+func statusResponse(request *http.Request, code int, reason metav1.StatusReason) *http.Response {
+	status := &metav1.Status{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Status",
+		},
+		Status: metav1.StatusSuccess,
+		Reason: reason,
+		Code:   int32(code),
+	}
+	if code >= http.StatusBadRequest {
+		status.Status = metav1.StatusFailure
+	}
+	response, _ := objectResponse(request, code, status)
+	return response
+}
+
+// RouterUpdatePlan returns the changes that RouterUpdateVersionInNamespace
+// would make to the site in the given namespace, without making any of
+// them. The update is run against a copy of the site's resources and
+// the plan is the changes it made to that copy.
+func (cli *VanClient) RouterUpdatePlan(ctx context.Context, hup bool, namespace string) (*types.RouterUpdatePlan, error) {
+	if namespace == "" {
+		namespace = cli.Namespace
+	}
+	check, err := cli.RouterCheckUpdate(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	planner := &updatePlanner{
+		plan: &types.RouterUpdatePlan{
+			Namespace:   namespace,
+			FromVersion: check.SiteVersion,
+			ToVersion:   check.LibraryVersion,
+			Changes:     []types.RouterUpdateChange{},
+		},
+	}
+	dryRun, err := cli.dryRunClient(namespace, planner)
+	if err != nil {
+		return nil, err
+	}
+	_, err = dryRun.RouterUpdateVersionInNamespace(ctx, hup, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return planner.plan, nil
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+	"gotest.tools/assert"
+)
+
+func planned(plan *types.RouterUpdatePlan) []string {
+	changes := []string{}
+	for _, change := range plan.Changes {
+		changes = append(changes, change.String())
+	}
+	return changes
+}
+
+func TestRouterUpdatePlan(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:       "skupper",
+			RouterMode:        string(types.TransportModeInterior),
+			EnableController:  true,
+			EnableServiceSync: true,
+			Ingress:           types.IngressNoneString,
+		},
+	})
+	assert.Assert(t, err)
+
+	plan, err := cli.RouterUpdatePlan(ctx, false, "")
+	assert.Assert(t, err)
+	assert.DeepEqual(t, planned(plan), []string{})
+
+	plan, err = cli.RouterUpdatePlan(ctx, true, "skupper")
+	assert.Assert(t, err)
+	assert.DeepEqual(t, planned(plan), []string{
+		"update deployment/skupper-router: restart",
+		"update deployment/skupper-service-controller: restart",
+	})
+
+	router, err := cli.KubeClient.AppsV1().Deployments("skupper").Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	router.Spec.Template.Spec.Containers[0].Image = "quay.io/skupper/qdrouterd:old"
+	_, err = cli.KubeClient.AppsV1().Deployments("skupper").Update(router)
+	assert.Assert(t, err)
+
+	plan, err = cli.RouterUpdatePlan(ctx, false, "skupper")
+	assert.Assert(t, err)
+	assert.DeepEqual(t, planned(plan), []string{
+		"update deployment/skupper-router: image quay.io/skupper/qdrouterd:old -> " + GetRouterImageName(),
+	})
+
+	// a site from before 0.5.0 has its resources renamed
+	defer func(version string) { Version = version }(Version)
+	Version = "0.5.1"
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps("skupper").Get(types.TransportConfigMapName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	config, err := qdr.GetRouterConfigFromConfigMap(configmap)
+	assert.Assert(t, err)
+	config.SetSiteMetadata(&qdr.SiteMetadata{Id: "site", Version: "0.4.3"})
+	_, err = config.UpdateConfigMap(configmap)
+	assert.Assert(t, err)
+	_, err = cli.KubeClient.CoreV1().ConfigMaps("skupper").Update(configmap)
+	assert.Assert(t, err)
+	for _, name := range []string{"skupper-messaging", "skupper-internal", "skupper-controller"} {
+		_, err = cli.KubeClient.CoreV1().Services("skupper").Create(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		assert.Assert(t, err)
+	}
+	for _, name := range []string{"skupper-ca", "skupper-internal-ca"} {
+		_, err = cli.KubeClient.CoreV1().Secrets("skupper").Create(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		assert.Assert(t, err)
+	}
+	for _, name := range []string{"skupper", "skupper-proxy-controller"} {
+		_, err = cli.KubeClient.CoreV1().ServiceAccounts("skupper").Create(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		assert.Assert(t, err)
+	}
+	_, err = cli.KubeClient.RbacV1().Roles("skupper").Create(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "skupper-view"}})
+	assert.Assert(t, err)
+	err = cli.KubeClient.RbacV1().Roles("skupper").Delete(types.ControllerRoleName, &metav1.DeleteOptions{})
+	assert.Assert(t, err)
+	for _, name := range []string{types.ControllerRoleBindingName, types.TransportRoleBindingName} {
+		err = cli.KubeClient.RbacV1().RoleBindings("skupper").Delete(name, &metav1.DeleteOptions{})
+		assert.Assert(t, err)
+	}
+
+	plan, err = cli.RouterUpdatePlan(ctx, false, "skupper")
+	assert.Assert(t, err)
+	assert.Equal(t, plan.FromVersion, "0.4.3")
+	assert.Equal(t, plan.ToVersion, "0.5.1")
+	assert.DeepEqual(t, planned(plan), []string{
+		"update configmap/skupper-internal: site version 0.4.3 -> 0.5.1",
+		"create service/skupper",
+		"create role/skupper-service-controller",
+		"create rolebinding/skupper-service-controller",
+		"create rolebinding/skupper-router",
+		"update deployment/skupper-router: image quay.io/skupper/qdrouterd:old -> " + GetRouterImageName(),
+		"update deployment/skupper-service-controller: restart",
+		"delete service/skupper-messaging",
+		"delete service/skupper-controller",
+		"delete secret/skupper-ca",
+		"delete secret/skupper-internal-ca",
+		"delete serviceaccount/skupper",
+		"delete serviceaccount/skupper-proxy-controller",
+		"delete role/skupper-view",
+	})
+
+	// nothing was changed
+	router, err = cli.KubeClient.AppsV1().Deployments("skupper").Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, router.Spec.Template.Spec.Containers[0].Image, "quay.io/skupper/qdrouterd:old")
+	_, err = cli.KubeClient.CoreV1().Secrets("skupper").Get("skupper-ca", metav1.GetOptions{})
+	assert.Assert(t, err)
+	inprogress, _, err := cli.isUpdating("skupper")
+	assert.Assert(t, err)
+	assert.Assert(t, !inprogress)
+
+	config.SetSiteMetadata(&qdr.SiteMetadata{Id: "site", Version: "0.6.0"})
+	_, err = config.UpdateConfigMap(configmap)
+	assert.Assert(t, err)
+	_, err = cli.KubeClient.CoreV1().ConfigMaps("skupper").Update(configmap)
+	assert.Assert(t, err)
+	_, err = cli.RouterUpdatePlan(ctx, false, "skupper")
+	assert.Error(t, err, "Site (0.6.0) is newer than library (0.5.1); cannot update")
+}
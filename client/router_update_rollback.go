@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/statestore"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	updateStateConfigMapName string = "skupper-update-state"
+	updateStateSchema        string = "update-state"
+	updateStateSchemaVersion int    = 1
+
+	// keys in the update state
+	updateStateFrom               string = "from"
+	updateStateCheckpoint         string = "checkpoint"
+	updateStateRouterTemplate     string = "router-template"
+	updateStateControllerTemplate string = "controller-template"
+
+	// checkpoints recorded as an update progresses
+	updateCheckpointStarted           string = "started"
+	updateCheckpointSiteVersion       string = "site-version-updated"
+	updateCheckpointResourcesCreated  string = "resources-created"
+	updateCheckpointRouterUpdated     string = "router-updated"
+	updateCheckpointControllerUpdated string = "controller-updated"
+	updateCheckpointRemovingResources string = "removing-resources"
+	updateCheckpointCompleted         string = "completed"
+)
+
+// the checkpoints in the order they are reached, for reporting progress
+var updateCheckpoints = []string{
+	updateCheckpointStarted,
+	updateCheckpointSiteVersion,
+	updateCheckpointResourcesCreated,
+	updateCheckpointRouterUpdated,
+	updateCheckpointControllerUpdated,
+	updateCheckpointRemovingResources,
+	updateCheckpointCompleted,
+}
+
+func (cli *VanClient) updateProgress(checkpoint string) {
+	for i, c := range updateCheckpoints {
+		if c == checkpoint {
+			cli.progress(progressUpdate, i+1, len(updateCheckpoints), checkpoint, time.Time{})
+		}
+	}
+}
+
+func encodePodTemplate(deployment *appsv1.Deployment) (string, error) {
+	encoded, err := json.Marshal(deployment.Spec.Template)
+	if err != nil {
+		return "", fmt.Errorf("Could not record pod template for %s: %w", deployment.ObjectMeta.Name, err)
+	}
+	return string(encoded), nil
+}
+
+func (cli *VanClient) updateCheckpoint(namespace string, checkpoint string) error {
+	err := statestore.Modify(cli.stateStore(namespace, nil), updateStateConfigMapName, func(record *statestore.Record) error {
+		if err := record.Check(updateStateSchema, updateStateSchemaVersion); err != nil {
+			return err
+		}
+		record.Data[updateStateCheckpoint] = checkpoint
+		return nil
+	})
+	if err == nil {
+		cli.updateProgress(checkpoint)
+	}
+	return err
+}
+
+// RouterUpdateRollback reverts an update of the site in the given
+// namespace that did not complete, restoring the router and controller
+// deployments and the site version recorded when the update started and
+// removing any resources created under their new names. It returns false
+// if there is no update in progress. An update that has started removing
+// the resources of the previous version can no longer be rolled back and
+// should be completed by running the update again.
+func (cli *VanClient) RouterUpdateRollback(ctx context.Context, namespace string) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	if namespace == "" {
+		namespace = cli.Namespace
+	}
+	state, err := cli.getUpdateState(namespace)
+	if err != nil {
+		return false, err
+	} else if state == nil {
+		return false, nil
+	}
+	if state.Data[updateStateCheckpoint] == updateCheckpointRemovingResources {
+		return false, fmt.Errorf("Update in %s has started removing resources from version %s and cannot be rolled back; run the update again to complete it", namespace, state.Data[updateStateFrom])
+	}
+	err = cli.restorePodTemplate(namespace, types.TransportDeploymentName, state.Data[updateStateRouterTemplate])
+	if err != nil {
+		return false, err
+	}
+	err = cli.restorePodTemplate(namespace, types.ControllerDeploymentName, state.Data[updateStateControllerTemplate])
+	if err != nil {
+		return false, err
+	}
+	from := state.Data[updateStateFrom]
+	if from != "" {
+		err = cli.restoreSiteVersion(namespace, from)
+		if err != nil {
+			return false, err
+		}
+		if utils.LessRecentThanVersion(from, "0.5.0") {
+			err = cli.removeRenamedResources(namespace)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	err = cli.updateCompleted(namespace)
+	if err != nil && err != statestore.ErrNotFound {
+		return true, err
+	}
+	return true, nil
+}
+
+func (cli *VanClient) restorePodTemplate(namespace string, name string, encoded string) error {
+	if encoded == "" {
+		// not recorded by the client that started the update
+		return nil
+	}
+	template := corev1.PodTemplateSpec{}
+	err := json.Unmarshal([]byte(encoded), &template)
+	if err != nil {
+		return fmt.Errorf("Could not read recorded pod template for %s: %w", name, err)
+	}
+	deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(deployment.Spec.Template, template) {
+		return nil
+	}
+	deployment.Spec.Template = template
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(deployment)
+	if err != nil {
+		return fmt.Errorf("Could not restore %s: %w", name, err)
+	}
+	return nil
+}
+
+func (cli *VanClient) restoreSiteVersion(namespace string, version string) error {
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get(types.TransportConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	config, err := qdr.GetRouterConfigFromConfigMap(configmap)
+	if err != nil {
+		return err
+	}
+	site := config.GetSiteMetadata()
+	if site.Version == version {
+		return nil
+	}
+	site.Version = version
+	config.SetSiteMetadata(&site)
+	_, err = config.UpdateConfigMap(configmap)
+	if err != nil {
+		return err
+	}
+	_, err = cli.KubeClient.CoreV1().ConfigMaps(namespace).Update(configmap)
+	return err
+}
+
+// removeRenamedResources undoes the copies made when updating a site from
+// a version prior to 0.5.0. None of the new names were in use before
+// then, so everything under them was created by the update.
+func (cli *VanClient) removeRenamedResources(namespace string) error {
+	if cli.RouteClient != nil {
+		err := cli.RouteClient.Routes(namespace).Delete(types.ConsoleRouteName, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		for _, route := range []string{types.EdgeRouteName, types.InterRouterRouteName} {
+			err = kube.UpdateTargetServiceForRoute(route, legacyTransportServiceName, namespace, cli.RouteClient)
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	services := []string{
+		types.LocalTransportServiceName,
+		types.TransportServiceName,
+		types.ControllerServiceName,
+	}
+	for _, service := range services {
+		err := cli.KubeClient.CoreV1().Services(namespace).Delete(service, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	secrets := []string{
+		types.LocalCaSecret,
+		types.SiteCaSecret,
+		types.LocalServerSecret,
+		types.LocalClientSecret,
+		types.SiteServerSecret,
+	}
+	for _, secret := range secrets {
+		err := cli.KubeClient.CoreV1().Secrets(namespace).Delete(secret, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	rolebindings := []string{
+		types.ControllerRoleBindingName,
+		types.TransportRoleBindingName,
+	}
+	for _, rolebinding := range rolebindings {
+		err := cli.KubeClient.RbacV1().RoleBindings(namespace).Delete(rolebinding, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	roles := []string{
+		types.ControllerRoleName,
+		types.TransportRoleName,
+	}
+	for _, role := range roles {
+		err := cli.KubeClient.RbacV1().Roles(namespace).Delete(role, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	serviceAccounts := []string{
+		types.TransportServiceAccountName,
+		types.ControllerServiceAccountName,
+	}
+	for _, serviceAccount := range serviceAccounts {
+		err := cli.KubeClient.CoreV1().ServiceAccounts(namespace).Delete(serviceAccount, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
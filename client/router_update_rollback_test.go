@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestRouterUpdateRollback(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:       "skupper",
+			RouterMode:        string(types.TransportModeInterior),
+			EnableController:  true,
+			EnableServiceSync: true,
+			Ingress:           types.IngressNoneString,
+		},
+	})
+	assert.Assert(t, err)
+
+	reverted, err := cli.RouterUpdateRollback(ctx, "")
+	assert.Assert(t, err)
+	assert.Assert(t, !reverted)
+
+	router, err := cli.KubeClient.AppsV1().Deployments("skupper").Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	router.Spec.Template.Spec.Containers[0].Image = "quay.io/skupper/qdrouterd:old"
+	_, err = cli.KubeClient.AppsV1().Deployments("skupper").Update(router)
+	assert.Assert(t, err)
+	controller, err := cli.KubeClient.AppsV1().Deployments("skupper").Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	controller.Spec.Template.Spec.Containers[0].Image = "quay.io/skupper/service-controller:old"
+	_, err = cli.KubeClient.AppsV1().Deployments("skupper").Update(controller)
+	assert.Assert(t, err)
+
+	// fail the update after the router has been changed
+	cli.KubeClient.(*fake.Clientset).PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deployment := action.(k8stesting.UpdateAction).GetObject().(*appsv1.Deployment)
+		if deployment.ObjectMeta.Name == types.ControllerDeploymentName && deployment.Spec.Template.Spec.Containers[0].Image != "quay.io/skupper/service-controller:old" {
+			return true, nil, fmt.Errorf("injected failure")
+		}
+		return false, nil, nil
+	})
+	_, err = cli.RouterUpdateVersionInNamespace(ctx, false, "skupper")
+	assert.ErrorContains(t, err, "injected failure")
+
+	state, err := cli.KubeClient.CoreV1().ConfigMaps("skupper").Get(updateStateConfigMapName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, state.Data[updateStateCheckpoint], updateCheckpointRouterUpdated)
+	router, err = cli.KubeClient.AppsV1().Deployments("skupper").Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, router.Spec.Template.Spec.Containers[0].Image, GetRouterImageName())
+
+	reverted, err = cli.RouterUpdateRollback(ctx, "skupper")
+	assert.Assert(t, err)
+	assert.Assert(t, reverted)
+	router, err = cli.KubeClient.AppsV1().Deployments("skupper").Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, router.Spec.Template.Spec.Containers[0].Image, "quay.io/skupper/qdrouterd:old")
+	controller, err = cli.KubeClient.AppsV1().Deployments("skupper").Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, controller.Spec.Template.Spec.Containers[0].Image, "quay.io/skupper/service-controller:old")
+	_, err = cli.KubeClient.CoreV1().ConfigMaps("skupper").Get(updateStateConfigMapName, metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err))
+
+	reverted, err = cli.RouterUpdateRollback(ctx, "skupper")
+	assert.Assert(t, err)
+	assert.Assert(t, !reverted)
+
+	// once old resources are being removed the update can only go forward
+	err = cli.updateStarted("0.4.3", "skupper", nil, router, controller)
+	assert.Assert(t, err)
+	assert.Assert(t, cli.updateCheckpoint("skupper", updateCheckpointRemovingResources))
+	_, err = cli.RouterUpdateRollback(ctx, "skupper")
+	assert.ErrorContains(t, err, "cannot be rolled back")
+
+	cli.ReadOnly = true
+	_, err = cli.RouterUpdateRollback(ctx, "skupper")
+	assert.Equal(t, err, ErrReadOnly)
+}
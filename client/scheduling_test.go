@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestRouterCreateWithScheduling(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	routerScheduling := types.SchedulingSpec{
+		NodeSelector:      map[string]string{"node-role.kubernetes.io/infra": ""},
+		AntiAffinity:      map[string]string{"app": "batch"},
+		Tolerations:       []corev1.Toleration{{Key: "infra", Operator: corev1.TolerationOpEqual, Value: "reserved", Effect: corev1.TaintEffectNoSchedule}},
+		PriorityClassName: "infra-critical",
+	}
+	controllerScheduling := types.SchedulingSpec{
+		Affinity: map[string]string{"application": types.TransportDeploymentName},
+	}
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:          "skupper",
+		RouterMode:           string(types.TransportModeInterior),
+		EnableController:     true,
+		Ingress:              types.IngressNoneString,
+		RouterScheduling:     routerScheduling,
+		ControllerScheduling: controllerScheduling,
+	})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, siteConfig.Spec.RouterScheduling, routerScheduling)
+	assert.DeepEqual(t, siteConfig.Spec.ControllerScheduling, controllerScheduling)
+
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+	router, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	spec := router.Spec.Template.Spec
+	assert.DeepEqual(t, spec.NodeSelector, routerScheduling.NodeSelector)
+	assert.DeepEqual(t, spec.Tolerations, routerScheduling.Tolerations)
+	assert.Equal(t, spec.PriorityClassName, "infra-critical")
+	assert.DeepEqual(t, spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels, routerScheduling.AntiAffinity)
+
+	controller, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	spec = controller.Spec.Template.Spec
+	assert.Equal(t, len(spec.NodeSelector), 0)
+	assert.DeepEqual(t, spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels, controllerScheduling.Affinity)
+}
+
+func TestSchedulingFromConfig(t *testing.T) {
+	_, err := schedulingFromConfig(map[string]string{"router-tolerations": "infra"}, "router")
+	assert.Error(t, err, `Invalid value for router-tolerations: Invalid toleration "infra", expected <key>[=<value>]:<effect>`)
+	_, err = schedulingFromConfig(map[string]string{"controller-node-selector": "zone"}, "controller")
+	assert.Error(t, err, `Invalid value for controller-node-selector: Invalid label "zone", expected <key>=<value>`)
+}
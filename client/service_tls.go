@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// How the router uses a secret named for TLS by a service
+type serviceTlsUsage struct {
+	credentials bool
+	trust       bool
+}
+
+func serviceTlsSecrets(service *types.ServiceInterface) map[string]*serviceTlsUsage {
+	secrets := map[string]*serviceTlsUsage{}
+	if service.TlsCredentials != "" {
+		secrets[service.TlsCredentials] = &serviceTlsUsage{credentials: true}
+	}
+	if service.TlsTrust != "" {
+		if usage, ok := secrets[service.TlsTrust]; ok {
+			usage.trust = true
+		} else {
+			secrets[service.TlsTrust] = &serviceTlsUsage{trust: true}
+		}
+	}
+	return secrets
+}
+
+func validateServiceTls(service *types.ServiceInterface) error {
+	if service.Headless != nil {
+		return fmt.Errorf("TLS settings are not supported for headless services")
+	}
+	return nil
+}
+
+func (cli *VanClient) checkServiceTlsSecret(name string, usage *serviceTlsUsage) error {
+	secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("Secret %s not found for TLS settings of service", name)
+	} else if err != nil {
+		return err
+	}
+	required := []string{}
+	if usage.credentials {
+		required = append(required, "tls.crt", "tls.key")
+	}
+	if usage.trust {
+		required = append(required, "ca.crt")
+	}
+	for _, key := range required {
+		if len(secret.Data[key]) == 0 {
+			return fmt.Errorf("Secret %s has no %s", name, key)
+		}
+	}
+	return nil
+}
+
+func serviceTlsMountPath(profile string) string {
+	return "/etc/qpid-dispatch-certs/" + profile + "/"
+}
+
+// serviceSslProfile returns the profile through which the router reads
+// the files of the secret that it needs for the given usage
+func serviceSslProfile(secret string, usage *serviceTlsUsage) qdr.SslProfile {
+	profile := qdr.SslProfile{
+		Name: naming.ServiceSslProfileName(secret),
+	}
+	path := serviceTlsMountPath(profile.Name)
+	if usage.credentials {
+		profile.CertFile = path + "tls.crt"
+		profile.PrivateKeyFile = path + "tls.key"
+	}
+	if usage.trust {
+		profile.CaCertFile = path + "ca.crt"
+	}
+	return profile
+}
+
+func hasVolume(deployment *appsv1.Deployment, name string) bool {
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// enableServiceTls defines ssl profiles in the router config for the
+// secrets named for TLS by a service exposed in this site, and mounts
+// the secrets into the router, restarting it if anything changed. A
+// profile is shared by all services naming the same secret, so is only
+// ever extended.
+func (cli *VanClient) enableServiceTls(ctx context.Context, service *types.ServiceInterface) error {
+	if service.Origin != "" {
+		return nil
+	}
+	secrets := serviceTlsSecrets(service)
+	if len(secrets) == 0 {
+		return nil
+	}
+	for name, usage := range secrets {
+		if err := cli.checkServiceTlsSecret(name, usage); err != nil {
+			return err
+		}
+	}
+	restart := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configmap, err := kube.GetConfigMap(types.TransportConfigMapName, cli.Namespace, cli.KubeClient)
+		if err != nil {
+			return err
+		}
+		current, err := qdr.GetRouterConfigFromConfigMap(configmap)
+		if err != nil {
+			return err
+		}
+		updated := false
+		for name, usage := range secrets {
+			required := serviceSslProfile(name, usage)
+			existing, ok := current.SslProfiles[required.Name]
+			if ok {
+				if required.CertFile == "" {
+					required.CertFile = existing.CertFile
+					required.PrivateKeyFile = existing.PrivateKeyFile
+				}
+				if required.CaCertFile == "" {
+					required.CaCertFile = existing.CaCertFile
+				}
+			}
+			if !ok || existing != required {
+				current.AddSslProfile(required)
+				updated = true
+			}
+		}
+		if !updated {
+			return nil
+		}
+		current.UpdateConfigMap(configmap)
+		if _, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(configmap); err != nil {
+			return err
+		}
+		restart = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := kube.GetDeployment(types.TransportDeploymentName, cli.Namespace, cli.KubeClient)
+		if err != nil {
+			return err
+		}
+		updated := false
+		spec := &deployment.Spec.Template.Spec
+		for name := range secrets {
+			profile := naming.ServiceSslProfileName(name)
+			if hasVolume(deployment, profile) {
+				continue
+			}
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name: profile,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: name,
+					},
+				},
+			})
+			spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+				Name:      profile,
+				MountPath: serviceTlsMountPath(profile),
+			})
+			updated = true
+		}
+		if !updated && !restart {
+			return nil
+		} else if !updated {
+			touch(deployment)
+		}
+		_, err = cli.KubeClient.AppsV1().Deployments(cli.Namespace).Update(deployment)
+		return err
+	})
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestServiceTls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:      "skupper",
+			RouterMode:       string(types.TransportModeInterior),
+			EnableController: true,
+			Ingress:          types.IngressNoneString,
+		},
+	})
+	assert.NilError(t, err)
+	secrets := cli.KubeClient.CoreV1().Secrets(cli.Namespace)
+	_, err = secrets.Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-tls"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+	})
+	assert.NilError(t, err)
+
+	service := &types.ServiceInterface{
+		Address:        "api",
+		Protocol:       "tcp",
+		Ports:          []types.ServicePort{{Port: 443}},
+		TlsCredentials: "api-tls",
+		TlsTrust:       "api-tls",
+	}
+	// the secret is checked for what the router needs from it
+	assert.ErrorContains(t, cli.ServiceInterfaceCreate(ctx, service), "has no ca.crt")
+	service.TlsTrust = "missing"
+	assert.ErrorContains(t, cli.ServiceInterfaceCreate(ctx, service), "Secret missing not found")
+
+	service.TlsTrust = ""
+	assert.NilError(t, cli.ServiceInterfaceCreate(ctx, service))
+	configmap, err := kube.GetConfigMap(types.TransportConfigMapName, cli.Namespace, cli.KubeClient)
+	assert.NilError(t, err)
+	config, err := qdr.GetRouterConfigFromConfigMap(configmap)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config.SslProfiles["service-api-tls-profile"], qdr.SslProfile{
+		Name:           "service-api-tls-profile",
+		CertFile:       "/etc/qpid-dispatch-certs/service-api-tls-profile/tls.crt",
+		PrivateKeyFile: "/etc/qpid-dispatch-certs/service-api-tls-profile/tls.key",
+	})
+	router, err := kube.GetDeployment(types.TransportDeploymentName, cli.Namespace, cli.KubeClient)
+	assert.NilError(t, err)
+	assert.Assert(t, hasVolume(router, "service-api-tls-profile"))
+	mounts := 0
+	for _, mount := range router.Spec.Template.Spec.Containers[0].VolumeMounts {
+		if mount.Name == "service-api-tls-profile" {
+			assert.Equal(t, mount.MountPath, "/etc/qpid-dispatch-certs/service-api-tls-profile/")
+			mounts++
+		}
+	}
+	assert.Equal(t, mounts, 1)
+
+	// services from other sites are not bridged with TLS here unless
+	// exposed in this site too
+	assert.NilError(t, cli.enableServiceTls(ctx, &types.ServiceInterface{Address: "remote", TlsCredentials: "missing", Origin: "other-site"}))
+
+	assert.ErrorContains(t, ValidateServiceInterface(&types.ServiceInterface{
+		Address:        "db",
+		Protocol:       "tcp",
+		Ports:          []types.ServicePort{{Port: 5432}},
+		Headless:       &types.Headless{Name: "db"},
+		TlsCredentials: "db-tls",
+	}), "not supported for headless")
+}
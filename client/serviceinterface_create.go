@@ -2,23 +2,35 @@ package client
 
 import (
 	"context"
-	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 )
 
 func (cli *VanClient) ServiceInterfaceCreate(ctx context.Context, service *types.ServiceInterface) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.ServiceCreate(ctx, service)
+	}
 	owner, err := getRootObject(cli)
 	if err == nil {
-		err = validateServiceInterface(service)
+		err = ValidateServiceInterface(service)
 		if err != nil {
 			return err
 		}
+		if err := cli.checkHeadlessSupported(ctx, service); err != nil {
+			return err
+		}
+		if err := cli.enableServiceTls(ctx, service); err != nil {
+			return err
+		}
 		return updateServiceInterface(service, false, owner, cli)
 	} else if errors.IsNotFound(err) {
-		return fmt.Errorf("Skupper not initialised in %s", cli.Namespace)
+		return messages.Errorf(messages.SiteNotInitialised, cli.Namespace)
 	} else {
 		return err
 	}
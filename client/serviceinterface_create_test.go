@@ -122,8 +122,8 @@ func TestServiceInterfaceCreate(t *testing.T) {
 			// show up, but I am giving it a large timeout here. The result
 			// checker will cut out as soon as it sees a result list of the
 			// right size.
-			svcsExpected:     []string{types.LocalTransportServiceName, types.TransportServiceName},
-			realSvcsExpected: []string{types.LocalTransportServiceName, types.TransportServiceName, "vsic-5-addr"},
+			svcsExpected:     []string{types.LocalTransportServiceName, types.TransportServiceName, types.ClaimsServiceName},
+			realSvcsExpected: []string{types.LocalTransportServiceName, types.TransportServiceName, types.ClaimsServiceName, "vsic-5-addr"},
 			timeout:          60.0,
 		},
 	}
@@ -233,7 +233,7 @@ func TestServiceInterfaceCreate(t *testing.T) {
 		service := types.ServiceInterface{
 			Address:  testcase.addr,
 			Protocol: testcase.proto,
-			Port:     testcase.port,
+			Ports:    []types.ServicePort{{Port: testcase.port}},
 		}
 		observedError := cli.ServiceInterfaceCreate(ctx, &service)
 
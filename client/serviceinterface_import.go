@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
+)
+
+// importOnDemandSiteConfig returns the config of the site, which must
+// only create the services of other sites that it has imported
+func (cli *VanClient) importOnDemandSiteConfig(ctx context.Context) (*types.SiteConfig, error) {
+	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if siteConfig == nil || !siteConfig.Spec.ImportOnDemand {
+		return nil, fmt.Errorf("Site imports all services of other sites, so none need to be imported (see --import-on-demand)")
+	}
+	return siteConfig, nil
+}
+
+// ServiceInterfaceImport opts in to having the service of another site
+// with the given address created in this site. The service is created
+// once that site next advertises it.
+func (cli *VanClient) ServiceInterfaceImport(ctx context.Context, address string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := naming.ValidateAddress(address, false); err != nil {
+		return err
+	}
+	siteConfig, err := cli.importOnDemandSiteConfig(ctx)
+	if err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		imports, err := kube.GetConfigMap(types.ServiceImportsConfigMap, cli.Namespace, cli.KubeClient)
+		if errors.IsNotFound(err) {
+			data := map[string]string{address: time.Now().UTC().Format(time.RFC3339)}
+			_, err = kube.NewConfigMap(types.ServiceImportsConfigMap, &data, asOwnerReference(siteConfig.Reference), cli.Namespace, cli.KubeClient)
+			return err
+		} else if err != nil {
+			return err
+		}
+		if _, ok := imports.Data[address]; ok {
+			return nil
+		}
+		if imports.Data == nil {
+			imports.Data = map[string]string{}
+		}
+		imports.Data[address] = time.Now().UTC().Format(time.RFC3339)
+		_, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(imports)
+		return err
+	})
+}
+
+// ServiceInterfaceUnimport withdraws the import of a service of another
+// site, so that the service is removed from this site
+func (cli *VanClient) ServiceInterfaceUnimport(ctx context.Context, address string) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		imports, err := kube.GetConfigMap(types.ServiceImportsConfigMap, cli.Namespace, cli.KubeClient)
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("Service %s is not imported", address)
+		} else if err != nil {
+			return err
+		}
+		if _, ok := imports.Data[address]; !ok {
+			return fmt.Errorf("Service %s is not imported", address)
+		}
+		delete(imports.Data, address)
+		_, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(imports)
+		return err
+	})
+}
+
+// ServiceInterfaceImportList returns the addresses of the services of
+// other sites that have been imported
+func (cli *VanClient) ServiceInterfaceImportList(ctx context.Context) ([]string, error) {
+	addresses := []string{}
+	imports, err := kube.GetConfigMap(types.ServiceImportsConfigMap, cli.Namespace, cli.KubeClient)
+	if errors.IsNotFound(err) {
+		return addresses, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for address := range imports.Data {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestServiceInterfaceImport(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	_, err = cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName: "skupper",
+		RouterMode:  string(types.TransportModeInterior),
+		Ingress:     types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	assert.Error(t, cli.ServiceInterfaceImport(ctx, "backend"), "Site imports all services of other sites, so none need to be imported (see --import-on-demand)")
+	assert.Assert(t, cli.SiteConfigRemove(ctx))
+
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:    "skupper",
+		RouterMode:     string(types.TransportModeInterior),
+		Ingress:        types.IngressNoneString,
+		ImportOnDemand: true,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, siteConfig.Spec.ImportOnDemand)
+
+	addresses, err := cli.ServiceInterfaceImportList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, len(addresses), 0)
+
+	assert.Assert(t, cli.ServiceInterfaceImport(ctx, "frontend"))
+	assert.Assert(t, cli.ServiceInterfaceImport(ctx, "backend"))
+	assert.Assert(t, cli.ServiceInterfaceImport(ctx, "backend"))
+	addresses, err = cli.ServiceInterfaceImportList(ctx)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, addresses, []string{"backend", "frontend"})
+	_, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.ServiceImportsConfigMap, metav1.GetOptions{})
+	assert.Assert(t, err)
+
+	assert.Assert(t, cli.ServiceInterfaceUnimport(ctx, "frontend"))
+	assert.Error(t, cli.ServiceInterfaceUnimport(ctx, "frontend"), "Service frontend is not imported")
+	addresses, err = cli.ServiceInterfaceImportList(ctx)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, addresses, []string{"backend"})
+}
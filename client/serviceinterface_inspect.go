@@ -12,6 +12,9 @@ import (
 )
 
 func (cli *VanClient) ServiceInterfaceInspect(ctx context.Context, address string) (*types.ServiceInterface, error) {
+	if cli.Driver != nil {
+		return cli.Driver.ServiceInspect(ctx, address)
+	}
 	current, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
 	if err == nil {
 		jsonDef := current.Data[address]
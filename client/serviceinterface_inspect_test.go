@@ -80,7 +80,7 @@ func TestServiceInterfaceInspect(t *testing.T) {
 		service := types.ServiceInterface{
 			Address:  testcase.addr,
 			Protocol: testcase.proto,
-			Port:     testcase.port,
+			Ports:    []types.ServicePort{{Port: testcase.port}},
 		}
 		err = cli.ServiceInterfaceCreate(ctx, &service)
 
@@ -111,10 +111,10 @@ func TestServiceInterfaceInspect(t *testing.T) {
 				testcase.namespace,
 				serviceInterface.Protocol,
 				testcase.proto)
-			assert.Equal(t, testcase.port, serviceInterface.Port,
+			assert.Equal(t, testcase.port, serviceInterface.PrimaryPort(),
 				"\n\nTest %s failure: Port was %d but should be %d.\n",
 				testcase.namespace,
-				serviceInterface.Port,
+				serviceInterface.PrimaryPort(),
 				testcase.port)
 			assert.Assert(t, nil == serviceInterface.Headless,
 				"\n\nTest %s failure: Headless was |%#v| but should be nil.\n",
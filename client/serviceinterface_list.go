@@ -3,16 +3,26 @@ package client
 import (
 	"context"
 	jsonencoding "encoding/json"
+	"fmt"
+	"sort"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/skupperproject/skupper/api/types"
 )
 
 func (cli *VanClient) ServiceInterfaceList(ctx context.Context) ([]*types.ServiceInterface, error) {
+	if cli.Driver != nil {
+		return cli.Driver.ServiceList(ctx)
+	}
+	return cli.serviceInterfaceListInNamespace(cli.Namespace)
+}
+
+func (cli *VanClient) serviceInterfaceListInNamespace(namespace string) ([]*types.ServiceInterface, error) {
 	var vsis []*types.ServiceInterface
 
-	current, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
+	current, err := cli.KubeClient.CoreV1().ConfigMaps(namespace).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
 	if err == nil {
 		for _, v := range current.Data {
 			if v != "" {
@@ -30,3 +40,65 @@ func (cli *VanClient) ServiceInterfaceList(ctx context.Context) ([]*types.Servic
 		return vsis, err
 	}
 }
+
+// ServiceInterfaceListWithOptions returns the services matching the
+// protocol, origin and selector (matched against the service metadata)
+// in the options. Only the definitions after the continue token are
+// decoded, so paging through a large number of services stays cheap.
+func (cli *VanClient) ServiceInterfaceListWithOptions(ctx context.Context, options types.ServiceInterfaceListOptions) (*types.ServiceInterfaceListResponse, error) {
+	selector := labels.Everything()
+	if options.Selector != "" {
+		var err error
+		selector, err = labels.Parse(options.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid selector %q: %s", options.Selector, err)
+		}
+	}
+	if options.Limit < 0 {
+		return nil, fmt.Errorf("Invalid limit %d", options.Limit)
+	}
+	current, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	addresses := []string{}
+	for address, v := range current.Data {
+		if v != "" && address > options.Continue {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	response := &types.ServiceInterfaceListResponse{
+		Items: []*types.ServiceInterface{},
+	}
+	for _, address := range addresses {
+		si := types.ServiceInterface{}
+		err = jsonencoding.Unmarshal([]byte(current.Data[address]), &si)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode definition of service %s: %s", address, err)
+		}
+		if !matchesListOptions(&si, options, selector) {
+			continue
+		}
+		if options.Limit > 0 && len(response.Items) == options.Limit {
+			response.Continue = response.Items[len(response.Items)-1].Address
+			break
+		}
+		response.Items = append(response.Items, &si)
+	}
+	return response, nil
+}
+
+func matchesListOptions(service *types.ServiceInterface, options types.ServiceInterfaceListOptions, selector labels.Selector) bool {
+	if options.Protocol != "" && service.Protocol != options.Protocol {
+		return false
+	}
+	if options.Origin == types.ServiceOriginLocal {
+		if service.Origin != "" && service.Origin != "annotation" {
+			return false
+		}
+	} else if options.Origin != "" && service.Origin != options.Origin {
+		return false
+	}
+	return selector.Matches(labels.Set(service.Metadata))
+}
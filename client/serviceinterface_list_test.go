@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	jsonencoding "encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestServiceInterfaceListWithOptions(t *testing.T) {
+	services := []types.ServiceInterface{
+		{Address: "cart", Protocol: "http", Metadata: map[string]string{"owner": "shop"}},
+		{Address: "catalog", Protocol: "http", Origin: "site-b", Metadata: map[string]string{"owner": "shop"}},
+		{Address: "db", Protocol: "tcp", Metadata: map[string]string{"owner": "data"}},
+		{Address: "payments", Protocol: "http2", Origin: "annotation"},
+		{Address: "search", Protocol: "tcp", Origin: "site-b"},
+	}
+	data := map[string]string{}
+	for _, s := range services {
+		encoded, err := jsonencoding.Marshal(s)
+		assert.NilError(t, err)
+		data[s.Address] = string(encoded)
+	}
+	cli := &VanClient{
+		Namespace: "skupper",
+		KubeClient: fake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: types.ServiceInterfaceConfigMap, Namespace: "skupper"},
+			Data:       data,
+		}),
+	}
+	addresses := func(response *types.ServiceInterfaceListResponse) []string {
+		result := []string{}
+		for _, s := range response.Items {
+			result = append(result, s.Address)
+		}
+		return result
+	}
+
+	testcases := []struct {
+		doc       string
+		options   types.ServiceInterfaceListOptions
+		expected  []string
+		continued string
+	}{
+		{"all", types.ServiceInterfaceListOptions{}, []string{"cart", "catalog", "db", "payments", "search"}, ""},
+		{"by protocol", types.ServiceInterfaceListOptions{Protocol: "tcp"}, []string{"db", "search"}, ""},
+		{"local", types.ServiceInterfaceListOptions{Origin: types.ServiceOriginLocal}, []string{"cart", "db", "payments"}, ""},
+		{"by origin", types.ServiceInterfaceListOptions{Origin: "site-b"}, []string{"catalog", "search"}, ""},
+		{"by selector", types.ServiceInterfaceListOptions{Selector: "owner=shop"}, []string{"cart", "catalog"}, ""},
+		{"first page", types.ServiceInterfaceListOptions{Limit: 2}, []string{"cart", "catalog"}, "catalog"},
+		{"next page", types.ServiceInterfaceListOptions{Limit: 2, Continue: "catalog"}, []string{"db", "payments"}, "payments"},
+		{"last page", types.ServiceInterfaceListOptions{Limit: 2, Continue: "payments"}, []string{"search"}, ""},
+		{"filtered page", types.ServiceInterfaceListOptions{Limit: 1, Protocol: "http"}, []string{"cart"}, "cart"},
+		{"exact page", types.ServiceInterfaceListOptions{Limit: 2, Protocol: "tcp"}, []string{"db", "search"}, ""},
+	}
+	for _, c := range testcases {
+		response, err := cli.ServiceInterfaceListWithOptions(context.Background(), c.options)
+		assert.NilError(t, err, c.doc)
+		assert.DeepEqual(t, addresses(response), c.expected)
+		assert.Equal(t, response.Continue, c.continued, c.doc)
+	}
+
+	_, err := cli.ServiceInterfaceListWithOptions(context.Background(), types.ServiceInterfaceListOptions{Selector: "owner in (shop"})
+	assert.ErrorContains(t, err, `Invalid selector "owner in (shop"`)
+}
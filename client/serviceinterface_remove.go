@@ -2,21 +2,42 @@ package client
 
 import (
 	"context"
+	jsonencoding "encoding/json"
 	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 )
 
 func (cli *VanClient) ServiceInterfaceRemove(ctx context.Context, address string) error {
+	return cli.ServiceInterfaceRemoveWithOptions(ctx, address, types.ServiceInterfaceRemoveOptions{})
+}
+
+// ServiceInterfaceRemoveWithOptions removes the service, unless it is
+// marked as protected and the removal is not forced
+func (cli *VanClient) ServiceInterfaceRemoveWithOptions(ctx context.Context, address string, options types.ServiceInterfaceRemoveOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.ServiceRemove(ctx, address)
+	}
 	current, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
 	if err == nil && current.Data != nil {
 		jsonDef := current.Data[address]
 		if jsonDef == "" {
 			return fmt.Errorf("Could not find service %s", address)
 		} else {
+			service := types.ServiceInterface{}
+			if err := jsonencoding.Unmarshal([]byte(jsonDef), &service); err != nil {
+				return fmt.Errorf("Failed to read json for service %s: %s", address, err)
+			}
+			if service.IsProtected() && !options.Force {
+				return messages.Errorf(messages.ServiceProtected, address)
+			}
 			delete(current.Data, address)
 			_, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(current)
 			if err != nil {
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
+)
+
+func TestRemoveProtectedService(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	service := &types.ServiceInterface{
+		Address:  "payments",
+		Protocol: "tcp",
+		Ports:    []types.ServicePort{{Port: 8080}},
+		Metadata: map[string]string{types.ProtectedAnnotation: "true"},
+		Targets: []types.ServiceInterfaceTarget{
+			{Name: "payments", Service: "payments"},
+		},
+	}
+	assert.NilError(t, updateServiceInterface(service, false, nil, cli))
+
+	err = cli.ServiceInterfaceRemove(ctx, "payments")
+	assert.Assert(t, messages.Is(err, messages.ServiceProtected))
+	err = cli.ServiceInterfaceUnbind(ctx, "service", "payments", "payments", true)
+	assert.Assert(t, messages.Is(err, messages.ServiceProtected))
+	// targets can still be unbound without unexposing the service
+	assert.NilError(t, cli.ServiceInterfaceUnbind(ctx, "service", "payments", "payments", false))
+	current, err := cli.ServiceInterfaceInspect(ctx, "payments")
+	assert.NilError(t, err)
+	assert.Equal(t, len(current.Targets), 0)
+
+	assert.NilError(t, cli.ServiceInterfaceRemoveWithOptions(ctx, "payments", types.ServiceInterfaceRemoveOptions{Force: true}))
+	current, err = cli.ServiceInterfaceInspect(ctx, "payments")
+	assert.NilError(t, err)
+	assert.Assert(t, current == nil)
+
+	assert.NilError(t, updateServiceInterface(service, false, nil, cli))
+	err = cli.ServiceInterfaceUnbindWithOptions(ctx, "service", "payments", "payments", types.ServiceInterfaceUnbindOptions{DeleteIfNoTargets: true, Force: true})
+	assert.NilError(t, err)
+	current, err = cli.ServiceInterfaceInspect(ctx, "payments")
+	assert.NilError(t, err)
+	assert.Assert(t, current == nil)
+}
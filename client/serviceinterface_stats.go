@@ -0,0 +1,29 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// ServiceInterfaceStats returns the router's delivery statistics for the
+// address of a service
+func (cli *VanClient) ServiceInterfaceStats(ctx context.Context, address string) (*types.AddressStats, error) {
+	service, err := cli.ServiceInterfaceInspect(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, fmt.Errorf("Could not find service %s", address)
+	}
+	stats, err := qdr.GetAddressStats(address, cli.Namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve router statistics for %s: %s", address, err)
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("Router has no address %s, check that the service-controller has configured the service", address)
+	}
+	return stats, nil
+}
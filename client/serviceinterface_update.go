@@ -4,16 +4,22 @@ import (
 	"context"
 	jsonencoding "encoding/json"
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/messages"
+	"github.com/skupperproject/skupper/pkg/naming"
 	"github.com/skupperproject/skupper/pkg/utils"
 )
 
+const maxServiceMetadataValueLength int = 1024
+
 func getRootObject(cli *VanClient) (*metav1.OwnerReference, error) {
 	root, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
 	if err != nil {
@@ -28,7 +34,7 @@ func addTargetToServiceInterface(service *types.ServiceInterface, target *types.
 	modified := false
 	targets := []types.ServiceInterfaceTarget{}
 	for _, t := range service.Targets {
-		if t.Name == target.Name {
+		if t.Name == target.Name && t.Namespace == target.Namespace {
 			modified = true
 			targets = append(targets, *target)
 		} else {
@@ -41,60 +47,95 @@ func addTargetToServiceInterface(service *types.ServiceInterface, target *types.
 	service.Targets = targets
 }
 
-func getServiceInterfaceTarget(targetType string, targetName string, deducePort bool, cli *VanClient) (*types.ServiceInterfaceTarget, error) {
+// getServiceInterfaceTarget returns the target for the named workload or
+// service and, if requested, the port it is deduced to listen on. The
+// target is looked up in the given namespace, or that of the site if
+// none is given.
+func getServiceInterfaceTarget(targetType string, targetName string, targetNamespace string, deducePort bool, cli *VanClient) (*types.ServiceInterfaceTarget, int, error) {
+	target, port, err := getServiceInterfaceTargetInNamespace(targetType, targetName, namespaceOrDefault(targetNamespace, cli.Namespace), deducePort, cli)
+	if err == nil && targetNamespace != "" && targetNamespace != cli.Namespace {
+		target.Namespace = targetNamespace
+	}
+	return target, port, err
+}
+
+func namespaceOrDefault(namespace string, defaultNamespace string) string {
+	if namespace == "" {
+		return defaultNamespace
+	}
+	return namespace
+}
+
+func getServiceInterfaceTargetInNamespace(targetType string, targetName string, namespace string, deducePort bool, cli *VanClient) (*types.ServiceInterfaceTarget, int, error) {
 	if targetType == "deployment" {
-		deployment, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(targetName, metav1.GetOptions{})
+		deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(targetName, metav1.GetOptions{})
 		if err == nil {
 			target := types.ServiceInterfaceTarget{
 				Name:     deployment.ObjectMeta.Name,
 				Selector: utils.StringifySelector(deployment.Spec.Selector.MatchLabels),
 			}
+			port := 0
 			if deducePort {
 				//TODO: handle case where there is more than one container (need --container option?)
 				if deployment.Spec.Template.Spec.Containers[0].Ports != nil {
-					target.TargetPort = int(deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+					port = int(deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
 				}
 			}
-			return &target, nil
+			return &target, port, nil
 		} else {
-			return nil, fmt.Errorf("Could not read deployment %s: %s", targetName, err)
+			return nil, 0, fmt.Errorf("Could not read deployment %s: %s", targetName, err)
 		}
 	} else if targetType == "statefulset" {
-		statefulset, err := cli.KubeClient.AppsV1().StatefulSets(cli.Namespace).Get(targetName, metav1.GetOptions{})
+		statefulset, err := cli.KubeClient.AppsV1().StatefulSets(namespace).Get(targetName, metav1.GetOptions{})
 		if err == nil {
 			target := types.ServiceInterfaceTarget{
 				Name:     statefulset.ObjectMeta.Name,
 				Selector: utils.StringifySelector(statefulset.Spec.Selector.MatchLabels),
 			}
+			port := 0
 			if deducePort {
 				//TODO: handle case where there is more than one container (need --container option?)
 				if statefulset.Spec.Template.Spec.Containers[0].Ports != nil {
-					target.TargetPort = int(statefulset.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+					port = int(statefulset.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
 				}
 			}
-			return &target, nil
+			return &target, port, nil
 		} else {
-			return nil, fmt.Errorf("Could not read statefulset %s: %s", targetName, err)
+			return nil, 0, fmt.Errorf("Could not read statefulset %s: %s", targetName, err)
+		}
+	} else if targetType == "daemonset" {
+		daemonset, err := cli.KubeClient.AppsV1().DaemonSets(namespace).Get(targetName, metav1.GetOptions{})
+		if err == nil {
+			target := types.ServiceInterfaceTarget{
+				Name:     daemonset.ObjectMeta.Name,
+				Selector: utils.StringifySelector(daemonset.Spec.Selector.MatchLabels),
+			}
+			port := 0
+			if deducePort {
+				port = int(kube.GetContainerPortForDaemonSet(daemonset))
+			}
+			return &target, port, nil
+		} else {
+			return nil, 0, fmt.Errorf("Could not read daemonset %s: %s", targetName, err)
 		}
 	} else if targetType == "pods" {
-		return nil, fmt.Errorf("VAN service interfaces for pods not yet implemented")
+		return nil, 0, fmt.Errorf("VAN service interfaces for pods not yet implemented")
 	} else if targetType == "service" {
 		target := types.ServiceInterfaceTarget{
 			Name:    targetName,
 			Service: targetName,
 		}
+		port := 0
 		if deducePort {
-			port, err := kube.GetPortForServiceTarget(targetName, cli.Namespace, cli.KubeClient)
+			var err error
+			port, err = kube.GetPortForServiceTarget(targetName, namespace, cli.KubeClient)
 			if err != nil {
-				return nil, err
-			}
-			if port != 0 {
-				target.TargetPort = port
+				return nil, 0, err
 			}
 		}
-		return &target, nil
+		return &target, port, nil
 	} else {
-		return nil, fmt.Errorf("VAN service interface unsupported target type")
+		return nil, 0, fmt.Errorf("VAN service interface unsupported target type")
 	}
 }
 
@@ -151,28 +192,57 @@ func updateServiceInterface(service *types.ServiceInterface, overwriteIfExists b
 	}
 }
 
-func validateServiceInterface(service *types.ServiceInterface) error {
+// ValidateServiceInterface checks a service definition, whether created
+// locally or received from another site, before it is acted on
+func ValidateServiceInterface(service *types.ServiceInterface) error {
+	if err := naming.ValidateAddress(service.Address, service.Headless != nil); err != nil {
+		return err
+	}
 	if service.Headless != nil {
 		if service.Headless.TargetPort < 0 || 65535 < service.Headless.TargetPort {
 			return fmt.Errorf("Bad headless target port number: %d", service.Headless.TargetPort)
 		}
 	}
 
+	if err := validateServicePorts(service); err != nil {
+		return err
+	}
 	for _, target := range service.Targets {
-		if target.TargetPort < 0 || 65535 < target.TargetPort {
-			return fmt.Errorf("Bad target port number. Target: %s  Port: %d", target.Name, target.TargetPort)
+		for port, targetPort := range target.TargetPorts {
+			if targetPort < 0 || 65535 < targetPort {
+				return fmt.Errorf("Bad target port number. Target: %s  Port: %d", target.Name, targetPort)
+			} else if len(service.Ports) > 0 && !service.HasPort(port) {
+				return fmt.Errorf("Target %s has a target port for %d, which is not a port of the service", target.Name, port)
+			}
+		}
+	}
+
+	if service.HealthCheck != nil {
+		if err := validateHealthCheck(service.HealthCheck); err != nil {
+			return err
+		}
+	}
+	if err := validateServiceMetadata(service.Metadata); err != nil {
+		return err
+	}
+	if service.IdlePolicy != nil && service.IdlePolicy.Days <= 0 {
+		return fmt.Errorf("The idle timeout must be a positive number of days")
+	}
+	if service.TlsCredentials != "" || service.TlsTrust != "" {
+		if err := validateServiceTls(service); err != nil {
+			return err
 		}
 	}
 
 	//TODO: change service.Protocol to service.Mapping
-	if service.Port < 0 || 65535 < service.Port {
-		return fmt.Errorf("Port %d is outside valid range.", service.Port)
-	} else if service.Aggregate != "" && service.EventChannel {
+	if service.Aggregate != "" && service.EventChannel {
 		return fmt.Errorf("Only one of aggregate and event-channel can be specified for a given service.")
 	} else if service.Aggregate != "" && service.Aggregate != "json" && service.Aggregate != "multipart" {
 		return fmt.Errorf("%s is not a valid aggregation strategy. Choose 'json' or 'multipart'.", service.Aggregate)
-	} else if service.Protocol != "" && service.Protocol != "tcp" && service.Protocol != "http" && service.Protocol != "http2" {
-		return fmt.Errorf("%s is not a valid mapping. Choose 'tcp', 'http' or 'http2'.", service.Protocol)
+	} else if service.Protocol == "sctp" || service.Protocol == "udp" {
+		return fmt.Errorf("%s cannot be bridged; only protocols carried over tcp can be exposed, using the 'tcp' mapping for any the router cannot parse.", service.Protocol)
+	} else if service.Protocol != "" && !isValidProtocol(service.Protocol) {
+		return fmt.Errorf("%s is not a valid mapping. Choose 'tcp', 'http', 'http2' or 'grpc'.", service.Protocol)
 	} else if service.Aggregate != "" && service.Protocol != "http" {
 		return fmt.Errorf("The aggregate option is currently only valid for http")
 	} else if service.EventChannel && service.Protocol != "http" {
@@ -182,66 +252,265 @@ func validateServiceInterface(service *types.ServiceInterface) error {
 	}
 }
 
+func isValidProtocol(protocol string) bool {
+	return isTcpProtocol(protocol) || isHttpProtocol(protocol)
+}
+
+// isTcpProtocol returns true for the protocols bridged as tcp; tcp is
+// also the mapping for protocols the router cannot parse, such as MQTT
+// or AMQP to a broker, as their bytes are passed through unchanged
+func isTcpProtocol(protocol string) bool {
+	return protocol == "tcp"
+}
+
+// isHttpProtocol returns true for the protocols bridged as http requests;
+// grpc is bridged as http2
+func isHttpProtocol(protocol string) bool {
+	return protocol == "http" || protocol == "http2" || protocol == "grpc"
+}
+
+func validateServicePorts(service *types.ServiceInterface) error {
+	if service.Headless != nil && len(service.Ports) > 1 {
+		return fmt.Errorf("Headless services can only be exposed on a single port")
+	}
+	seen := map[int]bool{}
+	for _, port := range service.Ports {
+		if port.Port < 0 || 65535 < port.Port {
+			return fmt.Errorf("Port %d is outside valid range.", port.Port)
+		} else if seen[port.Port] {
+			return fmt.Errorf("Port %d is specified more than once.", port.Port)
+		} else if port.Protocol != "" && !isValidProtocol(port.Protocol) {
+			return fmt.Errorf("%s is not a valid mapping for port %d. Choose 'tcp', 'http', 'http2' or 'grpc'.", port.Protocol, port.Port)
+		}
+		seen[port.Port] = true
+	}
+	return nil
+}
+
+// Metadata is synced to every site along with the service definition,
+// so the keys are restricted to the same form as labels and the values
+// are kept short
+func validateServiceMetadata(metadata map[string]string) error {
+	for key, value := range metadata {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("Invalid service metadata key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if len(value) > maxServiceMetadataValueLength {
+			return fmt.Errorf("Value for service metadata key %s is longer than %d characters", key, maxServiceMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+func validateHealthCheck(check *types.HealthCheck) error {
+	if check.Protocol != "tcp" && check.Protocol != "http" && check.Protocol != "grpc" {
+		return fmt.Errorf("%s is not a valid health check protocol. Choose 'tcp', 'http' or 'grpc'.", check.Protocol)
+	} else if check.Path != "" && check.Protocol != "http" {
+		return fmt.Errorf("A health check path is only valid for http")
+	} else if check.Service != "" && check.Protocol != "grpc" {
+		return fmt.Errorf("A health check service is only valid for grpc")
+	} else if check.Port < 0 || 65535 < check.Port {
+		return fmt.Errorf("Bad health check port number: %d", check.Port)
+	} else if check.IntervalSeconds < 0 || check.TimeoutSeconds < 0 || check.HealthyThreshold < 0 || check.UnhealthyThreshold < 0 {
+		return fmt.Errorf("Health check interval, timeout and thresholds must not be negative")
+	}
+	return nil
+}
+
+// retargetServicePorts keeps the target ports of a service in step with
+// changes to its ports. Ports are matched by position, so changing the
+// port a service is exposed on doesn't change the port its targets are
+// reached on, and target ports for ports that were removed are dropped.
+func retargetServicePorts(current *types.ServiceInterface, service *types.ServiceInterface) {
+	for i := range service.Targets {
+		target := &service.Targets[i]
+		if len(target.TargetPorts) == 0 {
+			continue
+		}
+		targetPorts := map[int]int{}
+		for j, port := range service.Ports {
+			if targetPort, ok := target.TargetPorts[port.Port]; ok {
+				targetPorts[port.Port] = targetPort
+			} else if j < len(current.Ports) && !service.HasPort(current.Ports[j].Port) {
+				if targetPort, ok := target.TargetPorts[current.Ports[j].Port]; ok {
+					targetPorts[port.Port] = targetPort
+				}
+			}
+		}
+		target.TargetPorts = targetPorts
+	}
+}
+
 func (cli *VanClient) ServiceInterfaceUpdate(ctx context.Context, service *types.ServiceInterface) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := cli.checkCluster("Updating a service"); err != nil {
+		return err
+	}
 	owner, err := getRootObject(cli)
 	if err == nil {
-		_, err = cli.ServiceInterfaceInspect(ctx, service.Address)
+		current, err := cli.ServiceInterfaceInspect(ctx, service.Address)
 		if err == nil {
-			err = validateServiceInterface(service)
+			if current != nil {
+				retargetServicePorts(current, service)
+			}
+			err = ValidateServiceInterface(service)
 			if err != nil {
 				return err
 			}
+			if err := cli.checkHeadlessSupported(ctx, service); err != nil {
+				return err
+			}
+			if err := cli.enableServiceTls(ctx, service); err != nil {
+				return err
+			}
 			return updateServiceInterface(service, true, owner, cli)
 		} else {
 			return fmt.Errorf("Service not found: %w", err)
 		}
 	} else if errors.IsNotFound(err) {
-		return fmt.Errorf("Skupper not initialised in %s", cli.Namespace)
+		return messages.Errorf(messages.SiteNotInitialised, cli.Namespace)
 	} else {
 		return err
 	}
 }
 
-func (cli *VanClient) ServiceInterfaceBind(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, protocol string, targetPort int) error {
+func (cli *VanClient) ServiceInterfaceBind(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, protocol string, targetPorts []int) error {
+	return cli.ServiceInterfaceBindWithOptions(ctx, service, targetType, targetName, protocol, targetPorts, types.ServiceInterfaceBindOptions{})
+}
+
+// ServiceInterfaceBindWithOptions adds the named target to the service,
+// looking it up in the target namespace of the options if one is given
+func (cli *VanClient) ServiceInterfaceBindWithOptions(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, protocol string, targetPorts []int, options types.ServiceInterfaceBindOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		if err := cli.checkHostTarget(targetType); err != nil {
+			return err
+		}
+		if protocol != "" && service.Protocol != protocol {
+			return fmt.Errorf("Invalid protocol %s for service with mapping %s", protocol, service.Protocol)
+		}
+		return cli.Driver.ServiceBind(ctx, service, targetName, targetPorts)
+	}
 	owner, err := getRootObject(cli)
 	if err == nil {
-		err = validateServiceInterface(service)
+		if err := cli.checkTargetNamespace(ctx, options.TargetNamespace); err != nil {
+			return err
+		}
+		if service.Headless != nil {
+			if namespaceOrDefault(options.TargetNamespace, cli.Namespace) != cli.Namespace {
+				return fmt.Errorf("Headless services can only be bound to a statefulset in the namespace of the site")
+			}
+			if err := cli.bindHeadless(service, targetType, targetName, targetPorts); err != nil {
+				return err
+			}
+		}
+		err = ValidateServiceInterface(service)
 		if err != nil {
 			return err
 		}
 		if protocol != "" && service.Protocol != protocol {
 			return fmt.Errorf("Invalid protocol %s for service with mapping %s", protocol, service.Protocol)
 		}
-		target, err := getServiceInterfaceTarget(targetType, targetName, service.Port == 0 && targetPort == 0, cli)
+		if service.Headless == nil {
+			if err := cli.bindTarget(service, targetType, targetName, options.TargetNamespace, protocol, targetPorts); err != nil {
+				return err
+			}
+		} else if err := cli.checkHeadlessSupported(ctx, service); err != nil {
+			return err
+		}
+		hooks, err := cli.getHooks(ctx, cli.Namespace)
 		if err != nil {
 			return err
 		}
-		if target.TargetPort != 0 {
-			service.Port = target.TargetPort
-			target.TargetPort = 0
-		} else if targetPort != 0 {
-			if service.Port == 0 {
-				service.Port = targetPort
-			} else {
-				target.TargetPort = targetPort
-			}
+		err = cli.runHook(ctx, hooks, types.HookPreExpose, cli.Namespace, service.Address)
+		if err != nil {
+			return fmt.Errorf("Service not exposed: %w", err)
 		}
-		if service.Port == 0 {
-			if protocol == "http" {
-				service.Port = 80
-			} else {
-				return fmt.Errorf("Service port required and cannot be deduced.")
-			}
+		if err := cli.enableServiceTls(ctx, service); err != nil {
+			return err
+		}
+		err = updateServiceInterface(service, true, owner, cli)
+		if err != nil {
+			return err
+		}
+		err = cli.runHook(ctx, hooks, types.HookPostExpose, cli.Namespace, service.Address)
+		if err != nil {
+			return fmt.Errorf("Service exposed, but %w", err)
 		}
-		addTargetToServiceInterface(service, target)
-		return updateServiceInterface(service, true, owner, cli)
+		return nil
 	} else if errors.IsNotFound(err) {
-		return fmt.Errorf("Skupper not initialised in %s", cli.Namespace)
+		return messages.Errorf(messages.SiteNotInitialised, cli.Namespace)
 	} else {
 		return err
 	}
 }
 
+// bindTarget adds the named target to the service, deducing the ports of
+// the service from it if they are not already set
+func (cli *VanClient) bindTarget(service *types.ServiceInterface, targetType string, targetName string, targetNamespace string, protocol string, targetPorts []int) error {
+	target, port, err := getServiceInterfaceTarget(targetType, targetName, targetNamespace, len(service.Ports) == 0 && len(targetPorts) == 0, cli)
+	if err != nil {
+		return err
+	}
+	if port != 0 {
+		service.Ports = []types.ServicePort{{Port: port}}
+	} else if len(targetPorts) > 0 {
+		if len(service.Ports) == 0 {
+			// the service is exposed on the same ports as the target
+			for _, targetPort := range targetPorts {
+				service.Ports = append(service.Ports, types.ServicePort{Port: targetPort})
+			}
+		} else if len(targetPorts) > len(service.Ports) {
+			return fmt.Errorf("%d target ports specified for a service with %d ports", len(targetPorts), len(service.Ports))
+		} else {
+			// target ports are given in the order of the ports of the service
+			target.TargetPorts = map[int]int{}
+			for i, targetPort := range targetPorts {
+				target.TargetPorts[service.Ports[i].Port] = targetPort
+			}
+		}
+	}
+	if len(service.Ports) == 0 {
+		if protocol == "http" {
+			service.Ports = []types.ServicePort{{Port: 80}}
+		} else {
+			return fmt.Errorf("Service port required and cannot be deduced.")
+		}
+	}
+	addTargetToServiceInterface(service, target)
+	return nil
+}
+
+// bindHeadless completes a headless service from the statefulset it is
+// bound to. The service takes its address from the governing service of
+// the statefulset, and each replica is reached through an address of its
+// own, so that in every site a proxy statefulset of the same name can
+// preserve the ordinal DNS names of the replicas (e.g. mysql-0.mysql).
+func (cli *VanClient) bindHeadless(service *types.ServiceInterface, targetType string, targetName string, targetPorts []int) error {
+	if targetType != "statefulset" {
+		return fmt.Errorf("Headless services can only be bound to a statefulset")
+	}
+	if len(service.Ports) > 1 || len(targetPorts) > 1 {
+		return fmt.Errorf("Headless services can only be exposed on a single port")
+	}
+	def, err := cli.GetHeadlessServiceConfiguration(targetName, service.Protocol, service.Address, service.PrimaryPort())
+	if err != nil {
+		return err
+	}
+	service.Address = def.Address
+	service.Ports = def.Ports
+	service.Headless = def.Headless
+	service.Targets = def.Targets
+	if len(targetPorts) == 1 && targetPorts[0] != service.PrimaryPort() {
+		service.Headless.TargetPort = targetPorts[0]
+	}
+	return nil
+}
+
 func (cli *VanClient) GetHeadlessServiceConfiguration(targetName string, protocol string, address string, port int) (*types.ServiceInterface, error) {
 	statefulset, err := cli.KubeClient.AppsV1().StatefulSets(cli.Namespace).Get(targetName, metav1.GetOptions{})
 	if err == nil {
@@ -252,7 +521,6 @@ func (cli *VanClient) GetHeadlessServiceConfiguration(targetName string, protoco
 		if err == nil {
 			def := types.ServiceInterface{
 				Address:  statefulset.Spec.ServiceName,
-				Port:     port,
 				Protocol: protocol,
 				Headless: &types.Headless{
 					Name: statefulset.ObjectMeta.Name,
@@ -265,9 +533,11 @@ func (cli *VanClient) GetHeadlessServiceConfiguration(targetName string, protoco
 					},
 				},
 			}
-			if port == 0 {
+			if port != 0 {
+				def.Ports = []types.ServicePort{{Port: port}}
+			} else {
 				if len(service.Spec.Ports) == 1 {
-					def.Port = int(service.Spec.Ports[0].Port)
+					def.Ports = []types.ServicePort{{Port: int(service.Spec.Ports[0].Port)}}
 					if service.Spec.Ports[0].TargetPort.IntValue() != 0 && int(service.Spec.Ports[0].Port) != service.Spec.Ports[0].TargetPort.IntValue() {
 						//TODO: handle string ports
 						def.Headless.TargetPort = service.Spec.Ports[0].TargetPort.IntValue()
@@ -289,7 +559,7 @@ func (cli *VanClient) GetHeadlessServiceConfiguration(targetName string, protoco
 	}
 }
 
-func removeServiceInterfaceTarget(serviceName string, targetName string, deleteIfNoTargets bool, cli *VanClient) error {
+func removeServiceInterfaceTarget(serviceName string, targetName string, options types.ServiceInterfaceUnbindOptions, cli *VanClient) error {
 	current, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
 	if err == nil {
 		jsonDef := current.Data[serviceName]
@@ -300,11 +570,19 @@ func removeServiceInterfaceTarget(serviceName string, targetName string, deleteI
 			err = jsonencoding.Unmarshal([]byte(jsonDef), &service)
 			if err != nil {
 				return fmt.Errorf("Failed to read json for service interface %s: %s", serviceName, err)
+			} else if options.DeleteIfNoTargets && service.IsProtected() && !options.Force {
+				return messages.Errorf(messages.ServiceProtected, serviceName)
 			} else {
 				modified := false
 				targets := []types.ServiceInterfaceTarget{}
+				targetNamespace := options.TargetNamespace
+				if targetNamespace == cli.Namespace {
+					targetNamespace = ""
+				}
 				for _, t := range service.Targets {
-					if t.Name == targetName || (t.Name == "" && targetName == serviceName) {
+					if t.Namespace != targetNamespace {
+						targets = append(targets, t)
+					} else if t.Name == targetName || (t.Name == "" && targetName == serviceName) {
 						modified = true
 					} else {
 						targets = append(targets, t)
@@ -313,7 +591,7 @@ func removeServiceInterfaceTarget(serviceName string, targetName string, deleteI
 				if !modified {
 					return fmt.Errorf("Could not find target %s for service interface %s", targetName, serviceName)
 				}
-				if len(targets) == 0 && deleteIfNoTargets {
+				if len(targets) == 0 && options.DeleteIfNoTargets {
 					delete(current.Data, serviceName)
 				} else {
 					service.Targets = targets
@@ -339,12 +617,30 @@ func removeServiceInterfaceTarget(serviceName string, targetName string, deleteI
 }
 
 func (cli *VanClient) ServiceInterfaceUnbind(ctx context.Context, targetType string, targetName string, address string, deleteIfNoTargets bool) error {
-	if targetType == "deployment" || targetType == "statefulset" || targetType == "service" {
+	return cli.ServiceInterfaceUnbindWithOptions(ctx, targetType, targetName, address, types.ServiceInterfaceUnbindOptions{
+		DeleteIfNoTargets: deleteIfNoTargets,
+	})
+}
+
+// ServiceInterfaceUnbindWithOptions removes the target from the service.
+// When unexposing, that is when the service is deleted along with its
+// last target, services marked as protected are left unless forced.
+func (cli *VanClient) ServiceInterfaceUnbindWithOptions(ctx context.Context, targetType string, targetName string, address string, options types.ServiceInterfaceUnbindOptions) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		if err := cli.checkHostTarget(targetType); err != nil {
+			return err
+		}
+		return cli.Driver.ServiceUnbind(ctx, address, targetName, options.DeleteIfNoTargets)
+	}
+	if targetType == "deployment" || targetType == "statefulset" || targetType == "daemonset" || targetType == "service" {
 		if address == "" {
-			err := removeServiceInterfaceTarget(targetName, targetName, deleteIfNoTargets, cli)
+			err := removeServiceInterfaceTarget(targetName, targetName, options, cli)
 			return err
 		} else {
-			err := removeServiceInterfaceTarget(address, targetName, deleteIfNoTargets, cli)
+			err := removeServiceInterfaceTarget(address, targetName, options, cli)
 			return err
 		}
 	} else if targetType == "pods" {
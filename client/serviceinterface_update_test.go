@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"gotest.tools/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
@@ -259,7 +261,7 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	err = cli.ServiceInterfaceCreate(ctx, &types.ServiceInterface{
 		Address:      "noaddress",
 		Protocol:     "tcp",
-		Port:         12345,
+		Ports:        []types.ServicePort{{Port: 12345}},
 		EventChannel: false,
 		Aggregate:    "",
 	})
@@ -292,7 +294,7 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	err = cli.ServiceInterfaceCreate(ctx, &types.ServiceInterface{
 		Address:      "tcp-go-echo",
 		Protocol:     "tcp",
-		Port:         9090,
+		Ports:        []types.ServicePort{{Port: 9090}},
 		EventChannel: false,
 		Aggregate:    "",
 	})
@@ -301,7 +303,7 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	err = cli.ServiceInterfaceCreate(ctx, &types.ServiceInterface{
 		Address:      "nginx",
 		Protocol:     "http",
-		Port:         8080,
+		Ports:        []types.ServicePort{{Port: 8080}},
 		EventChannel: false,
 		Aggregate:    "",
 	})
@@ -310,7 +312,7 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	err = cli.ServiceInterfaceCreate(ctx, &types.ServiceInterface{
 		Address:      "tcp-go-echo-ss",
 		Protocol:     "tcp",
-		Port:         9090,
+		Ports:        []types.ServicePort{{Port: 9090}},
 		EventChannel: false,
 		Aggregate:    "",
 	})
@@ -320,21 +322,21 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	// TODO: could range on list if target type was not needed for bind
 	si, err := cli.ServiceInterfaceInspect(ctx, "tcp-go-echo")
 	assert.Assert(t, err)
-	err = cli.ServiceInterfaceBind(ctx, si, "deployment", "tcp-go-echo", "tcp", 9090)
+	err = cli.ServiceInterfaceBind(ctx, si, "deployment", "tcp-go-echo", "tcp", []int{9090})
 	assert.Assert(t, err)
 
 	si, err = cli.ServiceInterfaceInspect(ctx, "tcp-go-echo-ss")
 	assert.Assert(t, err)
-	err = cli.ServiceInterfaceBind(ctx, si, "statefulset", "tcp-go-echo-ss", "tcp", 9090)
+	err = cli.ServiceInterfaceBind(ctx, si, "statefulset", "tcp-go-echo-ss", "tcp", []int{9090})
 	assert.Assert(t, err)
 
 	si, err = cli.ServiceInterfaceInspect(ctx, "nginx")
 	assert.Assert(t, err)
 	// bad bind
-	err = cli.ServiceInterfaceBind(ctx, si, "deployment", "nginx2", "http", 8080)
+	err = cli.ServiceInterfaceBind(ctx, si, "deployment", "nginx2", "http", []int{8080})
 	assert.Error(t, err, "Could not read deployment nginx2: deployments.apps \"nginx2\" not found")
 	// good bind
-	err = cli.ServiceInterfaceBind(ctx, si, "deployment", "nginx", "http", 8080)
+	err = cli.ServiceInterfaceBind(ctx, si, "deployment", "nginx", "http", []int{8080})
 	assert.Assert(t, err)
 
 	items, err := cli.ServiceInterfaceList(ctx)
@@ -357,7 +359,7 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 		assert.Check(t, err, c.doc)
 
 		if c.port != 0 {
-			si.Port = c.port
+			si.Ports = []types.ServicePort{{Port: c.port}}
 		}
 		if c.eventChannel != si.EventChannel {
 			si.EventChannel = c.eventChannel
@@ -377,7 +379,7 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	si, err = cli.ServiceInterfaceInspect(ctx, "tcp-go-echo")
 	assert.Assert(t, err)
 	assert.Equal(t, si.Protocol, "tcp")
-	assert.Equal(t, si.Port, 9091)
+	assert.Equal(t, si.PrimaryPort(), 9091)
 
 	si, err = cli.ServiceInterfaceInspect(ctx, "nginx")
 	assert.Assert(t, err)
@@ -408,3 +410,292 @@ func TestVanServiceInteraceUpdate(t *testing.T) {
 	assert.Equal(t, len(items), 0)
 
 }
+
+func TestValidateHealthCheck(t *testing.T) {
+	testcases := []struct {
+		check         types.HealthCheck
+		expectedError string
+	}{
+		{types.HealthCheck{Protocol: "tcp"}, ""},
+		{types.HealthCheck{Protocol: "http", Path: "/healthz", IntervalSeconds: 5}, ""},
+		{types.HealthCheck{Protocol: "grpc"}, ""},
+		{types.HealthCheck{Protocol: "grpc", Service: "orders"}, ""},
+		{types.HealthCheck{Protocol: "udp"}, "udp is not a valid health check protocol. Choose 'tcp', 'http' or 'grpc'."},
+		{types.HealthCheck{Protocol: "grpc", Path: "/healthz"}, "A health check path is only valid for http"},
+		{types.HealthCheck{Protocol: "http", Service: "orders"}, "A health check service is only valid for grpc"},
+		{types.HealthCheck{Protocol: "tcp", Path: "/healthz"}, "A health check path is only valid for http"},
+		{types.HealthCheck{Protocol: "tcp", Port: 70000}, "Bad health check port number: 70000"},
+		{types.HealthCheck{Protocol: "tcp", UnhealthyThreshold: -1}, "Health check interval, timeout and thresholds must not be negative"},
+	}
+	for _, c := range testcases {
+		err := validateHealthCheck(&c.check)
+		if c.expectedError == "" {
+			assert.Assert(t, err)
+		} else {
+			assert.Error(t, err, c.expectedError)
+		}
+	}
+}
+
+func TestValidateServiceMetadata(t *testing.T) {
+	testcases := []struct {
+		metadata      map[string]string
+		expectedError string
+	}{
+		{nil, ""},
+		{map[string]string{"version": "1.2", "owner": "payments", "docs": "https://docs.example.com/api"}, ""},
+		{map[string]string{"example.com/team": "payments"}, ""},
+		{map[string]string{"api docs": "x"}, `Invalid service metadata key "api docs": `},
+		{map[string]string{"notes": strings.Repeat("x", 1025)}, "Value for service metadata key notes is longer than 1024 characters"},
+	}
+	for _, c := range testcases {
+		err := validateServiceMetadata(c.metadata)
+		if c.expectedError == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.ErrorContains(t, err, c.expectedError)
+		}
+	}
+}
+
+func TestValidateServiceProtocol(t *testing.T) {
+	testcases := []struct {
+		protocol      string
+		expectedError string
+	}{
+		{"tcp", ""},
+		{"raw", "raw is not a valid mapping. Choose 'tcp', 'http', 'http2' or 'grpc'."},
+		{"grpc", ""},
+		{"sctp", "sctp cannot be bridged; only protocols carried over tcp can be exposed, using the 'tcp' mapping for any the router cannot parse."},
+		{"mqtt", "mqtt is not a valid mapping. Choose 'tcp', 'http', 'http2' or 'grpc'."},
+	}
+	for _, c := range testcases {
+		service := types.ServiceInterface{
+			Address:  "broker",
+			Protocol: c.protocol,
+			Ports:    []types.ServicePort{{Port: 1883}},
+		}
+		err := ValidateServiceInterface(&service)
+		if c.expectedError == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.Error(t, err, c.expectedError)
+		}
+	}
+}
+
+func TestValidateServicePorts(t *testing.T) {
+	testcases := []struct {
+		service       types.ServiceInterface
+		expectedError string
+	}{
+		{types.ServiceInterface{Ports: []types.ServicePort{{Port: 9042}, {Port: 7199, Protocol: "http"}}}, ""},
+		{types.ServiceInterface{Ports: []types.ServicePort{{Port: 9042}, {Port: 50051, Protocol: "grpc"}}}, ""},
+		{types.ServiceInterface{Ports: []types.ServicePort{{Port: 70000}}}, "Port 70000 is outside valid range."},
+		{types.ServiceInterface{Ports: []types.ServicePort{{Port: 9042}, {Port: 9042}}}, "Port 9042 is specified more than once."},
+		{types.ServiceInterface{Ports: []types.ServicePort{{Port: 9042, Protocol: "udp"}}}, "udp is not a valid mapping for port 9042. Choose 'tcp', 'http', 'http2' or 'grpc'."},
+		{types.ServiceInterface{Ports: []types.ServicePort{{Port: 9042}, {Port: 7199}}, Headless: &types.Headless{}}, "Headless services can only be exposed on a single port"},
+	}
+	for _, c := range testcases {
+		err := validateServicePorts(&c.service)
+		if c.expectedError == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.Error(t, err, c.expectedError)
+		}
+	}
+}
+
+func TestRetargetServicePorts(t *testing.T) {
+	current := &types.ServiceInterface{
+		Ports: []types.ServicePort{{Port: 9090}, {Port: 8080}},
+	}
+	service := &types.ServiceInterface{
+		Ports: []types.ServicePort{{Port: 9091}},
+		Targets: []types.ServiceInterfaceTarget{
+			{Name: "echo", TargetPorts: map[int]int{9090: 19090, 8080: 18080}},
+		},
+	}
+	retargetServicePorts(current, service)
+	assert.DeepEqual(t, service.Targets[0].TargetPorts, map[int]int{9091: 19090})
+}
+
+func TestBindHeadlessStatefulSet(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:      "site-a",
+			RouterMode:       string(types.TransportModeInterior),
+			EnableController: true,
+			Ingress:          types.IngressNoneString,
+		},
+	})
+	assert.NilError(t, err)
+	replicas := int32(3)
+	_, err = cli.KubeClient.AppsV1().StatefulSets("skupper").Create(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql"},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: "mysql-headless",
+			Replicas:    &replicas,
+			Selector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "mysql"}},
+		},
+	})
+	assert.NilError(t, err)
+	_, err = cli.KubeClient.CoreV1().Services("skupper").Create(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql-headless"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "None",
+			Ports:     []corev1.ServicePort{{Port: 3306}},
+		},
+	})
+	assert.NilError(t, err)
+
+	service := &types.ServiceInterface{Protocol: "tcp", Headless: &types.Headless{}}
+	err = cli.ServiceInterfaceBind(ctx, service, "deployment", "mysql", "", nil)
+	assert.Error(t, err, "Headless services can only be bound to a statefulset")
+
+	err = cli.ServiceInterfaceBind(ctx, service, "statefulset", "mysql", "", []int{13306})
+	assert.NilError(t, err)
+	bound, err := cli.ServiceInterfaceInspect(ctx, "mysql-headless")
+	assert.NilError(t, err)
+	assert.Assert(t, bound != nil)
+	assert.DeepEqual(t, bound.Ports, []types.ServicePort{{Port: 3306}})
+	assert.DeepEqual(t, bound.Headless, &types.Headless{Name: "mysql", Size: 3, TargetPort: 13306})
+	assert.Equal(t, len(bound.Targets), 1)
+	assert.Equal(t, bound.Targets[0].Selector, "app=mysql")
+
+	other := &types.ServiceInterface{Address: "mysql", Protocol: "tcp", Headless: &types.Headless{}}
+	err = cli.ServiceInterfaceBind(ctx, other, "statefulset", "mysql", "", nil)
+	assert.Error(t, err, "Cannot specify different address from service name for headless service.")
+}
+
+func TestBindDaemonSet(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:      "site-a",
+			RouterMode:       string(types.TransportModeInterior),
+			EnableController: true,
+			Ingress:          types.IngressNoneString,
+		},
+	})
+	assert.NilError(t, err)
+	_, err = cli.KubeClient.AppsV1().DaemonSets("skupper").Create(&appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-exporter"},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "node-exporter"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "exporter", Ports: []corev1.ContainerPort{{ContainerPort: 9100}}}},
+				},
+			},
+		},
+	})
+	assert.NilError(t, err)
+
+	service := &types.ServiceInterface{Address: "node-metrics", Protocol: "http"}
+	assert.NilError(t, cli.ServiceInterfaceBind(ctx, service, "daemonset", "node-exporter", "", nil))
+	bound, err := cli.ServiceInterfaceInspect(ctx, "node-metrics")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bound.Ports, []types.ServicePort{{Port: 9100}})
+	assert.Equal(t, len(bound.Targets), 1)
+	assert.Equal(t, bound.Targets[0].Name, "node-exporter")
+	assert.Equal(t, bound.Targets[0].Selector, "app=node-exporter")
+
+	err = cli.ServiceInterfaceBind(ctx, service, "daemonset", "missing", "", nil)
+	assert.ErrorContains(t, err, "Could not read daemonset missing")
+
+	assert.NilError(t, cli.ServiceInterfaceUnbind(ctx, "daemonset", "node-exporter", "node-metrics", true))
+	bound, err = cli.ServiceInterfaceInspect(ctx, "node-metrics")
+	assert.NilError(t, err)
+	assert.Assert(t, bound == nil)
+}
+
+func TestBindTargetNamespace(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+	spec := types.SiteConfigSpec{
+		SkupperName:      "site-a",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+	}
+	err = cli.RouterCreate(ctx, types.SiteConfig{Spec: spec})
+	assert.NilError(t, err)
+	for _, name := range []string{"skupper", "backend", "private"} {
+		if name != "skupper" {
+			labels := map[string]string{}
+			if name == "backend" {
+				labels[types.TargetNamespaceLabel] = "skupper"
+			}
+			_, err = cli.KubeClient.CoreV1().Namespaces().Create(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}})
+			assert.NilError(t, err)
+		}
+		_, err = cli.KubeClient.AppsV1().Deployments(name).Create(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api"},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "api", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}}},
+					},
+				},
+			},
+		})
+		assert.NilError(t, err)
+	}
+
+	service := &types.ServiceInterface{Address: "api", Protocol: "tcp"}
+	options := types.ServiceInterfaceBindOptions{TargetNamespace: "backend"}
+	err = cli.ServiceInterfaceBindWithOptions(ctx, service, "deployment", "api", "", nil, options)
+	assert.Error(t, err, "Targets in other namespaces can only be exposed by a cluster scoped site")
+
+	spec.ClusterScoped = true
+	_, err = cli.SiteConfigCreate(ctx, spec)
+	assert.NilError(t, err)
+	assert.NilError(t, cli.createClusterScopedRbac("skupper"))
+	binding, err := cli.KubeClient.RbacV1().ClusterRoleBindings().Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, binding.Subjects[0].Namespace, "skupper")
+
+	err = cli.ServiceInterfaceBindWithOptions(ctx, service, "deployment", "api", "", nil, types.ServiceInterfaceBindOptions{TargetNamespace: "private"})
+	assert.Error(t, err, "Namespace private is not labelled "+types.TargetNamespaceLabel+"=skupper")
+
+	assert.NilError(t, cli.ServiceInterfaceBindWithOptions(ctx, service, "deployment", "api", "", nil, options))
+	assert.NilError(t, cli.ServiceInterfaceBind(ctx, service, "deployment", "api", "", nil))
+	// the controller can only read targets in the namespaces that opted in
+	clusterRole, err := cli.KubeClient.RbacV1().ClusterRoles().Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, clusterRole.Rules, types.ControllerClusterPolicyRule)
+	role, err := cli.KubeClient.RbacV1().Roles("backend").Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, role.Rules, types.ControllerTargetPolicyRule)
+	_, err = cli.KubeClient.RbacV1().RoleBindings("backend").Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.NilError(t, err)
+	_, err = cli.KubeClient.RbacV1().Roles("private").Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err))
+	bound, err := cli.ServiceInterfaceInspect(ctx, "api")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bound.Ports, []types.ServicePort{{Port: 8080}})
+	assert.DeepEqual(t, bound.Targets, []types.ServiceInterfaceTarget{
+		{Name: "api", Selector: "app=api", Namespace: "backend"},
+		{Name: "api", Selector: "app=api"},
+	})
+
+	unbind := types.ServiceInterfaceUnbindOptions{DeleteIfNoTargets: true, TargetNamespace: "backend"}
+	assert.NilError(t, cli.ServiceInterfaceUnbindWithOptions(ctx, "deployment", "api", "api", unbind))
+	bound, err = cli.ServiceInterfaceInspect(ctx, "api")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bound.Targets, []types.ServiceInterfaceTarget{{Name: "api", Selector: "app=api"}})
+
+	assert.NilError(t, cli.SiteConfigRemove(ctx))
+	_, err = cli.KubeClient.RbacV1().ClusterRoleBindings().Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err))
+	_, err = cli.KubeClient.RbacV1().RoleBindings("backend").Get(clusterScopedRbacName("skupper"), metav1.GetOptions{})
+	assert.Assert(t, errors.IsNotFound(err))
+}
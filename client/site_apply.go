@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
+)
+
+// SiteApply reconciles the site in the client's namespace with the
+// spec: the site is initialised if needed, then links and services are
+// created, updated or removed until they match those listed. Applying
+// the same spec again makes no changes.
+func (cli *VanClient) SiteApply(ctx context.Context, spec types.SiteSpec) (*types.SiteApplyResult, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if err := validateSiteSpec(&spec); err != nil {
+		return nil, err
+	}
+	result := &types.SiteApplyResult{}
+	if err := cli.applySiteConfig(ctx, spec.Config, result); err != nil {
+		return result, err
+	}
+	if err := cli.applyLinks(ctx, spec.Links, result); err != nil {
+		return result, err
+	}
+	if err := cli.applyServices(ctx, spec.Services, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func validateSiteSpec(spec *types.SiteSpec) error {
+	links := map[string]bool{}
+	for _, link := range spec.Links {
+		if link.Name == "" {
+			return fmt.Errorf("Link for token %s must be named", link.TokenFile)
+		}
+		if err := naming.ValidateLinkName(link.Name); err != nil {
+			return err
+		}
+		if links[link.Name] {
+			return fmt.Errorf("Link %s is defined more than once", link.Name)
+		}
+		links[link.Name] = true
+	}
+	services := map[string]bool{}
+	for i := range spec.Services {
+		service := &spec.Services[i]
+		if err := ValidateServiceInterface(service); err != nil {
+			return err
+		}
+		if services[service.Address] {
+			return fmt.Errorf("Service %s is defined more than once", service.Address)
+		}
+		services[service.Address] = true
+	}
+	return nil
+}
+
+func (cli *VanClient) applySiteConfig(ctx context.Context, config map[string]string, result *types.SiteApplyResult) error {
+	desired, err := cli.SiteConfigInspect(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "skupper-site",
+			Namespace: cli.Namespace,
+		},
+		Data: config,
+	})
+	if err != nil {
+		return err
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if siteConfig == nil {
+		siteConfig, err = cli.SiteConfigCreate(ctx, desired.Spec)
+		if err != nil {
+			return err
+		}
+		result.Created = append(result.Created, "site/skupper-site")
+	} else {
+		updates, err := cli.SiteConfigUpdate(ctx, desired.Spec)
+		if err != nil {
+			return err
+		}
+		for _, update := range updates {
+			result.Updated = append(result.Updated, "site/"+update)
+		}
+	}
+	_, err = kube.GetDeployment(types.TransportDeploymentName, cli.Namespace, cli.KubeClient)
+	if errors.IsNotFound(err) {
+		err = cli.RouterCreate(ctx, *siteConfig)
+		if err != nil {
+			return err
+		}
+		result.Created = append(result.Created, "deployment/"+types.TransportDeploymentName)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cli *VanClient) applyLinks(ctx context.Context, links []types.SiteSpecLink, result *types.SiteApplyResult) error {
+	connectors, err := cli.ConnectorList(ctx)
+	if err != nil {
+		return err
+	}
+	existing := map[string]*types.Connector{}
+	for _, connector := range connectors {
+		existing[connector.Name] = connector
+	}
+	desired := map[string]bool{}
+	for _, link := range links {
+		desired[link.Name] = true
+		options := types.ConnectorCreateOptions{
+			SkupperNamespace: cli.Namespace,
+			Name:             link.Name,
+			Cost:             link.Cost,
+		}
+		if connector, ok := existing[link.Name]; ok {
+			changed, err := cli.linkChanged(link, connector)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+			// a link cannot be changed in place, as the token it was
+			// created from may have been replaced
+			err = cli.ConnectorRemove(ctx, types.ConnectorRemoveOptions{
+				SkupperNamespace: cli.Namespace,
+				Name:             link.Name,
+			})
+			if err != nil {
+				return fmt.Errorf("Could not remove link %s: %w", link.Name, err)
+			}
+			_, err = cli.ConnectorCreateFromFile(ctx, link.TokenFile, options)
+			if err != nil {
+				return fmt.Errorf("Could not recreate link %s: %w", link.Name, err)
+			}
+			result.Updated = append(result.Updated, "link/"+link.Name)
+			continue
+		}
+		_, err = cli.ConnectorCreateFromFile(ctx, link.TokenFile, options)
+		if err != nil {
+			return fmt.Errorf("Could not create link %s: %w", link.Name, err)
+		}
+		result.Created = append(result.Created, "link/"+link.Name)
+	}
+	stale := []string{}
+	for name := range existing {
+		if !desired[name] {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	for _, name := range stale {
+		err = cli.ConnectorRemove(ctx, types.ConnectorRemoveOptions{
+			SkupperNamespace: cli.Namespace,
+			Name:             name,
+		})
+		if err != nil {
+			return fmt.Errorf("Could not remove link %s: %w", name, err)
+		}
+		result.Deleted = append(result.Deleted, "link/"+name)
+	}
+	return nil
+}
+
+// linkChanged returns true if the link would not be the same were it
+// created from the spec now, i.e. if the token holds another certificate
+// or the cost differs. A claim is only redeemed when the link is created,
+// so only the cost of a link made from one is compared.
+func (cli *VanClient) linkChanged(link types.SiteSpecLink, connector *types.Connector) (bool, error) {
+	token, err := readConnectionToken(link.TokenFile)
+	if err != nil {
+		return false, fmt.Errorf("Could not read token for link %s: %w", link.Name, err)
+	}
+	if !IsClaim(token) {
+		cost, err := getLinkCost(token, types.ConnectorCreateOptions{Cost: link.Cost})
+		if err != nil {
+			return false, err
+		}
+		if cost != connector.Cost {
+			return true, nil
+		}
+		secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(link.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return !bytes.Equal(secret.Data["tls.crt"], token.Data["tls.crt"]), nil
+	}
+	return link.Cost != 0 && link.Cost != connector.Cost, nil
+}
+
+func (cli *VanClient) applyServices(ctx context.Context, services []types.ServiceInterface, result *types.SiteApplyResult) error {
+	current, err := cli.ServiceInterfaceList(ctx)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	existing := map[string]*types.ServiceInterface{}
+	for _, service := range current {
+		// services from other sites or created from annotations are
+		// not managed through the spec
+		if service.Origin == "" {
+			existing[service.Address] = service
+		}
+	}
+	desired := map[string]bool{}
+	for i := range services {
+		service := &services[i]
+		desired[service.Address] = true
+		if actual, ok := existing[service.Address]; !ok {
+			err = cli.ServiceInterfaceCreate(ctx, service)
+			if err != nil {
+				return err
+			}
+			result.Created = append(result.Created, "service/"+service.Address)
+		} else if !reflect.DeepEqual(actual, service) {
+			err = cli.ServiceInterfaceUpdate(ctx, service)
+			if err != nil {
+				return err
+			}
+			result.Updated = append(result.Updated, "service/"+service.Address)
+		}
+	}
+	stale := []string{}
+	for address := range existing {
+		if !desired[address] {
+			stale = append(stale, address)
+		}
+	}
+	sort.Strings(stale)
+	for _, address := range stale {
+		err = cli.ServiceInterfaceRemove(ctx, address)
+		if err != nil {
+			return err
+		}
+		result.Deleted = append(result.Deleted, "service/"+address)
+	}
+	return nil
+}
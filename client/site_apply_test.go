@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestSiteApply(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("site-apply", "", "")
+	assert.Assert(t, err)
+
+	spec := types.SiteSpec{
+		Config: map[string]string{
+			"name":           "east",
+			"ingress":        types.IngressNoneString,
+			"router-logging": "info",
+		},
+		Services: []types.ServiceInterface{
+			{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}},
+			{Address: "web", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
+		},
+	}
+	result, err := cli.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, result.Created, []string{"site/skupper-site", "deployment/skupper-router", "service/db", "service/web"})
+	assert.Assert(t, len(result.Updated) == 0 && len(result.Deleted) == 0)
+
+	result, err = cli.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.Assert(t, !result.Changed(), "second apply changed %v", result)
+
+	// a service synced from another site is left alone
+	assert.Assert(t, updateServiceInterface(&types.ServiceInterface{Address: "remote", Protocol: "tcp", Ports: []types.ServicePort{{Port: 9090}}, Origin: "other-site"}, false, nil, cli))
+
+	spec.Config["router-logging"] = "debug"
+	spec.Services = []types.ServiceInterface{
+		{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5433}}},
+		{Address: "cache", Protocol: "tcp", Ports: []types.ServicePort{{Port: 6379}}},
+	}
+	result, err = cli.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, result.Created, []string{"service/cache"})
+	assert.DeepEqual(t, result.Updated, []string{"site/router logging", "service/db"})
+	assert.DeepEqual(t, result.Deleted, []string{"service/web"})
+
+	services, err := cli.ServiceInterfaceList(ctx)
+	assert.Assert(t, err)
+	addresses := map[string]int{}
+	for _, service := range services {
+		addresses[service.Address] = service.PrimaryPort()
+	}
+	assert.DeepEqual(t, addresses, map[string]int{"db": 5433, "cache": 6379, "remote": 9090})
+
+	_, err = cli.SiteApply(ctx, types.SiteSpec{Links: []types.SiteSpecLink{{TokenFile: "token.yaml"}}})
+	assert.Error(t, err, "Link for token token.yaml must be named")
+	_, err = cli.SiteApply(ctx, types.SiteSpec{Services: []types.ServiceInterface{{Address: "db", Protocol: "tcp"}, {Address: "db", Protocol: "http"}}})
+	assert.Error(t, err, "Service db is defined more than once")
+
+	cli.ReadOnly = true
+	_, err = cli.SiteApply(ctx, spec)
+	assert.Equal(t, err, ErrReadOnly)
+}
+
+func TestSiteApplyLinks(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "site-apply-links")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	east, err := newMockClient("east", "", "")
+	assert.Assert(t, err)
+	configureSiteAndCreateRouter(t, ctx, east, "east")
+	// the fake clientset assigns no uids, which tell sites apart
+	site, err := east.KubeClient.CoreV1().ConfigMaps("east").Get(types.DefaultSiteName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	site.ObjectMeta.UID = "east"
+	_, err = east.KubeClient.CoreV1().ConfigMaps("east").Update(site)
+	assert.Assert(t, err)
+	token := filepath.Join(dir, "east.yaml")
+	writeToken := func() {
+		secret, _, err := east.ConnectorTokenCreate(ctx, "west", "")
+		assert.Assert(t, err)
+		secret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+		data, err := yaml.Marshal(secret)
+		assert.Assert(t, err)
+		assert.Assert(t, ioutil.WriteFile(token, data, 0600))
+	}
+	writeToken()
+
+	west, err := newMockClient("west", "", "")
+	assert.Assert(t, err)
+	spec := types.SiteSpec{
+		Config: map[string]string{"name": "west", "ingress": types.IngressNoneString},
+		Links:  []types.SiteSpecLink{{Name: "east", TokenFile: token}},
+	}
+	result, err := west.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, result.Created, []string{"site/skupper-site", "deployment/skupper-router", "link/east"})
+	result, err = west.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.Assert(t, !result.Changed(), "second apply changed %v", result)
+
+	// a change of cost, or a new token, recreates the link
+	spec.Links[0].Cost = 5
+	result, err = west.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, result.Updated, []string{"link/east"})
+	assert.Assert(t, len(result.Created) == 0 && len(result.Deleted) == 0)
+	links, err := west.ConnectorList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, links[0].Cost, int32(5))
+
+	writeToken()
+	result, err = west.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, result.Updated, []string{"link/east"})
+	result, err = west.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.Assert(t, !result.Changed(), "apply after new token changed %v", result)
+
+	spec.Links = nil
+	result, err = west.SiteApply(ctx, spec)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, result.Deleted, []string{"link/east"})
+}
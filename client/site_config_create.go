@@ -2,16 +2,28 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
 )
 
 func (cli *VanClient) SiteConfigCreate(ctx context.Context, spec types.SiteConfigSpec) (*types.SiteConfig, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.SiteConfigCreate(ctx, spec)
+	}
+	if err := cli.checkGatewayIngress(&spec); err != nil {
+		return nil, err
+	}
 	siteConfig := &corev1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -22,17 +34,18 @@ func (cli *VanClient) SiteConfigCreate(ctx context.Context, spec types.SiteConfi
 			Annotations: spec.Annotations,
 		},
 		Data: map[string]string{
-			"name":                   cli.Namespace,
-			"router-mode":            string(types.TransportModeInterior),
-			"service-controller":     "true",
-			"service-sync":           "true",
-			"console":                "true",
-			"router-console":         "false",
-			"router-logging":         "",
-			"console-authentication": "internal",
-			"console-user":           "",
-			"console-password":       "",
-			"ingress":                types.IngressLoadBalancerString,
+			types.SiteConfigVersionKey: types.SiteConfigVersion,
+			"name":                     cli.Namespace,
+			"router-mode":              string(types.TransportModeInterior),
+			"service-controller":       "true",
+			"service-sync":             "true",
+			"console":                  "true",
+			"router-console":           "false",
+			"router-logging":           "",
+			"console-authentication":   "internal",
+			"console-user":             "",
+			"console-password":         "",
+			"ingress":                  types.IngressLoadBalancerString,
 		},
 	}
 	if spec.SkupperName != "" {
@@ -68,6 +81,101 @@ func (cli *VanClient) SiteConfigCreate(ctx context.Context, spec types.SiteConfi
 	if spec.ConsoleIngress != "" {
 		siteConfig.Data["console-ingress"] = spec.ConsoleIngress
 	}
+	if spec.IngressService != "" {
+		siteConfig.Data["ingress-service"] = spec.IngressService
+	}
+	if spec.NodePorts.InterRouter > 0 {
+		siteConfig.Data["inter-router-node-port"] = strconv.Itoa(spec.NodePorts.InterRouter)
+	}
+	if spec.NodePorts.Edge > 0 {
+		siteConfig.Data["edge-node-port"] = strconv.Itoa(spec.NodePorts.Edge)
+	}
+	if spec.NodePorts.HostPort {
+		siteConfig.Data["router-host-ports"] = "true"
+	}
+	if spec.LinkCertWarningDays > 0 {
+		siteConfig.Data["link-cert-warning-days"] = strconv.Itoa(spec.LinkCertWarningDays)
+	}
+	if spec.IngressHost != "" {
+		siteConfig.Data["ingress-host"] = spec.IngressHost
+	}
+	if spec.GatewayClass != "" {
+		siteConfig.Data["gateway-class"] = spec.GatewayClass
+	}
+	if spec.ConsoleHost != "" {
+		siteConfig.Data["console-host"] = spec.ConsoleHost
+	}
+	if spec.ClusterDomain != "" {
+		siteConfig.Data["cluster-domain"] = spec.ClusterDomain
+	}
+	if spec.ClusterScoped {
+		siteConfig.Data["cluster-scoped"] = "true"
+	}
+	if spec.ConsoleTlsSecret != "" {
+		siteConfig.Data["console-tls-secret"] = spec.ConsoleTlsSecret
+	}
+	if spec.SiteCaSecret != "" {
+		siteConfig.Data["site-ca-secret"] = spec.SiteCaSecret
+	}
+	if spec.SiteServerSecret != "" {
+		siteConfig.Data["site-server-secret"] = spec.SiteServerSecret
+	}
+	if spec.CertificateProvider != "" {
+		siteConfig.Data["certificate-provider"] = spec.CertificateProvider
+	}
+	if spec.CertificateIssuer != "" {
+		siteConfig.Data["certificate-issuer"] = spec.CertificateIssuer
+	}
+	if spec.LinkDirection != "" {
+		siteConfig.Data["link-direction"] = spec.LinkDirection
+	}
+	if spec.UpdateStrategy != "" {
+		siteConfig.Data["update-strategy"] = spec.UpdateStrategy
+	}
+	if spec.ServiceSyncInterval > 0 {
+		siteConfig.Data["service-sync-interval"] = spec.ServiceSyncInterval.String()
+	}
+	if spec.ImportOnDemand {
+		siteConfig.Data["import-on-demand"] = "true"
+	}
+	setSchedulingConfig(siteConfig.Data, "router", spec.RouterScheduling)
+	setSchedulingConfig(siteConfig.Data, "controller", spec.ControllerScheduling)
+	setResourcesConfig(siteConfig.Data, "router", spec.RouterResources)
+	setResourcesConfig(siteConfig.Data, "controller", spec.ControllerResources)
+	if spec.CanaryBakeTime > 0 {
+		siteConfig.Data["canary-bake-time"] = spec.CanaryBakeTime.String()
+	}
+	if len(spec.HostAliases) > 0 {
+		siteConfig.Data["host-aliases"] = kube.FormatHostAliases(spec.HostAliases)
+	}
+	for hook, ref := range spec.Hooks {
+		siteConfig.Data["hook-"+hook] = ref
+	}
+	if spec.LoadBalancerProvider != "" {
+		siteConfig.Data["load-balancer-provider"] = spec.LoadBalancerProvider
+	}
+	if len(spec.LoadBalancerAnnotations) > 0 {
+		// annotation values may hold commas, e.g. lists of subnets, so
+		// are not recorded in the key=value,... form used for labels
+		annotations, err := json.Marshal(spec.LoadBalancerAnnotations)
+		if err != nil {
+			return nil, err
+		}
+		siteConfig.Data["load-balancer-annotations"] = string(annotations)
+	}
+	if len(spec.RouterEgress.Annotations) > 0 {
+		annotations, err := json.Marshal(spec.RouterEgress.Annotations)
+		if err != nil {
+			return nil, err
+		}
+		siteConfig.Data["router-egress-annotations"] = string(annotations)
+	}
+	if len(spec.RouterEgress.Labels) > 0 {
+		siteConfig.Data["router-egress-labels"] = kube.FormatLabels(spec.RouterEgress.Labels)
+	}
+	if len(spec.RouterEgress.IPs) > 0 {
+		siteConfig.Data["router-egress-ips"] = strings.Join(spec.RouterEgress.IPs, ",")
+	}
 	if spec.RouterLogging != nil {
 		siteConfig.Data["router-logging"] = RouterLogConfigToString(spec.RouterLogging)
 	}
@@ -80,7 +188,42 @@ func (cli *VanClient) SiteConfigCreate(ctx context.Context, spec types.SiteConfi
 	if spec.RouterMaxSessionFrames != types.RouterMaxSessionFramesDefault {
 		siteConfig.Data["xp-router-max-session-frames"] = strconv.Itoa(spec.RouterMaxSessionFrames)
 	}
-	// TODO: allow Replicas to be set through skupper-site configmap?
+	if spec.ImageVerification.PublicKey != "" {
+		siteConfig.Data["image-signature-key"] = spec.ImageVerification.PublicKey
+	}
+	if spec.ImageVerification.Identity != "" {
+		siteConfig.Data["image-signature-identity"] = spec.ImageVerification.Identity
+	}
+	if spec.ImageVerification.Issuer != "" {
+		siteConfig.Data["image-signature-issuer"] = spec.ImageVerification.Issuer
+	}
+	if spec.ImageVerification.Strict {
+		siteConfig.Data["image-signature-strict"] = "true"
+	}
+	if spec.EnableProfiling {
+		siteConfig.Data["controller-profiling"] = "true"
+	}
+	if spec.EnableMetricsApi {
+		siteConfig.Data["metrics-api"] = "true"
+	}
+	if spec.Watermarks.Memory != "" {
+		siteConfig.Data["router-memory-watermark"] = spec.Watermarks.Memory
+	}
+	if spec.Watermarks.Connections > 0 {
+		siteConfig.Data["router-connection-watermark"] = strconv.Itoa(spec.Watermarks.Connections)
+	}
+	if spec.Watermarks.Undelivered > 0 {
+		siteConfig.Data["router-undelivered-watermark"] = strconv.Itoa(spec.Watermarks.Undelivered)
+	}
+	if spec.Watermarks.ShedLoad {
+		siteConfig.Data["router-shed-load"] = "true"
+	}
+	if len(spec.Labels) > 0 {
+		siteConfig.Data["labels"] = kube.FormatLabels(spec.Labels)
+	}
+	if spec.Replicas > 1 {
+		siteConfig.Data["routers"] = strconv.Itoa(int(spec.Replicas))
+	}
 	if !spec.SiteControlled {
 		siteConfig.ObjectMeta.Labels = map[string]string{
 			"internal.skupper.io/site-controller-ignore": "true",
@@ -100,3 +243,39 @@ func (cli *VanClient) SiteConfigCreate(ctx context.Context, spec types.SiteConfi
 	}
 	return cli.SiteConfigInspect(ctx, actual)
 }
+
+// setSchedulingConfig records the scheduling constraints of the component
+// in the site config, under keys prefixed by the component name
+func setSchedulingConfig(data map[string]string, component string, scheduling types.SchedulingSpec) {
+	if len(scheduling.NodeSelector) > 0 {
+		data[component+"-node-selector"] = kube.FormatLabels(scheduling.NodeSelector)
+	}
+	if len(scheduling.Affinity) > 0 {
+		data[component+"-pod-affinity"] = kube.FormatLabels(scheduling.Affinity)
+	}
+	if len(scheduling.AntiAffinity) > 0 {
+		data[component+"-pod-antiaffinity"] = kube.FormatLabels(scheduling.AntiAffinity)
+	}
+	if len(scheduling.Tolerations) > 0 {
+		data[component+"-tolerations"] = kube.FormatTolerations(scheduling.Tolerations)
+	}
+	if scheduling.PriorityClassName != "" {
+		data[component+"-priority-class"] = scheduling.PriorityClassName
+	}
+}
+
+// setResourcesConfig records the cpu and memory of the component
+func setResourcesConfig(data map[string]string, component string, resources types.ResourcesSpec) {
+	if resources.Cpu != "" {
+		data[component+"-cpu"] = resources.Cpu
+	}
+	if resources.Memory != "" {
+		data[component+"-memory"] = resources.Memory
+	}
+	if resources.CpuLimit != "" {
+		data[component+"-cpu-limit"] = resources.CpuLimit
+	}
+	if resources.MemoryLimit != "" {
+		data[component+"-memory-limit"] = resources.MemoryLimit
+	}
+}
@@ -2,17 +2,25 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
 )
 
 func (cli *VanClient) SiteConfigInspect(ctx context.Context, input *corev1.ConfigMap) (*types.SiteConfig, error) {
+	if cli.Driver != nil && input == nil {
+		return cli.Driver.SiteConfigInspect(ctx)
+	}
 	return cli.SiteConfigInspectInNamespace(ctx, input, cli.Namespace)
 }
 
@@ -31,80 +39,198 @@ func (cli *VanClient) SiteConfigInspectInNamespace(ctx context.Context, input *c
 	}
 
 	var result types.SiteConfig
+	version, data, err := convertSiteConfig(siteConfig.Data)
+	if err != nil {
+		return nil, err
+	}
+	result.SchemaVersion = version
 	result.Spec.SkupperNamespace = siteConfig.Namespace
 	// TODO: what should the defaults be for name, namespace
-	if skupperName, ok := siteConfig.Data["name"]; ok {
+	if skupperName, ok := data["name"]; ok {
 		result.Spec.SkupperName = skupperName
 	} else {
 		result.Spec.SkupperName = namespace
 	}
-	if routerMode, ok := siteConfig.Data["router-mode"]; ok {
+	if routerMode, ok := data["router-mode"]; ok {
 		result.Spec.RouterMode = routerMode
 	} else {
-		// check for deprecated key
-		if isEdge, ok := siteConfig.Data["edge"]; ok {
-			if isEdge == "true" {
-				result.Spec.RouterMode = string(types.TransportModeEdge)
-			} else {
-				result.Spec.RouterMode = string(types.TransportModeInterior)
-			}
-		} else {
-			result.Spec.RouterMode = string(types.TransportModeInterior)
-		}
+		result.Spec.RouterMode = string(types.TransportModeInterior)
 	}
-	if enableController, ok := siteConfig.Data["service-controller"]; ok {
+	if enableController, ok := data["service-controller"]; ok {
 		result.Spec.EnableController, _ = strconv.ParseBool(enableController)
 	} else {
 		result.Spec.EnableController = true
 	}
-	if enableServiceSync, ok := siteConfig.Data["service-sync"]; ok {
+	if enableServiceSync, ok := data["service-sync"]; ok {
 		result.Spec.EnableServiceSync, _ = strconv.ParseBool(enableServiceSync)
 	} else {
 		result.Spec.EnableServiceSync = true
 	}
-	if enableConsole, ok := siteConfig.Data["console"]; ok {
+	if enableConsole, ok := data["console"]; ok {
 		result.Spec.EnableConsole, _ = strconv.ParseBool(enableConsole)
 	} else {
 		result.Spec.EnableConsole = true
 	}
-	if enableRouterConsole, ok := siteConfig.Data["router-console"]; ok {
+	if enableRouterConsole, ok := data["router-console"]; ok {
 		result.Spec.EnableRouterConsole, _ = strconv.ParseBool(enableRouterConsole)
 	} else {
 		result.Spec.EnableRouterConsole = false
 	}
-	if authMode, ok := siteConfig.Data["console-authentication"]; ok {
+	if authMode, ok := data["console-authentication"]; ok {
 		result.Spec.AuthMode = authMode
 	} else {
 		result.Spec.AuthMode = "internal"
 	}
-	if user, ok := siteConfig.Data["console-user"]; ok {
+	if user, ok := data["console-user"]; ok {
 		result.Spec.User = user
 	} else {
 		result.Spec.User = ""
 	}
-	if password, ok := siteConfig.Data["console-password"]; ok {
+	if password, ok := data["console-password"]; ok {
 		result.Spec.Password = password
 	} else {
 		result.Spec.Password = ""
 	}
-	if ingress, ok := siteConfig.Data["ingress"]; ok {
+	if ingressService, ok := data["ingress-service"]; ok {
+		result.Spec.IngressService = ingressService
+	}
+	if host, ok := data["ingress-host"]; ok {
+		result.Spec.IngressHost = host
+	}
+	if class, ok := data["gateway-class"]; ok {
+		result.Spec.GatewayClass = class
+	}
+	if domain, ok := data["cluster-domain"]; ok {
+		result.Spec.ClusterDomain = domain
+	}
+	if host, ok := data["console-host"]; ok {
+		result.Spec.ConsoleHost = host
+	}
+	if secret, ok := data["console-tls-secret"]; ok {
+		result.Spec.ConsoleTlsSecret = secret
+	}
+	if secret, ok := data["site-ca-secret"]; ok {
+		result.Spec.SiteCaSecret = secret
+	}
+	if secret, ok := data["site-server-secret"]; ok {
+		result.Spec.SiteServerSecret = secret
+	}
+	if provider, ok := data["certificate-provider"]; ok {
+		result.Spec.CertificateProvider = provider
+	}
+	if issuer, ok := data["certificate-issuer"]; ok {
+		result.Spec.CertificateIssuer = issuer
+	}
+	if direction, ok := data["link-direction"]; ok {
+		result.Spec.LinkDirection = direction
+	}
+	if ingress, ok := data["ingress"]; ok {
 		result.Spec.Ingress = ingress
+	} else if result.Spec.IngressService != "" {
+		result.Spec.Ingress = types.IngressLoadBalancerString
+	} else if result.Spec.LinkDirection == types.LinkDirectionOutbound {
+		// there are no listeners to expose
+		result.Spec.Ingress = types.IngressNoneString
 	} else {
-		// check for deprecated key
-		if clusterLocal, ok := siteConfig.Data["cluster-local"]; ok {
-			if clusterLocal == "true" {
-				result.Spec.Ingress = types.IngressNoneString
-			} else {
-				result.Spec.Ingress = types.IngressLoadBalancerString
+		result.Spec.Ingress = cli.GetIngressDefault()
+	}
+	if consoleIngress, ok := data["console-ingress"]; ok {
+		result.Spec.ConsoleIngress = consoleIngress
+	}
+	if port, ok := data["inter-router-node-port"]; ok && port != "" {
+		val, err := strconv.Atoi(port)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for inter-router-node-port: %s", err)
+		}
+		result.Spec.NodePorts.InterRouter = val
+	}
+	if port, ok := data["edge-node-port"]; ok && port != "" {
+		val, err := strconv.Atoi(port)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for edge-node-port: %s", err)
+		}
+		result.Spec.NodePorts.Edge = val
+	}
+	if hostPorts, ok := data["router-host-ports"]; ok {
+		result.Spec.NodePorts.HostPort, _ = strconv.ParseBool(hostPorts)
+	}
+	if strategy, ok := data["update-strategy"]; ok {
+		result.Spec.UpdateStrategy = strategy
+	}
+	if interval, ok := data["service-sync-interval"]; ok && interval != "" {
+		val, err := time.ParseDuration(interval)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for service-sync-interval: %s", err)
+		}
+		result.Spec.ServiceSyncInterval = val
+	}
+	if importOnDemand, ok := data["import-on-demand"]; ok {
+		result.Spec.ImportOnDemand, _ = strconv.ParseBool(importOnDemand)
+	}
+	result.Spec.RouterScheduling, err = schedulingFromConfig(data, "router")
+	if err != nil {
+		return &result, err
+	}
+	result.Spec.ControllerScheduling, err = schedulingFromConfig(data, "controller")
+	if err != nil {
+		return &result, err
+	}
+	result.Spec.RouterResources = resourcesFromConfig(data, "router")
+	result.Spec.ControllerResources = resourcesFromConfig(data, "controller")
+	if err := result.Spec.CheckResources(); err != nil {
+		return &result, err
+	}
+	if bakeTime, ok := data["canary-bake-time"]; ok && bakeTime != "" {
+		val, err := time.ParseDuration(bakeTime)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for canary-bake-time: %s", err)
+		}
+		result.Spec.CanaryBakeTime = val
+	}
+	if aliases, ok := data["host-aliases"]; ok && aliases != "" {
+		result.Spec.HostAliases, err = kube.ParseHostAliases(aliases)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for host-aliases: %s", err)
+		}
+	}
+	result.Spec.LoadBalancerProvider = data["load-balancer-provider"]
+	if annotations, ok := data["load-balancer-annotations"]; ok && annotations != "" {
+		if err := json.Unmarshal([]byte(annotations), &result.Spec.LoadBalancerAnnotations); err != nil {
+			return &result, fmt.Errorf("Invalid value for load-balancer-annotations: %s", err)
+		}
+	}
+	if annotations, ok := data["router-egress-annotations"]; ok && annotations != "" {
+		if err := json.Unmarshal([]byte(annotations), &result.Spec.RouterEgress.Annotations); err != nil {
+			return &result, fmt.Errorf("Invalid value for router-egress-annotations: %s", err)
+		}
+	}
+	if labels, ok := data["router-egress-labels"]; ok && labels != "" {
+		result.Spec.RouterEgress.Labels, err = kube.ParseLabels(labels)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for router-egress-labels: %s", err)
+		}
+	}
+	if ips, ok := data["router-egress-ips"]; ok && ips != "" {
+		result.Spec.RouterEgress.IPs = strings.Split(ips, ",")
+	}
+	if err := result.Spec.CheckEgress(); err != nil {
+		return &result, err
+	}
+	for _, hook := range types.ValidHooks {
+		if ref, ok := data["hook-"+hook]; ok && ref != "" {
+			if result.Spec.Hooks == nil {
+				result.Spec.Hooks = map[string]string{}
 			}
-		} else {
-			result.Spec.Ingress = cli.GetIngressDefault()
+			result.Spec.Hooks[hook] = ref
 		}
 	}
-	if consoleIngress, ok := siteConfig.Data["console-ingress"]; ok {
-		result.Spec.ConsoleIngress = consoleIngress
+	if routers, ok := data["routers"]; ok && routers != "" {
+		val, err := strconv.Atoi(routers)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for routers: %s", err)
+		}
+		result.Spec.Replicas = int32(val)
 	}
-	// TODO: allow Replicas to be set through skupper-site configmap?
 	if siteConfig.ObjectMeta.Labels == nil {
 		result.Spec.SiteControlled = true
 	} else if ignore, ok := siteConfig.ObjectMeta.Labels["internal.skupper.io/site-controller-ignore"]; ok {
@@ -117,17 +243,17 @@ func (cli *VanClient) SiteConfigInspectInNamespace(ctx context.Context, input *c
 	result.Reference.Name = siteConfig.ObjectMeta.Name
 	result.Reference.Kind = siteConfig.TypeMeta.Kind
 	result.Reference.APIVersion = siteConfig.TypeMeta.APIVersion
-	if routerDebugMode, ok := siteConfig.Data["router-debug-mode"]; ok && routerDebugMode != "" {
+	if routerDebugMode, ok := data["router-debug-mode"]; ok && routerDebugMode != "" {
 		result.Spec.RouterDebugMode = routerDebugMode
 	}
-	if routerLogging, ok := siteConfig.Data["router-logging"]; ok && routerLogging != "" {
+	if routerLogging, ok := data["router-logging"]; ok && routerLogging != "" {
 		logConf, err := ParseRouterLogConfig(routerLogging)
 		if err != nil {
 			return &result, err
 		}
 		result.Spec.RouterLogging = logConf
 	}
-	if routerMaxFrameSize, ok := siteConfig.Data["xp-router-max-frame-size"]; ok && routerMaxFrameSize != "" {
+	if routerMaxFrameSize, ok := data["xp-router-max-frame-size"]; ok && routerMaxFrameSize != "" {
 		val, err := strconv.Atoi(routerMaxFrameSize)
 		if err != nil {
 			return &result, err
@@ -136,7 +262,7 @@ func (cli *VanClient) SiteConfigInspectInNamespace(ctx context.Context, input *c
 	} else {
 		result.Spec.RouterMaxFrameSize = types.RouterMaxFrameSizeDefault
 	}
-	if routerMaxSessionFrames, ok := siteConfig.Data["xp-router-max-session-frames"]; ok && routerMaxSessionFrames != "" {
+	if routerMaxSessionFrames, ok := data["xp-router-max-session-frames"]; ok && routerMaxSessionFrames != "" {
 		val, err := strconv.Atoi(routerMaxSessionFrames)
 		if err != nil {
 			return &result, err
@@ -145,6 +271,51 @@ func (cli *VanClient) SiteConfigInspectInNamespace(ctx context.Context, input *c
 	} else {
 		result.Spec.RouterMaxSessionFrames = types.RouterMaxSessionFramesDefault
 	}
+	result.Spec.ImageVerification.PublicKey = data["image-signature-key"]
+	result.Spec.ImageVerification.Identity = data["image-signature-identity"]
+	result.Spec.ImageVerification.Issuer = data["image-signature-issuer"]
+	if strict, ok := data["image-signature-strict"]; ok {
+		result.Spec.ImageVerification.Strict, _ = strconv.ParseBool(strict)
+	}
+	if profiling, ok := data["controller-profiling"]; ok {
+		result.Spec.EnableProfiling, _ = strconv.ParseBool(profiling)
+	}
+	if clusterScoped, ok := data["cluster-scoped"]; ok {
+		result.Spec.ClusterScoped, _ = strconv.ParseBool(clusterScoped)
+	}
+	if metricsApi, ok := data["metrics-api"]; ok {
+		result.Spec.EnableMetricsApi, _ = strconv.ParseBool(metricsApi)
+	}
+	if memory, ok := data["router-memory-watermark"]; ok && memory != "" {
+		if _, err := resource.ParseQuantity(memory); err != nil {
+			return &result, fmt.Errorf("Invalid value for router-memory-watermark: %s", err)
+		}
+		result.Spec.Watermarks.Memory = memory
+	}
+	if days, ok := data["link-cert-warning-days"]; ok && days != "" {
+		val, err := strconv.Atoi(days)
+		if err != nil || val < 0 {
+			return &result, fmt.Errorf("Invalid value for link-cert-warning-days: %s", days)
+		}
+		result.Spec.LinkCertWarningDays = val
+	}
+	if connections, ok := data["router-connection-watermark"]; ok && connections != "" {
+		val, err := strconv.Atoi(connections)
+		if err != nil {
+			return &result, err
+		}
+		result.Spec.Watermarks.Connections = val
+	}
+	if undelivered, ok := data["router-undelivered-watermark"]; ok && undelivered != "" {
+		val, err := strconv.Atoi(undelivered)
+		if err != nil {
+			return &result, err
+		}
+		result.Spec.Watermarks.Undelivered = val
+	}
+	if shedLoad, ok := data["router-shed-load"]; ok {
+		result.Spec.Watermarks.ShedLoad, _ = strconv.ParseBool(shedLoad)
+	}
 	exclusions := []string{}
 	annotations := map[string]string{}
 	for key, value := range siteConfig.ObjectMeta.Annotations {
@@ -158,5 +329,61 @@ func (cli *VanClient) SiteConfigInspectInNamespace(ctx context.Context, input *c
 		delete(annotations, key)
 	}
 	result.Spec.Annotations = annotations
+	if labels, ok := data["labels"]; ok && labels != "" {
+		result.Spec.Labels, err = kube.ParseLabels(labels)
+		if err != nil {
+			return &result, fmt.Errorf("Invalid value for labels: %s", err)
+		}
+	}
+	if err := result.Spec.CheckAnnotations(); err != nil {
+		return &result, err
+	}
+	if err := result.Spec.CheckLabels(); err != nil {
+		return &result, err
+	}
 	return &result, nil
 }
+
+// schedulingFromConfig reads the scheduling constraints of the component
+// from the site config
+func schedulingFromConfig(data map[string]string, component string) (types.SchedulingSpec, error) {
+	scheduling := types.SchedulingSpec{}
+	var err error
+	if value, ok := data[component+"-node-selector"]; ok && value != "" {
+		scheduling.NodeSelector, err = kube.ParseLabels(value)
+		if err != nil {
+			return scheduling, fmt.Errorf("Invalid value for %s-node-selector: %s", component, err)
+		}
+	}
+	if value, ok := data[component+"-pod-affinity"]; ok && value != "" {
+		scheduling.Affinity, err = kube.ParseLabels(value)
+		if err != nil {
+			return scheduling, fmt.Errorf("Invalid value for %s-pod-affinity: %s", component, err)
+		}
+	}
+	if value, ok := data[component+"-pod-antiaffinity"]; ok && value != "" {
+		scheduling.AntiAffinity, err = kube.ParseLabels(value)
+		if err != nil {
+			return scheduling, fmt.Errorf("Invalid value for %s-pod-antiaffinity: %s", component, err)
+		}
+	}
+	if value, ok := data[component+"-tolerations"]; ok && value != "" {
+		scheduling.Tolerations, err = kube.ParseTolerations(value)
+		if err != nil {
+			return scheduling, fmt.Errorf("Invalid value for %s-tolerations: %s", component, err)
+		}
+	}
+	scheduling.PriorityClassName = data[component+"-priority-class"]
+	return scheduling, nil
+}
+
+// resourcesFromConfig reads the cpu and memory of the component as
+// recorded by setResourcesConfig
+func resourcesFromConfig(data map[string]string, component string) types.ResourcesSpec {
+	return types.ResourcesSpec{
+		Cpu:         data[component+"-cpu"],
+		Memory:      data[component+"-memory"],
+		CpuLimit:    data[component+"-cpu-limit"],
+		MemoryLimit: data[component+"-memory-limit"],
+	}
+}
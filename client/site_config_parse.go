@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// SiteConfigParse reads a site configuration from the yaml or json
+// serialization of a skupper-site ConfigMap
+func (cli *VanClient) SiteConfigParse(ctx context.Context, data []byte) (*types.SiteConfig, error) {
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	var siteConfig corev1.ConfigMap
+	_, gvk, err := s.Decode(data, nil, &siteConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse site config: %w", err)
+	}
+	// an object of another kind is decoded into a new object of that kind
+	if gvk != nil && gvk.Kind != "" && gvk.Kind != "ConfigMap" {
+		return nil, fmt.Errorf("Site config must be a ConfigMap, not %s", gvk.Kind)
+	}
+	if siteConfig.ObjectMeta.Name != "" && siteConfig.ObjectMeta.Name != "skupper-site" {
+		return nil, fmt.Errorf("Site config must be named skupper-site, not %s", siteConfig.ObjectMeta.Name)
+	}
+	if siteConfig.ObjectMeta.Namespace == "" {
+		siteConfig.ObjectMeta.Namespace = cli.Namespace
+	}
+	return cli.SiteConfigInspect(ctx, &siteConfig)
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestSiteConfigParse(t *testing.T) {
+	testcases := []struct {
+		doc           string
+		data          string
+		expectedError string
+		expected      types.SiteConfigSpec
+	}{
+		{
+			doc: "yaml",
+			data: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: skupper-site
+  annotations:
+    example.com/team: payments
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+    skupper.io/exclude-annotations: kubectl.kubernetes.io/last-applied-configuration
+  labels:
+    app.kubernetes.io/managed-by: kustomize
+data:
+  name: east
+  labels: example.com/tier=edge
+  router-mode: edge
+  console: "false"
+  ingress: none
+  router-logging: debug
+  xp-router-max-frame-size: "4096"
+`,
+			expected: types.SiteConfigSpec{
+				SkupperName:            "east",
+				SkupperNamespace:       "skupper",
+				RouterMode:             "edge",
+				EnableController:       true,
+				EnableServiceSync:      true,
+				AuthMode:               "internal",
+				Ingress:                types.IngressNoneString,
+				SiteControlled:         true,
+				RouterLogging:          []types.RouterLogConfig{{Level: "debug"}},
+				RouterMaxFrameSize:     4096,
+				RouterMaxSessionFrames: types.RouterMaxSessionFramesDefault,
+				Annotations:            map[string]string{"example.com/team": "payments"},
+				Labels:                 map[string]string{"example.com/tier": "edge"},
+			},
+		},
+		{
+			doc:  "json",
+			data: `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "skupper-site"}, "data": {"name": "west", "router-console": "true"}}`,
+			expected: types.SiteConfigSpec{
+				SkupperName:            "west",
+				SkupperNamespace:       "skupper",
+				RouterMode:             "interior",
+				EnableController:       true,
+				EnableServiceSync:      true,
+				EnableConsole:          true,
+				EnableRouterConsole:    true,
+				AuthMode:               "internal",
+				Ingress:                types.IngressLoadBalancerString,
+				SiteControlled:         true,
+				RouterMaxFrameSize:     types.RouterMaxFrameSizeDefault,
+				RouterMaxSessionFrames: types.RouterMaxSessionFramesDefault,
+				Annotations:            map[string]string{},
+			},
+		},
+		{
+			doc:           "reserved annotation",
+			data:          `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "skupper-site", "annotations": {"kubectl.kubernetes.io/restartedAt": "now"}}}`,
+			expectedError: `Invalid annotation "kubectl.kubernetes.io/restartedAt": the prefix is reserved; list it in skupper.io/exclude-annotations to keep it off the pods`,
+		},
+		{
+			doc:           "wrong kind",
+			data:          `{"kind": "Secret", "apiVersion": "v1", "metadata": {"name": "skupper-site"}}`,
+			expectedError: "Site config must be a ConfigMap, not Secret",
+		},
+		{
+			doc:           "wrong name",
+			data:          `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "my-site"}}`,
+			expectedError: "Site config must be named skupper-site, not my-site",
+		},
+	}
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Check(t, err)
+	for _, c := range testcases {
+		t.Run(c.doc, func(t *testing.T) {
+			siteConfig, err := cli.SiteConfigParse(context.Background(), []byte(c.data))
+			if c.expectedError != "" {
+				assert.Error(t, err, c.expectedError)
+				return
+			}
+			assert.Assert(t, err)
+			assert.DeepEqual(t, siteConfig.Spec, c.expected)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// RouterUpdateReplicas scales the router deployment to the number of
+// routers in the site config
+func (cli *VanClient) RouterUpdateReplicas(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
+	if err != nil {
+		return false, err
+	}
+	if err := siteConfig.Spec.CheckRouters(); err != nil {
+		return false, err
+	}
+	desired := siteConfig.Spec.Replicas
+	if desired < 1 {
+		desired = 1
+	}
+	namespace := settings.ObjectMeta.Namespace
+	router, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if router.Spec.Replicas != nil && *router.Spec.Replicas == desired {
+		return false, nil
+	}
+	router.Spec.Replicas = &desired
+	if desired > 1 && router.Spec.Template.Spec.Affinity == nil {
+		router.Spec.Template.Spec.Affinity = kube.SpreadReplicas(router.Spec.Selector.MatchLabels)
+	}
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(router)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (cli *VanClient) updateLabelsOnDeployment(namespace string, name string, labels map[string]string) (bool, error) {
+	deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	desired := kube.PodTemplateLabels(deployment.Spec.Selector.MatchLabels, labels)
+	if reflect.DeepEqual(deployment.Spec.Template.ObjectMeta.Labels, desired) {
+		return false, nil
+	}
+	deployment.Spec.Template.ObjectMeta.Labels = desired
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(deployment)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RouterUpdateLabels sets the labels of the router and controller pods to
+// those they are selected by and the labels in the site config, along with
+// those for egress in the case of the router
+func (cli *VanClient) RouterUpdateLabels(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
+	if err != nil {
+		return false, err
+	}
+	namespace := settings.ObjectMeta.Namespace
+	labels := map[string]string{}
+	for key, value := range siteConfig.Spec.Labels {
+		labels[key] = value
+	}
+	for key, value := range siteConfig.Spec.RouterEgress.Labels {
+		labels[key] = value
+	}
+	updated, err := cli.updateLabelsOnDeployment(namespace, types.TransportDeploymentName, labels)
+	if err != nil {
+		return updated, err
+	}
+	if !siteConfig.Spec.EnableController {
+		return updated, nil
+	}
+	updatedController, err := cli.updateLabelsOnDeployment(namespace, types.ControllerDeploymentName, siteConfig.Spec.Labels)
+	return updated || updatedController, err
+}
+
+func (cli *VanClient) updateResourcesOnDeployment(namespace string, name string, resources types.ResourcesSpec) (bool, error) {
+	deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !setContainerResources(deployment, resources) {
+		return false, nil
+	}
+	_, err = cli.KubeClient.AppsV1().Deployments(namespace).Update(deployment)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func setContainerResources(deployment *appsv1.Deployment, resources types.ResourcesSpec) bool {
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return false
+	}
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	desired := kube.ResourceRequirements(resources)
+	if kube.EquivalentResources(container.Resources, desired) {
+		return false
+	}
+	container.Resources = desired
+	return true
+}
+
+// RouterUpdateResources applies the cpu and memory in the site config to
+// the router and controller deployments
+func (cli *VanClient) RouterUpdateResources(ctx context.Context, settings *corev1.ConfigMap) (bool, error) {
+	if cli.ReadOnly {
+		return false, ErrReadOnly
+	}
+	siteConfig, err := cli.SiteConfigInspect(ctx, settings)
+	if err != nil {
+		return false, err
+	}
+	namespace := settings.ObjectMeta.Namespace
+	updated, err := cli.updateResourcesOnDeployment(namespace, types.TransportDeploymentName, siteConfig.Spec.RouterResources)
+	if err != nil {
+		return updated, err
+	}
+	if !siteConfig.Spec.EnableController {
+		return updated, nil
+	}
+	updatedController, err := cli.updateResourcesOnDeployment(namespace, types.ControllerDeploymentName, siteConfig.Spec.ControllerResources)
+	return updated || updatedController, err
+}
+
+// SiteConfigReconcile brings the deployments of the site in line with
+// the given site config, returning what was changed. Each change is
+// attempted even if an earlier one fails; the first error is returned.
+func (cli *VanClient) SiteConfigReconcile(ctx context.Context, settings *corev1.ConfigMap) ([]string, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	updates := []string{}
+	var first error
+	record := func(description string, updated bool, err error) {
+		if err != nil && first == nil {
+			first = err
+		}
+		if updated {
+			updates = append(updates, description)
+		}
+	}
+	updatedLogging, err := cli.RouterUpdateLogging(ctx, settings, false)
+	record("router logging", updatedLogging, err)
+	updatedDebugMode, err := cli.RouterUpdateDebugMode(ctx, settings)
+	record("router debug mode", updatedDebugMode, err)
+	// a change of debug mode already restarts the router
+	if updatedLogging && !updatedDebugMode {
+		record("", false, cli.RouterRestart(ctx, settings.ObjectMeta.Namespace))
+	}
+	updated, err := cli.RouterUpdateAnnotations(ctx, settings)
+	record("annotations", updated, err)
+	updated, err = cli.RouterUpdateReplicas(ctx, settings)
+	record("router replicas", updated, err)
+	updated, err = cli.RouterUpdateResources(ctx, settings)
+	record("resources", updated, err)
+	updated, err = cli.RouterUpdateLabels(ctx, settings)
+	record("labels", updated, err)
+	updated, err = cli.RouterUpdateNodePorts(ctx, settings)
+	record("pinned router ports", updated, err)
+	updated, err = cli.RouterUpdateHostAliases(ctx, settings)
+	record("router host aliases", updated, err)
+	return updates, first
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestCheckResources(t *testing.T) {
+	spec := types.SiteConfigSpec{}
+	assert.Assert(t, spec.CheckResources())
+	spec.RouterResources = types.ResourcesSpec{Cpu: "500m", Memory: "256Mi", CpuLimit: "1", MemoryLimit: "1Gi"}
+	assert.Assert(t, spec.CheckResources())
+	spec.ControllerResources.MemoryLimit = "lots"
+	assert.ErrorContains(t, spec.CheckResources(), "Invalid value for controller-memory-limit")
+}
+
+func TestSiteConfigReconcile(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+		RouterResources:  types.ResourcesSpec{Memory: "128Mi"},
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+	router, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	memory := router.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory]
+	assert.Equal(t, memory.String(), "128Mi")
+
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.DefaultSiteName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	updates, err := cli.SiteConfigReconcile(ctx, configmap)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, updates, []string{})
+
+	configmap.Data["routers"] = "3"
+	configmap.Data["router-debug-mode"] = "gdb"
+	configmap.Data["router-cpu"] = "500m"
+	delete(configmap.Data, "router-memory")
+	configmap.Data["controller-memory-limit"] = "1Gi"
+	configmap.ObjectMeta.Annotations = map[string]string{"team": "network"}
+	configmap, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(configmap)
+	assert.Assert(t, err)
+	updates, err = cli.SiteConfigReconcile(ctx, configmap)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, updates, []string{"router debug mode", "annotations", "router replicas", "resources"})
+
+	router, err = cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, *router.Spec.Replicas, int32(3))
+	assert.Assert(t, router.Spec.Template.Spec.Affinity != nil)
+	assert.Equal(t, router.Spec.Template.ObjectMeta.Annotations["team"], "network")
+	requests := router.Spec.Template.Spec.Containers[0].Resources.Requests
+	cpu := requests[corev1.ResourceCPU]
+	assert.Assert(t, cpu.Cmp(resource.MustParse("500m")) == 0)
+	_, ok := requests[corev1.ResourceMemory]
+	assert.Assert(t, !ok, "memory request removed from site config was kept")
+	controller, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	limits := controller.Spec.Template.Spec.Containers[0].Resources.Limits
+	memoryLimit := limits[corev1.ResourceMemory]
+	assert.Assert(t, memoryLimit.Cmp(resource.MustParse("1Gi")) == 0)
+
+	updates, err = cli.SiteConfigReconcile(ctx, configmap)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, updates, []string{})
+}
+
+func TestCheckEgress(t *testing.T) {
+	spec := types.SiteConfigSpec{}
+	assert.Assert(t, spec.CheckEgress())
+	spec.RouterEgress = types.EgressSpec{
+		Annotations: map[string]string{"egress.projectcalico.org/selector": "egress-code == 'red'"},
+		Labels:      map[string]string{"egress": "static"},
+		IPs:         []string{"203.0.113.10", "2001:db8::10"},
+	}
+	assert.Assert(t, spec.CheckEgress())
+	spec.RouterEgress.IPs = append(spec.RouterEgress.IPs, "egress.example.com")
+	assert.ErrorContains(t, spec.CheckEgress(), "Invalid value for router-egress-ips")
+	spec.RouterEgress.IPs = nil
+	spec.RouterEgress.Labels["application"] = "other"
+	assert.ErrorContains(t, spec.CheckEgress(), "is reserved for selecting the router")
+}
+
+func TestRouterEgress(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+		RouterEgress: types.EgressSpec{
+			Annotations: map[string]string{"egress.projectcalico.org/selector": "egress-code == 'red', zone == 'a'"},
+			Labels:      map[string]string{"egress": "static"},
+			IPs:         []string{"203.0.113.10"},
+		},
+	})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, siteConfig.Spec.RouterEgress.IPs, []string{"203.0.113.10"})
+	assert.Equal(t, siteConfig.Spec.RouterEgress.Annotations["egress.projectcalico.org/selector"], "egress-code == 'red', zone == 'a'")
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+
+	router, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, router.Spec.Template.ObjectMeta.Labels["egress"], "static")
+	assert.Equal(t, router.Spec.Template.ObjectMeta.Annotations["egress.projectcalico.org/selector"], "egress-code == 'red', zone == 'a'")
+	_, ok := router.Spec.Selector.MatchLabels["egress"]
+	assert.Assert(t, !ok, "egress label used to select the router")
+	controller, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	_, ok = controller.Spec.Template.ObjectMeta.Annotations["egress.projectcalico.org/selector"]
+	assert.Assert(t, !ok, "egress annotation added to the controller")
+
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.DefaultSiteName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	configmap.Data["router-egress-labels"] = "egress=gateway"
+	configmap, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(configmap)
+	assert.Assert(t, err)
+	updates, err := cli.SiteConfigReconcile(ctx, configmap)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, updates, []string{"labels"})
+	router, err = cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(types.TransportDeploymentName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, router.Spec.Template.ObjectMeta.Labels["egress"], "gateway")
+	assert.Equal(t, router.Spec.Template.ObjectMeta.Labels["application"], types.TransportDeploymentName)
+	assert.Equal(t, router.Spec.Template.ObjectMeta.Annotations["egress.projectcalico.org/selector"], "egress-code == 'red', zone == 'a'")
+}
+
+func TestCheckAnnotationsAndLabels(t *testing.T) {
+	spec := types.SiteConfigSpec{
+		Annotations: map[string]string{"example.com/team": "payments", "owner": ""},
+		Labels:      map[string]string{"example.com/tier": "backend"},
+	}
+	assert.Assert(t, spec.CheckAnnotations())
+	assert.Assert(t, spec.CheckLabels())
+
+	spec.Annotations["kubectl.kubernetes.io/restartedAt"] = "now"
+	assert.ErrorContains(t, spec.CheckAnnotations(), `Invalid annotation "kubectl.kubernetes.io/restartedAt": the prefix is reserved`)
+	delete(spec.Annotations, "kubectl.kubernetes.io/restartedAt")
+	spec.Annotations["bad key"] = ""
+	assert.ErrorContains(t, spec.CheckAnnotations(), `Invalid annotation "bad key"`)
+
+	spec.Labels["tier"] = "not a valid value"
+	assert.ErrorContains(t, spec.CheckLabels(), "Invalid value for label tier")
+	delete(spec.Labels, "tier")
+	spec.Labels["skupper.io/component"] = "router"
+	assert.ErrorContains(t, spec.CheckLabels(), "the prefix is reserved")
+	delete(spec.Labels, "skupper.io/component")
+	spec.Labels["application"] = "other"
+	assert.ErrorContains(t, spec.CheckLabels(), "reserved for selecting the pods of the site")
+}
+
+func TestRouterUpdateLabels(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+		Labels:           map[string]string{"example.com/tier": "backend"},
+	})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, siteConfig.Spec.Labels, map[string]string{"example.com/tier": "backend"})
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+	for _, name := range []string{types.TransportDeploymentName, types.ControllerDeploymentName} {
+		deployment, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(name, metav1.GetOptions{})
+		assert.Assert(t, err)
+		assert.Equal(t, deployment.Spec.Template.ObjectMeta.Labels["example.com/tier"], "backend")
+	}
+
+	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get(types.DefaultSiteName, metav1.GetOptions{})
+	assert.Assert(t, err)
+	configmap.Data["labels"] = "example.com/tier=frontend"
+	configmap.ObjectMeta.Labels["app.kubernetes.io/managed-by"] = "kustomize"
+	configmap.ObjectMeta.Annotations = map[string]string{
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+		types.AnnotationExcludes:                           "kubectl.kubernetes.io/last-applied-configuration",
+	}
+	configmap, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(configmap)
+	assert.Assert(t, err)
+	updates, err := cli.SiteConfigReconcile(ctx, configmap)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, updates, []string{"labels"})
+	for _, name := range []string{types.TransportDeploymentName, types.ControllerDeploymentName} {
+		deployment, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(name, metav1.GetOptions{})
+		assert.Assert(t, err)
+		assert.Equal(t, deployment.Spec.Template.ObjectMeta.Labels["example.com/tier"], "frontend")
+		_, ok := deployment.Spec.Template.ObjectMeta.Annotations["kubectl.kubernetes.io/last-applied-configuration"]
+		assert.Assert(t, !ok, "annotation of the tooling added to %s", name)
+		_, ok = deployment.Spec.Template.ObjectMeta.Labels["app.kubernetes.io/managed-by"]
+		assert.Assert(t, !ok, "label of the site config added to %s", name)
+	}
+}
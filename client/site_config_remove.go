@@ -7,5 +7,16 @@ import (
 )
 
 func (cli *VanClient) SiteConfigRemove(ctx context.Context) error {
+	if cli.ReadOnly {
+		return ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.RouterRemove(ctx)
+	}
+	if siteConfig, err := cli.SiteConfigInspect(ctx, nil); err == nil && siteConfig != nil && siteConfig.Spec.ClusterScoped {
+		if err := cli.removeClusterScopedRbac(cli.Namespace); err != nil {
+			return err
+		}
+	}
 	return cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Delete("skupper-site", &metav1.DeleteOptions{})
 }
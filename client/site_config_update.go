@@ -10,6 +10,12 @@ import (
 )
 
 func (cli *VanClient) SiteConfigUpdate(ctx context.Context, config types.SiteConfigSpec) ([]string, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if cli.Driver != nil {
+		return cli.Driver.SiteConfigUpdate(ctx, config)
+	}
 	configmap, err := cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Get("skupper-site", metav1.GetOptions{})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+type siteConfigConversion struct {
+	from    string
+	to      string
+	convert func(data map[string]string)
+}
+
+// conversions are applied in order, so each one only needs to
+// handle the version immediately preceding it
+var siteConfigConversions = []siteConfigConversion{
+	{
+		from:    types.SiteConfigVersion1,
+		to:      types.SiteConfigVersion2,
+		convert: convertSiteConfigV1ToV2,
+	},
+}
+
+func convertSiteConfigV1ToV2(data map[string]string) {
+	if isEdge, ok := data["edge"]; ok {
+		if _, ok := data["router-mode"]; !ok {
+			if isEdge == "true" {
+				data["router-mode"] = string(types.TransportModeEdge)
+			} else {
+				data["router-mode"] = string(types.TransportModeInterior)
+			}
+		}
+		delete(data, "edge")
+	}
+	if clusterLocal, ok := data["cluster-local"]; ok {
+		if _, ok := data["ingress"]; !ok {
+			if clusterLocal == "true" {
+				data["ingress"] = types.IngressNoneString
+			} else {
+				data["ingress"] = types.IngressLoadBalancerString
+			}
+		}
+		delete(data, "cluster-local")
+	}
+}
+
+// convertSiteConfig returns the schema version of the supplied site
+// config data along with a copy of that data converted to the current
+// schema version. Data without a version is treated as the original
+// schema.
+func convertSiteConfig(data map[string]string) (string, map[string]string, error) {
+	version, ok := data[types.SiteConfigVersionKey]
+	if !ok || version == "" {
+		version = types.SiteConfigVersion1
+	}
+	converted := map[string]string{}
+	for key, value := range data {
+		converted[key] = value
+	}
+	current := version
+	for _, conversion := range siteConfigConversions {
+		if conversion.from == current {
+			conversion.convert(converted)
+			current = conversion.to
+		}
+	}
+	if current != types.SiteConfigVersion {
+		return version, nil, fmt.Errorf("Unsupported site config version %s", version)
+	}
+	converted[types.SiteConfigVersionKey] = current
+	return version, converted, nil
+}
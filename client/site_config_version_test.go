@@ -0,0 +1,60 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestConvertSiteConfig(t *testing.T) {
+	testcases := []struct {
+		doc             string
+		data            map[string]string
+		expectedVersion string
+		expectedData    map[string]string
+		expectedError   string
+	}{
+		{
+			doc:             "unversioned with deprecated keys",
+			data:            map[string]string{"name": "east", "edge": "true", "cluster-local": "true"},
+			expectedVersion: types.SiteConfigVersion1,
+			expectedData:    map[string]string{"api-version": "v2", "name": "east", "router-mode": "edge", "ingress": "none"},
+		},
+		{
+			doc:             "unversioned with current keys preferred",
+			data:            map[string]string{"edge": "true", "router-mode": "interior", "cluster-local": "false"},
+			expectedVersion: types.SiteConfigVersion1,
+			expectedData:    map[string]string{"api-version": "v2", "router-mode": "interior", "ingress": "loadbalancer"},
+		},
+		{
+			doc:             "current version",
+			data:            map[string]string{"api-version": "v2", "name": "west", "router-mode": "edge"},
+			expectedVersion: types.SiteConfigVersion2,
+			expectedData:    map[string]string{"api-version": "v2", "name": "west", "router-mode": "edge"},
+		},
+		{
+			doc:             "unknown version",
+			data:            map[string]string{"api-version": "v99"},
+			expectedVersion: "v99",
+			expectedError:   "Unsupported site config version v99",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.doc, func(t *testing.T) {
+			original := map[string]string{}
+			for k, v := range c.data {
+				original[k] = v
+			}
+			version, data, err := convertSiteConfig(c.data)
+			assert.Equal(t, version, c.expectedVersion)
+			if c.expectedError != "" {
+				assert.Error(t, err, c.expectedError)
+			} else {
+				assert.Assert(t, err)
+				assert.DeepEqual(t, data, c.expectedData)
+			}
+			assert.DeepEqual(t, c.data, original)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// A SiteDriver runs a site on a platform other than Kubernetes. A client
+// with a driver passes the operations on its site, and on the links,
+// tokens and services of the site, to the driver instead of making them
+// of the cluster, so that the same API serves sites on either.
+type SiteDriver interface {
+	Platform() string
+	SiteConfigCreate(ctx context.Context, spec types.SiteConfigSpec) (*types.SiteConfig, error)
+	SiteConfigUpdate(ctx context.Context, spec types.SiteConfigSpec) ([]string, error)
+	// SiteConfigInspect returns nil if the site has not been created
+	SiteConfigInspect(ctx context.Context) (*types.SiteConfig, error)
+	RouterCreate(ctx context.Context, options types.SiteConfig) error
+	RouterInspect(ctx context.Context) (*types.RouterInspectResponse, error)
+	RouterRemove(ctx context.Context) error
+	TokenCreate(ctx context.Context, subject string, options types.TokenCreateOptions) (*corev1.Secret, error)
+	LinkCreate(ctx context.Context, token *corev1.Secret, options types.ConnectorCreateOptions) (*corev1.Secret, error)
+	LinkList(ctx context.Context) ([]*types.Connector, error)
+	LinkRemove(ctx context.Context, name string) error
+	ServiceCreate(ctx context.Context, service *types.ServiceInterface) error
+	// ServiceInspect returns nil if the service is not defined
+	ServiceInspect(ctx context.Context, address string) (*types.ServiceInterface, error)
+	ServiceList(ctx context.Context) ([]*types.ServiceInterface, error)
+	ServiceRemove(ctx context.Context, address string) error
+	// ServiceBind makes the process listening on the host a target of
+	// the service, on the given ports or else on those of the service,
+	// defining the service if it is not already
+	ServiceBind(ctx context.Context, service *types.ServiceInterface, host string, targetPorts []int) error
+	ServiceUnbind(ctx context.Context, address string, host string, deleteIfNoTargets bool) error
+}
+
+// HostTargetType is the type of target through which a service of a site
+// that is not on Kubernetes reaches a process on a host
+const HostTargetType string = "host"
+
+// readConnectionToken reads the secret of a token from the file it was
+// written to
+func readConnectionToken(secretFile string) (*corev1.Secret, error) {
+	yaml, err := ioutil.ReadFile(secretFile)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read connection token: %w", err)
+	}
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	var secret corev1.Secret
+	if _, _, err := s.Decode(yaml, nil, &secret); err != nil {
+		return nil, fmt.Errorf("Could not parse connection token: %w", err)
+	}
+	return &secret, nil
+}
+
+// checkCluster returns an error for an operation that needs the
+// Kubernetes cluster of the site if the client runs it elsewhere
+func (cli *VanClient) checkCluster(operation string) error {
+	if cli.Driver != nil {
+		return fmt.Errorf("%s is not supported on %s", operation, cli.Driver.Platform())
+	}
+	return nil
+}
+
+// checkHostTarget returns an error unless the target is one the driver of
+// the client can bind services to
+func (cli *VanClient) checkHostTarget(targetType string) error {
+	if targetType != HostTargetType {
+		return fmt.Errorf("Services of a site on %s can only be bound to a %s", cli.Driver.Platform(), HostTargetType)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/messages"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// SiteInspect reads the state of the site in the namespace (or that of
+// the client if none is given) from the resources that make it up, so
+// that callers need not know which of them to fetch and parse
+func (cli *VanClient) SiteInspect(ctx context.Context, namespace string) (*types.SiteInspectResponse, error) {
+	if namespace == "" {
+		namespace = cli.Namespace
+	}
+	configmap, err := kube.GetConfigMap(types.TransportConfigMapName, namespace, cli.KubeClient)
+	if errors.IsNotFound(err) {
+		return nil, messages.Errorf(messages.SiteNotInitialised, namespace)
+	} else if err != nil {
+		return nil, err
+	}
+	routerConfig, err := qdr.GetRouterConfigFromConfigMap(configmap)
+	if err != nil {
+		return nil, err
+	}
+	state := &types.SiteInspectResponse{
+		Namespace: namespace,
+		Router:    summariseRouterConfig(routerConfig),
+		Versions: types.SiteVersions{
+			Site:       routerConfig.GetSiteMetadata().Version,
+			Transport:  kube.GetComponentVersion(namespace, cli.KubeClient, types.TransportComponentName, types.TransportContainerName),
+			Controller: kube.GetComponentVersion(namespace, cli.KubeClient, types.ControllerComponentName, types.ControllerContainerName),
+		},
+	}
+
+	state.Config, err = cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if state.Config != nil {
+		state.Conditions = append(state.Conditions, types.SiteCondition{Type: types.SiteConditionConfigured, Status: true})
+	} else {
+		state.Conditions = append(state.Conditions, types.SiteCondition{Type: types.SiteConditionConfigured, Message: "No site config found"})
+	}
+
+	state.Links, err = cli.connectorsForRouter(routerConfig, namespace)
+	if err != nil {
+		return nil, err
+	}
+	state.Services, err = cli.serviceInterfaceListInNamespace(namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	sort.Slice(state.Services, func(i, j int) bool {
+		return state.Services[i].Address < state.Services[j].Address
+	})
+
+	condition, err := cli.deploymentCondition(types.SiteConditionRouterReady, types.TransportDeploymentName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	state.Conditions = append(state.Conditions, condition)
+	condition, err = cli.deploymentCondition(types.SiteConditionControllerReady, types.ControllerDeploymentName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	state.Conditions = append(state.Conditions, condition)
+
+	updating, from, err := cli.isUpdating(namespace)
+	if err != nil {
+		return nil, err
+	}
+	condition = types.SiteCondition{Type: types.SiteConditionUpdateInProgress, Status: updating}
+	if updating {
+		condition.Message = fmt.Sprintf("Update from %s in progress", from)
+	}
+	state.Conditions = append(state.Conditions, condition)
+	return state, nil
+}
+
+func summariseRouterConfig(config *qdr.RouterConfig) types.RouterConfigSummary {
+	summary := types.RouterConfigSummary{
+		Id:          config.Metadata.Id,
+		Mode:        string(config.Metadata.Mode),
+		SiteId:      config.GetSiteMetadata().Id,
+		TcpBridges:  len(config.Bridges.TcpListeners) + len(config.Bridges.TcpConnectors),
+		HttpBridges: len(config.Bridges.HttpListeners) + len(config.Bridges.HttpConnectors),
+	}
+	for name := range config.Listeners {
+		summary.Listeners = append(summary.Listeners, name)
+	}
+	for name := range config.Connectors {
+		summary.Connectors = append(summary.Connectors, name)
+	}
+	for name := range config.SslProfiles {
+		summary.SslProfiles = append(summary.SslProfiles, name)
+	}
+	sort.Strings(summary.Listeners)
+	sort.Strings(summary.Connectors)
+	sort.Strings(summary.SslProfiles)
+	return summary
+}
+
+// deploymentCondition reports whether all replicas of the deployment are
+// ready
+func (cli *VanClient) deploymentCondition(conditionType string, name string, namespace string) (types.SiteCondition, error) {
+	condition := types.SiteCondition{Type: conditionType}
+	deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		condition.Message = fmt.Sprintf("Deployment %s not found", name)
+		return condition, nil
+	} else if err != nil {
+		return condition, err
+	}
+	replicas := desiredReplicas(deployment)
+	condition.Status = replicas > 0 && deployment.Status.ReadyReplicas >= replicas
+	condition.Message = fmt.Sprintf("%d of %d replicas ready", deployment.Status.ReadyReplicas, replicas)
+	return condition, nil
+}
+
+func desiredReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
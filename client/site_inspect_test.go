@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
+)
+
+func TestSiteInspect(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.NilError(t, err)
+
+	_, err = cli.SiteInspect(ctx, "")
+	assert.Assert(t, messages.Is(err, messages.SiteNotInitialised))
+
+	err = cli.RouterCreate(ctx, types.SiteConfig{
+		Spec: types.SiteConfigSpec{
+			SkupperName:      "site-a",
+			RouterMode:       string(types.TransportModeInterior),
+			EnableController: true,
+			Ingress:          types.IngressNoneString,
+		},
+	})
+	assert.NilError(t, err)
+	for _, address := range []string{"db", "api"} {
+		service := &types.ServiceInterface{
+			Address:  address,
+			Protocol: "tcp",
+			Ports:    []types.ServicePort{{Port: 8080}},
+		}
+		assert.NilError(t, updateServiceInterface(service, false, nil, cli))
+	}
+
+	state, err := cli.SiteInspect(ctx, "")
+	assert.NilError(t, err)
+	assert.Equal(t, state.Namespace, "skupper")
+	assert.Equal(t, state.Router.Mode, string(types.TransportModeInterior))
+	assert.Equal(t, state.Versions.Site, Version)
+	assert.Assert(t, len(state.Router.Listeners) > 0)
+	assert.Equal(t, len(state.Services), 2)
+	assert.Equal(t, state.Services[0].Address, "api")
+	assert.Equal(t, state.Services[1].Address, "db")
+	assert.Equal(t, len(state.Links), 0)
+
+	routerReady := state.Condition(types.SiteConditionRouterReady)
+	assert.Assert(t, routerReady != nil)
+	assert.Assert(t, !routerReady.Status)
+	assert.Equal(t, routerReady.Message, "0 of 1 replicas ready")
+	updating := state.Condition(types.SiteConditionUpdateInProgress)
+	assert.Assert(t, updating != nil)
+	assert.Assert(t, !updating.Status)
+	assert.Assert(t, state.Condition("Unknown") == nil)
+}
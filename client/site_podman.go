@@ -0,0 +1,697 @@
+package client
+
+import (
+	"context"
+	jsonencoding "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/messages"
+	"github.com/skupperproject/skupper/pkg/naming"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// The files of a site on podman: its state, the certificates of the site
+// and of each of its links, and the config of its router
+const (
+	podmanSiteStateFile      string = "site.json"
+	podmanInternalSslProfile string = "skupper-internal"
+)
+
+// The commands that run the router of a site on podman, replaced in tests
+var (
+	podmanLookPath = exec.LookPath
+	podmanCommand  = runHostCommand
+)
+
+// podmanSiteState is what is recorded of a site on podman, from which the
+// config of its router is generated
+type podmanSiteState struct {
+	Site     types.SiteConfig                   `json:"site"`
+	Links    map[string]*types.Connector        `json:"links"`
+	Services map[string]*types.ServiceInterface `json:"services"`
+}
+
+// PodmanSiteDriver runs a site in a router container on this host, with
+// its certificates and config kept in files rather than secrets and
+// configmaps. The router uses the network of the host, so the endpoints
+// for links and the ports of services are those of the host.
+type PodmanSiteDriver struct {
+	name string
+}
+
+func NewPodmanSiteDriver(name string) *PodmanSiteDriver {
+	return &PodmanSiteDriver{
+		name: name,
+	}
+}
+
+func (d *PodmanSiteDriver) Platform() string {
+	return types.PlatformPodman
+}
+
+func (d *PodmanSiteDriver) dir() (string, error) {
+	return hostDataDir("sites", d.name)
+}
+
+func (d *PodmanSiteDriver) containerName() string {
+	return types.TransportDeploymentName + "-" + d.name
+}
+
+// load returns the state of the site, or nil if it has not been created
+func (d *PodmanSiteDriver) load() (*podmanSiteState, error) {
+	dir, err := d.dir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, podmanSiteStateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	state := &podmanSiteState{}
+	if err := jsonencoding.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("Invalid state for site %s: %s", d.name, err)
+	}
+	if state.Links == nil {
+		state.Links = map[string]*types.Connector{}
+	}
+	if state.Services == nil {
+		state.Services = map[string]*types.ServiceInterface{}
+	}
+	return state, nil
+}
+
+// current returns the state of the site, which must have been created
+func (d *PodmanSiteDriver) current() (*podmanSiteState, error) {
+	state, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, messages.Errorf(messages.SiteNotInitialised, d.name)
+	}
+	return state, nil
+}
+
+func (d *PodmanSiteDriver) save(state *podmanSiteState) error {
+	dir, err := d.dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	data, err := jsonencoding.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, podmanSiteStateFile), data, 0600)
+}
+
+func podmanConfigFile(dir string) string {
+	return filepath.Join(dir, "config", types.TransportConfigFile)
+}
+
+func podmanCertDir(dir string, name string) string {
+	return filepath.Join(dir, "certs", name)
+}
+
+func writeCertDir(dir string, secret *corev1.Secret) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for key, value := range secret.Data {
+		if err := ioutil.WriteFile(filepath.Join(dir, key), value, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readCertDir(dir string, name string) (*corev1.Secret, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Data:       map[string][]byte{},
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		secret.Data[file.Name()] = data
+	}
+	return secret, nil
+}
+
+// podmanSiteHost is the host through which other sites reach this one
+func podmanSiteHost(spec types.SiteConfigSpec) string {
+	if spec.IngressHost != "" {
+		return spec.IngressHost
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "localhost"
+	}
+	return hostname
+}
+
+func podmanSslProfile(name string, certDir string) qdr.SslProfile {
+	return qdr.SslProfile{
+		Name:           name,
+		CertFile:       filepath.Join(certDir, "tls.crt"),
+		PrivateKeyFile: filepath.Join(certDir, "tls.key"),
+		CaCertFile:     filepath.Join(certDir, "ca.crt"),
+	}
+}
+
+func sortedServices(services map[string]*types.ServiceInterface) []*types.ServiceInterface {
+	list := []*types.ServiceInterface{}
+	for _, service := range services {
+		list = append(list, service)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Address < list[j].Address
+	})
+	return list
+}
+
+// podmanRouterConfig returns the config of the router of a site on podman:
+// the endpoints for links if it is an interior router, a connector for
+// each link and a listener on the host for each port of each service,
+// with connectors to the hosts the service is bound to
+func podmanRouterConfig(state *podmanSiteState, dir string) (string, error) {
+	spec := state.Site.Spec
+	siteId := state.Site.Reference.UID
+	config := qdr.InitialConfig(state.Site.Reference.Name+"-${HOSTNAME}", siteId, Version, spec.IsEdge(), 3)
+	config.AddListener(qdr.Listener{
+		Name: "amqp",
+		Host: "localhost",
+		Port: types.AmqpDefaultPort,
+	})
+	if !spec.IsEdge() {
+		config.AddSslProfile(podmanSslProfile(podmanInternalSslProfile, podmanCertDir(dir, types.SiteServerSecret)))
+		config.AddListener(qdr.Listener{
+			Name:             "interior-listener",
+			Role:             qdr.RoleInterRouter,
+			Port:             types.InterRouterListenerPort,
+			SslProfile:       podmanInternalSslProfile,
+			SaslMechanisms:   "EXTERNAL",
+			AuthenticatePeer: true,
+		})
+		config.AddListener(qdr.Listener{
+			Name:             "edge-listener",
+			Role:             qdr.RoleEdge,
+			Port:             types.EdgeListenerPort,
+			SslProfile:       podmanInternalSslProfile,
+			SaslMechanisms:   "EXTERNAL",
+			AuthenticatePeer: true,
+		})
+	}
+	role := qdr.RoleInterRouter
+	if spec.IsEdge() {
+		role = qdr.RoleEdge
+	}
+	for _, link := range state.Links {
+		config.AddSslProfile(podmanSslProfile(link.Name+"-profile", podmanCertDir(dir, link.Name)))
+		config.AddConnector(qdr.Connector{
+			Name:       link.Name,
+			Role:       role,
+			Host:       link.Host,
+			Port:       link.Port,
+			Cost:       link.Cost,
+			SslProfile: link.Name + "-profile",
+		})
+	}
+	for _, service := range sortedServices(state.Services) {
+		primary := service.PrimaryPort()
+		for _, servicePort := range service.Ports {
+			address := types.AddressForPort(service.Address, primary, servicePort.Port)
+			protocol := servicePort.Protocol
+			if protocol == "" {
+				protocol = service.Protocol
+			}
+			port := strconv.Itoa(servicePort.Port)
+			switch protocol {
+			case "http":
+				config.AddHttpListener(qdr.HttpEndpoint{Name: address, Host: "0.0.0.0", Port: port, Address: address, SiteId: siteId})
+			case "http2", "grpc":
+				config.AddHttpListener(qdr.HttpEndpoint{Name: address, Host: "0.0.0.0", Port: port, Address: address, SiteId: siteId, ProtocolVersion: qdr.HttpVersion2})
+			case "tcp":
+				config.AddTcpListener(qdr.TcpEndpoint{Name: address, Host: "0.0.0.0", Port: port, Address: address, SiteId: siteId})
+			default:
+				return "", fmt.Errorf("Unrecognised protocol for service %s: %s", service.Address, protocol)
+			}
+			for _, target := range service.Targets {
+				targetPort := servicePort.Port
+				if value, ok := target.TargetPorts[servicePort.Port]; ok {
+					targetPort = value
+				}
+				name := address + "@" + target.Service
+				switch protocol {
+				case "http":
+					config.AddHttpConnector(qdr.HttpEndpoint{Name: name, Host: target.Service, Port: strconv.Itoa(targetPort), Address: address, SiteId: siteId})
+				case "http2", "grpc":
+					config.AddHttpConnector(qdr.HttpEndpoint{Name: name, Host: target.Service, Port: strconv.Itoa(targetPort), Address: address, SiteId: siteId, ProtocolVersion: qdr.HttpVersion2})
+				default:
+					config.AddTcpConnector(qdr.TcpEndpoint{Name: name, Host: target.Service, Port: strconv.Itoa(targetPort), Address: address, SiteId: siteId})
+				}
+			}
+		}
+	}
+	return qdr.MarshalRouterConfig(config)
+}
+
+// apply writes the config of the router for the state of the site and,
+// if the router is running, restarts it so that it is used
+func (d *PodmanSiteDriver) apply(state *podmanSiteState, restart bool) error {
+	if err := d.save(state); err != nil {
+		return err
+	}
+	dir, err := d.dir()
+	if err != nil {
+		return err
+	}
+	config, err := podmanRouterConfig(state, dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(podmanConfigFile(dir)), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(podmanConfigFile(dir), []byte(config), 0600); err != nil {
+		return fmt.Errorf("Could not write config for site %s: %w", d.name, err)
+	}
+	if !restart {
+		return nil
+	}
+	return podmanCommand("podman", "restart", d.containerName())
+}
+
+func (d *PodmanSiteDriver) SiteConfigCreate(ctx context.Context, spec types.SiteConfigSpec) (*types.SiteConfig, error) {
+	state, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		return nil, fmt.Errorf("Site %s already exists", d.name)
+	}
+	if spec.SkupperName == "" {
+		spec.SkupperName = d.name
+	}
+	state = &podmanSiteState{
+		Site: types.SiteConfig{
+			Spec: spec,
+			Reference: types.SiteConfigReference{
+				UID:  uuid.New().String(),
+				Name: d.name,
+			},
+		},
+		Links:    map[string]*types.Connector{},
+		Services: map[string]*types.ServiceInterface{},
+	}
+	if err := d.save(state); err != nil {
+		return nil, err
+	}
+	return &state.Site, nil
+}
+
+func (d *PodmanSiteDriver) SiteConfigUpdate(ctx context.Context, spec types.SiteConfigSpec) ([]string, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	if spec.SkupperName == "" {
+		spec.SkupperName = d.name
+	}
+	if spec.IsEdge() != state.Site.Spec.IsEdge() {
+		return nil, fmt.Errorf("The router mode of site %s cannot be changed", d.name)
+	}
+	state.Site.Spec = spec
+	if err := d.save(state); err != nil {
+		return nil, err
+	}
+	return []string{"site"}, nil
+}
+
+func (d *PodmanSiteDriver) SiteConfigInspect(ctx context.Context) (*types.SiteConfig, error) {
+	state, err := d.load()
+	if err != nil || state == nil {
+		return nil, err
+	}
+	return &state.Site, nil
+}
+
+// RouterCreate issues the certificates of the site, if it does not yet
+// have them, and starts its router, replacing any that is running
+func (d *PodmanSiteDriver) RouterCreate(ctx context.Context, options types.SiteConfig) error {
+	state, err := d.current()
+	if err != nil {
+		return err
+	}
+	path, err := podmanLookPath("podman")
+	if err != nil {
+		return fmt.Errorf("A site on podman needs podman on this host: %s", err)
+	}
+	dir, err := d.dir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(podmanCertDir(dir, types.SiteCaSecret)); os.IsNotExist(err) {
+		ca := certs.GenerateCASecret(types.SiteCaSecret, types.SiteCaSecret)
+		if err := writeCertDir(podmanCertDir(dir, types.SiteCaSecret), &ca); err != nil {
+			return fmt.Errorf("Could not write certificates for site %s: %w", d.name, err)
+		}
+		server := certs.GenerateSecret(types.SiteServerSecret, types.TransportServiceName, podmanSiteHost(state.Site.Spec), &ca)
+		if err := writeCertDir(podmanCertDir(dir, types.SiteServerSecret), &server); err != nil {
+			return fmt.Errorf("Could not write certificates for site %s: %w", d.name, err)
+		}
+	}
+	if err := d.apply(state, false); err != nil {
+		return err
+	}
+	return podmanCommand(path, "run", "--detach", "--replace", "--restart", "always", "--name", d.containerName(), "--network", "host",
+		"--volume", dir+":"+dir+":z",
+		"--env", types.TransportEnvConfig+"="+podmanConfigFile(dir),
+		"--env", "QDROUTERD_CONF_TYPE=json",
+		GetRouterImageName())
+}
+
+func (d *PodmanSiteDriver) RouterInspect(ctx context.Context) (*types.RouterInspectResponse, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	return &types.RouterInspectResponse{
+		Status: types.RouterStatusSpec{
+			SiteName:      state.Site.Spec.SkupperName,
+			Mode:          state.Site.Spec.RouterMode,
+			BindingsCount: len(state.Services),
+		},
+		TransportVersion: GetRouterImageName(),
+		ExposedServices:  len(state.Services),
+	}, nil
+}
+
+// RouterRemove stops the router of the site and removes its files
+func (d *PodmanSiteDriver) RouterRemove(ctx context.Context) error {
+	if _, err := d.current(); err != nil {
+		return err
+	}
+	if err := podmanCommand("podman", "rm", "--force", d.containerName()); err != nil {
+		return err
+	}
+	dir, err := d.dir()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// TokenCreate issues a certificate from the authority of the site with
+// which another site can link to this one. Tokens cannot be limited in
+// time or uses, as the site has no controller to enforce the limits.
+func (d *PodmanSiteDriver) TokenCreate(ctx context.Context, subject string, options types.TokenCreateOptions) (*corev1.Secret, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	if state.Site.Spec.IsEdge() {
+		return nil, fmt.Errorf("Edge configuration cannot accept connections")
+	}
+	if !state.Site.Spec.AcceptsLinks() {
+		return nil, fmt.Errorf("Cannot create token: %w (link-direction is %s)", ErrLinksNotAccepted, state.Site.Spec.LinkDirection)
+	}
+	if options.Type == types.TokenTypeClaim {
+		return nil, fmt.Errorf("A site on %s does not serve claims, create a token of type %s instead", types.PlatformPodman, types.TokenTypeCert)
+	}
+	if options.Expiry != 0 || options.Uses != 0 {
+		return nil, fmt.Errorf("Tokens of a site on %s cannot have an expiry or limited uses", types.PlatformPodman)
+	}
+	if options.Cost < 0 {
+		return nil, fmt.Errorf("Invalid value for cost: %d, must not be negative", options.Cost)
+	}
+	dir, err := d.dir()
+	if err != nil {
+		return nil, err
+	}
+	ca, err := readCertDir(podmanCertDir(dir, types.SiteCaSecret), types.SiteCaSecret)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the certificate authority of site %s: %w", d.name, err)
+	}
+	host := podmanSiteHost(state.Site.Spec)
+	var secret corev1.Secret
+	if options.Site != "" {
+		if options.Site == state.Site.Reference.UID {
+			return nil, fmt.Errorf("Cannot bind token to the site that generates it")
+		}
+		secret = certs.GenerateSiteBoundSecret(subject, subject, options.Site, host, ca)
+	} else {
+		secret = certs.GenerateSecret(subject, subject, host, ca)
+	}
+	annotateConnectionToken(&secret, "inter-router", host, strconv.Itoa(int(types.InterRouterListenerPort)))
+	annotateConnectionToken(&secret, "edge", host, strconv.Itoa(int(types.EdgeListenerPort)))
+	secret.ObjectMeta.Labels = map[string]string{
+		types.SkupperTypeQualifier: types.TypeToken,
+	}
+	secret.ObjectMeta.Annotations[types.TokenGeneratedBy] = state.Site.Reference.UID
+	if options.Site != "" {
+		secret.ObjectMeta.Annotations[types.TokenSiteId] = options.Site
+	}
+	if options.Cost > 0 {
+		secret.ObjectMeta.Annotations[types.TokenCost] = strconv.Itoa(int(options.Cost))
+	}
+	return &secret, nil
+}
+
+// LinkCreate keeps the certificates of the token with the site and
+// configures its router to connect to the site that issued the token
+func (d *PodmanSiteDriver) LinkCreate(ctx context.Context, token *corev1.Secret, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	if token.ObjectMeta.Annotations[types.TokenGeneratedBy] == state.Site.Reference.UID {
+		return nil, fmt.Errorf("Can't create connection to self with token")
+	}
+	name := options.Name
+	if name == "" {
+		names := []string{}
+		for existing := range state.Links {
+			names = append(names, existing)
+		}
+		name = naming.NextConnectorName(names)
+	} else if err := naming.ValidateLinkName(name); err != nil {
+		return nil, err
+	}
+	if _, ok := state.Links[name]; ok {
+		return nil, fmt.Errorf("Link %s already exists", name)
+	}
+	if err := checkTokenSite(token, name, state.Site.Reference.UID); err != nil {
+		return nil, err
+	}
+	hostKey, portKey := "inter-router-host", "inter-router-port"
+	role := types.ConnectorRoleInterRouter
+	if state.Site.Spec.IsEdge() {
+		hostKey, portKey = "edge-host", "edge-port"
+		role = types.ConnectorRoleEdge
+	}
+	link := &types.Connector{
+		Name: name,
+		Host: token.ObjectMeta.Annotations[hostKey],
+		Port: token.ObjectMeta.Annotations[portKey],
+		Role: string(role),
+		Cost: options.Cost,
+	}
+	if link.Host == "" || link.Port == "" {
+		return nil, fmt.Errorf("Token has no %s endpoint for link %s", role, name)
+	}
+	if link.Cost == 0 {
+		if cost, err := LinkCost(token); err == nil && cost > 0 {
+			link.Cost = cost
+		} else {
+			link.Cost = 1
+		}
+	}
+	dir, err := d.dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCertDir(podmanCertDir(dir, name), token); err != nil {
+		return nil, fmt.Errorf("Could not write certificates for link %s: %w", name, err)
+	}
+	state.Links[name] = link
+	if err := d.apply(state, true); err != nil {
+		return nil, err
+	}
+	secret := token.DeepCopy()
+	secret.ObjectMeta.Name = name
+	return secret, nil
+}
+
+func (d *PodmanSiteDriver) LinkList(ctx context.Context) ([]*types.Connector, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	links := []*types.Connector{}
+	for _, link := range state.Links {
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Name < links[j].Name
+	})
+	return links, nil
+}
+
+func (d *PodmanSiteDriver) LinkRemove(ctx context.Context, name string) error {
+	state, err := d.current()
+	if err != nil {
+		return err
+	}
+	if _, ok := state.Links[name]; !ok {
+		return fmt.Errorf("Link %s not found", name)
+	}
+	delete(state.Links, name)
+	dir, err := d.dir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(podmanCertDir(dir, name)); err != nil {
+		return err
+	}
+	return d.apply(state, true)
+}
+
+func (d *PodmanSiteDriver) ServiceCreate(ctx context.Context, service *types.ServiceInterface) error {
+	state, err := d.current()
+	if err != nil {
+		return err
+	}
+	if _, ok := state.Services[service.Address]; ok {
+		return fmt.Errorf("Service %s already defined", service.Address)
+	}
+	if service.Headless != nil {
+		return fmt.Errorf("Headless services are not supported by sites on %s", types.PlatformPodman)
+	}
+	if err := ValidateServiceInterface(service); err != nil {
+		return err
+	}
+	if service.Targets == nil {
+		service.Targets = []types.ServiceInterfaceTarget{}
+	}
+	state.Services[service.Address] = service
+	return d.apply(state, true)
+}
+
+func (d *PodmanSiteDriver) ServiceInspect(ctx context.Context, address string) (*types.ServiceInterface, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	return state.Services[address], nil
+}
+
+func (d *PodmanSiteDriver) ServiceList(ctx context.Context) ([]*types.ServiceInterface, error) {
+	state, err := d.current()
+	if err != nil {
+		return nil, err
+	}
+	return sortedServices(state.Services), nil
+}
+
+func (d *PodmanSiteDriver) ServiceRemove(ctx context.Context, address string) error {
+	state, err := d.current()
+	if err != nil {
+		return err
+	}
+	if _, ok := state.Services[address]; !ok {
+		return fmt.Errorf("Service %s not defined", address)
+	}
+	delete(state.Services, address)
+	return d.apply(state, true)
+}
+
+func (d *PodmanSiteDriver) ServiceBind(ctx context.Context, service *types.ServiceInterface, host string, targetPorts []int) error {
+	state, err := d.current()
+	if err != nil {
+		return err
+	}
+	if service.Headless != nil {
+		return fmt.Errorf("Headless services are not supported by sites on %s", types.PlatformPodman)
+	}
+	target := types.ServiceInterfaceTarget{
+		Name:    host,
+		Service: host,
+	}
+	if len(service.Ports) == 0 {
+		// the service is exposed on the same ports as the target
+		for _, targetPort := range targetPorts {
+			service.Ports = append(service.Ports, types.ServicePort{Port: targetPort})
+		}
+	} else if len(targetPorts) > len(service.Ports) {
+		return fmt.Errorf("%d target ports specified for a service with %d ports", len(targetPorts), len(service.Ports))
+	} else if len(targetPorts) > 0 {
+		// target ports are given in the order of the ports of the service
+		target.TargetPorts = map[int]int{}
+		for i, targetPort := range targetPorts {
+			target.TargetPorts[service.Ports[i].Port] = targetPort
+		}
+	}
+	if len(service.Ports) == 0 {
+		return fmt.Errorf("Service port required and cannot be deduced.")
+	}
+	targets := []types.ServiceInterfaceTarget{}
+	for _, existing := range service.Targets {
+		if existing.Service != host {
+			targets = append(targets, existing)
+		}
+	}
+	service.Targets = append(targets, target)
+	if err := ValidateServiceInterface(service); err != nil {
+		return err
+	}
+	state.Services[service.Address] = service
+	return d.apply(state, true)
+}
+
+func (d *PodmanSiteDriver) ServiceUnbind(ctx context.Context, address string, host string, deleteIfNoTargets bool) error {
+	state, err := d.current()
+	if err != nil {
+		return err
+	}
+	service, ok := state.Services[address]
+	if !ok {
+		return fmt.Errorf("Service %s not defined", address)
+	}
+	targets := []types.ServiceInterfaceTarget{}
+	for _, existing := range service.Targets {
+		if existing.Service != host {
+			targets = append(targets, existing)
+		}
+	}
+	if len(targets) == len(service.Targets) {
+		return fmt.Errorf("Service %s is not bound to %s", address, host)
+	}
+	service.Targets = targets
+	if len(targets) == 0 && deleteIfNoTargets {
+		delete(state.Services, address)
+	}
+	return d.apply(state, true)
+}
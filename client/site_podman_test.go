@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func readPodmanRouterConfig(t *testing.T, dir string, site string) qdr.RouterConfig {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "skupper", "sites", site, "config", types.TransportConfigFile))
+	assert.Assert(t, err)
+	config, err := qdr.UnmarshalRouterConfig(string(data))
+	assert.Assert(t, err)
+	return config
+}
+
+func TestPodmanSite(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "podman-site")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", dir)
+	commands := []string{}
+	defer func(lookPath func(string) (string, error), command func(string, ...string) error) {
+		podmanLookPath = lookPath
+		podmanCommand = command
+	}(podmanLookPath, podmanCommand)
+	podmanLookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+	podmanCommand = func(name string, args ...string) error {
+		commands = append(commands, filepath.Base(name)+" "+strings.Join(args, " "))
+		return nil
+	}
+
+	_, err = NewClientWithOptions(ClientOptions{Platform: "nomad"})
+	assert.ErrorContains(t, err, `Invalid platform "nomad"`)
+
+	newSite := func(name string, spec types.SiteConfigSpec) *VanClient {
+		cli, err := NewClientWithOptions(ClientOptions{Namespace: name, Platform: types.PlatformPodman})
+		assert.Assert(t, err)
+		assert.Equal(t, cli.Driver.Platform(), types.PlatformPodman)
+		siteConfig, err := cli.SiteConfigInspect(ctx, nil)
+		assert.Assert(t, err)
+		assert.Assert(t, siteConfig == nil)
+		siteConfig, err = cli.SiteConfigCreate(ctx, spec)
+		assert.Assert(t, err)
+		assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+		return cli
+	}
+	east := newSite("east", types.SiteConfigSpec{IngressHost: "east.example.com"})
+	west := newSite("west", types.SiteConfigSpec{RouterMode: string(types.TransportModeEdge)})
+	eastDir := filepath.Join(dir, "skupper", "sites", "east")
+	assert.Equal(t, commands[0], "podman run --detach --replace --restart always --name skupper-router-east --network host --volume "+eastDir+":"+eastDir+":z --env QDROUTERD_CONF="+filepath.Join(eastDir, "config", types.TransportConfigFile)+" --env QDROUTERD_CONF_TYPE=json "+GetRouterImageName())
+	_, err = east.SiteConfigCreate(ctx, types.SiteConfigSpec{})
+	assert.ErrorContains(t, err, "Site east already exists")
+
+	config := readPodmanRouterConfig(t, dir, "east")
+	assert.Assert(t, !config.IsEdge())
+	assert.Equal(t, config.Listeners["interior-listener"].Port, types.InterRouterListenerPort)
+	assert.Equal(t, config.SslProfiles[podmanInternalSslProfile].CertFile, filepath.Join(eastDir, "certs", types.SiteServerSecret, "tls.crt"))
+	assert.Equal(t, len(readPodmanRouterConfig(t, dir, "west").Listeners), 1)
+
+	// the edge site links to the interior one with a token from it
+	tokenFile := filepath.Join(dir, "east.yaml")
+	_, _, err = west.ConnectorTokenCreate(ctx, "west", "")
+	assert.ErrorContains(t, err, "Edge configuration cannot accept connections")
+	_, _, err = east.ConnectorTokenCreateWithOptions(ctx, "west", "", types.TokenCreateOptions{Expiry: time.Hour})
+	assert.ErrorContains(t, err, "cannot have an expiry or limited uses")
+	_, _, err = east.ConnectorTokenCreateWithOptions(ctx, "west", "", types.TokenCreateOptions{Type: types.TokenTypeClaim})
+	assert.ErrorContains(t, err, "does not serve claims")
+	token, _, err := east.ConnectorTokenCreateWithOptions(ctx, "west", "", types.TokenCreateOptions{Cost: 5})
+	assert.Assert(t, err)
+	token.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+	data, err := yaml.Marshal(token)
+	assert.Assert(t, err)
+	assert.Assert(t, ioutil.WriteFile(tokenFile, data, 0600))
+	_, err = east.ConnectorCreateFromFile(ctx, tokenFile, types.ConnectorCreateOptions{})
+	assert.ErrorContains(t, err, "Can't create connection to self")
+	secret, err := west.ConnectorCreateFromFile(ctx, tokenFile, types.ConnectorCreateOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, secret.ObjectMeta.Name, "conn1")
+	links, err := west.ConnectorList(ctx)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, links, []*types.Connector{{Name: "conn1", Host: "east.example.com", Port: "45671", Role: string(types.ConnectorRoleEdge), Cost: 5}})
+	inspected, err := west.ConnectorInspect(ctx, "conn1")
+	assert.Assert(t, err)
+	assert.Equal(t, inspected.Connector.Host, "east.example.com")
+	config = readPodmanRouterConfig(t, dir, "west")
+	assert.Equal(t, config.Connectors["conn1"].Role, qdr.Role(qdr.RoleEdge))
+	assert.Equal(t, config.Connectors["conn1"].SslProfile, "conn1-profile")
+	assert.Equal(t, commands[len(commands)-1], "podman restart skupper-router-west")
+
+	// services are bound to processes reachable from the host
+	service := &types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}}
+	assert.ErrorContains(t, east.ServiceInterfaceBind(ctx, service, "deployment", "db", "", nil), "can only be bound to a host")
+	assert.Assert(t, east.ServiceInterfaceBind(ctx, service, HostTargetType, "localhost", "", []int{15432}))
+	web := &types.ServiceInterface{Address: "web", Protocol: "http"}
+	assert.Assert(t, east.ServiceInterfaceBind(ctx, web, HostTargetType, "10.0.0.1", "", []int{8080}))
+	services, err := east.ServiceInterfaceList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, len(services), 2)
+	assert.DeepEqual(t, services[1].Ports, []types.ServicePort{{Port: 8080}})
+	config = readPodmanRouterConfig(t, dir, "east")
+	assert.Equal(t, config.Bridges.TcpListeners["db"].Port, "5432")
+	assert.DeepEqual(t, config.Bridges.TcpConnectors["db@localhost"], qdr.TcpEndpoint{Name: "db@localhost", Host: "localhost", Port: "15432", Address: "db", SiteId: config.GetSiteMetadata().Id})
+	assert.Equal(t, config.Bridges.HttpConnectors["web@10.0.0.1"].Port, "8080")
+
+	assert.ErrorContains(t, east.ServiceInterfaceUnbind(ctx, HostTargetType, "10.0.0.2", "web", true), "Service web is not bound to 10.0.0.2")
+	assert.Assert(t, east.ServiceInterfaceUnbind(ctx, HostTargetType, "10.0.0.1", "web", true))
+	found, err := east.ServiceInterfaceInspect(ctx, "web")
+	assert.Assert(t, err)
+	assert.Assert(t, found == nil)
+	assert.Assert(t, east.ServiceInterfaceRemove(ctx, "db"))
+	assert.Equal(t, len(readPodmanRouterConfig(t, dir, "east").Bridges.TcpListeners), 0)
+
+	assert.Assert(t, west.ConnectorRemove(ctx, types.ConnectorRemoveOptions{Name: "conn1"}))
+	assert.ErrorContains(t, west.ConnectorRemove(ctx, types.ConnectorRemoveOptions{Name: "conn1"}), "Link conn1 not found")
+
+	router, err := east.RouterInspect(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, router.Status.SiteName, "east")
+
+	assert.Assert(t, east.SiteConfigRemove(ctx))
+	assert.Equal(t, commands[len(commands)-1], "podman rm --force skupper-router-east")
+	_, err = os.Stat(eastDir)
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = east.ServiceInterfaceList(ctx)
+	assert.ErrorContains(t, err, "Skupper not initialised in east")
+}
+
+func TestPodmanClusterOperations(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClientWithOptions(ClientOptions{Namespace: "east", Platform: types.PlatformPodman})
+	assert.Assert(t, err)
+	_, err = cli.TokenList(ctx)
+	assert.Error(t, err, "Listing tokens is not supported on podman")
+	_, err = cli.TokenPrune(ctx, time.Hour)
+	assert.Error(t, err, "Pruning tokens is not supported on podman")
+	_, err = cli.NetworkStatus(ctx)
+	assert.Error(t, err, "Network status is not supported on podman")
+	_, err = cli.RouterUpdateVersion(ctx, false)
+	assert.Error(t, err, "Updating the site is not supported on podman")
+	err = cli.ServiceInterfaceUpdate(ctx, &types.ServiceInterface{Address: "db"})
+	assert.Error(t, err, "Updating a service is not supported on podman")
+	err = cli.DebugDump(ctx, "dump.tar.gz")
+	assert.Error(t, err, "Dumping the site is not supported on podman")
+	_, err = cli.GatewayInit(ctx, "vm1", "")
+	assert.Error(t, err, "Attaching gateways is not supported on podman")
+	_, err = cli.GatewayList(ctx)
+	assert.Error(t, err, "Attaching gateways is not supported on podman")
+}
@@ -0,0 +1,331 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+var (
+	ErrClaimNotFound = errors.New("no such claim")
+	ErrClaimRefused  = errors.New("claim refused")
+	// errClaimsNotServed is returned for a site created before claims
+	// were served, which can only issue tokens of type cert
+	errClaimsNotServed = errors.New("the site does not serve claims")
+)
+
+// claimRedeemTimeout bounds the request made to the site that issued a
+// claim to redeem it
+var claimRedeemTimeout = 30 * time.Second
+
+// IsClaim returns true if the secret is a claim to be redeemed for a
+// token rather than a token
+func IsClaim(secret *corev1.Secret) bool {
+	return secret.ObjectMeta.Labels[types.SkupperTypeQualifier] == types.TypeClaimRequest
+}
+
+func claimsUrl(host string, port string, record string) string {
+	return "https://" + net.JoinHostPort(host, port) + types.ClaimsPath + record
+}
+
+// claimsEndpoint returns the host and port through which the claims of the
+// site are redeemed, and whether they can only be reached from within the
+// cluster, as is also the case while a load balancer has yet to be given
+// an address. Claims are served through the same kind of ingress as links
+// are made through.
+func (cli *VanClient) claimsEndpoint(namespace string, siteConfig *types.SiteConfig) (string, string, bool, error) {
+	service, err := kube.GetService(types.ClaimsServiceName, namespace, cli.KubeClient)
+	if err != nil {
+		return "", "", false, fmt.Errorf("%w (%s)", errClaimsNotServed, err)
+	}
+	if siteConfig.Spec.IsIngressRoute() && cli.RouteClient != nil {
+		route, err := cli.RouteClient.Routes(namespace).Get(types.ClaimsServiceName, metav1.GetOptions{})
+		if err == nil {
+			return route.Spec.Host, "443", false, nil
+		} else if !kerrors.IsNotFound(err) {
+			return "", "", false, err
+		}
+	}
+	if siteConfig.Spec.IsIngressKubernetes() && cli.DynamicClient != nil {
+		host, err := kube.GetIngressHost(types.ClaimsServiceName, namespace, cli.DynamicClient)
+		if err == nil {
+			return host, "443", false, nil
+		} else if !kerrors.IsNotFound(err) {
+			return "", "", false, err
+		}
+	}
+	if siteConfig.Spec.IsIngressGateway() && cli.GatewayApi && cli.DynamicClient != nil {
+		host, err := kube.GetTLSRouteHost(types.ClaimsServiceName, namespace, cli.DynamicClient)
+		if err == nil {
+			return host, "443", false, nil
+		} else if !kerrors.IsNotFound(err) {
+			return "", "", false, err
+		}
+	}
+	port := strconv.Itoa(int(types.ClaimsPort))
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		if host := kube.GetLoadBalancerHostOrIp(service); host != "" {
+			return host, port, false, nil
+		}
+	}
+	return types.ClaimsServiceName + "." + namespace, port, true, nil
+}
+
+// claimCreate returns a claim for a token for the given endpoints, and
+// records it in the site so that the service controller can redeem it.
+// The limits on the use of the token are checked when it is redeemed.
+func (cli *VanClient) claimCreate(subject string, namespace string, options types.TokenCreateOptions, siteConfig *types.SiteConfig, ca *corev1.Secret, hostPorts RouterHostPorts) (*corev1.Secret, bool, error) {
+	host, port, localOnly, err := cli.claimsEndpoint(namespace, siteConfig)
+	if err != nil {
+		return nil, false, err
+	}
+	password := make([]byte, 24)
+	if _, err := rand.Read(password); err != nil {
+		return nil, false, fmt.Errorf("Could not generate password for claim: %w", err)
+	}
+	encoded := []byte(base64.RawURLEncoding.EncodeToString(password))
+	name := uuid.New().String()
+	record := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				types.SkupperTypeQualifier: types.TypeClaimRecord,
+			},
+			Annotations: map[string]string{
+				types.ClaimSubjectAnnotation: subject,
+			},
+		},
+		Data: map[string][]byte{
+			types.ClaimPasswordDataKey: encoded,
+		},
+	}
+	annotateConnectionToken(record, "inter-router", hostPorts.InterRouter.Host, hostPorts.InterRouter.Port)
+	annotateConnectionToken(record, "edge", hostPorts.Edge.Host, hostPorts.Edge.Port)
+	if options.Expiry > 0 {
+		record.ObjectMeta.Annotations[types.TokenExpiry] = time.Now().Add(options.Expiry).UTC().Format(time.RFC3339)
+	}
+	if options.Uses > 0 {
+		record.ObjectMeta.Annotations[types.TokenMaxUses] = strconv.Itoa(options.Uses)
+	}
+	if options.Expiry > 0 || options.Uses > 0 {
+		// the id under which the sites that redeemed the claim are
+		// recorded
+		record.ObjectMeta.Annotations[types.TokenId] = name
+	}
+	if options.Site != "" {
+		record.ObjectMeta.Annotations[types.TokenSiteId] = options.Site
+	}
+	if options.Cost > 0 {
+		record.ObjectMeta.Annotations[types.TokenCost] = strconv.Itoa(int(options.Cost))
+	}
+	if deployment, err := kube.GetDeployment(types.TransportDeploymentName, namespace, cli.KubeClient); err == nil {
+		record.ObjectMeta.OwnerReferences = []metav1.OwnerReference{kube.GetDeploymentOwnerReference(deployment)}
+	}
+	if _, err := cli.KubeClient.CoreV1().Secrets(namespace).Create(record); err != nil {
+		return nil, false, fmt.Errorf("Could not record claim: %w", err)
+	}
+	claim := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: subject,
+			Labels: map[string]string{
+				types.SkupperTypeQualifier: types.TypeClaimRequest,
+			},
+			Annotations: map[string]string{
+				types.ClaimUrlAnnotation: claimsUrl(host, port, name),
+			},
+		},
+		Data: map[string][]byte{
+			types.ClaimPasswordDataKey: encoded,
+			types.ClaimCaCertDataKey:   ca.Data["tls.crt"],
+		},
+	}
+	if siteConfig != nil {
+		claim.ObjectMeta.Annotations[types.TokenGeneratedBy] = siteConfig.Reference.UID
+	}
+	return claim, localOnly, nil
+}
+
+// claimEndpoints returns the endpoints recorded for the token of a claim
+func claimEndpoints(record *corev1.Secret) RouterHostPorts {
+	annotations := record.ObjectMeta.Annotations
+	endpoints := RouterHostPorts{
+		InterRouter: HostPort{Host: annotations["inter-router-host"], Port: annotations["inter-router-port"]},
+		Edge:        HostPort{Host: annotations["edge-host"], Port: annotations["edge-port"]},
+	}
+	endpoints.Hosts = endpoints.InterRouter.Host
+	if endpoints.Edge.Host != endpoints.InterRouter.Host {
+		endpoints.Hosts = endpoints.Edge.Host + "," + endpoints.InterRouter.Host
+	}
+	return endpoints
+}
+
+// ClaimRedeem returns a token for the site with the given id in return for
+// the named claim, if the password is that of the claim and the limits
+// given when it was created allow it. A site that has already redeemed
+// the claim may always do so again.
+func (cli *VanClient) ClaimRedeem(ctx context.Context, name string, siteId string, password []byte) (*corev1.Secret, error) {
+	record, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) || (err == nil && record.ObjectMeta.Labels[types.SkupperTypeQualifier] != types.TypeClaimRecord) {
+		return nil, fmt.Errorf("%w: %s", ErrClaimNotFound, name)
+	} else if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(password, record.Data[types.ClaimPasswordDataKey]) != 1 {
+		return nil, fmt.Errorf("%w: %s", ErrClaimRefused, name)
+	}
+	bound := record.ObjectMeta.Annotations[types.TokenSiteId]
+	if bound != "" && bound != siteId {
+		return nil, fmt.Errorf("Cannot redeem claim %s: %w (%s)", name, ErrTokenSite, bound)
+	}
+	if siteId == "" && record.ObjectMeta.Annotations[types.TokenId] != "" {
+		return nil, fmt.Errorf("%w: %s, the site redeeming it is not identified", ErrClaimRefused, name)
+	}
+	// claimed by the site rather than by a link, as the names of links
+	// are only unique within a site
+	if err := cli.claimToken(record, siteId, cli.Namespace); err != nil {
+		return nil, err
+	}
+	cost, err := LinkCost(record)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := claimEndpoints(record)
+	options := types.TokenCreateOptions{
+		Site: bound,
+		Cost: cost,
+	}
+	token, _, err := cli.connectorTokenCreate(ctx, record.ObjectMeta.Annotations[types.ClaimSubjectAnnotation], "", options, &endpoints)
+	return token, err
+}
+
+// verifyClaimsServer checks that the certificate presented by the site
+// redeeming a claim was issued for its claims service by the CA in the
+// claim. The name of the service is checked rather than the host the
+// claim was sent to, which depends on how the site is exposed.
+func verifyClaimsServer(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("No certificate presented by the site redeeming the claim")
+	}
+	certs := []*x509.Certificate{}
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       types.ClaimsServiceName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// claimProxy returns the proxy through which a claim is redeemed: that of
+// the link being created, if it has one, or else any in the environment
+func claimProxy(proxy types.LinkProxy) func(*http.Request) (*url.URL, error) {
+	if proxy.IsEmpty() {
+		return http.ProxyFromEnvironment
+	}
+	proxyUrl := &url.URL{Scheme: "http", Host: proxy.Address()}
+	if proxy.User != "" {
+		proxyUrl.User = url.UserPassword(proxy.User, proxy.Password)
+	}
+	return http.ProxyURL(proxyUrl)
+}
+
+// redeemClaim asks the site that issued the claim for a token in return
+// for it, on behalf of the site with the given id, through the proxy of
+// the link if it has one
+func redeemClaim(claim *corev1.Secret, siteId string, proxy types.LinkProxy) (*corev1.Secret, error) {
+	claimUrl := claim.ObjectMeta.Annotations[types.ClaimUrlAnnotation]
+	if claimUrl == "" {
+		return nil, fmt.Errorf("Claim %s has no url to redeem it at", claim.ObjectMeta.Name)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(claim.Data[types.ClaimCaCertDataKey]) {
+		return nil, fmt.Errorf("Claim %s has no valid certificate authority", claim.ObjectMeta.Name)
+	}
+	client := &http.Client{
+		Timeout: claimRedeemTimeout,
+		Transport: &http.Transport{
+			Proxy: claimProxy(proxy),
+			TLSClientConfig: &tls.Config{
+				// verified against the name of the claims
+				// service instead
+				InsecureSkipVerify: true,
+				VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+					return verifyClaimsServer(rawCerts, roots)
+				},
+			},
+		},
+	}
+	resp, err := client.Post(claimUrl+"?site="+url.QueryEscape(siteId), "text/plain", bytes.NewReader(claim.Data[types.ClaimPasswordDataKey]))
+	if err != nil {
+		return nil, fmt.Errorf("Could not redeem claim: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Could not redeem claim: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Claim was not redeemed (%s): %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var token corev1.Secret
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("Could not parse token returned for claim: %w", err)
+	}
+	return &token, nil
+}
+
+// redeemClaimInNamespace redeems the claim on behalf of the site in the
+// namespace, through the proxy of the link being created from it
+func (cli *VanClient) redeemClaimInNamespace(ctx context.Context, claim *corev1.Secret, namespace string, proxy types.LinkProxy) (*corev1.Secret, error) {
+	var siteConfig *types.SiteConfig
+	var err error
+	if cli.Driver != nil {
+		siteConfig, err = cli.Driver.SiteConfigInspect(ctx)
+	} else {
+		siteConfig, err = cli.SiteConfigInspectInNamespace(ctx, nil, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	siteId := ""
+	if siteConfig != nil {
+		siteId = siteConfig.Reference.UID
+	}
+	return redeemClaim(claim, siteId, proxy)
+}
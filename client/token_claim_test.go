@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestTokenClaimRedeem(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	configureSiteAndCreateRouter(t, ctx, cli, "public")
+
+	_, _, err = cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "", types.TokenCreateOptions{Type: "password"})
+	assert.Error(t, err, `Invalid token type "password", must be claim or cert`)
+	assert.ErrorContains(t, cli.TokenExportOffline(ctx, "conn1", "bundle.yaml", types.OfflineBundleOptions{TokenCreateOptions: types.TokenCreateOptions{Type: types.TokenTypeClaim}}), "cannot be a claim")
+
+	claim, localOnly, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "", types.TokenCreateOptions{Type: types.TokenTypeClaim, Uses: 1, Cost: 3})
+	assert.Assert(t, err)
+	assert.Assert(t, localOnly)
+	assert.Assert(t, IsClaim(claim))
+	_, ok := claim.Data["tls.key"]
+	assert.Assert(t, !ok)
+	url := claim.ObjectMeta.Annotations[types.ClaimUrlAnnotation]
+	assert.Assert(t, strings.HasPrefix(url, "https://skupper-claims.skupper:8081/claims/"), url)
+	name := strings.TrimPrefix(url, "https://skupper-claims.skupper:8081/claims/")
+	record, err := cli.KubeClient.CoreV1().Secrets("skupper").Get(name, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, record.ObjectMeta.Labels[types.SkupperTypeQualifier], types.TypeClaimRecord)
+	assert.Equal(t, record.ObjectMeta.Annotations[types.TokenMaxUses], "1")
+	password := claim.Data[types.ClaimPasswordDataKey]
+
+	_, err = cli.ClaimRedeem(ctx, "unknown", "site-a", password)
+	assert.Assert(t, errors.Is(err, ErrClaimNotFound), err)
+	_, err = cli.ClaimRedeem(ctx, types.SiteCaSecret, "site-a", password)
+	assert.Assert(t, errors.Is(err, ErrClaimNotFound), err)
+	_, err = cli.ClaimRedeem(ctx, name, "site-a", []byte("guess"))
+	assert.Assert(t, errors.Is(err, ErrClaimRefused), err)
+
+	token, err := cli.ClaimRedeem(ctx, name, "site-a", password)
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Name, "conn1")
+	assert.Equal(t, token.ObjectMeta.Labels[types.SkupperTypeQualifier], types.TypeToken)
+	assert.Equal(t, token.ObjectMeta.Annotations["inter-router-host"], "skupper-router.skupper")
+	assert.Equal(t, token.ObjectMeta.Annotations[types.TokenCost], "3")
+	_, ok = token.ObjectMeta.Annotations[types.TokenId]
+	assert.Assert(t, !ok)
+	// the site that redeemed the claim can do so again, no other can
+	_, err = cli.ClaimRedeem(ctx, name, "site-a", password)
+	assert.Assert(t, err)
+	_, err = cli.ClaimRedeem(ctx, name, "site-b", password)
+	assert.Assert(t, errors.Is(err, ErrTokenClaimed), err)
+
+	bound, _, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn2", "", types.TokenCreateOptions{Type: types.TokenTypeClaim, Site: "site-a", Expiry: time.Hour})
+	assert.Assert(t, err)
+	name = strings.TrimPrefix(bound.ObjectMeta.Annotations[types.ClaimUrlAnnotation], "https://skupper-claims.skupper:8081/claims/")
+	_, err = cli.ClaimRedeem(ctx, name, "site-b", bound.Data[types.ClaimPasswordDataKey])
+	assert.Assert(t, errors.Is(err, ErrTokenSite), err)
+
+	edge, err := newMockClient("edge", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := edge.SiteConfigCreate(ctx, types.SiteConfigSpec{RouterMode: string(types.TransportModeEdge), EnableController: true, Ingress: types.IngressNoneString})
+	assert.Assert(t, err)
+	assert.Assert(t, edge.RouterCreate(ctx, *siteConfig))
+	_, _, err = edge.ConnectorTokenCreateWithOptions(ctx, "conn1", "", types.TokenCreateOptions{Type: types.TokenTypeClaim})
+	assert.Error(t, err, "Edge configuration cannot accept connections")
+}
+
+func TestTokenClaimRedeemOverTls(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	configureSiteAndCreateRouter(t, ctx, cli, "public")
+	claim, _, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "", types.TokenCreateOptions{Type: types.TokenTypeClaim})
+	assert.Assert(t, err)
+
+	serverSecret, err := cli.KubeClient.CoreV1().Secrets("skupper").Get(types.ClaimsServerSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	cert, err := tls.X509KeyPair(serverSecret.Data["tls.crt"], serverSecret.Data["tls.key"])
+	assert.Assert(t, err)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		password, _ := ioutil.ReadAll(r.Body)
+		token, err := cli.ClaimRedeem(r.Context(), strings.TrimPrefix(r.URL.Path, types.ClaimsPath), r.URL.Query().Get("site"), password)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(token)
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+
+	url := claim.ObjectMeta.Annotations[types.ClaimUrlAnnotation]
+	claim.ObjectMeta.Annotations[types.ClaimUrlAnnotation] = server.URL + url[strings.Index(url, types.ClaimsPath):]
+	token, err := redeemClaim(claim, "site-a", types.LinkProxy{})
+	assert.Assert(t, err)
+	assert.Equal(t, token.ObjectMeta.Labels[types.SkupperTypeQualifier], types.TypeToken)
+	assert.Assert(t, len(token.Data["tls.crt"]) > 0)
+
+	password := claim.Data[types.ClaimPasswordDataKey]
+	claim.Data[types.ClaimPasswordDataKey] = []byte("guess")
+	_, err = redeemClaim(claim, "site-a", types.LinkProxy{})
+	assert.ErrorContains(t, err, "claim refused")
+	claim.Data[types.ClaimPasswordDataKey] = password
+
+	// a server with a certificate from another CA is not trusted
+	other := httptest.NewTLSServer(handler)
+	defer other.Close()
+	claim.ObjectMeta.Annotations[types.ClaimUrlAnnotation] = other.URL + types.ClaimsPath + "conn1"
+	_, err = redeemClaim(claim, "site-a", types.LinkProxy{})
+	assert.ErrorContains(t, err, "Could not redeem claim")
+}
+
+func TestTokenClaimRedeemThroughProxy(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	configureSiteAndCreateRouter(t, ctx, cli, "public")
+	claim, _, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "", types.TokenCreateOptions{Type: types.TokenTypeClaim})
+	assert.Assert(t, err)
+
+	// the proxy of the link refuses the tunnel, which is enough to know
+	// that the claim was redeemed through it
+	tunnels := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tunnels = append(tunnels, r.Method+" "+r.Host+" "+r.Header.Get("Proxy-Authorization"))
+		http.Error(w, "not allowed", http.StatusForbidden)
+	}))
+	defer proxy.Close()
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(proxy.URL, "http://"))
+	assert.Assert(t, err)
+	_, err = redeemClaim(claim, "site-a", types.LinkProxy{Host: host, Port: port, User: "user", Password: "secret"})
+	assert.ErrorContains(t, err, "Could not redeem claim")
+	assert.DeepEqual(t, tunnels, []string{"CONNECT skupper-claims.skupper:8081 Basic dXNlcjpzZWNyZXQ="})
+}
+
+func TestTokenClaimNotServed(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	configureSiteAndCreateRouter(t, ctx, cli, "public")
+	warnings := []string{}
+	cli.Events = func(event types.ClientEvent) {
+		if event.Type == types.ClientEventWarning {
+			warnings = append(warnings, event.Message)
+		}
+	}
+	// as for a site created before claims were served
+	assert.Assert(t, cli.KubeClient.CoreV1().Services("skupper").Delete(types.ClaimsServiceName, &metav1.DeleteOptions{}))
+
+	token, _, err := cli.ConnectorTokenCreateWithOptions(ctx, "conn1", "", types.TokenCreateOptions{Type: types.TokenTypeClaim})
+	assert.Assert(t, err)
+	assert.Assert(t, !IsClaim(token))
+	assert.Equal(t, token.ObjectMeta.Labels[types.SkupperTypeQualifier], types.TypeToken)
+	assert.Equal(t, len(warnings), 1)
+	assert.Assert(t, strings.HasPrefix(warnings[0], "Creating a token of type cert instead of a claim, as the site does not serve claims"), warnings[0])
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// routerConnections retrieves the connections of the router, which tell
+// whether a link is connected
+var routerConnections = qdr.GetConnections
+
+// LinkLastConnected returns when the link was last seen active by the
+// service controller, or zero if it never was
+func LinkLastConnected(secret *corev1.Secret) time.Time {
+	value, ok := secret.ObjectMeta.Annotations[types.LinkLastConnected]
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// tokenInfo describes the secret, using the connections of the router to
+// tell whether a link is connected
+func tokenInfo(secret *corev1.Secret, current *qdr.RouterConfig, connections []qdr.Connection) *types.TokenInfo {
+	info := &types.TokenInfo{
+		Name:              secret.ObjectMeta.Name,
+		Type:              secret.ObjectMeta.Labels[types.SkupperTypeQualifier],
+		Created:           secret.ObjectMeta.CreationTimestamp.Time,
+		CertificateExpiry: LinkCertificateExpiry(secret),
+		LastConnected:     LinkLastConnected(secret),
+		Protected:         secret.ObjectMeta.Annotations[types.ProtectedAnnotation] == "true",
+	}
+	if _, expiry, _, err := tokenLimits(secret); err == nil {
+		info.Expiry = expiry
+	}
+	if info.Type != types.TypeToken {
+		return info
+	}
+	_, info.Configured = current.Connectors[secret.ObjectMeta.Name]
+	hostKey := "inter-router-host"
+	portKey := "inter-router-port"
+	if current.IsEdge() {
+		hostKey = "edge-host"
+		portKey = "edge-port"
+	}
+	host := secret.ObjectMeta.Annotations[hostKey] + ":" + secret.ObjectMeta.Annotations[portKey]
+	if connection := qdr.GetInterRouterOrEdgeConnection(host, connections); connection != nil && connection.Active {
+		info.Connected = true
+	}
+	return info
+}
+
+// TokenList returns the secrets of the site that hold tokens: those of
+// the links made with a token and the requests for a token that the site
+// controller has yet to fulfil
+func (cli *VanClient) TokenList(ctx context.Context) ([]*types.TokenInfo, error) {
+	if err := cli.checkCluster("Listing tokens"); err != nil {
+		return nil, err
+	}
+	configmap, err := kube.GetConfigMap(types.TransportConfigMapName, cli.Namespace, cli.KubeClient)
+	if err != nil {
+		return nil, err
+	}
+	current, err := qdr.GetRouterConfigFromConfigMap(configmap)
+	if err != nil {
+		return nil, err
+	}
+	// without the connections of the router it is not known which links
+	// are connected, so none could be safely pruned
+	connections, err := routerConnections(cli.Namespace, cli.KubeClient, cli.RestConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve the connections of the router: %w", err)
+	}
+	tokens := []*types.TokenInfo{}
+	for _, selector := range []string{types.TypeTokenQualifier, types.TypeTokenRequestQualifier} {
+		secrets, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for i := range secrets.Items {
+			tokens = append(tokens, tokenInfo(&secrets.Items[i], current, connections))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Name < tokens[j].Name
+	})
+	return tokens, nil
+}
+
+// TokenPrune removes the links and token requests that are not in use and
+// have not been for longer than the given time, returning their names.
+// Links that are connected and secrets annotated as protected are kept,
+// and nothing is removed if the connections of the router are unknown.
+func (cli *VanClient) TokenPrune(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	if cli.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if err := cli.checkCluster("Pruning tokens"); err != nil {
+		return nil, err
+	}
+	tokens, err := cli.TokenList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	pruned := []string{}
+	for _, token := range tokens {
+		if !token.IsStale(olderThan, now) {
+			continue
+		}
+		if token.Type == types.TypeToken {
+			err = cli.ConnectorRemove(ctx, types.ConnectorRemoveOptions{
+				Name:             token.Name,
+				SkupperNamespace: cli.Namespace,
+				ForceCurrent:     true,
+			})
+		} else {
+			err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Delete(token.Name, &metav1.DeleteOptions{})
+		}
+		if err != nil {
+			return pruned, fmt.Errorf("Could not remove %s: %w", token.Name, err)
+		}
+		pruned = append(pruned, token.Name)
+	}
+	return pruned, nil
+}
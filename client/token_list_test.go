@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestTokenListAndPrune(t *testing.T) {
+	ctx := context.Background()
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+	siteConfig, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:      "skupper",
+		RouterMode:       string(types.TransportModeInterior),
+		EnableController: true,
+		Ingress:          types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	assert.Assert(t, cli.RouterCreate(ctx, *siteConfig))
+	defer func(connections func(string, kubernetes.Interface, *restclient.Config) ([]qdr.Connection, error)) {
+		routerConnections = connections
+	}(routerConnections)
+	routerConnections = func(string, kubernetes.Interface, *restclient.Config) ([]qdr.Connection, error) {
+		return []qdr.Connection{}, nil
+	}
+
+	now := time.Now()
+	secret := func(name string, kind string, created time.Time, annotations map[string]string) {
+		_, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{types.SkupperTypeQualifier: kind},
+				Annotations:       annotations,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		})
+		assert.Assert(t, err)
+	}
+	secret("old", types.TypeToken, now.Add(-48*time.Hour), nil)
+	secret("used", types.TypeToken, now.Add(-48*time.Hour), map[string]string{types.LinkLastConnected: now.Add(-time.Hour).Format(time.RFC3339)})
+	secret("protected", types.TypeToken, now.Add(-48*time.Hour), map[string]string{types.ProtectedAnnotation: "true"})
+	secret("request", types.TypeTokenRequest, now.Add(-48*time.Hour), map[string]string{types.TokenId: "abc", types.TokenExpiry: now.Add(-24 * time.Hour).UTC().Format(time.RFC3339)})
+	secret("new", types.TypeToken, now.Add(-time.Hour), nil)
+
+	tokens, err := cli.TokenList(ctx)
+	assert.Assert(t, err)
+	names := []string{}
+	for _, token := range tokens {
+		names = append(names, token.Name)
+	}
+	assert.DeepEqual(t, names, []string{"new", "old", "protected", "request", "used"})
+	assert.Equal(t, tokens[3].Type, types.TypeTokenRequest)
+	assert.Assert(t, !tokens[3].Expiry.IsZero())
+	assert.Equal(t, tokens[4].LastUsed().Unix(), now.Add(-time.Hour).Unix())
+	assert.Assert(t, tokens[1].IsStale(24*time.Hour, now))
+	assert.Assert(t, !tokens[2].IsStale(24*time.Hour, now))
+
+	cli.ReadOnly = true
+	_, err = cli.TokenPrune(ctx, 24*time.Hour)
+	assert.Equal(t, err, ErrReadOnly)
+	cli.ReadOnly = false
+
+	// nothing is pruned while it is not known which links are connected
+	routerConnections = func(string, kubernetes.Interface, *restclient.Config) ([]qdr.Connection, error) {
+		return nil, fmt.Errorf("router not running")
+	}
+	pruned, err := cli.TokenPrune(ctx, 24*time.Hour)
+	assert.Error(t, err, "Could not retrieve the connections of the router: router not running")
+	assert.Equal(t, len(pruned), 0)
+	routerConnections = func(string, kubernetes.Interface, *restclient.Config) ([]qdr.Connection, error) {
+		return []qdr.Connection{}, nil
+	}
+
+	pruned, err = cli.TokenPrune(ctx, 24*time.Hour)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, pruned, []string{"old", "request"})
+	tokens, err = cli.TokenList(ctx)
+	assert.Assert(t, err)
+	assert.Equal(t, len(tokens), 3)
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
@@ -32,7 +35,44 @@ func get(path string, output string) error {
 	return scanner.Err()
 }
 
+// post requests the action at the given path, failing unless the
+// controller accepts it
+func post(path string) error {
+	resp, err := http.Post("http://localhost:8181/"+path, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Response status: %s %s", resp.Status, body)
+	}
+	return nil
+}
+
+// profile writes the raw profile data to stdout, so that it can be
+// retrieved intact through kubectl exec
+func profile(name string, seconds int) error {
+	url := "http://localhost:8181/debug/pprof/" + name
+	if seconds > 0 {
+		url += "?seconds=" + strconv.Itoa(seconds)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Response status: %s", resp.Status)
+	}
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
 var output string
+var seconds int
 
 func simplePathCommand(path string, description string) *cobra.Command {
 	return &cobra.Command{
@@ -52,6 +92,9 @@ func main() {
 	rootCmd.AddCommand(simplePathCommand("version", "Shows version information"))
 	rootCmd.AddCommand(simplePathCommand("sites", "Shows connected sites"))
 	rootCmd.AddCommand(simplePathCommand("services", "Shows exposed services"))
+	rootCmd.AddCommand(simplePathCommand("linkprobe", "Probes each link with messages of increasing size to detect MTU problems"))
+	rootCmd.AddCommand(simplePathCommand("peers", "Compares the view each peer has of this site with the local view"))
+	rootCmd.AddCommand(simplePathCommand("network", "Shows the sites of the network, their links, services and gateways"))
 
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "servicecheck <address>",
@@ -63,6 +106,39 @@ func main() {
 		},
 	})
 
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "openapi [console|local|metrics]",
+		Short: "Shows the OpenAPI document describing the requests handled by a listener, the local one by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "openapi.json"
+			if len(args) > 0 {
+				path += "?listener=" + args[0]
+			}
+			return get(path, "")
+		},
+	})
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "servicesync",
+		Short: "Exchanges service definitions with the rest of the network now rather than at the next sync interval",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return post("servicesync")
+		},
+	})
+
+	profileCmd := &cobra.Command{
+		Use:   "profile <name>",
+		Short: "Retrieves a runtime profile (e.g. profile, heap, goroutine) if profiling is enabled",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return profile(args[0], seconds)
+		},
+	}
+	profileCmd.Flags().IntVar(&seconds, "seconds", 0, "The duration of the cpu profile")
+	rootCmd.AddCommand(profileCmd)
+
 	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "The output format to use (one of json or text)")
 
 	if err := rootCmd.Execute(); err != nil {
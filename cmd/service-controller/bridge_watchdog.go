@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	BridgeWatchdogEvent string = "BridgeWatchdogEvent"
+	BridgeWatchdogError string = "BridgeWatchdogError"
+)
+
+const (
+	bridgeWatchdogInterval = 30 * time.Second
+	// the number of failed checks, less one for each check without a
+	// failure, at which a bridge is restarted
+	bridgeWatchdogThreshold = 3
+	// the time to wait after restarting a bridge before doing so again,
+	// which doubles with each restart that does not help
+	bridgeWatchdogBackoff    = time.Minute
+	bridgeWatchdogMaxBackoff = 30 * time.Minute
+)
+
+// bridgeHealth is what the watchdog has seen of a bridge
+type bridgeHealth struct {
+	errors      int
+	traffic     int
+	failures    int
+	restarts    int
+	lastRestart time.Time
+}
+
+// stuckBridge is a bridge the watchdog decided to restart
+type stuckBridge struct {
+	status qdr.BridgeStatus
+	reason string
+}
+
+// Periodically checks the bridges on each router replica, restarting
+// those that are stuck: listeners the router could not bind and
+// connectors whose connections to their target keep failing. Bridges
+// that still fail are restarted less and less often.
+type BridgeWatchdog struct {
+	configSync *ConfigSync
+	replicas   map[*qdr.AgentPool]map[string]*bridgeHealth
+}
+
+func newBridgeWatchdog(configSync *ConfigSync) *BridgeWatchdog {
+	return &BridgeWatchdog{
+		configSync: configSync,
+		replicas:   map[*qdr.AgentPool]map[string]*bridgeHealth{},
+	}
+}
+
+func (w *BridgeWatchdog) start(stopCh <-chan struct{}) {
+	go wait.Until(w.check, bridgeWatchdogInterval, stopCh)
+}
+
+func bridgeDescription(status qdr.BridgeStatus) string {
+	entity := status.Entity[strings.LastIndex(status.Entity, ".")+1:]
+	return fmt.Sprintf("%s %s for %s", entity, status.Name, status.Address)
+}
+
+func restartBackoff(restarts int) time.Duration {
+	backoff := bridgeWatchdogBackoff
+	for i := 1; i < restarts && backoff < bridgeWatchdogMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > bridgeWatchdogMaxBackoff {
+		return bridgeWatchdogMaxBackoff
+	}
+	return backoff
+}
+
+// stuckBridges updates what has been seen of the bridges on a replica and
+// returns those that should be restarted now. A bridge is healthy again
+// once it carries traffic, and each check in which it did not fail takes
+// one from its failures, so that only repeated failures restart it.
+// Connections opened without traffic are not failures, as health probes
+// of a listener or target connect without sending anything.
+func stuckBridges(seen map[string]*bridgeHealth, statuses []qdr.BridgeStatus, now time.Time) []stuckBridge {
+	stuck := []stuckBridge{}
+	current := map[string]bool{}
+	for _, status := range statuses {
+		if isSelfTestEntity(status.Name) {
+			continue
+		}
+		key := status.Entity + "/" + status.Name
+		current[key] = true
+		traffic := status.BytesIn + status.BytesOut
+		health, ok := seen[key]
+		if !ok {
+			health = &bridgeHealth{errors: status.ConnectionErrors, traffic: traffic}
+			seen[key] = health
+		}
+		reason := ""
+		if status.IsListener() && status.OperStatus == qdr.BridgeOperStatusDown {
+			reason = "the router could not listen on its port"
+		} else if traffic > health.traffic {
+			health.failures = 0
+			health.restarts = 0
+		} else if !status.IsListener() && status.ConnectionErrors > health.errors {
+			reason = fmt.Sprintf("%d connections to its target failed", status.ConnectionErrors-health.errors)
+		}
+		// the counts start again when a bridge is restarted
+		health.errors = status.ConnectionErrors
+		health.traffic = traffic
+		if reason == "" {
+			if health.failures > 0 {
+				health.failures--
+			}
+			continue
+		}
+		health.failures++
+		if health.failures < bridgeWatchdogThreshold || (health.restarts > 0 && now.Sub(health.lastRestart) < restartBackoff(health.restarts)) {
+			continue
+		}
+		health.failures = 0
+		health.restarts++
+		health.lastRestart = now
+		health.errors = 0
+		health.traffic = 0
+		stuck = append(stuck, stuckBridge{status: status, reason: reason})
+	}
+	for key := range seen {
+		if !current[key] {
+			delete(seen, key)
+		}
+	}
+	return stuck
+}
+
+// restartDifference removes a bridge and adds it again, so that the
+// router opens its listener or connects to its target afresh
+func restartDifference(actual *qdr.BridgeConfig, status qdr.BridgeStatus) (*qdr.BridgeConfigDifference, bool) {
+	difference := &qdr.BridgeConfigDifference{}
+	switch status.Entity {
+	case "org.apache.qpid.dispatch.tcpListener":
+		endpoint, ok := actual.TcpListeners[status.Name]
+		if !ok {
+			return nil, false
+		}
+		difference.TcpListeners = qdr.TcpEndpointDifference{Deleted: []string{status.Name}, Added: []qdr.TcpEndpoint{endpoint}}
+	case "org.apache.qpid.dispatch.tcpConnector":
+		endpoint, ok := actual.TcpConnectors[status.Name]
+		if !ok {
+			return nil, false
+		}
+		difference.TcpConnectors = qdr.TcpEndpointDifference{Deleted: []string{status.Name}, Added: []qdr.TcpEndpoint{endpoint}}
+	case "org.apache.qpid.dispatch.httpListener":
+		endpoint, ok := actual.HttpListeners[status.Name]
+		if !ok {
+			return nil, false
+		}
+		difference.HttpListeners = qdr.HttpEndpointDifference{Deleted: []string{status.Name}, Added: []qdr.HttpEndpoint{endpoint}}
+	case "org.apache.qpid.dispatch.httpConnector":
+		endpoint, ok := actual.HttpConnectors[status.Name]
+		if !ok {
+			return nil, false
+		}
+		difference.HttpConnectors = qdr.HttpEndpointDifference{Deleted: []string{status.Name}, Added: []qdr.HttpEndpoint{endpoint}}
+	default:
+		return nil, false
+	}
+	return difference, true
+}
+
+func (w *BridgeWatchdog) check() {
+	// the listeners are removed on purpose while shedding load
+	if w.configSync.isShedding() {
+		return
+	}
+	pools, err := w.configSync.agentPools()
+	if err != nil {
+		event.Recordf(BridgeWatchdogError, "%s", err)
+		return
+	}
+	replicas := map[*qdr.AgentPool]map[string]*bridgeHealth{}
+	for _, pool := range pools {
+		seen, ok := w.replicas[pool]
+		if !ok {
+			seen = map[string]*bridgeHealth{}
+		}
+		replicas[pool] = seen
+		if err := w.checkReplica(pool, seen); err != nil {
+			event.Recordf(BridgeWatchdogError, "%s", err)
+		}
+	}
+	w.replicas = replicas
+}
+
+func (w *BridgeWatchdog) checkReplica(pool *qdr.AgentPool, seen map[string]*bridgeHealth) error {
+	agent, err := pool.Get()
+	if err != nil {
+		return fmt.Errorf("Could not get management agent: %s", err)
+	}
+	defer pool.Put(agent)
+	statuses, err := agent.GetLocalBridgeStatus()
+	if err != nil {
+		return fmt.Errorf("Could not retrieve the state of the bridges: %s", err)
+	}
+	stuck := stuckBridges(seen, statuses, time.Now())
+	if len(stuck) == 0 {
+		return nil
+	}
+	actual, err := agent.GetLocalBridgeConfig()
+	if err != nil {
+		return fmt.Errorf("Could not retrieve bridges: %s", err)
+	}
+	for _, bridge := range stuck {
+		description := bridgeDescription(bridge.status)
+		difference, ok := restartDifference(actual, bridge.status)
+		if !ok {
+			continue
+		}
+		event.Recordf(BridgeWatchdogEvent, "Restarting %s as %s", description, bridge.reason)
+		if err := agent.UpdateLocalBridgeConfig(difference); err != nil {
+			event.Recordf(BridgeWatchdogError, "Could not restart %s: %s", description, err)
+		}
+	}
+	return nil
+}
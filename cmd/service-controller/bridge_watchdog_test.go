@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestStuckBridges(t *testing.T) {
+	seen := map[string]*bridgeHealth{}
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	listener := qdr.BridgeStatus{Entity: "org.apache.qpid.dispatch.tcpListener", Name: "db:5432", Address: "db", OperStatus: qdr.BridgeOperStatusUp}
+	connector := qdr.BridgeStatus{Entity: "org.apache.qpid.dispatch.tcpConnector", Name: "db@10.0.0.1:5432", Address: "db"}
+	selfTest := qdr.BridgeStatus{Entity: "org.apache.qpid.dispatch.tcpListener", Name: selfTestName + "-listener", OperStatus: qdr.BridgeOperStatusDown}
+	check := func(minutes int) []stuckBridge {
+		return stuckBridges(seen, []qdr.BridgeStatus{listener, connector, selfTest}, start.Add(time.Duration(minutes)*time.Minute))
+	}
+
+	assert.Equal(t, len(check(0)), 0)
+	assert.Equal(t, len(seen), 2)
+
+	// health probes connect to the listener without sending anything,
+	// while the connections of the connector to its target fail
+	for i := 1; i < bridgeWatchdogThreshold; i++ {
+		listener.ConnectionsOpened++
+		connector.ConnectionErrors += 2
+		assert.Equal(t, len(check(i)), 0)
+	}
+	listener.ConnectionsOpened++
+	connector.ConnectionErrors += 2
+	stuck := check(bridgeWatchdogThreshold)
+	assert.Equal(t, len(stuck), 1)
+	assert.Equal(t, stuck[0].status.Name, connector.Name)
+	assert.Equal(t, stuck[0].reason, "2 connections to its target failed")
+
+	// the restarted connector still fails, so it is only restarted again
+	// after the backoff
+	connector.ConnectionErrors = 0
+	for i := 1; i <= bridgeWatchdogThreshold; i++ {
+		connector.ConnectionErrors++
+		assert.Equal(t, len(check(bridgeWatchdogThreshold)), 0)
+	}
+	connector.ConnectionErrors++
+	stuck = check(bridgeWatchdogThreshold + 1)
+	assert.Equal(t, len(stuck), 1)
+	assert.Equal(t, seen[connector.Entity+"/"+connector.Name].restarts, 2)
+
+	// traffic makes the connector healthy again
+	connector.ConnectionErrors = 0
+	connector.BytesOut = 10
+	assert.Equal(t, len(check(10)), 0)
+	assert.Equal(t, seen[connector.Entity+"/"+connector.Name].restarts, 0)
+
+	// failures decay in the checks without one, so occasional failures
+	// do not add up to a restart
+	for i := 0; i < 4*bridgeWatchdogThreshold; i++ {
+		if i%2 == 0 {
+			connector.ConnectionErrors++
+		}
+		assert.Equal(t, len(check(11+i)), 0)
+	}
+
+	// a listener the router could not bind
+	listener.OperStatus = qdr.BridgeOperStatusDown
+	for i := 1; i < bridgeWatchdogThreshold; i++ {
+		assert.Equal(t, len(check(30+i)), 0)
+	}
+	stuck = check(30 + bridgeWatchdogThreshold)
+	assert.Equal(t, len(stuck), 1)
+	assert.Equal(t, stuck[0].reason, "the router could not listen on its port")
+	assert.Equal(t, bridgeDescription(stuck[0].status), "tcpListener db:5432 for db")
+
+	// bridges that were removed are forgotten
+	stuckBridges(seen, []qdr.BridgeStatus{connector}, start.Add(time.Hour))
+	assert.Equal(t, len(seen), 1)
+}
+
+func TestRestartBackoff(t *testing.T) {
+	assert.Equal(t, restartBackoff(1), time.Minute)
+	assert.Equal(t, restartBackoff(2), 2*time.Minute)
+	assert.Equal(t, restartBackoff(4), 8*time.Minute)
+	assert.Equal(t, restartBackoff(10), bridgeWatchdogMaxBackoff)
+}
+
+func TestRestartDifference(t *testing.T) {
+	actual := qdr.NewBridgeConfig()
+	actual.AddTcpListener(qdr.TcpEndpoint{Name: "db:5432", Port: "1024", Address: "db"})
+	actual.AddHttpConnector(qdr.HttpEndpoint{Name: "web@10.0.0.1:8080", Host: "10.0.0.1", Port: "8080", Address: "web"})
+
+	difference, ok := restartDifference(&actual, qdr.BridgeStatus{Entity: "org.apache.qpid.dispatch.tcpListener", Name: "db:5432"})
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, difference.TcpListeners.Deleted, []string{"db:5432"})
+	assert.DeepEqual(t, difference.TcpListeners.Added, []qdr.TcpEndpoint{{Name: "db:5432", Port: "1024", Address: "db"}})
+	assert.Assert(t, difference.HttpConnectors.Deleted == nil)
+
+	difference, ok = restartDifference(&actual, qdr.BridgeStatus{Entity: "org.apache.qpid.dispatch.httpConnector", Name: "web@10.0.0.1:8080"})
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, difference.HttpConnectors.Deleted, []string{"web@10.0.0.1:8080"})
+	assert.Equal(t, difference.HttpConnectors.Added[0].Host, "10.0.0.1")
+
+	_, ok = restartDifference(&actual, qdr.BridgeStatus{Entity: "org.apache.qpid.dispatch.tcpConnector", Name: "gone"})
+	assert.Assert(t, !ok)
+}
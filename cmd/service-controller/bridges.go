@@ -15,6 +15,7 @@ import (
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/event"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
@@ -27,35 +28,42 @@ func getBridgeName(address string, host string) string {
 }
 
 type EgressBindings struct {
-	name       string
-	selector   string
-	service    string
-	egressPort int
-	informer   cache.SharedIndexInformer
-	stopper    chan struct{}
+	name        string
+	namespace   string
+	selector    string
+	service     string
+	egressPorts map[int]int
+	informer    cache.SharedIndexInformer
+	stopper     chan struct{}
 }
 
 type ServiceBindings struct {
-	origin       string
-	protocol     string
-	address      string
-	publicPort   int
-	ingressPort  int
-	aggregation  string
-	eventChannel bool
-	headless     *types.Headless
-	targets      map[string]*EgressBindings
+	origin         string
+	protocol       string
+	address        string
+	ports          []types.ServicePort
+	ingressPorts   map[int]int
+	aggregation    string
+	eventChannel   bool
+	headless       *types.Headless
+	healthCheck    *types.HealthCheck
+	tlsCredentials string
+	tlsTrust       string
+	targets        map[string]*EgressBindings
 }
 
 func asServiceInterface(bindings *ServiceBindings) types.ServiceInterface {
 	return types.ServiceInterface{
-		Address:      bindings.address,
-		Protocol:     bindings.protocol,
-		Port:         bindings.publicPort,
-		Aggregate:    bindings.aggregation,
-		EventChannel: bindings.eventChannel,
-		Headless:     bindings.headless,
-		Origin:       bindings.origin,
+		Address:        bindings.address,
+		Protocol:       bindings.protocol,
+		Ports:          bindings.ports,
+		Aggregate:      bindings.aggregation,
+		EventChannel:   bindings.eventChannel,
+		Headless:       bindings.headless,
+		Origin:         bindings.origin,
+		HealthCheck:    bindings.healthCheck,
+		TlsCredentials: bindings.tlsCredentials,
+		TlsTrust:       bindings.tlsTrust,
 	}
 }
 
@@ -71,61 +79,75 @@ func newServiceController() *ServiceController {
 	}
 }
 
-func getTargetPort(service types.ServiceInterface, target types.ServiceInterfaceTarget) int {
-	targetPort := target.TargetPort
-	if targetPort == 0 {
-		targetPort = service.Port
+// getTargetPorts maps each port of the service to the port the target
+// listens on for it
+func getTargetPorts(service types.ServiceInterface, target types.ServiceInterfaceTarget) map[int]int {
+	targetPorts := map[int]int{}
+	for _, p := range service.Ports {
+		targetPorts[p.Port] = target.TargetPortFor(p.Port)
 	}
-	return targetPort
+	return targetPorts
 }
 
-func hasTargetForSelector(si types.ServiceInterface, selector string) bool {
-	for _, t := range si.Targets {
-		if t.Selector == selector {
-			return true
+func equivalentPorts(a map[int]int, b map[int]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if v2, ok := b[k]; !ok || v != v2 {
+			return false
 		}
 	}
-	return false
+	return true
 }
 
-func hasTargetForService(si types.ServiceInterface, service string) bool {
+func hasTarget(si types.ServiceInterface, key string) bool {
 	for _, t := range si.Targets {
-		if t.Service == service {
+		if t.Key() == key {
 			return true
 		}
 	}
 	return false
 }
 
+// checkTargetNamespace returns an error unless the target is in the
+// namespace of the site or one that has opted in to its workloads being
+// exposed by the site
+func (c *Controller) checkTargetNamespace(target types.ServiceInterfaceTarget) error {
+	if target.Namespace == "" || target.Namespace == c.vanClient.Namespace {
+		return nil
+	}
+	return kube.CheckTargetNamespace(target.Namespace, c.vanClient.Namespace, c.vanClient.KubeClient)
+}
+
+// addTarget starts resolving a target of the service, unless it is in a
+// namespace the site may not expose targets from
+func (sb *ServiceBindings) addTarget(t types.ServiceInterfaceTarget, ports map[int]int, c *Controller) {
+	if err := c.checkTargetNamespace(t); err != nil {
+		event.Recordf(BridgeTargetEvent, "Ignoring target %s for %s: %s", t.Name, sb.address, err)
+		return
+	}
+	if t.Selector != "" {
+		sb.addSelectorTarget(t.Name, t.Namespace, t.Selector, ports, c)
+	} else if t.Service != "" {
+		sb.addServiceTarget(t.Name, t.Namespace, t.Service, ports, c)
+	}
+}
+
 func (c *Controller) updateServiceBindings(required types.ServiceInterface, portAllocations map[string]int) error {
 	bindings := c.bindings[required.Address]
 	if bindings == nil {
 		//create it
-		var port int
-		//headless services use distinct proxy pods, so don't need to allocate a port
-		if required.Headless != nil {
-			port = required.Port
-		} else {
-			if portAllocations != nil {
-				//existing bridge configuration is used on initiaising map to recover
-				//any previous port allocations
-				port = portAllocations[required.Address]
-			}
-			if port == 0 {
-				var err error
-				port, err = c.ports.nextFreePort()
-				if err != nil {
-					return err
-				}
-			}
+		sb := newServiceBindings(required.Origin, required.Protocol, required.Address, required.Ports, required.Headless, required.Aggregate, required.EventChannel)
+		err := c.allocateIngressPorts(sb, portAllocations)
+		if err != nil {
+			return err
 		}
-		sb := newServiceBindings(required.Origin, required.Protocol, required.Address, required.Port, required.Headless, port, required.Aggregate, required.EventChannel)
+		sb.healthCheck = required.HealthCheck
+		sb.tlsCredentials = required.TlsCredentials
+		sb.tlsTrust = required.TlsTrust
 		for _, t := range required.Targets {
-			if t.Selector != "" {
-				sb.addSelectorTarget(t.Name, t.Selector, getTargetPort(required, t), c)
-			} else if t.Service != "" {
-				sb.addServiceTarget(t.Name, t.Service, getTargetPort(required, t), c)
-			}
+			sb.addTarget(t, getTargetPorts(required, t), c)
 		}
 		c.bindings[required.Address] = sb
 	} else {
@@ -133,8 +155,12 @@ func (c *Controller) updateServiceBindings(required types.ServiceInterface, port
 		if bindings.protocol != required.Protocol {
 			bindings.protocol = required.Protocol
 		}
-		if bindings.publicPort != required.Port {
-			bindings.publicPort = required.Port
+		if current := asServiceInterface(bindings); !current.SamePorts(&required) {
+			bindings.ports = required.Ports
+			err := c.allocateIngressPorts(bindings, nil)
+			if err != nil {
+				return err
+			}
 		}
 		if bindings.aggregation != required.Aggregate {
 			bindings.aggregation = required.Aggregate
@@ -142,6 +168,9 @@ func (c *Controller) updateServiceBindings(required types.ServiceInterface, port
 		if bindings.eventChannel != required.EventChannel {
 			bindings.eventChannel = required.EventChannel
 		}
+		bindings.healthCheck = required.HealthCheck
+		bindings.tlsCredentials = required.TlsCredentials
+		bindings.tlsTrust = required.TlsTrust
 		if required.Headless != nil {
 			if bindings.headless == nil {
 				bindings.headless = required.Headless
@@ -158,33 +187,27 @@ func (c *Controller) updateServiceBindings(required types.ServiceInterface, port
 
 		hasSkupperSelector := false
 		for _, t := range required.Targets {
-			targetPort := getTargetPort(required, t)
+			targetPorts := getTargetPorts(required, t)
 			if strings.Contains(t.Selector, "skupper.io/component=router") {
 				hasSkupperSelector = true
 			}
-			if t.Selector != "" {
-				target := bindings.targets[t.Selector]
-				if target == nil {
-					bindings.addSelectorTarget(t.Name, t.Selector, targetPort, c)
-				} else if target.egressPort != targetPort {
-					target.egressPort = targetPort
-				}
-			} else if t.Service != "" {
-				target := bindings.targets[t.Service]
-				if target == nil {
-					bindings.addServiceTarget(t.Name, t.Service, targetPort, c)
-				} else if target.egressPort != targetPort {
-					target.egressPort = targetPort
-				}
+			if t.Selector == "" && t.Service == "" {
+				continue
+			}
+			target := bindings.targets[t.Key()]
+			if target == nil {
+				bindings.addTarget(t, targetPorts, c)
+			} else if !equivalentPorts(target.egressPorts, targetPorts) {
+				target.egressPorts = targetPorts
 			}
 		}
 		for k, v := range bindings.targets {
 			if v.selector != "" {
-				if !hasTargetForSelector(required, k) && !hasSkupperSelector {
+				if !hasTarget(required, k) && !hasSkupperSelector {
 					bindings.removeSelectorTarget(k)
 				}
 			} else if v.service != "" {
-				if !hasTargetForService(required, k) {
+				if !hasTarget(required, k) {
 					bindings.removeServiceTarget(k)
 				}
 			}
@@ -193,13 +216,42 @@ func (c *Controller) updateServiceBindings(required types.ServiceInterface, port
 	return nil
 }
 
-func newServiceBindings(origin string, protocol string, address string, publicPort int, headless *types.Headless, ingressPort int, aggregation string, eventChannel bool) *ServiceBindings {
+// allocateIngressPorts assigns a port for the router to listen on to each
+// port of the service that does not have one yet
+func (c *Controller) allocateIngressPorts(sb *ServiceBindings, portAllocations map[string]int) error {
+	ingressPorts := map[int]int{}
+	for _, p := range sb.ports {
+		port := sb.ingressPorts[p.Port]
+		//headless services use distinct proxy pods, so don't need to allocate a port
+		if sb.headless != nil {
+			port = p.Port
+		} else if port == 0 {
+			if portAllocations != nil {
+				//existing bridge configuration is used on initiaising map to recover
+				//any previous port allocations
+				port = portAllocations[sb.addressForPort(p.Port)]
+			}
+			if port == 0 {
+				var err error
+				port, err = c.ports.nextFreePort()
+				if err != nil {
+					return err
+				}
+			}
+		}
+		ingressPorts[p.Port] = port
+	}
+	sb.ingressPorts = ingressPorts
+	return nil
+}
+
+func newServiceBindings(origin string, protocol string, address string, ports []types.ServicePort, headless *types.Headless, aggregation string, eventChannel bool) *ServiceBindings {
 	return &ServiceBindings{
 		origin:       origin,
 		protocol:     protocol,
 		address:      address,
-		publicPort:   publicPort,
-		ingressPort:  ingressPort,
+		ports:        ports,
+		ingressPorts: map[int]int{},
 		aggregation:  aggregation,
 		eventChannel: eventChannel,
 		headless:     headless,
@@ -207,14 +259,50 @@ func newServiceBindings(origin string, protocol string, address string, publicPo
 	}
 }
 
-func (sb *ServiceBindings) addSelectorTarget(name string, selector string, port int, controller *Controller) error {
-	sb.targets[selector] = &EgressBindings{
-		name:       name,
-		selector:   selector,
-		egressPort: port,
+func (sb *ServiceBindings) primaryPort() int {
+	if len(sb.ports) == 0 {
+		return 0
+	}
+	return sb.ports[0].Port
+}
+
+// portMappings returns the ports of the service along with the port on
+// the router each is forwarded to
+func (sb *ServiceBindings) portMappings() ([]int, []int) {
+	publicPorts := []int{}
+	ingressPorts := []int{}
+	for _, p := range sb.ports {
+		publicPorts = append(publicPorts, p.Port)
+		ingressPorts = append(ingressPorts, sb.ingressPorts[p.Port])
+	}
+	return publicPorts, ingressPorts
+}
+
+func (sb *ServiceBindings) addressForPort(port int) string {
+	return types.AddressForPort(sb.address, sb.primaryPort(), port)
+}
+
+func (sb *ServiceBindings) protocolFor(port types.ServicePort) string {
+	if port.Protocol == "" {
+		return sb.protocol
+	}
+	return port.Protocol
+}
+
+func (sb *ServiceBindings) addSelectorTarget(name string, namespace string, selector string, ports map[int]int, controller *Controller) error {
+	key := (&types.ServiceInterfaceTarget{Selector: selector, Namespace: namespace}).Key()
+	informerNamespace := namespace
+	if informerNamespace == "" {
+		informerNamespace = controller.vanClient.Namespace
+	}
+	sb.targets[key] = &EgressBindings{
+		name:        name,
+		namespace:   namespace,
+		selector:    selector,
+		egressPorts: ports,
 		informer: corev1informer.NewFilteredPodInformer(
 			controller.vanClient.KubeClient,
-			controller.vanClient.Namespace,
+			informerNamespace,
 			time.Second*30,
 			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 			internalinterfaces.TweakListOptionsFunc(func(options *metav1.ListOptions) {
@@ -222,27 +310,29 @@ func (sb *ServiceBindings) addSelectorTarget(name string, selector string, port
 			})),
 		stopper: make(chan struct{}),
 	}
-	sb.targets[selector].informer.AddEventHandler(controller.newEventHandler("targetpods@"+sb.address, FixedKey, PodResourceVersionTest))
-	return sb.targets[selector].start()
+	sb.targets[key].informer.AddEventHandler(controller.newEventHandler("targetpods@"+sb.address, FixedKey, PodResourceVersionTest))
+	return sb.targets[key].start()
 }
 
-func (sb *ServiceBindings) removeSelectorTarget(selector string) {
-	sb.targets[selector].stop()
-	delete(sb.targets, selector)
+func (sb *ServiceBindings) removeSelectorTarget(key string) {
+	sb.targets[key].stop()
+	delete(sb.targets, key)
 }
 
-func (sb *ServiceBindings) addServiceTarget(name string, service string, port int, controller *Controller) error {
-	sb.targets[service] = &EgressBindings{
-		name:       name,
-		service:    service,
-		egressPort: port,
-		stopper:    make(chan struct{}),
+func (sb *ServiceBindings) addServiceTarget(name string, namespace string, service string, ports map[int]int, controller *Controller) error {
+	key := (&types.ServiceInterfaceTarget{Service: service, Namespace: namespace}).Key()
+	sb.targets[key] = &EgressBindings{
+		name:        name,
+		namespace:   namespace,
+		service:     service,
+		egressPorts: ports,
+		stopper:     make(chan struct{}),
 	}
 	return nil
 }
 
-func (sb *ServiceBindings) removeServiceTarget(service string) {
-	delete(sb.targets, service)
+func (sb *ServiceBindings) removeServiceTarget(key string) {
+	delete(sb.targets, key)
 }
 
 func (sb *ServiceBindings) stop() {
@@ -253,11 +343,13 @@ func (sb *ServiceBindings) stop() {
 	}
 }
 
-func (sb *ServiceBindings) updateBridgeConfiguration(siteId string, bridges *qdr.BridgeConfig) {
+func (sb *ServiceBindings) updateBridgeConfiguration(siteId string, bridges *qdr.BridgeConfig, health *HealthChecker) {
 	if sb.headless == nil {
-		addIngressBridge(sb, siteId, bridges)
+		for _, port := range sb.ports {
+			addIngressBridge(sb, port, siteId, bridges)
+		}
 		for _, eb := range sb.targets {
-			eb.updateBridgeConfiguration(sb, siteId, bridges)
+			eb.updateBridgeConfiguration(sb, siteId, bridges, health)
 		}
 	} // headless proxies are not specified through the main bridge configuration
 }
@@ -274,24 +366,55 @@ func (eb *EgressBindings) stop() {
 	close(eb.stopper)
 }
 
+// serviceHost is the name the target service is reached by from the
+// namespace of the site
+func (eb *EgressBindings) serviceHost() string {
+	if eb.namespace != "" {
+		return eb.service + "." + eb.namespace
+	}
+	return eb.service
+}
+
 const (
 	BridgeTargetEvent string = "BridgeTargetEvent"
 )
 
-func (eb *EgressBindings) updateBridgeConfiguration(sb *ServiceBindings, siteId string, bridges *qdr.BridgeConfig) {
+// addEgressBridges adds a connector to the given host for each port of
+// the service. Connectors for ports other than the primary one are
+// qualified by the port, as the addresses they serve are.
+func (eb *EgressBindings) addEgressBridges(sb *ServiceBindings, host string, hostOverride string, siteId string, bridges *qdr.BridgeConfig) {
+	for _, port := range sb.ports {
+		target := types.AddressForPort(eb.name, sb.primaryPort(), port.Port)
+		addEgressBridge(sb.protocolFor(port), host, eb.egressPorts[port.Port], sb.addressForPort(port.Port), target, siteId, hostOverride, sb.aggregation, sb.eventChannel, sb.egressSslProfile(), bridges)
+	}
+}
+
+func (eb *EgressBindings) updateBridgeConfiguration(sb *ServiceBindings, siteId string, bridges *qdr.BridgeConfig, health *HealthChecker) {
+	// targets are health checked on the port of the primary port of the
+	// service unless the check specifies otherwise
+	checkPort := eb.egressPorts[sb.primaryPort()]
 	if eb.selector != "" {
 		pods := eb.informer.GetStore().List()
 		for _, p := range pods {
 			pod := p.(*corev1.Pod)
 			if kube.IsPodRunning(pod) && kube.IsPodReady(pod) && pod.DeletionTimestamp == nil {
+				if !health.isHealthy(sb.address, sb.healthCheck, pod.Status.PodIP, checkPort) {
+					event.Recordf(BridgeTargetEvent, "Pod for %s has not passed health check: %s", sb.address, pod.ObjectMeta.Name)
+					continue
+				}
 				event.Recordf(BridgeTargetEvent, "Adding pod for %s: %s", sb.address, pod.ObjectMeta.Name)
-				addEgressBridge(sb.protocol, pod.Status.PodIP, eb.egressPort, sb.address, eb.name, siteId, "", sb.aggregation, sb.eventChannel, bridges)
+				eb.addEgressBridges(sb, pod.Status.PodIP, "", siteId, bridges)
 			} else {
 				event.Recordf(BridgeTargetEvent, "Pod for %s not ready/running: %s", sb.address, pod.ObjectMeta.Name)
 			}
 		}
 	} else if eb.service != "" {
-		addEgressBridge(sb.protocol, eb.service, eb.egressPort, sb.address, eb.name, siteId, eb.service, sb.aggregation, sb.eventChannel, bridges)
+		host := eb.serviceHost()
+		if !health.isHealthy(sb.address, sb.healthCheck, host, checkPort) {
+			event.Recordf(BridgeTargetEvent, "Service for %s has not passed health check: %s", sb.address, host)
+			return
+		}
+		eb.addEgressBridges(sb, host, host, siteId, bridges)
 	}
 }
 
@@ -301,23 +424,29 @@ func newBridgeConfiguration() *qdr.BridgeConfig {
 }
 
 const (
+	// tcp passes the bytes of any protocol carried over it through
+	// unchanged, so is also used for those the router cannot parse
 	ProtocolTCP   string = "tcp"
 	ProtocolHTTP  string = "http"
 	ProtocolHTTP2 string = "http2"
+	// gRPC is bridged as http2, which the router speaks to targets as
+	// h2c (http2 over cleartext, with prior knowledge), as gRPC does
+	ProtocolGRPC string = "grpc"
 )
 
-func addEgressBridge(protocol string, host string, port int, address string, target string, siteId string, hostOverride string, aggregation string, eventchannel bool, bridges *qdr.BridgeConfig) (bool, error) {
+func addEgressBridge(protocol string, host string, port int, address string, target string, siteId string, hostOverride string, aggregation string, eventchannel bool, sslProfile string, bridges *qdr.BridgeConfig) (bool, error) {
 	if host == "" {
 		return false, fmt.Errorf("Cannot add connector without host (%s %s)", address, protocol)
 	}
 	switch protocol {
 	case ProtocolHTTP:
 		b := qdr.HttpEndpoint{
-			Name:    getBridgeName(target, host),
-			Host:    host,
-			Port:    strconv.Itoa(port),
-			Address: address,
-			SiteId:  siteId,
+			Name:       getBridgeName(target, host),
+			Host:       host,
+			Port:       strconv.Itoa(port),
+			Address:    address,
+			SiteId:     siteId,
+			SslProfile: sslProfile,
 		}
 		if aggregation != "" {
 			b.Aggregation = aggregation
@@ -331,7 +460,7 @@ func addEgressBridge(protocol string, host string, port int, address string, tar
 			b.HostOverride = hostOverride
 		}
 		bridges.AddHttpConnector(b)
-	case ProtocolHTTP2:
+	case ProtocolHTTP2, ProtocolGRPC:
 		bridges.AddHttpConnector(qdr.HttpEndpoint{
 			Name:            getBridgeName(target, host),
 			Host:            host,
@@ -339,14 +468,16 @@ func addEgressBridge(protocol string, host string, port int, address string, tar
 			Address:         address,
 			SiteId:          siteId,
 			ProtocolVersion: qdr.HttpVersion2,
+			SslProfile:      sslProfile,
 		})
 	case ProtocolTCP:
 		bridges.AddTcpConnector(qdr.TcpEndpoint{
-			Name:    getBridgeName(target, host),
-			Host:    host,
-			Port:    strconv.Itoa(port),
-			Address: address,
-			SiteId:  siteId,
+			Name:       getBridgeName(target, host),
+			Host:       host,
+			Port:       strconv.Itoa(port),
+			Address:    address,
+			SiteId:     siteId,
+			SslProfile: sslProfile,
 		})
 	default:
 		return false, fmt.Errorf("Unrecognised protocol for service %s: %s", address, protocol)
@@ -354,54 +485,117 @@ func addEgressBridge(protocol string, host string, port int, address string, tar
 	return true, nil
 }
 
-func addIngressBridge(sb *ServiceBindings, siteId string, bridges *qdr.BridgeConfig) (bool, error) {
-	switch sb.protocol {
+// ingressSslProfile returns the ssl profile with which the listeners for
+// the service terminate TLS, if they do
+func (sb *ServiceBindings) ingressSslProfile() string {
+	if sb.tlsCredentials == "" {
+		return ""
+	}
+	return naming.ServiceSslProfileName(sb.tlsCredentials)
+}
+
+// egressSslProfile returns the ssl profile with which the connectors for
+// the service originate TLS to its targets, if they do
+func (sb *ServiceBindings) egressSslProfile() string {
+	if sb.tlsTrust == "" {
+		return ""
+	}
+	return naming.ServiceSslProfileName(sb.tlsTrust)
+}
+
+func addIngressBridge(sb *ServiceBindings, port types.ServicePort, siteId string, bridges *qdr.BridgeConfig) (bool, error) {
+	address := sb.addressForPort(port.Port)
+	ingressPort := strconv.Itoa(sb.ingressPorts[port.Port])
+	switch sb.protocolFor(port) {
 	case ProtocolHTTP:
-		address := sb.address
+		routerAddress := address
 		if sb.aggregation != "" || sb.eventChannel {
-			address = "mc/" + address
+			routerAddress = "mc/" + address
 		}
 		bridges.AddHttpListener(qdr.HttpEndpoint{
-			Name:         getBridgeName(sb.address, ""),
+			Name:         getBridgeName(address, ""),
 			Host:         "0.0.0.0",
-			Port:         strconv.Itoa(sb.ingressPort),
-			Address:      address,
+			Port:         ingressPort,
+			Address:      routerAddress,
 			SiteId:       siteId,
 			Aggregation:  sb.aggregation,
 			EventChannel: sb.eventChannel,
+			SslProfile:   sb.ingressSslProfile(),
 		})
-
-	case ProtocolHTTP2:
+	case ProtocolHTTP2, ProtocolGRPC:
 		bridges.AddHttpListener(qdr.HttpEndpoint{
-			Name:            getBridgeName(sb.address, ""),
+			Name:            getBridgeName(address, ""),
 			Host:            "0.0.0.0",
-			Port:            strconv.Itoa(sb.ingressPort),
-			Address:         sb.address,
+			Port:            ingressPort,
+			Address:         address,
 			SiteId:          siteId,
 			Aggregation:     sb.aggregation,
 			EventChannel:    sb.eventChannel,
 			ProtocolVersion: qdr.HttpVersion2,
+			SslProfile:      sb.ingressSslProfile(),
 		})
 	case ProtocolTCP:
 		bridges.AddTcpListener(qdr.TcpEndpoint{
-			Name:    getBridgeName(sb.address, ""),
-			Host:    "0.0.0.0",
-			Port:    strconv.Itoa(sb.ingressPort),
-			Address: sb.address,
-			SiteId:  siteId,
+			Name:       getBridgeName(address, ""),
+			Host:       "0.0.0.0",
+			Port:       ingressPort,
+			Address:    address,
+			SiteId:     siteId,
+			SslProfile: sb.ingressSslProfile(),
 		})
 	default:
-		return false, fmt.Errorf("Unrecognised protocol for service %s: %s", sb.address, sb.protocol)
+		return false, fmt.Errorf("Unrecognised protocol for service %s: %s", address, sb.protocolFor(port))
 	}
 	return true, nil
 }
 
-func requiredBridges(services map[string]*ServiceBindings, siteId string) *qdr.BridgeConfig {
+const (
+	BridgeTlsEvent string = "BridgeTlsEvent"
+)
+
+// removeBridgesWithUndefinedSslProfiles removes the bridges that refer to
+// ssl profiles the router does not have. The secrets for the TLS settings
+// of a service are only mounted into the router of the site it was
+// exposed in, and where the router does not have them the service is not
+// bridged at all rather than bridged without TLS.
+func removeBridgesWithUndefinedSslProfiles(bridges *qdr.BridgeConfig, profiles map[string]qdr.SslProfile) {
+	defined := func(name string, profile string) bool {
+		if _, ok := profiles[profile]; profile == "" || ok {
+			return true
+		}
+		event.Recordf(BridgeTlsEvent, "Error: no ssl profile %s in router for %s, not bridging it", profile, name)
+		return false
+	}
+	for key, b := range bridges.TcpListeners {
+		if !defined(key, b.SslProfile) {
+			delete(bridges.TcpListeners, key)
+		}
+	}
+	for key, b := range bridges.TcpConnectors {
+		if !defined(key, b.SslProfile) {
+			delete(bridges.TcpConnectors, key)
+		}
+	}
+	for key, b := range bridges.HttpListeners {
+		if !defined(key, b.SslProfile) {
+			delete(bridges.HttpListeners, key)
+		}
+	}
+	for key, b := range bridges.HttpConnectors {
+		if !defined(key, b.SslProfile) {
+			delete(bridges.HttpConnectors, key)
+		}
+	}
+}
+
+func requiredBridges(services map[string]*ServiceBindings, siteId string, health *HealthChecker) *qdr.BridgeConfig {
 	//TODO: headless services not yet handled
 	//TODO: update for multicast when merged
 	bridges := newBridgeConfiguration()
+	health.begin()
 	for _, service := range services {
-		service.updateBridgeConfiguration(siteId, bridges)
+		service.updateBridgeConfiguration(siteId, bridges, health)
 	}
+	health.sweep()
 	return bridges
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestMultiPortServiceBridges(t *testing.T) {
+	c := &Controller{
+		bindings: map[string]*ServiceBindings{},
+		ports:    newFreePorts(),
+	}
+	service := types.ServiceInterface{
+		Address:  "db",
+		Protocol: "tcp",
+		Ports:    []types.ServicePort{{Port: 5432}, {Port: 8080, Protocol: "http"}},
+		Targets: []types.ServiceInterfaceTarget{
+			{Name: "db", Service: "backend", TargetPorts: map[int]int{8080: 9090}},
+		},
+	}
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+
+	bridges := requiredBridges(c.bindings, "site", nil)
+	assert.DeepEqual(t, bridges.TcpListeners, qdr.TcpEndpointMap{
+		"db": {Name: "db", Host: "0.0.0.0", Port: "1024", Address: "db", SiteId: "site"},
+	})
+	assert.DeepEqual(t, bridges.HttpListeners, qdr.HttpEndpointMap{
+		"db:8080": {Name: "db:8080", Host: "0.0.0.0", Port: "1025", Address: "db:8080", SiteId: "site"},
+	})
+	assert.DeepEqual(t, bridges.TcpConnectors, qdr.TcpEndpointMap{
+		"db@backend": {Name: "db@backend", Host: "backend", Port: "5432", Address: "db", SiteId: "site"},
+	})
+	assert.DeepEqual(t, bridges.HttpConnectors, qdr.HttpEndpointMap{
+		"db:8080@backend": {Name: "db:8080@backend", Host: "backend", Port: "9090", Address: "db:8080", SiteId: "site", HostOverride: "backend"},
+	})
+	publicPorts, ingressPorts := c.bindings["db"].portMappings()
+	assert.DeepEqual(t, publicPorts, []int{5432, 8080})
+	assert.DeepEqual(t, ingressPorts, []int{1024, 1025})
+
+	// ports keep their allocation as others are added and removed
+	service.Ports = []types.ServicePort{{Port: 8080, Protocol: "http"}, {Port: 9000}}
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+	publicPorts, ingressPorts = c.bindings["db"].portMappings()
+	assert.DeepEqual(t, publicPorts, []int{8080, 9000})
+	assert.DeepEqual(t, ingressPorts, []int{1025, 1026})
+	bridges = requiredBridges(c.bindings, "site", nil)
+	_, ok := bridges.HttpListeners["db"]
+	assert.Assert(t, ok)
+	_, ok = bridges.TcpListeners["db:9000"]
+	assert.Assert(t, ok)
+}
+
+func TestGrpcBridges(t *testing.T) {
+	c := &Controller{
+		bindings: map[string]*ServiceBindings{},
+		ports:    newFreePorts(),
+	}
+	service := types.ServiceInterface{
+		Address:  "orders",
+		Protocol: "tcp",
+		Ports:    []types.ServicePort{{Port: 8080}, {Port: 50051, Protocol: "grpc"}},
+		Targets: []types.ServiceInterfaceTarget{
+			{Name: "orders", Service: "backend"},
+		},
+	}
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+
+	// grpc is bridged as http2
+	bridges := requiredBridges(c.bindings, "site", nil)
+	assert.DeepEqual(t, bridges.HttpListeners, qdr.HttpEndpointMap{
+		"orders:50051": {Name: "orders:50051", Host: "0.0.0.0", Port: "1025", Address: "orders:50051", SiteId: "site", ProtocolVersion: qdr.HttpVersion2},
+	})
+	assert.DeepEqual(t, bridges.HttpConnectors, qdr.HttpEndpointMap{
+		"orders:50051@backend": {Name: "orders:50051@backend", Host: "backend", Port: "50051", Address: "orders:50051", SiteId: "site", ProtocolVersion: qdr.HttpVersion2},
+	})
+	assert.DeepEqual(t, grpcAddresses([]*types.ServiceInterface{&service}), map[string]bool{"orders:50051": true})
+}
+
+func TestTlsBridges(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	c := &Controller{
+		bindings: map[string]*ServiceBindings{},
+		ports:    newFreePorts(),
+	}
+	service := types.ServiceInterface{
+		Address:        "api",
+		Protocol:       "tcp",
+		Ports:          []types.ServicePort{{Port: 443}},
+		TlsCredentials: "api-cert",
+		TlsTrust:       "api-ca",
+		Targets: []types.ServiceInterfaceTarget{
+			{Name: "api", Service: "backend"},
+		},
+	}
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+
+	bridges := requiredBridges(c.bindings, "site", nil)
+	assert.Equal(t, bridges.TcpListeners["api"].SslProfile, "service-api-cert-profile")
+	assert.Equal(t, bridges.TcpConnectors["api@backend"].SslProfile, "service-api-ca-profile")
+
+	// bridges whose profiles the router does not have are not made
+	// without TLS
+	removeBridgesWithUndefinedSslProfiles(bridges, map[string]qdr.SslProfile{
+		"service-api-ca-profile": {Name: "service-api-ca-profile"},
+	})
+	_, ok := bridges.TcpListeners["api"]
+	assert.Assert(t, !ok)
+	assert.Equal(t, bridges.TcpConnectors["api@backend"].SslProfile, "service-api-ca-profile")
+
+	service.TlsCredentials = ""
+	service.TlsTrust = ""
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+	bridges = requiredBridges(c.bindings, "site", nil)
+	assert.Equal(t, bridges.TcpConnectors["api@backend"].SslProfile, "")
+}
+
+func TestTargetNamespaceBridges(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "backend", Labels: map[string]string{types.TargetNamespaceLabel: "test"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "private"}},
+	)
+	c := &Controller{
+		vanClient: &client.VanClient{Namespace: "test", KubeClient: kubeClient},
+		bindings:  map[string]*ServiceBindings{},
+		ports:     newFreePorts(),
+	}
+	service := types.ServiceInterface{
+		Address:  "api",
+		Protocol: "tcp",
+		Ports:    []types.ServicePort{{Port: 8080}},
+		Targets: []types.ServiceInterfaceTarget{
+			{Name: "api", Service: "api"},
+			{Name: "api", Service: "api", Namespace: "backend"},
+			{Name: "api", Service: "api", Namespace: "private"},
+		},
+	}
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+	bridges := requiredBridges(c.bindings, "site", nil)
+	assert.DeepEqual(t, bridges.TcpConnectors, qdr.TcpEndpointMap{
+		"api@api":         {Name: "api@api", Host: "api", Port: "8080", Address: "api", SiteId: "site"},
+		"api@api.backend": {Name: "api@api.backend", Host: "api.backend", Port: "8080", Address: "api", SiteId: "site"},
+	})
+
+	// a target in another namespace is removed independently of one of
+	// the same name in the namespace of the site
+	service.Targets = service.Targets[:1]
+	assert.NilError(t, c.updateServiceBindings(service, nil))
+	bridges = requiredBridges(c.bindings, "site", nil)
+	assert.DeepEqual(t, bridges.TcpConnectors, qdr.TcpEndpointMap{
+		"api@api": {Name: "api@api", Host: "api", Port: "8080", Address: "api", SiteId: "site"},
+	})
+}
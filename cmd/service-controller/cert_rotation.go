@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	CertRotation      string = "CertRotation"
+	CertRotationError string = "CertRotationError"
+)
+
+const (
+	LinkCertRenewal      string = "link-cert-renewal"
+	LinkCertRenewalError string = "link-cert-renewal-error"
+)
+
+const certRotationInterval = 12 * time.Hour
+
+// Periodically renews the certificates of the site before they expire,
+// and asks the sites that issued the certificates for links from this
+// site to renew those
+type CertRotationMonitor struct {
+	vanClient *client.VanClient
+	agentPool *qdr.AgentPool
+}
+
+func newCertRotationMonitor(cli *client.VanClient, config *tls.Config) *CertRotationMonitor {
+	return &CertRotationMonitor{
+		vanClient: cli,
+		agentPool: qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+	}
+}
+
+func (m *CertRotationMonitor) start(stopCh <-chan struct{}) {
+	go wait.Until(m.rotate, certRotationInterval, stopCh)
+}
+
+func (m *CertRotationMonitor) rotate() {
+	ctx := context.Background()
+	renewed, err := m.vanClient.RotateCertificates(ctx)
+	if len(renewed) > 0 {
+		event.Recordf(CertRotation, "Renewed certificates %v", renewed)
+	}
+	if err != nil {
+		event.Recordf(CertRotationError, "Could not renew site certificates: %s", err)
+	}
+	links, err := m.vanClient.LinkCertificatesDue(ctx)
+	if err != nil {
+		event.Recordf(CertRotationError, "Could not retrieve link certificates: %s", err)
+		return
+	}
+	restart := false
+	for _, link := range links {
+		if m.renewLink(ctx, &link) {
+			restart = true
+		}
+	}
+	if restart {
+		if err := m.vanClient.RouterRestart(ctx, m.vanClient.Namespace); err != nil {
+			event.Recordf(CertRotationError, "Renewed link certificates, but could not restart router: %s", err)
+		}
+	}
+}
+
+// renewLink returns true if the certificates of the link were replaced
+func (m *CertRotationMonitor) renewLink(ctx context.Context, link *corev1.Secret) bool {
+	siteId, ok := link.ObjectMeta.Annotations[types.TokenGeneratedBy]
+	if !ok {
+		event.Recordf(CertRotationError, "Certificates for link %s are due for renewal, but the site that issued them is not known; recreate the link from a new token", link.ObjectMeta.Name)
+		return false
+	}
+	request, key, err := certs.NewRenewalRequest(link)
+	if err != nil {
+		event.Recordf(CertRotationError, "Could not create renewal request for link %s: %s", link.ObjectMeta.Name, err)
+		return false
+	}
+	response, err := requestLinkCertRenewal(m.agentPool, siteId, request)
+	if err != nil {
+		event.Recordf(CertRotationError, "Could not renew certificates for link %s: %s", link.ObjectMeta.Name, err)
+		return false
+	}
+	// the issuing site renews its CA on its own schedule, so the link is
+	// left as it is until the CA it would be verified with is renewed too
+	if err := checkRenewal(response, time.Now()); err != nil {
+		event.Recordf(CertRotation, "Renewal of certificates for link %s deferred: %s", link.ObjectMeta.Name, err)
+		return false
+	}
+	if err := m.vanClient.RenewLinkCertificate(ctx, link.ObjectMeta.Name, key, response); err != nil {
+		event.Recordf(CertRotationError, "Could not update certificates for link %s: %s", link.ObjectMeta.Name, err)
+		return false
+	}
+	event.Recordf(CertRotation, "Renewed certificates for link %s", link.ObjectMeta.Name)
+	return true
+}
+
+// checkRenewal returns an error if the renewed certificates would
+// themselves be due for renewal
+func checkRenewal(response *certs.RenewalResponse, now time.Time) error {
+	for name, data := range map[string][]byte{"certificate": response.Certificate, "CA certificate": response.CA} {
+		due, err := certs.NeedsRenewal(data, now)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+		if due {
+			return fmt.Errorf("%s of the remote site is due for renewal", name)
+		}
+	}
+	return nil
+}
+
+func requestLinkCertRenewal(pool *qdr.AgentPool, siteId string, request *certs.RenewalRequest) (*certs.RenewalResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	agent, err := pool.Get()
+	if err != nil {
+		return nil, fmt.Errorf("Could not get management agent: %s", err)
+	}
+	defer pool.Put(agent)
+	reply, err := agent.Request(&qdr.Request{
+		Address: getSiteQueryAddress(siteId),
+		Version: client.Version,
+		Type:    LinkCertRenewal,
+		Body:    string(body),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Type == LinkCertRenewalError {
+		return nil, fmt.Errorf("%s", reply.Body)
+	} else if reply.Type != LinkCertRenewal {
+		return nil, fmt.Errorf("Site %s does not support renewal of link certificates", siteId)
+	}
+	response := &certs.RenewalResponse{}
+	if err := json.Unmarshal([]byte(reply.Body), response); err != nil {
+		return nil, fmt.Errorf("Could not parse renewal response: %s", err)
+	}
+	return response, nil
+}
+
+func (s *SiteQueryServer) HandleLinkCertRenewal(request *qdr.Request) (*qdr.Response, error) {
+	renewal := certs.RenewalRequest{}
+	if err := json.Unmarshal([]byte(request.Body), &renewal); err != nil {
+		return nil, fmt.Errorf("Could not parse renewal request: %s", err)
+	}
+	response, err := s.client.SignLinkCertificateRenewal(context.Background(), &renewal)
+	if err != nil {
+		event.Recordf(CertRotationError, "Refused to renew link certificate: %s", err)
+		return &qdr.Response{
+			Version: client.Version,
+			Type:    LinkCertRenewalError,
+			Body:    err.Error(),
+		}, nil
+	}
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("Could not encode renewal response: %s", err)
+	}
+	event.Record(CertRotation, "Renewed certificate for link from another site")
+	return &qdr.Response{
+		Version: client.Version,
+		Type:    request.Type,
+		Body:    string(bytes),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+)
+
+// policyAllows returns true if the rules grant the verb on resources of
+// the kind in the core group
+func policyAllows(rules []rbacv1.PolicyRule, resource string, verb string) bool {
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			if group != "" {
+				continue
+			}
+			for _, r := range rule.Resources {
+				for _, v := range rule.Verbs {
+					if r == resource && v == verb {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestCheckRenewal(t *testing.T) {
+	now := time.Now()
+	ca := certs.GenerateCASecret("ca", "ca")
+	link := certs.GenerateSecret("conn1", "conn1", "", &ca)
+	response := &certs.RenewalResponse{
+		Certificate: link.Data["tls.crt"],
+		CA:          ca.Data["tls.crt"],
+	}
+	assert.NilError(t, checkRenewal(response, now))
+	assert.ErrorContains(t, checkRenewal(response, now.Add(4*365*24*time.Hour)), "due for renewal")
+	response.CA = nil
+	assert.ErrorContains(t, checkRenewal(response, now), "invalid CA certificate")
+}
+
+func TestCertRotationPolicy(t *testing.T) {
+	// the controller renews the certificates held in the secrets
+	for _, verb := range []string{"get", "list", "update"} {
+		assert.Assert(t, policyAllows(types.ControllerPolicyRule, "secrets", verb), "controller cannot %s secrets", verb)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+const (
+	ClaimsServerEvent string = "ClaimsServerEvent"
+	ClaimsServerError string = "ClaimsServerError"
+)
+
+// the password of a claim is far shorter; anything longer is not read
+const maxClaimPasswordLength = 1024
+
+func claimsEnabled() bool {
+	return os.Getenv("SKUPPER_CLAIMS") == "true"
+}
+
+// claimsServer redeems the claims issued by the site for tokens, each of
+// which is minted when the claim is redeemed
+type claimsServer struct {
+	redeem func(ctx context.Context, name string, siteId string, password []byte) (*corev1.Secret, error)
+}
+
+// claimErrorStatus returns the status of a response to a claim that could
+// not be redeemed
+func claimErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, client.ErrClaimNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, client.ErrClaimRefused), errors.Is(err, client.ErrTokenSite):
+		return http.StatusForbidden
+	case errors.Is(err, client.ErrTokenExpired), errors.Is(err, client.ErrTokenClaimed):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *claimsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Claims are redeemed with a POST", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, types.ClaimsPath)
+	if name == "" || strings.Contains(name, "/") {
+		http.Error(w, "No such claim", http.StatusNotFound)
+		return
+	}
+	password, err := ioutil.ReadAll(io.LimitReader(r.Body, maxClaimPasswordLength))
+	if err != nil {
+		http.Error(w, "Could not read claim", http.StatusBadRequest)
+		return
+	}
+	site := r.URL.Query().Get("site")
+	token, err := s.redeem(r.Context(), name, site, password)
+	if err != nil {
+		code := claimErrorStatus(err)
+		if code == http.StatusInternalServerError {
+			event.Recordf(ClaimsServerError, "Could not redeem claim %s for site %s: %s", name, site, err)
+			http.Error(w, "Could not redeem claim", code)
+			return
+		}
+		event.Recordf(ClaimsServerEvent, "Claim %s refused for site %s: %s", name, site, err)
+		http.Error(w, err.Error(), code)
+		return
+	}
+	event.Recordf(ClaimsServerEvent, "Claim %s redeemed by site %s", name, site)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// listenClaims serves the claims of the site over TLS, with a certificate
+// issued by the CA that is given in each claim
+func (server *ConsoleServer) listenClaims() {
+	addr := ":" + strconv.Itoa(int(types.ClaimsPort))
+	log.Printf("Claims server listening on %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle(types.ClaimsPath, &claimsServer{redeem: server.vanClient.ClaimRedeem})
+	log.Fatal(http.ListenAndServeTLS(addr, path.Join(types.ClaimsCertsPath, "tls.crt"), path.Join(types.ClaimsCertsPath, "tls.key"), mux))
+}
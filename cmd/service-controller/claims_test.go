@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+func TestClaimsServer(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	redeemed := []string{}
+	server := httptest.NewServer(&claimsServer{
+		redeem: func(ctx context.Context, name string, siteId string, password []byte) (*corev1.Secret, error) {
+			redeemed = append(redeemed, name+"@"+siteId)
+			switch {
+			case name == "missing":
+				return nil, fmt.Errorf("Claim %s: %w", name, client.ErrClaimNotFound)
+			case string(password) != "secret":
+				return nil, client.ErrClaimRefused
+			case siteId == "other":
+				return nil, client.ErrTokenClaimed
+			case siteId == "broken":
+				return nil, fmt.Errorf("secrets is forbidden")
+			}
+			return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "conn1"}, Data: map[string][]byte{"tls.crt": []byte("cert")}}, nil
+		},
+	})
+	defer server.Close()
+
+	post := func(path string, password string) (int, string) {
+		resp, err := http.Post(server.URL+path, "text/plain", strings.NewReader(password))
+		assert.Assert(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.Assert(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	resp, err := http.Get(server.URL + types.ClaimsPath + "abc")
+	assert.Assert(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusMethodNotAllowed)
+	assert.Equal(t, resp.Header.Get("Allow"), http.MethodPost)
+
+	code, _ := post(types.ClaimsPath, "secret")
+	assert.Equal(t, code, http.StatusNotFound)
+	code, _ = post(types.ClaimsPath+"abc/def", "secret")
+	assert.Equal(t, code, http.StatusNotFound)
+	assert.Equal(t, len(redeemed), 0)
+
+	code, _ = post(types.ClaimsPath+"missing?site=a", "secret")
+	assert.Equal(t, code, http.StatusNotFound)
+	code, _ = post(types.ClaimsPath+"abc?site=a", "guess")
+	assert.Equal(t, code, http.StatusForbidden)
+	code, _ = post(types.ClaimsPath+"abc?site=other", "secret")
+	assert.Equal(t, code, http.StatusGone)
+	code, body := post(types.ClaimsPath+"abc?site=broken", "secret")
+	assert.Equal(t, code, http.StatusInternalServerError)
+	assert.Assert(t, !strings.Contains(body, "forbidden"), body)
+
+	code, body = post(types.ClaimsPath+"abc?site=a", "secret")
+	assert.Equal(t, code, http.StatusOK)
+	token := corev1.Secret{}
+	assert.Assert(t, json.Unmarshal([]byte(body), &token))
+	assert.Equal(t, token.ObjectMeta.Name, "conn1")
+	assert.Equal(t, string(token.Data["tls.crt"]), "cert")
+	assert.Equal(t, redeemed[len(redeemed)-1], "abc@a")
+}
@@ -4,31 +4,50 @@ import (
 	"crypto/tls"
 	"fmt"
 	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/kube"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
 // Syncs the live router config with the configmap (currently only
-// bridge configuration needs to be synced in this way)
+// bridge configuration needs to be synced in this way). When the router
+// has more than one replica, each of them is synced directly, as the
+// service would only reach one of them.
 type ConfigSync struct {
-	informer  cache.SharedIndexInformer
-	events    workqueue.RateLimitingInterface
-	agentPool *qdr.AgentPool
+	informer   cache.SharedIndexInformer
+	events     workqueue.RateLimitingInterface
+	agentPool  *qdr.AgentPool
+	shedding   int32
+	kubeClient kubernetes.Interface
+	namespace  string
+	tlsConfig  *tls.Config
+	podPools   map[string]*qdr.AgentPool
+	poolLock   sync.Mutex
 }
 
-func newConfigSync(configInformer cache.SharedIndexInformer, config *tls.Config) *ConfigSync {
+func newConfigSync(configInformer cache.SharedIndexInformer, config *tls.Config, kubeClient kubernetes.Interface, namespace string) *ConfigSync {
 	configSync := &ConfigSync{
-		informer:  configInformer,
-		agentPool: qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+		informer:   configInformer,
+		agentPool:  qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		tlsConfig:  config,
+		podPools:   map[string]*qdr.AgentPool{},
 	}
 	configSync.events = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "skupper-config-sync")
 	configSync.informer.AddEventHandler(newEventHandlerFor(configSync.events, "", SimpleKey, ConfigMapResourceVersionTest))
@@ -45,6 +64,23 @@ func (c *ConfigSync) stop() {
 	c.events.ShutDown()
 }
 
+// While shedding load, bridge listeners are removed from the router so
+// that no new connections are accepted for exposed services
+func (c *ConfigSync) setShedding(shedding bool) {
+	var value int32
+	if shedding {
+		value = 1
+	}
+	atomic.StoreInt32(&c.shedding, value)
+	for _, key := range c.informer.GetStore().ListKeys() {
+		c.events.Add(key)
+	}
+}
+
+func (c *ConfigSync) isShedding() bool {
+	return atomic.LoadInt32(&c.shedding) == 1
+}
+
 func (c *ConfigSync) runConfigSync() {
 	for c.processNextEvent() {
 	}
@@ -87,6 +123,10 @@ func (c *ConfigSync) processNextEvent() bool {
 				if err != nil {
 					return fmt.Errorf("Error parsing bridge configuration from %s: %s", key, err)
 				}
+				if c.isShedding() {
+					bridges.TcpListeners = qdr.TcpEndpointMap{}
+					bridges.HttpListeners = qdr.HttpEndpointMap{}
+				}
 				err = c.syncConfig(bridges)
 				if err != nil {
 					event.Recordf(ConfigSyncError, "sync failed: %s", err)
@@ -118,6 +158,17 @@ func syncConfig(agent *qdr.Agent, desired *qdr.BridgeConfig) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("Error retrieving bridges: %s", err)
 	}
+	// leave the transient self-test bridges alone
+	for name := range actual.TcpConnectors {
+		if isSelfTestEntity(name) {
+			delete(actual.TcpConnectors, name)
+		}
+	}
+	for name := range actual.TcpListeners {
+		if isSelfTestEntity(name) {
+			delete(actual.TcpListeners, name)
+		}
+	}
 	differences := actual.Difference(desired)
 	if differences.Empty() {
 		return true, nil
@@ -130,8 +181,89 @@ func syncConfig(agent *qdr.Agent, desired *qdr.BridgeConfig) (bool, error) {
 	}
 }
 
+// routerPodIPs returns the addresses of the router replicas that are
+// running
+func routerPodIPs(pods []corev1.Pod) []string {
+	ips := []string{}
+	for i := range pods {
+		if kube.IsPodRunning(&pods[i]) && pods[i].Status.PodIP != "" {
+			ips = append(ips, pods[i].Status.PodIP)
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// closeAgentPool closes the connections to a router replica that is no
+// longer running
+var closeAgentPool = func(pool *qdr.AgentPool) {
+	pool.Close()
+}
+
+// agentPools returns a pool for each router replica, or just that for
+// the service when there is only one replica. The pool for a replica is
+// kept for as long as it runs, so the same one is returned each time,
+// and is closed once it no longer does.
+func (c *ConfigSync) agentPools() ([]*qdr.AgentPool, error) {
+	c.poolLock.Lock()
+	defer c.poolLock.Unlock()
+	if c.kubeClient == nil {
+		return []*qdr.AgentPool{c.agentPool}, nil
+	}
+	pods, err := kube.GetDeploymentPods(types.TransportDeploymentName, "skupper.io/component="+types.TransportComponentName, c.namespace, c.kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("Could not list router pods: %s", err)
+	}
+	ips := routerPodIPs(pods)
+	pools := []*qdr.AgentPool{}
+	current := map[string]*qdr.AgentPool{}
+	if len(ips) < 2 {
+		pools = append(pools, c.agentPool)
+	} else {
+		for _, ip := range ips {
+			pool, ok := c.podPools[ip]
+			if !ok {
+				// the certificate of the router names the service, not the pod
+				config := &tls.Config{}
+				if c.tlsConfig != nil {
+					config = c.tlsConfig.Clone()
+				}
+				config.ServerName = types.LocalTransportServiceName
+				pool = qdr.NewAgentPool("amqps://"+net.JoinHostPort(ip, "5671"), config)
+			}
+			current[ip] = pool
+			pools = append(pools, pool)
+		}
+	}
+	for ip, pool := range c.podPools {
+		if _, ok := current[ip]; !ok {
+			closeAgentPool(pool)
+		}
+	}
+	c.podPools = current
+	return pools, nil
+}
+
+// syncConfig syncs every router replica, even if some of them fail
 func (c *ConfigSync) syncConfig(desired *qdr.BridgeConfig) error {
-	agent, err := c.agentPool.Get()
+	pools, err := c.agentPools()
+	if err != nil {
+		return err
+	}
+	errs := []string{}
+	for _, pool := range pools {
+		if err := syncConfigWith(pool, desired); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Could not sync %d of %d router replicas: %s", len(errs), len(pools), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func syncConfigWith(pool *qdr.AgentPool, desired *qdr.BridgeConfig) error {
+	agent, err := pool.Get()
 	if err != nil {
 		return fmt.Errorf("Could not get management agent : %s", err)
 	}
@@ -139,7 +271,7 @@ func (c *ConfigSync) syncConfig(desired *qdr.BridgeConfig) error {
 	for i := 0; i < 3 && err == nil && !synced; i++ {
 		synced, err = syncConfig(agent, desired)
 	}
-	c.agentPool.Put(agent)
+	pool.Put(agent)
 	if err != nil {
 		return fmt.Errorf("Error while syncing bridge config : %s", err)
 	}
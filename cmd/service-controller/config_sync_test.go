@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func routerPod(name string, ip string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+			Labels:    map[string]string{"skupper.io/component": "router"},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			PodIP: ip,
+		},
+	}
+}
+
+func TestConfigSyncAgentPools(t *testing.T) {
+	closed := []*qdr.AgentPool{}
+	defer func(original func(*qdr.AgentPool)) {
+		closeAgentPool = original
+	}(closeAgentPool)
+	closeAgentPool = func(pool *qdr.AgentPool) {
+		closed = append(closed, pool)
+	}
+	kubeClient := fake.NewSimpleClientset(routerPod("router-a", "10.0.0.2", corev1.PodRunning))
+	configSync := &ConfigSync{
+		agentPool:  qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", nil),
+		kubeClient: kubeClient,
+		namespace:  "test",
+		podPools:   map[string]*qdr.AgentPool{},
+	}
+
+	pools, err := configSync.agentPools()
+	assert.Assert(t, err)
+	assert.Equal(t, len(pools), 1)
+	assert.Equal(t, pools[0], configSync.agentPool)
+
+	kubeClient.CoreV1().Pods("test").Create(routerPod("router-b", "10.0.0.1", corev1.PodRunning))
+	kubeClient.CoreV1().Pods("test").Create(routerPod("router-c", "", corev1.PodPending))
+	pools, err = configSync.agentPools()
+	assert.Assert(t, err)
+	assert.Equal(t, len(pools), 2)
+	assert.Equal(t, len(configSync.podPools), 2)
+	first := configSync.podPools["10.0.0.1"]
+	second := configSync.podPools["10.0.0.2"]
+	assert.Equal(t, pools[0], first)
+
+	// pools are kept for as long as the replica is running
+	pools, err = configSync.agentPools()
+	assert.Assert(t, err)
+	assert.Equal(t, pools[0], first)
+
+	kubeClient.CoreV1().Pods("test").Delete("router-b", &metav1.DeleteOptions{})
+	pools, err = configSync.agentPools()
+	assert.Assert(t, err)
+	assert.Equal(t, len(pools), 1)
+	assert.Equal(t, pools[0], configSync.agentPool)
+	assert.Equal(t, len(configSync.podPools), 0)
+
+	// the pools for replicas no longer synced directly are closed
+	assert.Equal(t, len(closed), 2)
+	assert.Assert(t, (closed[0] == first && closed[1] == second) || (closed[0] == second && closed[1] == first))
+}
+
+func TestConfigSyncAllReplicas(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		routerPod("router-a", "127.0.0.1", corev1.PodRunning),
+		routerPod("router-b", "127.0.0.2", corev1.PodRunning),
+	)
+	configSync := &ConfigSync{
+		agentPool:  qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", nil),
+		kubeClient: kubeClient,
+		namespace:  "test",
+		podPools:   map[string]*qdr.AgentPool{},
+	}
+
+	// a replica that cannot be synced does not stop the others being tried
+	err := configSync.syncConfig(&qdr.BridgeConfig{})
+	assert.ErrorContains(t, err, "Could not sync 2 of 2 router replicas")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/auth"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+const (
+	ConsoleAdminAction string = "ConsoleAdminAction"
+)
+
+// The changes that admins can make through the console
+type consoleAdmin interface {
+	ServiceInterfaceRemove(ctx context.Context, address string) error
+	ConnectorRemove(ctx context.Context, options types.ConnectorRemoveOptions) error
+	GetNamespace() string
+}
+
+type authWrapper func(role string, h http.Handler) http.Handler
+
+type ConsoleUserInfo struct {
+	Name string `json:"name,omitempty"`
+	Role string `json:"role"`
+}
+
+// addAdminHandlers registers the handlers that let the console find out
+// what the user may do and that make changes, which require the admin
+// role
+func addAdminHandlers(mux handlerRegistry, admin consoleAdmin, authorize authWrapper) {
+	mux.Handle("/user", authorize(auth.RoleViewer, serveUser()))
+	mux.Handle("/services/", authorize(auth.RoleAdmin, deleteService(admin)))
+	mux.Handle("/links/", authorize(auth.RoleAdmin, deleteLink(admin)))
+}
+
+func serveUser() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ := r.BasicAuth()
+		bytes, err := json.MarshalIndent(ConsoleUserInfo{Name: user, Role: userRole(r)}, "", "    ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, string(bytes)+"\n")
+	})
+}
+
+// Returns the name following the prefix in the path of a DELETE request,
+// writing an error response if there is none
+func deleteTarget(w http.ResponseWriter, r *http.Request, prefix string) (string, bool) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if name == "" || strings.Contains(name, "/") {
+		http.Error(w, "Invalid path", http.StatusNotFound)
+		return "", false
+	}
+	return name, true
+}
+
+func deleteService(admin consoleAdmin) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address, ok := deleteTarget(w, r, "/services/")
+		if !ok {
+			return
+		}
+		if err := admin.ServiceInterfaceRemove(r.Context(), address); err != nil {
+			http.Error(w, fmt.Sprintf("Could not unexpose service %s: %s", address, err), http.StatusInternalServerError)
+			return
+		}
+		user, _, _ := r.BasicAuth()
+		event.Recordf(ConsoleAdminAction, "Service %s unexposed by console user %s", address, user)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func deleteLink(admin consoleAdmin) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := deleteTarget(w, r, "/links/")
+		if !ok {
+			return
+		}
+		err := admin.ConnectorRemove(r.Context(), types.ConnectorRemoveOptions{
+			Name:             name,
+			SkupperNamespace: admin.GetNamespace(),
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Could not delete link %s: %s", name, err), http.StatusInternalServerError)
+			return
+		}
+		user, _, _ := r.BasicAuth()
+		event.Recordf(ConsoleAdminAction, "Link %s deleted by console user %s", name, user)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
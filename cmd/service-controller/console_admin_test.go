@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/auth"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+type fakeConsoleAdmin struct {
+	removed []string
+}
+
+func (a *fakeConsoleAdmin) ServiceInterfaceRemove(ctx context.Context, address string) error {
+	a.removed = append(a.removed, "service/"+address)
+	return nil
+}
+
+func (a *fakeConsoleAdmin) ConnectorRemove(ctx context.Context, options types.ConnectorRemoveOptions) error {
+	a.removed = append(a.removed, "link/"+options.SkupperNamespace+"/"+options.Name)
+	return nil
+}
+
+func (a *fakeConsoleAdmin) GetNamespace() string {
+	return "skupper"
+}
+
+func writeConsoleUser(t *testing.T, dir string, name string, entry string) {
+	if err := ioutil.WriteFile(path.Join(dir, name), []byte(entry), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConsoleRoles(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	dir, err := ioutil.TempDir("", "console-users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, role := range map[string]string{"alice": auth.RoleAdmin, "bob": auth.RoleViewer} {
+		entry, err := auth.NewUserEntry(name+"-password", role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeConsoleUser(t, dir, name, entry)
+	}
+	writeConsoleUser(t, dir, "legacy", "legacy-password")
+
+	admin := &fakeConsoleAdmin{}
+	mux := http.NewServeMux()
+	addAdminHandlers(mux, admin, func(role string, h http.Handler) http.Handler {
+		return authorizedIn(dir, role, h)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		user   string
+		method string
+		path   string
+		status int
+	}{
+		{"", http.MethodGet, "/user", http.StatusUnauthorized},
+		{"bob", http.MethodGet, "/user", http.StatusOK},
+		{"bob", http.MethodDelete, "/services/frontend", http.StatusForbidden},
+		{"bob", http.MethodDelete, "/links/west", http.StatusForbidden},
+		{"alice", http.MethodGet, "/services/frontend", http.StatusMethodNotAllowed},
+		{"alice", http.MethodDelete, "/services/", http.StatusNotFound},
+		{"alice", http.MethodDelete, "/services/frontend", http.StatusNoContent},
+		{"legacy", http.MethodDelete, "/links/west", http.StatusNoContent},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequest(test.method, server.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.user != "" {
+			request.SetBasicAuth(test.user, test.user+"-password")
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != test.status {
+			t.Errorf("%s %s as %q: expected status %d, got %d", test.method, test.path, test.user, test.status, response.StatusCode)
+		}
+	}
+	expected := []string{"service/frontend", "link/skupper/west"}
+	if len(admin.removed) != len(expected) || admin.removed[0] != expected[0] || admin.removed[1] != expected[1] {
+		t.Errorf("Expected %v to be removed, got %v", expected, admin.removed)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/user", nil)
+	request.SetBasicAuth("bob", "wrong")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected wrong password to be rejected, got %d", response.StatusCode)
+	}
+}
+
+func TestServeUser(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	recorder := httptest.NewRecorder()
+	serveUser().ServeHTTP(recorder, request)
+	info := ConsoleUserInfo{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	// without internal authentication no one is an admin
+	if info.Role != auth.RoleViewer {
+		t.Errorf("Expected role %s, got %s", auth.RoleViewer, info.Role)
+	}
+}
+
+func TestConsoleRolesWithoutUsers(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	admin := &fakeConsoleAdmin{}
+	mux := http.NewServeMux()
+	addAdminHandlers(mux, admin, func(role string, h http.Handler) http.Handler {
+		return authorizedIn("", role, h)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/user", http.StatusOK},
+		{http.MethodDelete, "/services/frontend", http.StatusForbidden},
+		{http.MethodDelete, "/links/west", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequest(test.method, server.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != test.status {
+			t.Errorf("%s %s: expected status %d, got %d", test.method, test.path, test.status, response.StatusCode)
+		}
+	}
+	if len(admin.removed) != 0 {
+		t.Errorf("Expected nothing to be removed, got %v", admin.removed)
+	}
+}
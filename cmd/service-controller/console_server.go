@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/auth"
 	"github.com/skupperproject/skupper/pkg/data"
 	"github.com/skupperproject/skupper/pkg/event"
 	"github.com/skupperproject/skupper/pkg/qdr"
@@ -29,15 +31,27 @@ const (
 
 type ConsoleServer struct {
 	agentPool *qdr.AgentPool
+	vanClient *client.VanClient
+	tcpTotals *tcpTotals
+	rates     *rateTracker
+	// links whose certificates expire within this many days are
+	// flagged in the metrics
+	linkCertWarningDays int
+	// requestServiceSync triggers an immediate service sync
+	requestServiceSync func() error
 }
 
 func newConsoleServer(cli *client.VanClient, config *tls.Config) *ConsoleServer {
 	return &ConsoleServer{
 		agentPool: qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+		vanClient: cli,
+		tcpTotals: newTcpTotals(),
+		rates:     newRateTracker(),
 	}
 }
 
-func authenticate(dir string, user string, password string) bool {
+// authenticate returns the role of the user if the password matches
+func authenticate(dir string, user string, password string) (string, bool) {
 	filename := path.Join(dir, user)
 	file, err := os.Open(filename)
 	if err != nil {
@@ -46,34 +60,65 @@ func authenticate(dir string, user string, password string) bool {
 		} else {
 			event.Recordf(HttpAuthFailure, "Failed to authenticate %s: %s", user, err)
 		}
-		return false
+		return "", false
 	}
 	defer file.Close()
 
 	bytes, err := ioutil.ReadAll(file)
 	if err != nil {
 		event.Recordf(HttpAuthFailure, "Failed to authenticate %s: %s", user, err)
-		return false
+		return "", false
 	}
-	return string(bytes) == password
+	return auth.Verify(string(bytes), password)
 }
 
-func authenticated(h http.Handler) http.Handler {
-	dir := os.Getenv("METRICS_USERS")
-	if dir != "" {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			user, password, _ := r.BasicAuth()
+// authorized only passes on requests from users with the given role;
+// admins are authorized for everything. Without internal authentication
+// there are no admins, so only requests needing no more than the viewer
+// role are passed on.
+func authorized(role string, h http.Handler) http.Handler {
+	return authorizedIn(os.Getenv("METRICS_USERS"), role, h)
+}
 
-			if authenticate(dir, user, password) {
-				h.ServeHTTP(w, r)
-			} else {
-				w.Header().Set("WWW-Authenticate", "Basic realm=skupper")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+func authorizedIn(dir string, role string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if dir == "" {
+			if role != auth.RoleViewer {
+				event.Recordf(HttpAuthFailure, "Refused to %s %s, console users are not configured", r.Method, r.URL.Path)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
 			}
-		})
-	} else {
-		return h
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userRoleKey{}, auth.RoleViewer)))
+			return
+		}
+		user, password, _ := r.BasicAuth()
+
+		actual, ok := authenticate(dir, user, password)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Basic realm=skupper")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		} else if actual != role && actual != auth.RoleAdmin {
+			event.Recordf(HttpAuthFailure, "User %s with role %s is not permitted to %s %s", user, actual, r.Method, r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		} else {
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userRoleKey{}, actual)))
+		}
+	})
+}
+
+func authenticated(h http.Handler) http.Handler {
+	return authorized(auth.RoleViewer, h)
+}
+
+type userRoleKey struct{}
+
+// Returns the role of the authenticated user making the request, which
+// is viewer when internal authentication is not in use
+func userRole(r *http.Request) string {
+	if role, ok := r.Context().Value(userRoleKey{}).(string); ok {
+		return role
 	}
+	return auth.RoleViewer
 }
 
 type VersionInfo struct {
@@ -312,19 +357,94 @@ func (server *ConsoleServer) checkService() http.Handler {
 	})
 }
 
+func (server *ConsoleServer) checkPeers() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent, err := server.agentPool.Get()
+		if err != nil {
+			server.httpInternalError(w, fmt.Errorf("Could not get management agent : %s", err))
+			return
+		}
+		checkServices := os.Getenv("SKUPPER_DISABLE_SERVICE_SYNC") != "true"
+		peers, err := checkPeers(agent, server.vanClient, os.Getenv("SKUPPER_SITE_ID"), checkServices)
+		server.agentPool.Put(agent)
+		if err != nil {
+			server.httpInternalError(w, err)
+		} else if wantsJsonOutput(r) {
+			bytes, err := json.MarshalIndent(peers, "", "    ")
+			if err != nil {
+				server.httpInternalError(w, fmt.Errorf("Error writing json: %s", err))
+			} else {
+				fmt.Fprintf(w, string(bytes)+"\n")
+			}
+		} else {
+			tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+			fmt.Fprintln(tw, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", "ID", "NAME", "REACHABLE", "LINKED LOCALLY", "LINKED REMOTELY", "OBSERVATIONS"))
+			for _, peer := range peers {
+				fmt.Fprintln(tw, fmt.Sprintf("%s\t%s\t%t\t%t\t%t\t%s", peer.SiteId, peer.SiteName, peer.Reachable, peer.LinkedLocally, peer.LinkedRemotely, strings.Join(peer.Observations, "; ")))
+			}
+			tw.Flush()
+		}
+	})
+}
+
+func (server *ConsoleServer) serviceSync() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		} else if server.requestServiceSync == nil {
+			http.Error(w, "Service sync is not available", http.StatusServiceUnavailable)
+		} else if err := server.requestServiceSync(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+}
+
+func (server *ConsoleServer) probeLinks() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results, err := probeLinks(server.agentPool, os.Getenv("SKUPPER_SITE_ID"))
+		if err != nil {
+			server.httpInternalError(w, err)
+		} else if wantsJsonOutput(r) {
+			bytes, err := json.MarshalIndent(results, "", "    ")
+			if err != nil {
+				server.httpInternalError(w, fmt.Errorf("Error writing json: %s", err))
+			} else {
+				fmt.Fprintf(w, string(bytes)+"\n")
+			}
+		} else {
+			tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+			fmt.Fprintln(tw, fmt.Sprintf("%s\t%s\t%s\t%s", "ID", "DELIVERED", "FAILED", "PROBLEM"))
+			for _, result := range results {
+				fmt.Fprintln(tw, fmt.Sprintf("%s\t%v\t%v\t%s", result.SiteId, result.Delivered, result.Failed, result.Problem))
+			}
+			tw.Flush()
+		}
+	})
+}
+
 func (server *ConsoleServer) getData(w http.ResponseWriter) *data.ConsoleData {
 	agent, err := server.agentPool.Get()
 	if err != nil {
 		server.httpInternalError(w, fmt.Errorf("Could not get management agent : %s", err))
 		return nil
 	}
-	data, err := getConsoleData(agent)
+	consoleData, err := getConsoleData(agent)
 	server.agentPool.Put(agent)
 	if err != nil {
 		server.httpInternalError(w, err)
 		return nil
 	}
-	return data
+	if server.vanClient != nil {
+		definitions, err := server.vanClient.ServiceInterfaceList(context.Background())
+		if err != nil {
+			event.Recordf(HttpInternalServerError, "Could not retrieve service metadata: %s", err)
+		} else {
+			data.AddServiceMetadata(consoleData.Services, definitions)
+		}
+	}
+	return consoleData
 }
 
 func (server *ConsoleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -342,9 +462,46 @@ func (server *ConsoleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (server *ConsoleServer) start(stopCh <-chan struct{}) error {
 	go server.listen()
 	go server.listenLocal()
+	go server.listenMetrics()
+	if metricsApiEnabled() {
+		go server.listenMetricsApi()
+	}
+	if claimsEnabled() {
+		go server.listenClaims()
+	}
 	return nil
 }
 
+// handlerRegistry is satisfied by *http.ServeMux, and allows the
+// requests handled by each listener to be enumerated
+type handlerRegistry interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+func (server *ConsoleServer) addConsoleHandlers(mux handlerRegistry) {
+	mux.Handle("/DATA", authenticated(server))
+	mux.Handle("/version", authenticated(server.version()))
+	mux.Handle("/events", authenticated(server.serveEvents()))
+	mux.Handle("/servicecheck/", server.checkService())
+	mux.Handle("/openapi.json", server.serveOpenApi(ConsoleListener))
+	addAdminHandlers(mux, server.vanClient, authorized)
+}
+
+func (server *ConsoleServer) addLocalHandlers(mux handlerRegistry) {
+	mux.Handle("/DATA", server)
+	mux.Handle("/version", server.version())
+	mux.Handle("/events", server.serveEvents())
+	mux.Handle("/sites", server.serveSites())
+	mux.Handle("/services", server.serveServices())
+	mux.Handle("/servicecheck/", server.checkService())
+	mux.Handle("/peers", server.checkPeers())
+	mux.Handle("/network", server.serveNetwork())
+	mux.Handle("/servicesync", server.serviceSync())
+	mux.Handle("/linkprobe", server.probeLinks())
+	mux.Handle("/metrics", server.serveMetrics())
+	mux.Handle("/openapi.json", server.serveOpenApi(LocalListener))
+}
+
 func (server *ConsoleServer) listen() {
 	addr := ":8080"
 	if os.Getenv("METRICS_PORT") != "" {
@@ -354,23 +511,29 @@ func (server *ConsoleServer) listen() {
 		addr = os.Getenv("METRICS_HOST") + addr
 	}
 	log.Printf("Console server listening on %s", addr)
-	http.Handle("/DATA", authenticated(server))
-	http.Handle("/version", authenticated(server.version()))
-	http.Handle("/events", authenticated(server.serveEvents()))
-	http.Handle("/servicecheck/", server.checkService())
-	http.Handle("/", authenticated(http.FileServer(http.Dir("/app/console/"))))
-	log.Fatal(http.ListenAndServe(addr, nil))
+	// a dedicated mux is used so that handlers registered on the
+	// default mux by net/http/pprof and expvar are not exposed
+	mux := http.NewServeMux()
+	server.addConsoleHandlers(mux)
+	mux.Handle("/", authenticated(http.FileServer(http.Dir("/app/console/"))))
+	if profilingEnabled() && profilingAuthenticated() {
+		addProfilingHandlers(mux, authenticated)
+	}
+	// the console is served over TLS when it is not behind an oauth
+	// proxy or a route that terminates TLS for it
+	if certs := os.Getenv("METRICS_TLS"); certs != "" {
+		log.Fatal(http.ListenAndServeTLS(addr, path.Join(certs, "tls.crt"), path.Join(certs, "tls.key"), mux))
+	}
+	log.Fatal(http.ListenAndServe(addr, mux))
 }
 
 func (server *ConsoleServer) listenLocal() {
 	addr := "localhost:8181"
 	mux := http.NewServeMux()
-	mux.Handle("/DATA", server)
-	mux.Handle("/version", server.version())
-	mux.Handle("/events", server.serveEvents())
-	mux.Handle("/sites", server.serveSites())
-	mux.Handle("/services", server.serveServices())
-	mux.Handle("/servicecheck/", server.checkService())
+	server.addLocalHandlers(mux)
+	if profilingEnabled() {
+		addProfilingHandlers(mux, noWrapper)
+	}
 	log.Fatal(http.ListenAndServe(addr, mux))
 }
 
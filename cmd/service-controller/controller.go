@@ -30,6 +30,7 @@ import (
 	"github.com/skupperproject/skupper/client"
 	"github.com/skupperproject/skupper/pkg/event"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
@@ -53,14 +54,28 @@ type Controller struct {
 	amqpSession        *amqp.Session
 	byOrigin           map[string]map[string]types.ServiceInterface
 	localServices      map[string]types.ServiceInterface
+	localEncoded       string
 	byName             map[string]types.ServiceInterface
 	desiredServices    map[string]types.ServiceInterface
 	heardFrom          map[string]time.Time
+	syncInterval       time.Duration
+	syncIntervals      map[string]time.Duration
+	syncRequests       chan bool
+	serviceImports     *ServiceImports
 
 	definitionMonitor *DefinitionMonitor
 	consoleServer     *ConsoleServer
 	siteQueryServer   *SiteQueryServer
 	configSync        *ConfigSync
+	watermarkMonitor  *WatermarkMonitor
+	idleMonitor       *IdleMonitor
+	linkCerts         *LinkCertMonitor
+	linkActivity      *LinkActivityMonitor
+	certRotation      *CertRotationMonitor
+	linkProxy         *LinkProxyForwarder
+	bridgeWatchdog    *BridgeWatchdog
+	selfTest          *SelfTest
+	healthChecker     *HealthChecker
 }
 
 const (
@@ -80,11 +95,11 @@ func hasProxyAnnotation(service corev1.Service) bool {
 }
 
 func getProxyName(name string) string {
-	return name + "-proxy"
+	return naming.ProxyStatefulSetName(name)
 }
 
 func getServiceName(name string) string {
-	return strings.TrimSuffix(name, "-proxy")
+	return naming.ProxyServiceName(name)
 }
 
 func hasSkupperAnnotation(service corev1.Service, annotation string) bool {
@@ -123,7 +138,18 @@ func hasOriginalAssigned(service corev1.Service) bool {
 	return hasSkupperAnnotation(service, types.OriginalAssignedQualifier)
 }
 
-func NewController(cli *client.VanClient, origin string, tlsConfig *tls.Config, disableServiceSync bool) (*Controller, error) {
+// The original target ports of an annotated service, and the ports on
+// the router they were replaced with, are recorded in the order of the
+// ports of the service
+func formatPorts(ports []int) string {
+	values := []string{}
+	for _, port := range ports {
+		values = append(values, strconv.Itoa(port))
+	}
+	return strings.Join(values, ",")
+}
+
+func NewController(cli *client.VanClient, origin string, tlsConfig *tls.Config, disableServiceSync bool, syncInterval time.Duration, importOnDemand bool, watermarks *Watermarks, linkCertWarningDays int) (*Controller, error) {
 
 	// create informers
 	svcInformer := corev1informer.NewServiceInformer(
@@ -169,6 +195,8 @@ func NewController(cli *client.VanClient, origin string, tlsConfig *tls.Config,
 		events:             events,
 		ports:              newFreePorts(),
 		disableServiceSync: disableServiceSync,
+		syncInterval:       syncInterval,
+		syncRequests:       make(chan bool, 1),
 	}
 
 	// Organize service definitions
@@ -177,6 +205,11 @@ func NewController(cli *client.VanClient, origin string, tlsConfig *tls.Config,
 	controller.byName = make(map[string]types.ServiceInterface)
 	controller.desiredServices = make(map[string]types.ServiceInterface)
 	controller.heardFrom = make(map[string]time.Time)
+	controller.syncIntervals = make(map[string]time.Duration)
+	if importOnDemand {
+		controller.serviceImports = newServiceImports(cli.KubeClient, cli.Namespace)
+		controller.serviceImports.onChange(controller.serviceImportsChanged)
+	}
 
 	log.Println("Setting up event handlers")
 	svcDefInformer.AddEventHandler(controller.newEventHandler("servicedefs", AnnotatedKey, ConfigMapResourceVersionTest))
@@ -184,10 +217,23 @@ func NewController(cli *client.VanClient, origin string, tlsConfig *tls.Config,
 	svcInformer.AddEventHandler(controller.newEventHandler("actual-services", AnnotatedKey, ServiceResourceVersionTest))
 	headlessInformer.AddEventHandler(controller.newEventHandler("statefulset", AnnotatedKey, StatefulSetResourceVersionTest))
 	controller.consoleServer = newConsoleServer(cli, tlsConfig)
+	controller.consoleServer.linkCertWarningDays = linkCertWarningDays
+	controller.consoleServer.requestServiceSync = controller.requestServiceSync
 	controller.siteQueryServer = newSiteQueryServer(cli, tlsConfig)
 
 	controller.definitionMonitor = newDefinitionMonitor(controller.origin, controller.vanClient, controller.svcDefInformer, controller.svcInformer)
-	controller.configSync = newConfigSync(controller.bridgeDefInformer, tlsConfig)
+	controller.configSync = newConfigSync(controller.bridgeDefInformer, tlsConfig, cli.KubeClient, cli.Namespace)
+	controller.selfTest = newSelfTest(cli, origin, tlsConfig)
+	controller.healthChecker = newHealthChecker(events)
+	controller.idleMonitor = newIdleMonitor(cli, tlsConfig)
+	controller.linkCerts = newLinkCertMonitor(cli, linkCertWarningDays)
+	controller.linkActivity = newLinkActivityMonitor(cli)
+	controller.certRotation = newCertRotationMonitor(cli, tlsConfig)
+	controller.linkProxy = newLinkProxyForwarder(cli)
+	controller.bridgeWatchdog = newBridgeWatchdog(controller.configSync)
+	if watermarks != nil {
+		controller.watermarkMonitor = newWatermarkMonitor(watermarks, tlsConfig, controller.configSync)
+	}
 	return controller, nil
 }
 
@@ -285,6 +331,11 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 	go c.bridgeDefInformer.Run(stopCh)
 	go c.svcInformer.Run(stopCh)
 	go c.headlessInformer.Run(stopCh)
+	synced := []cache.InformerSynced{c.svcDefInformer.HasSynced, c.bridgeDefInformer.HasSynced, c.svcInformer.HasSynced, c.headlessInformer.HasSynced}
+	if c.serviceImports != nil {
+		go c.serviceImports.informer.Run(stopCh)
+		synced = append(synced, c.serviceImports.informer.HasSynced)
+	}
 
 	defer utilruntime.HandleCrash()
 	defer c.events.ShutDown()
@@ -292,7 +343,7 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 	log.Println("Starting the Skupper controller")
 
 	log.Println("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.svcDefInformer.HasSynced, c.bridgeDefInformer.HasSynced, c.svcInformer.HasSynced, c.headlessInformer.HasSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, synced...); !ok {
 		return fmt.Errorf("Failed to wait for caches to sync")
 	}
 
@@ -305,6 +356,16 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 	c.siteQueryServer.start(stopCh)
 	c.consoleServer.start(stopCh)
 	c.configSync.start(stopCh)
+	if c.watermarkMonitor != nil {
+		c.watermarkMonitor.start(stopCh)
+	}
+	c.idleMonitor.start(stopCh)
+	c.linkCerts.start(stopCh)
+	c.linkActivity.start(stopCh)
+	c.certRotation.start(stopCh)
+	c.linkProxy.start(stopCh)
+	c.bridgeWatchdog.start(stopCh)
+	c.selfTest.start()
 
 	log.Println("Started workers")
 	<-stopCh
@@ -317,7 +378,8 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 
 func (c *Controller) createServiceFor(desired *ServiceBindings) error {
 	event.Recordf(ServiceControllerCreateEvent, "Creating new service for %s", desired.address)
-	_, err := kube.NewServiceForAddress(desired.address, desired.publicPort, desired.ingressPort, getOwnerReference(), c.vanClient.Namespace, c.vanClient.KubeClient)
+	publicPorts, ingressPorts := desired.portMappings()
+	_, err := kube.NewServiceForAddress(desired.address, publicPorts, ingressPorts, getOwnerReference(), c.vanClient.Namespace, c.vanClient.KubeClient)
 	if err != nil {
 		event.Recordf(ServiceControllerError, "Error while creating service %s: %s", desired.address, err)
 	}
@@ -326,7 +388,8 @@ func (c *Controller) createServiceFor(desired *ServiceBindings) error {
 
 func (c *Controller) createHeadlessServiceFor(desired *ServiceBindings) error {
 	event.Recordf(ServiceControllerCreateEvent, "Creating new headless service for %s", desired.address)
-	_, err := kube.NewHeadlessServiceForAddress(desired.address, desired.publicPort, desired.ingressPort, getOwnerReference(), c.vanClient.Namespace, c.vanClient.KubeClient)
+	publicPorts, ingressPorts := desired.portMappings()
+	_, err := kube.NewHeadlessServiceForAddress(desired.address, publicPorts, ingressPorts, getOwnerReference(), c.vanClient.Namespace, c.vanClient.KubeClient)
 	if err != nil {
 		event.Recordf(ServiceControllerError, "Error while creating headless service %s: %s", desired.address, err)
 	}
@@ -354,23 +417,47 @@ func (c *Controller) checkServiceFor(desired *ServiceBindings, actual *corev1.Se
 	event.Recordf(ServiceControllerEvent, "Checking service changes for %s", actual.ObjectMeta.Name)
 	update := false
 	if len(actual.Spec.Ports) > 0 {
-		if actual.Spec.Ports[0].Port != int32(desired.publicPort) {
-			update = true
-			actual.Spec.Ports[0].Port = int32(desired.publicPort)
+		publicPorts, ingressPorts := desired.portMappings()
+		originalAssignedPorts := parsePorts(actual.Annotations[types.OriginalAssignedQualifier])
+		originalTargetPorts := parsePorts(actual.Annotations[types.OriginalTargetPortQualifier])
+		retargeted := false
+		for i, port := range publicPorts {
+			if i == len(actual.Spec.Ports) {
+				update = true
+				actual.Spec.Ports = append(actual.Spec.Ports, corev1.ServicePort{
+					Name: kube.ServicePortName(desired.address, i, port),
+				})
+			}
+			if actual.Spec.Ports[i].Port != int32(port) {
+				update = true
+				actual.Spec.Ports[i].Port = int32(port)
+			}
+			actualTargetPort := actual.Spec.Ports[i].TargetPort.IntValue()
+			if actualTargetPort != ingressPorts[i] {
+				update = true
+				retargeted = true
+				// If target port has been modified by user
+				if i >= len(originalAssignedPorts) || actualTargetPort != originalAssignedPorts[i] {
+					for len(originalTargetPorts) <= i {
+						originalTargetPorts = append(originalTargetPorts, 0)
+					}
+					originalTargetPorts[i] = actualTargetPort
+				}
+				actual.Spec.Ports[i].TargetPort = intstr.FromInt(ingressPorts[i])
+			}
 		}
-		if actual.Spec.Ports[0].TargetPort.IntValue() != desired.ingressPort {
+		if len(actual.Spec.Ports) > len(publicPorts) {
 			update = true
-			originalAssignedPort, _ := strconv.Atoi(actual.Annotations[types.OriginalAssignedQualifier])
-			actualTargetPort := actual.Spec.Ports[0].TargetPort.IntValue()
+			actual.Spec.Ports = actual.Spec.Ports[:len(publicPorts)]
+		}
+		if retargeted {
 			if actual.ObjectMeta.Annotations == nil {
 				actual.ObjectMeta.Annotations = map[string]string{}
 			}
-			// If target port has been modified by user
-			if actualTargetPort != originalAssignedPort {
-				actual.ObjectMeta.Annotations[types.OriginalTargetPortQualifier] = strconv.Itoa(actualTargetPort)
+			if len(originalTargetPorts) > 0 {
+				actual.ObjectMeta.Annotations[types.OriginalTargetPortQualifier] = formatPorts(originalTargetPorts)
 			}
-			actual.ObjectMeta.Annotations[types.OriginalAssignedQualifier] = strconv.Itoa(desired.ingressPort)
-			actual.Spec.Ports[0].TargetPort = intstr.FromInt(desired.ingressPort)
+			actual.ObjectMeta.Annotations[types.OriginalAssignedQualifier] = formatPorts(ingressPorts)
 		}
 	}
 	if desired.headless == nil && !equivalentSelectors(actual.Spec.Selector, kube.GetLabelsForRouter()) {
@@ -393,7 +480,7 @@ func (c *Controller) checkServiceFor(desired *ServiceBindings, actual *corev1.Se
 
 func (c *Controller) ensureServiceFor(desired *ServiceBindings) error {
 	event.Recordf(ServiceControllerEvent, "Checking service for: %s", desired.address)
-	obj, exists, err := c.svcInformer.GetStore().GetByKey(c.namespaced(desired.address))
+	obj, exists, err := c.svcInformer.GetStore().GetByKey(c.namespaced(naming.ServiceName(desired.address)))
 	if err != nil {
 		return fmt.Errorf("Error checking service %s", err)
 	} else if !exists {
@@ -412,6 +499,20 @@ func (c *Controller) ensureServiceFor(desired *ServiceBindings) error {
 	}
 }
 
+// Services for addresses that are not valid names have a derived name,
+// so the bindings cannot always be looked up by service name directly
+func (c *Controller) getBindingsForServiceName(name string) *ServiceBindings {
+	if bindings, ok := c.bindings[name]; ok {
+		return bindings
+	}
+	for address, bindings := range c.bindings {
+		if naming.ServiceName(address) == name {
+			return bindings
+		}
+	}
+	return nil
+}
+
 func (c *Controller) deleteService(svc *corev1.Service) error {
 	event.Recordf(ServiceControllerDeleteEvent, "Deleting service %s", svc.ObjectMeta.Name)
 	return c.vanClient.KubeClient.CoreV1().Services(c.vanClient.Namespace).Delete(svc.ObjectMeta.Name, &metav1.DeleteOptions{})
@@ -424,7 +525,7 @@ func (c *Controller) updateActualServices() {
 	services := c.svcInformer.GetStore().List()
 	for _, v := range services {
 		svc := v.(*corev1.Service)
-		if c.bindings[svc.ObjectMeta.Name] == nil && isOwned(svc) {
+		if c.bindings[naming.AddressForService(svc)] == nil && isOwned(svc) {
 			event.Recordf(ServiceControllerDeleteEvent, "No service binding found for %s", svc.ObjectMeta.Name)
 			c.deleteService(svc)
 		}
@@ -531,7 +632,12 @@ func (c *Controller) updateBridgeConfig(name string) error {
 		if !ok {
 			return fmt.Errorf("Expected ConfigMap for %s but got %#v", name, obj)
 		}
-		desiredBridges := requiredBridges(c.bindings, c.origin)
+		desiredBridges := requiredBridges(c.bindings, c.origin, c.healthChecker)
+		current, err := qdr.GetRouterConfigFromConfigMap(cm)
+		if err != nil {
+			return fmt.Errorf("Error reading router config from %s: %s", cm.ObjectMeta.Name, err)
+		}
+		removeBridgesWithUndefinedSslProfiles(desiredBridges, current.SslProfiles)
 		update, err := desiredBridges.UpdateConfigMap(cm)
 		if err != nil {
 			return fmt.Errorf("Error updating %s: %s", cm.ObjectMeta.Name, err)
@@ -709,7 +815,7 @@ func (c *Controller) processNextEvent() bool {
 					if !ok {
 						return fmt.Errorf("Expected Service for %s but got %#v", name, obj)
 					}
-					bindings := c.bindings[svc.ObjectMeta.Name]
+					bindings := c.bindings[naming.AddressForService(svc)]
 					if bindings == nil {
 						if isOwned(svc) {
 							err = c.deleteService(svc)
@@ -729,7 +835,7 @@ func (c *Controller) processNextEvent() bool {
 					if err != nil {
 						return fmt.Errorf("Could not determine name of deleted service from key %s: %w", name, err)
 					}
-					bindings := c.bindings[unqualified]
+					bindings := c.getBindingsForServiceName(unqualified)
 					if bindings != nil {
 						if bindings.headless == nil {
 							err = c.createServiceFor(bindings)
@@ -745,6 +851,9 @@ func (c *Controller) processNextEvent() bool {
 				event.Recordf(ServiceControllerEvent, "Got targetpods event %s", name)
 				//name is the address of the skupper service
 				c.updateBridgeConfig(c.namespaced(types.TransportConfigMapName))
+			case "healthcheck":
+				event.Recordf(ServiceControllerEvent, "Got health check event %s", name)
+				c.updateBridgeConfig(c.namespaced(types.TransportConfigMapName))
 			case "statefulset":
 				event.Recordf(ServiceControllerEvent, "Got statefulset proxy event %s", name)
 				obj, exists, err := c.headlessInformer.GetStore().GetByKey(name)
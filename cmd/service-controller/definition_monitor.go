@@ -3,11 +3,14 @@ package main
 import (
 	jsonencoding "encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -122,61 +125,186 @@ func (m *DefinitionMonitor) runDefinitionMonitor() {
 	}
 }
 
-func deducePort(deployment *appsv1.Deployment) int {
-	if port, ok := deployment.ObjectMeta.Annotations[types.PortQualifier]; ok {
-		if iport, err := strconv.Atoi(port); err == nil {
-			return iport
-		} else {
-			return 0
+// parsePorts reads the port annotation of a workload, which may list
+// several ports separated by commas. Nothing is returned if any of them
+// is not a valid number.
+func parsePorts(value string) []int {
+	ports := []int{}
+	for _, port := range strings.Split(value, ",") {
+		iport, err := strconv.Atoi(strings.TrimSpace(port))
+		if err != nil {
+			return nil
 		}
+		ports = append(ports, iport)
+	}
+	return ports
+}
+
+func containerPorts(port int32) []int {
+	if port == 0 {
+		return nil
+	}
+	return []int{int(port)}
+}
+
+func deducePorts(deployment *appsv1.Deployment) []int {
+	if port, ok := deployment.ObjectMeta.Annotations[types.PortQualifier]; ok {
+		return parsePorts(port)
 	} else {
-		return int(kube.GetContainerPort(deployment))
+		return containerPorts(kube.GetContainerPort(deployment))
 	}
 }
 
-func deducePortFromStatefulSet(statefulSet *appsv1.StatefulSet) int {
+func deducePortsFromStatefulSet(statefulSet *appsv1.StatefulSet) []int {
 	if port, ok := statefulSet.ObjectMeta.Annotations[types.PortQualifier]; ok {
-		if iport, err := strconv.Atoi(port); err == nil {
-			return iport
-		} else {
-			return 0
-		}
+		return parsePorts(port)
 	} else {
-		return int(kube.GetContainerPortForStatefulSet(statefulSet))
+		return containerPorts(kube.GetContainerPortForStatefulSet(statefulSet))
 	}
 }
 
-func deducePortFromDaemonSet(daemonSet *appsv1.DaemonSet) int {
+func deducePortsFromDaemonSet(daemonSet *appsv1.DaemonSet) []int {
 	if port, ok := daemonSet.ObjectMeta.Annotations[types.PortQualifier]; ok {
-		if iport, err := strconv.Atoi(port); err == nil {
-			return iport
-		} else {
-			return 0
-		}
+		return parsePorts(port)
 	} else {
-		return int(kube.GetContainerPortForDaemonSet(daemonSet))
+		return containerPorts(kube.GetContainerPortForDaemonSet(daemonSet))
 	}
 }
 
-func deducePortFromService(service *corev1.Service) int {
-	if len(service.Spec.Ports) > 0 {
-		return int(service.Spec.Ports[0].Port)
+func deducePortsFromService(service *corev1.Service) []int {
+	ports := []int{}
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, int(port.Port))
 	}
-	return 0
+	return ports
 }
 
-func deduceTargetPortFromService(service *corev1.Service) int {
-	if len(service.Spec.Ports) > 0 {
-		return service.Spec.Ports[0].TargetPort.IntValue()
+func deduceTargetPortsFromService(service *corev1.Service) []int {
+	ports := []int{}
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, port.TargetPort.IntValue())
 	}
-	return 0
+	return ports
+}
+
+func servicePorts(ports []int) []types.ServicePort {
+	var result []types.ServicePort
+	for _, port := range ports {
+		result = append(result, types.ServicePort{Port: port})
+	}
+	return result
+}
+
+// annotatedMetadata returns the metadata for the service definition of
+// an annotated object, which marks the service as protected if the
+// object is
+func annotatedMetadata(annotations map[string]string) map[string]string {
+	if annotations[types.ProtectedAnnotation] != "true" {
+		return nil
+	}
+	return map[string]string{
+		types.ProtectedAnnotation: "true",
+	}
+}
+
+// proxySpec is the full form of the proxy annotation, a JSON object
+// given in place of just the protocol when the service cannot simply be
+// deduced from the annotated object
+type proxySpec struct {
+	Protocol    string            `json:"protocol"`
+	Ports       []int             `json:"ports,omitempty"`
+	TargetPorts []int             `json:"targetPorts,omitempty"`
+	Headless    bool              `json:"headless,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+func parseProxySpec(value string) (proxySpec, error) {
+	spec := proxySpec{}
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		spec.Protocol = value
+		return spec, nil
+	}
+	decoder := jsonencoding.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("invalid proxy annotation: %s", err)
+	}
+	if spec.Protocol == "" {
+		spec.Protocol = "tcp"
+	} else if spec.Protocol != "tcp" && spec.Protocol != "http" && spec.Protocol != "http2" && spec.Protocol != "grpc" {
+		return spec, fmt.Errorf("invalid protocol %s in proxy annotation", spec.Protocol)
+	}
+	for _, port := range append(spec.Ports, spec.TargetPorts...) {
+		if port <= 0 || port > 65535 {
+			return spec, fmt.Errorf("invalid port %d in proxy annotation", port)
+		}
+	}
+	return spec, nil
+}
+
+// proxyAnnotation returns the parsed proxy annotation of an object of
+// the given kind, if it has a valid one
+func proxyAnnotation(kind string, object *metav1.ObjectMeta) (proxySpec, bool) {
+	value, ok := object.Annotations[types.ProxyQualifier]
+	if !ok {
+		return proxySpec{}, false
+	}
+	spec, err := parseProxySpec(value)
+	if err != nil {
+		event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated %s %s; %s", kind, object.Name, err)
+		return spec, false
+	}
+	if spec.Headless && kind != "statefulset" {
+		event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated %s %s; headless is only supported for statefulsets", kind, object.Name)
+		return spec, false
+	}
+	return spec, true
+}
+
+// applyTo sets the target ports and labels given in the annotation on
+// the service deduced for the annotated object. Target ports are matched
+// to the ports of the service by position.
+func (spec *proxySpec) applyTo(svc *types.ServiceInterface) error {
+	if len(spec.TargetPorts) > len(svc.Ports) {
+		return fmt.Errorf("%d target ports given for %d ports", len(spec.TargetPorts), len(svc.Ports))
+	}
+	if svc.Headless != nil {
+		if len(spec.TargetPorts) > 1 {
+			return fmt.Errorf("headless services only support a single target port")
+		} else if len(spec.TargetPorts) == 1 && spec.TargetPorts[0] != svc.Ports[0].Port {
+			svc.Headless.TargetPort = spec.TargetPorts[0]
+		}
+	} else if len(spec.TargetPorts) > 0 && len(svc.Targets) > 0 {
+		target := &svc.Targets[0]
+		for i, targetPort := range spec.TargetPorts {
+			if target.TargetPorts == nil {
+				target.TargetPorts = map[int]int{}
+			}
+			target.TargetPorts[svc.Ports[i].Port] = targetPort
+		}
+	}
+	if len(spec.Labels) > 0 {
+		if svc.Metadata == nil {
+			svc.Metadata = map[string]string{}
+		}
+		for key, value := range spec.Labels {
+			svc.Metadata[key] = value
+		}
+	}
+	return nil
 }
 
 func updateAnnotatedServiceDefinition(actual *types.ServiceInterface, desired *types.ServiceInterface) bool {
 	if actual.Origin != "annotation" {
 		return false
 	}
-	if actual.Protocol != desired.Protocol || actual.Port != desired.Port {
+	if actual.Protocol != desired.Protocol || !actual.SamePorts(desired) {
+		return true
+	}
+	if !equivalentMetadata(actual.Metadata, desired.Metadata) {
+		return true
+	}
+	if !reflect.DeepEqual(actual.Headless, desired.Headless) {
 		return true
 	}
 	if len(actual.Targets) != len(desired.Targets) {
@@ -185,7 +313,7 @@ func updateAnnotatedServiceDefinition(actual *types.ServiceInterface, desired *t
 	if len(desired.Targets) > 0 {
 		nameChanged := actual.Targets[0].Name != desired.Targets[0].Name
 		selectorChanged := actual.Targets[0].Selector != desired.Targets[0].Selector
-		targetPortChanged := actual.Targets[0].TargetPort != desired.Targets[0].TargetPort
+		targetPortChanged := !equivalentPorts(actual.Targets[0].TargetPorts, desired.Targets[0].TargetPorts)
 		if nameChanged || selectorChanged || targetPortChanged {
 			return true
 		}
@@ -195,11 +323,14 @@ func updateAnnotatedServiceDefinition(actual *types.ServiceInterface, desired *t
 
 func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedDeployment(deployment *appsv1.Deployment) (types.ServiceInterface, bool) {
 	var svc types.ServiceInterface
-	if protocol, ok := deployment.ObjectMeta.Annotations[types.ProxyQualifier]; ok {
-		if port := deducePort(deployment); port != 0 {
-			svc.Port = int(port)
+	if spec, ok := proxyAnnotation("deployment", &deployment.ObjectMeta); ok {
+		protocol := spec.Protocol
+		if len(spec.Ports) > 0 {
+			svc.Ports = servicePorts(spec.Ports)
+		} else if ports := deducePorts(deployment); len(ports) > 0 {
+			svc.Ports = servicePorts(ports)
 		} else if protocol == "http" {
-			svc.Port = 80
+			svc.Ports = servicePorts([]int{80})
 		} else {
 			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated deployment %s; cannot deduce port", deployment.ObjectMeta.Name)
 			return svc, false
@@ -221,6 +352,11 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedDeployment(deployme
 				Selector: selector,
 			},
 		}
+		svc.Metadata = annotatedMetadata(deployment.ObjectMeta.Annotations)
+		if err := spec.applyTo(&svc); err != nil {
+			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated deployment %s; %s", deployment.ObjectMeta.Name, err)
+			return svc, false
+		}
 		svc.Origin = "annotation"
 		return svc, true
 	} else {
@@ -230,11 +366,14 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedDeployment(deployme
 
 func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedStatefulSet(statefulset *appsv1.StatefulSet) (types.ServiceInterface, bool) {
 	var svc types.ServiceInterface
-	if protocol, ok := statefulset.ObjectMeta.Annotations[types.ProxyQualifier]; ok {
-		if port := deducePortFromStatefulSet(statefulset); port != 0 {
-			svc.Port = int(port)
+	if spec, ok := proxyAnnotation("statefulset", &statefulset.ObjectMeta); ok {
+		protocol := spec.Protocol
+		if len(spec.Ports) > 0 {
+			svc.Ports = servicePorts(spec.Ports)
+		} else if ports := deducePortsFromStatefulSet(statefulset); len(ports) > 0 {
+			svc.Ports = servicePorts(ports)
 		} else if protocol == "http" {
-			svc.Port = 80
+			svc.Ports = servicePorts([]int{80})
 		} else {
 			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated statefulset %s; cannot deduce port", statefulset.ObjectMeta.Name)
 			return svc, false
@@ -245,6 +384,20 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedStatefulSet(statefu
 		} else {
 			svc.Address = statefulset.ObjectMeta.Name
 		}
+		if spec.Headless {
+			if statefulset.Spec.ServiceName == "" {
+				event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated statefulset %s; headless requires a service name", statefulset.ObjectMeta.Name)
+				return svc, false
+			}
+			svc.Address = statefulset.Spec.ServiceName
+			svc.Headless = &types.Headless{
+				Name: statefulset.ObjectMeta.Name,
+				Size: 1,
+			}
+			if statefulset.Spec.Replicas != nil {
+				svc.Headless.Size = int(*statefulset.Spec.Replicas)
+			}
+		}
 
 		selector := ""
 		if statefulset.Spec.Selector != nil {
@@ -256,6 +409,11 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedStatefulSet(statefu
 				Selector: selector,
 			},
 		}
+		svc.Metadata = annotatedMetadata(statefulset.ObjectMeta.Annotations)
+		if err := spec.applyTo(&svc); err != nil {
+			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated statefulset %s; %s", statefulset.ObjectMeta.Name, err)
+			return svc, false
+		}
 		svc.Origin = "annotation"
 		return svc, true
 	} else {
@@ -265,11 +423,14 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedStatefulSet(statefu
 
 func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedDaemonSet(daemonset *appsv1.DaemonSet) (types.ServiceInterface, bool) {
 	var svc types.ServiceInterface
-	if protocol, ok := daemonset.ObjectMeta.Annotations[types.ProxyQualifier]; ok {
-		if port := deducePortFromDaemonSet(daemonset); port != 0 {
-			svc.Port = int(port)
+	if spec, ok := proxyAnnotation("daemonset", &daemonset.ObjectMeta); ok {
+		protocol := spec.Protocol
+		if len(spec.Ports) > 0 {
+			svc.Ports = servicePorts(spec.Ports)
+		} else if ports := deducePortsFromDaemonSet(daemonset); len(ports) > 0 {
+			svc.Ports = servicePorts(ports)
 		} else if protocol == "http" {
-			svc.Port = 80
+			svc.Ports = servicePorts([]int{80})
 		} else {
 			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated daemonset %s; cannot deduce port", daemonset.ObjectMeta.Name)
 			return svc, false
@@ -291,6 +452,11 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedDaemonSet(daemonset
 				Selector: selector,
 			},
 		}
+		svc.Metadata = annotatedMetadata(daemonset.ObjectMeta.Annotations)
+		if err := spec.applyTo(&svc); err != nil {
+			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated daemonset %s; %s", daemonset.ObjectMeta.Name, err)
+			return svc, false
+		}
 		svc.Origin = "annotation"
 		return svc, true
 	} else {
@@ -300,9 +466,12 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedDaemonSet(daemonset
 
 func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedService(service *corev1.Service) (types.ServiceInterface, bool) {
 	var svc types.ServiceInterface
-	if protocol, ok := service.ObjectMeta.Annotations[types.ProxyQualifier]; ok {
-		if port := deducePortFromService(service); port != 0 {
-			svc.Port = int(port)
+	if spec, ok := proxyAnnotation("service", &service.ObjectMeta); ok {
+		protocol := spec.Protocol
+		if len(spec.Ports) > 0 {
+			svc.Ports = servicePorts(spec.Ports)
+		} else {
+			svc.Ports = servicePorts(deducePortsFromService(service))
 		}
 		svc.Protocol = protocol
 		if address, ok := service.ObjectMeta.Annotations[types.AddressQualifier]; ok {
@@ -315,11 +484,11 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedService(service *co
 			if err != nil {
 				event.Recordf(DefinitionMonitorError, "Could not deduce port for target service %s on annotated service %s: %s", target, service.ObjectMeta.Name, err)
 			}
-			if svc.Port == 0 {
+			if len(svc.Ports) == 0 {
 				if port != 0 {
-					svc.Port = port
+					svc.Ports = servicePorts([]int{port})
 				} else if protocol == "http" {
-					svc.Port = 80
+					svc.Ports = servicePorts([]int{80})
 				} else {
 					event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated service %s; cannot deduce port", service.ObjectMeta.Name)
 					return svc, false
@@ -329,16 +498,16 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedService(service *co
 				Name:    target,
 				Service: target,
 			}
-			if port != 0 && port != svc.Port {
-				svcTgt.TargetPort = port
+			if port != 0 && port != svc.PrimaryPort() {
+				svcTgt.TargetPorts = map[int]int{svc.PrimaryPort(): port}
 			}
 			svc.Targets = []types.ServiceInterfaceTarget{
 				svcTgt,
 			}
 		} else if service.Spec.Selector != nil {
-			if svc.Port == 0 {
+			if len(svc.Ports) == 0 {
 				if protocol == "http" {
-					svc.Port = 80
+					svc.Ports = servicePorts([]int{80})
 				} else {
 					event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated service %s; cannot deduce port", service.ObjectMeta.Name)
 					return svc, false
@@ -361,16 +530,20 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedService(service *co
 				Name:     service.ObjectMeta.Name,
 				Selector: svcSelector,
 			}
-			if !hasOriginalTargetPort(*service) {
-				// If getting target port from new annotated service, deduce target port from service
-				if targetPort := deduceTargetPortFromService(service); targetPort != 0 {
-					target.TargetPort = targetPort
+			// If getting target ports from new annotated service, deduce target ports from service
+			targetPorts := deduceTargetPortsFromService(service)
+			if hasOriginalTargetPort(*service) {
+				// If getting target ports from previously annotated service, deduce target ports from existing annotation
+				// as in this case the target ports might have been already modified
+				targetPorts = parsePorts(service.Annotations[types.OriginalTargetPortQualifier])
+			}
+			for i, targetPort := range targetPorts {
+				if i < len(svc.Ports) && (targetPort != 0 || hasOriginalTargetPort(*service)) {
+					if target.TargetPorts == nil {
+						target.TargetPorts = map[int]int{}
+					}
+					target.TargetPorts[svc.Ports[i].Port] = targetPort
 				}
-			} else {
-				// If getting target port from previously annotated service, deduce target port from existing annotation
-				// as in this case the target port might have been already modified
-				originalTargetPort, _ := strconv.Atoi(service.Annotations[types.OriginalTargetPortQualifier])
-				target.TargetPort = originalTargetPort
 			}
 			svc.Targets = []types.ServiceInterfaceTarget{
 				target,
@@ -379,6 +552,11 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedService(service *co
 			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated service %s; no selector defined", service.ObjectMeta.Name)
 			return svc, false
 		}
+		svc.Metadata = annotatedMetadata(service.ObjectMeta.Annotations)
+		if err := spec.applyTo(&svc); err != nil {
+			event.Recordf(DefinitionMonitorIgnored, "Ignoring annotated service %s; %s", service.ObjectMeta.Name, err)
+			return svc, false
+		}
 		svc.Origin = "annotation"
 		return svc, true
 	} else {
@@ -388,7 +566,10 @@ func (m *DefinitionMonitor) getServiceDefinitionFromAnnotatedService(service *co
 
 func (m *DefinitionMonitor) deleteServiceDefinitionForAddress(address string) error {
 	svc, ok := m.annotated[address]
-	if ok {
+	if ok && svc.IsProtected() {
+		event.Recordf(DefinitionMonitorIgnored, "Not deleting protected service definition for %s; it can only be removed with --force", address)
+		return nil
+	} else if ok {
 		// delete the svc definition
 		changed := []types.ServiceInterface{}
 		deleted := []string{
@@ -438,9 +619,13 @@ func (m *DefinitionMonitor) restoreServiceDefinitions(service *corev1.Service) e
 	}
 	if hasOriginalTargetPort(*service) {
 		updated = true
-		originalTargetPort, _ := strconv.Atoi(service.ObjectMeta.Annotations[types.OriginalTargetPortQualifier])
+		originalTargetPorts := parsePorts(service.ObjectMeta.Annotations[types.OriginalTargetPortQualifier])
 		delete(service.ObjectMeta.Annotations, types.OriginalTargetPortQualifier)
-		service.Spec.Ports[0].TargetPort = intstr.FromInt(originalTargetPort)
+		for i, originalTargetPort := range originalTargetPorts {
+			if i < len(service.Spec.Ports) {
+				service.Spec.Ports[i].TargetPort = intstr.FromInt(originalTargetPort)
+			}
+		}
 	}
 	if hasOriginalAssigned(*service) {
 		updated = true
@@ -112,7 +112,7 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "dep1",
 				Protocol: "http",
-				Port:     81,
+				Ports:    []types.ServicePort{{Port: 81}},
 				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: "label1=value1"}},
 				Origin:   "annotation",
 			},
@@ -122,7 +122,7 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "dep1",
 				Protocol: "http",
-				Port:     81,
+				Ports:    []types.ServicePort{{Port: 81}},
 				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: ""}},
 				Origin:   "annotation",
 			},
@@ -132,7 +132,7 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "dep1",
 				Protocol: "http",
-				Port:     8080,
+				Ports:    []types.ServicePort{{Port: 8080}},
 				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: "label1=value1"}},
 				Origin:   "annotation",
 			},
@@ -142,7 +142,7 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "dep1",
 				Protocol: "http",
-				Port:     80,
+				Ports:    []types.ServicePort{{Port: 80}},
 				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: "label1=value1"}},
 				Origin:   "annotation",
 			},
@@ -152,7 +152,7 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address1",
 				Protocol: "http",
-				Port:     80,
+				Ports:    []types.ServicePort{{Port: 80}},
 				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: "label1=value1"}},
 				Origin:   "annotation",
 			},
@@ -162,6 +162,34 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 			service: types.ServiceInterface{},
 			success: false,
 		}},
+		{"json-spec-ports", newDeployment("dep1", `{"protocol":"http2","ports":[9090,9091]}`, "81", "", 8080, selectorWithLabels), result{
+			service: types.ServiceInterface{
+				Address:  "dep1",
+				Protocol: "http2",
+				Ports:    []types.ServicePort{{Port: 9090}, {Port: 9091}},
+				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: "label1=value1"}},
+				Origin:   "annotation",
+			},
+			success: true,
+		}},
+		{"json-spec-default-protocol", newDeployment("dep1", `{}`, "", "", 8080, selectorWithLabels), result{
+			service: types.ServiceInterface{
+				Address:  "dep1",
+				Protocol: "tcp",
+				Ports:    []types.ServicePort{{Port: 8080}},
+				Targets:  []types.ServiceInterfaceTarget{{Name: "dep1", Selector: "label1=value1"}},
+				Origin:   "annotation",
+			},
+			success: true,
+		}},
+		{"json-spec-invalid", newDeployment("dep1", `{"protocol":"tcp","port":8080}`, "", "", 8080, selectorWithLabels), result{
+			service: types.ServiceInterface{},
+			success: false,
+		}},
+		{"json-spec-headless", newDeployment("dep1", `{"protocol":"tcp","headless":true}`, "", "", 8080, selectorWithLabels), result{
+			service: types.ServiceInterface{},
+			success: false,
+		}},
 	}
 
 	// Iterating through the test table
@@ -169,7 +197,7 @@ func TestGetServiceDefinitionFromAnnotatedDeployment(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			service, success := dm.getServiceDefinitionFromAnnotatedDeployment(test.deployment)
 			// Validating returned service
-			assert.Equal(t, test.expected.service.Port, service.Port)
+			assert.DeepEqual(t, test.expected.service.Ports, service.Ports)
 			assert.Equal(t, test.expected.service.Protocol, service.Protocol)
 			assert.Equal(t, test.expected.service.Address, service.Address)
 			assert.Equal(t, len(test.expected.service.Targets), len(service.Targets))
@@ -291,7 +319,6 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "svc",
 				Protocol: "http",
-				Port:     0,
 			},
 			success: false,
 		}},
@@ -299,13 +326,13 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "http",
-				Port:     8080,
+				Ports:    []types.ServicePort{{Port: 8080}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
-						Name:       "targetsvc",
-						Selector:   "",
-						TargetPort: 8888,
-						Service:    "targetsvc",
+						Name:        "targetsvc",
+						Selector:    "",
+						TargetPorts: map[int]int{8080: 8888},
+						Service:     "targetsvc",
 					},
 				},
 				Origin: "annotation",
@@ -316,13 +343,12 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "http",
-				Port:     80,
+				Ports:    []types.ServicePort{{Port: 80}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
-						Name:       "targetsvcnoport",
-						Selector:   "",
-						TargetPort: 0,
-						Service:    "targetsvcnoport",
+						Name:     "targetsvcnoport",
+						Selector: "",
+						Service:  "targetsvcnoport",
 					},
 				},
 				Origin: "annotation",
@@ -333,7 +359,6 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "tcp",
-				Port:     0,
 			},
 			success: false,
 		}},
@@ -341,13 +366,12 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "tcp",
-				Port:     8888,
+				Ports:    []types.ServicePort{{Port: 8888}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
-						Name:       "targetsvc",
-						Selector:   "",
-						TargetPort: 0,
-						Service:    "targetsvc",
+						Name:     "targetsvc",
+						Selector: "",
+						Service:  "targetsvc",
 					},
 				},
 				Origin: "annotation",
@@ -358,13 +382,12 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "http",
-				Port:     8080,
+				Ports:    []types.ServicePort{{Port: 8080}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
-						Name:       "badtargetsvc",
-						Selector:   "",
-						TargetPort: 0,
-						Service:    "badtargetsvc",
+						Name:     "badtargetsvc",
+						Selector: "",
+						Service:  "badtargetsvc",
 					},
 				},
 				Origin: "annotation",
@@ -375,13 +398,12 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "tcp",
-				Port:     8888,
+				Ports:    []types.ServicePort{{Port: 8888}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
-						Name:       "targetsvc",
-						Selector:   "",
-						TargetPort: 0,
-						Service:    "targetsvc",
+						Name:     "targetsvc",
+						Selector: "",
+						Service:  "targetsvc",
 					},
 				},
 				Origin: "annotation",
@@ -393,7 +415,6 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "tcp",
-				Port:     0,
 			},
 			success: false,
 		}},
@@ -402,7 +423,7 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "http",
-				Port:     80,
+				Ports:    []types.ServicePort{{Port: 80}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
 						Name:     "svc",
@@ -418,12 +439,12 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "address",
 				Protocol: "http",
-				Port:     8080,
+				Ports:    []types.ServicePort{{Port: 8080}},
 				Targets: []types.ServiceInterfaceTarget{
 					{
-						Name:       "svc",
-						Selector:   "label1=value1",
-						TargetPort: 8888,
+						Name:        "svc",
+						Selector:    "label1=value1",
+						TargetPorts: map[int]int{8080: 8888},
 					},
 				},
 				Origin: "annotation",
@@ -438,12 +459,12 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 				service: types.ServiceInterface{
 					Address:  "address",
 					Protocol: "http",
-					Port:     8080,
+					Ports:    []types.ServicePort{{Port: 8080}},
 					Targets: []types.ServiceInterfaceTarget{
 						{
-							Name:       "svc",
-							Selector:   "label1=value1",
-							TargetPort: 8080,
+							Name:        "svc",
+							Selector:    "label1=value1",
+							TargetPorts: map[int]int{8080: 8080},
 						},
 					},
 					Origin: "annotation",
@@ -457,7 +478,7 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 			service: types.ServiceInterface{
 				Address:  "svc",
 				Protocol: "http",
-				Port:     8080,
+				Ports:    []types.ServicePort{{Port: 8080}},
 				Targets:  []types.ServiceInterfaceTarget{},
 			},
 			success: false,
@@ -468,13 +489,13 @@ func TestGetServiceDefinitionFromAnnotatedService(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			service, success := dm.getServiceDefinitionFromAnnotatedService(test.service)
 			assert.Equal(t, test.expected.success, success)
-			assert.Equal(t, test.expected.service.Port, service.Port)
+			assert.DeepEqual(t, test.expected.service.Ports, service.Ports)
 			assert.Equal(t, test.expected.service.Address, service.Address)
 			assert.Equal(t, len(test.expected.service.Targets), len(service.Targets))
 			if len(service.Targets) > 0 {
 				assert.Equal(t, test.expected.service.Targets[0].Name, service.Targets[0].Name)
 				assert.Equal(t, test.expected.service.Targets[0].Service, service.Targets[0].Service)
-				assert.Equal(t, test.expected.service.Targets[0].TargetPort, service.Targets[0].TargetPort)
+				assert.DeepEqual(t, test.expected.service.Targets[0].TargetPorts, service.Targets[0].TargetPorts)
 				assert.Equal(t, test.expected.service.Targets[0].Selector, service.Targets[0].Selector)
 			}
 			assert.Equal(t, test.expected.service.Origin, service.Origin)
@@ -487,9 +508,9 @@ func TestDeducePort(t *testing.T) {
 
 	// Helps generating the test table
 	type test struct {
-		name         string
-		deployment   *v1.Deployment
-		expectedPort int
+		name          string
+		deployment    *v1.Deployment
+		expectedPorts []int
 	}
 
 	newDeployment := func(portAnnotation string, portContainer int) *v1.Deployment {
@@ -519,14 +540,15 @@ func TestDeducePort(t *testing.T) {
 	}
 
 	testTable := []test{
-		{"no-annotation-container-port", newDeployment("", 8080), 8080},
-		{"valid-annotation-container-port", newDeployment("8888", 8080), 8888},
-		{"invalid-annotation-container-port", newDeployment("invalid", 8080), 0},
+		{"no-annotation-container-port", newDeployment("", 8080), []int{8080}},
+		{"valid-annotation-container-port", newDeployment("8888", 8080), []int{8888}},
+		{"multiple-annotation-container-ports", newDeployment("8888, 9999", 8080), []int{8888, 9999}},
+		{"invalid-annotation-container-port", newDeployment("invalid", 8080), nil},
 	}
 
 	for _, test := range testTable {
 		t.Run(test.name, func(t *testing.T) {
-			assert.Assert(t, test.expectedPort == deducePort(test.deployment))
+			assert.DeepEqual(t, test.expectedPorts, deducePorts(test.deployment))
 		})
 	}
 }
@@ -535,7 +557,7 @@ func TestDeducePortFromService(t *testing.T) {
 	type test struct {
 		name     string
 		service  *corev1.Service
-		expected int
+		expected []int
 	}
 
 	// Helper used to prepare test table
@@ -558,14 +580,14 @@ func TestDeducePortFromService(t *testing.T) {
 	}
 
 	testTable := []test{
-		{"no-port", newService(), 0},
-		{"one-port", newService(8080), 8080},
-		{"two-ports", newService(8080, 8081), 8080},
+		{"no-port", newService(), []int{}},
+		{"one-port", newService(8080), []int{8080}},
+		{"two-ports", newService(8080, 8081), []int{8080, 8081}},
 	}
 
 	for _, test := range testTable {
 		t.Run(test.name, func(t *testing.T) {
-			assert.Equal(t, deducePortFromService(test.service), test.expected)
+			assert.DeepEqual(t, deducePortsFromService(test.service), test.expected)
 		})
 	}
 }
@@ -574,7 +596,7 @@ func TestDeduceTargetPortFromService(t *testing.T) {
 	type test struct {
 		name     string
 		service  *corev1.Service
-		expected int
+		expected []int
 	}
 
 	// Helper used to prepare test table
@@ -597,14 +619,118 @@ func TestDeduceTargetPortFromService(t *testing.T) {
 	}
 
 	testTable := []test{
-		{"no-port", newService(), 0},
-		{"one-port", newService(8080), 8080},
-		{"two-ports", newService(8080, 8081), 8080},
+		{"no-port", newService(), []int{}},
+		{"one-port", newService(8080), []int{8080}},
+		{"two-ports", newService(8080, 8081), []int{8080, 8081}},
 	}
 
 	for _, test := range testTable {
 		t.Run(test.name, func(t *testing.T) {
-			assert.Equal(t, deduceTargetPortFromService(test.service), test.expected)
+			assert.DeepEqual(t, deduceTargetPortsFromService(test.service), test.expected)
 		})
 	}
 }
+
+func TestProtectedAnnotatedServiceDefinition(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	kubeClient := fake.NewSimpleClientset()
+	m := &DefinitionMonitor{
+		vanClient: &client.VanClient{Namespace: "test", KubeClient: kubeClient},
+		annotated: map[string]types.ServiceInterface{
+			"db": {Address: "db", Protocol: "tcp", Origin: "annotation", Metadata: annotatedMetadata(map[string]string{types.ProtectedAnnotation: "true"})},
+		},
+		annotatedDeployments: map[string]string{"db": "db"},
+	}
+	actual := m.annotated["db"]
+	assert.Assert(t, actual.IsProtected())
+	assert.Assert(t, annotatedMetadata(map[string]string{types.ProtectedAnnotation: "false"}) == nil)
+
+	// removing the protected annotation is an update to the definition
+	desired := actual
+	desired.Metadata = nil
+	assert.Assert(t, updateAnnotatedServiceDefinition(&actual, &desired))
+
+	// the definition is not deleted with the annotated deployment
+	assert.NilError(t, m.deleteServiceDefinitionForAnnotatedDeployment("db"))
+	assert.Equal(t, len(kubeClient.Actions()), 0)
+}
+
+func TestParseProxySpec(t *testing.T) {
+	testTable := []struct {
+		name     string
+		value    string
+		expected proxySpec
+		err      string
+	}{
+		{"protocol", "http", proxySpec{Protocol: "http"}, ""},
+		{"full", `{"protocol":"http","ports":[8080],"targetPorts":[9090],"labels":{"app":"web"}}`, proxySpec{Protocol: "http", Ports: []int{8080}, TargetPorts: []int{9090}, Labels: map[string]string{"app": "web"}}, ""},
+		{"default-protocol", ` {"headless":true}`, proxySpec{Protocol: "tcp", Headless: true}, ""},
+		{"bad-protocol", `{"protocol":"udp"}`, proxySpec{}, "invalid protocol udp in proxy annotation"},
+		{"bad-port", `{"ports":[0]}`, proxySpec{}, "invalid port 0 in proxy annotation"},
+		{"bad-target-port", `{"ports":[80],"targetPorts":[70000]}`, proxySpec{}, "invalid port 70000 in proxy annotation"},
+		{"unknown-field", `{"protocol":"tcp","address":"foo"}`, proxySpec{}, `invalid proxy annotation: json: unknown field "address"`},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			spec, err := parseProxySpec(test.value)
+			if test.err != "" {
+				assert.Error(t, err, test.err)
+			} else {
+				assert.NilError(t, err)
+				assert.DeepEqual(t, spec, test.expected)
+			}
+		})
+	}
+}
+
+func TestProxySpecAnnotatedStatefulSet(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	m := &DefinitionMonitor{
+		vanClient: &client.VanClient{Namespace: "test", KubeClient: fake.NewSimpleClientset()},
+	}
+	replicas := int32(3)
+	statefulset := &v1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db",
+			Namespace: "test",
+			Annotations: map[string]string{
+				types.ProxyQualifier: `{"protocol":"tcp","ports":[5432],"targetPorts":[15432],"labels":{"tier":"data"}}`,
+			},
+		},
+		Spec: v1.StatefulSetSpec{
+			ServiceName: "db-headless",
+			Replicas:    &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "db"},
+			},
+		},
+	}
+
+	svc, ok := m.getServiceDefinitionFromAnnotatedStatefulSet(statefulset)
+	assert.Assert(t, ok)
+	assert.Equal(t, svc.Address, "db")
+	assert.DeepEqual(t, svc.Ports, []types.ServicePort{{Port: 5432}})
+	assert.DeepEqual(t, svc.Targets[0].TargetPorts, map[int]int{5432: 15432})
+	assert.DeepEqual(t, svc.Metadata, map[string]string{"tier": "data"})
+	assert.Assert(t, svc.Headless == nil)
+
+	statefulset.ObjectMeta.Annotations[types.ProxyQualifier] = `{"protocol":"tcp","ports":[5432],"targetPorts":[15432],"headless":true}`
+	headless, ok := m.getServiceDefinitionFromAnnotatedStatefulSet(statefulset)
+	assert.Assert(t, ok)
+	assert.Equal(t, headless.Address, "db-headless")
+	assert.DeepEqual(t, headless.Headless, &types.Headless{Name: "db", Size: 3, TargetPort: 15432})
+	assert.Assert(t, headless.Targets[0].TargetPorts == nil)
+	assert.Assert(t, updateAnnotatedServiceDefinition(&svc, &headless))
+
+	// changing the replicas of a headless statefulset updates the definition
+	svc = headless
+	replicas = 4
+	headless, ok = m.getServiceDefinitionFromAnnotatedStatefulSet(statefulset)
+	assert.Assert(t, ok)
+	assert.Assert(t, updateAnnotatedServiceDefinition(&svc, &headless))
+
+	// more target ports than ports are rejected
+	statefulset.ObjectMeta.Annotations[types.ProxyQualifier] = `{"protocol":"tcp","ports":[5432],"targetPorts":[1,2]}`
+	_, ok = m.getServiceDefinitionFromAnnotatedStatefulSet(statefulset)
+	assert.Assert(t, !ok)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// The standard gRPC health checking protocol, grpc.health.v1.Health. The
+// messages are simple enough to encode by hand rather than depend on a
+// gRPC implementation.
+const grpcHealthCheckPath string = "/grpc.health.v1.Health/Check"
+
+const (
+	grpcHealthUnknown        uint64 = 0
+	grpcHealthServing        uint64 = 1
+	grpcHealthNotServing     uint64 = 2
+	grpcHealthServiceUnknown uint64 = 3
+)
+
+func grpcHealthStatusName(status uint64) string {
+	switch status {
+	case grpcHealthUnknown:
+		return "UNKNOWN"
+	case grpcHealthServing:
+		return "SERVING"
+	case grpcHealthNotServing:
+		return "NOT_SERVING"
+	case grpcHealthServiceUnknown:
+		return "SERVICE_UNKNOWN"
+	default:
+		return fmt.Sprintf("status %d", status)
+	}
+}
+
+// grpcFrame prefixes a message with the uncompressed flag and its length
+func grpcFrame(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// grpcMessage returns the single message in a response body
+func grpcMessage(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, fmt.Errorf("Incomplete gRPC response")
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("Compressed gRPC responses are not supported")
+	}
+	length := binary.BigEndian.Uint32(body[1:5])
+	if uint32(len(body)-5) < length {
+		return nil, fmt.Errorf("Incomplete gRPC response")
+	}
+	return body[5 : 5+length], nil
+}
+
+// encodeHealthCheckRequest returns a HealthCheckRequest, whose only
+// field is the name of the service (field 1, length delimited)
+func encodeHealthCheckRequest(service string) []byte {
+	if service == "" {
+		return []byte{}
+	}
+	message := []byte{0x0a}
+	length := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(length, uint64(len(service)))
+	message = append(message, length[:n]...)
+	return append(message, service...)
+}
+
+// decodeHealthCheckResponse returns the status (field 1, varint) of a
+// HealthCheckResponse, skipping any fields it does not know
+func decodeHealthCheckResponse(message []byte) (uint64, error) {
+	status := grpcHealthUnknown
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, fmt.Errorf("Invalid health check response")
+		}
+		message = message[n:]
+		switch tag & 0x7 {
+		case 0:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, fmt.Errorf("Invalid health check response")
+			}
+			message = message[n:]
+			if tag>>3 == 1 {
+				status = value
+			}
+		case 2:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return 0, fmt.Errorf("Invalid health check response")
+			}
+			message = message[uint64(n)+length:]
+		default:
+			return 0, fmt.Errorf("Invalid health check response")
+		}
+	}
+	return status, nil
+}
+
+// probeGrpc calls the health service of a gRPC server over h2c, as the
+// router's http2 bridge reaches it, and checks the service is serving
+func probeGrpc(hostPort string, service string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network string, addr string, config *tls.Config) (net.Conn, error) {
+				return net.DialTimeout(network, addr, timeout)
+			},
+		},
+	}
+	request, err := http.NewRequest(http.MethodPost, "http://"+hostPort+grpcHealthCheckPath, bytes.NewReader(grpcFrame(encodeHealthCheckRequest(service))))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("TE", "trailers")
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Health check returned %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// a response with no message carries its status in the headers
+	grpcStatus := resp.Trailer.Get("Grpc-Status")
+	if grpcStatus == "" {
+		grpcStatus = resp.Header.Get("Grpc-Status")
+	}
+	if grpcStatus != "0" {
+		message := resp.Trailer.Get("Grpc-Message")
+		if message == "" {
+			message = resp.Header.Get("Grpc-Message")
+		}
+		return fmt.Errorf("Health check failed with gRPC status %s: %s", grpcStatus, message)
+	}
+	message, err := grpcMessage(body)
+	if err != nil {
+		return err
+	}
+	status, err := decodeHealthCheckResponse(message)
+	if err != nil {
+		return err
+	}
+	if status != grpcHealthServing {
+		return fmt.Errorf("Health check reported %s", grpcHealthStatusName(status))
+	}
+	return nil
+}
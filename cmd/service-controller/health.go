@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+const (
+	HealthCheckEvent string = "HealthCheckEvent"
+)
+
+// Probes the targets of services that define a health check, and
+// requests a bridge update whenever a target changes state. Targets
+// are withheld from the bridge configuration until they pass.
+type HealthChecker struct {
+	lock       sync.Mutex
+	events     workqueue.RateLimitingInterface
+	targets    map[string]*targetHealth
+	generation int
+}
+
+type targetHealth struct {
+	address    string
+	host       string
+	port       int
+	check      types.HealthCheck
+	healthy    bool
+	successes  int
+	failures   int
+	generation int
+	stopper    chan struct{}
+}
+
+func newHealthChecker(events workqueue.RateLimitingInterface) *HealthChecker {
+	return &HealthChecker{
+		events:  events,
+		targets: map[string]*targetHealth{},
+	}
+}
+
+func withHealthCheckDefaults(check types.HealthCheck, targetPort int) types.HealthCheck {
+	if check.Port == 0 {
+		check.Port = targetPort
+	}
+	if check.Protocol == "http" && check.Path == "" {
+		check.Path = "/"
+	}
+	if check.IntervalSeconds == 0 {
+		check.IntervalSeconds = types.HealthCheckDefaultInterval
+	}
+	if check.TimeoutSeconds == 0 {
+		check.TimeoutSeconds = types.HealthCheckDefaultTimeout
+	}
+	if check.HealthyThreshold == 0 {
+		check.HealthyThreshold = types.HealthCheckDefaultHealthyThreshold
+	}
+	if check.UnhealthyThreshold == 0 {
+		check.UnhealthyThreshold = types.HealthCheckDefaultUnhealthyThreshold
+	}
+	return check
+}
+
+// Marks the start of a bridge configuration pass; targets not looked
+// up again before sweep() is called are no longer probed.
+func (h *HealthChecker) begin() {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.generation++
+}
+
+func (h *HealthChecker) sweep() {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for key, target := range h.targets {
+		if target.generation != h.generation {
+			close(target.stopper)
+			delete(h.targets, key)
+		}
+	}
+}
+
+// Returns whether the target of the address is currently considered
+// healthy, starting to probe it if it was not already being probed
+func (h *HealthChecker) isHealthy(address string, check *types.HealthCheck, host string, targetPort int) bool {
+	if h == nil || check == nil {
+		return true
+	}
+	actual := withHealthCheckDefaults(*check, targetPort)
+	key := address + "/" + net.JoinHostPort(host, strconv.Itoa(targetPort))
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	target, ok := h.targets[key]
+	if ok && target.check != actual {
+		close(target.stopper)
+		ok = false
+	}
+	if !ok {
+		target = &targetHealth{
+			address: address,
+			host:    host,
+			port:    targetPort,
+			check:   actual,
+			stopper: make(chan struct{}),
+		}
+		h.targets[key] = target
+		go h.run(target)
+	}
+	target.generation = h.generation
+	return target.healthy
+}
+
+func (h *HealthChecker) run(target *targetHealth) {
+	ticker := time.NewTicker(time.Duration(target.check.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		err := probeTarget(target.host, target.check)
+		if h.record(target, err) {
+			h.events.Add("healthcheck@" + target.address)
+		}
+		select {
+		case <-target.stopper:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Updates the state of the target with the result of a probe, returning
+// true if the target became healthy or unhealthy as a result
+func (h *HealthChecker) record(target *targetHealth, err error) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err == nil {
+		target.failures = 0
+		target.successes++
+		if !target.healthy && target.successes >= target.check.HealthyThreshold {
+			target.healthy = true
+			event.Recordf(HealthCheckEvent, "Target %s for %s passed health check", target.host, target.address)
+			return true
+		}
+	} else {
+		target.successes = 0
+		target.failures++
+		if target.healthy && target.failures >= target.check.UnhealthyThreshold {
+			target.healthy = false
+			event.Recordf(HealthCheckEvent, "Target %s for %s failed health check: %s", target.host, target.address, err)
+			return true
+		}
+	}
+	return false
+}
+
+func probeTarget(host string, check types.HealthCheck) error {
+	timeout := time.Duration(check.TimeoutSeconds) * time.Second
+	hostPort := net.JoinHostPort(host, strconv.Itoa(check.Port))
+	if check.Protocol == "grpc" {
+		return probeGrpc(hostPort, check.Service, timeout)
+	}
+	if check.Protocol == "http" {
+		client := &http.Client{Timeout: timeout}
+		resp, err := client.Get("http://" + hostPort + check.Path)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("Health check returned %s", resp.Status)
+		}
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", hostPort, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
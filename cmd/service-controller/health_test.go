@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"gotest.tools/assert"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+func TestHealthCheckThresholds(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	h := newHealthChecker(workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()))
+	target := &targetHealth{
+		address: "myservice",
+		host:    "10.0.0.1",
+		check:   withHealthCheckDefaults(types.HealthCheck{Protocol: "tcp", HealthyThreshold: 2}, 8080),
+	}
+	if target.check.Port != 8080 || target.check.UnhealthyThreshold != types.HealthCheckDefaultUnhealthyThreshold {
+		t.Errorf("Defaults not applied: %v", target.check)
+	}
+	failed := fmt.Errorf("connection refused")
+	steps := []struct {
+		err     error
+		changed bool
+		healthy bool
+	}{
+		{nil, false, false},
+		{nil, true, true},
+		{failed, false, true},
+		{nil, false, true},
+		{failed, false, true},
+		{failed, false, true},
+		{failed, true, false},
+		{failed, false, false},
+	}
+	for i, step := range steps {
+		changed := h.record(target, step.err)
+		if changed != step.changed || target.healthy != step.healthy {
+			t.Errorf("Step %d: expected changed=%t healthy=%t, got changed=%t healthy=%t", i, step.changed, step.healthy, changed, target.healthy)
+		}
+	}
+}
+
+func TestHealthCheckSweep(t *testing.T) {
+	h := newHealthChecker(workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()))
+	if !h.isHealthy("myservice", nil, "10.0.0.1", 8080) {
+		t.Errorf("Expected target with no health check to be healthy")
+	}
+	var none *HealthChecker
+	if !none.isHealthy("myservice", &types.HealthCheck{Protocol: "tcp"}, "10.0.0.1", 8080) {
+		t.Errorf("Expected target to be healthy when health checks are disabled")
+	}
+
+	check := &types.HealthCheck{Protocol: "tcp", IntervalSeconds: 3600}
+	h.begin()
+	h.isHealthy("myservice", check, "127.0.0.1", 1)
+	h.isHealthy("myservice", check, "127.0.0.2", 1)
+	h.sweep()
+	if len(h.targets) != 2 {
+		t.Errorf("Expected 2 targets, got %d", len(h.targets))
+	}
+	h.begin()
+	h.isHealthy("myservice", check, "127.0.0.1", 1)
+	h.sweep()
+	if len(h.targets) != 1 {
+		t.Errorf("Expected 1 target after sweep, got %d", len(h.targets))
+	}
+}
+
+func TestProbeTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	host, portString, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portString)
+
+	if err := probeTarget(host, withHealthCheckDefaults(types.HealthCheck{Protocol: "tcp"}, port)); err != nil {
+		t.Errorf("Expected tcp check to pass: %s", err)
+	}
+	if err := probeTarget(host, withHealthCheckDefaults(types.HealthCheck{Protocol: "http", Path: "/healthz"}, port)); err != nil {
+		t.Errorf("Expected http check to pass: %s", err)
+	}
+	if err := probeTarget(host, withHealthCheckDefaults(types.HealthCheck{Protocol: "http", Path: "/other"}, port)); err == nil {
+		t.Errorf("Expected http check of unknown path to fail")
+	}
+}
+
+func TestProbeGrpcTarget(t *testing.T) {
+	statuses := map[string]uint64{
+		"":       grpcHealthServing,
+		"orders": grpcHealthServing,
+		"stock":  grpcHealthNotServing,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		message, err := grpcMessage(body)
+		if r.URL.Path != grpcHealthCheckPath || r.ProtoMajor != 2 || err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		service := ""
+		if len(message) > 2 {
+			service = string(message[2:])
+		}
+		status, ok := statuses[service]
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		if !ok {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+		w.Write(grpcFrame([]byte{0x08, byte(status)}))
+		w.Header().Set("Grpc-Status", "0")
+	})
+	server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	host, portString, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portString)
+
+	testcases := []struct {
+		service       string
+		expectedError string
+	}{
+		{"", ""},
+		{"orders", ""},
+		{"stock", "Health check reported NOT_SERVING"},
+		{"billing", "Health check failed with gRPC status 5: unknown service"},
+	}
+	for _, c := range testcases {
+		err := probeTarget(host, withHealthCheckDefaults(types.HealthCheck{Protocol: "grpc", Service: c.service}, port))
+		if c.expectedError == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.Error(t, err, c.expectedError)
+		}
+	}
+}
+
+func TestDecodeHealthCheckResponse(t *testing.T) {
+	status, err := decodeHealthCheckResponse([]byte{})
+	assert.NilError(t, err)
+	assert.Equal(t, status, grpcHealthUnknown)
+	// unknown fields are skipped
+	status, err = decodeHealthCheckResponse([]byte{0x12, 0x02, 'o', 'k', 0x08, 0x01})
+	assert.NilError(t, err)
+	assert.Equal(t, status, grpcHealthServing)
+	_, err = decodeHealthCheckResponse([]byte{0x12, 0x05, 'o'})
+	assert.Error(t, err, "Invalid health check response")
+	assert.DeepEqual(t, encodeHealthCheckRequest("orders"), append([]byte{0x0a, 0x06}, "orders"...))
+}
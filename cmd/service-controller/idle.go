@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	ServiceIdleEvent string = "ServiceIdleEvent"
+	ServiceIdleError string = "ServiceIdleError"
+)
+
+// ServiceActivityConfigMap records when traffic for each service with an
+// idle policy was last seen, so that the idle time survives restarts
+const ServiceActivityConfigMap string = "skupper-service-activity"
+
+const idleCheckInterval = time.Hour
+
+// Periodically checks whether the services defined in this site that
+// have an idle policy have had any traffic, across all sites, since they
+// were last checked, warning of and optionally unexposing those that
+// have been idle for too long
+type IdleMonitor struct {
+	vanClient *client.VanClient
+	agentPool *qdr.AgentPool
+	// the traffic totals seen when last checked; traffic is only
+	// detected once there is something to compare with
+	totals map[string]int
+	warned map[string]bool
+}
+
+func newIdleMonitor(cli *client.VanClient, config *tls.Config) *IdleMonitor {
+	return &IdleMonitor{
+		vanClient: cli,
+		agentPool: qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+		totals:    map[string]int{},
+		warned:    map[string]bool{},
+	}
+}
+
+func (m *IdleMonitor) start(stopCh <-chan struct{}) {
+	go wait.Until(m.check, idleCheckInterval, stopCh)
+}
+
+// serviceTraffic returns, for each address, a total that changes whenever
+// traffic for it is handled, and whether any connections for it are open
+func serviceTraffic(requests [][]qdr.HttpRequestInfo, connections [][]qdr.TcpConnection) (map[string]int, map[string]bool) {
+	totals := map[string]int{}
+	open := map[string]bool{}
+	for _, site := range requests {
+		for _, r := range site {
+			totals[r.Address] += r.Requests
+		}
+	}
+	for _, site := range connections {
+		for _, c := range site {
+			totals[c.Address] += c.BytesIn + c.BytesOut
+			open[c.Address] = true
+		}
+	}
+	return totals, open
+}
+
+// idleServices updates the activity recorded for the services and returns
+// those that have been idle for longer than their policy allows. Records
+// for services that no longer have a policy are dropped.
+func (m *IdleMonitor) idleServices(services []*types.ServiceInterface, totals map[string]int, open map[string]bool, activity map[string]string, now time.Time) ([]*types.ServiceInterface, bool) {
+	changed := false
+	idle := []*types.ServiceInterface{}
+	policies := map[string]bool{}
+	for _, service := range services {
+		if service.IdlePolicy == nil || service.Origin != "" {
+			continue
+		}
+		address := service.Address
+		policies[address] = true
+		previous, seen := m.totals[address]
+		m.totals[address] = totals[address]
+		last, err := time.Parse(time.RFC3339, activity[address])
+		if open[address] || (seen && previous != totals[address]) || err != nil {
+			activity[address] = now.UTC().Format(time.RFC3339)
+			m.warned[address] = false
+			changed = true
+			continue
+		}
+		if now.Sub(last) >= time.Duration(service.IdlePolicy.Days)*24*time.Hour {
+			idle = append(idle, service)
+		}
+	}
+	for address := range activity {
+		if !policies[address] {
+			delete(activity, address)
+			delete(m.totals, address)
+			delete(m.warned, address)
+			changed = true
+		}
+	}
+	return idle, changed
+}
+
+func (m *IdleMonitor) getActivity() (*corev1.ConfigMap, error) {
+	cm, err := m.vanClient.KubeClient.CoreV1().ConfigMaps(m.vanClient.Namespace).Get(ServiceActivityConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ServiceActivityConfigMap,
+			},
+		}
+		if owner := getOwnerReference(); owner != nil {
+			cm.ObjectMeta.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		cm, err = m.vanClient.KubeClient.CoreV1().ConfigMaps(m.vanClient.Namespace).Create(cm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	return cm, nil
+}
+
+func (m *IdleMonitor) check() {
+	services, err := m.vanClient.ServiceInterfaceList(context.Background())
+	if err != nil {
+		event.Recordf(ServiceIdleError, "Could not retrieve service definitions: %s", err)
+		return
+	}
+	withPolicy := false
+	for _, service := range services {
+		withPolicy = withPolicy || (service.IdlePolicy != nil && service.Origin == "")
+	}
+	if !withPolicy && len(m.totals) == 0 {
+		return
+	}
+	totals, open, err := m.getTraffic()
+	if err != nil {
+		event.Recordf(ServiceIdleError, "Could not retrieve service traffic: %s", err)
+		return
+	}
+	activity, err := m.getActivity()
+	if err != nil {
+		event.Recordf(ServiceIdleError, "Could not retrieve service activity: %s", err)
+		return
+	}
+	idle, changed := m.idleServices(services, totals, open, activity.Data, time.Now())
+	for _, service := range idle {
+		if service.IdlePolicy.Unexpose {
+			event.Recordf(ServiceIdleEvent, "Unexposing %s as it has had no traffic for %d days", service.Address, service.IdlePolicy.Days)
+			if err := m.vanClient.ServiceInterfaceRemove(context.Background(), service.Address); err != nil {
+				event.Recordf(ServiceIdleError, "Could not unexpose %s: %s", service.Address, err)
+			}
+		} else if !m.warned[service.Address] {
+			event.Recordf(ServiceIdleEvent, "Warning: %s has had no traffic for %d days", service.Address, service.IdlePolicy.Days)
+			m.warned[service.Address] = true
+		}
+	}
+	if changed {
+		if _, err := m.vanClient.KubeClient.CoreV1().ConfigMaps(m.vanClient.Namespace).Update(activity); err != nil {
+			event.Recordf(ServiceIdleError, "Could not record service activity: %s", err)
+		}
+	}
+}
+
+func (m *IdleMonitor) getTraffic() (map[string]int, map[string]bool, error) {
+	agent, err := m.agentPool.Get()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not get management agent: %s", err)
+	}
+	defer m.agentPool.Put(agent)
+	routers, err := agent.GetAllRouters()
+	if err != nil {
+		return nil, nil, err
+	}
+	requests, err := agent.GetHttpRequestInfo(routers)
+	if err != nil {
+		return nil, nil, err
+	}
+	connections, err := agent.GetTcpConnections(routers)
+	if err != nil {
+		return nil, nil, err
+	}
+	totals, open := serviceTraffic(requests, connections)
+	return totals, open, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestServiceTraffic(t *testing.T) {
+	totals, open := serviceTraffic([][]qdr.HttpRequestInfo{
+		{{Address: "web", Requests: 3}},
+		{{Address: "web", Requests: 2}, {Address: "api", Requests: 1}},
+	}, [][]qdr.TcpConnection{
+		{{Address: "db", BytesIn: 10, BytesOut: 20}},
+	})
+	assert.DeepEqual(t, totals, map[string]int{"web": 5, "api": 1, "db": 30})
+	assert.DeepEqual(t, open, map[string]bool{"db": true})
+}
+
+func TestIdleServices(t *testing.T) {
+	m := &IdleMonitor{totals: map[string]int{}, warned: map[string]bool{}}
+	web := &types.ServiceInterface{Address: "web", IdlePolicy: &types.IdlePolicy{Days: 2}}
+	db := &types.ServiceInterface{Address: "db", IdlePolicy: &types.IdlePolicy{Days: 1, Unexpose: true}}
+	remote := &types.ServiceInterface{Address: "remote", Origin: "other-site", IdlePolicy: &types.IdlePolicy{Days: 1}}
+	plain := &types.ServiceInterface{Address: "plain"}
+	services := []*types.ServiceInterface{web, db, remote, plain}
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	activity := map[string]string{"removed": start.Format(time.RFC3339)}
+
+	// the first check records when the services started to be observed
+	idle, changed := m.idleServices(services, map[string]int{"web": 5}, map[string]bool{}, activity, start)
+	assert.Equal(t, len(idle), 0)
+	assert.Assert(t, changed)
+	assert.DeepEqual(t, activity, map[string]string{"web": "2021-03-01T12:00:00Z", "db": "2021-03-01T12:00:00Z"})
+
+	// requests for web are seen, db has been idle for a day
+	idle, changed = m.idleServices(services, map[string]int{"web": 7}, map[string]bool{}, activity, start.Add(24*time.Hour))
+	assert.DeepEqual(t, idle, []*types.ServiceInterface{db})
+	assert.Assert(t, changed)
+	assert.Equal(t, activity["web"], "2021-03-02T12:00:00Z")
+
+	// an open connection counts as traffic
+	idle, changed = m.idleServices(services, map[string]int{"web": 7, "db": 0}, map[string]bool{"db": true}, activity, start.Add(48*time.Hour))
+	assert.Equal(t, len(idle), 0)
+	assert.Assert(t, changed)
+	assert.Equal(t, activity["db"], "2021-03-03T12:00:00Z")
+
+	idle, changed = m.idleServices(services, map[string]int{"web": 7, "db": 0}, map[string]bool{}, activity, start.Add(96*time.Hour))
+	assert.DeepEqual(t, idle, []*types.ServiceInterface{web, db})
+	assert.Assert(t, !changed)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+const LinkActivityError string = "LinkActivityError"
+
+const (
+	linkActivityInterval = 10 * time.Minute
+	// how out of date the time recorded for a link may get before it is
+	// updated, to avoid writing the secret on every check
+	linkActivityResolution = time.Hour
+)
+
+// Periodically records on the secret of each connected link when it was
+// last seen active, so that links that have not been used in a long time
+// can be told apart and pruned
+type LinkActivityMonitor struct {
+	vanClient *client.VanClient
+}
+
+func newLinkActivityMonitor(cli *client.VanClient) *LinkActivityMonitor {
+	return &LinkActivityMonitor{
+		vanClient: cli,
+	}
+}
+
+func (m *LinkActivityMonitor) start(stopCh <-chan struct{}) {
+	go wait.Until(m.check, linkActivityInterval, stopCh)
+}
+
+// activeLinks returns the names of the connected links whose recorded
+// activity is out of date
+func activeLinks(tokens []*types.TokenInfo, now time.Time) []string {
+	names := []string{}
+	for _, token := range tokens {
+		if token.Type == types.TypeToken && token.Connected && now.Sub(token.LastConnected) >= linkActivityResolution {
+			names = append(names, token.Name)
+		}
+	}
+	return names
+}
+
+func (m *LinkActivityMonitor) check() {
+	tokens, err := m.vanClient.TokenList(context.Background())
+	if err != nil {
+		event.Recordf(LinkActivityError, "Could not retrieve links: %s", err)
+		return
+	}
+	now := time.Now()
+	for _, name := range activeLinks(tokens, now) {
+		if err := m.record(name, now); err != nil {
+			event.Recordf(LinkActivityError, "Could not record activity of link %s: %s", name, err)
+		}
+	}
+}
+
+func (m *LinkActivityMonitor) record(name string, now time.Time) error {
+	secrets := m.vanClient.KubeClient.CoreV1().Secrets(m.vanClient.Namespace)
+	secret, err := secrets.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if secret.ObjectMeta.Annotations == nil {
+		secret.ObjectMeta.Annotations = map[string]string{}
+	}
+	secret.ObjectMeta.Annotations[types.LinkLastConnected] = now.UTC().Format(time.RFC3339)
+	_, err = secrets.Update(secret)
+	return err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+)
+
+func TestActiveLinks(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tokens := []*types.TokenInfo{
+		{Name: "never", Type: types.TypeToken, Connected: true},
+		{Name: "recent", Type: types.TypeToken, Connected: true, LastConnected: now.Add(-10 * time.Minute)},
+		{Name: "stale", Type: types.TypeToken, Connected: true, LastConnected: now.Add(-2 * time.Hour)},
+		{Name: "down", Type: types.TypeToken, LastConnected: now.Add(-2 * time.Hour)},
+		{Name: "request", Type: types.TypeTokenRequest},
+	}
+	assert.DeepEqual(t, activeLinks(tokens, now), []string{"never", "stale"})
+}
+
+func TestRecordLinkActivity(t *testing.T) {
+	// the controller is only allowed to record the activity if its role
+	// lets it update the secrets of the links
+	assert.Assert(t, policyAllows(types.ControllerPolicyRule, "secrets", "get"), "controller cannot get secrets")
+	assert.Assert(t, policyAllows(types.ControllerPolicyRule, "secrets", "update"), "controller cannot update secrets")
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "link1",
+			Namespace: "test",
+			Labels:    map[string]string{types.SkupperTypeQualifier: types.TypeToken},
+		},
+	})
+	m := newLinkActivityMonitor(&client.VanClient{Namespace: "test", KubeClient: kubeClient})
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	assert.NilError(t, m.record("link1", now))
+	secret, err := kubeClient.CoreV1().Secrets("test").Get("link1", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, secret.ObjectMeta.Annotations[types.LinkLastConnected], "2021-03-01T12:00:00Z")
+	assert.ErrorContains(t, m.record("link2", now), "not found")
+}
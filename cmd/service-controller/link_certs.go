@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+const (
+	LinkCertExpiring string = "LinkCertExpiring"
+	LinkCertError    string = "LinkCertError"
+)
+
+const linkCertCheckInterval = time.Hour
+
+// Periodically checks the certificates the links of this site are made
+// with, warning of those that expire within the configured number of
+// days so that the links can be recreated from new tokens in time
+type LinkCertMonitor struct {
+	vanClient   *client.VanClient
+	warningDays int
+	warned      map[string]bool
+}
+
+func newLinkCertMonitor(cli *client.VanClient, warningDays int) *LinkCertMonitor {
+	return &LinkCertMonitor{
+		vanClient:   cli,
+		warningDays: warningDays,
+		warned:      map[string]bool{},
+	}
+}
+
+func linkCertWarningDaysFromEnv() (int, error) {
+	value := os.Getenv("SKUPPER_LINK_CERT_WARNING_DAYS")
+	if value == "" {
+		return types.DefaultLinkCertWarningDays, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("Invalid link certificate warning days %q", value)
+	}
+	return days, nil
+}
+
+func (m *LinkCertMonitor) start(stopCh <-chan struct{}) {
+	go wait.Until(m.check, linkCertCheckInterval, stopCh)
+}
+
+func (m *LinkCertMonitor) check() {
+	expiries, err := m.vanClient.LinkCertificateExpiries(context.Background())
+	if err != nil {
+		event.Recordf(LinkCertError, "Could not retrieve link certificates: %s", err)
+		return
+	}
+	now := time.Now()
+	expiring := map[string]bool{}
+	for _, name := range expiringLinks(expiries, m.warningDays, now) {
+		expiring[name] = true
+		if m.warned[name] {
+			continue
+		}
+		if days := daysRemaining(expiries[name], now); days < 0 {
+			event.Recordf(LinkCertExpiring, "Certificates for link %s expired at %s", name, expiries[name].Format(time.RFC3339))
+		} else {
+			event.Recordf(LinkCertExpiring, "Certificates for link %s expire in %d days, at %s", name, days, expiries[name].Format(time.RFC3339))
+		}
+	}
+	// links that are recreated with new certificates are warned of again
+	// when those are about to expire
+	m.warned = expiring
+}
+
+// daysRemaining returns the whole days until expiry, which are negative
+// once it has passed
+func daysRemaining(expiry time.Time, now time.Time) int {
+	remaining := expiry.Sub(now)
+	if remaining < 0 {
+		return -1 - int(-remaining.Hours()/24)
+	}
+	return int(remaining.Hours() / 24)
+}
+
+// expiringLinks returns, in order, the names of the links whose
+// certificates expire within the given number of days
+func expiringLinks(expiries map[string]time.Time, warningDays int, now time.Time) []string {
+	names := []string{}
+	for name, expiry := range expiries {
+		if daysRemaining(expiry, now) < warningDays {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestLinkCertExpiry(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	assert.Equal(t, daysRemaining(now.Add(45*day), now), 45)
+	assert.Equal(t, daysRemaining(now.Add(day-time.Minute), now), 0)
+	assert.Equal(t, daysRemaining(now.Add(-time.Minute), now), -1)
+	assert.Equal(t, daysRemaining(now.Add(-2*day), now), -3)
+
+	expiries := map[string]time.Time{
+		"conn1": now.Add(90 * day),
+		"conn2": now.Add(10 * day),
+		"conn3": now.Add(-day),
+	}
+	assert.DeepEqual(t, expiringLinks(expiries, 30, now), []string{"conn2", "conn3"})
+	assert.DeepEqual(t, expiringLinks(expiries, 0, now), []string{"conn3"})
+
+	out := &bytes.Buffer{}
+	writeMetrics(out, &siteMetrics{linkCertDays: map[string]int{"conn2": 10, "conn1": 90}, linkCertWarningDays: 30})
+	assert.Assert(t, strings.Contains(out.String(), "skupper_link_certificate_expiry_days{link=\"conn1\"} 90\nskupper_link_certificate_expiry_days{link=\"conn2\"} 10\n"))
+	assert.Assert(t, strings.Contains(out.String(), "skupper_link_certificate_expiring{link=\"conn1\"} 0\nskupper_link_certificate_expiring{link=\"conn2\"} 1\n"))
+}
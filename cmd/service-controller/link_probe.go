@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/data"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// Sizes either side of common path MTUs, up to several router frames
+var linkProbeSizes = []int{512, 1024, 1400, 1500, 4096, 9000, 16384, 65536}
+
+// Sends probe messages of increasing size to each directly linked site,
+// which echoes them back, to detect links over which large frames are
+// dropped
+func probeLinks(pool *qdr.AgentPool, siteId string) ([]types.LinkProbeResult, error) {
+	agent, err := pool.Get()
+	if err != nil {
+		return nil, fmt.Errorf("Could not get management agent: %s", err)
+	}
+	routers, err := agent.GetAllRouters()
+	if err != nil {
+		pool.Put(agent)
+		return nil, fmt.Errorf("Error retrieving routers: %s", err)
+	}
+	connections, err := agent.GetConnections()
+	pool.Put(agent)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving connections: %s", err)
+	}
+	_, peers, order := groupPeerRouters(routers, siteId)
+	results := []types.LinkProbeResult{}
+	for _, peer := range order {
+		if !data.IsLinkedTo(connections, peers[peer]) {
+			continue
+		}
+		probes := map[int]error{}
+		for _, size := range linkProbeSizes {
+			probes[size] = sendLinkProbe(pool, peer, size)
+		}
+		results = append(results, analyseLinkProbe(peer, linkProbeSizes, probes))
+	}
+	return results, nil
+}
+
+func sendLinkProbe(pool *qdr.AgentPool, siteId string, size int) error {
+	// a failed request closes the agent, so each probe gets its own
+	agent, err := pool.Get()
+	if err != nil {
+		return err
+	}
+	defer pool.Put(agent)
+	response, err := agent.Request(&qdr.Request{
+		Address: getSiteQueryAddress(siteId),
+		Version: client.Version,
+		Type:    LinkProbe,
+		Body:    strings.Repeat("x", size),
+	})
+	if err != nil {
+		return err
+	}
+	if response.Type != LinkProbe {
+		return fmt.Errorf("Site %s does not support link probes", siteId)
+	}
+	if len(response.Body) != size {
+		return fmt.Errorf("Probe of %d bytes was echoed back with %d bytes", size, len(response.Body))
+	}
+	return nil
+}
+
+func analyseLinkProbe(siteId string, sizes []int, probes map[int]error) types.LinkProbeResult {
+	result := types.LinkProbeResult{
+		SiteId:    siteId,
+		Delivered: []int{},
+		Failed:    []int{},
+	}
+	errors := map[string]bool{}
+	for _, size := range sizes {
+		if err := probes[size]; err != nil {
+			result.Failed = append(result.Failed, size)
+			if !errors[err.Error()] {
+				errors[err.Error()] = true
+				result.Errors = append(result.Errors, err.Error())
+			}
+		} else {
+			result.Delivered = append(result.Delivered, size)
+		}
+	}
+	if len(result.Failed) == 0 {
+		return result
+	}
+	if len(result.Delivered) == 0 {
+		result.Problem = "No probe messages were echoed back"
+		return result
+	}
+	smallestFailed := result.Failed[0]
+	largestDelivered := result.Delivered[len(result.Delivered)-1]
+	if largestDelivered < smallestFailed {
+		result.Problem = fmt.Sprintf("Messages of %d bytes or more were dropped while smaller messages were delivered, which is typical of an MTU or fragmentation problem on the path", smallestFailed)
+		frameSize := largestDelivered
+		if frameSize > types.RouterMaxFrameSizeDefault {
+			frameSize = types.RouterMaxFrameSizeDefault
+		}
+		result.Suggestion = fmt.Sprintf("Reduce the router max frame size on both sites (xp-router-max-frame-size in the skupper-site ConfigMap) to %d or less", frameSize)
+	} else {
+		result.Problem = "Some probe messages were dropped regardless of size, which suggests an unreliable link rather than an MTU problem"
+	}
+	return result
+}
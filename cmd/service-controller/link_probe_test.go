@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyseLinkProbe(t *testing.T) {
+	sizes := []int{512, 1500, 9000, 65536}
+	timeout := fmt.Errorf("Failed to receive reponse: context deadline exceeded")
+	tests := []struct {
+		name       string
+		probes     map[int]error
+		delivered  []int
+		failed     []int
+		problem    string
+		suggestion string
+	}{
+		{
+			name:      "all delivered",
+			probes:    map[int]error{},
+			delivered: []int{512, 1500, 9000, 65536},
+			failed:    []int{},
+		},
+		{
+			name:       "mtu",
+			probes:     map[int]error{9000: timeout, 65536: timeout},
+			delivered:  []int{512, 1500},
+			failed:     []int{9000, 65536},
+			problem:    "Messages of 9000 bytes or more were dropped",
+			suggestion: "to 1500 or less",
+		},
+		{
+			name:      "intermittent",
+			probes:    map[int]error{1500: timeout},
+			delivered: []int{512, 9000, 65536},
+			failed:    []int{1500},
+			problem:   "regardless of size",
+		},
+		{
+			name:      "unreachable",
+			probes:    map[int]error{512: timeout, 1500: timeout, 9000: timeout, 65536: timeout},
+			delivered: []int{},
+			failed:    []int{512, 1500, 9000, 65536},
+			problem:   "No probe messages were echoed back",
+		},
+	}
+	for _, test := range tests {
+		result := analyseLinkProbe("site-b", sizes, test.probes)
+		if !reflect.DeepEqual(result.Delivered, test.delivered) || !reflect.DeepEqual(result.Failed, test.failed) {
+			t.Errorf("%s: expected delivered %v and failed %v, got %v and %v", test.name, test.delivered, test.failed, result.Delivered, result.Failed)
+		}
+		if !strings.Contains(result.Problem, test.problem) || (test.problem == "" && result.Problem != "") {
+			t.Errorf("%s: expected problem %q, got %q", test.name, test.problem, result.Problem)
+		}
+		if !strings.Contains(result.Suggestion, test.suggestion) || (test.suggestion == "" && result.Suggestion != "") {
+			t.Errorf("%s: expected suggestion %q, got %q", test.name, test.suggestion, result.Suggestion)
+		}
+		if len(test.failed) > 0 && len(result.Errors) != 1 {
+			t.Errorf("%s: expected a single distinct error, got %v", test.name, result.Errors)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	corev1informer "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/informers/internalinterfaces"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/tunnel"
+)
+
+const (
+	LinkProxyEvent string = "LinkProxy"
+	LinkProxyError string = "LinkProxyError"
+)
+
+const linkProxyDialTimeout = 30 * time.Second
+
+type linkForwarder struct {
+	listener net.Listener
+	target   string
+	proxy    types.LinkProxy
+}
+
+func (f *linkForwarder) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Forwards the links that are made through an HTTP proxy. The router
+// reaches the other site of such a link through a service that selects
+// the controller, which relays each connection to the proxy.
+type LinkProxyForwarder struct {
+	vanClient  *client.VanClient
+	informer   cache.SharedIndexInformer
+	changes    chan bool
+	forwarders map[string]*linkForwarder
+}
+
+func newLinkProxyForwarder(cli *client.VanClient) *LinkProxyForwarder {
+	informer := corev1informer.NewFilteredServiceInformer(
+		cli.KubeClient,
+		cli.Namespace,
+		time.Second*30,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		internalinterfaces.TweakListOptionsFunc(func(options *metav1.ListOptions) {
+			options.LabelSelector = types.SkupperTypeQualifier + "=" + types.TypeLinkProxy
+		}))
+	f := &LinkProxyForwarder{
+		vanClient:  cli,
+		informer:   informer,
+		changes:    make(chan bool, 1),
+		forwarders: map[string]*linkForwarder{},
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			f.changed()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			f.changed()
+		},
+		DeleteFunc: func(obj interface{}) {
+			f.changed()
+		},
+	})
+	return f
+}
+
+func (f *LinkProxyForwarder) changed() {
+	select {
+	case f.changes <- true:
+	default:
+	}
+}
+
+func (f *LinkProxyForwarder) start(stopCh <-chan struct{}) {
+	go f.informer.Run(stopCh)
+	go f.run(stopCh)
+}
+
+func (f *LinkProxyForwarder) run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, f.informer.HasSynced) {
+		return
+	}
+	for {
+		select {
+		case <-f.changes:
+			f.reconcile()
+		case <-stopCh:
+			for name := range f.forwarders {
+				f.stop(name)
+			}
+			return
+		}
+	}
+}
+
+func (f *LinkProxyForwarder) stop(name string) {
+	f.forwarders[name].listener.Close()
+	delete(f.forwarders, name)
+}
+
+// reconcile forwards the connections of each link that uses a proxy, and
+// points its service at the port they are forwarded from
+func (f *LinkProxyForwarder) reconcile() {
+	current := map[string]bool{}
+	for _, obj := range f.informer.GetStore().List() {
+		service, ok := obj.(*corev1.Service)
+		if !ok {
+			continue
+		}
+		current[service.ObjectMeta.Name] = true
+		f.forward(service)
+	}
+	for name := range f.forwarders {
+		if !current[name] {
+			event.Recordf(LinkProxyEvent, "Stopped forwarding %s", name)
+			f.stop(name)
+		}
+	}
+}
+
+func (f *LinkProxyForwarder) forward(service *corev1.Service) {
+	name := service.ObjectMeta.Name
+	link := service.ObjectMeta.Annotations[types.LinkProxyLinkAnnotation]
+	target := service.ObjectMeta.Annotations[types.LinkProxyTargetAnnotation]
+	secret, err := f.vanClient.KubeClient.CoreV1().Secrets(f.vanClient.Namespace).Get(link, metav1.GetOptions{})
+	if err != nil {
+		event.Recordf(LinkProxyError, "Could not retrieve link %s: %s", link, err)
+		return
+	}
+	proxy, err := client.GetLinkProxy(secret)
+	if err != nil {
+		event.Recordf(LinkProxyError, "%s", err)
+		return
+	}
+	if proxy.IsEmpty() {
+		event.Recordf(LinkProxyError, "Link %s has no proxy", link)
+		return
+	}
+	forwarder, ok := f.forwarders[name]
+	if ok && (forwarder.target != target || forwarder.proxy != proxy) {
+		f.stop(name)
+		ok = false
+	}
+	if !ok {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			event.Recordf(LinkProxyError, "Could not listen for link %s: %s", link, err)
+			return
+		}
+		forwarder = &linkForwarder{
+			listener: listener,
+			target:   target,
+			proxy:    proxy,
+		}
+		p := &tunnel.Proxy{
+			Address:  proxy.Address(),
+			User:     proxy.User,
+			Password: proxy.Password,
+			Timeout:  linkProxyDialTimeout,
+		}
+		go p.Forward(listener, target)
+		f.forwarders[name] = forwarder
+		event.Recordf(LinkProxyEvent, "Forwarding link %s to %s through proxy %s on port %d", link, target, proxy.Address(), forwarder.port())
+	}
+	if len(service.Spec.Ports) == 0 || service.Spec.Ports[0].TargetPort.IntValue() == forwarder.port() {
+		return
+	}
+	updated := service.DeepCopy()
+	updated.Spec.Ports[0].TargetPort = intstr.FromInt(forwarder.port())
+	if _, err := f.vanClient.KubeClient.CoreV1().Services(f.vanClient.Namespace).Update(updated); err != nil {
+		event.Recordf(LinkProxyError, "Could not update service %s to port %s: %s", name, strconv.Itoa(forwarder.port()), err)
+	}
+}
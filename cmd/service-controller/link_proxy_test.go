@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+func TestLinkProxyForwarderReconcile(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "link1",
+			Namespace:   "test",
+			Annotations: map[string]string{types.LinkProxyAnnotation: "proxy.example.com:3128"},
+		},
+		Data: map[string][]byte{"proxy-user": []byte("alice"), "proxy-password": []byte("secret")},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      client.LinkProxyServiceName("link1"),
+			Namespace: "test",
+			Labels:    map[string]string{types.SkupperTypeQualifier: types.TypeLinkProxy},
+			Annotations: map[string]string{
+				types.LinkProxyLinkAnnotation:   "link1",
+				types.LinkProxyTargetAnnotation: "east.example.com:55671",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "link", Port: 55671, TargetPort: intstr.FromInt(55671)}},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(secret, service)
+	f := newLinkProxyForwarder(&client.VanClient{Namespace: "test", KubeClient: kubeClient})
+
+	assert.NilError(t, f.informer.GetStore().Add(service))
+	f.reconcile()
+	forwarder, ok := f.forwarders[service.ObjectMeta.Name]
+	assert.Assert(t, ok)
+	assert.Equal(t, forwarder.target, "east.example.com:55671")
+	assert.Equal(t, forwarder.proxy, types.LinkProxy{Host: "proxy.example.com", Port: "3128", User: "alice", Password: "secret"})
+	updated, err := kubeClient.CoreV1().Services("test").Get(service.ObjectMeta.Name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, updated.Spec.Ports[0].TargetPort.IntValue(), forwarder.port())
+
+	// a link that is removed is no longer forwarded
+	assert.NilError(t, f.informer.GetStore().Delete(service))
+	f.reconcile()
+	assert.Equal(t, len(f.forwarders), 0)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -78,7 +79,10 @@ func main() {
 	stopCh := SetupSignalHandler()
 
 	// todo, get context from env?
-	cli, err := client.NewClient(namespace, "", "")
+	cli, err := client.NewClientWithOptions(client.ClientOptions{
+		Namespace: namespace,
+		UserAgent: "skupper-service-controller/" + client.Version,
+	})
 	if err != nil {
 		log.Fatal("Error getting van client", err.Error())
 	}
@@ -88,9 +92,30 @@ func main() {
 		log.Fatal("Error getting tls config", err.Error())
 	}
 
+	watermarks, err := watermarksFromEnv()
+	if err != nil {
+		log.Fatal("Error getting router watermarks", err.Error())
+	}
+
+	linkCertWarningDays, err := linkCertWarningDaysFromEnv()
+	if err != nil {
+		log.Fatal("Error getting link certificate warning days", err.Error())
+	}
+
+	syncInterval, err := serviceSyncIntervalFromEnv()
+	if err != nil {
+		log.Fatal("Error getting service sync interval", err.Error())
+	}
+
 	event.StartDefaultEventStore(stopCh)
 
-	controller, err := NewController(cli, origin, tlsConfig, disableServiceSync == "true")
+	if domain, err := cli.ClusterDomainRecord(context.Background(), namespace); err != nil {
+		log.Println("Error recording cluster domain in site config", err.Error())
+	} else if domain != "" {
+		log.Printf("Recorded cluster domain %s in site config", domain)
+	}
+
+	controller, err := NewController(cli, origin, tlsConfig, disableServiceSync == "true", syncInterval, importOnDemandFromEnv(), watermarks, linkCertWarningDays)
 	if err != nil {
 		log.Fatal("Error getting new controller", err.Error())
 	}
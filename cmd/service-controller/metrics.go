@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	MetricsError string = "MetricsError"
+)
+
+// serviceMetrics holds the traffic the local router has handled for an
+// address since it started
+type serviceMetrics struct {
+	protocol          string
+	requests          int
+	bytesIn           int
+	bytesOut          int
+	activeConnections int
+}
+
+// siteMetrics is a snapshot of the state of the site, as exposed on the
+// /metrics endpoint
+type siteMetrics struct {
+	routerUp    bool
+	usage       qdr.RouterUsage
+	links       map[string]int
+	services    map[string]*serviceMetrics
+	definitions int
+	// the days until the certificates of each link expire
+	linkCertDays        map[string]int
+	linkCertWarningDays int
+	// the days until the certificate in each secret of the site expires
+	secretCertDays map[string]int
+}
+
+func (m *siteMetrics) service(address string, protocol string) *serviceMetrics {
+	service, ok := m.services[address]
+	if !ok {
+		service = &serviceMetrics{protocol: protocol}
+		m.services[address] = service
+	}
+	return service
+}
+
+// collectMetrics queries the local router for the traffic it has handled
+// and the links it has to other sites. The router is reported as down,
+// rather than an error returned, if it cannot be queried.
+func collectMetrics(agent *qdr.Agent, tcp *tcpTotals) *siteMetrics {
+	m := &siteMetrics{
+		links:    map[string]int{},
+		services: map[string]*serviceMetrics{},
+	}
+	usage, err := agent.GetLocalRouterUsage()
+	if err != nil {
+		event.Recordf(MetricsError, "Could not retrieve router usage: %s", err)
+		return m
+	}
+	m.routerUp = true
+	m.usage = *usage
+	connections, err := agent.GetConnections()
+	if err != nil {
+		event.Recordf(MetricsError, "Could not retrieve router connections: %s", err)
+	}
+	for _, c := range connections {
+		if (c.Role == string(qdr.RoleInterRouter) || c.Role == qdr.RoleEdge) && c.Dir == qdr.DirectionOut && c.Active {
+			m.links[c.Role]++
+		}
+	}
+	requests, err := agent.GetLocalHttpRequestInfo()
+	if err != nil {
+		event.Recordf(MetricsError, "Could not retrieve http request info: %s", err)
+	}
+	for _, r := range requests {
+		if r.Direction != qdr.DirectionIn {
+			// requests are counted once, by the router that received them
+			continue
+		}
+		service := m.service(r.Address, "http")
+		service.requests += r.Requests
+		service.bytesIn += r.BytesIn
+		service.bytesOut += r.BytesOut
+	}
+	tcpConnections, err := agent.GetLocalTcpConnections()
+	if err != nil {
+		event.Recordf(MetricsError, "Could not retrieve tcp connections: %s", err)
+	} else {
+		tcp.update(tcpConnections, m)
+	}
+	return m
+}
+
+// tcpTotals accumulates the traffic of tcp connections once they close,
+// as the router only reports those that are open. Connections that open
+// and close between two scrapes are not counted.
+type tcpTotals struct {
+	lock   sync.Mutex
+	open   map[string]qdr.TcpConnection
+	closed map[string]serviceMetrics
+}
+
+func newTcpTotals() *tcpTotals {
+	return &tcpTotals{
+		open:   map[string]qdr.TcpConnection{},
+		closed: map[string]serviceMetrics{},
+	}
+}
+
+func (t *tcpTotals) update(connections []qdr.TcpConnection, m *siteMetrics) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	open := map[string]qdr.TcpConnection{}
+	for _, c := range connections {
+		if c.Direction == qdr.DirectionIn {
+			open[c.Name] = c
+		}
+	}
+	for name, c := range t.open {
+		if _, ok := open[name]; !ok {
+			closed := t.closed[c.Address]
+			closed.requests++
+			closed.bytesIn += c.BytesIn
+			closed.bytesOut += c.BytesOut
+			t.closed[c.Address] = closed
+		}
+	}
+	t.open = open
+	for address, closed := range t.closed {
+		service := m.service(address, "tcp")
+		service.requests += closed.requests
+		service.bytesIn += closed.bytesIn
+		service.bytesOut += closed.bytesOut
+	}
+	for _, c := range open {
+		service := m.service(c.Address, "tcp")
+		service.requests++
+		service.activeConnections++
+		service.bytesIn += c.BytesIn
+		service.bytesOut += c.BytesOut
+	}
+}
+
+func sortedKeys(values map[string]int) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
+
+type metricsWriter struct {
+	out io.Writer
+}
+
+func (w *metricsWriter) describe(name string, kind string, help string) {
+	fmt.Fprintf(w.out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (w *metricsWriter) sample(name string, labels []string, value string) {
+	if len(labels) == 0 {
+		fmt.Fprintf(w.out, "%s %s\n", name, value)
+		return
+	}
+	pairs := []string{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1])))
+	}
+	fmt.Fprintf(w.out, "%s{%s} %s\n", name, strings.Join(pairs, ","), value)
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// writeMetrics writes the metrics in the Prometheus text exposition format
+func writeMetrics(out io.Writer, m *siteMetrics) {
+	w := &metricsWriter{out: out}
+	w.describe("skupper_controller_info", "gauge", "Version of the service controller.")
+	w.sample("skupper_controller_info", []string{"version", client.Version}, "1")
+	w.describe("skupper_router_up", "gauge", "Whether the local router could be queried.")
+	w.sample("skupper_router_up", nil, boolValue(m.routerUp))
+	if m.routerUp {
+		w.describe("skupper_router_memory_bytes", "gauge", "Memory in use by the local router.")
+		w.sample("skupper_router_memory_bytes", nil, strconv.FormatUint(m.usage.MemoryUsage, 10))
+		w.describe("skupper_router_connections", "gauge", "Connections open on the local router.")
+		w.sample("skupper_router_connections", nil, strconv.Itoa(m.usage.Connections))
+		w.describe("skupper_router_undelivered_deliveries", "gauge", "Deliveries not yet delivered by the local router.")
+		w.sample("skupper_router_undelivered_deliveries", nil, strconv.Itoa(m.usage.Undelivered))
+	}
+	w.describe("skupper_active_links", "gauge", "Links from this site to other sites that are active.")
+	roles := []string{string(qdr.RoleInterRouter), qdr.RoleEdge}
+	for _, role := range roles {
+		w.sample("skupper_active_links", []string{"role", role}, strconv.Itoa(m.links[role]))
+	}
+	if len(m.linkCertDays) > 0 {
+		names := sortedKeys(m.linkCertDays)
+		w.describe("skupper_link_certificate_expiry_days", "gauge", "Whole days until the first of the certificates of the link expires.")
+		for _, name := range names {
+			w.sample("skupper_link_certificate_expiry_days", []string{"link", name}, strconv.Itoa(m.linkCertDays[name]))
+		}
+		w.describe("skupper_link_certificate_expiring", "gauge", "Whether the certificates of the link expire within the warning threshold.")
+		for _, name := range names {
+			w.sample("skupper_link_certificate_expiring", []string{"link", name}, boolValue(m.linkCertDays[name] < m.linkCertWarningDays))
+		}
+	}
+	if len(m.secretCertDays) > 0 {
+		w.describe("skupper_secret_certificate_expiry_days", "gauge", "Whole days until the certificate in the secret expires.")
+		for _, name := range sortedKeys(m.secretCertDays) {
+			w.sample("skupper_secret_certificate_expiry_days", []string{"secret", name}, strconv.Itoa(m.secretCertDays[name]))
+		}
+	}
+	w.describe("skupper_service_definitions", "gauge", "Services defined in this site.")
+	w.sample("skupper_service_definitions", nil, strconv.Itoa(m.definitions))
+
+	addresses := []string{}
+	for address := range m.services {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	w.describe("skupper_service_requests_total", "counter", "Requests, or connections for tcp services, received by this site for the service.")
+	for _, address := range addresses {
+		s := m.services[address]
+		w.sample("skupper_service_requests_total", []string{"address", address, "protocol", s.protocol}, strconv.Itoa(s.requests))
+	}
+	w.describe("skupper_service_bytes_in_total", "counter", "Bytes received from clients of the service in this site.")
+	for _, address := range addresses {
+		s := m.services[address]
+		w.sample("skupper_service_bytes_in_total", []string{"address", address, "protocol", s.protocol}, strconv.Itoa(s.bytesIn))
+	}
+	w.describe("skupper_service_bytes_out_total", "counter", "Bytes sent to clients of the service in this site.")
+	for _, address := range addresses {
+		s := m.services[address]
+		w.sample("skupper_service_bytes_out_total", []string{"address", address, "protocol", s.protocol}, strconv.Itoa(s.bytesOut))
+	}
+	w.describe("skupper_service_active_connections", "gauge", "Open tcp connections from clients of the service in this site.")
+	for _, address := range addresses {
+		s := m.services[address]
+		if s.protocol == "tcp" {
+			w.sample("skupper_service_active_connections", []string{"address", address}, strconv.Itoa(s.activeConnections))
+		}
+	}
+}
+
+func (server *ConsoleServer) serveMetrics() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent, err := server.agentPool.Get()
+		if err != nil {
+			server.httpInternalError(w, fmt.Errorf("Could not get management agent : %s", err))
+			return
+		}
+		m := collectMetrics(agent, server.tcpTotals)
+		server.agentPool.Put(agent)
+		if server.vanClient != nil {
+			definitions, err := server.vanClient.ServiceInterfaceList(context.Background())
+			if err != nil {
+				event.Recordf(MetricsError, "Could not retrieve service definitions: %s", err)
+			} else {
+				m.definitions = len(definitions)
+			}
+			expiries, err := server.vanClient.LinkCertificateExpiries(context.Background())
+			if err != nil {
+				event.Recordf(MetricsError, "Could not retrieve link certificates: %s", err)
+			} else {
+				now := time.Now()
+				m.linkCertDays = map[string]int{}
+				for name, expiry := range expiries {
+					m.linkCertDays[name] = daysRemaining(expiry, now)
+				}
+				m.linkCertWarningDays = server.linkCertWarningDays
+			}
+			expiries, err = server.vanClient.SiteCertificateExpiries(context.Background())
+			if err != nil {
+				event.Recordf(MetricsError, "Could not retrieve site certificates: %s", err)
+			} else {
+				now := time.Now()
+				m.secretCertDays = map[string]int{}
+				for name, expiry := range expiries {
+					m.secretCertDays[name] = daysRemaining(expiry, now)
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, m)
+		// the certificates the controller itself issued, such as when
+		// rotating them
+		certs.WriteMetrics(w)
+	})
+}
+
+// addMetricsHandlers serves the metrics to the same users as the console,
+// so a scraper needs the credentials of a console user when the console
+// authenticates its users
+func (server *ConsoleServer) addMetricsHandlers(mux handlerRegistry) {
+	mux.Handle("/metrics", authenticated(server.serveMetrics()))
+}
+
+// listenMetrics serves the metrics on their own port, so that they can be
+// scraped without the console being exposed. Failing to do so does not
+// stop the controller.
+func (server *ConsoleServer) listenMetrics() {
+	addr := ":" + strconv.Itoa(int(types.ControllerMetricsPort))
+	if os.Getenv("SKUPPER_CONTROLLER_METRICS_PORT") != "" {
+		addr = ":" + os.Getenv("SKUPPER_CONTROLLER_METRICS_PORT")
+	}
+	log.Printf("Metrics server listening on %s", addr)
+	mux := http.NewServeMux()
+	server.addMetricsHandlers(mux)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Metrics not served on %s: %s", addr, err)
+	}
+}
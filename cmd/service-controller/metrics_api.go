@@ -0,0 +1,511 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const metricsApiPrefix = "/apis/" + types.MetricsApiGroup + "/" + types.MetricsApiVersion
+
+// The metrics served through the custom metrics API, by the resource
+// describing what they measure. Services are those exposed through
+// skupper, secrets those holding the links of the site.
+var metricsApiResources = map[string][]string{
+	"services":   {"skupper_requests", "skupper_requests_per_second", "skupper_bytes_in", "skupper_bytes_out", "skupper_active_connections"},
+	"secrets":    {"skupper_link_up"},
+	"namespaces": {"skupper_router_up", "skupper_active_links"},
+}
+
+var metricsApiKinds = map[string]string{
+	"services":   "Service",
+	"secrets":    "Secret",
+	"namespaces": "Namespace",
+}
+
+// metricValue and metricValueList are the types of version v1beta1 of
+// the custom metrics API
+type metricValue struct {
+	DescribedObject corev1.ObjectReference `json:"describedObject"`
+	MetricName      string                 `json:"metricName"`
+	Timestamp       metav1.Time            `json:"timestamp"`
+	Value           resource.Quantity      `json:"value"`
+}
+
+type metricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []metricValue `json:"items"`
+}
+
+// metricsSnapshot is the state of the site from which the values of
+// the metrics are taken
+type metricsSnapshot struct {
+	time  time.Time
+	site  *siteMetrics
+	links map[string]bool
+	rates map[string]float64
+}
+
+// value returns the value of a metric for an object, if it has one
+func (s *metricsSnapshot) value(resourceName string, name string, metric string) (*resource.Quantity, bool) {
+	switch resourceName {
+	case "services":
+		if metric == "skupper_requests_per_second" {
+			rate, ok := s.rates[name]
+			return resource.NewMilliQuantity(int64(rate*1000), resource.DecimalSI), ok
+		}
+		service, ok := s.site.services[name]
+		if !ok {
+			return nil, false
+		}
+		switch metric {
+		case "skupper_requests":
+			return resource.NewQuantity(int64(service.requests), resource.DecimalSI), true
+		case "skupper_bytes_in":
+			return resource.NewQuantity(int64(service.bytesIn), resource.DecimalSI), true
+		case "skupper_bytes_out":
+			return resource.NewQuantity(int64(service.bytesOut), resource.DecimalSI), true
+		case "skupper_active_connections":
+			return resource.NewQuantity(int64(service.activeConnections), resource.DecimalSI), true
+		}
+	case "secrets":
+		up, ok := s.links[name]
+		if ok && metric == "skupper_link_up" {
+			return resource.NewQuantity(int64(boolToInt(up)), resource.DecimalSI), true
+		}
+	case "namespaces":
+		switch metric {
+		case "skupper_router_up":
+			return resource.NewQuantity(int64(boolToInt(s.site.routerUp)), resource.DecimalSI), true
+		case "skupper_active_links":
+			total := 0
+			for _, count := range s.site.links {
+				total += count
+			}
+			return resource.NewQuantity(int64(total), resource.DecimalSI), true
+		}
+	}
+	return nil, false
+}
+
+// names returns the objects of a resource that have metrics
+func (s *metricsSnapshot) names(resourceName string) []string {
+	names := []string{}
+	switch resourceName {
+	case "services":
+		for address := range s.site.services {
+			names = append(names, address)
+		}
+	case "secrets":
+		for link := range s.links {
+			names = append(names, link)
+		}
+	}
+	return names
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func isMetricsApiMetric(resourceName string, metric string) bool {
+	for _, m := range metricsApiResources[resourceName] {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// metricValues returns the values of a metric for the named object of a
+// resource, or for all those selected if the name is "*"
+func metricValues(snapshot *metricsSnapshot, namespace string, resourceName string, name string, metric string, selected map[string]bool) []metricValue {
+	names := []string{name}
+	if name == "*" {
+		names = []string{}
+		for _, n := range snapshot.names(resourceName) {
+			if selected == nil || selected[n] {
+				names = append(names, n)
+			}
+		}
+	}
+	values := []metricValue{}
+	for _, n := range names {
+		value, ok := snapshot.value(resourceName, n, metric)
+		if !ok {
+			continue
+		}
+		object := corev1.ObjectReference{
+			Kind:       metricsApiKinds[resourceName],
+			APIVersion: "v1",
+			Name:       n,
+		}
+		if resourceName != "namespaces" {
+			object.Namespace = namespace
+		}
+		values = append(values, metricValue{
+			DescribedObject: object,
+			MetricName:      metric,
+			Timestamp:       metav1.NewTime(snapshot.time),
+			Value:           *value,
+		})
+	}
+	return values
+}
+
+// rateTracker derives the rate of requests for each service from the
+// totals reported by the router, over intervals of at least rateInterval
+type rateTracker struct {
+	lock   sync.Mutex
+	last   time.Time
+	totals map[string]int
+	rates  map[string]float64
+}
+
+const rateInterval = 30 * time.Second
+
+func newRateTracker() *rateTracker {
+	return &rateTracker{
+		totals: map[string]int{},
+		rates:  map[string]float64{},
+	}
+}
+
+func (r *rateTracker) update(now time.Time, site *siteMetrics) map[string]float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	totals := map[string]int{}
+	for address, service := range site.services {
+		totals[address] = service.requests
+	}
+	if r.last.IsZero() {
+		r.last = now
+		r.totals = totals
+	} else if elapsed := now.Sub(r.last); elapsed >= rateInterval {
+		rates := map[string]float64{}
+		for address, total := range totals {
+			if previous, ok := r.totals[address]; ok && total >= previous {
+				rates[address] = float64(total-previous) / elapsed.Seconds()
+			}
+		}
+		r.last = now
+		r.totals = totals
+		r.rates = rates
+	}
+	return r.rates
+}
+
+// requestHeaderAuth authenticates requests proxied by the aggregation
+// layer, which identifies the user on whose behalf it makes them in
+// headers of requests made with a client certificate issued by the CA
+// it publishes
+type requestHeaderAuth struct {
+	clientCAs    *x509.CertPool
+	allowedNames []string
+	userHeaders  []string
+	groupHeaders []string
+}
+
+func getRequestHeaderAuth(kube kubernetes.Interface) (*requestHeaderAuth, error) {
+	cm, err := kube.CoreV1().ConfigMaps(types.MetricsApiAuthNamespace).Get(types.MetricsApiAuthConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	auth := &requestHeaderAuth{
+		clientCAs:    x509.NewCertPool(),
+		userHeaders:  []string{"X-Remote-User"},
+		groupHeaders: []string{"X-Remote-Group"},
+	}
+	if !auth.clientCAs.AppendCertsFromPEM([]byte(cm.Data["requestheader-client-ca-file"])) {
+		return nil, fmt.Errorf("No request header client CA found in %s/%s", types.MetricsApiAuthNamespace, types.MetricsApiAuthConfigMap)
+	}
+	for key, value := range map[string]*[]string{
+		"requestheader-allowed-names":    &auth.allowedNames,
+		"requestheader-username-headers": &auth.userHeaders,
+		"requestheader-group-headers":    &auth.groupHeaders,
+	} {
+		if cm.Data[key] != "" {
+			if err := json.Unmarshal([]byte(cm.Data[key]), value); err != nil {
+				return nil, fmt.Errorf("Invalid value for %s: %s", key, err)
+			}
+		}
+	}
+	return auth, nil
+}
+
+func (a *requestHeaderAuth) authenticate(r *http.Request) (string, []string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", nil, false
+	}
+	if len(a.allowedNames) > 0 {
+		name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		allowed := false
+		for _, n := range a.allowedNames {
+			allowed = allowed || n == name
+		}
+		if !allowed {
+			return "", nil, false
+		}
+	}
+	user := ""
+	for _, header := range a.userHeaders {
+		if user = r.Header.Get(header); user != "" {
+			break
+		}
+	}
+	groups := []string{}
+	for _, header := range a.groupHeaders {
+		groups = append(groups, r.Header[http.CanonicalHeaderKey(header)]...)
+	}
+	return user, groups, user != ""
+}
+
+// metricsApiServer serves the custom metrics API to the aggregation
+// layer. Authorization of each request is delegated to the apiserver.
+type metricsApiServer struct {
+	namespace string
+	kube      kubernetes.Interface
+	auth      *requestHeaderAuth
+	snapshot  func() (*metricsSnapshot, error)
+}
+
+func writeApiStatus(w http.ResponseWriter, code int, reason metav1.StatusReason, message string) {
+	status := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+func writeApiObject(w http.ResponseWriter, object interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(object)
+}
+
+func metricsApiResourceList() *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: types.MetricsApiGroup + "/" + types.MetricsApiVersion,
+	}
+	for _, resourceName := range []string{"namespaces", "secrets", "services"} {
+		for _, metric := range metricsApiResources[resourceName] {
+			list.APIResources = append(list.APIResources, metav1.APIResource{
+				Name:       resourceName + "/" + metric,
+				Namespaced: resourceName != "namespaces",
+				Kind:       "MetricValueList",
+				Verbs:      []string{"get"},
+			})
+		}
+	}
+	return list
+}
+
+func (s *metricsApiServer) authorize(user string, groups []string, attributes *authv1.ResourceAttributes) (bool, error) {
+	review, err := s.kube.AuthorizationV1().SubjectAccessReviews().Create(&authv1.SubjectAccessReview{
+		Spec: authv1.SubjectAccessReviewSpec{
+			User:               user,
+			Groups:             groups,
+			ResourceAttributes: attributes,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+// selectedNames returns the names of the objects matching a label
+// selector, or nil if there is no selector
+func (s *metricsApiServer) selectedNames(resourceName string, selector string) (map[string]bool, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	names := map[string]bool{}
+	options := metav1.ListOptions{LabelSelector: selector}
+	switch resourceName {
+	case "services":
+		list, err := s.kube.CoreV1().Services(s.namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			names[item.Name] = true
+		}
+	case "secrets":
+		list, err := s.kube.CoreV1().Secrets(s.namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			names[item.Name] = true
+		}
+	}
+	return names, nil
+}
+
+func (s *metricsApiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, groups, ok := s.auth.authenticate(r)
+	if !ok {
+		writeApiStatus(w, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, "Unauthorized")
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, metricsApiPrefix) {
+		writeApiStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "Not found")
+		return
+	}
+	parts := removeEmpty(strings.Split(strings.TrimPrefix(r.URL.Path, metricsApiPrefix), "/"))
+	if len(parts) == 0 {
+		writeApiObject(w, metricsApiResourceList())
+		return
+	}
+	// either namespaces/{namespace}/metrics/{metric} for metrics of
+	// the namespace, or namespaces/{namespace}/{resource}/{name}/{metric}
+	var namespace, resourceName, name, metric string
+	attributes := &authv1.ResourceAttributes{
+		Verb:  "get",
+		Group: types.MetricsApiGroup,
+	}
+	if len(parts) == 4 && parts[0] == "namespaces" && parts[2] == "metrics" {
+		namespace, resourceName, name, metric = parts[1], "namespaces", parts[1], parts[3]
+		attributes.Resource = "metrics"
+		attributes.Name = metric
+	} else if len(parts) == 5 && parts[0] == "namespaces" {
+		namespace, resourceName, name, metric = parts[1], parts[2], parts[3], parts[4]
+		attributes.Resource = resourceName
+		attributes.Name = name
+		attributes.Subresource = metric
+		if name == "*" {
+			attributes.Verb = "list"
+			attributes.Name = ""
+		}
+	} else {
+		writeApiStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "Not found")
+		return
+	}
+	attributes.Namespace = namespace
+	allowed, err := s.authorize(user, groups, attributes)
+	if err != nil {
+		writeApiStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, fmt.Sprintf("Could not authorize request: %s", err))
+		return
+	}
+	if !allowed {
+		writeApiStatus(w, http.StatusForbidden, metav1.StatusReasonForbidden, fmt.Sprintf("User %s cannot get %s %s in namespace %s", user, resourceName, metric, namespace))
+		return
+	}
+	if namespace != s.namespace || !isMetricsApiMetric(resourceName, metric) {
+		writeApiStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("No metric %s for %s %s in namespace %s", metric, resourceName, name, namespace))
+		return
+	}
+	selected, err := s.selectedNames(resourceName, r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeApiStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+	snapshot, err := s.snapshot()
+	if err != nil {
+		writeApiStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+	values := metricValues(snapshot, namespace, resourceName, name, metric, selected)
+	if name != "*" && len(values) == 0 {
+		writeApiStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("No metric %s for %s %s in namespace %s", metric, resourceName, name, namespace))
+		return
+	}
+	writeApiObject(w, &metricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "MetricValueList", APIVersion: types.MetricsApiGroup + "/" + types.MetricsApiVersion},
+		ListMeta: metav1.ListMeta{SelfLink: r.URL.Path},
+		Items:    values,
+	})
+}
+
+// metricsSnapshot collects the state of the site for the custom metrics
+// API, including whether each of its links is active
+func (server *ConsoleServer) metricsSnapshot() (*metricsSnapshot, error) {
+	agent, err := server.agentPool.Get()
+	if err != nil {
+		return nil, fmt.Errorf("Could not get management agent: %s", err)
+	}
+	defer server.agentPool.Put(agent)
+	now := time.Now()
+	snapshot := &metricsSnapshot{
+		time:  now,
+		site:  collectMetrics(agent, server.tcpTotals),
+		links: map[string]bool{},
+	}
+	snapshot.rates = server.rates.update(now, snapshot.site)
+	connectors, err := server.vanClient.ConnectorList(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve links: %s", err)
+	}
+	connections, err := agent.GetConnections()
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve router connections: %s", err)
+	}
+	for _, connector := range connectors {
+		connection := qdr.GetInterRouterOrEdgeConnection(connector.Host+":"+connector.Port, connections)
+		snapshot.links[connector.Name] = connection != nil && connection.Active
+	}
+	return snapshot, nil
+}
+
+func metricsApiEnabled() bool {
+	return os.Getenv("SKUPPER_METRICS_API") == "true"
+}
+
+// listenMetricsApi serves the custom metrics API over TLS, with the
+// certificate the aggregation layer is configured to trust
+func (server *ConsoleServer) listenMetricsApi() {
+	auth, err := getRequestHeaderAuth(server.vanClient.KubeClient)
+	if err != nil {
+		log.Printf("Metrics API not served, could not retrieve the configuration for authenticating requests: %s", err)
+		return
+	}
+	api := &metricsApiServer{
+		namespace: server.vanClient.Namespace,
+		kube:      server.vanClient.KubeClient,
+		auth:      auth,
+		snapshot:  server.metricsSnapshot,
+	}
+	addr := ":" + strconv.Itoa(int(types.MetricsApiPort))
+	log.Printf("Metrics API server listening on %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle(metricsApiPrefix, api)
+	mux.Handle(metricsApiPrefix+"/", api)
+	s := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  auth.clientCAs,
+		},
+	}
+	log.Fatal(s.ListenAndServeTLS(path.Join(types.MetricsApiCertsPath, "tls.crt"), path.Join(types.MetricsApiCertsPath, "tls.key")))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestMetricsApi(t *testing.T) {
+	kube := fake.NewSimpleClientset(
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "east", Labels: map[string]string{"app": "web"}}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "east"}},
+	)
+	reviews := []authv1.ResourceAttributes{}
+	kube.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authv1.SubjectAccessReview)
+		reviews = append(reviews, *review.Spec.ResourceAttributes)
+		review.Status.Allowed = review.Spec.User == "system:serviceaccount:kube-system:horizontal-pod-autoscaler"
+		return true, review, nil
+	})
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := &metricsApiServer{
+		namespace: "east",
+		kube:      kube,
+		auth:      &requestHeaderAuth{allowedNames: []string{"front-proxy-client"}, userHeaders: []string{"X-Remote-User"}, groupHeaders: []string{"X-Remote-Group"}},
+		snapshot: func() (*metricsSnapshot, error) {
+			return &metricsSnapshot{
+				time: now,
+				site: &siteMetrics{
+					routerUp: true,
+					links:    map[string]int{"inter-router": 1, "edge": 1},
+					services: map[string]*serviceMetrics{
+						"web": {protocol: "http", requests: 42, bytesIn: 100, bytesOut: 2000},
+						"db":  {protocol: "tcp", requests: 3, activeConnections: 2},
+					},
+				},
+				links: map[string]bool{"link1": true, "link2": false},
+				rates: map[string]float64{"web": 1.5},
+			}, nil
+		},
+	}
+	get := func(path string, user string, proxy string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if proxy != "" {
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: proxy}}}}}
+		}
+		r.Header.Set("X-Remote-User", user)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w
+	}
+	hpa := "system:serviceaccount:kube-system:horizontal-pod-autoscaler"
+	values := func(w *httptest.ResponseRecorder) map[string]string {
+		assert.Equal(t, w.Code, http.StatusOK, w.Body.String())
+		list := metricValueList{}
+		assert.Assert(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, list.Kind, "MetricValueList")
+		result := map[string]string{}
+		for _, item := range list.Items {
+			result[item.DescribedObject.Kind+"/"+item.DescribedObject.Name] = item.Value.String()
+		}
+		return result
+	}
+
+	// requests must be proxied by the aggregation layer
+	assert.Equal(t, get(metricsApiPrefix, hpa, "").Code, http.StatusUnauthorized)
+	assert.Equal(t, get(metricsApiPrefix, hpa, "someone-else").Code, http.StatusUnauthorized)
+	assert.Equal(t, get(metricsApiPrefix, "", "front-proxy-client").Code, http.StatusUnauthorized)
+
+	w := get(metricsApiPrefix, hpa, "front-proxy-client")
+	assert.Equal(t, w.Code, http.StatusOK)
+	resources := metav1.APIResourceList{}
+	assert.Assert(t, json.Unmarshal(w.Body.Bytes(), &resources))
+	assert.Equal(t, resources.GroupVersion, "custom.metrics.k8s.io/v1beta1")
+	assert.Equal(t, len(resources.APIResources), 8)
+
+	assert.DeepEqual(t, values(get(metricsApiPrefix+"/namespaces/east/services/web/skupper_requests", hpa, "front-proxy-client")), map[string]string{"Service/web": "42"})
+	assert.DeepEqual(t, values(get(metricsApiPrefix+"/namespaces/east/services/web/skupper_requests_per_second", hpa, "front-proxy-client")), map[string]string{"Service/web": "1500m"})
+	assert.DeepEqual(t, values(get(metricsApiPrefix+"/namespaces/east/services/*/skupper_active_connections", hpa, "front-proxy-client")), map[string]string{"Service/web": "0", "Service/db": "2"})
+	assert.DeepEqual(t, values(get(metricsApiPrefix+"/namespaces/east/services/*/skupper_bytes_out?labelSelector=app%3Dweb", hpa, "front-proxy-client")), map[string]string{"Service/web": "2k"})
+	assert.DeepEqual(t, values(get(metricsApiPrefix+"/namespaces/east/secrets/*/skupper_link_up", hpa, "front-proxy-client")), map[string]string{"Secret/link1": "1", "Secret/link2": "0"})
+	assert.DeepEqual(t, values(get(metricsApiPrefix+"/namespaces/east/metrics/skupper_active_links", hpa, "front-proxy-client")), map[string]string{"Namespace/east": "2"})
+
+	assert.DeepEqual(t, reviews[0], authv1.ResourceAttributes{Namespace: "east", Verb: "get", Group: "custom.metrics.k8s.io", Resource: "services", Subresource: "skupper_requests", Name: "web"})
+	assert.DeepEqual(t, reviews[2], authv1.ResourceAttributes{Namespace: "east", Verb: "list", Group: "custom.metrics.k8s.io", Resource: "services", Subresource: "skupper_active_connections"})
+
+	assert.Equal(t, get(metricsApiPrefix+"/namespaces/east/services/web/skupper_requests", "someone", "front-proxy-client").Code, http.StatusForbidden)
+	assert.Equal(t, get(metricsApiPrefix+"/namespaces/east/services/other/skupper_requests", hpa, "front-proxy-client").Code, http.StatusNotFound)
+	assert.Equal(t, get(metricsApiPrefix+"/namespaces/west/services/web/skupper_requests", hpa, "front-proxy-client").Code, http.StatusNotFound)
+	assert.Equal(t, get(metricsApiPrefix+"/namespaces/east/services/web/unknown", hpa, "front-proxy-client").Code, http.StatusNotFound)
+}
+
+func TestRateTracker(t *testing.T) {
+	r := newRateTracker()
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	site := func(requests int) *siteMetrics {
+		return &siteMetrics{services: map[string]*serviceMetrics{"web": {requests: requests}}}
+	}
+	assert.DeepEqual(t, r.update(start, site(10)), map[string]float64{})
+	// rates are only derived over long enough intervals
+	assert.DeepEqual(t, r.update(start.Add(10*time.Second), site(20)), map[string]float64{})
+	assert.DeepEqual(t, r.update(start.Add(40*time.Second), site(90)), map[string]float64{"web": 2})
+	assert.DeepEqual(t, r.update(start.Add(50*time.Second), site(100)), map[string]float64{"web": 2})
+}
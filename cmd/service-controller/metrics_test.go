@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestTcpTotals(t *testing.T) {
+	totals := newTcpTotals()
+	scrape := func(connections ...qdr.TcpConnection) *siteMetrics {
+		m := &siteMetrics{services: map[string]*serviceMetrics{}}
+		totals.update(connections, m)
+		return m
+	}
+	a := qdr.TcpConnection{Name: "a", Address: "db", Direction: qdr.DirectionIn, BytesIn: 10, BytesOut: 100}
+	b := qdr.TcpConnection{Name: "b", Address: "db", Direction: qdr.DirectionIn, BytesIn: 1, BytesOut: 2}
+	egress := qdr.TcpConnection{Name: "c", Address: "db", Direction: qdr.DirectionOut, BytesIn: 1000, BytesOut: 1000}
+
+	m := scrape(a, egress)
+	assert.Equal(t, *m.services["db"], serviceMetrics{protocol: "tcp", requests: 1, bytesIn: 10, bytesOut: 100, activeConnections: 1})
+
+	a.BytesIn = 20
+	m = scrape(a, b)
+	assert.Equal(t, *m.services["db"], serviceMetrics{protocol: "tcp", requests: 2, bytesIn: 21, bytesOut: 102, activeConnections: 2})
+
+	// closed connections are still counted
+	m = scrape()
+	assert.Equal(t, *m.services["db"], serviceMetrics{protocol: "tcp", requests: 2, bytesIn: 21, bytesOut: 102, activeConnections: 0})
+}
+
+func TestWriteMetrics(t *testing.T) {
+	defer func(version string) { client.Version = version }(client.Version)
+	client.Version = "0.6.0"
+	m := &siteMetrics{
+		routerUp: true,
+		usage:    qdr.RouterUsage{MemoryUsage: 2048, Connections: 4, Undelivered: 1},
+		links:    map[string]int{"inter-router": 2},
+		services: map[string]*serviceMetrics{
+			"web": {protocol: "http", requests: 5, bytesIn: 50, bytesOut: 500},
+			"db":  {protocol: "tcp", requests: 3, bytesIn: 30, bytesOut: 300, activeConnections: 1},
+		},
+		definitions: 2,
+	}
+	out := &bytes.Buffer{}
+	writeMetrics(out, m)
+	samples := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			samples = append(samples, line)
+		}
+	}
+	assert.DeepEqual(t, samples, []string{
+		`skupper_controller_info{version="0.6.0"} 1`,
+		`skupper_router_up 1`,
+		`skupper_router_memory_bytes 2048`,
+		`skupper_router_connections 4`,
+		`skupper_router_undelivered_deliveries 1`,
+		`skupper_active_links{role="inter-router"} 2`,
+		`skupper_active_links{role="edge"} 0`,
+		`skupper_service_definitions 2`,
+		`skupper_service_requests_total{address="db",protocol="tcp"} 3`,
+		`skupper_service_requests_total{address="web",protocol="http"} 5`,
+		`skupper_service_bytes_in_total{address="db",protocol="tcp"} 30`,
+		`skupper_service_bytes_in_total{address="web",protocol="http"} 50`,
+		`skupper_service_bytes_out_total{address="db",protocol="tcp"} 300`,
+		`skupper_service_bytes_out_total{address="web",protocol="http"} 500`,
+		`skupper_service_active_connections{address="db"} 1`,
+	})
+	assert.Assert(t, strings.Contains(out.String(), "# TYPE skupper_service_requests_total counter\n"))
+
+	out.Reset()
+	writeMetrics(out, &siteMetrics{})
+	assert.Assert(t, strings.Contains(out.String(), "skupper_router_up 0\n"))
+	assert.Assert(t, !strings.Contains(out.String(), "skupper_router_memory_bytes"))
+	assert.Equal(t, escapeLabelValue(`a"b\c`), `a\"b\\c`)
+}
+
+func TestWriteCertificateMetrics(t *testing.T) {
+	m := &siteMetrics{
+		secretCertDays: map[string]int{"skupper-site-ca": 1800, "skupper-local-server": 20},
+	}
+	out := &bytes.Buffer{}
+	writeMetrics(out, m)
+	for _, sample := range []string{
+		`skupper_secret_certificate_expiry_days{secret="skupper-local-server"} 20`,
+		`skupper_secret_certificate_expiry_days{secret="skupper-site-ca"} 1800`,
+	} {
+		assert.Assert(t, strings.Contains(out.String(), sample+"\n"), "missing %s", sample)
+	}
+}
+
+func TestMetricsAuthenticated(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	dir, err := ioutil.TempDir("", "console-users")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	os.Setenv("METRICS_USERS", dir)
+	defer os.Unsetenv("METRICS_USERS")
+
+	mux := http.NewServeMux()
+	(&ConsoleServer{}).addMetricsHandlers(mux)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+}
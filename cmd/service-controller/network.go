@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/data"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// isGateway returns true for the routers of gateways, which attach to a
+// site as edge routers without site metadata of their own
+func isGateway(r qdr.Router) bool {
+	return r.Edge && r.Site.Id == ""
+}
+
+// getNetworkStatus combines the routers of the network with the results
+// of querying each site into a description of every site
+func getNetworkStatus(routers []qdr.Router, sites []data.SiteQueryData) *types.NetworkStatus {
+	routerToSite := map[string]string{}
+	for _, r := range routers {
+		if !isGateway(r) {
+			routerToSite[r.Id] = r.Site.Id
+		}
+	}
+	gateways := map[string]map[string]bool{}
+	for _, r := range routers {
+		if !isGateway(r) {
+			continue
+		}
+		for _, id := range r.ConnectedTo {
+			if siteId, ok := routerToSite[id]; ok {
+				set(gateways, siteId, r.Id)
+			}
+		}
+	}
+	status := &types.NetworkStatus{
+		Sites: []types.NetworkSiteStatus{},
+	}
+	for _, s := range sites {
+		site := types.NetworkSiteStatus{
+			Id:        s.SiteId,
+			Name:      s.SiteName,
+			Namespace: s.Namespace,
+			Version:   s.Version,
+			Edge:      s.Edge,
+			Links:     []string{},
+			Services:  []types.NetworkServiceStatus{},
+			Gateways:  []string{},
+		}
+		for _, id := range s.Connected {
+			if id != s.SiteId {
+				site.Links = append(site.Links, id)
+			}
+		}
+		sort.Strings(site.Links)
+		for _, service := range s.TcpServices {
+			if len(service.Targets) > 0 {
+				site.Services = append(site.Services, types.NetworkServiceStatus{Address: service.Address, Protocol: service.Protocol})
+			}
+		}
+		for _, service := range s.HttpServices {
+			if len(service.Targets) > 0 {
+				site.Services = append(site.Services, types.NetworkServiceStatus{Address: service.Address, Protocol: service.Protocol})
+			}
+		}
+		sort.Slice(site.Services, func(i, j int) bool {
+			return site.Services[i].Address < site.Services[j].Address
+		})
+		for id := range gateways[s.SiteId] {
+			site.Gateways = append(site.Gateways, id)
+		}
+		sort.Strings(site.Gateways)
+		status.Sites = append(status.Sites, site)
+	}
+	sort.Slice(status.Sites, func(i, j int) bool {
+		return status.Sites[i].Id < status.Sites[j].Id
+	})
+	return status
+}
+
+func getNetwork(agent *qdr.Agent) (*types.NetworkStatus, error) {
+	routers, err := agent.GetAllRouters()
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving routers: %s", err)
+	}
+	siteRouters := []qdr.Router{}
+	for _, r := range routers {
+		if !isGateway(r) {
+			siteRouters = append(siteRouters, r)
+		}
+	}
+	sites := getAllSites(siteRouters)
+	querySites(agent, sites)
+	for i, s := range sites {
+		if s.Version == "" {
+			// prior to 0.5 site query did not return services
+			err = getServiceInfo(agent, siteRouters, &sites[i], data.NewNullNameMapping())
+			if err != nil {
+				return nil, fmt.Errorf("Error retrieving service data from old site %s: %s", s.SiteId, err)
+			}
+		}
+	}
+	return getNetworkStatus(routers, sites), nil
+}
+
+func (server *ConsoleServer) serveNetwork() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent, err := server.agentPool.Get()
+		if err != nil {
+			server.httpInternalError(w, fmt.Errorf("Could not get management agent : %s", err))
+			return
+		}
+		status, err := getNetwork(agent)
+		server.agentPool.Put(agent)
+		if err != nil {
+			server.httpInternalError(w, err)
+		} else if wantsJsonOutput(r) {
+			bytes, err := json.MarshalIndent(status, "", "    ")
+			if err != nil {
+				server.httpInternalError(w, fmt.Errorf("Error writing json: %s", err))
+			} else {
+				fmt.Fprintf(w, string(bytes)+"\n")
+			}
+		} else {
+			tw := tabwriter.NewWriter(w, 0, 4, 1, ' ', 0)
+			fmt.Fprintln(tw, "ID\tNAME\tVERSION\tLINKS\tSERVICES\tGATEWAYS")
+			for _, site := range status.Sites {
+				services := []string{}
+				for _, service := range site.Services {
+					services = append(services, service.Address)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", site.Id, site.Name, site.Version, strings.Join(site.Links, ","), strings.Join(services, ","), strings.Join(site.Gateways, ","))
+			}
+			tw.Flush()
+		}
+	})
+}
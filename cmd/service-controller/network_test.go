@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/data"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestGetNetworkStatus(t *testing.T) {
+	routers := []qdr.Router{
+		{Id: "east-router", Site: qdr.SiteMetadata{Id: "east", Version: "0.6.0"}, ConnectedTo: []string{"west-router"}},
+		{Id: "west-router", Site: qdr.SiteMetadata{Id: "west", Version: "0.6.0"}},
+		{Id: "laptop", Edge: true, ConnectedTo: []string{"west-router"}},
+	}
+	sites := []data.SiteQueryData{
+		{
+			Site: data.Site{SiteId: "west", SiteName: "west", Version: "0.6.0", Connected: []string{"east"}},
+			TcpServices: []data.TcpService{
+				{Service: data.Service{Address: "db", Protocol: "tcp", Targets: []data.ServiceTarget{{Name: "db", SiteId: "west"}}}},
+			},
+			HttpServices: []data.HttpService{
+				{Service: data.Service{Address: "web", Protocol: "http"}},
+			},
+		},
+		{
+			Site: data.Site{SiteId: "east", SiteName: "east", Version: "0.6.0", Connected: []string{"west"}},
+			HttpServices: []data.HttpService{
+				{Service: data.Service{Address: "web", Protocol: "http", Targets: []data.ServiceTarget{{Name: "web", SiteId: "east"}}}},
+			},
+		},
+	}
+	assert.DeepEqual(t, getNetworkStatus(routers, sites), &types.NetworkStatus{
+		Sites: []types.NetworkSiteStatus{
+			{
+				Id:       "east",
+				Name:     "east",
+				Version:  "0.6.0",
+				Links:    []string{"west"},
+				Services: []types.NetworkServiceStatus{{Address: "web", Protocol: "http"}},
+				Gateways: []string{},
+			},
+			{
+				Id:       "west",
+				Name:     "west",
+				Version:  "0.6.0",
+				Links:    []string{"east"},
+				Services: []types.NetworkServiceStatus{{Address: "db", Protocol: "tcp"}},
+				Gateways: []string{"laptop"},
+			},
+		},
+	})
+}
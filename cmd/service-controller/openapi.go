@@ -0,0 +1,474 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/auth"
+	"github.com/skupperproject/skupper/pkg/data"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+// The HTTP listeners of the controller, each of which is described by
+// its own OpenAPI document
+const (
+	ConsoleListener string = "console"
+	LocalListener   string = "local"
+	MetricsListener string = "metrics"
+)
+
+var apiListeners = []string{ConsoleListener, LocalListener, MetricsListener}
+
+type apiSchema map[string]interface{}
+
+// apiParameter is a parameter taken from the path or query of a request
+type apiParameter struct {
+	name        string
+	description string
+}
+
+// apiOperation describes a request handled by the controller, from
+// which its OpenAPI documents are generated
+type apiOperation struct {
+	id         string
+	method     string
+	path       string
+	summary    string
+	listeners  []string
+	parameters []apiParameter
+	query      []apiParameter
+	// the role required on the console listener, if it authenticates
+	// the request
+	role string
+	// a value of the type returned as JSON, if any
+	response interface{}
+	// whether the response is text unless JSON is requested through
+	// the output query parameter
+	text bool
+	// the content type of responses that are neither text nor JSON
+	contentType string
+	status      int
+}
+
+// muxPattern returns the pattern the operation is registered with
+func (op *apiOperation) muxPattern() string {
+	if i := strings.Index(op.path, "{"); i >= 0 {
+		return op.path[:i]
+	}
+	return op.path
+}
+
+func (op *apiOperation) servedBy(listener string) bool {
+	for _, l := range op.listeners {
+		if l == listener {
+			return true
+		}
+	}
+	return false
+}
+
+var controllerApi = []apiOperation{
+	{
+		id:        "getConsoleData",
+		method:    http.MethodGet,
+		path:      "/DATA",
+		summary:   "Returns the sites of the network and the traffic of the services exposed in it, as shown by the console",
+		listeners: []string{ConsoleListener, LocalListener},
+		role:      auth.RoleViewer,
+		response:  data.ConsoleData{},
+	},
+	{
+		id:        "getVersion",
+		method:    http.MethodGet,
+		path:      "/version",
+		summary:   "Returns the versions of the controller, router and site",
+		listeners: []string{ConsoleListener, LocalListener},
+		role:      auth.RoleViewer,
+		response:  VersionInfo{},
+		text:      true,
+	},
+	{
+		id:        "getEvents",
+		method:    http.MethodGet,
+		path:      "/events",
+		summary:   "Returns the events recently recorded by the controller",
+		listeners: []string{ConsoleListener, LocalListener},
+		role:      auth.RoleViewer,
+		response:  []event.EventGroup{},
+		text:      true,
+	},
+	{
+		id:        "getSites",
+		method:    http.MethodGet,
+		path:      "/sites",
+		summary:   "Returns the sites of the network",
+		listeners: []string{LocalListener},
+		response:  []data.Site{},
+		text:      true,
+	},
+	{
+		id:        "getServices",
+		method:    http.MethodGet,
+		path:      "/services",
+		summary:   "Returns the services exposed in the network",
+		listeners: []string{LocalListener},
+		response:  []interface{}{},
+		text:      true,
+	},
+	{
+		id:         "checkService",
+		method:     http.MethodGet,
+		path:       "/servicecheck/{address}",
+		summary:    "Checks the configuration of a service in each site",
+		listeners:  []string{ConsoleListener, LocalListener},
+		parameters: []apiParameter{{"address", "The address of the service"}},
+		response:   data.ServiceCheck{},
+		text:       true,
+	},
+	{
+		id:        "checkPeers",
+		method:    http.MethodGet,
+		path:      "/peers",
+		summary:   "Compares the view each peer has of this site with the local view",
+		listeners: []string{LocalListener},
+		response:  []types.PeerStatus{},
+		text:      true,
+	},
+	{
+		id:        "probeLinks",
+		method:    http.MethodGet,
+		path:      "/linkprobe",
+		summary:   "Probes each link with messages of increasing size",
+		listeners: []string{LocalListener},
+		response:  []types.LinkProbeResult{},
+		text:      true,
+	},
+	{
+		id:        "requestServiceSync",
+		method:    http.MethodPost,
+		path:      "/servicesync",
+		summary:   "Exchanges service definitions with the rest of the network now rather than at the next sync interval",
+		listeners: []string{LocalListener},
+		status:    http.StatusAccepted,
+	},
+	{
+		id:        "getNetworkStatus",
+		method:    http.MethodGet,
+		path:      "/network",
+		summary:   "Returns the sites of the network, their links, services and gateways",
+		listeners: []string{LocalListener},
+		response:  types.NetworkStatus{},
+		text:      true,
+	},
+	{
+		id:          "getMetrics",
+		method:      http.MethodGet,
+		path:        "/metrics",
+		summary:     "Returns the metrics of the site in the Prometheus text format",
+		listeners:   []string{LocalListener, MetricsListener},
+		contentType: "text/plain; version=0.0.4",
+	},
+	{
+		id:        "getUser",
+		method:    http.MethodGet,
+		path:      "/user",
+		summary:   "Returns the name and role of the console user",
+		listeners: []string{ConsoleListener},
+		role:      auth.RoleViewer,
+		response:  ConsoleUserInfo{},
+	},
+	{
+		id:         "deleteService",
+		method:     http.MethodDelete,
+		path:       "/services/{address}",
+		summary:    "Unexposes a service defined in this site",
+		listeners:  []string{ConsoleListener},
+		parameters: []apiParameter{{"address", "The address of the service"}},
+		role:       auth.RoleAdmin,
+		status:     http.StatusNoContent,
+	},
+	{
+		id:         "deleteLink",
+		method:     http.MethodDelete,
+		path:       "/links/{name}",
+		summary:    "Deletes a link from this site to another",
+		listeners:  []string{ConsoleListener},
+		parameters: []apiParameter{{"name", "The name of the link"}},
+		role:       auth.RoleAdmin,
+		status:     http.StatusNoContent,
+	},
+	{
+		id:          "getOpenApi",
+		method:      http.MethodGet,
+		path:        "/openapi.json",
+		summary:     "Returns the OpenAPI document describing this listener",
+		listeners:   []string{ConsoleListener, LocalListener},
+		query:       []apiParameter{{"listener", "The listener to describe instead: " + strings.Join(apiListeners, ", ")}},
+		contentType: "application/json",
+		status:      http.StatusOK,
+	},
+}
+
+// schemaGenerator derives schemas from the types the controller
+// encodes as JSON, collecting those of structs as components
+type schemaGenerator struct {
+	components apiSchema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: apiSchema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func (g *schemaGenerator) schema(t reflect.Type) apiSchema {
+	switch t {
+	case timeType:
+		return apiSchema{"type": "string", "format": "date-time"}
+	case durationType:
+		return apiSchema{"type": "integer", "format": "int64", "description": "A duration in nanoseconds"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return apiSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return apiSchema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return apiSchema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return apiSchema{"type": "number"}
+	case reflect.String:
+		return apiSchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return apiSchema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return apiSchema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Interface:
+		// the only values of unspecified type are the services
+		// of the console data
+		return apiSchema{"oneOf": []apiSchema{
+			g.schema(reflect.TypeOf(data.HttpService{})),
+			g.schema(reflect.TypeOf(data.TcpService{})),
+		}}
+	case reflect.Struct:
+		return apiSchema{"$ref": "#/components/schemas/" + g.component(t)}
+	}
+	return apiSchema{}
+}
+
+// component adds the schema of a struct to the components, if not
+// already there, returning its name
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, exists := g.components[name]; exists {
+		// qualify the name with the package when another type has it
+		name = strings.Title(path.Base(t.PkgPath())) + name
+	}
+	g.names[t] = name
+	// the name is recorded first so that recursive types refer to it
+	properties := apiSchema{}
+	required := []string{}
+	g.properties(t, properties, &required)
+	schema := apiSchema{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	g.components[name] = schema
+	return name
+}
+
+// properties adds the fields of a struct as encoding/json would encode
+// them, including those of embedded structs
+func (g *schemaGenerator) properties(t reflect.Type, properties apiSchema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.properties(field.Type, properties, required)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := false
+		for _, option := range parts[1:] {
+			omitempty = omitempty || option == "omitempty"
+		}
+		properties[name] = g.schema(field.Type)
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func (op *apiOperation) describe(g *schemaGenerator, listener string) apiSchema {
+	parameters := []apiSchema{}
+	for _, p := range op.parameters {
+		parameters = append(parameters, apiSchema{
+			"name":        p.name,
+			"in":          "path",
+			"required":    true,
+			"description": p.description,
+			"schema":      apiSchema{"type": "string"},
+		})
+	}
+	for _, p := range op.query {
+		parameters = append(parameters, apiSchema{
+			"name":        p.name,
+			"in":          "query",
+			"description": p.description,
+			"schema":      apiSchema{"type": "string"},
+		})
+	}
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := apiSchema{"description": http.StatusText(status)}
+	content := apiSchema{}
+	if op.response != nil {
+		content["application/json"] = apiSchema{"schema": g.schema(reflect.TypeOf(op.response))}
+	}
+	if op.text {
+		content["text/plain"] = apiSchema{"schema": apiSchema{"type": "string"}}
+		parameters = append(parameters, apiSchema{
+			"name":        "output",
+			"in":          "query",
+			"description": "Set to json for a JSON response rather than text",
+			"schema":      apiSchema{"type": "string", "enum": []string{"json"}},
+		})
+	}
+	if op.contentType != "" {
+		content[op.contentType] = apiSchema{"schema": apiSchema{"type": "string"}}
+	}
+	if len(content) > 0 {
+		response["content"] = content
+	}
+	responses := apiSchema{
+		fmt.Sprint(status): response,
+	}
+	if op.response != nil || op.status != 0 {
+		responses["500"] = apiSchema{"description": http.StatusText(http.StatusInternalServerError)}
+	}
+	if len(op.query) > 0 {
+		responses["400"] = apiSchema{"description": http.StatusText(http.StatusBadRequest)}
+	}
+	if len(op.parameters) > 0 {
+		responses["404"] = apiSchema{"description": http.StatusText(http.StatusNotFound)}
+	}
+	description := apiSchema{
+		"operationId": op.id,
+		"summary":     op.summary,
+		"responses":   responses,
+	}
+	if len(parameters) > 0 {
+		description["parameters"] = parameters
+	}
+	if listener == ConsoleListener && op.role != "" {
+		// users are only authenticated when the console uses internal
+		// authentication
+		description["security"] = []apiSchema{{"basicAuth": []string{}}, {}}
+		description["description"] = fmt.Sprintf("Requires the %s role when users are authenticated by the controller", op.role)
+		responses["401"] = apiSchema{"description": http.StatusText(http.StatusUnauthorized)}
+		if op.role == auth.RoleAdmin {
+			description["description"] = fmt.Sprintf("Requires the %s role, so is refused unless users are authenticated by the controller", op.role)
+			responses["403"] = apiSchema{"description": http.StatusText(http.StatusForbidden)}
+		}
+	}
+	return description
+}
+
+// openApiDocument generates the OpenAPI document describing the
+// requests handled by a listener
+func openApiDocument(listener string) apiSchema {
+	g := newSchemaGenerator()
+	paths := apiSchema{}
+	for i := range controllerApi {
+		op := &controllerApi[i]
+		if !op.servedBy(listener) {
+			continue
+		}
+		item, ok := paths[op.path].(apiSchema)
+		if !ok {
+			item = apiSchema{}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.describe(g, listener)
+	}
+	components := apiSchema{
+		"schemas": g.components,
+	}
+	if listener == ConsoleListener {
+		components["securitySchemes"] = apiSchema{
+			"basicAuth": apiSchema{"type": "http", "scheme": "basic"},
+		}
+	}
+	return apiSchema{
+		"openapi": "3.0.3",
+		"info": apiSchema{
+			"title":   fmt.Sprintf("Skupper service controller (%s listener)", listener),
+			"version": client.Version,
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+func isApiListener(name string) bool {
+	for _, listener := range apiListeners {
+		if listener == name {
+			return true
+		}
+	}
+	return false
+}
+
+// serveOpenApi returns the OpenAPI document for the listener it is
+// served by, unless another is requested through the listener query
+// parameter
+func (server *ConsoleServer) serveOpenApi(listener string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selected := r.URL.Query().Get("listener")
+		if selected == "" {
+			selected = listener
+		} else if !isApiListener(selected) {
+			http.Error(w, fmt.Sprintf("Invalid listener %q, must be one of %s", selected, strings.Join(apiListeners, ", ")), http.StatusBadRequest)
+			return
+		}
+		bytes, err := json.MarshalIndent(openApiDocument(selected), "", "    ")
+		if err != nil {
+			server.httpInternalError(w, fmt.Errorf("Error writing OpenAPI document: %s", err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, string(bytes)+"\n")
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type patternRecorder []string
+
+func (p *patternRecorder) Handle(pattern string, handler http.Handler) {
+	*p = append(*p, pattern)
+}
+
+func TestOpenApiCoversHandlers(t *testing.T) {
+	server := &ConsoleServer{}
+	listeners := map[string]func(handlerRegistry){
+		ConsoleListener: server.addConsoleHandlers,
+		LocalListener:   server.addLocalHandlers,
+		MetricsListener: server.addMetricsHandlers,
+	}
+	for listener, add := range listeners {
+		patterns := patternRecorder{}
+		add(&patterns)
+		registered := map[string]bool{}
+		for _, pattern := range patterns {
+			registered[pattern] = true
+		}
+		documented := map[string]bool{}
+		for _, op := range controllerApi {
+			if op.servedBy(listener) {
+				documented[op.muxPattern()] = true
+				assert.Assert(t, registered[op.muxPattern()], "%s %s is documented but not handled by the %s listener", op.method, op.path, listener)
+			}
+		}
+		for pattern := range registered {
+			assert.Assert(t, documented[pattern], "%s is handled by the %s listener but not documented", pattern, listener)
+		}
+	}
+}
+
+// resolve checks that every reference within a schema is to a component
+func resolve(t *testing.T, value interface{}, schemas map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if key == "$ref" {
+				name := strings.TrimPrefix(item.(string), "#/components/schemas/")
+				_, ok := schemas[name]
+				assert.Assert(t, ok, "no schema for %s", item)
+			} else {
+				resolve(t, item, schemas)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			resolve(t, item, schemas)
+		}
+	}
+}
+
+func TestOpenApiDocument(t *testing.T) {
+	server := &ConsoleServer{}
+	for _, listener := range apiListeners {
+		w := httptest.NewRecorder()
+		server.serveOpenApi(LocalListener).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json?listener="+listener, nil))
+		assert.Equal(t, w.Code, http.StatusOK)
+		document := map[string]interface{}{}
+		assert.Assert(t, json.Unmarshal(w.Body.Bytes(), &document))
+		assert.Equal(t, document["openapi"], "3.0.3")
+		schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		resolve(t, document, schemas)
+		_, secured := document["components"].(map[string]interface{})["securitySchemes"]
+		assert.Equal(t, secured, listener == ConsoleListener)
+	}
+
+	w := httptest.NewRecorder()
+	server.serveOpenApi(ConsoleListener).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	document := map[string]interface{}{}
+	assert.Assert(t, json.Unmarshal(w.Body.Bytes(), &document))
+	paths := document["paths"].(map[string]interface{})
+	del := paths["/links/{name}"].(map[string]interface{})["delete"].(map[string]interface{})
+	assert.Equal(t, del["operationId"], "deleteLink")
+	_, ok := del["responses"].(map[string]interface{})["403"]
+	assert.Assert(t, ok)
+	_, ok = paths["/sites"]
+	assert.Assert(t, !ok)
+
+	// recursive types refer to themselves
+	schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	stats := schemas["HttpRequestStats"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.DeepEqual(t, stats["by_handling_site"], map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"$ref": "#/components/schemas/HttpRequestStats"},
+	})
+	// embedded structs are flattened
+	tcp := schemas["TcpService"].(map[string]interface{})["properties"].(map[string]interface{})
+	_, ok = tcp["address"]
+	assert.Assert(t, ok)
+
+	w = httptest.NewRecorder()
+	server.serveOpenApi(LocalListener).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json?listener=other", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
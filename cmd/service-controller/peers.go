@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/data"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// Asks each peer site what it sees of this site and compares that with
+// the local view, flagging any asymmetries
+func checkPeers(agent *qdr.Agent, cli *client.VanClient, siteId string, checkServices bool) ([]types.PeerStatus, error) {
+	routers, err := agent.GetAllRouters()
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving routers: %s", err)
+	}
+	connections, err := agent.GetConnections()
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving connections: %s", err)
+	}
+	localServices := []string{}
+	if checkServices {
+		services, err := cli.ServiceInterfaceList(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("Error retrieving services: %s", err)
+		}
+		for _, service := range services {
+			if service.Origin == "" {
+				localServices = append(localServices, service.Address)
+			}
+		}
+	}
+	local, peers, order := groupPeerRouters(routers, siteId)
+	request := data.PeerViewRequest{
+		SiteId:  siteId,
+		Routers: local,
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("Could not encode peer view request: %s", err)
+	}
+	results := []types.PeerStatus{}
+	for _, peer := range order {
+		linked := data.IsLinkedTo(connections, peers[peer])
+		sent := time.Now()
+		view, err := getPeerView(agent, peer, string(body))
+		received := time.Now()
+		if err != nil {
+			event.Recordf(PeerViewError, "Request to %s failed: %s", peer, err)
+		}
+		status := data.ComparePeerView(peer, linked, localServices, view, checkServices)
+		if err != nil {
+			status.Observations = append(status.Observations, err.Error())
+		} else if view != nil && !view.Time.IsZero() {
+			// peers of earlier versions do not report their time
+			data.CheckClockSkew(&status, data.EstimateClockSkew(sent, received, view.Time))
+		}
+		results = append(results, status)
+	}
+	return results, nil
+}
+
+func getPeerView(agent qdr.RequestResponse, siteId string, body string) (*data.PeerView, error) {
+	response, err := agent.Request(&qdr.Request{
+		Address: getSiteQueryAddress(siteId),
+		Version: client.Version,
+		Type:    PeerView,
+		Body:    body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.Type != PeerView {
+		return nil, fmt.Errorf("Peer %s does not support peer view requests", siteId)
+	}
+	view := data.PeerView{}
+	err = json.Unmarshal([]byte(response.Body), &view)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing peer view from %s: %s", siteId, err)
+	}
+	return &view, nil
+}
+
+// Returns the ids of the local routers and of the routers of each peer
+// site, along with the peer site ids in the order first seen
+func groupPeerRouters(routers []qdr.Router, siteId string) ([]string, map[string][]string, []string) {
+	local := []string{}
+	peers := map[string][]string{}
+	order := []string{}
+	for _, r := range routers {
+		if r.Site.Id == siteId {
+			local = append(local, r.Id)
+		} else if r.Site.Id != "" && r.Site.Version != "" {
+			if _, ok := peers[r.Site.Id]; !ok {
+				order = append(order, r.Site.Id)
+			}
+			peers[r.Site.Id] = append(peers[r.Site.Id], r.Id)
+		}
+	}
+	return local, peers, order
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+)
+
+func profilingEnabled() bool {
+	return os.Getenv("SKUPPER_ENABLE_PROFILING") == "true"
+}
+
+// The public console listener only exposes the profiling endpoints when
+// requests to it are authenticated, either by the controller itself or
+// by the openshift oauth proxy in front of it
+func profilingAuthenticated() bool {
+	return os.Getenv("METRICS_USERS") != "" || os.Getenv("METRICS_HOST") == "localhost"
+}
+
+type handlerWrapper func(http.Handler) http.Handler
+
+func noWrapper(h http.Handler) http.Handler {
+	return h
+}
+
+func addProfilingHandlers(mux *http.ServeMux, wrap handlerWrapper) {
+	mux.Handle("/debug/pprof/", wrap(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", wrap(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", wrap(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", wrap(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", wrap(http.HandlerFunc(pprof.Trace)))
+	mux.Handle("/debug/vars", wrap(expvar.Handler()))
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	SelfTestEvent string = "SelfTestEvent"
+	SelfTestError string = "SelfTestError"
+
+	selfTestName     string        = "skupper-self-test"
+	selfTestPort     int           = 65432
+	selfTestAttempts int           = 10
+	selfTestDelay    time.Duration = time.Second
+	selfTestMaxDelay time.Duration = 15 * time.Second
+)
+
+func isSelfTestEntity(name string) bool {
+	return strings.HasPrefix(name, selfTestName)
+}
+
+// The self test exposes a loopback echo service through the local
+// router and checks that data sent to the router's listener comes back
+// intact. It verifies that the router can accept and make tcp
+// connections within the cluster, catching broken networking or images
+// as soon as a site is initialised or updated.
+type SelfTest struct {
+	vanClient *client.VanClient
+	agentPool *qdr.AgentPool
+	siteId    string
+}
+
+func newSelfTest(cli *client.VanClient, siteId string, config *tls.Config) *SelfTest {
+	return &SelfTest{
+		vanClient: cli,
+		agentPool: qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+		siteId:    siteId,
+	}
+}
+
+// retryWithBackoff calls f until it succeeds or has been called the given
+// number of times, doubling the delay between calls up to the maximum.
+// The error from the last call is returned.
+func retryWithBackoff(attempts int, delay time.Duration, maxDelay time.Duration, f func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			delay *= 2
+			if delay > maxDelay {
+				delay = maxDelay
+			}
+		}
+		if err = f(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// start runs the test in the background. The router may still be
+// starting when the controller does, so the whole test is retried with
+// backoff before it is recorded as failed.
+func (s *SelfTest) start() {
+	go func() {
+		err := retryWithBackoff(selfTestAttempts, selfTestDelay, selfTestMaxDelay, s.run)
+		if err != nil {
+			event.Recordf(SelfTestError, "Data path self-test failed: %s", err)
+			s.record("failed: " + err.Error())
+		} else {
+			event.Record(SelfTestEvent, "Data path self-test passed")
+			s.record("passed")
+		}
+	}()
+}
+
+func (s *SelfTest) record(result string) {
+	deployment, err := s.vanClient.KubeClient.AppsV1().Deployments(s.vanClient.Namespace).Get(types.ControllerDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		log.Println("Could not record self-test result:", err.Error())
+		return
+	}
+	if deployment.ObjectMeta.Annotations == nil {
+		deployment.ObjectMeta.Annotations = map[string]string{}
+	}
+	deployment.ObjectMeta.Annotations[types.SelfTestAnnotation] = result
+	_, err = s.vanClient.KubeClient.AppsV1().Deployments(s.vanClient.Namespace).Update(deployment)
+	if err != nil {
+		log.Println("Could not record self-test result:", err.Error())
+	}
+}
+
+func localIp() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	addrs, err := net.LookupHost(hostname)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("No address found for %s", hostname)
+	}
+	return addrs[0], nil
+}
+
+func echo(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			io.Copy(conn, conn)
+			conn.Close()
+		}()
+	}
+}
+
+func (s *SelfTest) run() error {
+	host, err := localIp()
+	if err != nil {
+		return fmt.Errorf("Could not determine controller address: %s", err)
+	}
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return fmt.Errorf("Could not start echo server: %s", err)
+	}
+	defer listener.Close()
+	go echo(listener)
+
+	router, err := kube.GetReadyPod(s.vanClient.Namespace, s.vanClient.KubeClient, types.TransportComponentName)
+	if err != nil {
+		return fmt.Errorf("Could not find router: %s", err)
+	}
+
+	agent, err := s.agentPool.Get()
+	if err != nil {
+		return fmt.Errorf("Could not get management agent: %s", err)
+	}
+	defer s.agentPool.Put(agent)
+
+	address := selfTestName + "-" + s.siteId
+	connector := map[string]interface{}{
+		"host":    host,
+		"port":    strconv.Itoa(listener.Addr().(*net.TCPAddr).Port),
+		"address": address,
+		"siteId":  s.siteId,
+	}
+	if err := agent.Create("org.apache.qpid.dispatch.tcpConnector", selfTestName+"-connector", connector); err != nil {
+		return fmt.Errorf("Could not create tcp connector: %s", err)
+	}
+	defer agent.Delete("org.apache.qpid.dispatch.tcpConnector", selfTestName+"-connector")
+	tcpListener := map[string]interface{}{
+		"host":    "0.0.0.0",
+		"port":    strconv.Itoa(selfTestPort),
+		"address": address,
+		"siteId":  s.siteId,
+	}
+	if err := agent.Create("org.apache.qpid.dispatch.tcpListener", selfTestName+"-listener", tcpListener); err != nil {
+		return fmt.Errorf("Could not create tcp listener: %s", err)
+	}
+	defer agent.Delete("org.apache.qpid.dispatch.tcpListener", selfTestName+"-listener")
+
+	return probe(net.JoinHostPort(router.Status.PodIP, strconv.Itoa(selfTestPort)))
+}
+
+func probe(target string) error {
+	conn, err := net.DialTimeout("tcp", target, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	sent := []byte(selfTestName)
+	if _, err := conn.Write(sent); err != nil {
+		return err
+	}
+	received := make([]byte, len(sent))
+	if _, err := io.ReadFull(conn, received); err != nil {
+		return fmt.Errorf("No response received through router: %s", err)
+	}
+	if !bytes.Equal(sent, received) {
+		return fmt.Errorf("Unexpected response received through router: %q", received)
+	}
+	return nil
+}
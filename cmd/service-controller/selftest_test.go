@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSelfTestProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not start listener: %s", err)
+	}
+	go echo(listener)
+	if err := probe(listener.Addr().String()); err != nil {
+		t.Errorf("Expected probe of echo server to succeed, got %s", err)
+	}
+	listener.Close()
+	if err := probe(listener.Addr().String()); err == nil {
+		t.Errorf("Expected probe of closed listener to fail")
+	}
+}
+
+func TestIsSelfTestEntity(t *testing.T) {
+	if !isSelfTestEntity("skupper-self-test-listener") {
+		t.Errorf("Expected self-test listener to be recognised")
+	}
+	if isSelfTestEntity("my-service") {
+		t.Errorf("Expected ordinary bridge not to be recognised as self-test")
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	calls := 0
+	started := time.Now()
+	err := retryWithBackoff(4, time.Millisecond, 2*time.Millisecond, func() error {
+		calls++
+		return fmt.Errorf("attempt %d failed", calls)
+	})
+	if err == nil || err.Error() != "attempt 4 failed" || calls != 4 {
+		t.Errorf("Expected error from the last of 4 attempts, got %v after %d", err, calls)
+	}
+	// delays of 1ms, 2ms and 2ms, as the delay is capped
+	if elapsed := time.Since(started); elapsed < 5*time.Millisecond {
+		t.Errorf("Expected attempts to be delayed, took %s", elapsed)
+	}
+
+	calls = 0
+	err = retryWithBackoff(4, time.Millisecond, time.Millisecond, func() error {
+		calls++
+		if calls < 2 {
+			return fmt.Errorf("not ready")
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Expected success on second attempt, got %v after %d", err, calls)
+	}
+}
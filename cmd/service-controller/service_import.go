@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1informer "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/informers/internalinterfaces"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+func importOnDemandFromEnv() bool {
+	value, _ := strconv.ParseBool(os.Getenv("SKUPPER_IMPORT_ON_DEMAND"))
+	return value
+}
+
+// ServiceImports tracks which services of other sites have been imported
+// by a site that only creates those it has imported
+type ServiceImports struct {
+	informer  cache.SharedIndexInformer
+	namespace string
+}
+
+func newServiceImports(kubeClient kubernetes.Interface, namespace string) *ServiceImports {
+	informer := corev1informer.NewFilteredConfigMapInformer(
+		kubeClient,
+		namespace,
+		time.Second*30,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		internalinterfaces.TweakListOptionsFunc(func(options *metav1.ListOptions) {
+			options.FieldSelector = "metadata.name=" + types.ServiceImportsConfigMap
+		}))
+	return &ServiceImports{
+		informer:  informer,
+		namespace: namespace,
+	}
+}
+
+// onChange has the handler called whenever the imports change
+func (i *ServiceImports) onChange(handler func()) {
+	i.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			handler()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			handler()
+		},
+		DeleteFunc: func(obj interface{}) {
+			handler()
+		},
+	})
+}
+
+func (i *ServiceImports) imported(address string) bool {
+	obj, exists, err := i.informer.GetStore().GetByKey(i.namespace + "/" + types.ServiceImportsConfigMap)
+	if err != nil || !exists {
+		return false
+	}
+	configmap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return false
+	}
+	_, ok = configmap.Data[address]
+	return ok
+}
+
+// filter drops the service definitions advertised by another site that
+// have not been imported. Definitions dropped that were previously
+// created are then removed like any the site no longer advertises.
+func (i *ServiceImports) filter(definitions map[string]types.ServiceInterface) {
+	for address := range definitions {
+		if !i.imported(address) {
+			delete(definitions, address)
+		}
+	}
+}
+
+// serviceImportsChanged asks every site to advertise its services, so
+// that services just imported are created without waiting for the next
+// interval
+func (c *Controller) serviceImportsChanged() {
+	if c.disableServiceSync {
+		return
+	}
+	event.Recordf(ServiceSyncServiceEvent, "Service imports changed")
+	c.triggerServiceSync(true)
+}
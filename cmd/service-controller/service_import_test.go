@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+func TestServiceSyncImportOnDemand(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	n, err := newSyncNetwork("a", "b")
+	assert.NilError(t, err)
+	assert.NilError(t, n.network.Link("a", "b"))
+	b := n.controllers["b"]
+	b.serviceImports = newServiceImports(b.vanClient.KubeClient, b.vanClient.Namespace)
+	setImports := func(addresses ...string) {
+		imports := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: types.ServiceImportsConfigMap, Namespace: b.vanClient.Namespace},
+			Data:       map[string]string{},
+		}
+		for _, address := range addresses {
+			imports.Data[address] = ""
+		}
+		assert.NilError(t, b.serviceImports.informer.GetStore().Update(imports))
+	}
+	imported := func() []string {
+		services, err := n.network.Site("b").Services()
+		assert.NilError(t, err)
+		addresses := []string{}
+		for address := range services {
+			addresses = append(addresses, address)
+		}
+		return addresses
+	}
+
+	assert.NilError(t, n.expose("a", types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}}))
+	assert.NilError(t, n.expose("a", types.ServiceInterface{Address: "web", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}}))
+	assert.NilError(t, n.settle())
+	assert.DeepEqual(t, imported(), []string{})
+
+	setImports("db")
+	assert.NilError(t, n.settle())
+	assert.DeepEqual(t, imported(), []string{"db"})
+
+	// services defined at the site are never filtered
+	assert.NilError(t, n.expose("b", types.ServiceInterface{Address: "cache", Protocol: "tcp", Ports: []types.ServicePort{{Port: 6379}}}))
+	assert.NilError(t, n.settle())
+	services, err := n.network.Site("a").Services()
+	assert.NilError(t, err)
+	assert.Equal(t, services["cache"].Origin, "b")
+
+	setImports()
+	assert.NilError(t, n.settle())
+	assert.DeepEqual(t, imported(), []string{"cache"})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	jsonencoding "encoding/json"
 	"fmt"
+	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,6 +26,66 @@ const (
 	ServiceSyncError        string = "ServiceSyncError"
 )
 
+const (
+	serviceSyncUpdateSubject  string = "service-sync-update"
+	serviceSyncRequestSubject string = "service-sync-request"
+	serviceSyncIntervalKey    string = "sync-interval"
+)
+
+func serviceSyncIntervalFromEnv() (time.Duration, error) {
+	value := os.Getenv("SKUPPER_SERVICE_SYNC_INTERVAL")
+	if value == "" {
+		return types.DefaultServiceSyncInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("Invalid service sync interval %q", value)
+	}
+	return interval, nil
+}
+
+// serviceSyncAgeLimit is how long a site that advertises its services at
+// the given interval can go unheard before its services are withdrawn
+func serviceSyncAgeLimit(interval time.Duration) time.Duration {
+	if limit := 3 * interval; limit > types.MinServiceSyncAgeLimit {
+		return limit
+	}
+	return types.MinServiceSyncAgeLimit
+}
+
+// requestServiceSync has the services of this site advertised now, and
+// every other site asked to advertise theirs, rather than waiting for the
+// next interval
+func (c *Controller) requestServiceSync() error {
+	if c.disableServiceSync {
+		return fmt.Errorf("Service sync is disabled for this site")
+	}
+	c.triggerServiceSync(true)
+	return nil
+}
+
+// triggerServiceSync has the sender advertise the services of this site
+// now. A trigger already pending is not repeated, though a request to
+// ask other sites to advertise theirs is never lost.
+func (c *Controller) triggerServiceSync(askPeers bool) {
+	for {
+		select {
+		case c.syncRequests <- askPeers:
+			return
+		case pending := <-c.syncRequests:
+			askPeers = askPeers || pending
+		}
+	}
+}
+
+// recordSyncInterval notes the interval at which a site advertises its
+// services, if it says
+func (c *Controller) recordSyncInterval(origin string, value interface{}) {
+	if seconds, ok := value.(int64); ok && seconds > 0 {
+		c.syncIntervals[origin] = time.Duration(seconds) * time.Second
+	}
+}
+
 func (c *Controller) pareByOrigin(service string) {
 	for _, origin := range c.byOrigin {
 		if _, ok := origin[service]; ok {
@@ -50,14 +112,17 @@ func (c *Controller) serviceSyncDefinitionsUpdated(definitions map[string]types.
 
 	for name, original := range definitions {
 		service := types.ServiceInterface{
-			Address:      original.Address,
-			Protocol:     original.Protocol,
-			Port:         original.Port,
-			Origin:       original.Origin,
-			Headless:     original.Headless,
-			Aggregate:    original.Aggregate,
-			EventChannel: original.EventChannel,
-			Targets:      []types.ServiceInterfaceTarget{},
+			Address:        original.Address,
+			Protocol:       original.Protocol,
+			Ports:          original.Ports,
+			Origin:         original.Origin,
+			Headless:       original.Headless,
+			Aggregate:      original.Aggregate,
+			EventChannel:   original.EventChannel,
+			Metadata:       original.Metadata,
+			TlsCredentials: original.TlsCredentials,
+			TlsTrust:       original.TlsTrust,
+			Targets:        []types.ServiceInterfaceTarget{},
 		}
 		if service.Origin != "" && service.Origin != "annotation" {
 			if _, ok := c.byOrigin[service.Origin]; !ok {
@@ -96,12 +161,21 @@ func (c *Controller) serviceSyncDefinitionsUpdated(definitions map[string]types.
 		event.Recordf(ServiceSyncServiceEvent, "Service interface(s) modified %s", strings.Join(getAddresses(modified), ","))
 	}
 
+	if len(added) > 0 || len(removed) > 0 || len(modified) > 0 {
+		c.localEncoded = ""
+	}
 	c.localServices = latest
 	c.byName = byName
 }
 
 func equivalentServiceDefinition(a *types.ServiceInterface, b *types.ServiceInterface) bool {
-	if a.Protocol != b.Protocol || a.Port != b.Port || a.EventChannel != b.EventChannel || a.Aggregate != b.Aggregate {
+	if a.Protocol != b.Protocol || !a.SamePorts(b) || a.EventChannel != b.EventChannel || a.Aggregate != b.Aggregate {
+		return false
+	}
+	if !equivalentMetadata(a.Metadata, b.Metadata) {
+		return false
+	}
+	if a.TlsCredentials != b.TlsCredentials || a.TlsTrust != b.TlsTrust {
 		return false
 	}
 	if a.Headless == nil && b.Headless == nil {
@@ -117,6 +191,18 @@ func equivalentServiceDefinition(a *types.ServiceInterface, b *types.ServiceInte
 	}
 }
 
+func equivalentMetadata(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Controller) ensureServiceInterfaceDefinitions(origin string, serviceInterfaceDefs map[string]types.ServiceInterface) {
 	var changed []types.ServiceInterface
 	var deleted []string
@@ -162,61 +248,160 @@ func (c *Controller) syncSender(sendLocal chan bool) {
 		sender.Close(ctx)
 	}()
 
-	tickerSend := time.NewTicker(5 * time.Second)
+	interval := c.syncInterval
+	if interval <= 0 {
+		interval = types.DefaultServiceSyncInterval
+	}
+	tickerSend := time.NewTicker(interval)
 	tickerAge := time.NewTicker(30 * time.Second)
 
-	properties.Subject = "service-sync-update"
+	properties.Subject = serviceSyncUpdateSubject
 	request.Properties = &properties
 	request.ApplicationProperties = make(map[string]interface{})
 	request.ApplicationProperties["origin"] = c.origin
 	request.ApplicationProperties["version"] = client.Version
+	request.ApplicationProperties[serviceSyncIntervalKey] = int64(interval / time.Second)
+
+	syncRequest := amqp.Message{
+		Properties: &amqp.MessageProperties{Subject: serviceSyncRequestSubject},
+		ApplicationProperties: map[string]interface{}{
+			"origin":  c.origin,
+			"version": client.Version,
+		},
+	}
+
+	sendUpdate := func() bool {
+		encoded, err := c.encodeServiceSyncUpdate()
+		if err != nil {
+			event.Recordf(ServiceSyncError, "Failed to create json for service definition sync: %s", err.Error())
+			return false
+		}
+		request.Value = encoded
+		err = sender.Send(ctx, &request)
+		return true
+	}
 
 	for {
 		select {
 		case <-tickerSend.C:
-			local := make([]types.ServiceInterface, 0)
-
-			for _, si := range c.localServices {
-				local = append(local, si)
+			if !sendUpdate() {
+				return
 			}
 
-			encoded, err := jsonencoding.Marshal(local)
-			if err != nil {
-				event.Recordf(ServiceSyncError, "Failed to create json for service definition sync: %s", err.Error())
+		case askPeers := <-c.syncRequests:
+			if !sendUpdate() {
 				return
 			}
-			request.Value = string(encoded)
-			err = sender.Send(ctx, &request)
+			if askPeers {
+				event.Recordf(ServiceSyncSiteEvent, "Requesting service definitions from all sites")
+				if err := sender.Send(ctx, &syncRequest); err != nil {
+					event.Recordf(ServiceSyncError, "Failed to request service definitions: %s", err)
+				}
+			}
 
 		case <-tickerAge.C:
-			var agedOrigins []string
-
-			now := time.Now()
-
-			for origin, _ := range c.byOrigin {
-				var deleted []string
-
-				if lastHeard, ok := c.heardFrom[origin]; ok {
-					if now.Sub(lastHeard) >= 60*time.Second {
-						agedOrigins = append(agedOrigins, origin)
-						agedDefinitions := c.byOrigin[origin]
-						for name, _ := range agedDefinitions {
-							deleted = append(deleted, name)
-						}
-						if len(deleted) > 0 {
-							kube.UpdateSkupperServices([]types.ServiceInterface{}, deleted, origin, c.vanClient.Namespace, c.vanClient.KubeClient)
-						}
-					}
+			c.ageServiceDefinitions(time.Now())
+		}
+	}
+}
+
+// encodeServiceSyncUpdate returns the service sync message body that
+// advertises the services defined at this site. The body is only
+// re-encoded when the local services change, as it is sent every few
+// seconds regardless.
+func (c *Controller) encodeServiceSyncUpdate() (string, error) {
+	if c.localEncoded != "" {
+		return c.localEncoded, nil
+	}
+	local := make([]types.ServiceInterface, 0, len(c.localServices))
+
+	for _, si := range c.localServices {
+		local = append(local, si)
+	}
+	sort.Slice(local, func(i, j int) bool {
+		return local[i].Address < local[j].Address
+	})
+
+	encoded, err := jsonencoding.Marshal(local)
+	if err != nil {
+		return "", err
+	}
+	c.localEncoded = string(encoded)
+	return c.localEncoded, nil
+}
+
+// ageServiceDefinitions removes the definitions from any origin that
+// has not been heard from for a minute, or three of its sync intervals
+// if longer
+func (c *Controller) ageServiceDefinitions(now time.Time) {
+	var agedOrigins []string
+
+	for origin, _ := range c.byOrigin {
+		var deleted []string
+
+		if lastHeard, ok := c.heardFrom[origin]; ok {
+			if now.Sub(lastHeard) >= serviceSyncAgeLimit(c.syncIntervals[origin]) {
+				agedOrigins = append(agedOrigins, origin)
+				agedDefinitions := c.byOrigin[origin]
+				for name, _ := range agedDefinitions {
+					deleted = append(deleted, name)
+				}
+				if len(deleted) > 0 {
+					kube.UpdateSkupperServices([]types.ServiceInterface{}, deleted, origin, c.vanClient.Namespace, c.vanClient.KubeClient)
 				}
 			}
+		}
+	}
 
-			for _, originName := range agedOrigins {
-				event.Recordf(ServiceSyncSiteEvent, "Service sync aged out service definitions from origin %s", originName)
-				delete(c.heardFrom, originName)
-				delete(c.byOrigin, originName)
-			}
+	for _, originName := range agedOrigins {
+		event.Recordf(ServiceSyncSiteEvent, "Service sync aged out service definitions from origin %s", originName)
+		delete(c.heardFrom, originName)
+		delete(c.byOrigin, originName)
+		delete(c.syncIntervals, originName)
+	}
+}
+
+// handleServiceSyncUpdate reconciles the service definitions advertised
+// by another site
+func (c *Controller) handleServiceSyncUpdate(origin string, value interface{}) {
+	if origin == c.origin {
+		return
+	}
+	updates, ok := value.(string)
+	if !ok {
+		event.Recordf(ServiceSyncError, "Skupper service sync update from %s was not a string", origin)
+		return
+	}
+	indexed, err := decodeServiceSyncUpdate(origin, updates)
+	if err != nil {
+		event.Recordf(ServiceSyncError, "Skupper service sync update from %s was not valid json: %s", origin, err)
+		return
+	}
+	if c.serviceImports != nil {
+		c.serviceImports.filter(indexed)
+	}
+	c.ensureServiceInterfaceDefinitions(origin, indexed)
+}
+
+// decodeServiceSyncUpdate indexes the service definitions in an update by
+// address. Definitions that would not be accepted if created locally are
+// dropped, as the update comes from another site.
+func decodeServiceSyncUpdate(origin string, updates string) (map[string]types.ServiceInterface, error) {
+	defs := []types.ServiceInterface{}
+	err := jsonencoding.Unmarshal([]byte(updates), &defs)
+	if err != nil {
+		return nil, err
+	}
+	indexed := make(map[string]types.ServiceInterface)
+	for _, def := range defs {
+		if err := client.ValidateServiceInterface(&def); err != nil {
+			event.Recordf(ServiceSyncError, "Ignoring invalid service definition %q from %s: %s", def.Address, origin, err)
+			continue
 		}
+		def.Origin = origin
+		indexed[def.Address] = def
 	}
+	return indexed, nil
 }
 
 func (c *Controller) runServiceSync() {
@@ -270,29 +455,18 @@ func (c *Controller) runServiceSync() {
 		msg.Accept()
 		subject := msg.Properties.Subject
 
-		if subject == "service-sync-update" {
+		if subject == serviceSyncUpdateSubject {
 			if origin, ok = msg.ApplicationProperties["origin"].(string); ok {
-				if origin != c.origin {
-					if updates, ok := msg.Value.(string); ok {
-						defs := []types.ServiceInterface{}
-						err := jsonencoding.Unmarshal([]byte(updates), &defs)
-						if err == nil {
-							indexed := make(map[string]types.ServiceInterface)
-							for _, def := range defs {
-								def.Origin = origin
-								indexed[def.Address] = def
-							}
-							c.ensureServiceInterfaceDefinitions(origin, indexed)
-						} else {
-							event.Recordf(ServiceSyncError, "Skupper service sync update from %s was not valid json: %s", origin, err)
-						}
-					} else {
-						event.Recordf(ServiceSyncError, "Skupper service sync update from %s was not a string", origin)
-					}
-				}
+				c.recordSyncInterval(origin, msg.ApplicationProperties[serviceSyncIntervalKey])
+				c.handleServiceSyncUpdate(origin, msg.Value)
 			} else {
 				event.Record(ServiceSyncError, "Skupper service sync update type assertion error")
 			}
+		} else if subject == serviceSyncRequestSubject {
+			if origin, ok = msg.ApplicationProperties["origin"].(string); ok && origin != c.origin {
+				event.Recordf(ServiceSyncSiteEvent, "Service definitions requested by %s", origin)
+				c.triggerServiceSync(false)
+			}
 		} else {
 			event.Record(ServiceSyncError, "Service sync subject not valid")
 		}
@@ -0,0 +1,36 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/event"
+)
+
+// FuzzDecodeServiceSyncUpdate checks that no update received from
+// another site panics the controller or is accepted with definitions
+// that could not have been created locally
+func FuzzDecodeServiceSyncUpdate(f *testing.F) {
+	event.StartDefaultEventStore(nil)
+	f.Add(`[{"address": "db", "protocol": "tcp", "port": 5432, "targets": []}]`)
+	f.Add(`[{"address": "web", "protocol": "http", "port": 8080, "aggregate": "json", "headless": {"name": "web", "size": 2}}]`)
+	f.Add(`[{"address": "", "port": -1}, null]`)
+	f.Add(`{"address": "db"}`)
+	f.Fuzz(func(t *testing.T, updates string) {
+		defs, err := decodeServiceSyncUpdate("remote", updates)
+		if err != nil {
+			return
+		}
+		for address, def := range defs {
+			if address != def.Address || def.Origin != "remote" {
+				t.Fatalf("Definition %#v indexed under %q", def, address)
+			}
+			if err := client.ValidateServiceInterface(&def); err != nil {
+				t.Fatalf("Accepted invalid definition %#v: %v", def, err)
+			}
+		}
+	})
+}
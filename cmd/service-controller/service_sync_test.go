@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	is "gotest.tools/assert/cmp"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/simulation"
+)
+
+// syncNetwork runs the service sync of a controller at each site of a
+// simulated network
+type syncNetwork struct {
+	network     *simulation.Network
+	controllers map[string]*Controller
+	// services defined at each site, by site then address
+	local map[string]map[string]types.ServiceInterface
+}
+
+func newSyncNetwork(names ...string) (*syncNetwork, error) {
+	n := &syncNetwork{
+		network:     simulation.NewNetwork(),
+		controllers: map[string]*Controller{},
+		local:       map[string]map[string]types.ServiceInterface{},
+	}
+	for _, name := range names {
+		site, err := n.network.AddSite(name)
+		if err != nil {
+			return nil, err
+		}
+		c := &Controller{
+			vanClient:     site.Client,
+			origin:        name,
+			byOrigin:      make(map[string]map[string]types.ServiceInterface),
+			localServices: make(map[string]types.ServiceInterface),
+			byName:        make(map[string]types.ServiceInterface),
+			heardFrom:     make(map[string]time.Time),
+		}
+		site.Subscribe(func(origin string, value string) {
+			c.handleServiceSyncUpdate(origin, value)
+		})
+		n.controllers[name] = c
+		n.local[name] = map[string]types.ServiceInterface{}
+	}
+	return n, nil
+}
+
+func (n *syncNetwork) expose(site string, service types.ServiceInterface) error {
+	n.local[site][service.Address] = service
+	return n.network.Site(site).Expose(service)
+}
+
+func (n *syncNetwork) unexpose(site string, address string) error {
+	delete(n.local[site], address)
+	return n.network.Site(site).Unexpose(address)
+}
+
+// round has every controller pick up its skupper-services ConfigMap, as
+// the informer would, then send its periodic update
+func (n *syncNetwork) round() error {
+	for _, site := range n.network.Sites() {
+		cm, err := site.ServiceConfigMap()
+		if err != nil {
+			return err
+		}
+		n.controllers[site.Name].updateServiceSync(cm)
+	}
+	for _, site := range n.network.Sites() {
+		encoded, err := n.controllers[site.Name].encodeServiceSyncUpdate()
+		if err != nil {
+			return err
+		}
+		site.Publish(encoded)
+	}
+	return nil
+}
+
+// settle lets every site age out origins it can no longer hear from,
+// then runs enough rounds for the remaining definitions to propagate
+func (n *syncNetwork) settle() error {
+	if err := n.round(); err != nil {
+		return err
+	}
+	for _, c := range n.controllers {
+		c.ageServiceDefinitions(time.Now().Add(2 * time.Minute))
+	}
+	for i := 0; i < 2; i++ {
+		if err := n.round(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expected returns the definitions a site should have once settled:
+// its own, and those of every site it can reach with that site as the
+// origin
+func (n *syncNetwork) expected(site string) map[string]string {
+	result := map[string]string{}
+	for name := range n.network.Reachable(site) {
+		for address, service := range n.local[name] {
+			origin := name
+			if name == site {
+				origin = ""
+			}
+			result[address] = fmt.Sprintf("%s %s:%d from %q", address, service.Protocol, service.PrimaryPort(), origin)
+		}
+	}
+	return result
+}
+
+func (n *syncNetwork) actual(site string) (map[string]string, error) {
+	services, err := n.network.Site(site).Services()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	for address, service := range services {
+		result[address] = fmt.Sprintf("%s %s:%d from %q", address, service.Protocol, service.PrimaryPort(), service.Origin)
+	}
+	return result, nil
+}
+
+func (n *syncNetwork) check(t *testing.T, step string) {
+	for _, site := range n.network.Sites() {
+		actual, err := n.actual(site.Name)
+		assert.NilError(t, err)
+		assert.Assert(t, is.DeepEqual(actual, n.expected(site.Name)), "%s: services at %s", step, site.Name)
+	}
+}
+
+func TestServiceSyncPropagation(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	n, err := newSyncNetwork("east", "central", "west")
+	assert.NilError(t, err)
+	assert.NilError(t, n.network.Link("east", "central"))
+	assert.NilError(t, n.network.Link("west", "central"))
+	assert.NilError(t, n.expose("east", types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}}))
+	assert.NilError(t, n.expose("west", types.ServiceInterface{Address: "web", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}}))
+	assert.NilError(t, n.settle())
+	n.check(t, "initial")
+
+	services, err := n.network.Site("west").Services()
+	assert.NilError(t, err)
+	assert.Equal(t, services["db"].Origin, "east")
+
+	// a change at the origin is propagated
+	assert.NilError(t, n.expose("east", types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5433}}}))
+	assert.NilError(t, n.settle())
+	n.check(t, "changed")
+
+	// and so is removal
+	assert.NilError(t, n.unexpose("west", "web"))
+	assert.NilError(t, n.settle())
+	n.check(t, "removed")
+
+	// sites that can no longer be heard from are aged out
+	assert.NilError(t, n.expose("west", types.ServiceInterface{Address: "web", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}}))
+	assert.NilError(t, n.settle())
+	n.network.Unlink("west", "central")
+	assert.NilError(t, n.settle())
+	n.check(t, "partitioned")
+	services, err = n.network.Site("east").Services()
+	assert.NilError(t, err)
+	_, ok := services["web"]
+	assert.Assert(t, !ok)
+}
+
+func TestServiceSyncLocalDefinitionWins(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	n, err := newSyncNetwork("a", "b", "c")
+	assert.NilError(t, err)
+	assert.NilError(t, n.network.Link("a", "b"))
+	assert.NilError(t, n.network.Link("b", "c"))
+	assert.NilError(t, n.expose("a", types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}}))
+	assert.NilError(t, n.expose("b", types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 3306}}}))
+	assert.NilError(t, n.settle())
+
+	for _, site := range []string{"a", "b"} {
+		services, err := n.network.Site(site).Services()
+		assert.NilError(t, err)
+		assert.Equal(t, services["db"].Origin, "", site)
+		assert.DeepEqual(t, services["db"], n.local[site]["db"])
+	}
+	// a site that hears of the address from two origins settles on one
+	services, err := n.network.Site("c").Services()
+	assert.NilError(t, err)
+	origin := services["db"].Origin
+	assert.Assert(t, origin == "a" || origin == "b", origin)
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, n.round())
+		services, err = n.network.Site("c").Services()
+		assert.NilError(t, err)
+		assert.Equal(t, services["db"].Origin, origin)
+	}
+}
+
+func TestServiceSyncIgnoresInvalidUpdates(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	n, err := newSyncNetwork("a", "b")
+	assert.NilError(t, err)
+	assert.NilError(t, n.network.Link("a", "b"))
+	n.network.Site("a").Publish("not json")
+	n.controllers["b"].handleServiceSyncUpdate("a", 42)
+	n.network.Site("a").Publish(`[{"address": "", "protocol": "tcp", "port": 8080}, {"address": "db", "protocol": "tcp", "port": 70000}, {"address": "web", "protocol": "gopher", "port": 80}]`)
+	services, err := n.network.Site("b").Services()
+	assert.NilError(t, err)
+	assert.Equal(t, len(services), 0)
+}
+
+func TestServiceSyncEncodingFollowsLocalServices(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	c := &Controller{
+		byOrigin:      make(map[string]map[string]types.ServiceInterface),
+		localServices: make(map[string]types.ServiceInterface),
+	}
+	c.serviceSyncDefinitionsUpdated(map[string]types.ServiceInterface{
+		"b": {Address: "b", Protocol: "tcp", Ports: []types.ServicePort{{Port: 8080}}},
+		"a": {Address: "a", Protocol: "tcp", Ports: []types.ServicePort{{Port: 8080}}},
+	})
+	first, err := c.encodeServiceSyncUpdate()
+	assert.NilError(t, err)
+	assert.Assert(t, is.Regexp(`^\[\{"address":"a".*\{"address":"b"`, first))
+	c.serviceSyncDefinitionsUpdated(map[string]types.ServiceInterface{
+		"b": {Address: "b", Protocol: "tcp", Ports: []types.ServicePort{{Port: 8080}}},
+		"a": {Address: "a", Protocol: "tcp", Ports: []types.ServicePort{{Port: 8080}}},
+	})
+	assert.Equal(t, c.localEncoded, first)
+	c.serviceSyncDefinitionsUpdated(map[string]types.ServiceInterface{
+		"a": {Address: "a", Protocol: "tcp", Ports: []types.ServicePort{{Port: 9090}}},
+	})
+	second, err := c.encodeServiceSyncUpdate()
+	assert.NilError(t, err)
+	assert.Assert(t, is.Contains(second, `"port":9090`))
+	assert.Assert(t, !strings.Contains(second, `"address":"b"`))
+}
+
+func BenchmarkEncodeServiceSyncUpdate(b *testing.B) {
+	event.StartDefaultEventStore(nil)
+	c := &Controller{
+		byOrigin:      make(map[string]map[string]types.ServiceInterface),
+		localServices: make(map[string]types.ServiceInterface),
+	}
+	definitions := map[string]types.ServiceInterface{}
+	for i := 0; i < 1000; i++ {
+		address := fmt.Sprintf("service-%d", i)
+		definitions[address] = types.ServiceInterface{Address: address, Protocol: "tcp", Ports: []types.ServicePort{{Port: 8080}}}
+	}
+	c.serviceSyncDefinitionsUpdated(definitions)
+	b.Run("unchanged", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := c.encodeServiceSyncUpdate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("changed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.localEncoded = ""
+			if _, err := c.encodeServiceSyncUpdate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+var syncProtocols = []string{"tcp", "http", "http2"}
+
+// runSyncScenario builds a random network, then repeatedly changes its
+// services or links and checks that every site converges on the
+// services of the sites it can reach
+func runSyncScenario(t *testing.T, r *rand.Rand) {
+	names := []string{}
+	sites := 2 + r.Intn(4)
+	for i := 0; i < sites; i++ {
+		names = append(names, fmt.Sprintf("site-%d", i))
+	}
+	n, err := newSyncNetwork(names...)
+	assert.NilError(t, err)
+	for i := range names {
+		for j := 0; j < i; j++ {
+			if r.Intn(2) == 0 {
+				assert.NilError(t, n.network.Link(names[i], names[j]))
+			}
+		}
+	}
+	next := 0
+	newService := func(site string) types.ServiceInterface {
+		next++
+		return types.ServiceInterface{
+			Address:  fmt.Sprintf("%s-svc-%d", site, next),
+			Protocol: syncProtocols[r.Intn(len(syncProtocols))],
+			Ports:    []types.ServicePort{{Port: 1024 + r.Intn(1000)}},
+		}
+	}
+	for _, name := range names {
+		for i := r.Intn(3); i > 0; i-- {
+			assert.NilError(t, n.expose(name, newService(name)))
+		}
+	}
+	assert.NilError(t, n.settle())
+	n.check(t, "initial")
+
+	for step := 0; step < 5; step++ {
+		site := names[r.Intn(len(names))]
+		other := names[r.Intn(len(names))]
+		var description string
+		switch r.Intn(5) {
+		case 0:
+			service := newService(site)
+			description = fmt.Sprintf("expose %s at %s", service.Address, site)
+			assert.NilError(t, n.expose(site, service))
+		case 1:
+			addresses := []string{}
+			for address := range n.local[site] {
+				addresses = append(addresses, address)
+			}
+			if len(addresses) == 0 {
+				continue
+			}
+			sort.Strings(addresses)
+			address := addresses[r.Intn(len(addresses))]
+			description = fmt.Sprintf("unexpose %s at %s", address, site)
+			assert.NilError(t, n.unexpose(site, address))
+		case 2:
+			for address, service := range n.local[site] {
+				service.Ports = []types.ServicePort{{Port: service.PrimaryPort() + 1}}
+				description = fmt.Sprintf("change port of %s at %s", address, site)
+				assert.NilError(t, n.expose(site, service))
+				break
+			}
+		case 3:
+			if site == other {
+				continue
+			}
+			description = fmt.Sprintf("link %s to %s", site, other)
+			assert.NilError(t, n.network.Link(site, other))
+		case 4:
+			description = fmt.Sprintf("unlink %s from %s", site, other)
+			n.network.Unlink(site, other)
+		}
+		assert.NilError(t, n.settle())
+		n.check(t, description)
+	}
+}
+
+func TestServiceSyncScenarios(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	scenarios := 2000
+	if testing.Short() {
+		scenarios = 200
+	}
+	for seed := 0; seed < scenarios; seed++ {
+		r := rand.New(rand.NewSource(int64(seed)))
+		t.Run(fmt.Sprintf("seed-%d", seed), func(t *testing.T) {
+			runSyncScenario(t, r)
+		})
+	}
+}
+
+func TestServiceSyncIntervalFromEnv(t *testing.T) {
+	defer os.Unsetenv("SKUPPER_SERVICE_SYNC_INTERVAL")
+	interval, err := serviceSyncIntervalFromEnv()
+	assert.NilError(t, err)
+	assert.Equal(t, interval, types.DefaultServiceSyncInterval)
+
+	os.Setenv("SKUPPER_SERVICE_SYNC_INTERVAL", "30s")
+	interval, err = serviceSyncIntervalFromEnv()
+	assert.NilError(t, err)
+	assert.Equal(t, interval, 30*time.Second)
+
+	for _, value := range []string{"soon", "100ms", "-5s"} {
+		os.Setenv("SKUPPER_SERVICE_SYNC_INTERVAL", value)
+		_, err = serviceSyncIntervalFromEnv()
+		assert.Error(t, err, fmt.Sprintf("Invalid service sync interval %q", value))
+	}
+}
+
+func TestServiceSyncAgesByAdvertisedInterval(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	c := &Controller{
+		byOrigin:      map[string]map[string]types.ServiceInterface{"fast": {}, "slow": {}},
+		heardFrom:     map[string]time.Time{},
+		syncIntervals: map[string]time.Duration{},
+	}
+	now := time.Now()
+	c.heardFrom["fast"] = now
+	c.heardFrom["slow"] = now
+	c.recordSyncInterval("fast", int64(5))
+	c.recordSyncInterval("slow", int64(120))
+	c.recordSyncInterval("slow", "bogus")
+	assert.Equal(t, c.syncIntervals["slow"], 2*time.Minute)
+
+	c.ageServiceDefinitions(now.Add(90 * time.Second))
+	_, ok := c.byOrigin["fast"]
+	assert.Assert(t, !ok)
+	_, ok = c.byOrigin["slow"]
+	assert.Assert(t, ok)
+
+	c.ageServiceDefinitions(now.Add(6 * time.Minute))
+	_, ok = c.byOrigin["slow"]
+	assert.Assert(t, !ok)
+	assert.Equal(t, len(c.syncIntervals), 0)
+}
+
+func TestRequestServiceSync(t *testing.T) {
+	c := &Controller{syncRequests: make(chan bool, 1)}
+	c.triggerServiceSync(false)
+	c.triggerServiceSync(false)
+	assert.Equal(t, <-c.syncRequests, false)
+
+	// a request to ask other sites is kept when merged with a pending
+	// trigger from another site
+	assert.NilError(t, c.requestServiceSync())
+	c.triggerServiceSync(false)
+	assert.Equal(t, <-c.syncRequests, true)
+	assert.Equal(t, len(c.syncRequests), 0)
+
+	c.disableServiceSync = true
+	assert.Error(t, c.requestServiceSync(), "Service sync is disabled for this site")
+
+	server := &ConsoleServer{}
+	handler := server.serviceSync()
+	request := func(method string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/servicesync", nil))
+		return w.Code
+	}
+	assert.Equal(t, request(http.MethodPost), http.StatusServiceUnavailable)
+	server.requestServiceSync = c.requestServiceSync
+	assert.Equal(t, request(http.MethodGet), http.StatusMethodNotAllowed)
+	assert.Equal(t, request(http.MethodPost), http.StatusConflict)
+	c.disableServiceSync = false
+	assert.Equal(t, request(http.MethodPost), http.StatusAccepted)
+	assert.Equal(t, <-c.syncRequests, true)
+}
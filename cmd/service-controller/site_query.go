@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
@@ -16,6 +18,7 @@ import (
 	"github.com/skupperproject/skupper/pkg/data"
 	"github.com/skupperproject/skupper/pkg/event"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/naming"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
@@ -24,6 +27,8 @@ const (
 	SiteQueryRequest    string = "SiteQueryRequest"
 	ServiceCheckError   string = "ServiceCheckError"
 	ServiceCheckRequest string = "ServiceCheckRequest"
+	PeerViewError       string = "PeerViewError"
+	PeerViewRequest     string = "PeerViewRequest"
 )
 
 type SiteQueryServer struct {
@@ -81,13 +86,42 @@ func (s *SiteQueryServer) getLocalSiteQueryData() (data.SiteQueryData, error) {
 	if err != nil {
 		return data, fmt.Errorf("Error getting local service info: %s", err)
 	}
+	s.markGrpcServices(data.HttpServices)
 	return data, nil
 }
 
+// markGrpcServices reports services using grpc as such, rather than as
+// the http2 the router bridges them as
+func (s *SiteQueryServer) markGrpcServices(services []data.HttpService) {
+	definitions, err := s.client.ServiceInterfaceList(context.Background())
+	if err != nil {
+		event.Recordf(SiteQueryError, "Failed to retrieve service definitions: %s", err)
+		return
+	}
+	data.MarkGrpcServices(services, grpcAddresses(definitions))
+}
+
+// grpcAddresses returns the router addresses of the ports of services
+// that use grpc
+func grpcAddresses(definitions []*types.ServiceInterface) map[string]bool {
+	addresses := map[string]bool{}
+	for _, definition := range definitions {
+		for _, port := range definition.Ports {
+			if definition.ProtocolFor(port) == ProtocolGRPC {
+				addresses[definition.AddressForPort(port.Port)] = true
+			}
+		}
+	}
+	return addresses
+}
+
 func getSiteUrl(vanClient *client.VanClient) (string, error) {
 	if vanClient.RouteClient == nil {
-		service, err := vanClient.KubeClient.CoreV1().Services(vanClient.Namespace).Get(types.TransportServiceName, metav1.GetOptions{})
-		if err != nil {
+		service, err := kube.GetRouterIngressService(vanClient.Namespace, vanClient.KubeClient)
+		if errors.IsNotFound(err) {
+			// the site does not accept links
+			return "", nil
+		} else if err != nil {
 			return "", err
 		} else {
 			if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
@@ -113,11 +147,24 @@ func getSiteQueryAddress(siteId string) string {
 
 const (
 	ServiceCheck string = "service-check"
+	PeerView     string = "peer-view"
+	LinkProbe    string = "link-probe"
 )
 
 func (s *SiteQueryServer) Request(request *qdr.Request) (*qdr.Response, error) {
 	if request.Type == ServiceCheck {
 		return s.HandleServiceCheck(request)
+	} else if request.Type == PeerView {
+		return s.HandlePeerView(request)
+	} else if request.Type == LinkCertRenewal {
+		return s.HandleLinkCertRenewal(request)
+	} else if request.Type == LinkProbe {
+		// echo the probe back so that both directions are tested
+		return &qdr.Response{
+			Version: client.Version,
+			Type:    request.Type,
+			Body:    request.Body,
+		}, nil
 	} else {
 		return s.HandleSiteQuery(request)
 	}
@@ -174,6 +221,49 @@ func (s *SiteQueryServer) HandleServiceCheck(request *qdr.Request) (*qdr.Respons
 	}, nil
 }
 
+func (s *SiteQueryServer) HandlePeerView(request *qdr.Request) (*qdr.Response, error) {
+	peer := data.PeerViewRequest{}
+	err := json.Unmarshal([]byte(request.Body), &peer)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse peer view request: %s", err)
+	}
+	event.Recordf(PeerViewRequest, "peer view requested by %s", peer.SiteId)
+	view := data.PeerView{
+		SiteId:   s.siteInfo.SiteId,
+		SiteName: s.siteInfo.SiteName,
+		Services: []string{},
+	}
+	agent, err := s.agentPool.Get()
+	if err != nil {
+		return nil, fmt.Errorf("Could not get management agent: %s", err)
+	}
+	connections, err := agent.GetConnections()
+	s.agentPool.Put(agent)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving connections: %s", err)
+	}
+	view.Linked = data.IsLinkedTo(connections, peer.Routers)
+	services, err := s.client.ServiceInterfaceList(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving services: %s", err)
+	}
+	for _, service := range services {
+		if service.Origin == peer.SiteId {
+			view.Services = append(view.Services, service.Address)
+		}
+	}
+	view.Time = time.Now()
+	bytes, err := json.Marshal(view)
+	if err != nil {
+		return nil, fmt.Errorf("Could not encode peer view response: %s", err)
+	}
+	return &qdr.Response{
+		Version: client.Version,
+		Type:    request.Type,
+		Body:    string(bytes),
+	}, nil
+}
+
 func (s *SiteQueryServer) run() {
 	for {
 		ctxt := context.Background()
@@ -203,7 +293,7 @@ func (s *SiteQueryServer) getServiceDetail(context context.Context, address stri
 	}
 	detail.Definition = *definition
 
-	service, err := kube.GetService(address, s.client.Namespace, s.client.KubeClient)
+	service, err := kube.GetService(naming.ServiceName(address), s.client.Namespace, s.client.KubeClient)
 	if err != nil {
 		return detail, err
 	}
@@ -213,16 +303,17 @@ func (s *SiteQueryServer) getServiceDetail(context context.Context, address stri
 	} else if len(service.Spec.Ports) > 1 {
 		var name string
 		for _, ports := range service.Spec.Ports {
-			if int(ports.Port) == detail.Definition.Port {
+			if int(ports.Port) == detail.Definition.PrimaryPort() {
 				name = ports.Name
 				detail.IngressBinding.ServicePort = int(ports.Port)
 				detail.IngressBinding.ServiceTargetPort = ports.TargetPort.IntValue()
 				break
 			}
 		}
+		// the bindings for the primary port of the service are checked
 		if name == "" {
 			detail.AddObservation("Service Spec has multiple ports defined, none of which match port in definition")
-		} else {
+		} else if len(service.Spec.Ports) != len(detail.Definition.Ports) {
 			detail.AddObservation("Service Spec has multiple ports defined; using " + name)
 		}
 	} else {
@@ -273,7 +364,7 @@ func (s *SiteQueryServer) getServiceDetail(context context.Context, address stri
 				Host: connector.Host,
 			})
 		}
-	} else if detail.Definition.Protocol == "http" || detail.Definition.Protocol == "http2" {
+	} else if detail.Definition.Protocol == "http" || detail.Definition.Protocol == "http2" || detail.Definition.Protocol == "grpc" {
 		listener, err := agent.GetLocalHttpListener(detail.Definition.Address, detail.IngressBinding.ServiceTargetPort)
 		if err != nil {
 			return detail, fmt.Errorf("Error retrieving http listener for %s: %s", detail.Definition.Address, err)
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	WatermarkEvent string = "WatermarkEvent"
+	WatermarkAlarm string = "WatermarkAlarm"
+	WatermarkError string = "WatermarkError"
+)
+
+type Watermarks struct {
+	Memory      uint64
+	Connections int
+	Undelivered int
+	ShedLoad    bool
+}
+
+// Reads the watermarks set on the controller deployment; returns nil
+// if none have been configured
+func watermarksFromEnv() (*Watermarks, error) {
+	watermarks := &Watermarks{}
+	enabled := false
+	if value := os.Getenv("SKUPPER_WATERMARK_MEMORY"); value != "" {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid memory watermark %q: %s", value, err)
+		}
+		watermarks.Memory = uint64(quantity.Value())
+		enabled = true
+	}
+	if value := os.Getenv("SKUPPER_WATERMARK_CONNECTIONS"); value != "" {
+		connections, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid connection watermark %q: %s", value, err)
+		}
+		watermarks.Connections = connections
+		enabled = true
+	}
+	if value := os.Getenv("SKUPPER_WATERMARK_UNDELIVERED"); value != "" {
+		undelivered, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid undelivered watermark %q: %s", value, err)
+		}
+		watermarks.Undelivered = undelivered
+		enabled = true
+	}
+	if !enabled {
+		return nil, nil
+	}
+	watermarks.ShedLoad = os.Getenv("SKUPPER_WATERMARK_SHED_LOAD") == "true"
+	return watermarks, nil
+}
+
+// Returns a description of each watermark exceeded by the supplied
+// usage
+func (w *Watermarks) exceeded(usage *qdr.RouterUsage) []string {
+	exceeded := []string{}
+	if w.Memory > 0 && usage.MemoryUsage >= w.Memory {
+		exceeded = append(exceeded, fmt.Sprintf("memory usage %d bytes exceeds watermark of %d", usage.MemoryUsage, w.Memory))
+	}
+	if w.Connections > 0 && usage.Connections >= w.Connections {
+		exceeded = append(exceeded, fmt.Sprintf("%d open connections exceeds watermark of %d", usage.Connections, w.Connections))
+	}
+	if w.Undelivered > 0 && usage.Undelivered >= w.Undelivered {
+		exceeded = append(exceeded, fmt.Sprintf("%d undelivered messages exceeds watermark of %d", usage.Undelivered, w.Undelivered))
+	}
+	return exceeded
+}
+
+// Periodically checks router usage against the configured watermarks,
+// recording an alarm when they are exceeded and, if requested, having
+// the config sync withdraw bridge listeners until usage falls again
+type WatermarkMonitor struct {
+	watermarks *Watermarks
+	agentPool  *qdr.AgentPool
+	configSync *ConfigSync
+	alarmed    bool
+}
+
+func newWatermarkMonitor(watermarks *Watermarks, config *tls.Config, configSync *ConfigSync) *WatermarkMonitor {
+	return &WatermarkMonitor{
+		watermarks: watermarks,
+		agentPool:  qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", config),
+		configSync: configSync,
+	}
+}
+
+func (m *WatermarkMonitor) start(stopCh <-chan struct{}) {
+	go wait.Until(m.check, 30*time.Second, stopCh)
+}
+
+func (m *WatermarkMonitor) check() {
+	agent, err := m.agentPool.Get()
+	if err != nil {
+		event.Recordf(WatermarkError, "Could not get management agent: %s", err)
+		return
+	}
+	usage, err := agent.GetLocalRouterUsage()
+	m.agentPool.Put(agent)
+	if err != nil {
+		event.Recordf(WatermarkError, "Could not retrieve router usage: %s", err)
+		return
+	}
+	exceeded := m.watermarks.exceeded(usage)
+	for _, description := range exceeded {
+		event.Record(WatermarkAlarm, description)
+	}
+	if len(exceeded) > 0 && !m.alarmed {
+		m.alarmed = true
+		if m.watermarks.ShedLoad {
+			event.Record(WatermarkAlarm, "Shedding load: withdrawing bridge listeners")
+			m.configSync.setShedding(true)
+		}
+	} else if len(exceeded) == 0 && m.alarmed {
+		m.alarmed = false
+		event.Record(WatermarkEvent, "Router usage has fallen below all watermarks")
+		if m.watermarks.ShedLoad {
+			event.Record(WatermarkEvent, "Restoring bridge listeners")
+			m.configSync.setShedding(false)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestWatermarksFromEnv(t *testing.T) {
+	watermarks, err := watermarksFromEnv()
+	if err != nil || watermarks != nil {
+		t.Errorf("Expected no watermarks when none are set, got %v, %v", watermarks, err)
+	}
+
+	os.Setenv("SKUPPER_WATERMARK_MEMORY", "1Ki")
+	os.Setenv("SKUPPER_WATERMARK_CONNECTIONS", "100")
+	os.Setenv("SKUPPER_WATERMARK_SHED_LOAD", "true")
+	defer os.Unsetenv("SKUPPER_WATERMARK_MEMORY")
+	defer os.Unsetenv("SKUPPER_WATERMARK_CONNECTIONS")
+	defer os.Unsetenv("SKUPPER_WATERMARK_SHED_LOAD")
+	watermarks, err = watermarksFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := Watermarks{Memory: 1024, Connections: 100, ShedLoad: true}
+	if *watermarks != expected {
+		t.Errorf("Expected %v, got %v", expected, *watermarks)
+	}
+
+	os.Setenv("SKUPPER_WATERMARK_CONNECTIONS", "lots")
+	if _, err = watermarksFromEnv(); err == nil {
+		t.Errorf("Expected error for invalid connection watermark")
+	}
+}
+
+func TestWatermarksExceeded(t *testing.T) {
+	watermarks := Watermarks{Memory: 1000, Undelivered: 10}
+	if exceeded := watermarks.exceeded(&qdr.RouterUsage{MemoryUsage: 999, Connections: 5000, Undelivered: 9}); len(exceeded) != 0 {
+		t.Errorf("Expected no watermarks exceeded, got %v", exceeded)
+	}
+	if exceeded := watermarks.exceeded(&qdr.RouterUsage{MemoryUsage: 1000, Undelivered: 20}); len(exceeded) != 2 {
+		t.Errorf("Expected two watermarks exceeded, got %v", exceeded)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -233,39 +233,21 @@ func (c *SiteController) checkSite(key string) error {
 		_, err := c.vanClient.RouterInspectNamespace(context.Background(), configmap.ObjectMeta.Namespace)
 		if err == nil {
 			log.Println("Skupper site exists", key)
-			updatedLogging, err := c.vanClient.RouterUpdateLogging(context.Background(), configmap, false)
-			if err != nil {
-				log.Println("Error checking router logging configuration:", err)
-			}
-			updatedDebugMode, err := c.vanClient.RouterUpdateDebugMode(context.Background(), configmap)
-			if err != nil {
-				log.Println("Error updating router debug mode:", err)
+			updates, err := c.vanClient.SiteConfigReconcile(context.Background(), configmap)
+			if len(updates) > 0 {
+				log.Printf("Updated %s for %s", strings.Join(updates, ", "), key)
 			}
-			if updatedLogging {
-				if updatedDebugMode {
-					log.Println("Updated router logging and debug mode for", key)
-				} else {
-					err = c.vanClient.RouterRestart(context.Background(), configmap.ObjectMeta.Namespace)
-					if err != nil {
-						log.Println("Error restarting router:", err)
-					} else {
-						log.Println("Updated router logging for", key)
-					}
-				}
-			} else if updatedDebugMode {
-				log.Println("Updated debug mode for", key)
-			}
-			updatedAnnotations, err := c.vanClient.RouterUpdateAnnotations(context.Background(), configmap)
 			if err != nil {
-				log.Println("Error checking annotations:", err)
-			} else if updatedAnnotations {
-				log.Println("Updated annotations for", key)
+				log.Println("Error reconciling site config:", err)
 			}
-
 			c.checkAllForSite()
 		} else if errors.IsNotFound(err) {
 			log.Println("Initialising skupper site ...")
-			siteConfig, _ := c.vanClient.SiteConfigInspect(context.Background(), configmap)
+			siteConfig, err := c.vanClient.SiteConfigInspect(context.Background(), configmap)
+			if siteConfig == nil {
+				log.Println("Error reading site config: ", err)
+				return err
+			}
 			siteConfig.Spec.SkupperNamespace = siteNamespace
 			err = c.vanClient.RouterCreate(context.Background(), *siteConfig)
 			if err != nil {
@@ -283,19 +265,15 @@ func (c *SiteController) checkSite(key string) error {
 	return nil
 }
 
-func getTokenCost(token *corev1.Secret) (int32, bool) {
-	if token.ObjectMeta.Annotations == nil {
-		return 0, false
-	}
-	if costString, ok := token.ObjectMeta.Annotations[types.TokenCost]; ok {
-		cost, err := strconv.Atoi(costString)
-		if err != nil {
-			log.Printf("Ignoring invalid cost annotation %q", costString)
-			return 0, false
-		}
-		return int32(cost), true
+// getTokenCost returns the cost for a link from its token; an invalid
+// cost is ignored in favour of the router's default
+func getTokenCost(token *corev1.Secret) int32 {
+	cost, err := client.LinkCost(token)
+	if err != nil {
+		log.Printf("Ignoring invalid cost annotation: %s", err)
+		return 1
 	}
-	return 0, false
+	return cost
 }
 
 func (c *SiteController) connect(token *corev1.Secret, namespace string) error {
@@ -303,10 +281,14 @@ func (c *SiteController) connect(token *corev1.Secret, namespace string) error {
 	var options types.ConnectorCreateOptions
 	options.Name = token.ObjectMeta.Name
 	options.SkupperNamespace = namespace
-	if cost, ok := getTokenCost(token); ok {
-		options.Cost = cost
+	options.Cost = getTokenCost(token)
+	err := c.vanClient.ConnectorCreate(context.Background(), token, options)
+	if client.IsTokenRejected(err) || client.IsLinkDirectionRejected(err) {
+		// retrying will not make the token usable
+		log.Printf("Rejected token %s in %s: %s", token.ObjectMeta.Name, namespace, err)
+		return nil
 	}
-	return c.vanClient.ConnectorCreate(context.Background(), token, options)
+	return err
 }
 
 func (c *SiteController) disconnect(name string, namespace string) error {
@@ -321,7 +303,11 @@ func (c *SiteController) disconnect(name string, namespace string) error {
 
 func (c *SiteController) generate(token *corev1.Secret) error {
 	log.Printf("Generating token for request %s...", token.ObjectMeta.Name)
-	generated, _, err := c.vanClient.ConnectorTokenCreate(context.Background(), token.ObjectMeta.Name, token.ObjectMeta.Namespace)
+	// a request may ask for the token to be bound to a site
+	options := types.TokenCreateOptions{
+		Site: token.ObjectMeta.Annotations[types.TokenSiteId],
+	}
+	generated, _, err := c.vanClient.ConnectorTokenCreateWithOptions(context.Background(), token.ObjectMeta.Name, token.ObjectMeta.Namespace, options)
 	if err == nil {
 		token.Data = generated.Data
 		if token.ObjectMeta.Annotations == nil {
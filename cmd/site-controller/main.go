@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/certs"
 )
 
 func describe(i interface{}) {
@@ -34,6 +36,28 @@ func SetupSignalHandler() (stopCh <-chan struct{}) {
 	return stop
 }
 
+const defaultMetricsPort = "9091"
+
+// listenMetrics serves the certificates the site controller issued, and
+// the changes it made to secrets holding credentials, as those counts
+// are only known to the process that did the work. Failing to do so
+// does not stop the controller.
+func listenMetrics() {
+	addr := ":" + defaultMetricsPort
+	if os.Getenv("SKUPPER_SITE_CONTROLLER_METRICS_PORT") != "" {
+		addr = ":" + os.Getenv("SKUPPER_SITE_CONTROLLER_METRICS_PORT")
+	}
+	log.Printf("Metrics server listening on %s", addr)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		certs.WriteMetrics(w)
+	})
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Metrics not served on %s: %s", addr, err)
+	}
+}
+
 func main() {
 	namespace := os.Getenv("NAMESPACE")
 	kubeconfig := os.Getenv("KUBECONFIG")
@@ -42,11 +66,17 @@ func main() {
 	stopCh := SetupSignalHandler()
 
 	// todo, get context from env?
-	cli, err := client.NewClient(namespace, "", kubeconfig)
+	cli, err := client.NewClientWithOptions(client.ClientOptions{
+		Namespace:      namespace,
+		KubeConfigPath: kubeconfig,
+		UserAgent:      "skupper-site-controller/" + client.Version,
+	})
 	if err != nil {
 		log.Fatal("Error getting van client ", err.Error())
 	}
 
+	go listenMetrics()
+
 	controller, err := NewSiteController(cli)
 	if err != nil {
 		log.Fatal("Error getting new site controller ", err.Error())
@@ -1,36 +1,98 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	routev1 "github.com/openshift/api/route/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/data"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/messages"
 )
 
 type ExposeOptions struct {
-	Protocol   string
-	Address    string
-	Port       int
-	TargetPort int
-	Headless   bool
+	Protocol    string
+	Address     string
+	Ports       []int
+	TargetPorts []int
+	Headless    bool
+	HealthCheck types.HealthCheck
+	Metadata    map[string]string
+	IdlePolicy  types.IdlePolicy
+	Tls         serviceTls
+	Protected   bool
+	// TargetNamespace is that of the target, if not the namespace of
+	// the site
+	TargetNamespace string
+}
+
+func addHealthCheckFlags(cmd *cobra.Command, check *types.HealthCheck) {
+	cmd.Flags().StringVar(&check.Protocol, "health-check", "", "Probe targets using the given protocol (tcp, http or grpc) and only route to those that pass")
+	cmd.Flags().StringVar(&check.Path, "health-check-path", "", "The path requested by an http health check (defaults to /)")
+	cmd.Flags().StringVar(&check.Service, "health-check-service", "", "The service whose status a grpc health check asks for (defaults to the server as a whole)")
+	cmd.Flags().IntVar(&check.Port, "health-check-port", 0, "The port to probe (defaults to the target port)")
+	cmd.Flags().IntVar(&check.IntervalSeconds, "health-check-interval", 0, fmt.Sprintf("Seconds between health checks (defaults to %d)", types.HealthCheckDefaultInterval))
+	cmd.Flags().IntVar(&check.TimeoutSeconds, "health-check-timeout", 0, fmt.Sprintf("Seconds to wait for a health check to respond (defaults to %d)", types.HealthCheckDefaultTimeout))
+	cmd.Flags().IntVar(&check.HealthyThreshold, "health-check-healthy-threshold", 0, fmt.Sprintf("Consecutive passes before a target is used (defaults to %d)", types.HealthCheckDefaultHealthyThreshold))
+	cmd.Flags().IntVar(&check.UnhealthyThreshold, "health-check-unhealthy-threshold", 0, fmt.Sprintf("Consecutive failures before a target is withheld (defaults to %d)", types.HealthCheckDefaultUnhealthyThreshold))
+}
+
+func healthCheckFromFlags(check types.HealthCheck) *types.HealthCheck {
+	if check.Protocol == "" {
+		return nil
+	}
+	return &check
+}
+
+func addIdlePolicyFlags(cmd *cobra.Command, policy *types.IdlePolicy) {
+	cmd.Flags().IntVar(&policy.Days, "idle-timeout-days", 0, "Warn when no traffic for the service has crossed the network for this many days")
+	cmd.Flags().BoolVar(&policy.Unexpose, "unexpose-when-idle", false, "Remove the service once it has been idle for the idle timeout")
+}
+
+func idlePolicyFromFlags(policy types.IdlePolicy) (*types.IdlePolicy, error) {
+	if policy.Days == 0 {
+		if policy.Unexpose {
+			return nil, fmt.Errorf("--unexpose-when-idle requires --idle-timeout-days")
+		}
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+type serviceTls struct {
+	credentials string
+	trust       string
+}
+
+func addTlsFlags(cmd *cobra.Command, tls *serviceTls) {
+	cmd.Flags().StringVar(&tls.credentials, "tls-credentials", "", "The secret holding the tls.crt and tls.key with which the router terminates TLS for callers of the service")
+	cmd.Flags().StringVar(&tls.trust, "tls-trust", "", "The secret holding the ca.crt with which the router verifies the targets of the service, originating TLS to them")
 }
 
 func SkupperNotInstalledError(namespace string) error {
-	return fmt.Errorf("Skupper is not installed in Namespace: '" + namespace + "`")
+	return messages.Errorf(messages.SiteNotInstalled, namespace)
 
 }
 
@@ -59,10 +121,23 @@ func expose(cli types.VanClientInterface, ctx context.Context, targetType string
 
 	if service == nil {
 		if options.Headless {
+			if options.TargetNamespace != "" {
+				return "", fmt.Errorf("The headless option is not supported for targets in other namespaces")
+			}
 			if targetType != "statefulset" {
 				return "", fmt.Errorf("The headless option is only supported for statefulsets")
 			}
-			service, err = cli.GetHeadlessServiceConfiguration(targetName, options.Protocol, options.Address, options.Port)
+			if options.HealthCheck.Protocol != "" {
+				return "", fmt.Errorf("Health checks are not supported for headless services")
+			}
+			if len(options.Ports) > 1 {
+				return "", fmt.Errorf("Headless services can only be exposed on a single port")
+			}
+			port := 0
+			if len(options.Ports) == 1 {
+				port = options.Ports[0]
+			}
+			service, err = cli.GetHeadlessServiceConfiguration(targetName, options.Protocol, options.Address, port)
 			if err != nil {
 				return "", err
 			}
@@ -70,7 +145,7 @@ func expose(cli types.VanClientInterface, ctx context.Context, targetType string
 		} else {
 			service = &types.ServiceInterface{
 				Address:  serviceName,
-				Port:     options.Port,
+				Ports:    servicePorts(options.Ports),
 				Protocol: options.Protocol,
 			}
 		}
@@ -84,7 +159,34 @@ func expose(cli types.VanClientInterface, ctx context.Context, targetType string
 
 	// service may exist from remote origin
 	service.Origin = ""
-	err = cli.ServiceInterfaceBind(ctx, service, targetType, targetName, options.Protocol, options.TargetPort)
+	if check := healthCheckFromFlags(options.HealthCheck); check != nil {
+		service.HealthCheck = check
+	}
+	if policy, err := idlePolicyFromFlags(options.IdlePolicy); err != nil {
+		return "", err
+	} else if policy != nil {
+		service.IdlePolicy = policy
+	}
+	if options.Tls.credentials != "" {
+		service.TlsCredentials = options.Tls.credentials
+	}
+	if options.Tls.trust != "" {
+		service.TlsTrust = options.Tls.trust
+	}
+	if len(options.Metadata) > 0 {
+		if service.Metadata == nil {
+			service.Metadata = map[string]string{}
+		}
+		for k, v := range options.Metadata {
+			service.Metadata[k] = v
+		}
+	}
+	if options.Protected {
+		protect(service)
+	}
+	err = cli.ServiceInterfaceBindWithOptions(ctx, service, targetType, targetName, options.Protocol, options.TargetPorts, types.ServiceInterfaceBindOptions{
+		TargetNamespace: options.TargetNamespace,
+	})
 	if errors.IsNotFound(err) {
 		return "", SkupperNotInstalledError(cli.GetNamespace())
 	} else if err != nil {
@@ -94,6 +196,23 @@ func expose(cli types.VanClientInterface, ctx context.Context, targetType string
 	return options.Address, nil
 }
 
+// protect marks the service as protected, so that it is only unexposed
+// or deleted when that is forced
+func protect(service *types.ServiceInterface) {
+	if service.Metadata == nil {
+		service.Metadata = map[string]string{}
+	}
+	service.Metadata[types.ProtectedAnnotation] = "true"
+}
+
+func servicePorts(ports []int) []types.ServicePort {
+	var result []types.ServicePort
+	for _, port := range ports {
+		result = append(result, types.ServicePort{Port: port})
+	}
+	return result
+}
+
 func stringSliceContains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -103,10 +222,16 @@ func stringSliceContains(s []string, e string) bool {
 	return false
 }
 
-var validExposeTargets = []string{"deployment", "statefulset", "pods", "service"}
+var validExposeTargets = []string{"deployment", "statefulset", "daemonset", "pods", "service"}
 
 func verifyTargetTypeFromArgs(args []string) error {
 	targetType, _ := parseTargetTypeAndName(args)
+	if platform == types.PlatformPodman {
+		if targetType != client.HostTargetType {
+			return fmt.Errorf("target type must be %s for a site on %s", client.HostTargetType, platform)
+		}
+		return nil
+	}
 	if !stringSliceContains(validExposeTargets, targetType) {
 		return fmt.Errorf("target type must be one of: [%s]", strings.Join(validExposeTargets, ", "))
 	}
@@ -130,9 +255,6 @@ func createServiceArgs(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 || (!strings.Contains(args[0], ":") && len(args) < 2) {
 		return fmt.Errorf("Name and port must be specified")
 	}
-	if len(args) > 2 {
-		return fmt.Errorf("illegal argument: %s", args[2])
-	}
 	if len(args) > 1 && strings.Contains(args[0], ":") {
 		return fmt.Errorf("extra argument: %s", args[1])
 	}
@@ -161,7 +283,16 @@ func NewClient(namespace string, context string, kubeConfigPath string) *client.
 }
 
 func NewClientHandleError(namespace string, context string, kubeConfigPath string, exitOnError bool) *client.VanClient {
-	cli, err := client.NewClient(namespace, context, kubeConfigPath)
+	options := client.ClientOptions{
+		Namespace:      namespace,
+		Context:        context,
+		KubeConfigPath: kubeConfigPath,
+		Platform:       platform,
+		VerifyImages:   true,
+	}
+	// warnings are printed at any verbosity
+	options.Events = newProgressPrinter(os.Stderr, verbosity).handle
+	cli, err := client.NewClientWithOptions(options)
 	if err != nil {
 		if exitOnError {
 			if strings.Contains(err.Error(), "invalid configuration: no configuration has been provided") {
@@ -179,6 +310,10 @@ func NewClientHandleError(namespace string, context string, kubeConfigPath strin
 
 var routerCreateOpts types.SiteConfigSpec
 var routerLogging string
+var routerTolerations []string
+var controllerTolerations []string
+var loadBalancerAnnotations []string
+var routerEgressAnnotations []string
 
 // TODO unit-test me
 func inStringSlice(options []string, value string) bool {
@@ -199,10 +334,116 @@ func inStringSlice(options []string, value string) bool {
 }
 
 var ClusterLocal bool
+var siteConfigFile string
+
+var siteConfigHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+func readSiteConfigFile(location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		resp, err := siteConfigHttpClient.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Could not retrieve %s: %s", location, resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+	return ioutil.ReadFile(location)
+}
+
+func initFromFile(cmd *cobra.Command, ns string) error {
+	conflicting := []string{}
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed && f.Name != "file" {
+			conflicting = append(conflicting, "--"+f.Name)
+		}
+	})
+	if len(conflicting) > 0 {
+		return fmt.Errorf("%s can not be used together with --file", strings.Join(conflicting, ", "))
+	}
+	data, err := readSiteConfigFile(siteConfigFile)
+	if err != nil {
+		return fmt.Errorf("Could not read site config: %s", err)
+	}
+	fromFile, err := cli.SiteConfigParse(context.Background(), data)
+	if err != nil {
+		return err
+	}
+	spec := fromFile.Spec
+	spec.SkupperNamespace = ns
+	spec.SiteControlled = false
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	return initSite(ns, siteConfig, spec)
+}
+
+func initSite(ns string, siteConfig *types.SiteConfig, spec types.SiteConfigSpec) error {
+	var err error
+	if siteConfig == nil {
+		siteConfig, err = cli.SiteConfigCreate(context.Background(), spec)
+		if err != nil {
+			return err
+		}
+	} else {
+		updated, err := cli.SiteConfigUpdate(context.Background(), spec)
+		if err != nil {
+			return fmt.Errorf("Error while trying to update router configuration: %s", err)
+		}
+		if len(updated) > 0 {
+			for _, i := range updated {
+				fmt.Println("Updated", i)
+			}
+		}
+	}
+
+	err = cli.RouterCreate(context.Background(), *siteConfig)
+	if err != nil {
+		return err
+	}
+	fmt.Println(messages.Sprintf(messages.SiteInstalled, ns))
+	if siteConfig.Spec.EnableMetricsApi {
+		if err := cli.MetricsApiRegister(context.Background()); err != nil {
+			fmt.Println("Warning: the custom metrics API could not be registered:", err)
+			fmt.Println("A cluster administrator can register it with 'skupper metrics-api register'.")
+		}
+	}
+	return nil
+}
+
+// parseKeyValues reads the annotations or labels given to the flag, as
+// <key>=<value> or just <key> for an empty value, ignoring any spaces
+// around either
+func parseKeyValues(flag string, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			return nil, fmt.Errorf("Bad value for --%s: %q, expected <key>=<value>", flag, value)
+		}
+		if len(parts) > 1 {
+			result[key] = strings.TrimSpace(parts[1])
+		} else {
+			result[key] = ""
+		}
+	}
+	return result, nil
+}
 
 func NewCmdInit(newClient cobraFunc) *cobra.Command {
 	var routerMode string
 	annotations := []string{}
+	labels := []string{}
 	var isEdge bool
 	cmd := &cobra.Command{
 		Use:   "init",
@@ -216,6 +457,10 @@ installation that can then be connected to other skupper installations`,
 			silenceCobra(cmd)
 			ns := cli.GetNamespace()
 
+			if siteConfigFile != "" {
+				return initFromFile(cmd, ns)
+			}
+
 			routerModeFlag := cmd.Flag("router-mode")
 			edgeFlag := cmd.Flag("edge")
 			if routerModeFlag.Changed && edgeFlag.Changed {
@@ -246,23 +491,44 @@ installation that can then be connected to other skupper installations`,
 					routerCreateOpts.Ingress = types.IngressNoneString
 				}
 			} else if !routerIngressFlag.Changed {
-				routerCreateOpts.Ingress = cli.GetIngressDefault()
-			}
-			for _, a := range annotations {
-				parts := strings.Split(a, "=")
-				if routerCreateOpts.Annotations == nil {
-					routerCreateOpts.Annotations = map[string]string{}
-				}
-				if len(parts) > 1 {
-					routerCreateOpts.Annotations[parts[0]] = parts[1]
+				if routerCreateOpts.IngressService != "" {
+					routerCreateOpts.Ingress = types.IngressLoadBalancerString
+				} else if routerCreateOpts.LinkDirection == types.LinkDirectionOutbound {
+					routerCreateOpts.Ingress = types.IngressNoneString
 				} else {
-					routerCreateOpts.Annotations[parts[0]] = ""
+					routerCreateOpts.Ingress = cli.GetIngressDefault()
 				}
 			}
-			if err := routerCreateOpts.CheckIngress(); err != nil {
+			var err error
+			routerCreateOpts.Annotations, err = parseKeyValues("annotations", annotations)
+			if err != nil {
 				return err
 			}
-			if err := routerCreateOpts.CheckConsoleIngress(); err != nil {
+			routerCreateOpts.Labels, err = parseKeyValues("labels", labels)
+			if err != nil {
+				return err
+			}
+			for _, a := range loadBalancerAnnotations {
+				parts := strings.SplitN(a, "=", 2)
+				if len(parts) != 2 {
+					return fmt.Errorf("Bad value for --load-balancer-annotation: %q, expected <key>=<value>", a)
+				}
+				if routerCreateOpts.LoadBalancerAnnotations == nil {
+					routerCreateOpts.LoadBalancerAnnotations = map[string]string{}
+				}
+				routerCreateOpts.LoadBalancerAnnotations[parts[0]] = parts[1]
+			}
+			for _, a := range routerEgressAnnotations {
+				parts := strings.SplitN(a, "=", 2)
+				if len(parts) != 2 {
+					return fmt.Errorf("Bad value for --router-egress-annotation: %q, expected <key>=<value>", a)
+				}
+				if routerCreateOpts.RouterEgress.Annotations == nil {
+					routerCreateOpts.RouterEgress.Annotations = map[string]string{}
+				}
+				routerCreateOpts.RouterEgress.Annotations[parts[0]] = parts[1]
+			}
+			if err := routerCreateOpts.Validate(); err != nil {
 				return err
 			}
 
@@ -283,50 +549,100 @@ installation that can then be connected to other skupper installations`,
 					return fmt.Errorf("Bad value for --router-debug-mode: %s (use 'valgrind' or 'gdb')", routerCreateOpts.RouterDebugMode)
 				}
 			}
-
-			if siteConfig == nil {
-				siteConfig, err = cli.SiteConfigCreate(context.Background(), routerCreateOpts)
+			if len(routerTolerations) > 0 {
+				tolerations, err := kube.ParseTolerations(strings.Join(routerTolerations, ","))
 				if err != nil {
-					return err
+					return fmt.Errorf("Bad value for --router-tolerations: %s", err)
 				}
-			} else {
-				updated, err := cli.SiteConfigUpdate(context.Background(), routerCreateOpts)
+				routerCreateOpts.RouterScheduling.Tolerations = tolerations
+			}
+			if len(controllerTolerations) > 0 {
+				tolerations, err := kube.ParseTolerations(strings.Join(controllerTolerations, ","))
 				if err != nil {
-					return fmt.Errorf("Error while trying to update router configuration: %s", err)
-				}
-				if len(updated) > 0 {
-					for _, i := range updated {
-						fmt.Println("Updated", i)
-					}
+					return fmt.Errorf("Bad value for --controller-tolerations: %s", err)
 				}
+				routerCreateOpts.ControllerScheduling.Tolerations = tolerations
 			}
 
-			err = cli.RouterCreate(context.Background(), *siteConfig)
-			if err != nil {
-				return err
-			}
-			fmt.Println("Skupper is now installed in namespace '" + ns + "'.  Use 'skupper status' to get more information.")
-			return nil
+			return initSite(ns, siteConfig, routerCreateOpts)
 		},
 	}
 	routerCreateOpts.EnableController = true
+	cmd.Flags().StringVarP(&siteConfigFile, "file", "f", "", "Read the site configuration from a skupper-site ConfigMap in the given file or URL")
 	cmd.Flags().StringVarP(&routerCreateOpts.SkupperName, "site-name", "", "", "Provide a specific name for this skupper installation")
 	cmd.Flags().BoolVarP(&routerCreateOpts.EnableServiceSync, "enable-service-sync", "", true, "Participate in cross-site service synchronization")
 	cmd.Flags().BoolVarP(&routerCreateOpts.EnableRouterConsole, "enable-router-console", "", false, "Enable router console")
 	cmd.Flags().StringVarP(&routerLogging, "router-logging", "", "", "Logging settings for router (e.g. trace,debug,info,notice,warning,error)")
 	cmd.Flags().StringVarP(&routerCreateOpts.RouterDebugMode, "router-debug-mode", "", "", "Enable debug mode for router ('valgrind' or 'gdb' are valid values)")
+	cmd.Flags().Int32VarP(&routerCreateOpts.Replicas, "routers", "", 1, "Number of router replicas to run. The replicas of an interior router mesh with each other, so the site stays connected while any one of them restarts.")
 	cmd.Flags().BoolVarP(&routerCreateOpts.EnableConsole, "enable-console", "", true, "Enable skupper console")
 	cmd.Flags().StringVarP(&routerCreateOpts.AuthMode, "console-auth", "", "", "Authentication mode for console(s). One of: 'openshift', 'internal', 'unsecured'")
 	cmd.Flags().StringVarP(&routerCreateOpts.User, "console-user", "", "", "Skupper console user. Valid only when --console-auth=internal")
 	cmd.Flags().StringVarP(&routerCreateOpts.Password, "console-password", "", "", "Skupper console user. Valid only when --console-auth=internal")
 	cmd.Flags().StringSliceVar(&annotations, "annotations", []string{}, "Annotations to add to skupper deployments")
+	cmd.Flags().StringSliceVar(&labels, "labels", []string{}, "Labels to add to the pods of skupper deployments, as <key>=<value>")
+	cmd.Flags().StringVarP(&routerCreateOpts.ImageVerification.PublicKey, "image-signature-key", "", "", "Public key (path or KMS reference) used to verify the cosign signatures of skupper images")
+	cmd.Flags().StringVarP(&routerCreateOpts.ImageVerification.Identity, "image-signature-identity", "", "", "Certificate identity used for keyless verification of the cosign signatures of skupper images")
+	cmd.Flags().StringVarP(&routerCreateOpts.ImageVerification.Issuer, "image-signature-issuer", "", "", "OIDC issuer used for keyless verification of the cosign signatures of skupper images")
+	cmd.Flags().BoolVarP(&routerCreateOpts.ImageVerification.Strict, "image-signature-strict", "", false, "Refuse to deploy skupper images whose signatures cannot be verified")
+	cmd.Flags().BoolVarP(&routerCreateOpts.EnableProfiling, "enable-controller-profiling", "", false, "Serve pprof and expvar diagnostics from the service controller (behind console authentication)")
+	cmd.Flags().BoolVarP(&routerCreateOpts.EnableMetricsApi, "enable-metrics-api", "", false, "Serve link health and service traffic through the custom metrics API of the cluster")
+	cmd.Flags().StringVarP(&routerCreateOpts.Watermarks.Memory, "router-memory-watermark", "", "", "Router memory use (e.g. 1Gi) at which the controller raises an alarm")
+	cmd.Flags().IntVarP(&routerCreateOpts.Watermarks.Connections, "router-connection-watermark", "", 0, "Number of open router connections at which the controller raises an alarm")
+	cmd.Flags().IntVarP(&routerCreateOpts.Watermarks.Undelivered, "router-undelivered-watermark", "", 0, "Number of undelivered messages at which the controller raises an alarm")
+	cmd.Flags().BoolVarP(&routerCreateOpts.Watermarks.ShedLoad, "router-shed-load", "", false, "Stop accepting new service connections while a router watermark is exceeded")
+	cmd.Flags().IntVarP(&routerCreateOpts.LinkCertWarningDays, "link-cert-warning-days", "", 0, fmt.Sprintf("How many days before the certificates of a link expire that the controller warns of it (default %d)", types.DefaultLinkCertWarningDays))
+	cmd.Flags().StringVarP(&routerCreateOpts.UpdateStrategy, "update-strategy", "", "", "How router updates are rolled out, one of: [rolling|canary]. With canary, a router with more than one replica has a single replica updated first.")
+	cmd.Flags().DurationVarP(&routerCreateOpts.ServiceSyncInterval, "service-sync-interval", "", 0, fmt.Sprintf("How often the site advertises its services to the network (default %s)", types.DefaultServiceSyncInterval))
+	cmd.Flags().BoolVarP(&routerCreateOpts.ImportOnDemand, "import-on-demand", "", false, "Only create the services of other sites that are imported with 'skupper service import', rather than all of them")
+	cmd.Flags().DurationVarP(&routerCreateOpts.CanaryBakeTime, "canary-bake-time", "", 0, "How long an updated canary router replica must stay healthy before the remaining replicas are updated (default 5m)")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.Hooks, "hook", map[string]string{}, "Run a hook at a point in the lifecycle of the site, given as <point>=<url> to post to a webhook or <point>=job:<configmap> to run the Job template in the named ConfigMap. Points are: "+strings.Join(types.ValidHooks, ", "))
+	cmd.Flags().StringVarP(&routerCreateOpts.LinkDirection, "link-direction", "", "", "Restrict the links of the site to one direction, one of: [both|inbound|outbound]. An inbound site never creates links to other sites; an outbound site never accepts links from them, so has no ingress.")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.HostAliases, "host-alias", map[string]string{}, "Resolve the given hostnames to IP addresses in the router, as <hostname>=<ip>, for peers whose public DNS name is not resolvable in the cluster")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.RouterScheduling.NodeSelector, "router-node-selector", map[string]string{}, "Only run the router on nodes with these labels, as <key>=<value>")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.RouterScheduling.Affinity, "router-pod-affinity", map[string]string{}, "Only run the router on nodes running pods with these labels, as <key>=<value>")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.RouterScheduling.AntiAffinity, "router-pod-antiaffinity", map[string]string{}, "Never run the router on nodes running pods with these labels, as <key>=<value>")
+	cmd.Flags().StringSliceVar(&routerTolerations, "router-tolerations", []string{}, "Taints the router tolerates, as <key>[=<value>]:<effect>")
+	cmd.Flags().StringVarP(&routerCreateOpts.RouterScheduling.PriorityClassName, "router-priority-class", "", "", "Name of the priority class of the router pods")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.ControllerScheduling.NodeSelector, "controller-node-selector", map[string]string{}, "Only run the service controller on nodes with these labels, as <key>=<value>")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.ControllerScheduling.Affinity, "controller-pod-affinity", map[string]string{}, "Only run the service controller on nodes running pods with these labels, as <key>=<value>")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.ControllerScheduling.AntiAffinity, "controller-pod-antiaffinity", map[string]string{}, "Never run the service controller on nodes running pods with these labels, as <key>=<value>")
+	cmd.Flags().StringSliceVar(&controllerTolerations, "controller-tolerations", []string{}, "Taints the service controller tolerates, as <key>[=<value>]:<effect>")
+	cmd.Flags().StringVarP(&routerCreateOpts.ControllerScheduling.PriorityClassName, "controller-priority-class", "", "", "Name of the priority class of the service controller pods")
+	cmd.Flags().StringVarP(&routerCreateOpts.RouterResources.Cpu, "router-cpu", "", "", "CPU requested for the router (e.g. 500m)")
+	cmd.Flags().StringVarP(&routerCreateOpts.RouterResources.Memory, "router-memory", "", "", "Memory requested for the router (e.g. 256Mi)")
+	cmd.Flags().StringVarP(&routerCreateOpts.RouterResources.CpuLimit, "router-cpu-limit", "", "", "Most CPU the router may use")
+	cmd.Flags().StringVarP(&routerCreateOpts.RouterResources.MemoryLimit, "router-memory-limit", "", "", "Most memory the router may use")
+	cmd.Flags().StringVarP(&routerCreateOpts.ControllerResources.Cpu, "controller-cpu", "", "", "CPU requested for the service controller (e.g. 500m)")
+	cmd.Flags().StringVarP(&routerCreateOpts.ControllerResources.Memory, "controller-memory", "", "", "Memory requested for the service controller (e.g. 256Mi)")
+	cmd.Flags().StringVarP(&routerCreateOpts.ControllerResources.CpuLimit, "controller-cpu-limit", "", "", "Most CPU the service controller may use")
+	cmd.Flags().StringVarP(&routerCreateOpts.ControllerResources.MemoryLimit, "controller-memory-limit", "", "", "Most memory the service controller may use")
+	cmd.Flags().StringArrayVar(&routerEgressAnnotations, "router-egress-annotation", []string{}, "An annotation for the router pods only, as <key>=<value>, e.g. to route their connections through an egress gateway (may be repeated)")
+	cmd.Flags().StringToStringVar(&routerCreateOpts.RouterEgress.Labels, "router-egress-label", map[string]string{}, "Labels for the router pods only, as <key>=<value>, for egress IP policies that select pods by label")
+	cmd.Flags().StringSliceVar(&routerCreateOpts.RouterEgress.IPs, "router-egress-ip", []string{}, "The static source IPs the links of the site leave the cluster from, which the firewalls of the sites it links to need to allow; they are shown by 'skupper status'")
 
 	cmd.Flags().BoolVarP(&ClusterLocal, "cluster-local", "", false, "Set up Skupper to only accept connections from within the local cluster.")
 	f := cmd.Flag("cluster-local")
 	f.Deprecated = "This flag is deprecated, use --ingress [loadbalancer|route|none]"
 	f.Hidden = true
-	cmd.Flags().StringVarP(&routerCreateOpts.Ingress, "ingress", "", "", "Setup Skupper ingress to one of: [loadbalancer|route|none]. If not specified route is used when available, otherwise loadbalancer is used.")
-	cmd.Flags().StringVarP(&routerCreateOpts.ConsoleIngress, "console-ingress", "", "", "Determines if/how console is exposed outside cluster. If not specified uses value of --ingress. One of: [loadbalancer|route|none].")
+	cmd.Flags().StringVarP(&routerCreateOpts.Ingress, "ingress", "", "", "Setup Skupper ingress to one of: [loadbalancer|loadbalancer-internal|route|ingress|gateway|none]. If not specified route is used when available, otherwise loadbalancer is used. With loadbalancer-internal, the load balancer is only reachable from within the cloud network of the cluster, e.g. over VPC peering. With ingress, Kubernetes Ingress resources with TLS passthrough are created, which requires an ingress controller that supports the nginx ssl-passthrough annotation. With gateway, a Gateway API Gateway and TLSRoutes are created, which requires the Gateway API to be installed in the cluster.")
+	cmd.Flags().StringVarP(&routerCreateOpts.IngressHost, "ingress-host", "", "", "The domain under which hosts are generated for the resources created with --ingress ingress or gateway")
+	cmd.Flags().StringVarP(&routerCreateOpts.GatewayClass, "gateway-class", "", "", "The class of the Gateway created with --ingress gateway")
+	cmd.Flags().StringVarP(&routerCreateOpts.CertificateProvider, "certificate-provider", "", "", "What issues the certificates of the site, one of: [skupper|cert-manager]. With cert-manager, the site CA and the certificates it signs are issued through cert-manager Certificates.")
+	cmd.Flags().StringVarP(&routerCreateOpts.CertificateIssuer, "certificate-issuer", "", "", "The cert-manager issuer of the site CA, as [Issuer/|ClusterIssuer/]<name>, e.g. to have it issued by an external CA (defaults to a self-signed issuer)")
+	cmd.Flags().StringVarP(&routerCreateOpts.IngressService, "ingress-service", "", "", "Use the named existing LoadBalancer service for inter-router and edge ingress instead of creating one. It must expose ports 55671 and 45671.")
+	cmd.Flags().StringVarP(&routerCreateOpts.LoadBalancerProvider, "load-balancer-provider", "", "", "The cloud whose annotations make the load balancer internal with --ingress loadbalancer-internal, one of: [aws|gcp|azure] (defaults to those of all of them)")
+	cmd.Flags().StringArrayVar(&loadBalancerAnnotations, "load-balancer-annotation", []string{}, "An annotation for the load balancer services, as <key>=<value>, overriding the cloud annotation with the same key; an empty value removes it (may be repeated)")
+	cmd.Flags().IntVarP(&routerCreateOpts.NodePorts.InterRouter, "inter-router-node-port", "", 0, "Pin the node port for inter-router connections (requires --ingress loadbalancer unless --router-host-ports is set)")
+	cmd.Flags().IntVarP(&routerCreateOpts.NodePorts.Edge, "edge-node-port", "", 0, "Pin the node port for edge connections (requires --ingress loadbalancer unless --router-host-ports is set)")
+	cmd.Flags().BoolVarP(&routerCreateOpts.NodePorts.HostPort, "router-host-ports", "", false, "Expose the pinned inter-router and edge ports as host ports of the router pod rather than node ports")
+	cmd.Flags().StringVarP(&routerCreateOpts.ConsoleIngress, "console-ingress", "", "", "Determines if/how console is exposed outside cluster. If not specified uses value of --ingress. One of: [loadbalancer|loadbalancer-internal|route|ingress|none]. With ingress, the console is served over HTTPS through an Ingress with TLS passthrough.")
+	cmd.Flags().BoolVarP(&routerCreateOpts.ClusterScoped, "cluster-scoped", "", false, "Allow the site to expose workloads in other namespaces labelled "+types.TargetNamespaceLabel+"=<site namespace> (requires permission to create cluster roles, and roles in those namespaces)")
+	cmd.Flags().StringVarP(&routerCreateOpts.ClusterDomain, "cluster-domain", "", "", "The DNS domain of the cluster, used in the names the site's certificates are valid for (detected if not specified)")
+	cmd.Flags().StringVarP(&routerCreateOpts.ConsoleHost, "console-host", "", "", "The host the console is reached on with --console-ingress ingress (defaults to one generated under --ingress-host)")
+	cmd.Flags().StringVarP(&routerCreateOpts.ConsoleTlsSecret, "console-tls-secret", "", "", "The secret holding the certificate (tls.crt and tls.key) the console is served with over HTTPS. If not specified with --console-ingress ingress, one is generated.")
+	cmd.Flags().StringVarP(&routerCreateOpts.SiteCaSecret, "site-ca-secret", "", "", "An existing secret holding the CA certificate and RSA key (tls.crt and tls.key) the site issues certificates with, rather than generating one")
+	cmd.Flags().StringVarP(&routerCreateOpts.SiteServerSecret, "site-server-secret", "", "", "An existing secret holding the certificate (tls.crt and tls.key) for the inter-router and edge listeners of the site, rather than generating one. It must be issued by --site-ca-secret and be valid for the ingress hosts of the site.")
 
 	cmd.Flags().BoolVarP(&isEdge, "edge", "", false, "Configure as an edge")
 	f = cmd.Flag("edge")
@@ -356,6 +672,11 @@ func NewCmdDelete(newClient cobraFunc) *cobra.Command {
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
+			if siteConfig, err := cli.SiteConfigInspect(context.Background(), nil); err == nil && siteConfig != nil && siteConfig.Spec.EnableMetricsApi {
+				if err := cli.MetricsApiUnregister(context.Background()); err != nil {
+					fmt.Println("Warning: the custom metrics API could not be unregistered:", err)
+				}
+			}
 			err := cli.SiteConfigRemove(context.Background())
 			if err != nil {
 				err = cli.RouterRemove(context.Background())
@@ -363,7 +684,7 @@ func NewCmdDelete(newClient cobraFunc) *cobra.Command {
 			if err != nil {
 				return err
 			} else {
-				fmt.Println("Skupper is now removed from '" + cli.GetNamespace() + "'.")
+				fmt.Println(messages.Sprintf(messages.SiteRemoved, cli.GetNamespace()))
 			}
 			return nil
 		},
@@ -372,6 +693,8 @@ func NewCmdDelete(newClient cobraFunc) *cobra.Command {
 }
 
 var forceHup bool
+var rollbackUpdate bool
+var dryRunUpdate bool
 
 func NewCmdUpdate(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
@@ -382,12 +705,39 @@ func NewCmdUpdate(newClient cobraFunc) *cobra.Command {
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
+			if dryRunUpdate {
+				plan, err := cli.RouterUpdatePlan(context.Background(), forceHup, cli.GetNamespace())
+				if err != nil {
+					return err
+				}
+				if len(plan.Changes) == 0 {
+					fmt.Println("No update required in '" + cli.GetNamespace() + "'.")
+					return nil
+				}
+				fmt.Printf("Updating '%s' from %s to %s would:\n", plan.Namespace, plan.FromVersion, plan.ToVersion)
+				for _, change := range plan.Changes {
+					fmt.Println("   ", change.String())
+				}
+				return nil
+			}
+			if rollbackUpdate {
+				reverted, err := cli.RouterUpdateRollback(context.Background(), cli.GetNamespace())
+				if err != nil {
+					return err
+				}
+				if reverted {
+					fmt.Println("Update rolled back in '" + cli.GetNamespace() + "'.")
+				} else {
+					fmt.Println("No update in progress in '" + cli.GetNamespace() + "'.")
+				}
+				return nil
+			}
 			updated, err := cli.RouterUpdateVersion(context.Background(), forceHup)
 			if err != nil {
 				return err
 			}
 			if updated {
-				fmt.Println("Skupper is now updated in '" + cli.GetNamespace() + "'.")
+				fmt.Println(messages.Sprintf(messages.SiteUpdated, cli.GetNamespace()))
 			} else {
 				fmt.Println("No update required in '" + cli.GetNamespace() + "'.")
 			}
@@ -395,10 +745,71 @@ func NewCmdUpdate(newClient cobraFunc) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVarP(&forceHup, "force-restart", "", false, "Restart skupper daemons even if image tag is not updated")
+	cmd.Flags().BoolVarP(&rollbackUpdate, "rollback", "", false, "Revert an update that failed to complete")
+	cmd.Flags().BoolVarP(&dryRunUpdate, "dry-run", "", false, "List the changes the update would make without making them")
+	return cmd
+}
+
+var forceFinalize bool
+
+func NewCmdUpdateFinalizeLegacy(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "finalize-legacy",
+		Short: "Remove resources retained when updating from a version prior to 0.5.0",
+		Long: `Remove resources retained when updating from a version prior to 0.5.0.
+Resources are only removed if no links in the cluster still refer to
+them. Links from sites in other clusters cannot be checked, so ensure
+any such sites have been given new tokens before using --force.`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			removed, err := cli.RouterFinalizeLegacyUpdate(context.Background(), forceFinalize)
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				fmt.Println("No legacy resources found in '" + cli.GetNamespace() + "'.")
+			}
+			for _, r := range removed {
+				fmt.Println("Removed", r)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&forceFinalize, "force", "", false, "Remove legacy resources even if links still refer to them")
+	return cmd
+}
+
+func NewCmdUpdateRotateCerts(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate-certs",
+		Short: "Renew the certificates of the site that are due to expire",
+		Long: `Renew the certificates of the site that are due to expire.
+The site controller does this periodically; this renews them immediately.
+Certificates for links to other sites are renewed by the site controller.`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			renewed, err := cli.RotateCertificates(context.Background())
+			for _, r := range renewed {
+				fmt.Println("Renewed", r)
+			}
+			if err != nil {
+				return err
+			}
+			if len(renewed) == 0 {
+				fmt.Println("No certificates due for renewal in '" + cli.GetNamespace() + "'.")
+			}
+			return nil
+		},
+	}
 	return cmd
 }
 
 var clientIdentity string
+var tokenCreateOpts types.TokenCreateOptions
 
 func NewCmdConnectionToken(newClient cobraFunc) *cobra.Command {
 	cmd := NewCmdTokenCreate(newClient, "client-identity")
@@ -469,17 +880,17 @@ func NewCmdStatus(newClient cobraFunc) *cobra.Command {
 			vir, err := cli.RouterInspect(context.Background())
 			if err == nil {
 				ns := cli.GetNamespace()
-				var modedesc string = " in interior mode"
+				var modedesc string = messages.Sprintf(messages.StatusInteriorMode)
 				if vir.Status.Mode == string(types.TransportModeEdge) {
-					modedesc = " in edge mode"
+					modedesc = messages.Sprintf(messages.StatusEdgeMode)
 				}
 				sitename := ""
 				if vir.Status.SiteName != "" && vir.Status.SiteName != ns {
-					sitename = fmt.Sprintf(" with site name %q", vir.Status.SiteName)
+					sitename = messages.Sprintf(messages.StatusSiteName, vir.Status.SiteName)
 				}
-				fmt.Printf("Skupper is enabled for namespace %q%s%s.", ns, sitename, modedesc)
+				fmt.Print(messages.Sprintf(messages.StatusEnabled, ns, sitename, modedesc))
 				if vir.Status.TransportReadyReplicas == 0 {
-					fmt.Printf(" Status pending...")
+					fmt.Print(messages.Sprintf(messages.StatusPending))
 				} else {
 					if len(vir.Status.ConnectedSites.Warnings) > 0 {
 						for _, w := range vir.Status.ConnectedSites.Warnings {
@@ -488,36 +899,52 @@ func NewCmdStatus(newClient cobraFunc) *cobra.Command {
 						}
 					}
 					if vir.Status.ConnectedSites.Total == 0 {
-						fmt.Printf(" It is not connected to any other sites.")
+						fmt.Print(messages.Sprintf(messages.StatusNotConnected))
 					} else if vir.Status.ConnectedSites.Total == 1 {
-						fmt.Printf(" It is connected to 1 other site.")
+						fmt.Print(messages.Sprintf(messages.StatusConnectedOne))
 					} else if vir.Status.ConnectedSites.Total == vir.Status.ConnectedSites.Direct {
-						fmt.Printf(" It is connected to %d other sites.", vir.Status.ConnectedSites.Total)
+						fmt.Print(messages.Sprintf(messages.StatusConnected, vir.Status.ConnectedSites.Total))
 					} else {
-						fmt.Printf(" It is connected to %d other sites (%d indirectly).", vir.Status.ConnectedSites.Total, vir.Status.ConnectedSites.Indirect)
+						fmt.Print(messages.Sprintf(messages.StatusConnectedIndirectly, vir.Status.ConnectedSites.Total, vir.Status.ConnectedSites.Indirect))
 					}
 				}
 				if vir.ExposedServices == 0 {
-					fmt.Printf(" It has no exposed services.")
+					fmt.Print(messages.Sprintf(messages.StatusNoServices))
 				} else if vir.ExposedServices == 1 {
-					fmt.Printf(" It has 1 exposed service.")
+					fmt.Print(messages.Sprintf(messages.StatusOneService))
 				} else {
-					fmt.Printf(" It has %d exposed services.", vir.ExposedServices)
+					fmt.Print(messages.Sprintf(messages.StatusServices, vir.ExposedServices))
 				}
 				fmt.Println()
-				if vir.ConsoleUrl != "" {
-					fmt.Println("The site console url is: ", vir.ConsoleUrl)
-					siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
-					if err != nil {
-						return err
+				if strings.HasPrefix(vir.SelfTest, "failed") {
+					fmt.Println("Warning: data path self-test " + vir.SelfTest)
+				}
+				if !showPeers && vir.Status.ConnectedSites.Total > 0 {
+					// controllers of earlier versions cannot be asked
+					// for their peers, and are not warned about
+					if peers, err := cli.RouterPeerStatus(context.Background()); err == nil {
+						printClockSkewWarnings(peers)
 					}
+				}
+				siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
+				if err != nil {
+					return err
+				}
+				if vir.ConsoleUrl != "" {
+					fmt.Println(messages.Sprintf(messages.StatusConsoleUrl, vir.ConsoleUrl))
 					if siteConfig.Spec.AuthMode == "internal" {
-						fmt.Println("The credentials for internal console-auth mode are held in secret: 'skupper-console-users'")
+						fmt.Println(messages.Sprintf(messages.StatusConsoleCredentials))
 					}
 				}
+				if siteConfig != nil && len(siteConfig.Spec.RouterEgress.IPs) > 0 {
+					fmt.Println(messages.Sprintf(messages.StatusEgressIPs, strings.Join(siteConfig.Spec.RouterEgress.IPs, ", ")))
+				}
+				if showPeers {
+					return printPeerStatus()
+				}
 			} else {
 				if vir == nil {
-					fmt.Printf("Skupper is not enabled in namespace '%s'\n", cli.GetNamespace())
+					fmt.Println(messages.Sprintf(messages.SiteNotEnabled, cli.GetNamespace()))
 				} else {
 					return fmt.Errorf("Unable to retrieve skupper status: %w", err)
 				}
@@ -525,14 +952,61 @@ func NewCmdStatus(newClient cobraFunc) *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&showPeers, "peers", false, "Ask each linked peer what it sees of this site and report any differences from the local view")
 	return cmd
 }
 
+var showPeers bool
+
+func printPeerStatus() error {
+	peers, err := cli.RouterPeerStatus(context.Background())
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve peer status: %w", err)
+	}
+	if len(peers) == 0 {
+		fmt.Println("No peers found")
+		return nil
+	}
+	fmt.Println("Peers:")
+	for _, peer := range peers {
+		name := peer.SiteId
+		if peer.SiteName != "" {
+			name = fmt.Sprintf("%s (%s)", peer.SiteName, peer.SiteId)
+		}
+		if peer.ClockSkew != nil {
+			name = fmt.Sprintf("%s [clock skew %s]", name, peer.ClockSkew.Round(time.Millisecond))
+		}
+		if len(peer.Observations) == 0 {
+			fmt.Printf("  %s: consistent", name)
+			fmt.Println()
+		} else {
+			fmt.Printf("  %s:", name)
+			fmt.Println()
+			for _, observation := range peer.Observations {
+				fmt.Printf("    - %s", observation)
+				fmt.Println()
+			}
+		}
+	}
+	return nil
+}
+
+// printClockSkewWarnings warns of peers whose clocks differ from that
+// of this site by enough for certificates and tokens to be affected
+func printClockSkewWarnings(peers []types.PeerStatus) {
+	for _, peer := range peers {
+		if warning := data.ClockSkewWarning(peer); warning != "" {
+			fmt.Printf("Warning: %s", warning)
+			fmt.Println()
+		}
+	}
+}
+
 var exposeOpts ExposeOptions
 
 func NewCmdExpose(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "expose [deployment <name>|pods <selector>|statefulset <statefulsetname>|service <name>]",
+		Use:    "expose [deployment <name>|pods <selector>|statefulset <statefulsetname>|daemonset <daemonsetname>|service <name>]",
 		Short:  "Expose a set of pods through a Skupper address",
 		Args:   exposeTargetArgs,
 		PreRun: newClient,
@@ -554,25 +1028,33 @@ func NewCmdExpose(newClient cobraFunc) *cobra.Command {
 
 			addr, err := expose(cli, context.Background(), targetType, targetName, exposeOpts)
 			if err == nil {
-				fmt.Printf("%s %s exposed as %s\n", targetType, targetName, addr)
+				fmt.Println(messages.Sprintf(messages.ServiceExposed, targetType, targetName, addr))
 			}
 			return err
 		},
 	}
-	cmd.Flags().StringVar(&(exposeOpts.Protocol), "protocol", "tcp", "The protocol to proxy (tcp, http, or http2)")
+	cmd.Flags().StringVar(&(exposeOpts.Protocol), "protocol", "tcp", "The protocol to proxy (tcp, http, http2 or grpc). Use tcp for protocols carried over tcp that the router cannot parse, e.g. MQTT, whose bytes are passed through unchanged.")
 	cmd.Flags().StringVar(&(exposeOpts.Address), "address", "", "The Skupper address to expose")
-	cmd.Flags().IntVar(&(exposeOpts.Port), "port", 0, "The port to expose on")
-	cmd.Flags().IntVar(&(exposeOpts.TargetPort), "target-port", 0, "The port to target on pods")
+	cmd.Flags().IntSliceVar(&(exposeOpts.Ports), "port", nil, "The port to expose on (may be repeated to expose several ports)")
+	cmd.Flags().IntSliceVar(&(exposeOpts.TargetPorts), "target-port", nil, "The port to target on pods (may be repeated, in the order of the ports exposed)")
 	cmd.Flags().BoolVar(&(exposeOpts.Headless), "headless", false, "Expose through a headless service (valid only for a statefulset target)")
+	addHealthCheckFlags(cmd, &exposeOpts.HealthCheck)
+	addIdlePolicyFlags(cmd, &exposeOpts.IdlePolicy)
+	addTlsFlags(cmd, &exposeOpts.Tls)
+	cmd.Flags().BoolVar(&exposeOpts.Protected, "protected", false, protectedUsage)
+	cmd.Flags().StringToStringVar(&(exposeOpts.Metadata), "metadata", nil, serviceMetadataUsage)
+	cmd.Flags().StringVar(&exposeOpts.TargetNamespace, "target-namespace", "", targetNamespaceUsage)
 
 	return cmd
 }
 
 var unexposeAddress string
+var unexposeForce bool
+var unexposeTargetNamespace string
 
 func NewCmdUnexpose(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "unexpose [deployment <name>|pods <selector>|statefulset <statefulsetname>|service <name>]",
+		Use:    "unexpose [deployment <name>|pods <selector>|statefulset <statefulsetname>|daemonset <daemonsetname>|service <name>]",
 		Short:  "Unexpose a set of pods previously exposed through a Skupper address",
 		Args:   exposeTargetArgs,
 		PreRun: newClient,
@@ -581,9 +1063,13 @@ func NewCmdUnexpose(newClient cobraFunc) *cobra.Command {
 
 			targetType, targetName := parseTargetTypeAndName(args)
 
-			err := cli.ServiceInterfaceUnbind(context.Background(), targetType, targetName, unexposeAddress, true)
+			err := cli.ServiceInterfaceUnbindWithOptions(context.Background(), targetType, targetName, unexposeAddress, types.ServiceInterfaceUnbindOptions{
+				DeleteIfNoTargets: true,
+				Force:             unexposeForce,
+				TargetNamespace:   unexposeTargetNamespace,
+			})
 			if err == nil {
-				fmt.Printf("%s %s unexposed\n", targetType, targetName)
+				fmt.Println(messages.Sprintf(messages.ServiceUnexposed, targetType, targetName))
 			} else {
 				return fmt.Errorf("Unable to unbind skupper service: %w", err)
 			}
@@ -591,6 +1077,8 @@ func NewCmdUnexpose(newClient cobraFunc) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&unexposeAddress, "address", "", "Skupper address the target was exposed as")
+	cmd.Flags().BoolVar(&unexposeForce, "force", false, "Unexpose the target even if the service is protected")
+	cmd.Flags().StringVar(&unexposeTargetNamespace, "target-namespace", "", "The namespace of the target, if not that of the site")
 
 	return cmd
 }
@@ -601,101 +1089,384 @@ func NewCmdListExposed(newClient cobraFunc) *cobra.Command {
 	return cmd
 }
 
+var serviceListOpts types.ServiceInterfaceListOptions
+
+func formatServicePorts(si *types.ServiceInterface) string {
+	ports := []string{}
+	for _, port := range si.Ports {
+		if port.Protocol != "" && port.Protocol != si.Protocol {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+		} else {
+			ports = append(ports, strconv.Itoa(port.Port))
+		}
+	}
+	if len(ports) == 1 {
+		return "port " + ports[0]
+	}
+	return "ports " + strings.Join(ports, ",")
+}
+
+func printServiceInterface(si *types.ServiceInterface) {
+	if len(si.Targets) == 0 {
+		fmt.Printf("    %s (%s %s)", si.Address, si.Protocol, formatServicePorts(si))
+		fmt.Println()
+	} else {
+		fmt.Printf("    %s (%s %s) with targets", si.Address, si.Protocol, formatServicePorts(si))
+		fmt.Println()
+		for _, t := range si.Targets {
+			var name string
+			if t.Name != "" {
+				name = fmt.Sprintf("name=%s", t.Name)
+			}
+			if t.Selector != "" {
+				fmt.Printf("      => %s %s", t.Selector, name)
+			} else if t.Service != "" {
+				fmt.Printf("      => %s %s", t.Service, name)
+			} else {
+				fmt.Printf("      => %s (no selector)", name)
+			}
+			fmt.Println()
+		}
+	}
+	if len(si.Metadata) > 0 {
+		fmt.Printf("      metadata: %s", formatServiceMetadata(si.Metadata))
+		fmt.Println()
+	}
+	if si.TlsCredentials != "" {
+		fmt.Printf("      tls terminated with credentials from %s", si.TlsCredentials)
+		fmt.Println()
+	}
+	if si.TlsTrust != "" {
+		fmt.Printf("      tls originated to targets trusted by %s", si.TlsTrust)
+		fmt.Println()
+	}
+	if si.IdlePolicy != nil {
+		if si.IdlePolicy.Unexpose {
+			fmt.Printf("      unexposed when idle for %d days", si.IdlePolicy.Days)
+		} else {
+			fmt.Printf("      warns when idle for %d days", si.IdlePolicy.Days)
+		}
+		fmt.Println()
+	}
+}
+
+func printAddressStats(stats *types.AddressStats) {
+	fmt.Printf("Router statistics for %s (%s distribution):", stats.Address, stats.Distribution)
+	fmt.Println()
+	fmt.Printf("    consumers: %d local, %d remote", stats.LocalConsumers, stats.RemoteConsumers)
+	fmt.Println()
+	fmt.Printf("    deliveries: %d ingress, %d egress, %d transit, %d to container, %d from container", stats.DeliveriesIngress, stats.DeliveriesEgress, stats.DeliveriesTransit, stats.DeliveriesToContainer, stats.DeliveriesFromContainer)
+	fmt.Println()
+	if len(stats.Links) == 0 {
+		fmt.Println("    no links attached")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "    LINK\tDIR\tDELIVERIES\tSETTLED\tUNSETTLED\tUNDELIVERED\tCREDIT\tCAPACITY")
+	for _, l := range stats.Links {
+		fmt.Fprintf(tw, "    %s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", l.Name, l.Direction, l.Deliveries, l.Settled, l.Unsettled, l.Undelivered, l.CreditAvailable, l.Capacity)
+	}
+	tw.Flush()
+}
+
+var showServiceStats bool
+
 func NewCmdServiceStatus(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "status",
-		Short:  "List services exposed over the Skupper network",
-		Args:   cobra.NoArgs,
+		Use:   "status [<address>]",
+		Short: "List services exposed over the Skupper network",
+		Long:  "List services exposed over the Skupper network, or show a single service. With --stats, the router's delivery statistics for the service address are shown, to help debug stuck or slow services.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("illegal argument: %s", args[1])
+			}
+			if showServiceStats && len(args) == 0 {
+				return fmt.Errorf("An address must be specified with --stats")
+			}
+			return nil
+		},
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
-			vsis, err := cli.ServiceInterfaceList(context.Background())
+			if len(args) == 1 {
+				si, err := cli.ServiceInterfaceInspect(context.Background(), args[0])
+				if err != nil {
+					return fmt.Errorf("Could not retrieve service: %w", err)
+				}
+				if si == nil {
+					return fmt.Errorf("Service %s not found", args[0])
+				}
+				printServiceInterface(si)
+				if showServiceStats {
+					stats, err := cli.ServiceInterfaceStats(context.Background(), args[0])
+					if err != nil {
+						return err
+					}
+					printAddressStats(stats)
+				}
+				return nil
+			}
+			response, err := cli.ServiceInterfaceListWithOptions(context.Background(), serviceListOpts)
 			if err == nil {
+				vsis := response.Items
 				if len(vsis) == 0 {
 					fmt.Println("No services defined")
 				} else {
 					fmt.Println("Services exposed through Skupper:")
 					for _, si := range vsis {
-						if len(si.Targets) == 0 {
-							fmt.Printf("    %s (%s port %d)", si.Address, si.Protocol, si.Port)
-							fmt.Println()
-						} else {
-							fmt.Printf("    %s (%s port %d) with targets", si.Address, si.Protocol, si.Port)
-							fmt.Println()
-							for _, t := range si.Targets {
-								var name string
-								if t.Name != "" {
-									name = fmt.Sprintf("name=%s", t.Name)
-								}
-								if t.Selector != "" {
-									fmt.Printf("      => %s %s", t.Selector, name)
-								} else if t.Service != "" {
-									fmt.Printf("      => %s %s", t.Service, name)
-								} else {
-									fmt.Printf("      => %s (no selector)", name)
-								}
-								fmt.Println()
-							}
-						}
+						printServiceInterface(si)
 					}
 				}
+				if response.Continue != "" {
+					fmt.Printf("More services available, use --continue %s to list them", response.Continue)
+					fmt.Println()
+				}
 			} else {
 				return fmt.Errorf("Could not retrieve services: %w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&showServiceStats, "stats", false, "Show router statistics (deliveries, settlement and credit) for the service address")
+	cmd.Flags().StringVar(&serviceListOpts.Protocol, "protocol", "", "Only list services with this protocol (tcp, http, http2 or grpc)")
+	cmd.Flags().StringVar(&serviceListOpts.Origin, "origin", "", "Only list services defined by the site with this id, or '"+types.ServiceOriginLocal+"' for those defined at this site")
+	cmd.Flags().StringVarP(&serviceListOpts.Selector, "selector", "l", "", "Only list services whose metadata matches this selector (e.g. owner=payments)")
+	cmd.Flags().IntVar(&serviceListOpts.Limit, "limit", 0, "The maximum number of services to list (0 for no limit)")
+	cmd.Flags().StringVar(&serviceListOpts.Continue, "continue", "", "List the services following those from a previous call with --limit")
 
 	return cmd
 }
 
+const serviceMetadataUsage string = "Metadata to describe the service to other sites, e.g. version=1.2,owner=payments,docs=https://docs.example.com/payments"
+
+const protectedUsage string = "Protect the service from removal; unexposing or deleting it then requires --force"
+
+const targetNamespaceUsage string = "The namespace of the target, if not that of the site. The site must be cluster scoped and the namespace labelled " + types.TargetNamespaceLabel + "=<site namespace>"
+
+func formatServiceMetadata(metadata map[string]string) string {
+	keys := []string{}
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+metadata[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// applyMetadataChanges sets each <key>=<value> and removes each <key>-
+func applyMetadataChanges(metadata map[string]string, changes []string) (map[string]string, error) {
+	result := map[string]string{}
+	for k, v := range metadata {
+		result[k] = v
+	}
+	for _, change := range changes {
+		if strings.HasSuffix(change, "-") && !strings.Contains(change, "=") {
+			delete(result, strings.TrimSuffix(change, "-"))
+			continue
+		}
+		parts := strings.SplitN(change, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid metadata change %q, expected <key>=<value> or <key>-", change)
+		}
+		result[parts[0]] = parts[1]
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func NewCmdServiceSyncNow(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync-now",
+		Short: "Exchange service definitions with the rest of the network now",
+		Long: `Exchange service definitions with the rest of the network now rather than at
+the next service sync interval. The services of this site are advertised and
+every other site is asked to advertise its own.`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if err := cli.ServiceSyncNow(context.Background()); err != nil {
+				return fmt.Errorf("Unable to sync services: %w", err)
+			}
+			fmt.Println("Service sync requested")
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdServiceImport(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [<address>...]",
+		Short: "Import services of other sites into a site that imports on demand",
+		Long: `Import services of other sites into a site initialised with --import-on-demand.
+Only the services imported are created in the site, once the site that defines
+them next advertises them. With no addresses, the services imported are listed.`,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if len(args) == 0 {
+				addresses, err := cli.ServiceInterfaceImportList(context.Background())
+				if err != nil {
+					return fmt.Errorf("Unable to list imported services: %w", err)
+				}
+				if len(addresses) == 0 {
+					fmt.Println("No services imported")
+				}
+				for _, address := range addresses {
+					fmt.Println(address)
+				}
+				return nil
+			}
+			for _, address := range args {
+				if err := cli.ServiceInterfaceImport(context.Background(), address); err != nil {
+					return fmt.Errorf("Unable to import service %s: %w", address, err)
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdServiceUnimport(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "unimport <address>...",
+		Short:  "Remove services of other sites imported with 'skupper service import'",
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			for _, address := range args {
+				if err := cli.ServiceInterfaceUnimport(context.Background(), address); err != nil {
+					return fmt.Errorf("Unable to unimport service %s: %w", address, err)
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdServiceMetadata(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metadata <name> [<key>=<value>|<key>-]...",
+		Short: "Show or change the metadata of a skupper service",
+		Long: `Show or change the metadata of a skupper service. Metadata such as the
+version, owning team or API docs url is synced to every site along with the
+service and shown by 'skupper service status' and the console.`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			service, err := cli.ServiceInterfaceInspect(context.Background(), args[0])
+			if err != nil {
+				return fmt.Errorf("%w", err)
+			} else if service == nil {
+				return fmt.Errorf("Service %s not found", args[0])
+			}
+			if len(args) == 1 {
+				if len(service.Metadata) == 0 {
+					fmt.Println("No metadata defined")
+				} else {
+					fmt.Println(formatServiceMetadata(service.Metadata))
+				}
+				return nil
+			}
+			if service.Origin != "" {
+				return fmt.Errorf("Service %s is defined by another site, its metadata can only be changed there", args[0])
+			}
+			service.Metadata, err = applyMetadataChanges(service.Metadata, args[1:])
+			if err != nil {
+				return err
+			}
+			err = cli.ServiceInterfaceUpdate(context.Background(), service)
+			if err != nil {
+				return fmt.Errorf("%w", err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
 func NewCmdService() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "service create <name> <port> or service delete port",
+		Use:   "service create <name> <port>... or service delete port",
 		Short: "Manage skupper service definitions",
 	}
 	return cmd
 }
 
 var serviceToCreate types.ServiceInterface
+var serviceHealthCheck types.HealthCheck
+var serviceIdlePolicy types.IdlePolicy
+var serviceTlsFlags serviceTls
+var serviceProtected bool
 
 func NewCmdCreateService(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "create <name> <port>",
+		Use:    "create <name> <port>...",
 		Short:  "Create a skupper service",
 		Args:   createServiceArgs,
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
-			var sPort string
-			if len(args) == 1 {
+			var sPorts []string
+			if strings.Contains(args[0], ":") {
 				parts := strings.Split(args[0], ":")
 				serviceToCreate.Address = parts[0]
-				sPort = parts[1]
+				sPorts = parts[1:]
 			} else {
 				serviceToCreate.Address = args[0]
-				sPort = args[1]
+				sPorts = args[1:]
 			}
-			servicePort, err := strconv.Atoi(sPort)
-			if err != nil {
-				return fmt.Errorf("%s is not a valid port", sPort)
-			} else {
-				serviceToCreate.Port = servicePort
-				err = cli.ServiceInterfaceCreate(context.Background(), &serviceToCreate)
+			ports := []int{}
+			for _, sPort := range sPorts {
+				servicePort, err := strconv.Atoi(sPort)
 				if err != nil {
-					return fmt.Errorf("%w", err)
+					return fmt.Errorf("%s is not a valid port", sPort)
 				}
+				ports = append(ports, servicePort)
+			}
+			serviceToCreate.Ports = servicePorts(ports)
+			var err error
+			serviceToCreate.HealthCheck = healthCheckFromFlags(serviceHealthCheck)
+			serviceToCreate.IdlePolicy, err = idlePolicyFromFlags(serviceIdlePolicy)
+			if err != nil {
+				return err
+			}
+			serviceToCreate.TlsCredentials = serviceTlsFlags.credentials
+			serviceToCreate.TlsTrust = serviceTlsFlags.trust
+			if serviceProtected {
+				protect(&serviceToCreate)
+			}
+			err = cli.ServiceInterfaceCreate(context.Background(), &serviceToCreate)
+			if err != nil {
+				return fmt.Errorf("%w", err)
 			}
 			return nil
 		},
 	}
-	cmd.Flags().StringVar(&serviceToCreate.Protocol, "mapping", "tcp", "The mapping in use for this service address (currently one of tcp or http)")
+	cmd.Flags().StringVar(&serviceToCreate.Protocol, "mapping", "tcp", "The mapping in use for this service address (tcp, http, http2 or grpc)")
 	cmd.Flags().StringVar(&serviceToCreate.Aggregate, "aggregate", "", "The aggregation strategy to use. One of 'json' or 'multipart'. If specified requests to this service will be sent to all registered implementations and the responses aggregated.")
 	cmd.Flags().BoolVar(&serviceToCreate.EventChannel, "event-channel", false, "If specified, this service will be a channel for multicast events.")
+	addHealthCheckFlags(cmd, &serviceHealthCheck)
+	addIdlePolicyFlags(cmd, &serviceIdlePolicy)
+	addTlsFlags(cmd, &serviceTlsFlags)
+	cmd.Flags().BoolVar(&serviceProtected, "protected", false, protectedUsage)
+	cmd.Flags().StringToStringVar(&serviceToCreate.Metadata, "metadata", nil, serviceMetadataUsage)
 
 	return cmd
 }
 
+var deleteServiceForce bool
+
 func NewCmdDeleteService(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:    "delete <name>",
@@ -704,18 +1475,22 @@ func NewCmdDeleteService(newClient cobraFunc) *cobra.Command {
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
-			err := cli.ServiceInterfaceRemove(context.Background(), args[0])
+			err := cli.ServiceInterfaceRemoveWithOptions(context.Background(), args[0], types.ServiceInterfaceRemoveOptions{
+				Force: deleteServiceForce,
+			})
 			if err != nil {
 				return fmt.Errorf("%w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&deleteServiceForce, "force", false, "Delete the service even if it is protected")
 	return cmd
 }
 
-var targetPort int
+var targetPorts []int
 var protocol string
+var bindTargetNamespace string
 
 func NewCmdBind(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
@@ -725,8 +1500,8 @@ func NewCmdBind(newClient cobraFunc) *cobra.Command {
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
-			if protocol != "" && protocol != "tcp" && protocol != "http" && protocol != "http2" {
-				return fmt.Errorf("%s is not a valid protocol. Choose 'tcp', 'http' or 'http2'.", protocol)
+			if protocol != "" && protocol != "tcp" && protocol != "http" && protocol != "http2" && protocol != "grpc" {
+				return fmt.Errorf("%s is not a valid protocol. Choose 'tcp', 'http', 'http2' or 'grpc'.", protocol)
 			} else {
 				targetType, targetName := parseTargetTypeAndName(args[1:])
 
@@ -737,7 +1512,9 @@ func NewCmdBind(newClient cobraFunc) *cobra.Command {
 				} else if service == nil {
 					return fmt.Errorf("Service %s not found", args[0])
 				} else {
-					err = cli.ServiceInterfaceBind(context.Background(), service, targetType, targetName, protocol, targetPort)
+					err = cli.ServiceInterfaceBindWithOptions(context.Background(), service, targetType, targetName, protocol, targetPorts, types.ServiceInterfaceBindOptions{
+						TargetNamespace: bindTargetNamespace,
+					})
 					if err != nil {
 						return fmt.Errorf("%w", err)
 					}
@@ -746,12 +1523,15 @@ func NewCmdBind(newClient cobraFunc) *cobra.Command {
 			return nil
 		},
 	}
-	cmd.Flags().StringVar(&protocol, "protocol", "", "The protocol to proxy (tcp, http or http2).")
-	cmd.Flags().IntVar(&targetPort, "target-port", 0, "The port the target is listening on.")
+	cmd.Flags().StringVar(&protocol, "protocol", "", "The protocol to proxy (tcp, http, http2 or grpc).")
+	cmd.Flags().IntSliceVar(&targetPorts, "target-port", nil, "The port the target is listening on (may be repeated, in the order of the ports of the service).")
+	cmd.Flags().StringVar(&bindTargetNamespace, "target-namespace", "", targetNamespaceUsage)
 
 	return cmd
 }
 
+var unbindTargetNamespace string
+
 func NewCmdUnbind(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:    "unbind <service-name> <target-type> <target-name>",
@@ -763,13 +1543,16 @@ func NewCmdUnbind(newClient cobraFunc) *cobra.Command {
 
 			targetType, targetName := parseTargetTypeAndName(args[1:])
 
-			err := cli.ServiceInterfaceUnbind(context.Background(), targetType, targetName, args[0], false)
+			err := cli.ServiceInterfaceUnbindWithOptions(context.Background(), targetType, targetName, args[0], types.ServiceInterfaceUnbindOptions{
+				TargetNamespace: unbindTargetNamespace,
+			})
 			if err != nil {
 				return fmt.Errorf("%w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&unbindTargetNamespace, "target-namespace", "", "The namespace of the target, if not that of the site")
 	return cmd
 }
 
@@ -789,6 +1572,9 @@ func NewCmdVersion(newClient cobraFunc) *cobra.Command {
 			if !IsZero(reflect.ValueOf(cli)) {
 				fmt.Printf("%-30s %s\n", "transport version", cli.GetVersion(types.TransportComponentName, types.TransportContainerName))
 				fmt.Printf("%-30s %s\n", "controller version", cli.GetVersion(types.ControllerComponentName, types.ControllerContainerName))
+				if showProvenance {
+					return printProvenance()
+				}
 			} else {
 				fmt.Printf("%-30s %s\n", "transport version", "not-found (no configuration has been provided)")
 				fmt.Printf("%-30s %s\n", "controller version", "not-found (no configuration has been provided)")
@@ -796,9 +1582,37 @@ func NewCmdVersion(newClient cobraFunc) *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVarP(&showProvenance, "provenance", "", false, "Report the image digests running for each component along with any attached SBOM and attestations")
 	return cmd
 }
 
+var showProvenance bool
+
+func printProvenance() error {
+	components, err := cli.ProvenanceInspect(context.Background(), true)
+	if err != nil {
+		return fmt.Errorf("Unable to retrieve provenance: %w", err)
+	}
+	for _, c := range components {
+		fmt.Println()
+		fmt.Printf("%-30s %s\n", c.Component+" image", c.Image)
+		for _, digest := range c.Digests {
+			fmt.Printf("%-30s %s\n", c.Component+" digest", digest)
+		}
+		if c.SBOM == "" {
+			fmt.Printf("%-30s %s\n", c.Component+" sbom", "not-found")
+		} else {
+			fmt.Printf("%s sbom:\n%s\n", c.Component, c.SBOM)
+		}
+		if c.Attestations == "" {
+			fmt.Printf("%-30s %s\n", c.Component+" attestations", "not-found")
+		} else {
+			fmt.Printf("%s attestations:\n%s\n", c.Component, c.Attestations)
+		}
+	}
+	return nil
+}
+
 func NewCmdDebug() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "debug dump <file> or debug action <tbd>",
@@ -807,21 +1621,160 @@ func NewCmdDebug() *cobra.Command {
 	return cmd
 }
 
+var dumpLogsSince time.Duration
+var dumpLogLimit string
+
 func NewCmdDebugDump(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "dump <filename>",
-		Short:  "Collect and save skupper logs, config, etc.",
+		Use:   "dump <filename>",
+		Short: "Collect and save skupper logs, config, etc.",
+		Long: `Collect and save skupper logs, config, etc. into a compressed tar file
+for support: versions, site config, link status, deployments, router
+management queries, qdstat output and container logs. A manifest.txt in
+the archive lists what was collected and anything that could not be.
+
+Container logs are streamed to a <filename>.logs directory before being
+archived; if the dump is interrupted, running it again with the same
+filename reuses the logs already collected.`,
 		Args:   cobra.ExactArgs(1),
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
-			err := cli.SkupperDump(context.Background(), args[0], client.Version, kubeConfigPath, kubeContext)
+			options := types.DumpOptions{
+				Version:           client.Version,
+				KubeConfigPath:    kubeConfigPath,
+				KubeConfigContext: kubeContext,
+				LogsSince:         dumpLogsSince,
+			}
+			if dumpLogLimit != "" {
+				limit, err := resource.ParseQuantity(dumpLogLimit)
+				if err != nil {
+					return fmt.Errorf("Invalid log limit %q: %s", dumpLogLimit, err)
+				}
+				options.LogLimitBytes = limit.Value()
+			}
+			err := cli.SkupperDumpWithOptions(context.Background(), args[0], options)
 			if err != nil {
 				return fmt.Errorf("Unable to save skupper details: %w", err)
 			}
 			return nil
 		},
 	}
+	cmd.Flags().DurationVar(&dumpLogsSince, "logs-since", 0, "Only collect container logs newer than this (e.g. 1h)")
+	cmd.Flags().StringVar(&dumpLogLimit, "log-limit", "", "The maximum size of the logs collected from each container (e.g. 100Mi)")
+	return cmd
+}
+
+func NewCmdDebugReport(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Diagnose common problems with the site",
+		Long: `Diagnose common problems with the site. Checks that skupper is installed
+and running, and looks for known issues such as ingress misconfiguration,
+expired certificates, version skew and inactive links. The summary leaves out
+hostnames and credentials so that the report can be attached to an issue.`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			report, err := cli.SkupperDebugReport(context.Background())
+			if err != nil {
+				return fmt.Errorf("Unable to generate report: %w", err)
+			}
+			if len(report.Summary) > 0 {
+				fmt.Println("Summary:")
+				for _, line := range report.Summary {
+					fmt.Println("    " + line)
+				}
+				fmt.Println()
+			}
+			fmt.Println("Checks:")
+			for _, f := range report.Findings {
+				fmt.Printf("    [%s] %s: %s", f.Status, f.Check, f.Message)
+				fmt.Println()
+				if f.Suggestion != "" {
+					fmt.Printf("        suggestion: %s", f.Suggestion)
+					fmt.Println()
+				}
+			}
+			fmt.Println()
+			problems := report.Count(types.FindingProblem)
+			warnings := report.Count(types.FindingWarning)
+			if problems == 0 && warnings == 0 {
+				fmt.Println("Diagnosis: no known issues found")
+			} else {
+				fmt.Printf("Diagnosis: %d problem(s), %d warning(s)", problems, warnings)
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdDebugConnectivity(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "connectivity",
+		Short: "Check the links of this site for connectivity problems",
+		Long: `Check the links of this site for connectivity problems. Probe messages of
+increasing size are sent to each directly linked site and echoed back, to
+detect paths over which large frames are dropped (e.g. MTU problems).`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			results, err := cli.RouterLinkProbe(context.Background())
+			if err != nil {
+				return fmt.Errorf("Unable to probe links: %w", err)
+			}
+			if len(results) == 0 {
+				fmt.Println("No linked sites found")
+				return nil
+			}
+			for _, result := range results {
+				if result.Problem == "" {
+					fmt.Printf("%s: all probes up to %d bytes delivered", result.SiteId, result.Delivered[len(result.Delivered)-1])
+					fmt.Println()
+					continue
+				}
+				fmt.Printf("%s: %s", result.SiteId, result.Problem)
+				fmt.Println()
+				fmt.Printf("    delivered: %v, dropped: %v", result.Delivered, result.Failed)
+				fmt.Println()
+				for _, e := range result.Errors {
+					fmt.Printf("    - %s", e)
+					fmt.Println()
+				}
+				if result.Suggestion != "" {
+					fmt.Printf("    %s", result.Suggestion)
+					fmt.Println()
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+var profileDuration time.Duration
+
+func NewCmdDebugProfile(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "profile <filename>",
+		Short:  "Capture cpu and heap profiles of the service controller",
+		Long:   "Capture cpu and heap profiles of the service controller. Requires the site to be initialised with --enable-controller-profiling.",
+		Args:   cobra.ExactArgs(1),
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			err := cli.SkupperProfile(context.Background(), args[0], profileDuration)
+			if err != nil {
+				return fmt.Errorf("Unable to save profiles: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&profileDuration, "duration", 30*time.Second, "The duration of the cpu profile")
 	return cmd
 }
 
@@ -859,6 +1812,7 @@ func newClientSansExit(cmd *cobra.Command, args []string) {
 var kubeContext string
 var namespace string
 var kubeConfigPath string
+var platform string
 var rootCmd *cobra.Command
 var cli types.VanClientInterface
 
@@ -868,6 +1822,8 @@ func init() {
 	cmdInit := NewCmdInit(newClient)
 	cmdDelete := NewCmdDelete(newClient)
 	cmdUpdate := NewCmdUpdate(newClient)
+	cmdUpdate.AddCommand(NewCmdUpdateFinalizeLegacy(newClient))
+	cmdUpdate.AddCommand(NewCmdUpdateRotateCerts(newClient))
 	cmdStatus := NewCmdStatus(newClient)
 	cmdExpose := NewCmdExpose(newClient)
 	cmdUnexpose := NewCmdUnexpose(newClient)
@@ -879,6 +1835,7 @@ func init() {
 	cmdUnbind := NewCmdUnbind(newClient)
 	cmdVersion := NewCmdVersion(newClientSansExit)
 	cmdDebugDump := NewCmdDebugDump(newClient)
+	cmdDebugProfile := NewCmdDebugProfile(newClient)
 
 	//backwards compatibility commands hidden
 	deprecatedMessage := "please use 'skupper service [bind|unbind]' instead"
@@ -919,9 +1876,16 @@ func init() {
 	cmdService.AddCommand(NewCmdBind(newClient))
 	cmdService.AddCommand(NewCmdUnbind(newClient))
 	cmdService.AddCommand(cmdStatusService)
+	cmdService.AddCommand(NewCmdServiceMetadata(newClient))
+	cmdService.AddCommand(NewCmdServiceSyncNow(newClient))
+	cmdService.AddCommand(NewCmdServiceImport(newClient))
+	cmdService.AddCommand(NewCmdServiceUnimport(newClient))
 
 	cmdDebug := NewCmdDebug()
 	cmdDebug.AddCommand(cmdDebugDump)
+	cmdDebug.AddCommand(cmdDebugProfile)
+	cmdDebug.AddCommand(NewCmdDebugConnectivity(newClient))
+	cmdDebug.AddCommand(NewCmdDebugReport(newClient))
 
 	cmdLink := NewCmdLink()
 	cmdLink.AddCommand(NewCmdLinkCreate(newClient, ""))
@@ -930,6 +1894,36 @@ func init() {
 
 	cmdToken := NewCmdToken()
 	cmdToken.AddCommand(NewCmdTokenCreate(newClient, ""))
+	cmdToken.AddCommand(NewCmdTokenList(newClient))
+	cmdToken.AddCommand(NewCmdTokenPrune(newClient))
+
+	cmdFleet := NewCmdFleet()
+	cmdFleet.AddCommand(NewCmdFleetExec())
+
+	cmdConsoleUser := NewCmdConsoleUser()
+	cmdConsoleUser.AddCommand(NewCmdConsoleUserCreate(newClient))
+	cmdConsoleUser.AddCommand(NewCmdConsoleUserList(newClient))
+	cmdConsoleUser.AddCommand(NewCmdConsoleUserDelete(newClient))
+
+	cmdConfig := NewCmdConfig()
+	cmdConfig.AddCommand(NewCmdConfigSet())
+	cmdConfig.AddCommand(NewCmdConfigGet())
+
+	cmdNetwork := NewCmdNetwork()
+	cmdNetwork.AddCommand(NewCmdNetworkScaffold())
+	cmdNetwork.AddCommand(NewCmdNetworkStatus(newClient))
+
+	cmdGateway := NewCmdGateway()
+	cmdGateway.AddCommand(NewCmdGatewayInit(newClient))
+	cmdGateway.AddCommand(NewCmdGatewayExpose(newClient))
+	cmdGateway.AddCommand(NewCmdGatewayBind(newClient))
+	cmdGateway.AddCommand(NewCmdGatewayUnbind(newClient))
+	cmdGateway.AddCommand(NewCmdGatewayDelete(newClient))
+	cmdGateway.AddCommand(NewCmdGatewayStatus(newClient))
+
+	cmdMetricsApi := NewCmdMetricsApi()
+	cmdMetricsApi.AddCommand(NewCmdMetricsApiRegister(newClient))
+	cmdMetricsApi.AddCommand(NewCmdMetricsApiUnregister(newClient))
 
 	cmdCompletion := NewCmdCompletion()
 
@@ -953,16 +1947,50 @@ func init() {
 		cmdUnbind,
 		cmdVersion,
 		cmdDebug,
+		cmdFleet,
+		cmdConsoleUser,
+		cmdConfig,
+		cmdNetwork,
+		cmdGateway,
+		cmdMetricsApi,
 		cmdCompletion)
 
+	rootCmd.PersistentPreRun = applyCliConfig
 	rootCmd.PersistentFlags().StringVarP(&kubeConfigPath, "kubeconfig", "", "", "Path to the kubeconfig file to use")
 	rootCmd.PersistentFlags().StringVarP(&kubeContext, "context", "c", "", "The kubeconfig context to use")
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "The Kubernetes namespace to use")
+	rootCmd.PersistentFlags().StringVarP(&platform, "platform", "", "", "The platform of the site: kubernetes or podman, which runs the site in a container on this host with the namespace naming the site")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Show the progress of long running operations on stderr; repeat (-vv) to also show each request made of the cluster")
 
 }
 
+// writeMetricsFile writes the certificates the command issued, and the
+// changes it made to secrets holding credentials, to the file named by
+// SKUPPER_METRICS_FILE if set, in the Prometheus text format, for a
+// textfile collector to export. The command exits before it could be
+// scraped, so this is the only way its counts can be collected.
+func writeMetricsFile() {
+	path := os.Getenv("SKUPPER_METRICS_FILE")
+	if path == "" {
+		return
+	}
+	out := &bytes.Buffer{}
+	certs.WriteMetrics(out)
+	// written through a temporary file so that a collector never reads
+	// a partial one
+	if err := ioutil.WriteFile(path+".tmp", out.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write metrics to %s: %s\n", path, err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write metrics to %s: %s\n", path, err)
+	}
+}
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	writeMetricsFile()
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -794,7 +794,7 @@ func TestExposeWithCluster(t *testing.T) {
 			args:            []string{"deployent", "tcp-not-deployed"},
 			expectedCapture: "",
 			expectedOutput:  "",
-			expectedError:   "target type must be one of: [deployment, statefulset, pods, service]",
+			expectedError:   "target type must be one of: [deployment, statefulset, daemonset, pods, service]",
 			realCluster:     false,
 		},
 		{
@@ -964,7 +964,7 @@ func TestUnexposeWithCluster(t *testing.T) {
 			args:            []string{"deployent", "tcp-not-deployed"},
 			expectedCapture: "",
 			expectedOutput:  "",
-			expectedError:   "target type must be one of: [deployment, statefulset, pods, service]",
+			expectedError:   "target type must be one of: [deployment, statefulset, daemonset, pods, service]",
 			realCluster:     false,
 		},
 		{
@@ -1245,7 +1245,7 @@ func TestBindWithCluster(t *testing.T) {
 			args:            []string{"tcp-go-echo", "deployment", "tcp-go-echo3", "--protocol", "sctp"},
 			expectedCapture: "",
 			expectedOutput:  "",
-			expectedError:   "sctp is not a valid protocol. Choose 'tcp', 'http' or 'http2'",
+			expectedError:   "sctp is not a valid protocol. Choose 'tcp', 'http', 'http2' or 'grpc'",
 			realCluster:     true,
 		},
 	}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/messages"
+)
+
+var cliConfig *config.Config
+
+func loadCliConfig() *config.Config {
+	c, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Println("Ignoring config file:", err.Error())
+	}
+	if path := os.Getenv(messages.EnvCatalog); path != "" {
+		if err := messages.LoadCatalog(path); err != nil {
+			fmt.Println("Ignoring message catalog:", err.Error())
+		}
+	}
+	return c
+}
+
+// applyCliConfig loads the config when a command is run, rather than
+// when the CLI starts, and uses it for the global flags not given
+func applyCliConfig(cmd *cobra.Command, args []string) {
+	cliConfig = loadCliConfig()
+	for _, key := range config.Keys {
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if value := cliConfig.Get(key); value != "" {
+			flag.Value.Set(value)
+		}
+	}
+}
+
+func NewCmdConfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config set <key> <value> or config get [<key>]",
+		Short: "Manage default settings for the skupper CLI",
+		Long: `Manage default settings for the skupper CLI. Settings are stored in
+` + config.DefaultPath() + ` and can be overridden
+by environment variables (e.g. SKUPPER_NAMESPACE) or command line flags.`,
+	}
+	return cmd
+}
+
+func NewCmdConfigSet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set the default for a setting; an empty value removes it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			err := cliConfig.Set(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return cliConfig.Save()
+		},
+	}
+	return cmd
+}
+
+func NewCmdConfigGet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get [<key>]",
+		Short: "Show the default for one or all settings",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if len(args) == 1 {
+				if !config.IsValidKey(args[0]) {
+					return fmt.Errorf("Invalid key %q", args[0])
+				}
+				fmt.Println(cliConfig.Get(args[0]))
+				return nil
+			}
+			values := cliConfig.Values()
+			keys := []string{}
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Printf("%-15s %s", key, values[key])
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skupperproject/skupper/pkg/auth"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+func NewCmdConsoleUser() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "console-user create <name> or console-user list or console-user delete <name>",
+		Short: "Manage the users of the skupper console when console authentication is internal",
+	}
+	return cmd
+}
+
+var consoleUserPassword string
+var consoleUserRole string
+
+func NewCmdConsoleUserCreate(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a console user, or change the password and role of an existing one",
+		Long: `Create a console user, or change the password and role of an existing one.
+Passwords are stored hashed; if no password is given, one is generated and
+printed.`,
+		Args:   cobra.ExactArgs(1),
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			password := consoleUserPassword
+			if password == "" {
+				password = utils.RandomId(10)
+			}
+			err := cli.ConsoleUserCreate(context.Background(), args[0], password, consoleUserRole)
+			if err != nil {
+				return fmt.Errorf("Failed to create console user: %w", err)
+			}
+			fmt.Printf("Console user %s created with role %s", args[0], consoleUserRole)
+			fmt.Println()
+			if consoleUserPassword == "" {
+				fmt.Println("Password:", password)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&consoleUserPassword, "password", "", "The password for the user (generated if not specified)")
+	cmd.Flags().StringVar(&consoleUserRole, "role", auth.RoleAdmin, "The role of the user: "+auth.RoleAdmin+" or "+auth.RoleViewer+" (read-only)")
+	return cmd
+}
+
+func NewCmdConsoleUserList(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "list",
+		Short:  "List the console users",
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			users, err := cli.ConsoleUserList(context.Background())
+			if err != nil {
+				return fmt.Errorf("Failed to list console users: %w", err)
+			}
+			if len(users) == 0 {
+				fmt.Println("No console users defined")
+				return nil
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tROLE")
+			for _, user := range users {
+				fmt.Fprintf(tw, "%s\t%s\n", user.Name, user.Role)
+			}
+			return tw.Flush()
+		},
+	}
+	return cmd
+}
+
+func NewCmdConsoleUserDelete(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "delete <name>",
+		Short:  "Delete a console user",
+		Args:   cobra.ExactArgs(1),
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			err := cli.ConsoleUserRemove(context.Background(), args[0])
+			if err != nil {
+				return fmt.Errorf("Failed to delete console user: %w", err)
+			}
+			fmt.Printf("Console user %s deleted", args[0])
+			fmt.Println()
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skupperproject/skupper/client"
+)
+
+func NewCmdFleet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fleet exec --selector <labels> -- <subcommand>",
+		Short: "Run a skupper command against many sites",
+	}
+	return cmd
+}
+
+var validFleetCommands = []string{"status", "update", "expose", "unexpose", "service", "link", "version"}
+
+func fleetExecArgs(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("A subcommand must be specified (e.g. 'skupper fleet exec --selector env=prod -- status')")
+	}
+	if !stringSliceContains(validFleetCommands, args[0]) {
+		return fmt.Errorf("subcommand must be one of: [%s]", strings.Join(validFleetCommands, ", "))
+	}
+	return nil
+}
+
+func fleetMemberArgs(member *client.FleetMember, args []string) []string {
+	memberArgs := append([]string{}, args...)
+	memberArgs = append(memberArgs, "--context", member.Context, "--namespace", member.Namespace)
+	if kubeConfigPath != "" {
+		memberArgs = append(memberArgs, "--kubeconfig", kubeConfigPath)
+	}
+	return memberArgs
+}
+
+var fleetSelector string
+
+func NewCmdFleetExec() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec -- <subcommand> [args]",
+		Short: "Run a subcommand against every site in the kubeconfig matching the selector",
+		Long: `Run a subcommand against every site in the kubeconfig whose skupper-site labels
+match the selector. Output from each site is reported in turn and the
+command fails if the subcommand failed for any site.`,
+		Args: fleetExecArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			members, err := client.NewFleet(namespace, kubeConfigPath, fleetSelector)
+			if err != nil {
+				return fmt.Errorf("Unable to determine sites: %w", err)
+			}
+			if len(members) == 0 {
+				fmt.Println("No sites match the selector")
+				return nil
+			}
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, member := range members {
+				fmt.Printf("=== %s (namespace %s) ===", member.Context, member.Namespace)
+				fmt.Println()
+				if member.Error != nil {
+					fmt.Println("Unable to reach site:", member.Error.Error())
+					failed++
+					continue
+				}
+				out, err := exec.Command(executable, fleetMemberArgs(member, args)...).CombinedOutput()
+				fmt.Print(string(out))
+				if err != nil {
+					failed++
+				}
+			}
+			fmt.Println()
+			fmt.Printf("Completed on %d of %d sites", len(members)-failed, len(members))
+			fmt.Println()
+			if failed > 0 {
+				return fmt.Errorf("%s failed on %d sites", args[0], failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&fleetSelector, "selector", "l", "", "Label selector matched against the labels of each skupper-site (e.g. env=prod)")
+
+	return cmd
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
+)
+
+func NewCmdGateway() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gateway init or gateway expose ...",
+		Short: "Expose processes running outside of the cluster, e.g. on VMs, through a gateway",
+		Long: `A gateway runs a router on this host that attaches to the site, so that
+processes reachable from this host can be exposed as services of the
+network. The router is run as a systemd user service or, with --type
+podman, in a container.`,
+	}
+	return cmd
+}
+
+var gatewayName string
+var gatewayType string
+var gatewayProtocol string
+var gatewayStatusOutput string
+
+func addGatewayNameFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&gatewayName, "name", "", "", "The name of the gateway (defaults to the name of this host)")
+}
+
+// parseGatewayPorts reads ports given as <port> or <port>:<target-port>
+func parseGatewayPorts(args []string) ([]int, []int, error) {
+	ports := []int{}
+	targetPorts := []int{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, ":", 2)
+		port, err := strconv.Atoi(parts[0])
+		if err != nil || port <= 0 {
+			return nil, nil, fmt.Errorf("Bad port: %q, expected <port> or <port>:<target-port>", arg)
+		}
+		targetPort := port
+		if len(parts) == 2 {
+			targetPort, err = strconv.Atoi(parts[1])
+			if err != nil || targetPort <= 0 {
+				return nil, nil, fmt.Errorf("Bad port: %q, expected <port> or <port>:<target-port>", arg)
+			}
+		}
+		ports = append(ports, port)
+		targetPorts = append(targetPorts, targetPort)
+	}
+	return ports, targetPorts, nil
+}
+
+func gatewayBindingFromArgs(args []string) (types.GatewayBinding, error) {
+	binding := types.GatewayBinding{
+		Address:  args[0],
+		Host:     args[1],
+		Protocol: gatewayProtocol,
+	}
+	ports, targetPorts, err := parseGatewayPorts(args[2:])
+	if err != nil {
+		return binding, err
+	}
+	if len(ports) > 0 {
+		binding.Ports = ports
+		binding.TargetPorts = targetPorts
+	}
+	return binding, nil
+}
+
+func NewCmdGatewayInit(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "init",
+		Short:  "Set up a gateway on this host, attached to the site",
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			name, err := cli.GatewayInit(context.Background(), gatewayName, gatewayType)
+			if err != nil {
+				return fmt.Errorf("Unable to create gateway: %w", err)
+			}
+			fmt.Printf("Skupper gateway: '%s'. Use 'skupper gateway status' to get more information.\n", name)
+			return nil
+		},
+	}
+	addGatewayNameFlag(cmd)
+	cmd.Flags().StringVarP(&gatewayType, "type", "", types.GatewayTypeService, "How the router of the gateway is run: service (a systemd user service) or podman")
+	return cmd
+}
+
+func NewCmdGatewayExpose(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "expose <address> <host> <port>[:<target-port>]...",
+		Short: "Expose a process reachable from this host as a service, creating the service if needed",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 3 {
+				return fmt.Errorf("An address, a host and at least one port must be specified")
+			}
+			return nil
+		},
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			binding, err := gatewayBindingFromArgs(args)
+			if err != nil {
+				return err
+			}
+			if err := cli.GatewayExpose(context.Background(), gatewayName, binding); err != nil {
+				return fmt.Errorf("Unable to expose %s through gateway: %w", binding.Address, err)
+			}
+			return nil
+		},
+	}
+	addGatewayNameFlag(cmd)
+	cmd.Flags().StringVarP(&gatewayProtocol, "protocol", "", "", "The protocol of the service if it is created (tcp, http, http2 or grpc; defaults to tcp)")
+	return cmd
+}
+
+func NewCmdGatewayBind(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bind <address> <host> [<port>[:<target-port>]...]",
+		Short: "Bind an existing service to a process reachable from this host",
+		Long: `Bind an existing service to a process reachable from this host. The
+ports default to those of the service, and each port is forwarded to the
+same port on the host unless a target port is given.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("An address and a host must be specified")
+			}
+			return nil
+		},
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			binding, err := gatewayBindingFromArgs(args)
+			if err != nil {
+				return err
+			}
+			if err := cli.GatewayBind(context.Background(), gatewayName, binding); err != nil {
+				return fmt.Errorf("Unable to bind %s to gateway: %w", binding.Address, err)
+			}
+			return nil
+		},
+	}
+	addGatewayNameFlag(cmd)
+	return cmd
+}
+
+func NewCmdGatewayUnbind(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "unbind <address>",
+		Short:  "Stop reaching the targets of a service through the gateway",
+		Args:   cobra.ExactArgs(1),
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if err := cli.GatewayUnbind(context.Background(), gatewayName, args[0]); err != nil {
+				return fmt.Errorf("Unable to unbind %s from gateway: %w", args[0], err)
+			}
+			return nil
+		},
+	}
+	addGatewayNameFlag(cmd)
+	return cmd
+}
+
+func NewCmdGatewayDelete(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "delete",
+		Short:  "Stop the gateway and remove it from the site",
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if err := cli.GatewayRemove(context.Background(), gatewayName); err != nil {
+				return fmt.Errorf("Unable to delete gateway: %w", err)
+			}
+			return nil
+		},
+	}
+	addGatewayNameFlag(cmd)
+	return cmd
+}
+
+// writeGatewayStatus describes each gateway and the services bound to it
+func writeGatewayStatus(out io.Writer, gateways []*types.GatewayInspectResponse) {
+	if len(gateways) == 0 {
+		fmt.Fprintln(out, messages.Sprintf(messages.GatewayNoneDefined))
+		return
+	}
+	for _, gateway := range gateways {
+		if gateway.Connected {
+			fmt.Fprintln(out, messages.Sprintf(messages.GatewayConnected, gateway.Name, gateway.Type, gateway.Url))
+		} else {
+			fmt.Fprintln(out, messages.Sprintf(messages.GatewayNotConnected, gateway.Name, gateway.Type, gateway.Url))
+		}
+		for _, binding := range gateway.Bindings {
+			targets := []string{}
+			for i, port := range binding.Ports {
+				targets = append(targets, fmt.Sprintf("%d => %s:%d", port, binding.Host, binding.TargetPorts[i]))
+			}
+			fmt.Fprintf(out, "    %s (%s): %s\n", binding.Address, binding.Protocol, strings.Join(targets, ", "))
+		}
+	}
+}
+
+func NewCmdGatewayStatus(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "status",
+		Short:  "Show the gateways of the site, whether they are connected and the services bound to them",
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if gatewayStatusOutput != "table" && gatewayStatusOutput != "json" {
+				return fmt.Errorf("Invalid output format %q, choose table or json", gatewayStatusOutput)
+			}
+			var gateways []*types.GatewayInspectResponse
+			if gatewayName != "" {
+				gateway, err := cli.GatewayInspect(context.Background(), gatewayName)
+				if err != nil {
+					return fmt.Errorf("Unable to retrieve gateway status: %w", err)
+				}
+				gateways = append(gateways, gateway)
+			} else {
+				list, err := cli.GatewayList(context.Background())
+				if err != nil {
+					return fmt.Errorf("Unable to retrieve gateway status: %w", err)
+				}
+				gateways = list
+			}
+			if gatewayStatusOutput == "json" {
+				data, err := json.MarshalIndent(gateways, "", "    ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			writeGatewayStatus(os.Stdout, gateways)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&gatewayName, "name", "", "", "Show only the named gateway")
+	cmd.Flags().StringVarP(&gatewayStatusOutput, "output", "o", "table", "The output format (table or json)")
+	return cmd
+}
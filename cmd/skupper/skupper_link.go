@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 )
 
 func NewCmdLink() *cobra.Command {
@@ -22,6 +24,23 @@ func NewCmdLink() *cobra.Command {
 }
 
 var connectorCreateOpts types.ConnectorCreateOptions
+var linkFromBundle bool
+var linkProxy string
+
+// parseLinkProxy sets the host and port of the proxy for the link from
+// its address, given as <host>:<port>
+func parseLinkProxy(address string, proxy *types.LinkProxy) error {
+	if address == "" {
+		return types.CheckLinkProxy(*proxy)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return fmt.Errorf("Bad value for --proxy: %q, expected <host>:<port>", address)
+	}
+	proxy.Host = host
+	proxy.Port = port
+	return types.CheckLinkProxy(*proxy)
+}
 
 func NewCmdLinkCreate(newClient cobraFunc, flag string) *cobra.Command {
 
@@ -36,45 +55,56 @@ func NewCmdLinkCreate(newClient cobraFunc, flag string) *cobra.Command {
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
+			if err := parseLinkProxy(linkProxy, &connectorCreateOpts.Proxy); err != nil {
+				return err
+			}
 			siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
 			if err != nil {
 				fmt.Println("Unable to retrieve site config: ", err.Error())
 				os.Exit(1)
 			} else if siteConfig == nil || !siteConfig.Spec.SiteControlled {
 				connectorCreateOpts.SkupperNamespace = cli.GetNamespace()
-				secret, err := cli.ConnectorCreateFromFile(context.Background(), args[0], connectorCreateOpts)
+				createFromFile := cli.ConnectorCreateFromFile
+				if linkFromBundle {
+					createFromFile = cli.LinkCreateFromBundle
+				}
+				secret, err := createFromFile(context.Background(), args[0], connectorCreateOpts)
 				if err != nil {
 					return fmt.Errorf("Failed to create connection: %w", err)
 				} else {
 					if siteConfig.Spec.RouterMode == string(types.TransportModeEdge) {
-						fmt.Printf("Skupper configured to connect to %s:%s (name=%s)\n",
+						fmt.Println(messages.Sprintf(messages.LinkConfigured,
 							secret.ObjectMeta.Annotations["edge-host"],
 							secret.ObjectMeta.Annotations["edge-port"],
-							secret.ObjectMeta.Name)
+							secret.ObjectMeta.Name))
 					} else {
-						fmt.Printf("Skupper configured to connect to %s:%s (name=%s)\n",
+						fmt.Println(messages.Sprintf(messages.LinkConfigured,
 							secret.ObjectMeta.Annotations["inter-router-host"],
 							secret.ObjectMeta.Annotations["inter-router-port"],
-							secret.ObjectMeta.Name)
+							secret.ObjectMeta.Name))
 					}
 				}
 			} else {
 				// create the secret, site-controller will do the rest
 				connectorCreateOpts.SkupperNamespace = cli.GetNamespace()
-				secret, err := cli.ConnectorCreateSecretFromFile(context.Background(), args[0], connectorCreateOpts)
+				createFromFile := cli.ConnectorCreateSecretFromFile
+				if linkFromBundle {
+					createFromFile = cli.LinkCreateFromBundle
+				}
+				secret, err := createFromFile(context.Background(), args[0], connectorCreateOpts)
 				if err != nil {
 					return fmt.Errorf("Failed to create connection: %w", err)
 				} else {
 					if siteConfig.Spec.RouterMode == string(types.TransportModeEdge) {
-						fmt.Printf("Skupper site-controller configured to connect to %s:%s (name=%s)\n",
+						fmt.Println(messages.Sprintf(messages.LinkConfiguredBySite,
 							secret.ObjectMeta.Annotations["edge-host"],
 							secret.ObjectMeta.Annotations["edge-port"],
-							secret.ObjectMeta.Name)
+							secret.ObjectMeta.Name))
 					} else {
-						fmt.Printf("Skupper site-controller configured to connect to %s:%s (name=%s)\n",
+						fmt.Println(messages.Sprintf(messages.LinkConfiguredBySite,
 							secret.ObjectMeta.Annotations["inter-router-host"],
 							secret.ObjectMeta.Annotations["inter-router-port"],
-							secret.ObjectMeta.Name)
+							secret.ObjectMeta.Name))
 					}
 				}
 			}
@@ -82,7 +112,12 @@ func NewCmdLinkCreate(newClient cobraFunc, flag string) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&connectorCreateOpts.Name, flag, "", "", "Provide a specific name for the connection (used when removing it with disconnect)")
-	cmd.Flags().Int32VarP(&connectorCreateOpts.Cost, "cost", "", 1, "Specify a cost for this connection.")
+	cmd.Flags().Int32VarP(&connectorCreateOpts.Cost, "cost", "", 0, "Specify a cost for this connection; traffic prefers the links with the lowest cost. By default the cost in the token is used if it has one, otherwise 1.")
+	cmd.Flags().StringToStringVar(&connectorCreateOpts.HostAliases, "host-alias", map[string]string{}, "Resolve the given hostnames to IP addresses in the router, as <hostname>=<ip>, where the host in the token is not resolvable in the cluster")
+	cmd.Flags().StringVarP(&linkProxy, "proxy", "", "", "Make the link through the HTTP proxy at <host>:<port>, using the CONNECT method, where the site cannot reach the other directly")
+	cmd.Flags().StringVarP(&connectorCreateOpts.Proxy.User, "proxy-user", "", "", "The user to authenticate to the proxy as")
+	cmd.Flags().StringVarP(&connectorCreateOpts.Proxy.Password, "proxy-password", "", "", "The password to authenticate to the proxy with")
+	cmd.Flags().BoolVarP(&linkFromBundle, "offline", "", false, "The token is an offline bundle created with 'token create --offline', which is checked for alteration before use")
 
 	return cmd
 }
@@ -102,7 +137,7 @@ func NewCmdLinkDelete(newClient cobraFunc) *cobra.Command {
 			connectorRemoveOpts.ForceCurrent = false
 			err := cli.ConnectorRemove(context.Background(), connectorRemoveOpts)
 			if err == nil {
-				fmt.Println("Link '" + args[0] + "' has been removed")
+				fmt.Println(messages.Sprintf(messages.LinkRemoved, args[0]))
 			} else {
 				return fmt.Errorf("Failed to remove link: %w", err)
 			}
@@ -115,6 +150,15 @@ func NewCmdLinkDelete(newClient cobraFunc) *cobra.Command {
 
 var waitFor int
 
+// formatCertificateExpiry reports the whole days remaining before the
+// certificates of a link expire
+func formatCertificateExpiry(name string, expiry time.Time, now time.Time) string {
+	if !expiry.After(now) {
+		return messages.Sprintf(messages.LinkCertificateExpired, name)
+	}
+	return messages.Sprintf(messages.LinkCertificateExpiry, name, int(expiry.Sub(now).Hours()/24))
+}
+
 func NewCmdLinkStatus(newClient cobraFunc) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:    "status [<connection-name>]",
@@ -136,10 +180,16 @@ func NewCmdLinkStatus(newClient cobraFunc) *cobra.Command {
 				vcis, err := cli.ConnectorList(context.Background())
 				if err == nil {
 					for _, vci := range vcis {
-						connectors = append(connectors, &types.ConnectorInspectResponse{
-							Connector: vci,
-							Connected: false,
-						})
+						inspected, err := cli.ConnectorInspect(context.Background(), vci.Name)
+						if err != nil || inspected == nil {
+							inspected = &types.ConnectorInspectResponse{
+								Connector: vci,
+								Connected: false,
+							}
+						} else if inspected.Connected {
+							connected++
+						}
+						connectors = append(connectors, inspected)
 					}
 				}
 			} else {
@@ -164,15 +214,19 @@ func NewCmdLinkStatus(newClient cobraFunc) *cobra.Command {
 			}
 
 			if len(connectors) == 0 {
-				fmt.Println("There are no connectors configured or active")
+				fmt.Println(messages.Sprintf(messages.LinkNoneConfigured))
 			} else {
 				for _, c := range connectors {
 					if c.Connected {
-						fmt.Printf("Connection for %s is active", c.Connector.Name)
-						fmt.Println()
+						fmt.Println(messages.Sprintf(messages.LinkActive, c.Connector.Name))
 					} else {
-						fmt.Printf("Connection for %s not active", c.Connector.Name)
-						fmt.Println()
+						fmt.Println(messages.Sprintf(messages.LinkNotActive, c.Connector.Name))
+					}
+					if c.Connector.Cost > 0 {
+						fmt.Println(messages.Sprintf(messages.LinkCost, c.Connector.Name, c.Connector.Cost))
+					}
+					if !c.CertificateExpiry.IsZero() {
+						fmt.Println(formatCertificateExpiry(c.Connector.Name, c.CertificateExpiry, time.Now()))
 					}
 				}
 			}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/skupperproject/skupper/pkg/messages"
+)
+
+func NewCmdMetricsApi() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics-api register or metrics-api unregister",
+		Short: "Manage the registration of the custom metrics API served by the site with the Kubernetes API aggregation layer",
+	}
+	return cmd
+}
+
+func NewCmdMetricsApiRegister(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "register",
+		Short: "Register the custom metrics API served by this site",
+		Long: `Register the custom metrics API served by the service controller of this site,
+so that link health and service traffic can be used by horizontal pod autoscalers
+and monitoring through the apiserver. Requires the site to be initialised with
+--enable-metrics-api and permission to manage cluster level resources.`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if err := cli.MetricsApiRegister(context.Background()); err != nil {
+				return err
+			}
+			fmt.Println(messages.Sprintf(messages.MetricsApiRegistered, cli.GetNamespace()))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdMetricsApiUnregister(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "unregister",
+		Short:  "Remove the registration of the custom metrics API served by this site",
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if err := cli.MetricsApiUnregister(context.Background()); err != nil {
+				return err
+			}
+			fmt.Println("The custom metrics API is no longer served by '" + cli.GetNamespace() + "'.")
+			return nil
+		},
+	}
+	return cmd
+}
@@ -6,9 +6,11 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 	"github.com/spf13/cobra"
 	"gotest.tools/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -19,14 +21,16 @@ import (
 type serviceInterfaceUnbindCallArgs struct {
 	targetType, targetName, address string
 	deleteIfNoTargets               bool
+	targetNamespace                 string
 }
 
 type serviceInterfaceBindCallArgs struct {
-	service    *types.ServiceInterface
-	targetType string
-	targetName string
-	protocol   string
-	targetPort int
+	service         *types.ServiceInterface
+	targetType      string
+	targetName      string
+	protocol        string
+	targetPorts     []int
+	targetNamespace string
 }
 
 type getHeadlessServiceConfigurationCallArgs struct {
@@ -98,6 +102,9 @@ func (v *vanClientMock) RouterInspect(ctx context.Context) (*types.RouterInspect
 func (v *vanClientMock) RouterInspectNamespace(ctx context.Context, namespace string) (*types.RouterInspectResponse, error) {
 	return nil, nil
 }
+func (v *vanClientMock) SiteInspect(ctx context.Context, namespace string) (*types.SiteInspectResponse, error) {
+	return nil, nil
+}
 func (v *vanClientMock) RouterRemove(ctx context.Context) error {
 	return nil
 }
@@ -107,6 +114,36 @@ func (v *vanClientMock) RouterUpdateVersion(ctx context.Context, hup bool) (bool
 func (v *vanClientMock) RouterUpdateVersionInNamespace(ctx context.Context, hup bool, namespace string) (bool, error) {
 	return true, nil
 }
+func (v *vanClientMock) RouterUpdateRollback(ctx context.Context, namespace string) (bool, error) {
+	return true, nil
+}
+func (v *vanClientMock) RouterUpdatePlan(ctx context.Context, hup bool, namespace string) (*types.RouterUpdatePlan, error) {
+	return &types.RouterUpdatePlan{}, nil
+}
+func (v *vanClientMock) RouterPeerStatus(ctx context.Context) ([]types.PeerStatus, error) {
+	return []types.PeerStatus{}, nil
+}
+func (v *vanClientMock) RouterLinkProbe(ctx context.Context) ([]types.LinkProbeResult, error) {
+	return []types.LinkProbeResult{}, nil
+}
+func (v *vanClientMock) ServiceSyncNow(ctx context.Context) error {
+	return nil
+}
+func (v *vanClientMock) NetworkStatus(ctx context.Context) (*types.NetworkStatus, error) {
+	return nil, nil
+}
+func (v *vanClientMock) MetricsApiRegister(ctx context.Context) error {
+	return nil
+}
+func (v *vanClientMock) MetricsApiUnregister(ctx context.Context) error {
+	return nil
+}
+func (v *vanClientMock) RouterCheckUpdate(ctx context.Context, namespace string) (*types.RouterUpdateCheckResponse, error) {
+	return &types.RouterUpdateCheckResponse{}, nil
+}
+func (v *vanClientMock) RouterFinalizeLegacyUpdate(ctx context.Context, force bool) ([]string, error) {
+	return nil, nil
+}
 func (v *vanClientMock) ConnectorCreateFromFile(ctx context.Context, secretFile string, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
 	return nil, nil
 }
@@ -133,6 +170,21 @@ func (v *vanClientMock) ConnectorTokenCreate(ctx context.Context, subject string
 func (v *vanClientMock) ConnectorTokenCreateFile(ctx context.Context, subject string, secretFile string) error {
 	return nil
 }
+func (v *vanClientMock) ConnectorTokenCreateWithOptions(ctx context.Context, subject string, namespace string, options types.TokenCreateOptions) (*corev1.Secret, bool, error) {
+	return nil, false, nil
+}
+func (v *vanClientMock) ConnectorTokenCreateFileWithOptions(ctx context.Context, subject string, secretFile string, options types.TokenCreateOptions) error {
+	return nil
+}
+func (v *vanClientMock) TokenExportOffline(ctx context.Context, subject string, bundleFile string, options types.OfflineBundleOptions) error {
+	return nil
+}
+func (v *vanClientMock) LinkCreateFromBundle(ctx context.Context, bundleFile string, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
+	return nil, nil
+}
+func (v *vanClientMock) RotateCertificates(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
 func (v *vanClientMock) ServiceInterfaceCreate(ctx context.Context, service *types.ServiceInterface) error {
 	return nil
 }
@@ -149,6 +201,31 @@ func (v *vanClientMock) ServiceInterfaceUnbind(ctx context.Context, targetType s
 	return v.injectedReturns.serviceInterfaceUnbind
 }
 
+func (v *vanClientMock) ServiceInterfaceUnbindWithOptions(ctx context.Context, targetType string, targetName string, address string, options types.ServiceInterfaceUnbindOptions) error {
+	err := v.ServiceInterfaceUnbind(ctx, targetType, targetName, address, options.DeleteIfNoTargets)
+	v.serviceInterfaceUnbindCalledWith[len(v.serviceInterfaceUnbindCalledWith)-1].targetNamespace = options.TargetNamespace
+	return err
+}
+
+func (v *vanClientMock) ServiceInterfaceImport(ctx context.Context, address string) error {
+	return nil
+}
+func (v *vanClientMock) ServiceInterfaceUnimport(ctx context.Context, address string) error {
+	return nil
+}
+func (v *vanClientMock) ServiceInterfaceImportList(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (v *vanClientMock) ConsoleUserCreate(ctx context.Context, name string, password string, role string) error {
+	return nil
+}
+func (v *vanClientMock) ConsoleUserList(ctx context.Context) ([]types.ConsoleUser, error) {
+	return []types.ConsoleUser{}, nil
+}
+func (v *vanClientMock) ConsoleUserRemove(ctx context.Context, name string) error {
+	return nil
+}
 func (v *vanClientMock) SiteConfigCreate(ctx context.Context, spec types.SiteConfigSpec) (*types.SiteConfig, error) {
 	v.siteConfigCreateCalledWith = append(v.siteConfigCreateCalledWith, spec)
 	return v.injectedReturns.siteConfigCreate.siteConfig, v.injectedReturns.siteConfigCreate.err
@@ -163,21 +240,47 @@ func (v *vanClientMock) SiteConfigInspect(ctx context.Context, input *corev1.Con
 	return v.injectedReturns.siteConfigInspect.siteConfig, v.injectedReturns.siteConfigInspect.err
 }
 
+func (v *vanClientMock) SiteConfigParse(ctx context.Context, data []byte) (*types.SiteConfig, error) {
+	return nil, nil
+}
+
 func (v *vanClientMock) SiteConfigRemove(ctx context.Context) error {
 	return nil
 }
 
+func (v *vanClientMock) SiteApply(ctx context.Context, spec types.SiteSpec) (*types.SiteApplyResult, error) {
+	return &types.SiteApplyResult{}, nil
+}
+
+func (v *vanClientMock) SkupperProfile(ctx context.Context, tarName string, duration time.Duration) error {
+	return nil
+}
+
+func (v *vanClientMock) SkupperDebugReport(ctx context.Context) (*types.DebugReport, error) {
+	return &types.DebugReport{}, nil
+}
+func (v *vanClientMock) SkupperDumpWithOptions(ctx context.Context, tarName string, options types.DumpOptions) error {
+	return nil
+}
+func (v *vanClientMock) DebugDump(ctx context.Context, path string) error {
+	return nil
+}
 func (v *vanClientMock) SkupperDump(ctx context.Context, tarName string, version string, kubeConfigPath string, kubeConfigContext string) error {
 	return nil
 }
 
-func (v *vanClientMock) ServiceInterfaceBind(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, protocol string, targetPort int) error {
+func (v *vanClientMock) ServiceInterfaceBind(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, protocol string, targetPorts []int) error {
+	return v.ServiceInterfaceBindWithOptions(ctx, service, targetType, targetName, protocol, targetPorts, types.ServiceInterfaceBindOptions{})
+}
+
+func (v *vanClientMock) ServiceInterfaceBindWithOptions(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, protocol string, targetPorts []int, options types.ServiceInterfaceBindOptions) error {
 	var calledWith = serviceInterfaceBindCallArgs{
-		service:    service,
-		targetType: targetType,
-		targetName: targetName,
-		protocol:   protocol,
-		targetPort: targetPort,
+		service:         service,
+		targetType:      targetType,
+		targetName:      targetName,
+		protocol:        protocol,
+		targetPorts:     targetPorts,
+		targetNamespace: options.TargetNamespace,
 	}
 	v.serviceInterfaceBindCalledWith = append(v.serviceInterfaceBindCalledWith, calledWith)
 
@@ -193,11 +296,22 @@ func (v *vanClientMock) ServiceInterfaceList(ctx context.Context) ([]*types.Serv
 	//return []*ServiceInterface{}, nil
 	return nil, nil
 }
+func (v *vanClientMock) ServiceInterfaceListWithOptions(ctx context.Context, options types.ServiceInterfaceListOptions) (*types.ServiceInterfaceListResponse, error) {
+	return &types.ServiceInterfaceListResponse{}, nil
+}
+
+func (v *vanClientMock) ServiceInterfaceStats(ctx context.Context, address string) (*types.AddressStats, error) {
+	return &types.AddressStats{Address: address}, nil
+}
 
 func (v *vanClientMock) ServiceInterfaceRemove(ctx context.Context, address string) error {
 	return nil
 }
 
+func (v *vanClientMock) ServiceInterfaceRemoveWithOptions(ctx context.Context, address string, options types.ServiceInterfaceRemoveOptions) error {
+	return nil
+}
+
 func (v *vanClientMock) ServiceInterfaceUpdate(ctx context.Context, service *types.ServiceInterface) error {
 	v.serviceInterfaceUpdateCalledWith = append(v.serviceInterfaceUpdateCalledWith, service)
 	return v.injectedReturns.serviceInterfaceUpdate
@@ -222,6 +336,38 @@ func (cli *vanClientMock) GetVersion(component string, name string) string {
 	return "not-found"
 }
 
+func (cli *vanClientMock) ProvenanceInspect(ctx context.Context, includeAttestations bool) ([]*types.ComponentProvenance, error) {
+	return nil, nil
+}
+
+func (cli *vanClientMock) GatewayInit(ctx context.Context, name string, gatewayType string) (string, error) {
+	return name, nil
+}
+
+func (cli *vanClientMock) GatewayBind(ctx context.Context, name string, binding types.GatewayBinding) error {
+	return nil
+}
+
+func (cli *vanClientMock) GatewayExpose(ctx context.Context, name string, binding types.GatewayBinding) error {
+	return nil
+}
+
+func (cli *vanClientMock) GatewayUnbind(ctx context.Context, name string, address string) error {
+	return nil
+}
+
+func (cli *vanClientMock) GatewayRemove(ctx context.Context, name string) error {
+	return nil
+}
+
+func (cli *vanClientMock) GatewayInspect(ctx context.Context, name string) (*types.GatewayInspectResponse, error) {
+	return nil, nil
+}
+
+func (cli *vanClientMock) GatewayList(ctx context.Context) ([]*types.GatewayInspectResponse, error) {
+	return nil, nil
+}
+
 func TestCmdUnexposeRun(t *testing.T) {
 	cmd := NewCmdUnexpose(nil)
 	test := func(targetType, targetName, address string) {
@@ -340,11 +486,9 @@ func TestExpose_NotBinding(t *testing.T) {
 	var err error
 	ctx := context.Background()
 	options := ExposeOptions{
-		Protocol:   "",
-		Address:    "",
-		Port:       0,
-		TargetPort: 0,
-		Headless:   false,
+		Protocol: "",
+		Address:  "",
+		Headless: false,
 	}
 
 	t.Run("ServiceInterfaceInspect returns error",
@@ -376,7 +520,7 @@ func TestExpose_NotBinding(t *testing.T) {
 			cli.injectedReturns.getHeadlessServiceConfiguration.serviceInterface = aService
 
 			options.Protocol = "theprotocol"
-			options.Port = 123
+			options.Ports = []int{123}
 
 			_, err = expose(cli, ctx, "statefulset", "name", options)
 			assert.Assert(t, err)
@@ -388,7 +532,7 @@ func TestExpose_NotBinding(t *testing.T) {
 				targetName: "name",
 				protocol:   options.Protocol,
 				address:    "ServiceName",
-				port:       options.Port,
+				port:       options.Ports[0],
 			}
 
 			assert.Assert(t, cmp.Equal(cli.getHeadlessServiceConfigurationCalledWith[0], expectedGetHead, cmp.AllowUnexported(getHeadlessServiceConfigurationCallArgs{})))
@@ -432,10 +576,11 @@ func TestExpose_Binding(t *testing.T) {
 		assert.Assert(t, a.targetType == b.targetType)
 		assert.Assert(t, a.targetName == b.targetName)
 		assert.Assert(t, a.protocol == b.protocol)
-		assert.Assert(t, a.targetPort == b.targetPort)
+		assert.DeepEqual(t, a.targetPorts, b.targetPorts)
+		assert.Equal(t, a.targetNamespace, b.targetNamespace)
 		assert.Assert(t, a.service.Address == b.service.Address)
 		assert.Assert(t, a.service.Protocol == b.service.Protocol)
-		assert.Assert(t, a.service.Port == b.service.Port)
+		assert.DeepEqual(t, a.service.Ports, b.service.Ports)
 	}
 	options := ExposeOptions{}
 
@@ -443,19 +588,19 @@ func TestExpose_Binding(t *testing.T) {
 	options.Address = "TheService"
 	options.Headless = false
 	options.Protocol = test_protocol
-	options.Port = 123
-	options.TargetPort = 234
+	options.Ports = []int{123}
+	options.TargetPorts = []int{234}
 
 	expectedBindCall := serviceInterfaceBindCallArgs{
 		service: &types.ServiceInterface{
 			Address:  "TheService",
 			Protocol: test_protocol,
-			Port:     123,
+			Ports:    []types.ServicePort{{Port: 123}},
 		},
-		targetType: "any",
-		targetName: "name",
-		protocol:   test_protocol,
-		targetPort: 234,
+		targetType:  "any",
+		targetName:  "name",
+		protocol:    test_protocol,
+		targetPorts: []int{234},
 	}
 
 	t.Run("service not existent and options.expose.headless == false",
@@ -475,7 +620,7 @@ func TestExpose_Binding(t *testing.T) {
 			cli := &vanClientMock{}
 			aService := &types.ServiceInterface{
 				Address:  "TheOtherService",
-				Port:     options.Port,
+				Ports:    []types.ServicePort{{Port: options.Ports[0]}},
 				Protocol: options.Protocol,
 			}
 			expectedBindCall := expectedBindCall
@@ -504,7 +649,24 @@ func TestExpose_Binding(t *testing.T) {
 			cli.injectedReturns.serviceInterfaceBind = errors.NewNotFound(schema.GroupResource{}, "name")
 			_, err := expose(cli, ctx, "any", "name", options)
 			assert.Error(t, err, "Skupper is not installed in Namespace: 'MockNamespace`")
+			assert.Assert(t, messages.Is(err, messages.SiteNotInstalled))
+			compare(&cli.serviceInterfaceBindCalledWith[0], &expectedBindCall)
+		})
+
+	t.Run("target in another namespace",
+		func(t *testing.T) {
+			cli := &vanClientMock{}
+			options := options
+			options.TargetNamespace = "backend"
+			expectedBindCall := expectedBindCall
+			expectedBindCall.targetNamespace = "backend"
+			_, err := expose(cli, ctx, "any", "name", options)
+			assert.Assert(t, err)
 			compare(&cli.serviceInterfaceBindCalledWith[0], &expectedBindCall)
+
+			options.Headless = true
+			_, err = expose(cli, ctx, "statefulset", "name", options)
+			assert.Error(t, err, "The headless option is not supported for targets in other namespaces")
 		})
 }
 
@@ -541,7 +703,7 @@ func TestCmdBind(t *testing.T) {
 			resetCli()
 			protocol = "invalidProtocol"
 			err := cmd.RunE(&cobra.Command{}, args)
-			assert.Error(t, err, "invalidProtocol is not a valid protocol. Choose 'tcp', 'http' or 'http2'.")
+			assert.Error(t, err, "invalidProtocol is not a valid protocol. Choose 'tcp', 'http', 'http2' or 'grpc'.")
 		})
 
 	t.Run("serviceNotFound",
@@ -574,7 +736,7 @@ func TestCmdBind(t *testing.T) {
 		func(t *testing.T) {
 			resetCli()
 			protocol = "tcp"
-			targetPort = 567
+			targetPorts = []int{567}
 			args = []string{"TheService", "type", "name"}
 			lcli.injectedReturns.serviceInterfaceInspect.serviceInterface = injectedService
 			err := cmd.RunE(&cobra.Command{}, args)
@@ -584,7 +746,7 @@ func TestCmdBind(t *testing.T) {
 			assert.Assert(t, c.protocol == "tcp")
 			assert.Assert(t, c.targetType == "type")
 			assert.Assert(t, c.targetName == "name")
-			assert.Assert(t, c.targetPort == 567)
+			assert.DeepEqual(t, c.targetPorts, []int{567})
 			assert.Assert(t, c.service == injectedService)
 
 		})
@@ -593,7 +755,7 @@ func TestCmdBind(t *testing.T) {
 		func(t *testing.T) {
 			resetCli()
 			protocol = "tcp"
-			targetPort = 567
+			targetPorts = []int{567}
 			args = []string{"TheService", "type", "name"}
 			lcli.injectedReturns.serviceInterfaceInspect.serviceInterface = injectedService
 			lcli.injectedReturns.serviceInterfaceBind = fmt.Errorf("some error")
@@ -602,3 +764,11 @@ func TestCmdBind(t *testing.T) {
 			assert.Error(t, err, "some error")
 		})
 }
+
+func (cli *vanClientMock) TokenList(ctx context.Context) ([]*types.TokenInfo, error) {
+	return nil, nil
+}
+
+func (cli *vanClientMock) TokenPrune(ctx context.Context, olderThan time.Duration) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+)
+
+func NewCmdNetwork() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network scaffold or status",
+		Short: "Plan networks of skupper sites",
+	}
+	return cmd
+}
+
+func siteConfigFileName(site types.NetworkSite) string {
+	return site.Name + "-site.yaml"
+}
+
+// writeSiteConfigs writes the config of each site as a skupper-site
+// ConfigMap that can be passed to 'skupper init --file'
+func writeSiteConfigs(topology *types.NetworkTopology, dir string) error {
+	for _, site := range topology.Sites {
+		cm := corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "skupper-site",
+			},
+			Data: site.Config,
+		}
+		data, err := yaml.Marshal(cm)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, siteConfigFileName(site)), data, 0644)
+		if err != nil {
+			return fmt.Errorf("Could not write site config for %s: %w", site.Name, err)
+		}
+	}
+	return nil
+}
+
+// networkPlan describes the commands that create the topology
+func networkPlan(topology *types.NetworkTopology) []string {
+	costs := map[string]int{}
+	for _, l := range topology.Links {
+		costs[l.Name] = l.Cost
+	}
+	steps := []string{}
+	for _, site := range topology.Sites {
+		steps = append(steps, fmt.Sprintf("%s: skupper init --file %s", site.Name, siteConfigFileName(site)))
+	}
+	for _, t := range topology.TokenExchange {
+		steps = append(steps,
+			fmt.Sprintf("%s: skupper token create %s", t.IssuedBy, t.Token),
+			fmt.Sprintf("%s: skupper link create %s --name %s --cost %d", t.RedeemedBy, t.Token, t.Link, costs[t.Link]))
+	}
+	return steps
+}
+
+var scaffoldPattern string
+var scaffoldSites int
+var scaffoldOutput string
+
+func NewCmdNetworkScaffold() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scaffold",
+		Short: "Generate the topology of a network in a common pattern",
+		Long: `Generate the site configuration, links and token exchange for a
+network of sites connected in one of the patterns hub-spoke, mesh or
+chain. The topology is written as yaml, to be edited as needed, and
+the commands that create it are listed. With --output, the config
+for each site is also written alongside it as a file for
+'skupper init --file'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			topology, err := client.NetworkScaffold(scaffoldPattern, scaffoldSites)
+			if err != nil {
+				return err
+			}
+			data, err := yaml.Marshal(topology)
+			if err != nil {
+				return err
+			}
+			if scaffoldOutput == "" {
+				fmt.Print(string(data))
+				return nil
+			}
+			err = ioutil.WriteFile(scaffoldOutput, data, 0644)
+			if err != nil {
+				return fmt.Errorf("Could not write topology: %w", err)
+			}
+			err = writeSiteConfigs(topology, filepath.Dir(scaffoldOutput))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Topology for %d sites written to %s, create it with:", len(topology.Sites), scaffoldOutput)
+			fmt.Println()
+			for _, step := range networkPlan(topology) {
+				fmt.Println("   ", step)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&scaffoldPattern, "pattern", types.NetworkPatternHubSpoke, "The pattern to connect the sites in (hub-spoke, mesh or chain)")
+	cmd.Flags().IntVar(&scaffoldSites, "sites", 3, "The number of sites in the network")
+	cmd.Flags().StringVarP(&scaffoldOutput, "output", "o", "", "Write the topology to this file rather than standard output")
+
+	return cmd
+}
+
+func siteLabel(site types.NetworkSiteStatus) string {
+	if site.Name == "" {
+		return site.Id
+	}
+	return site.Name
+}
+
+// writeNetworkTable writes a line for each site of the network
+func writeNetworkTable(out io.Writer, status *types.NetworkStatus) {
+	names := map[string]string{}
+	for _, site := range status.Sites {
+		names[site.Id] = siteLabel(site)
+	}
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SITE\tNAMESPACE\tVERSION\tLINKED TO\tSERVICES\tGATEWAYS")
+	for _, site := range status.Sites {
+		links := []string{}
+		for _, id := range site.Links {
+			if name, ok := names[id]; ok {
+				links = append(links, name)
+			} else {
+				links = append(links, id)
+			}
+		}
+		services := []string{}
+		for _, service := range site.Services {
+			services = append(services, fmt.Sprintf("%s (%s)", service.Address, service.Protocol))
+		}
+		name := siteLabel(site)
+		if site.Edge {
+			name += " (edge)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", name, site.Namespace, site.Version, strings.Join(links, ", "), strings.Join(services, ", "), strings.Join(site.Gateways, ", "))
+	}
+	tw.Flush()
+}
+
+// writeNetworkDot writes the network as a graph in the DOT language, with
+// the services of each site listed in its node
+func writeNetworkDot(out io.Writer, status *types.NetworkStatus) {
+	fmt.Fprintln(out, "digraph network {")
+	for _, site := range status.Sites {
+		label := siteLabel(site)
+		if site.Version != "" {
+			label += "\\n" + site.Version
+		}
+		for _, service := range site.Services {
+			label += fmt.Sprintf("\\n%s (%s)", service.Address, service.Protocol)
+		}
+		shape := "ellipse"
+		if site.Edge {
+			shape = "box"
+		}
+		fmt.Fprintf(out, "  %q [label=\"%s\" shape=%s];\n", site.Id, strings.ReplaceAll(label, `"`, `\"`), shape)
+	}
+	for _, site := range status.Sites {
+		for _, id := range site.Links {
+			fmt.Fprintf(out, "  %q -> %q;\n", site.Id, id)
+		}
+		for _, gateway := range site.Gateways {
+			fmt.Fprintf(out, "  %q [shape=diamond];\n", gateway)
+			fmt.Fprintf(out, "  %q -> %q [style=dashed];\n", gateway, site.Id)
+		}
+	}
+	fmt.Fprintln(out, "}")
+}
+
+var networkStatusOutput string
+
+func NewCmdNetworkStatus(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the sites of the network, the links between them and their services and gateways",
+		Long: `Show every site in the network as seen from this site: its name and
+version, the sites it has links to, the services exposed in it and the
+gateways attached to it. The output can be a table, json, or a graph in
+the DOT language (e.g. for 'dot -Tsvg').`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if networkStatusOutput != "table" && networkStatusOutput != "json" && networkStatusOutput != "dot" {
+				return fmt.Errorf("Invalid output format %q, choose table, json or dot", networkStatusOutput)
+			}
+			status, err := cli.NetworkStatus(context.Background())
+			if err != nil {
+				return fmt.Errorf("Unable to retrieve network status: %w", err)
+			}
+			switch networkStatusOutput {
+			case "json":
+				data, err := json.MarshalIndent(status, "", "    ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+			case "dot":
+				writeNetworkDot(os.Stdout, status)
+			default:
+				writeNetworkTable(os.Stdout, status)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&networkStatusOutput, "output", "o", "table", "The output format (table, json or dot)")
+
+	return cmd
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+var verbosity int
+
+const (
+	verboseProgress int = 1
+	verboseRequests int = 2
+
+	progressBarWidth    int           = 20
+	progressWaitPrinted time.Duration = 5 * time.Second
+)
+
+// progressPrinter writes the events reported by the client. Waits are
+// only reported every few seconds, so that they show the client is still
+// making progress without filling the terminal.
+type progressPrinter struct {
+	out     io.Writer
+	level   int
+	printed map[string]time.Duration
+}
+
+func newProgressPrinter(out io.Writer, level int) *progressPrinter {
+	return &progressPrinter{
+		out:     out,
+		level:   level,
+		printed: map[string]time.Duration{},
+	}
+}
+
+func progressBar(step int, steps int) string {
+	done := progressBarWidth * step / steps
+	return "[" + strings.Repeat("#", done) + strings.Repeat("-", progressBarWidth-done) + "]"
+}
+
+func (p *progressPrinter) handle(event types.ClientEvent) {
+	switch event.Type {
+	case types.ClientEventProgress:
+		if p.level < verboseProgress {
+			return
+		}
+		if event.Steps > 0 {
+			fmt.Fprintf(p.out, "%s %s %d/%d %s\n", event.Operation, progressBar(event.Step, event.Steps), event.Step, event.Steps, event.Message)
+			return
+		}
+		key := event.Operation + "/" + event.Message
+		if last, ok := p.printed[key]; ok && event.Elapsed-last < progressWaitPrinted {
+			return
+		}
+		p.printed[key] = event.Elapsed
+		fmt.Fprintf(p.out, "%s: %s (%s)\n", event.Operation, event.Message, event.Elapsed.Round(time.Second))
+	case types.ClientEventWarning:
+		fmt.Fprintf(p.out, "Warning: %s\n", event.Message)
+	case types.ClientEventRequest:
+		if p.level < verboseRequests {
+			return
+		}
+		result := ""
+		if event.Err != nil {
+			result = event.Err.Error()
+		} else {
+			result = fmt.Sprintf("%d %s", event.Status, http.StatusText(event.Status))
+		}
+		fmt.Fprintf(p.out, "%s %s: %s (%s)\n", event.Verb, event.Resource, result, event.Elapsed.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestProgressPrinter(t *testing.T) {
+	events := []types.ClientEvent{
+		{Type: types.ClientEventProgress, Operation: "update", Step: 2, Steps: 4, Message: "site-version-updated"},
+		{Type: types.ClientEventProgress, Operation: "loadbalancer", Message: "Waiting", Elapsed: 0},
+		{Type: types.ClientEventProgress, Operation: "loadbalancer", Message: "Waiting", Elapsed: 2 * time.Second},
+		{Type: types.ClientEventProgress, Operation: "loadbalancer", Message: "Waiting", Elapsed: 6 * time.Second},
+		{Type: types.ClientEventRequest, Verb: "get", Resource: "configmaps/skupper-site", Status: 200, Elapsed: 12 * time.Millisecond},
+		{Type: types.ClientEventRequest, Verb: "create", Resource: "secrets", Err: errors.New("refused"), Elapsed: time.Millisecond},
+		{Type: types.ClientEventWarning, Operation: "verify", Message: "Could not verify signature"},
+	}
+	progress := "update [##########----------] 2/4 site-version-updated\n" +
+		"loadbalancer: Waiting (0s)\n" +
+		"loadbalancer: Waiting (6s)\n"
+	requests := "get configmaps/skupper-site: 200 OK (12ms)\n" +
+		"create secrets: refused (1ms)\n"
+	warning := "Warning: Could not verify signature\n"
+
+	for level, expected := range map[int]string{
+		0:               warning,
+		verboseProgress: progress + warning,
+		verboseRequests: progress + requests + warning,
+	} {
+		out := &bytes.Buffer{}
+		printer := newProgressPrinter(out, level)
+		for _, event := range events {
+			printer.handle(event)
+		}
+		assert.Equal(t, out.String(), expected)
+	}
+}
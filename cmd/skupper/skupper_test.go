@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
 	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
 )
 
 func Test_parseTargetTypeAndName(t *testing.T) {
@@ -32,7 +38,7 @@ func Test_bindArgs(t *testing.T) {
 	//must this fail?
 	//assert.Error(t, b([]string{"one/two", "resource/name"}), genericError)
 
-	assert.Error(t, b([]string{"one", "resource/name"}), "target type must be one of: [deployment, statefulset, pods, service]")
+	assert.Error(t, b([]string{"one", "resource/name"}), "target type must be one of: [deployment, statefulset, daemonset, pods, service]")
 
 	assert.Assert(t, b([]string{"one", "pods/name"}))
 	assert.Assert(t, b([]string{"one", "pods", "name"}))
@@ -57,16 +63,16 @@ func Test_createServiceArgs(t *testing.T) {
 	assert.Assert(t, c([]string{"service:port"}))
 
 	assert.Error(t, c([]string{"service:port", "other"}), "extra argument: other")
-	assert.Error(t, c([]string{"service:port", "other", "arg"}), "illegal argument: arg")
+	assert.Error(t, c([]string{"service:port", "other", "arg"}), "extra argument: other")
 
 	assert.Assert(t, c([]string{"service", "port"}))
-	assert.Error(t, c([]string{"service", "port", "other"}), "illegal argument: other")
-	assert.Error(t, c([]string{"service", "port", "other", "arg"}), "illegal argument: other")
+	assert.Assert(t, c([]string{"service", "port", "other"}))
+	assert.Assert(t, c([]string{"service", "port", "other", "arg"}))
 }
 
 func Test_exposeTargetArgs(t *testing.T) {
 	genericError := "expose target and name must be specified (e.g. 'skupper expose deployment <name>'"
-	targetError := "target type must be one of: [deployment, statefulset, pods, service]"
+	targetError := "target type must be one of: [deployment, statefulset, daemonset, pods, service]"
 
 	e := func(args []string) error {
 		return exposeTargetArgs(nil, args)
@@ -105,9 +111,184 @@ func Test_cmdExposeParseArgs(t *testing.T) {
 	assert.Equal(t, exposeOpts.Address, "theAddress")
 }
 
+func Test_fleetExecArgs(t *testing.T) {
+	f := func(args []string) error {
+		return fleetExecArgs(nil, args)
+	}
+
+	assert.Error(t, f([]string{}), "A subcommand must be specified (e.g. 'skupper fleet exec --selector env=prod -- status')")
+	assert.Error(t, f([]string{"delete"}), "subcommand must be one of: [status, update, expose, unexpose, service, link, version]")
+
+	for _, command := range validFleetCommands {
+		assert.Assert(t, f([]string{command}))
+	}
+	assert.Assert(t, f([]string{"expose", "deployment", "name", "--port", "8080"}))
+}
+
+func Test_applyMetadataChanges(t *testing.T) {
+	current := map[string]string{"version": "1.1", "owner": "payments"}
+	updated, err := applyMetadataChanges(current, []string{"version=1.2", "owner-", "docs=https://docs.example.com/?a=b"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, updated, map[string]string{"version": "1.2", "docs": "https://docs.example.com/?a=b"})
+	assert.Equal(t, current["owner"], "payments")
+	assert.Equal(t, formatServiceMetadata(updated), "docs=https://docs.example.com/?a=b, version=1.2")
+
+	updated, err = applyMetadataChanges(current, []string{"version-", "owner-"})
+	assert.NilError(t, err)
+	assert.Assert(t, updated == nil)
+
+	_, err = applyMetadataChanges(current, []string{"version"})
+	assert.Error(t, err, `Invalid metadata change "version", expected <key>=<value> or <key>-`)
+}
+
+func Test_parseKeyValues(t *testing.T) {
+	values, err := parseKeyValues("labels", []string{"tier = backend", "owner", "selector=a=b"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, values, map[string]string{"tier": "backend", "owner": "", "selector": "a=b"})
+
+	values, err = parseKeyValues("labels", nil)
+	assert.NilError(t, err)
+	assert.Assert(t, values == nil)
+
+	_, err = parseKeyValues("annotations", []string{"=payments"})
+	assert.ErrorContains(t, err, `Bad value for --annotations: "=payments"`)
+}
+
+func Test_parseGatewayPorts(t *testing.T) {
+	ports, targetPorts, err := parseGatewayPorts([]string{"8080", "8443:443"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ports, []int{8080, 8443})
+	assert.DeepEqual(t, targetPorts, []int{8080, 443})
+
+	_, _, err = parseGatewayPorts([]string{"8080:http"})
+	assert.ErrorContains(t, err, `Bad port: "8080:http"`)
+	_, _, err = parseGatewayPorts([]string{"0"})
+	assert.ErrorContains(t, err, `Bad port: "0"`)
+}
+
+func Test_writeGatewayStatus(t *testing.T) {
+	out := &bytes.Buffer{}
+	writeGatewayStatus(out, nil)
+	assert.Equal(t, out.String(), "There are no gateways defined\n")
+
+	out.Reset()
+	writeGatewayStatus(out, []*types.GatewayInspectResponse{
+		{
+			Name:      "vm1",
+			Type:      types.GatewayTypeService,
+			Url:       "skupper-edge.example.com:443",
+			Connected: true,
+			Bindings: []types.GatewayBinding{
+				{Address: "web", Protocol: "http", Host: "localhost", Ports: []int{8080, 8443}, TargetPorts: []int{80, 443}},
+			},
+		},
+		{Name: "vm2", Type: types.GatewayTypePodman, Url: "skupper-edge.example.com:443"},
+	})
+	assert.Equal(t, out.String(), `Gateway vm1 (service) is connected to skupper-edge.example.com:443
+    web (http): 8080 => localhost:80, 8443 => localhost:443
+Gateway vm2 (podman) is not connected to skupper-edge.example.com:443
+`)
+}
+
+func Test_writeNetworkStatus(t *testing.T) {
+	status := &types.NetworkStatus{
+		Sites: []types.NetworkSiteStatus{
+			{
+				Id:       "a",
+				Name:     "east",
+				Version:  "0.6.0",
+				Links:    []string{"b"},
+				Services: []types.NetworkServiceStatus{{Address: "db", Protocol: "tcp"}},
+				Gateways: []string{"laptop"},
+			},
+			{
+				Id:   "b",
+				Name: "west",
+				Edge: true,
+			},
+		},
+	}
+	out := &bytes.Buffer{}
+	writeNetworkDot(out, status)
+	assert.Equal(t, out.String(), `digraph network {
+  "a" [label="east\n0.6.0\ndb (tcp)" shape=ellipse];
+  "b" [label="west" shape=box];
+  "a" -> "b";
+  "laptop" [shape=diamond];
+  "laptop" -> "a" [style=dashed];
+}
+`)
+
+	out.Reset()
+	writeNetworkTable(out, status)
+	assert.Equal(t, out.String(), `SITE         NAMESPACE  VERSION  LINKED TO  SERVICES  GATEWAYS
+east                    0.6.0    west       db (tcp)  laptop
+west (edge)                                           
+`)
+}
+
+func Test_writeTokenTable(t *testing.T) {
+	created := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	out := &bytes.Buffer{}
+	writeTokenTable(out, []*types.TokenInfo{
+		{Name: "conn1", Type: types.TypeToken, Created: created, LastConnected: created.Add(time.Hour), Configured: true, Connected: true},
+		{Name: "conn2", Type: types.TypeToken, Created: created, Protected: true},
+		{Name: "req1", Type: types.TypeTokenRequest, Created: created, Expiry: created.Add(24 * time.Hour)},
+	})
+	assert.Equal(t, out.String(), `NAME   TYPE     CREATED               LAST CONNECTED        EXPIRES               STATUS
+conn1  link     2021-03-01T12:00:00Z  2021-03-01T13:00:00Z  -                     connected
+conn2  link     2021-03-01T12:00:00Z  -                     -                     not configured (protected)
+req1   request  2021-03-01T12:00:00Z  -                     2021-03-02T12:00:00Z  not connected
+`)
+}
+
+func Test_applyCliConfig(t *testing.T) {
+	defer os.Setenv("SKUPPER_CONFIG", os.Getenv("SKUPPER_CONFIG"))
+	os.Setenv("SKUPPER_CONFIG", "testdata/no-such-config.yaml")
+	defer os.Unsetenv("SKUPPER_NAMESPACE")
+	os.Setenv("SKUPPER_NAMESPACE", "west")
+
+	var ns, context string
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVarP(&ns, "namespace", "n", "", "")
+	cmd.Flags().StringVarP(&context, "context", "c", "", "")
+	applyCliConfig(cmd, nil)
+	assert.Equal(t, ns, "west")
+	assert.Equal(t, context, "")
+
+	// flags that are given are not overridden
+	assert.Assert(t, cmd.Flags().Set("namespace", "east"))
+	applyCliConfig(cmd, nil)
+	assert.Equal(t, ns, "east")
+}
+
 var clusterRun = flag.Bool("use-cluster", false, "run tests against a configured cluster")
 
 func TestMain(m *testing.M) {
 	flag.Parse()
 	os.Exit(m.Run())
 }
+
+func TestClusterCommandsOnPodman(t *testing.T) {
+	defer func() {
+		platform = ""
+		namespace = ""
+		rootCmd.SetArgs(nil)
+	}()
+	testcases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{[]string{"token", "list"}, "Unable to retrieve tokens: Listing tokens is not supported on podman"},
+		{[]string{"token", "prune", "--older-than", "1h"}, "Unable to prune tokens: Pruning tokens is not supported on podman"},
+		{[]string{"network", "status"}, "Network status is not supported on podman"},
+		{[]string{"update"}, "Updating the site is not supported on podman"},
+		{[]string{"debug", "dump", "dump.tar.gz"}, "Dumping the site is not supported on podman"},
+		{[]string{"gateway", "init"}, "Attaching gateways is not supported on podman"},
+		{[]string{"gateway", "status"}, "Attaching gateways is not supported on podman"},
+	}
+	for _, c := range testcases {
+		rootCmd.SetArgs(append([]string{"--platform", "podman", "--namespace", "east"}, c.args...))
+		assert.ErrorContains(t, rootCmd.Execute(), c.expectedError, "skupper %s", strings.Join(c.args, " "))
+	}
+}
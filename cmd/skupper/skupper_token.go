@@ -3,22 +3,31 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"github.com/spf13/cobra"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 )
 
 func NewCmdToken() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "token create <output-token-file> [--name <name>] or link delete ...",
+		Use:   "token create <output-token-file> [--name <name>] or token list or token prune ...",
 		Short: "Manage skupper tokens",
 	}
 	return cmd
 }
 
+var offlineBundle bool
+var offlineInterRouterAddress string
+var offlineEdgeAddress string
+
 func NewCmdTokenCreate(newClient cobraFunc, flag string) *cobra.Command {
 	subflag := ""
 	if flag == "client-identity" {
@@ -35,7 +44,21 @@ func NewCmdTokenCreate(newClient cobraFunc, flag string) *cobra.Command {
 		PreRun: newClient,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			silenceCobra(cmd)
-			err := cli.ConnectorTokenCreateFile(context.Background(), clientIdentity, args[0])
+			if offlineBundle {
+				err := cli.TokenExportOffline(context.Background(), clientIdentity, args[0], types.OfflineBundleOptions{
+					TokenCreateOptions: tokenCreateOpts,
+					InterRouterAddress: offlineInterRouterAddress,
+					EdgeAddress:        offlineEdgeAddress,
+				})
+				if err != nil {
+					return fmt.Errorf("Failed to create offline bundle: %w", err)
+				}
+				fmt.Println(messages.Sprintf(messages.TokenBundleWritten, args[0]))
+				return nil
+			} else if offlineInterRouterAddress != "" || offlineEdgeAddress != "" {
+				return fmt.Errorf("--inter-router-address and --edge-address are only valid with --offline")
+			}
+			err := cli.ConnectorTokenCreateFileWithOptions(context.Background(), clientIdentity, args[0], tokenCreateOpts)
 			if err != nil {
 				return fmt.Errorf("Failed to create connection token: %w", err)
 			}
@@ -43,6 +66,113 @@ func NewCmdTokenCreate(newClient cobraFunc, flag string) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&clientIdentity, flag, subflag, types.DefaultVanName, "Provide a specific identity as which connecting skupper installation will be authenticated")
+	cmd.Flags().DurationVarP(&tokenCreateOpts.Expiry, "expiry", "", 0, "How long the token can be used to create links for (e.g. 24h); by default it does not expire. Only a claim token's expiry is enforced by this site")
+	cmd.Flags().IntVarP(&tokenCreateOpts.Uses, "uses", "", 0, "The number of links that can be created using the token; by default there is no limit. Only a claim token's uses are enforced by this site")
+	cmd.Flags().StringVarP(&tokenCreateOpts.Site, "site", "", "", "The id of the only site that can use the token to create a link; by default any site can use it")
+	cmd.Flags().Int32VarP(&tokenCreateOpts.Cost, "cost", "", 0, "The cost of links created using the token, unless they specify their own; by default it is 1")
+	cmd.Flags().StringVarP(&tokenCreateOpts.Type, "token-type", "", types.TokenTypeCert, "The type of token to create: cert, for a token holding the certificate itself, or claim, for a claim that the site redeems for a certificate when the link is created (a site that does not serve claims creates a cert token instead)")
+	cmd.Flags().BoolVarP(&offlineBundle, "offline", "", false, "Write a self-contained bundle for a site that cannot reach this one until the link is made; use it with 'link create --offline'")
+	cmd.Flags().StringVarP(&offlineInterRouterAddress, "inter-router-address", "", "", "The host:port through which the remote site reaches the inter-router endpoint of this site, if not the one this site advertises (requires --offline)")
+	cmd.Flags().StringVarP(&offlineEdgeAddress, "edge-address", "", "", "The host:port through which a remote edge site reaches this site (requires --offline and --inter-router-address)")
+
+	return cmd
+}
 
+// formatTokenTime writes a time as a date, or "-" if it is not known
+func formatTokenTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeTokenTable writes a line for each link or token request of the site
+func writeTokenTable(out io.Writer, tokens []*types.TokenInfo) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tCREATED\tLAST CONNECTED\tEXPIRES\tSTATUS")
+	for _, token := range tokens {
+		kind := "link"
+		if token.Type == types.TypeTokenRequest {
+			kind = "request"
+		}
+		status := "not connected"
+		if token.Connected {
+			status = "connected"
+		} else if token.Type == types.TypeToken && !token.Configured {
+			status = "not configured"
+		}
+		if token.Protected {
+			status += " (protected)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", token.Name, kind, formatTokenTime(token.Created), formatTokenTime(token.LastConnected), formatTokenTime(token.Expiry), status)
+	}
+	tw.Flush()
+}
+
+func NewCmdTokenList(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "list",
+		Short:  "List the links made with tokens and the token requests of the site, with when they were created and last connected",
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			tokens, err := cli.TokenList(context.Background())
+			if err != nil {
+				return fmt.Errorf("Unable to retrieve tokens: %w", err)
+			}
+			if len(tokens) == 0 {
+				fmt.Println("There are no tokens in use by the site")
+				return nil
+			}
+			writeTokenTable(os.Stdout, tokens)
+			return nil
+		},
+	}
+	return cmd
+}
+
+var tokenPruneOlderThan time.Duration
+var tokenPruneDryRun bool
+
+func NewCmdTokenPrune(newClient cobraFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune --older-than <duration>",
+		Short: "Remove the links and token requests that have not been connected for the given time",
+		Long: `Remove the links and token requests that have not been connected for the
+given time, or since they were created if they never were. Connected links
+and those annotated with skupper.io/protected=true are kept.`,
+		Args:   cobra.NoArgs,
+		PreRun: newClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if tokenPruneOlderThan <= 0 {
+				return fmt.Errorf("--older-than must be given a positive duration (e.g. 720h)")
+			}
+			if tokenPruneDryRun {
+				tokens, err := cli.TokenList(context.Background())
+				if err != nil {
+					return fmt.Errorf("Unable to retrieve tokens: %w", err)
+				}
+				now := time.Now()
+				for _, token := range tokens {
+					if token.IsStale(tokenPruneOlderThan, now) {
+						fmt.Printf("Would remove %s (last used %s)\n", token.Name, formatTokenTime(token.LastUsed()))
+					}
+				}
+				return nil
+			}
+			pruned, err := cli.TokenPrune(context.Background(), tokenPruneOlderThan)
+			for _, name := range pruned {
+				fmt.Printf("Removed %s\n", name)
+			}
+			if err != nil {
+				return fmt.Errorf("Unable to prune tokens: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVarP(&tokenPruneOlderThan, "older-than", "", 0, "How long a link or token request must have been unused for to be removed (e.g. 720h)")
+	cmd.Flags().BoolVarP(&tokenPruneDryRun, "dry-run", "", false, "List what would be removed without removing it")
 	return cmd
 }
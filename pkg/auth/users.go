@@ -0,0 +1,121 @@
+// Package auth handles the console users held in the console users
+// secret when console authentication is internal. Each key in the secret
+// is a user name; its value is either a hashed entry written by
+// NewUserEntry or, for sites created before users could be managed, the
+// password itself.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	RoleAdmin  string = "admin"
+	RoleViewer string = "viewer"
+
+	hashScheme     string = "pbkdf2-sha256"
+	hashIterations int    = 10000
+	saltLength     int    = 16
+	keyLength      int    = 32
+)
+
+func CheckRole(role string) error {
+	if role != RoleAdmin && role != RoleViewer {
+		return fmt.Errorf("Invalid console user role %q, must be %s or %s", role, RoleAdmin, RoleViewer)
+	}
+	return nil
+}
+
+// NewUserEntry returns the value to store for a user with the given
+// password and role
+func NewUserEntry(password string, role string) (string, error) {
+	if err := CheckRole(role); err != nil {
+		return "", err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2(password, salt, hashIterations)
+	return strings.Join([]string{
+		role,
+		hashScheme,
+		strconv.Itoa(hashIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, ":"), nil
+}
+
+// Role returns the role of the user stored with the given value. Users
+// holding a plain password predate roles and are admins.
+func Role(entry string) string {
+	parts := strings.Split(entry, ":")
+	if isHashed(parts) {
+		return parts[0]
+	}
+	return RoleAdmin
+}
+
+// Verify checks the password against the stored value, returning the
+// role of the user if it matches
+func Verify(entry string, password string) (string, bool) {
+	parts := strings.Split(entry, ":")
+	if !isHashed(parts) {
+		return RoleAdmin, hmac.Equal([]byte(entry), []byte(password))
+	}
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations <= 0 {
+		return "", false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return "", false
+	}
+	if !hmac.Equal(pbkdf2(password, salt, iterations), expected) {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// IsHashed returns true if the value was written by NewUserEntry rather
+// than being a plain password
+func IsHashed(entry string) bool {
+	return isHashed(strings.Split(entry, ":"))
+}
+
+func isHashed(parts []string) bool {
+	return len(parts) == 5 && parts[1] == hashScheme && CheckRole(parts[0]) == nil
+}
+
+// pbkdf2 derives a single block key as described in RFC 8018, which is
+// all that is needed for a key the size of the hash
+func pbkdf2(password string, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, []byte(password))
+	block := make([]byte, 4)
+	binary.BigEndian.PutUint32(block, 1)
+	prf.Write(salt)
+	prf.Write(block)
+	u := prf.Sum(nil)
+	key := make([]byte, len(u))
+	copy(key, u)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key[:keyLength]
+}
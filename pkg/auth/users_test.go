@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPbkdf2(t *testing.T) {
+	// test vectors for PBKDF2-HMAC-SHA256
+	assert.Equal(t, hex.EncodeToString(pbkdf2("password", []byte("salt"), 1)), "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b")
+	assert.Equal(t, hex.EncodeToString(pbkdf2("password", []byte("salt"), 2)), "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43")
+}
+
+func TestUserEntry(t *testing.T) {
+	entry, err := NewUserEntry("secret", RoleViewer)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(entry, "secret"))
+	assert.Equal(t, Role(entry), RoleViewer)
+	assert.Assert(t, IsHashed(entry))
+
+	role, ok := Verify(entry, "secret")
+	assert.Assert(t, ok)
+	assert.Equal(t, role, RoleViewer)
+	_, ok = Verify(entry, "wrong")
+	assert.Assert(t, !ok)
+
+	other, err := NewUserEntry("secret", RoleViewer)
+	assert.NilError(t, err)
+	assert.Assert(t, other != entry, "expected a different salt for each entry")
+
+	_, err = NewUserEntry("secret", "owner")
+	assert.Error(t, err, `Invalid console user role "owner", must be admin or viewer`)
+}
+
+func TestPlainUserEntry(t *testing.T) {
+	assert.Equal(t, Role("plain-password"), RoleAdmin)
+	assert.Assert(t, !IsHashed("plain-password"))
+	role, ok := Verify("plain-password", "plain-password")
+	assert.Assert(t, ok)
+	assert.Equal(t, role, RoleAdmin)
+	_, ok = Verify("plain-password", "other")
+	assert.Assert(t, !ok)
+}
@@ -85,10 +85,24 @@ func getCAFromSecret(secret *corev1.Secret) CertificateAuthority {
 	}
 }
 
-func generateSecret(name string, subject string, hosts string, ca *CertificateAuthority) corev1.Secret {
+func generateSecret(name string, subject string, unit string, hosts string, ca *CertificateAuthority) corev1.Secret {
+	kind := IssueCertificate
+	if ca == nil {
+		kind = IssueCA
+	}
+	start := time.Now()
+	secret, err := issueSecret(name, subject, unit, hosts, ca)
+	recordIssuance(kind, start, err)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return secret
+}
+
+func issueSecret(name string, subject string, unit string, hosts string, ca *CertificateAuthority) (corev1.Secret, error) {
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
-		log.Fatalf("failed to generate private key: %s", err)
+		return corev1.Secret{}, fmt.Errorf("failed to generate private key: %s", err)
 	}
 
 	notBefore := time.Now()
@@ -97,7 +111,7 @@ func generateSecret(name string, subject string, hosts string, ca *CertificateAu
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
-		log.Fatalf("failed to generate serial number: %s", err)
+		return corev1.Secret{}, fmt.Errorf("failed to generate serial number: %s", err)
 	}
 
 	template := x509.Certificate{
@@ -111,6 +125,9 @@ func generateSecret(name string, subject string, hosts string, ca *CertificateAu
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		BasicConstraintsValid: true,
 	}
+	if unit != "" {
+		template.Subject.OrganizationalUnit = []string{unit}
+	}
 
 	hosts_list := strings.Split(hosts, ",")
 	for _, h := range hosts_list {
@@ -136,7 +153,7 @@ func generateSecret(name string, subject string, hosts string, ca *CertificateAu
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template, parent, publicKey(priv), cakey)
 	if err != nil {
-		log.Fatalf("Failed to create certificate: %s", err)
+		return corev1.Secret{}, fmt.Errorf("Failed to create certificate: %s", err)
 	}
 
 	secret := corev1.Secret{
@@ -160,7 +177,38 @@ func generateSecret(name string, subject string, hosts string, ca *CertificateAu
 		secret.Data["ca.crt"] = ca.CrtData
 	}
 
-	return secret
+	return secret, nil
+}
+
+// GetCertificateExpiry returns the expiry of the first certificate in the
+// PEM encoded data
+func GetCertificateExpiry(data []byte) (time.Time, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, fmt.Errorf("No certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// GetCertificateSiteId returns the id of the site the first certificate in
+// the PEM encoded data is bound to, which is empty if it is not bound
+func GetCertificateSiteId(data []byte) (string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("No certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	if len(cert.Subject.OrganizationalUnit) == 0 {
+		return "", nil
+	}
+	return cert.Subject.OrganizationalUnit[0], nil
 }
 
 func SecretToCertData(secret corev1.Secret) CertificateData {
@@ -192,11 +240,19 @@ func CertDataToSecret(name string, certData CertificateData, annotations map[str
 
 func GenerateSecret(name string, subject string, hosts string, ca *corev1.Secret) corev1.Secret {
 	caCert := getCAFromSecret(ca)
-	return generateSecret(name, subject, hosts, &caCert)
+	return generateSecret(name, subject, "", hosts, &caCert)
+}
+
+// GenerateSiteBoundSecret generates a certificate as GenerateSecret does,
+// identifying the site it is issued to in the organizational unit of its
+// subject, so that the site presenting it can be checked
+func GenerateSiteBoundSecret(name string, subject string, siteId string, hosts string, ca *corev1.Secret) corev1.Secret {
+	caCert := getCAFromSecret(ca)
+	return generateSecret(name, subject, siteId, hosts, &caCert)
 }
 
 func GenerateCASecret(name string, subject string) corev1.Secret {
-	return generateSecret(name, subject, "", nil)
+	return generateSecret(name, subject, "", "", nil)
 }
 
 func GenerateCertificateData(name string, subject string, hosts string, caData CertificateData) CertificateData {
@@ -0,0 +1,135 @@
+package certs
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The kinds of certificate issuance recorded
+const (
+	IssueCA          string = "ca"
+	IssueCertificate string = "certificate"
+	IssueCARenewal   string = "ca-renewal"
+	IssueRenewal     string = "renewal"
+	IssueSigned      string = "signed-renewal"
+)
+
+// The changes made to secrets holding credentials that are recorded
+const (
+	SecretCreated string = "created"
+	SecretUpdated string = "updated"
+	SecretDeleted string = "deleted"
+)
+
+// IssuanceStats summarises the certificates of one kind issued by this
+// process since it started. The counts are only those of the process,
+// so each process that issues certificates exports its own with
+// WriteMetrics.
+type IssuanceStats struct {
+	Issued  int
+	Failed  int
+	Seconds float64
+}
+
+var stats = struct {
+	lock     sync.Mutex
+	issuance map[string]*IssuanceStats
+	secrets  map[string]int
+}{
+	issuance: map[string]*IssuanceStats{},
+	secrets:  map[string]int{},
+}
+
+func recordIssuance(kind string, start time.Time, err error) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	s, ok := stats.issuance[kind]
+	if !ok {
+		s = &IssuanceStats{}
+		stats.issuance[kind] = s
+	}
+	if err != nil {
+		s.Failed++
+		return
+	}
+	s.Issued++
+	s.Seconds += time.Since(start).Seconds()
+}
+
+// RecordSecretChange counts a change made to a secret holding
+// credentials
+func RecordSecretChange(operation string) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	stats.secrets[operation]++
+}
+
+// Issuance returns the certificates issued so far, by kind
+func Issuance() map[string]IssuanceStats {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	result := map[string]IssuanceStats{}
+	for kind, s := range stats.issuance {
+		result[kind] = *s
+	}
+	return result
+}
+
+// SecretChanges returns the changes made to secrets holding credentials
+// so far, by operation
+func SecretChanges() map[string]int {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	result := map[string]int{}
+	for operation, count := range stats.secrets {
+		result[operation] = count
+	}
+	return result
+}
+
+// WriteMetrics writes the certificates issued and the changes made to
+// secrets holding credentials by this process in the Prometheus text
+// format
+func WriteMetrics(out io.Writer) {
+	issuance := Issuance()
+	if len(issuance) > 0 {
+		kinds := []string{}
+		for kind := range issuance {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		describe(out, "skupper_certificates_issued_total", "counter", "Certificates issued by this process.")
+		for _, kind := range kinds {
+			fmt.Fprintf(out, "skupper_certificates_issued_total{kind=\"%s\"} %d\n", kind, issuance[kind].Issued)
+		}
+		describe(out, "skupper_certificate_issuance_failures_total", "counter", "Certificates this process failed to issue.")
+		for _, kind := range kinds {
+			fmt.Fprintf(out, "skupper_certificate_issuance_failures_total{kind=\"%s\"} %d\n", kind, issuance[kind].Failed)
+		}
+		describe(out, "skupper_certificate_issuance_seconds", "summary", "Time taken by this process to issue certificates.")
+		for _, kind := range kinds {
+			fmt.Fprintf(out, "skupper_certificate_issuance_seconds_sum{kind=\"%s\"} %s\n", kind, strconv.FormatFloat(issuance[kind].Seconds, 'f', -1, 64))
+			fmt.Fprintf(out, "skupper_certificate_issuance_seconds_count{kind=\"%s\"} %d\n", kind, issuance[kind].Issued)
+		}
+	}
+	changes := SecretChanges()
+	if len(changes) > 0 {
+		operations := []string{}
+		for operation := range changes {
+			operations = append(operations, operation)
+		}
+		sort.Strings(operations)
+		describe(out, "skupper_credential_secret_changes_total", "counter", "Secrets holding credentials created, updated or deleted by this process.")
+		for _, operation := range operations {
+			fmt.Fprintf(out, "skupper_credential_secret_changes_total{operation=\"%s\"} %d\n", operation, changes[operation])
+		}
+	}
+}
+
+func describe(out io.Writer, name string, kind string, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
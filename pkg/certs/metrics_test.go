@@ -0,0 +1,37 @@
+package certs
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	stats.lock.Lock()
+	stats.issuance = map[string]*IssuanceStats{}
+	stats.secrets = map[string]int{}
+	stats.lock.Unlock()
+
+	GenerateCASecret("test-ca", "test-ca")
+	recordIssuance(IssueRenewal, time.Now(), nil)
+	recordIssuance(IssueRenewal, time.Now(), fmt.Errorf("failed"))
+	RecordSecretChange(SecretUpdated)
+
+	out := &bytes.Buffer{}
+	WriteMetrics(out)
+	for _, sample := range []string{
+		`skupper_certificates_issued_total{kind="ca"} 1`,
+		`skupper_certificates_issued_total{kind="renewal"} 1`,
+		`skupper_certificate_issuance_failures_total{kind="ca"} 0`,
+		`skupper_certificate_issuance_failures_total{kind="renewal"} 1`,
+		`skupper_certificate_issuance_seconds_count{kind="ca"} 1`,
+		`skupper_credential_secret_changes_total{operation="updated"} 1`,
+	} {
+		assert.Assert(t, strings.Contains(out.String(), sample+"\n"), "missing %s", sample)
+	}
+	assert.Assert(t, strings.Contains(out.String(), "# TYPE skupper_certificate_issuance_seconds summary\n"))
+}
@@ -0,0 +1,251 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// certificates are renewed once less than this fraction of their
+// lifetime remains
+const renewalFraction = 3
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("No certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("No private key found")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// NeedsRenewal returns true if less than a third of the lifetime of the
+// first certificate in the PEM encoded data remains
+func NeedsRenewal(data []byte, now time.Time) (bool, error) {
+	cert, err := parseCertificate(data)
+	if err != nil {
+		return false, err
+	}
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotAfter.Sub(now) < lifetime/renewalFraction, nil
+}
+
+// renewedTemplate returns a template for a certificate with the same
+// subject, hosts and usage as the existing one, valid for as long as
+// newly generated certificates are
+func renewedTemplate(existing *x509.Certificate) (*x509.Certificate, error) {
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	notBefore := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               existing.Subject,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(5 * 365 * 24 * time.Hour),
+		KeyUsage:              existing.KeyUsage,
+		ExtKeyUsage:           existing.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  existing.IsCA,
+		DNSNames:              existing.DNSNames,
+		IPAddresses:           existing.IPAddresses,
+	}, nil
+}
+
+// RenewCASecret returns the CA with a new certificate for the same
+// subject and key, so that certificates it has already issued can still
+// be verified with the new certificate
+func RenewCASecret(ca *corev1.Secret) (*corev1.Secret, error) {
+	start := time.Now()
+	result, err := renewCASecret(ca)
+	recordIssuance(IssueCARenewal, start, err)
+	return result, err
+}
+
+func renewCASecret(ca *corev1.Secret) (*corev1.Secret, error) {
+	existing, err := parseCertificate(ca.Data["tls.crt"])
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseKey(ca.Data["tls.key"])
+	if err != nil {
+		return nil, err
+	}
+	template, err := renewedTemplate(existing)
+	if err != nil {
+		return nil, err
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	renewed := ca.DeepCopy()
+	renewed.Data["tls.crt"] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	return renewed, nil
+}
+
+// RenewSecret returns the secret with a new key and a certificate for
+// the same subject and hosts issued by the CA. Any other data in the
+// secret is kept.
+func RenewSecret(secret *corev1.Secret, ca *corev1.Secret) (*corev1.Secret, error) {
+	start := time.Now()
+	result, err := renewSecret(secret, ca)
+	recordIssuance(IssueRenewal, start, err)
+	return result, err
+}
+
+func renewSecret(secret *corev1.Secret, ca *corev1.Secret) (*corev1.Secret, error) {
+	existing, err := parseCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		return nil, err
+	}
+	authority, err := parseCertificate(ca.Data["tls.crt"])
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := parseKey(ca.Data["tls.key"])
+	if err != nil {
+		return nil, err
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	template, err := renewedTemplate(existing)
+	if err != nil {
+		return nil, err
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, authority, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	renewed := secret.DeepCopy()
+	renewed.Data["tls.crt"] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	renewed.Data["tls.key"] = pem.EncodeToMemory(pemBlockForKey(key))
+	renewed.Data["ca.crt"] = ca.Data["tls.crt"]
+	return renewed, nil
+}
+
+// RenewalRequest asks the site that issued the certificate of a link for
+// a new one. The request is for a new key, and is signed with the key of
+// the current certificate to show that it comes from the holder of the
+// link.
+type RenewalRequest struct {
+	Csr         []byte `json:"csr"`
+	Certificate []byte `json:"certificate"`
+	Signature   []byte `json:"signature"`
+}
+
+// RenewalResponse carries the renewed certificate of a link along with
+// the current certificate of the CA that issued it
+type RenewalResponse struct {
+	Certificate []byte `json:"certificate"`
+	CA          []byte `json:"ca"`
+}
+
+// NewRenewalRequest returns a request to renew the certificate of the
+// link secret and the key for the renewed certificate
+func NewRenewalRequest(secret *corev1.Secret) (*RenewalRequest, []byte, error) {
+	current, err := parseKey(secret.Data["tls.key"])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := sha256.Sum256(csr)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, current, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	request := &RenewalRequest{
+		Csr:         csr,
+		Certificate: secret.Data["tls.crt"],
+		Signature:   signature,
+	}
+	return request, pem.EncodeToMemory(pemBlockForKey(key)), nil
+}
+
+// SignRenewalRequest issues a renewed certificate for a request whose
+// current certificate was issued by the CA and has not expired. The
+// renewed certificate has the subject and hosts of the current one; only
+// the key is taken from the request.
+func SignRenewalRequest(request *RenewalRequest, ca *corev1.Secret, now time.Time) (*RenewalResponse, error) {
+	start := time.Now()
+	result, err := signRenewalRequest(request, ca, now)
+	recordIssuance(IssueSigned, start, err)
+	return result, err
+}
+
+func signRenewalRequest(request *RenewalRequest, ca *corev1.Secret, now time.Time) (*RenewalResponse, error) {
+	authority, err := parseCertificate(ca.Data["tls.crt"])
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := parseKey(ca.Data["tls.key"])
+	if err != nil {
+		return nil, err
+	}
+	current, err := parseCertificate(request.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	if err := current.CheckSignatureFrom(authority); err != nil {
+		return nil, fmt.Errorf("Certificate was not issued by this site: %s", err)
+	}
+	if now.After(current.NotAfter) {
+		return nil, fmt.Errorf("Certificate has expired")
+	}
+	holder, ok := current.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported key type")
+	}
+	digest := sha256.Sum256(request.Csr)
+	if err := rsa.VerifyPKCS1v15(holder, crypto.SHA256, digest[:], request.Signature); err != nil {
+		return nil, fmt.Errorf("Request was not signed by the holder of the certificate")
+	}
+	csr, err := x509.ParseCertificateRequest(request.Csr)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	template, err := renewedTemplate(current)
+	if err != nil {
+		return nil, err
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, authority, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return &RenewalResponse{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes}),
+		CA:          ca.Data["tls.crt"],
+	}, nil
+}
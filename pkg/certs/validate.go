@@ -0,0 +1,107 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// keyPair returns the first certificate in the secret, having checked
+// that it is current and matches the key in the secret
+func keyPair(secret *corev1.Secret, now time.Time) (*x509.Certificate, error) {
+	for _, key := range []string{"tls.crt", "tls.key"} {
+		if len(secret.Data[key]) == 0 {
+			return nil, fmt.Errorf("Secret %s has no %s", secret.ObjectMeta.Name, key)
+		}
+	}
+	if _, err := tls.X509KeyPair(secret.Data["tls.crt"], secret.Data["tls.key"]); err != nil {
+		return nil, fmt.Errorf("Secret %s does not hold a valid certificate and key: %s", secret.ObjectMeta.Name, err)
+	}
+	cert, err := parseCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		return nil, fmt.Errorf("Secret %s does not hold a valid certificate: %s", secret.ObjectMeta.Name, err)
+	}
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("Certificate in secret %s is only valid from %s to %s", secret.ObjectMeta.Name, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	}
+	return cert, nil
+}
+
+// ValidateCA checks that the secret holds a current CA certificate that
+// may sign others, along with its RSA key in PKCS#1 form, which is what
+// certificates are signed with
+func ValidateCA(secret *corev1.Secret, now time.Time) error {
+	cert, err := keyPair(secret, now)
+	if err != nil {
+		return err
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("Certificate in secret %s is not a CA", secret.ObjectMeta.Name)
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return fmt.Errorf("Certificate in secret %s may not be used to sign certificates", secret.ObjectMeta.Name)
+	}
+	if _, err := parseKey(secret.Data["tls.key"]); err != nil {
+		return fmt.Errorf("Key in secret %s must be an RSA key in PKCS#1 form: %s", secret.ObjectMeta.Name, err)
+	}
+	return nil
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	if len(cert.ExtKeyUsage) == 0 {
+		return true
+	}
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage || u == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateServerCertificate checks that the secret holds a current
+// certificate, and its key, that a TLS server may use for each of the
+// hosts. If a CA is given, the certificate must have been issued by it.
+func ValidateServerCertificate(secret *corev1.Secret, hosts []string, ca *corev1.Secret, now time.Time) error {
+	cert, err := keyPair(secret, now)
+	if err != nil {
+		return err
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&(x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment) == 0 {
+		return fmt.Errorf("Certificate in secret %s may not be used for digital signatures or key encipherment", secret.ObjectMeta.Name)
+	}
+	if !hasExtKeyUsage(cert, x509.ExtKeyUsageServerAuth) {
+		return fmt.Errorf("Certificate in secret %s may not be used for server authentication", secret.ObjectMeta.Name)
+	}
+	for _, host := range hosts {
+		if host == "" {
+			continue
+		}
+		if err := cert.VerifyHostname(host); err != nil {
+			return fmt.Errorf("Certificate in secret %s is not valid for %s", secret.ObjectMeta.Name, host)
+		}
+	}
+	if ca == nil {
+		return nil
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca.Data["tls.crt"]) {
+		return fmt.Errorf("Secret %s does not hold a valid CA certificate", ca.ObjectMeta.Name)
+	}
+	// any intermediate CAs follow the certificate
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM(secret.Data["tls.crt"])
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("Certificate in secret %s was not issued by the CA in secret %s: %s", secret.ObjectMeta.Name, ca.ObjectMeta.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	EnvPrefix string = "SKUPPER_"
+	EnvConfig string = EnvPrefix + "CONFIG"
+)
+
+// Keys lists the settings that can be given defaults through the
+// config file or environment, each of which is a global flag of the CLI
+var Keys = []string{
+	"namespace",
+	"context",
+	"kubeconfig",
+	"platform",
+}
+
+// Config holds CLI defaults read from a config file. Values from the
+// environment (e.g. SKUPPER_NAMESPACE) take precedence over the file.
+type Config struct {
+	Path   string
+	values map[string]string
+}
+
+func DefaultPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "skupper", "config.yaml")
+}
+
+func IsValidKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// Load reads the config file at the given path. A missing file is
+// treated as an empty config.
+func Load(path string) (*Config, error) {
+	c := &Config{
+		Path:   path,
+		values: map[string]string{},
+	}
+	if path == "" {
+		return c, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return c, err
+	}
+	err = yaml.Unmarshal(data, &c.values)
+	if err != nil {
+		return c, fmt.Errorf("Could not parse %s: %w", path, err)
+	}
+	if c.values == nil {
+		c.values = map[string]string{}
+	}
+	return c, nil
+}
+
+// Get returns the value for a key from the environment if set there,
+// otherwise from the config file
+func (c *Config) Get(key string) string {
+	if value, ok := os.LookupEnv(envName(key)); ok {
+		return value
+	}
+	return c.values[key]
+}
+
+func (c *Config) Set(key string, value string) error {
+	if !IsValidKey(key) {
+		return fmt.Errorf("Invalid key %q, must be one of: [%s]", key, strings.Join(Keys, ", "))
+	}
+	if value == "" {
+		delete(c.values, key)
+	} else {
+		c.values[key] = value
+	}
+	return nil
+}
+
+// Values returns the keys that have a value, along with that value
+func (c *Config) Values() map[string]string {
+	result := map[string]string{}
+	for _, key := range Keys {
+		if value := c.Get(key); value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func (c *Config) Save() error {
+	if c.Path == "" {
+		return fmt.Errorf("Could not determine location of config file")
+	}
+	err := os.MkdirAll(filepath.Dir(c.Path), 0700)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(c.values)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, data, 0600)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skupper-config")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "skupper", "config.yaml")
+
+	c, err := Load(path)
+	assert.Assert(t, err)
+	assert.Equal(t, c.Get("namespace"), "")
+
+	assert.Assert(t, c.Set("namespace", "east"))
+	assert.Assert(t, c.Set("context", "prod"))
+	assert.Error(t, c.Set("colour", "blue"), `Invalid key "colour", must be one of: [namespace, context, kubeconfig, platform]`)
+	assert.Assert(t, c.Save())
+
+	c, err = Load(path)
+	assert.Assert(t, err)
+	assert.Equal(t, c.Get("namespace"), "east")
+	assert.DeepEqual(t, c.Values(), map[string]string{"namespace": "east", "context": "prod"})
+
+	os.Setenv("SKUPPER_NAMESPACE", "west")
+	defer os.Unsetenv("SKUPPER_NAMESPACE")
+	assert.Equal(t, c.Get("namespace"), "west")
+
+	assert.Assert(t, c.Set("context", ""))
+	assert.DeepEqual(t, c.Values(), map[string]string{"namespace": "west"})
+}
+
+func TestLoadInvalid(t *testing.T) {
+	file, err := ioutil.TempFile("", "config.yaml")
+	assert.Assert(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("namespace: [east")
+	file.Close()
+
+	_, err = Load(file.Name())
+	assert.ErrorContains(t, err, "Could not parse")
+}
@@ -144,6 +144,18 @@ func getHttpProtocol(protocolVersion string) string {
 	}
 }
 
+// MarkGrpcServices reports the services with the given addresses as
+// grpc. The router bridges them as http2 and cannot tell them apart, so
+// only the protocol changes; their stats are those of any http2
+// service.
+func MarkGrpcServices(services []HttpService, grpcAddresses map[string]bool) {
+	for i := range services {
+		if grpcAddresses[services[i].Address] {
+			services[i].Protocol = "grpc"
+		}
+	}
+}
+
 func asHttpRequestStats(r *qdr.HttpRequestInfo) HttpRequestStats {
 	stats := HttpRequestStats{
 		Requests:   r.Requests,
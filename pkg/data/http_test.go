@@ -349,3 +349,19 @@ func TestTargetDefinesService(t *testing.T) {
 		}
 	}
 }
+
+func TestMarkGrpcServices(t *testing.T) {
+	services := []HttpService{
+		{Service: Service{Address: "web", Protocol: "http"}},
+		{Service: Service{Address: "orders", Protocol: "http2"}},
+		{Service: Service{Address: "stock", Protocol: "http2"}},
+	}
+	MarkGrpcServices(services, map[string]bool{"orders": true})
+	protocols := []string{}
+	for _, service := range services {
+		protocols = append(protocols, service.Protocol)
+	}
+	if !reflect.DeepEqual(protocols, []string{"http", "grpc", "http2"}) {
+		t.Errorf("Unexpected protocols %v", protocols)
+	}
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// Sent to a peer to ask what it sees of the requesting site
+type PeerViewRequest struct {
+	SiteId  string   `json:"site_id"`
+	Routers []string `json:"routers"`
+}
+
+// The view a peer has of the requesting site
+type PeerView struct {
+	SiteId   string    `json:"site_id"`
+	SiteName string    `json:"site_name,omitempty"`
+	Linked   bool      `json:"linked"`
+	Services []string  `json:"services"`
+	Time     time.Time `json:"time"`
+}
+
+// Clocks that differ by more than this risk certificates and tokens
+// being treated as not yet valid, or as already expired, by the peer
+const ClockSkewThreshold time.Duration = 30 * time.Second
+
+// Returns true if there is an active inter-router or edge connection,
+// in either direction, to any of the given routers
+func IsLinkedTo(connections []qdr.Connection, routers []string) bool {
+	for _, c := range connections {
+		if !c.Active || (c.Role != "inter-router" && c.Role != "edge") {
+			continue
+		}
+		for _, r := range routers {
+			if c.Container == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compares the local view of a peer with the view the peer reported of
+// this site. A nil view means the peer could not be reached.
+func ComparePeerView(siteId string, linked bool, localServices []string, view *PeerView, checkServices bool) types.PeerStatus {
+	status := types.PeerStatus{
+		SiteId:        siteId,
+		LinkedLocally: linked,
+	}
+	if view == nil {
+		status.Observations = append(status.Observations, "No view received from peer")
+		return status
+	}
+	status.Reachable = true
+	status.SiteName = view.SiteName
+	status.LinkedRemotely = view.Linked
+	if linked && !view.Linked {
+		status.Observations = append(status.Observations, "Link is up locally but not seen by peer")
+	} else if !linked && view.Linked {
+		status.Observations = append(status.Observations, "Link is seen by peer but not up locally")
+	}
+	if checkServices {
+		imported := map[string]bool{}
+		for _, s := range view.Services {
+			imported[s] = true
+		}
+		for _, s := range localServices {
+			if !imported[s] {
+				status.Observations = append(status.Observations, fmt.Sprintf("Service %s not known to peer", s))
+			}
+		}
+	}
+	return status
+}
+
+// EstimateClockSkew returns how far the clock of a peer is ahead of the
+// local clock, assuming the peer read its clock halfway between the
+// request being sent and the response being received
+func EstimateClockSkew(sent time.Time, received time.Time, peerTime time.Time) time.Duration {
+	return peerTime.Sub(sent.Add(received.Sub(sent) / 2))
+}
+
+// CheckClockSkew records the skew in the status, with an observation if
+// it exceeds the threshold
+func CheckClockSkew(status *types.PeerStatus, skew time.Duration) {
+	status.ClockSkew = &skew
+	if exceedsClockSkewThreshold(skew) {
+		status.Observations = append(status.Observations, fmt.Sprintf("Clock differs from this site by %s", skew.Round(time.Second)))
+	}
+}
+
+// ClockSkewWarning describes the skew recorded for a peer if it exceeds
+// the threshold, and is empty otherwise
+func ClockSkewWarning(peer types.PeerStatus) string {
+	if peer.ClockSkew == nil || !exceedsClockSkewThreshold(*peer.ClockSkew) {
+		return ""
+	}
+	name := peer.SiteId
+	if peer.SiteName != "" {
+		name = peer.SiteName
+	}
+	return fmt.Sprintf("Clock of site %s differs from this site by %s", name, peer.ClockSkew.Round(time.Second))
+}
+
+func exceedsClockSkewThreshold(skew time.Duration) bool {
+	return skew > ClockSkewThreshold || skew < -ClockSkewThreshold
+}
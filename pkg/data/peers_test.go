@@ -0,0 +1,117 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestIsLinkedTo(t *testing.T) {
+	connections := []qdr.Connection{
+		{Container: "site-a-router", Role: "normal", Active: true},
+		{Container: "site-b-router", Role: "inter-router", Active: false},
+		{Container: "site-c-router", Role: "edge", Active: true, Dir: "in"},
+	}
+	tests := []struct {
+		routers []string
+		linked  bool
+	}{
+		{[]string{"site-a-router"}, false},
+		{[]string{"site-b-router"}, false},
+		{[]string{"site-c-router"}, true},
+		{[]string{"site-d-router", "site-c-router"}, true},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if actual := IsLinkedTo(connections, test.routers); actual != test.linked {
+			t.Errorf("Expected linked=%t for %v, got %t", test.linked, test.routers, actual)
+		}
+	}
+}
+
+func TestComparePeerView(t *testing.T) {
+	tests := []struct {
+		name          string
+		linked        bool
+		view          *PeerView
+		checkServices bool
+		reachable     bool
+		observations  []string
+	}{
+		{
+			name:         "unreachable",
+			linked:       true,
+			observations: []string{"No view received from peer"},
+		},
+		{
+			name:          "consistent",
+			linked:        true,
+			view:          &PeerView{Linked: true, Services: []string{"a", "b"}},
+			checkServices: true,
+			reachable:     true,
+		},
+		{
+			name:         "link down remotely",
+			linked:       true,
+			view:         &PeerView{Linked: false},
+			reachable:    true,
+			observations: []string{"Link is up locally but not seen by peer"},
+		},
+		{
+			name:          "link down locally, service missing",
+			linked:        false,
+			view:          &PeerView{Linked: true, Services: []string{"a"}},
+			checkServices: true,
+			reachable:     true,
+			observations:  []string{"Link is seen by peer but not up locally", "Service b not known to peer"},
+		},
+	}
+	for _, test := range tests {
+		status := ComparePeerView("peer", test.linked, []string{"a", "b"}, test.view, test.checkServices)
+		if status.Reachable != test.reachable {
+			t.Errorf("%s: expected reachable=%t, got %t", test.name, test.reachable, status.Reachable)
+		}
+		if !reflect.DeepEqual(status.Observations, test.observations) {
+			t.Errorf("%s: expected observations %v, got %v", test.name, test.observations, status.Observations)
+		}
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	sent := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	received := sent.Add(2 * time.Second)
+	if skew := EstimateClockSkew(sent, received, sent.Add(time.Second)); skew != 0 {
+		t.Errorf("Expected no skew, got %s", skew)
+	}
+	if skew := EstimateClockSkew(sent, received, sent.Add(-time.Minute)); skew != -61*time.Second {
+		t.Errorf("Expected skew of -61s, got %s", skew)
+	}
+
+	status := types.PeerStatus{}
+	CheckClockSkew(&status, 5*time.Second)
+	if status.ClockSkew == nil || *status.ClockSkew != 5*time.Second || len(status.Observations) != 0 {
+		t.Errorf("Expected skew within threshold to be recorded without observation, got %v", status)
+	}
+	CheckClockSkew(&status, -2*time.Minute)
+	expected := []string{"Clock differs from this site by -2m0s"}
+	if !reflect.DeepEqual(status.Observations, expected) {
+		t.Errorf("Expected %v, got %v", expected, status.Observations)
+	}
+
+	status.SiteId = "abc"
+	status.SiteName = "west"
+	if warning := ClockSkewWarning(status); warning != "Clock of site west differs from this site by -2m0s" {
+		t.Errorf("Unexpected warning %q", warning)
+	}
+	CheckClockSkew(&status, time.Second)
+	if warning := ClockSkewWarning(status); warning != "" {
+		t.Errorf("Expected no warning for skew within threshold, got %q", warning)
+	}
+	if warning := ClockSkewWarning(types.PeerStatus{SiteId: "abc"}); warning != "" {
+		t.Errorf("Expected no warning for peer without time, got %q", warning)
+	}
+}
@@ -8,9 +8,10 @@ import (
 )
 
 type Service struct {
-	Address  string          `json:"address"`
-	Protocol string          `json:"protocol"`
-	Targets  []ServiceTarget `json:"targets"`
+	Address  string            `json:"address"`
+	Protocol string            `json:"protocol"`
+	Targets  []ServiceTarget   `json:"targets"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type ServiceTarget struct {
@@ -28,6 +29,27 @@ func (s *Service) AddTarget(name string, host string, siteId string, mapping Nam
 	s.Targets = append(s.Targets, target)
 }
 
+// AddServiceMetadata sets the metadata of each of the services from the
+// definition with the same address
+func AddServiceMetadata(services []interface{}, definitions []*types.ServiceInterface) {
+	metadata := map[string]map[string]string{}
+	for _, def := range definitions {
+		if len(def.Metadata) > 0 {
+			metadata[def.Address] = def.Metadata
+		}
+	}
+	for i, s := range services {
+		switch service := s.(type) {
+		case HttpService:
+			service.Metadata = metadata[service.Address]
+			services[i] = service
+		case TcpService:
+			service.Metadata = metadata[service.Address]
+			services[i] = service
+		}
+	}
+}
+
 type IngressBinding struct {
 	ListenerPort      int               `json:"listener_port"`
 	ServicePort       int               `json:"service_port"`
@@ -85,8 +107,8 @@ func CheckService(details *ServiceCheck) {
 			if a.Protocol != b.Protocol {
 				details.AddObservation(fmt.Sprintf("Mismatched protocol between sites %s and %s (%s != %s)", aSiteId, bSiteId, a.Protocol, b.Protocol))
 			}
-			if a.Port != b.Port {
-				details.AddObservation(fmt.Sprintf("Different port used in sites %s (%d) and %s (%d)", aSiteId, a.Port, bSiteId, b.Port))
+			if !a.SamePorts(b) {
+				details.AddObservation(fmt.Sprintf("Different ports used in sites %s (%v) and %s (%v)", aSiteId, a.PortNumbers(), bSiteId, b.PortNumbers()))
 			}
 		}
 	}
@@ -0,0 +1,32 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestAddServiceMetadata(t *testing.T) {
+	services := []interface{}{
+		HttpService{Service: Service{Address: "frontend", Protocol: "http"}},
+		TcpService{Service: Service{Address: "database", Protocol: "tcp"}},
+		TcpService{Service: Service{Address: "cache", Protocol: "tcp"}},
+	}
+	metadata := map[string]string{"version": "1.2", "owner": "payments"}
+	definitions := []*types.ServiceInterface{
+		{Address: "frontend", Metadata: metadata},
+		{Address: "database", Metadata: map[string]string{"docs": "https://docs.example.com/db"}},
+		{Address: "cache"},
+	}
+	AddServiceMetadata(services, definitions)
+	if actual := services[0].(HttpService).Metadata; !reflect.DeepEqual(actual, metadata) {
+		t.Errorf("Expected %v, got %v", metadata, actual)
+	}
+	if actual := services[1].(TcpService).Metadata["docs"]; actual != "https://docs.example.com/db" {
+		t.Errorf("Expected docs url, got %q", actual)
+	}
+	if actual := services[2].(TcpService).Metadata; actual != nil {
+		t.Errorf("Expected no metadata, got %v", actual)
+	}
+}
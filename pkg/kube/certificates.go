@@ -0,0 +1,370 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+)
+
+// CertificateProvider issues the CAs and credentials of a site
+type CertificateProvider interface {
+	NewCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, namespace string) error
+	NewSecret(cred types.Credential, owner *metav1.OwnerReference, namespace string) error
+}
+
+// SelfSignedCertificates generates self-signed CAs and signs credentials
+// with them
+type SelfSignedCertificates struct {
+	Client kubernetes.Interface
+}
+
+func (p *SelfSignedCertificates) NewCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, namespace string) error {
+	_, err := NewCertAuthority(ca, owner, namespace, p.Client)
+	return err
+}
+
+func (p *SelfSignedCertificates) NewSecret(cred types.Credential, owner *metav1.OwnerReference, namespace string) error {
+	_, err := NewSecret(cred, owner, namespace, p.Client)
+	return err
+}
+
+// ExistingCertificates uses the existing secrets in Secrets, keyed by
+// the name of the CA or credential they replace, rather than issuing
+// those. Each is checked to be usable, then copied under the name the
+// site expects it by. Others are issued by Provider.
+type ExistingCertificates struct {
+	Provider CertificateProvider
+	Client   kubernetes.Interface
+	Secrets  map[string]string
+}
+
+func (p *ExistingCertificates) NewCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, namespace string) error {
+	source, ok := p.Secrets[ca.Name]
+	if !ok {
+		return p.Provider.NewCertAuthority(ca, owner, namespace)
+	}
+	return p.copy(source, ca.Name, owner, namespace, func(secret *corev1.Secret) error {
+		return certs.ValidateCA(secret, time.Now())
+	})
+}
+
+func (p *ExistingCertificates) NewSecret(cred types.Credential, owner *metav1.OwnerReference, namespace string) error {
+	source, ok := p.Secrets[cred.Name]
+	if !ok {
+		return p.Provider.NewSecret(cred, owner, namespace)
+	}
+	var ca *corev1.Secret
+	if cred.CA != "" {
+		var err error
+		ca, err = p.Client.CoreV1().Secrets(namespace).Get(cred.CA, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("Failed to retrieve CA %s: %w", cred.CA, err)
+		}
+		// the CA is referred to by the name it was supplied under
+		if supplied, ok := ca.ObjectMeta.Annotations[types.CertificateSourceAnnotation]; ok {
+			ca.ObjectMeta.Name = supplied
+		}
+	}
+	return p.copy(source, cred.Name, owner, namespace, func(secret *corev1.Secret) error {
+		if err := certs.ValidateServerCertificate(secret, cred.Hosts, ca, time.Now()); err != nil {
+			return err
+		}
+		if len(secret.Data["ca.crt"]) == 0 && ca != nil {
+			secret.Data["ca.crt"] = ca.Data["tls.crt"]
+		}
+		return nil
+	})
+}
+
+// copy copies the source secret to the named one, unless that already
+// exists, once check has accepted it
+func (p *ExistingCertificates) copy(source string, name string, owner *metav1.OwnerReference, namespace string, check func(*corev1.Secret) error) error {
+	secrets := p.Client.CoreV1().Secrets(namespace)
+	if _, err := secrets.Get(name, metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("Failed to check secret %s: %w", name, err)
+	}
+	original, err := secrets.Get(source, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve secret %s: %w", source, err)
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: source,
+			Annotations: map[string]string{
+				types.CertificateSourceAnnotation: source,
+			},
+		},
+		Data: map[string][]byte{},
+		Type: corev1.SecretTypeTLS,
+	}
+	for key, value := range original.Data {
+		secret.Data[key] = value
+	}
+	if owner != nil {
+		secret.ObjectMeta.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	if err := check(secret); err != nil {
+		return err
+	}
+	secret.ObjectMeta.Name = name
+	if _, err := secrets.Create(secret); err != nil {
+		return fmt.Errorf("Failed to create secret %s: %w", name, err)
+	}
+	certs.RecordSecretChange(certs.SecretCreated)
+	return nil
+}
+
+var CertManagerGroupVersion = schema.GroupVersion{
+	Group:   "cert-manager.io",
+	Version: "v1",
+}
+
+var CertificateResource = CertManagerGroupVersion.WithResource("certificates")
+
+var IssuerResource = CertManagerGroupVersion.WithResource("issuers")
+
+// CertManagerSelfSignedIssuer is the issuer of the CAs delegated to
+// cert-manager when no other issuer is configured
+const CertManagerSelfSignedIssuer string = "skupper-selfsigned"
+
+// CertManagerCertificateAnnotation is set by cert-manager on the secrets
+// it issues, naming the Certificate that they are for
+const CertManagerCertificateAnnotation string = "cert-manager.io/certificate-name"
+
+// IsCertManagerInstalled returns true if the custom resources for
+// cert-manager Certificates and Issuers are defined in the cluster
+func IsCertManagerInstalled(dc discovery.DiscoveryInterface) bool {
+	resources, err := dc.ServerResourcesForGroupVersion(CertManagerGroupVersion.String())
+	if err != nil || resources == nil {
+		return false
+	}
+	found := map[string]bool{}
+	for _, resource := range resources.APIResources {
+		found[resource.Name] = true
+	}
+	return found[CertificateResource.Resource] && found[IssuerResource.Resource]
+}
+
+// IssuerReference identifies a cert-manager Issuer or ClusterIssuer
+type IssuerReference struct {
+	Kind string
+	Name string
+}
+
+// ParseIssuerReference parses an issuer given as <kind>/<name>, where
+// kind is Issuer or ClusterIssuer, or as just the name of an Issuer
+func ParseIssuerReference(value string) (*IssuerReference, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) == 1 {
+		return &IssuerReference{Kind: "Issuer", Name: parts[0]}, nil
+	}
+	if parts[0] != "Issuer" && parts[0] != "ClusterIssuer" {
+		return nil, fmt.Errorf("Invalid issuer kind %q, must be Issuer or ClusterIssuer", parts[0])
+	}
+	if parts[1] == "" {
+		return nil, fmt.Errorf("No issuer name given in %q", value)
+	}
+	return &IssuerReference{Kind: parts[0], Name: parts[1]}, nil
+}
+
+func (r *IssuerReference) asUnstructured() map[string]interface{} {
+	return map[string]interface{}{
+		"group": CertManagerGroupVersion.Group,
+		"kind":  r.Kind,
+		"name":  r.Name,
+	}
+}
+
+// CertManagerCertificates has cert-manager issue the CAs named in CAs,
+// and the credentials they sign, through Certificate resources. Each CA
+// is issued by Issuer, which may be an external CA, or if that is not
+// set is self-signed, and is made an Issuer itself for its credentials.
+// Other CAs and their credentials are generated as by
+// SelfSignedCertificates.
+//
+// The secrets are written by cert-manager after the Certificates are
+// created, so may not be available immediately.
+type CertManagerCertificates struct {
+	Client        kubernetes.Interface
+	DynamicClient dynamic.Interface
+	Issuer        *IssuerReference
+	CAs           []string
+}
+
+func (p *CertManagerCertificates) delegated(ca string) bool {
+	for _, name := range p.CAs {
+		if name == ca {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CertManagerCertificates) selfSigned() *SelfSignedCertificates {
+	return &SelfSignedCertificates{Client: p.Client}
+}
+
+func (p *CertManagerCertificates) NewCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, namespace string) error {
+	if !p.delegated(ca.Name) {
+		return p.selfSigned().NewCertAuthority(ca, owner, namespace)
+	}
+	issuer := p.Issuer
+	if issuer == nil {
+		issuer = &IssuerReference{Kind: "Issuer", Name: CertManagerSelfSignedIssuer}
+		if err := p.createIssuer(NewSelfSignedIssuer(CertManagerSelfSignedIssuer), owner, namespace); err != nil {
+			return err
+		}
+	}
+	if err := p.createCertificate(NewCACertificate(ca.Name, issuer), owner, namespace); err != nil {
+		return err
+	}
+	return p.createIssuer(NewCAIssuer(ca.Name, ca.Name), owner, namespace)
+}
+
+func (p *CertManagerCertificates) NewSecret(cred types.Credential, owner *metav1.OwnerReference, namespace string) error {
+	// credentials that carry more than a certificate are generated
+	// locally, as cert-manager only writes the certificate
+	if cred.CA == "" || cred.ConnectJson || !p.delegated(cred.CA) {
+		return p.selfSigned().NewSecret(cred, owner, namespace)
+	}
+	return p.createCertificate(NewCertificate(cred, &IssuerReference{Kind: "Issuer", Name: cred.CA}), owner, namespace)
+}
+
+func (p *CertManagerCertificates) createIssuer(issuer *unstructured.Unstructured, owner *metav1.OwnerReference, namespace string) error {
+	if owner != nil {
+		issuer.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	// an existing issuer is returned along with the error, and reused
+	current, err := createUnstructured(IssuerResource, "Issuer", issuer, namespace, p.DynamicClient)
+	if err != nil && current == nil {
+		return err
+	}
+	return nil
+}
+
+func (p *CertManagerCertificates) createCertificate(certificate *unstructured.Unstructured, owner *metav1.OwnerReference, namespace string) error {
+	if owner != nil {
+		certificate.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	_, err := createUnstructured(CertificateResource, "Certificate", certificate, namespace, p.DynamicClient)
+	return err
+}
+
+// NewSelfSignedIssuer returns an Issuer that self-signs the certificates
+// it issues
+func NewSelfSignedIssuer(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": CertManagerGroupVersion.String(),
+			"kind":       "Issuer",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"selfSigned": map[string]interface{}{},
+			},
+		},
+	}
+}
+
+// NewCAIssuer returns an Issuer that signs the certificates it issues
+// with the CA in the named secret
+func NewCAIssuer(name string, secret string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": CertManagerGroupVersion.String(),
+			"kind":       "Issuer",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"ca": map[string]interface{}{
+					"secretName": secret,
+				},
+			},
+		},
+	}
+}
+
+func newCertificate(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": CertManagerGroupVersion.String(),
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+// NewCACertificate returns a Certificate for a CA, written to the secret
+// of the same name
+func NewCACertificate(name string, issuer *IssuerReference) *unstructured.Unstructured {
+	return newCertificate(name, map[string]interface{}{
+		"secretName": name,
+		"commonName": name,
+		"isCA":       true,
+		"privateKey": map[string]interface{}{
+			"algorithm": "RSA",
+			"encoding":  "PKCS1",
+			"size":      int64(2048),
+		},
+		"issuerRef": issuer.asUnstructured(),
+	})
+}
+
+// NewCertificate returns a Certificate for the credential, usable by
+// both servers and clients as the credentials skupper generates are
+func NewCertificate(cred types.Credential, issuer *IssuerReference) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"secretName": cred.Name,
+		"commonName": cred.Subject,
+		"usages":     []interface{}{"digital signature", "key encipherment", "server auth", "client auth"},
+		"privateKey": map[string]interface{}{
+			"algorithm": "RSA",
+			"encoding":  "PKCS1",
+			"size":      int64(2048),
+		},
+		"issuerRef": issuer.asUnstructured(),
+	}
+	dnsNames := []interface{}{}
+	ipAddresses := []interface{}{}
+	for _, host := range cred.Hosts {
+		if net.ParseIP(host) != nil {
+			ipAddresses = append(ipAddresses, host)
+		} else if host != "" {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+	if len(dnsNames) > 0 {
+		spec["dnsNames"] = dnsNames
+	}
+	if len(ipAddresses) > 0 {
+		spec["ipAddresses"] = ipAddresses
+	}
+	return newCertificate(cred.Name, spec)
+}
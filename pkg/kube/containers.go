@@ -13,6 +13,7 @@ func ContainerForController(ds types.DeploymentSpec) corev1.Container {
 		ImagePullPolicy: GetPullPolicy(ds.Image.PullPolicy),
 		Name:            types.ControllerContainerName,
 		Env:             ds.EnvVar,
+		Resources:       ResourceRequirements(ds.Resources),
 	}
 	return container
 }
@@ -31,8 +32,9 @@ func ContainerForTransport(ds types.DeploymentSpec) corev1.Container {
 				},
 			},
 		},
-		Env:   ds.EnvVar,
-		Ports: ds.Ports,
+		Env:       ds.EnvVar,
+		Ports:     ds.Ports,
+		Resources: ResourceRequirements(ds.Resources),
 	}
 	return container
 }
@@ -13,6 +13,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/naming"
 )
 
 func GetDeploymentLabel(name string, key string, namespace string, cli kubernetes.Interface) string {
@@ -66,7 +67,7 @@ func getProxyStatefulSetName(definition types.ServiceInterface) string {
 	if definition.Origin == "" {
 		//in the originating site, the name cannot clash with
 		//the statefulset being exposed
-		return definition.Address + "-proxy"
+		return naming.ProxyStatefulSetName(definition.Address)
 	} else {
 		//in all other sites, the name must match the
 		//statefulset that was exposed in the originating site
@@ -222,7 +223,7 @@ func NewControllerDeployment(van *types.RouterSpec, ownerRef *metav1.OwnerRefere
 				},
 				Template: corev1.PodTemplateSpec{
 					ObjectMeta: metav1.ObjectMeta{
-						Labels:      van.Controller.Labels,
+						Labels:      PodTemplateLabels(van.Controller.Labels, van.Controller.PodLabels),
 						Annotations: van.Controller.Annotations,
 					},
 					Spec: corev1.PodSpec{
@@ -244,6 +245,7 @@ func NewControllerDeployment(van *types.RouterSpec, ownerRef *metav1.OwnerRefere
 		for i, _ := range van.Controller.VolumeMounts {
 			dep.Spec.Template.Spec.Containers[i].VolumeMounts = van.Controller.VolumeMounts[i]
 		}
+		ApplyScheduling(&dep.Spec.Template.Spec, van.Controller.Scheduling)
 
 		created, err := deployments.Create(dep)
 		if err != nil {
@@ -280,7 +282,7 @@ func NewTransportDeployment(van *types.RouterSpec, ownerRef *metav1.OwnerReferen
 				},
 				Template: corev1.PodTemplateSpec{
 					ObjectMeta: metav1.ObjectMeta{
-						Labels:      van.Transport.Labels,
+						Labels:      PodTemplateLabels(van.Transport.Labels, van.Transport.PodLabels),
 						Annotations: van.Transport.Annotations,
 					},
 					Spec: corev1.PodSpec{
@@ -306,6 +308,11 @@ func NewTransportDeployment(van *types.RouterSpec, ownerRef *metav1.OwnerReferen
 		for i, _ := range van.Transport.VolumeMounts {
 			dep.Spec.Template.Spec.Containers[i].VolumeMounts = van.Transport.VolumeMounts[i]
 		}
+		MergeHostAliases(&dep.Spec.Template.Spec, van.Transport.HostAliases)
+		if van.Transport.Replicas > 1 {
+			dep.Spec.Template.Spec.Affinity = SpreadReplicas(van.Transport.Labels)
+		}
+		ApplyScheduling(&dep.Spec.Template.Spec, van.Transport.Scheduling)
 
 		created, err := deployments.Create(dep)
 		if err != nil {
@@ -320,6 +327,42 @@ func NewTransportDeployment(van *types.RouterSpec, ownerRef *metav1.OwnerReferen
 	}
 }
 
+// PodTemplateLabels returns the labels for the pods of a deployment: those
+// it selects them by, along with any others given
+func PodTemplateLabels(selector map[string]string, labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return selector
+	}
+	result := map[string]string{}
+	for key, value := range labels {
+		result[key] = value
+	}
+	for key, value := range selector {
+		result[key] = value
+	}
+	return result
+}
+
+// SpreadReplicas prefers to schedule the replicas with the given labels
+// on different nodes, so that losing a node does not take all of them
+func SpreadReplicas(labels map[string]string) *corev1.Affinity {
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: labels,
+						},
+						TopologyKey: "kubernetes.io/hostname",
+					},
+				},
+			},
+		},
+	}
+}
+
 func GetContainerPort(deployment *appsv1.Deployment) int32 {
 	if len(deployment.Spec.Template.Spec.Containers) > 0 && len(deployment.Spec.Template.Spec.Containers[0].Ports) > 0 {
 		return deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort
@@ -0,0 +1,34 @@
+package kube
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ClusterDomainFromResolvConf returns the DNS domain of the cluster as
+// implied by the search list of a pod's resolv.conf, which kubelet sets
+// to <namespace>.svc.<domain> svc.<domain> <domain>, or the empty string
+// if the search list does not look like one kubelet wrote
+func ClusterDomainFromResolvConf(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "search" {
+			continue
+		}
+		for _, domain := range fields[1:] {
+			domain = strings.TrimSuffix(domain, ".")
+			if strings.HasPrefix(domain, "svc.") && len(domain) > len("svc.") {
+				return strings.TrimPrefix(domain, "svc.")
+			}
+		}
+	}
+	return ""
+}
+
+// QualifiedServiceName returns the fully qualified DNS name of a service
+// in a cluster with the given domain
+func QualifiedServiceName(name string, namespace string, domain string) string {
+	return name + "." + namespace + ".svc." + domain
+}
@@ -0,0 +1,51 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestClusterDomainFromResolvConf(t *testing.T) {
+	testTable := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "default",
+			content:  "nameserver 10.96.0.10\nsearch myns.svc.cluster.local svc.cluster.local cluster.local\noptions ndots:5\n",
+			expected: "cluster.local",
+		},
+		{
+			name:     "custom",
+			content:  "search myns.svc.corp.example svc.corp.example corp.example ec2.internal\nnameserver 172.30.0.10\n",
+			expected: "corp.example",
+		},
+		{
+			name:     "fully qualified",
+			content:  "search myns.svc.k8s.example. svc.k8s.example.\n",
+			expected: "k8s.example",
+		},
+		{
+			name:     "not in cluster",
+			content:  "nameserver 8.8.8.8\nsearch example.com\n",
+			expected: "",
+		},
+		{
+			name:     "empty",
+			content:  "",
+			expected: "",
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, ClusterDomainFromResolvConf(strings.NewReader(test.content)), test.expected)
+		})
+	}
+}
+
+func TestQualifiedServiceName(t *testing.T) {
+	assert.Equal(t, QualifiedServiceName("skupper-router", "west", "corp.example"), "skupper-router.west.svc.corp.example")
+}
@@ -0,0 +1,158 @@
+package kube
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// TLSRoute is only available in the experimental channel of the Gateway
+// API, so the version that includes it is used for the Gateway as well
+var GatewayApiGroupVersion = schema.GroupVersion{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha2",
+}
+
+var GatewayResource = GatewayApiGroupVersion.WithResource("gateways")
+
+var TLSRouteResource = GatewayApiGroupVersion.WithResource("tlsroutes")
+
+// IsGatewayApiInstalled returns true if the custom resources for both
+// Gateways and TLSRoutes are defined in the cluster
+func IsGatewayApiInstalled(dc discovery.DiscoveryInterface) bool {
+	resources, err := dc.ServerResourcesForGroupVersion(GatewayApiGroupVersion.String())
+	if err != nil || resources == nil {
+		return false
+	}
+	found := map[string]bool{}
+	for _, resource := range resources.APIResources {
+		found[resource.Name] = true
+	}
+	return found[GatewayResource.Resource] && found[TLSRouteResource.Resource]
+}
+
+// GatewayListener is a TLS passthrough listener of a Gateway, accepting
+// connections for a single host
+type GatewayListener struct {
+	Name string
+	Host string
+}
+
+// NewPassthroughGateway returns a Gateway of the given class that listens
+// on port 443 for each of the hosts, and passes TLS connections through
+// to the backends of the TLSRoutes attached to it
+func NewPassthroughGateway(name string, className string, listeners []GatewayListener) *unstructured.Unstructured {
+	specListeners := []interface{}{}
+	for _, listener := range listeners {
+		specListeners = append(specListeners, map[string]interface{}{
+			"name":     listener.Name,
+			"hostname": listener.Host,
+			"port":     int64(443),
+			"protocol": "TLS",
+			"tls": map[string]interface{}{
+				"mode": "Passthrough",
+			},
+			"allowedRoutes": map[string]interface{}{
+				"namespaces": map[string]interface{}{
+					"from": "Same",
+				},
+				"kinds": []interface{}{
+					map[string]interface{}{
+						"kind": "TLSRoute",
+					},
+				},
+			},
+		})
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": GatewayApiGroupVersion.String(),
+			"kind":       "Gateway",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"gatewayClassName": className,
+				"listeners":        specListeners,
+			},
+		},
+	}
+}
+
+// NewTLSRoute returns a TLSRoute that attaches to the named listener of
+// a gateway and routes connections for host to the port of the service
+func NewTLSRoute(name string, gateway string, listener string, host string, service string, port int) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": GatewayApiGroupVersion.String(),
+			"kind":       "TLSRoute",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{
+						"name":        gateway,
+						"sectionName": listener,
+					},
+				},
+				"hostnames": []interface{}{host},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": service,
+								"port": int64(port),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func createUnstructured(resource schema.GroupVersionResource, kind string, obj *unstructured.Unstructured, namespace string, dc dynamic.Interface) (*unstructured.Unstructured, error) {
+	client := dc.Resource(resource).Namespace(namespace)
+	current, err := client.Get(obj.GetName(), metav1.GetOptions{})
+	if err == nil {
+		return current, fmt.Errorf("%s %s already exists", kind, obj.GetName())
+	} else if errors.IsNotFound(err) {
+		created, err := client.Create(obj, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create %s : %w", kind, err)
+		}
+		return created, nil
+	} else {
+		return nil, fmt.Errorf("Failed while checking %s: %w", kind, err)
+	}
+}
+
+func CreateGateway(gateway *unstructured.Unstructured, namespace string, dc dynamic.Interface) (*unstructured.Unstructured, error) {
+	return createUnstructured(GatewayResource, "Gateway", gateway, namespace, dc)
+}
+
+func CreateTLSRoute(route *unstructured.Unstructured, namespace string, dc dynamic.Interface) (*unstructured.Unstructured, error) {
+	return createUnstructured(TLSRouteResource, "TLSRoute", route, namespace, dc)
+}
+
+// GetTLSRouteHost returns the first hostname of the named TLSRoute
+func GetTLSRouteHost(name string, namespace string, dc dynamic.Interface) (string, error) {
+	route, err := dc.Resource(TLSRouteResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if err != nil {
+		return "", err
+	}
+	if len(hostnames) == 0 {
+		return "", fmt.Errorf("TLSRoute %s has no hostnames", name)
+	}
+	return hostnames[0], nil
+}
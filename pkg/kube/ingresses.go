@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Ingress resources of the networking.k8s.io/v1 API are not in the typed
+// client, so are handled through the dynamic client
+var IngressResource = schema.GroupVersionResource{
+	Group:    "networking.k8s.io",
+	Version:  "v1",
+	Resource: "ingresses",
+}
+
+// NewIngressWithPassthrough returns an Ingress that routes TLS connections
+// for host to the port of the service without terminating them, which
+// requires an ingress controller supporting the nginx ssl-passthrough
+// annotation
+func NewIngressWithPassthrough(name string, host string, service string, port int) *unstructured.Unstructured {
+	ingress := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": IngressResource.GroupVersion().String(),
+			"kind":       "Ingress",
+			"metadata": map[string]interface{}{
+				"name": name,
+				"annotations": map[string]interface{}{
+					"nginx.ingress.kubernetes.io/ssl-passthrough": "true",
+				},
+			},
+			"spec": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"host": host,
+						"http": map[string]interface{}{
+							"paths": []interface{}{
+								map[string]interface{}{
+									"path":     "/",
+									"pathType": "Prefix",
+									"backend": map[string]interface{}{
+										"service": map[string]interface{}{
+											"name": service,
+											"port": map[string]interface{}{
+												"number": int64(port),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return ingress
+}
+
+func CreateIngress(ingress *unstructured.Unstructured, namespace string, dc dynamic.Interface) (*unstructured.Unstructured, error) {
+	ingresses := dc.Resource(IngressResource).Namespace(namespace)
+	current, err := ingresses.Get(ingress.GetName(), metav1.GetOptions{})
+	if err == nil {
+		return current, fmt.Errorf("Ingress %s already exists", ingress.GetName())
+	} else if errors.IsNotFound(err) {
+		created, err := ingresses.Create(ingress, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create ingress : %w", err)
+		}
+		return created, nil
+	} else {
+		return nil, fmt.Errorf("Failed while checking ingress: %w", err)
+	}
+}
+
+// GetIngressHost returns the host of the first rule of the named Ingress
+func GetIngressHost(name string, namespace string, dc dynamic.Interface) (string, error) {
+	ingress, err := dc.Resource(IngressResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	rules, _, err := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+	if err != nil {
+		return "", err
+	}
+	if len(rules) == 0 {
+		return "", fmt.Errorf("Ingress %s has no rules", name)
+	}
+	rule, ok := rules[0].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("Ingress %s has an invalid rule", name)
+	}
+	host, _, _ := unstructured.NestedString(rule, "host")
+	if host == "" {
+		return "", fmt.Errorf("Ingress %s has no host", name)
+	}
+	return host, nil
+}
@@ -7,6 +7,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
 )
 
 func NewNamespace(name string, cli kubernetes.Interface) (*corev1.Namespace, error) {
@@ -32,3 +34,16 @@ func DeleteNamespace(name string, cli kubernetes.Interface) error {
 		return fmt.Errorf("Failed to delete namesspace: %w", err)
 	}
 }
+
+// CheckTargetNamespace returns an error unless the namespace has opted in
+// to having its workloads exposed by the site in the given namespace
+func CheckTargetNamespace(name string, site string, cli kubernetes.Interface) error {
+	namespace, err := cli.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Could not read namespace %s: %w", name, err)
+	}
+	if namespace.ObjectMeta.Labels[types.TargetNamespaceLabel] != site {
+		return fmt.Errorf("Namespace %s is not labelled %s=%s", name, types.TargetNamespaceLabel, site)
+	}
+	return nil
+}
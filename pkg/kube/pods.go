@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,6 +32,76 @@ import (
 	"github.com/skupperproject/skupper/pkg/utils"
 )
 
+// ParseHostAliases parses a comma separated list of hostname=ip pairs
+func ParseHostAliases(value string) (map[string]string, error) {
+	aliases := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid host alias %q, expected <hostname>=<ip>", pair)
+		}
+		if net.ParseIP(parts[1]) == nil {
+			return nil, fmt.Errorf("Invalid IP address for host alias %s: %q", parts[0], parts[1])
+		}
+		aliases[parts[0]] = parts[1]
+	}
+	return aliases, nil
+}
+
+// FormatHostAliases is the inverse of ParseHostAliases
+func FormatHostAliases(aliases map[string]string) string {
+	pairs := []string{}
+	for host, ip := range aliases {
+		pairs = append(pairs, host+"="+ip)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// MergeHostAliases adds the hostname to IP mappings to the host aliases
+// of the pod, replacing any existing mapping for the same hostname. It
+// returns true if the pod spec was changed.
+func MergeHostAliases(spec *corev1.PodSpec, aliases map[string]string) bool {
+	current := map[string]string{}
+	for _, alias := range spec.HostAliases {
+		for _, host := range alias.Hostnames {
+			current[host] = alias.IP
+		}
+	}
+	changed := false
+	for host, ip := range aliases {
+		if current[host] != ip {
+			current[host] = ip
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	byIp := map[string][]string{}
+	ips := []string{}
+	for host, ip := range current {
+		if _, ok := byIp[ip]; !ok {
+			ips = append(ips, ip)
+		}
+		byIp[ip] = append(byIp[ip], host)
+	}
+	sort.Strings(ips)
+	spec.HostAliases = nil
+	for _, ip := range ips {
+		sort.Strings(byIp[ip])
+		spec.HostAliases = append(spec.HostAliases, corev1.HostAlias{
+			IP:        ip,
+			Hostnames: byIp[ip],
+		})
+	}
+	return true
+}
+
 func GetPullPolicy(policy string) corev1.PullPolicy {
 	switch policy {
 	case string(corev1.PullAlways):
@@ -95,6 +167,33 @@ func GetImageVersion(pod *corev1.Pod, container string) string {
 	return "not-found"
 }
 
+// GetImageDigests returns the image and the distinct image digests
+// running in the given container of all pods for a component
+func GetImageDigests(namespace string, clientset kubernetes.Interface, component string, container string) (string, []string, error) {
+	selector := "skupper.io/component=" + component
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", nil, err
+	}
+	image := ""
+	digests := []string{}
+	seen := map[string]bool{}
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.ContainerStatuses {
+			if c.Name != container || c.ImageID == "" {
+				continue
+			}
+			image = c.Image
+			digest := strings.TrimPrefix(c.ImageID, "docker-pullable://")
+			if !seen[digest] {
+				seen[digest] = true
+				digests = append(digests, digest)
+			}
+		}
+	}
+	return image, digests, nil
+}
+
 func GetComponentVersion(namespace string, clientset kubernetes.Interface, component string, container string) string {
 	pod, err := GetReadyPod(namespace, clientset, component)
 	if err == nil {
@@ -127,6 +226,19 @@ func GetPodContainerLogs(podName string, containerName string, namespace string,
 	return GetPodContainerLogsWithOpts(podName, containerName, namespace, clientset, podLogOpts)
 }
 
+// StreamPodContainerLogs copies the logs of the container to w as they
+// are read, rather than holding them in memory, returning the number of
+// bytes written
+func StreamPodContainerLogs(podName string, containerName string, namespace string, clientset kubernetes.Interface, podLogOpts corev1.PodLogOptions, w io.Writer) (int64, error) {
+	podLogOpts.Container = containerName
+	podLogs, err := clientset.CoreV1().Pods(namespace).GetLogs(podName, &podLogOpts).Stream()
+	if err != nil {
+		return 0, err
+	}
+	defer podLogs.Close()
+	return io.Copy(w, podLogs)
+}
+
 func GetPodContainerLogsWithOpts(podName string, containerName string, namespace string, clientset kubernetes.Interface, podLogOpts corev1.PodLogOptions) (string, error) {
 	if containerName != "" {
 		podLogOpts.Container = containerName
@@ -0,0 +1,48 @@
+package kube
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseHostAliases(t *testing.T) {
+	aliases, err := ParseHostAliases("peer.example.com=10.1.2.3, other.example.com=10.1.2.4,")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, aliases, map[string]string{
+		"peer.example.com":  "10.1.2.3",
+		"other.example.com": "10.1.2.4",
+	})
+	assert.Equal(t, FormatHostAliases(aliases), "other.example.com=10.1.2.4,peer.example.com=10.1.2.3")
+
+	_, err = ParseHostAliases("peer.example.com")
+	assert.Error(t, err, `Invalid host alias "peer.example.com", expected <hostname>=<ip>`)
+	_, err = ParseHostAliases("peer.example.com=not-an-ip")
+	assert.Error(t, err, `Invalid IP address for host alias peer.example.com: "not-an-ip"`)
+}
+
+func TestMergeHostAliases(t *testing.T) {
+	spec := &corev1.PodSpec{
+		HostAliases: []corev1.HostAlias{
+			{IP: "10.1.2.3", Hostnames: []string{"a.example.com"}},
+		},
+	}
+	assert.Assert(t, !MergeHostAliases(spec, map[string]string{"a.example.com": "10.1.2.3"}))
+	assert.Assert(t, !MergeHostAliases(spec, nil))
+
+	assert.Assert(t, MergeHostAliases(spec, map[string]string{
+		"b.example.com": "10.1.2.3",
+		"c.example.com": "10.0.0.1",
+	}))
+	assert.DeepEqual(t, spec.HostAliases, []corev1.HostAlias{
+		{IP: "10.0.0.1", Hostnames: []string{"c.example.com"}},
+		{IP: "10.1.2.3", Hostnames: []string{"a.example.com", "b.example.com"}},
+	})
+
+	assert.Assert(t, MergeHostAliases(spec, map[string]string{"a.example.com": "10.0.0.1"}))
+	assert.DeepEqual(t, spec.HostAliases, []corev1.HostAlias{
+		{IP: "10.0.0.1", Hostnames: []string{"a.example.com", "c.example.com"}},
+		{IP: "10.1.2.3", Hostnames: []string{"b.example.com"}},
+	})
+}
@@ -0,0 +1,54 @@
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func setQuantity(list corev1.ResourceList, name corev1.ResourceName, value string) corev1.ResourceList {
+	if value == "" {
+		return list
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return list
+	}
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	list[name] = quantity
+	return list
+}
+
+// ResourceRequirements returns the requests and limits for a container
+// with the given resources. Quantities that cannot be parsed are
+// ignored, as they are checked before any deployment is created.
+func ResourceRequirements(spec types.ResourcesSpec) corev1.ResourceRequirements {
+	requirements := corev1.ResourceRequirements{}
+	requirements.Requests = setQuantity(requirements.Requests, corev1.ResourceCPU, spec.Cpu)
+	requirements.Requests = setQuantity(requirements.Requests, corev1.ResourceMemory, spec.Memory)
+	requirements.Limits = setQuantity(requirements.Limits, corev1.ResourceCPU, spec.CpuLimit)
+	requirements.Limits = setQuantity(requirements.Limits, corev1.ResourceMemory, spec.MemoryLimit)
+	return requirements
+}
+
+// EquivalentResources returns true if the requirements request and limit
+// the same quantities
+func EquivalentResources(a corev1.ResourceRequirements, b corev1.ResourceRequirements) bool {
+	return equivalentResourceLists(a.Requests, b.Requests) && equivalentResourceLists(a.Limits, b.Limits)
+}
+
+func equivalentResourceLists(a corev1.ResourceList, b corev1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, quantity := range a {
+		other, ok := b[name]
+		if !ok || quantity.Cmp(other) != 0 {
+			return false
+		}
+	}
+	return true
+}
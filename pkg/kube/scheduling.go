@@ -0,0 +1,143 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// ParseLabels parses a comma separated list of <key>=<value> pairs, as
+// used for node selectors and pod (anti-)affinity
+func ParseLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid label %q, expected <key>=<value>", pair)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
+
+// FormatLabels is the inverse of ParseLabels
+func FormatLabels(labels map[string]string) string {
+	pairs := []string{}
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ParseToleration parses a toleration given as <key>[=<value>]:<effect>,
+// in the form taints are given to kubectl. The effect may be empty to
+// tolerate all effects.
+func ParseToleration(value string) (corev1.Toleration, error) {
+	toleration := corev1.Toleration{}
+	i := strings.LastIndex(value, ":")
+	if i < 0 {
+		return toleration, fmt.Errorf("Invalid toleration %q, expected <key>[=<value>]:<effect>", value)
+	}
+	effect := corev1.TaintEffect(value[i+1:])
+	switch effect {
+	case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		toleration.Effect = effect
+	default:
+		return toleration, fmt.Errorf("Invalid effect for toleration %q, expected one of %s, %s or %s", value, corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute)
+	}
+	parts := strings.SplitN(value[:i], "=", 2)
+	if parts[0] == "" {
+		return toleration, fmt.Errorf("Invalid toleration %q, expected <key>[=<value>]:<effect>", value)
+	}
+	toleration.Key = parts[0]
+	if len(parts) == 2 {
+		toleration.Operator = corev1.TolerationOpEqual
+		toleration.Value = parts[1]
+	} else {
+		toleration.Operator = corev1.TolerationOpExists
+	}
+	return toleration, nil
+}
+
+// ParseTolerations parses a comma separated list of tolerations
+func ParseTolerations(value string) ([]corev1.Toleration, error) {
+	tolerations := []corev1.Toleration{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		toleration, err := ParseToleration(item)
+		if err != nil {
+			return nil, err
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	return tolerations, nil
+}
+
+// FormatTolerations is the inverse of ParseTolerations
+func FormatTolerations(tolerations []corev1.Toleration) string {
+	items := []string{}
+	for _, toleration := range tolerations {
+		item := toleration.Key
+		if toleration.Operator == corev1.TolerationOpEqual {
+			item += "=" + toleration.Value
+		}
+		items = append(items, item+":"+string(toleration.Effect))
+	}
+	return strings.Join(items, ",")
+}
+
+func podAffinityTerm(labels map[string]string) corev1.PodAffinityTerm {
+	return corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: labels,
+		},
+		TopologyKey: "kubernetes.io/hostname",
+	}
+}
+
+// ApplyScheduling constrains the nodes the pod is scheduled on as
+// requested. Any affinity the pod already has is kept.
+func ApplyScheduling(spec *corev1.PodSpec, scheduling types.SchedulingSpec) {
+	if len(scheduling.NodeSelector) > 0 {
+		spec.NodeSelector = scheduling.NodeSelector
+	}
+	if len(scheduling.Tolerations) > 0 {
+		spec.Tolerations = scheduling.Tolerations
+	}
+	if scheduling.PriorityClassName != "" {
+		spec.PriorityClassName = scheduling.PriorityClassName
+	}
+	if len(scheduling.Affinity) == 0 && len(scheduling.AntiAffinity) == 0 {
+		return
+	}
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+	if len(scheduling.Affinity) > 0 {
+		if spec.Affinity.PodAffinity == nil {
+			spec.Affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		affinity := spec.Affinity.PodAffinity
+		affinity.RequiredDuringSchedulingIgnoredDuringExecution = append(affinity.RequiredDuringSchedulingIgnoredDuringExecution, podAffinityTerm(scheduling.Affinity))
+	}
+	if len(scheduling.AntiAffinity) > 0 {
+		if spec.Affinity.PodAntiAffinity == nil {
+			spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		antiAffinity := spec.Affinity.PodAntiAffinity
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, podAffinityTerm(scheduling.AntiAffinity))
+	}
+}
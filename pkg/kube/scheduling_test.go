@@ -0,0 +1,66 @@
+package kube
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestParseTolerations(t *testing.T) {
+	tolerations, err := ParseTolerations("dedicated=infra:NoSchedule, node-role.kubernetes.io/infra:,spot:NoExecute")
+	assert.Assert(t, err)
+	assert.DeepEqual(t, tolerations, []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "infra", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists},
+		{Key: "spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	})
+	assert.Equal(t, FormatTolerations(tolerations), "dedicated=infra:NoSchedule,node-role.kubernetes.io/infra:,spot:NoExecute")
+
+	_, err = ParseTolerations("dedicated=infra")
+	assert.Error(t, err, `Invalid toleration "dedicated=infra", expected <key>[=<value>]:<effect>`)
+	_, err = ParseTolerations("dedicated:Sometimes")
+	assert.ErrorContains(t, err, "Invalid effect for toleration")
+	_, err = ParseTolerations("=infra:NoSchedule")
+	assert.ErrorContains(t, err, "Invalid toleration")
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels("zone=east, node-role.kubernetes.io/infra=")
+	assert.Assert(t, err)
+	assert.DeepEqual(t, labels, map[string]string{"zone": "east", "node-role.kubernetes.io/infra": ""})
+	assert.Equal(t, FormatLabels(labels), "node-role.kubernetes.io/infra=,zone=east")
+	_, err = ParseLabels("zone")
+	assert.Error(t, err, `Invalid label "zone", expected <key>=<value>`)
+}
+
+func TestApplyScheduling(t *testing.T) {
+	spec := corev1.PodSpec{}
+	ApplyScheduling(&spec, types.SchedulingSpec{})
+	assert.DeepEqual(t, spec, corev1.PodSpec{})
+
+	spec.Affinity = &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{Weight: 100}},
+		},
+	}
+	ApplyScheduling(&spec, types.SchedulingSpec{
+		NodeSelector:      map[string]string{"zone": "east"},
+		Affinity:          map[string]string{"app": "db"},
+		AntiAffinity:      map[string]string{"app": "batch"},
+		Tolerations:       []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+		PriorityClassName: "infra",
+	})
+	assert.DeepEqual(t, spec.NodeSelector, map[string]string{"zone": "east"})
+	assert.Equal(t, spec.PriorityClassName, "infra")
+	assert.Equal(t, len(spec.Tolerations), 1)
+	affinity := spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	assert.Equal(t, len(affinity), 1)
+	assert.DeepEqual(t, affinity[0].LabelSelector.MatchLabels, map[string]string{"app": "db"})
+	antiAffinity := spec.Affinity.PodAntiAffinity
+	assert.Equal(t, len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution), 1)
+	assert.Equal(t, len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution), 1)
+	assert.Equal(t, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey, "kubernetes.io/hostname")
+}
@@ -28,6 +28,7 @@ func NewCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, name
 		}
 		_, err := cli.CoreV1().Secrets(namespace).Create(&newca)
 		if err == nil {
+			certs.RecordSecretChange(certs.SecretCreated)
 			return &newca, nil
 		} else {
 			return nil, fmt.Errorf("Failed to create CA %s : %w", ca.Name, err)
@@ -77,6 +78,7 @@ func NewSecret(cred types.Credential, owner *metav1.OwnerReference, namespace st
 		return nil, err
 
 	}
+	certs.RecordSecretChange(certs.SecretCreated)
 
 	return &secret, nil
 }
@@ -85,6 +87,7 @@ func DeleteSecret(name string, namespace string, cli kubernetes.Interface) error
 	secrets := cli.CoreV1().Secrets(namespace)
 	err := secrets.Delete(name, &metav1.DeleteOptions{})
 	if err == nil {
+		certs.RecordSecretChange(certs.SecretDeleted)
 		return err
 	} else if errors.IsNotFound(err) {
 		return fmt.Errorf("Secret %s does not exist.", name)
@@ -11,6 +11,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/naming"
 )
 
 func GetLabelsForRouter() map[string]string {
@@ -31,6 +32,102 @@ func GetLoadBalancerHostOrIP(service *corev1.Service) string {
 	return ""
 }
 
+// internalLoadBalancerAnnotations are those each cloud provider requires
+// for the load balancer of a service to only be reachable from within
+// its network, e.g. over VPC peering
+var internalLoadBalancerAnnotations = map[string]map[string]string{
+	types.LoadBalancerProviderAWS: {
+		"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+		"service.beta.kubernetes.io/aws-load-balancer-scheme":   "internal",
+	},
+	types.LoadBalancerProviderGCP: {
+		"networking.gke.io/load-balancer-type": "Internal",
+	},
+	types.LoadBalancerProviderAzure: {
+		"service.beta.kubernetes.io/azure-load-balancer-internal": "true",
+	},
+}
+
+// LoadBalancerAnnotations returns the annotations for a service of type
+// LoadBalancer. An internal load balancer has the annotations of the
+// given cloud provider, or of all of them if none is given. Overrides
+// are applied last; an override with an empty value removes the
+// annotation.
+func LoadBalancerAnnotations(internal bool, provider string, overrides map[string]string) map[string]string {
+	annotations := map[string]string{}
+	if internal {
+		for name, providerAnnotations := range internalLoadBalancerAnnotations {
+			if provider != "" && provider != name {
+				continue
+			}
+			for key, value := range providerAnnotations {
+				annotations[key] = value
+			}
+		}
+	}
+	for key, value := range overrides {
+		if value == "" {
+			delete(annotations, key)
+		} else {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// GetRouterIngressService returns the service through which the router
+// is reached from outside the cluster. This is the service adopted for
+// ingress, if the site was initialised with one, else skupper-router.
+func GetRouterIngressService(namespace string, kubeclient kubernetes.Interface) (*corev1.Service, error) {
+	service, err := GetService(types.TransportServiceName, namespace, kubeclient)
+	if err != nil {
+		return nil, err
+	}
+	if name := service.ObjectMeta.Annotations[types.IngressServiceAnnotation]; name != "" {
+		return GetService(name, namespace, kubeclient)
+	}
+	return service, nil
+}
+
+// CheckServicePorts returns an error if the service does not expose
+// each of the required ports over tcp
+func CheckServicePorts(service *corev1.Service, required []corev1.ServicePort) error {
+	for _, r := range required {
+		found := false
+		for _, p := range service.Spec.Ports {
+			if p.Port == r.Port && (p.Protocol == "" || p.Protocol == corev1.ProtocolTCP) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Service %s does not expose port %d (%s)", service.ObjectMeta.Name, r.Port, r.Name)
+		}
+	}
+	return nil
+}
+
+// AdoptService points an existing service at the pods matching the
+// selector, targeting each of the required ports
+func AdoptService(name string, selector map[string]string, required []corev1.ServicePort, namespace string, kubeclient kubernetes.Interface) (*corev1.Service, error) {
+	service, err := GetService(name, namespace, kubeclient)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckServicePorts(service, required); err != nil {
+		return nil, err
+	}
+	service.Spec.Selector = selector
+	for i, p := range service.Spec.Ports {
+		for _, r := range required {
+			if p.Port == r.Port {
+				service.Spec.Ports[i].TargetPort = r.TargetPort
+			}
+		}
+	}
+	return kubeclient.CoreV1().Services(namespace).Update(service)
+}
+
 func DeleteService(name string, namespace string, kubeclient kubernetes.Interface) error {
 	_, err := kubeclient.CoreV1().Services(namespace).Get(name, metav1.GetOptions{})
 	if err == nil {
@@ -44,22 +141,47 @@ func GetService(name string, namespace string, kubeclient kubernetes.Interface)
 	return current, err
 }
 
-func NewServiceForAddress(address string, port int, targetPort int, owner *metav1.OwnerReference, namespace string, kubeclient kubernetes.Interface) (*corev1.Service, error) {
+// NewServiceForAddress creates a service for the given address that
+// forwards each of the ports to the corresponding target port on the router
+func NewServiceForAddress(address string, ports []int, targetPorts []int, owner *metav1.OwnerReference, namespace string, kubeclient kubernetes.Interface) (*corev1.Service, error) {
 	labels := GetLabelsForRouter()
-	service := makeServiceObjectForAddress(address, port, targetPort, labels, owner)
+	service := makeServiceObjectForAddress(address, ports, targetPorts, labels, owner)
 	return createServiceFromObject(service, namespace, kubeclient)
 }
 
-func NewHeadlessServiceForAddress(address string, port int, targetPort int, owner *metav1.OwnerReference, namespace string, kubeclient kubernetes.Interface) (*corev1.Service, error) {
+func NewHeadlessServiceForAddress(address string, ports []int, targetPorts []int, owner *metav1.OwnerReference, namespace string, kubeclient kubernetes.Interface) (*corev1.Service, error) {
 	labels := map[string]string{
 		"internal.skupper.io/service": address,
 	}
-	service := makeServiceObjectForAddress(address, port, targetPort, labels, owner)
+	service := makeServiceObjectForAddress(address, ports, targetPorts, labels, owner)
 	service.Spec.ClusterIP = "None"
 	return createServiceFromObject(service, namespace, kubeclient)
 }
 
-func makeServiceObjectForAddress(address string, port int, targetPort int, labels map[string]string, owner *metav1.OwnerReference) *corev1.Service {
+// ServicePortName returns the name of the port at the given index of the
+// service for an address. The first port is named after the service, as
+// it was when services had a single port.
+func ServicePortName(address string, index int, port int) string {
+	if index == 0 {
+		return naming.ServiceName(address)
+	}
+	return fmt.Sprintf("port%d", port)
+}
+
+// ServicePortsFor returns the ports of the service for an address
+func ServicePortsFor(address string, ports []int, targetPorts []int) []corev1.ServicePort {
+	servicePorts := []corev1.ServicePort{}
+	for i, port := range ports {
+		servicePorts = append(servicePorts, corev1.ServicePort{
+			Name:       ServicePortName(address, i, port),
+			Port:       int32(port),
+			TargetPort: intstr.FromInt(targetPorts[i]),
+		})
+	}
+	return servicePorts
+}
+
+func makeServiceObjectForAddress(address string, ports []int, targetPorts []int, labels map[string]string, owner *metav1.OwnerReference) *corev1.Service {
 	// TODO: make common service creation and deal with annotation, label differences
 	service := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
@@ -67,20 +189,15 @@ func makeServiceObjectForAddress(address string, port int, targetPort int, label
 			Kind:       "Service",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: address,
+			Name: naming.ServiceName(address),
 			Annotations: map[string]string{
 				"internal.skupper.io/controlled": "true",
+				types.ServiceAddressAnnotation:   address,
 			},
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: labels,
-			Ports: []corev1.ServicePort{
-				corev1.ServicePort{
-					Name:       address,
-					Port:       int32(port),
-					TargetPort: intstr.FromInt(targetPort),
-				},
-			},
+			Ports:    ServicePortsFor(address, ports, targetPorts),
 		},
 	}
 	if owner != nil {
@@ -6,9 +6,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
 )
 
 func TestGetPortForServiceTarget(t *testing.T) {
@@ -88,3 +91,61 @@ func TestGetPortForServiceTarget(t *testing.T) {
 		})
 	}
 }
+
+func TestAdoptService(t *testing.T) {
+	const NS = "test"
+	required := []corev1.ServicePort{
+		{Name: "inter-router", Port: 55671, TargetPort: intstr.FromInt(55671)},
+		{Name: "edge", Port: 45671, TargetPort: intstr.FromInt(45671)},
+	}
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.CoreV1().Services(NS).Create(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "static-ip"},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{
+				{Name: "a", Port: 55671, TargetPort: intstr.FromInt(8080)},
+				{Name: "b", Port: 45671},
+			},
+		},
+	})
+	kubeClient.CoreV1().Services(NS).Create(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "missing-edge"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "a", Port: 55671}},
+		},
+	})
+	selector := map[string]string{"application": "skupper-router"}
+
+	_, err := AdoptService("missing-edge", selector, required, NS, kubeClient)
+	assert.Error(t, err, "Service missing-edge does not expose port 45671 (edge)")
+
+	_, err = AdoptService("nonexistent", selector, required, NS, kubeClient)
+	assert.Assert(t, err != nil)
+
+	adopted, err := AdoptService("static-ip", selector, required, NS, kubeClient)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, adopted.Spec.Selector, selector)
+	assert.Equal(t, adopted.Spec.Ports[0].TargetPort, intstr.FromInt(55671))
+	assert.Equal(t, adopted.Spec.Ports[1].TargetPort, intstr.FromInt(45671))
+}
+
+func TestLoadBalancerAnnotations(t *testing.T) {
+	assert.DeepEqual(t, LoadBalancerAnnotations(false, "", nil), map[string]string{})
+	assert.DeepEqual(t, LoadBalancerAnnotations(true, "", nil), map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-internal":   "true",
+		"service.beta.kubernetes.io/aws-load-balancer-scheme":     "internal",
+		"networking.gke.io/load-balancer-type":                    "Internal",
+		"service.beta.kubernetes.io/azure-load-balancer-internal": "true",
+	})
+	assert.DeepEqual(t, LoadBalancerAnnotations(true, types.LoadBalancerProviderGCP, nil), map[string]string{
+		"networking.gke.io/load-balancer-type": "Internal",
+	})
+	overrides := map[string]string{
+		"networking.gke.io/load-balancer-type":                         "",
+		"networking.gke.io/internal-load-balancer-allow-global-access": "true",
+	}
+	assert.DeepEqual(t, LoadBalancerAnnotations(true, types.LoadBalancerProviderGCP, overrides), map[string]string{
+		"networking.gke.io/internal-load-balancer-allow-global-access": "true",
+	})
+}
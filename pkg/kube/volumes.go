@@ -69,6 +69,16 @@ func RemoveSecretVolumeForDeployment(name string, dep *appsv1.Deployment, index
 	dep.Spec.Template.Spec.Containers[index].VolumeMounts = volumeMounts
 }
 
+// HasSecretVolume returns true if the pod mounts the named secret
+func HasSecretVolume(spec *corev1.PodSpec, name string) bool {
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == name {
+			return true
+		}
+	}
+	return false
+}
+
 func UpdateSecretVolume(spec *corev1.PodSpec, oldname string, name string) {
 	for i, volume := range spec.Volumes {
 		if volume.Name == oldname {
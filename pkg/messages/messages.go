@@ -0,0 +1,171 @@
+// Package messages holds the user facing messages of the skupper CLI
+// and client, keyed by a stable id. Distributions can replace any of
+// the default (English) texts by loading a catalog, and tests can check
+// which message was produced without matching on its text.
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+type ID string
+
+// EnvCatalog names a file of translated messages loaded by the CLI
+const EnvCatalog string = "SKUPPER_MESSAGE_CATALOG"
+
+const (
+	SiteNotInitialised        ID = "site.not-initialised"
+	SiteNotInstalled          ID = "site.not-installed"
+	SiteNotEnabled            ID = "site.not-enabled"
+	SiteInstalled             ID = "site.installed"
+	SiteRemoved               ID = "site.removed"
+	SiteUpdated               ID = "site.updated"
+	StatusEnabled             ID = "status.enabled"
+	StatusSiteName            ID = "status.site-name"
+	StatusInteriorMode        ID = "status.interior-mode"
+	StatusEdgeMode            ID = "status.edge-mode"
+	StatusPending             ID = "status.pending"
+	StatusNotConnected        ID = "status.not-connected"
+	StatusConnectedOne        ID = "status.connected-one"
+	StatusConnected           ID = "status.connected"
+	StatusConnectedIndirectly ID = "status.connected-indirectly"
+	StatusNoServices          ID = "status.no-services"
+	StatusOneService          ID = "status.one-service"
+	StatusServices            ID = "status.services"
+	StatusConsoleUrl          ID = "status.console-url"
+	StatusConsoleCredentials  ID = "status.console-credentials"
+	StatusEgressIPs           ID = "status.egress-ips"
+	LinkConfigured            ID = "link.configured"
+	LinkConfiguredBySite      ID = "link.configured-by-site-controller"
+	LinkRemoved               ID = "link.removed"
+	LinkActive                ID = "link.active"
+	LinkNotActive             ID = "link.not-active"
+	LinkNoneConfigured        ID = "link.none-configured"
+	LinkCertificateExpiry     ID = "link.certificate-expiry"
+	LinkCertificateExpired    ID = "link.certificate-expired"
+	LinkCost                  ID = "link.cost"
+	GatewayNoneDefined        ID = "gateway.none-defined"
+	GatewayConnected          ID = "gateway.connected"
+	GatewayNotConnected       ID = "gateway.not-connected"
+	ServiceProtected          ID = "service.protected"
+	ServiceExposed            ID = "service.exposed"
+	ServiceUnexposed          ID = "service.unexposed"
+	TokenWritten              ID = "token.written"
+	TokenLocalOnly            ID = "token.local-only"
+	TokenLimitsAdvisory       ID = "token.limits-advisory"
+	TokenBundleWritten        ID = "token.bundle-written"
+	MetricsApiRegistered      ID = "metrics-api.registered"
+)
+
+var defaults = map[ID]string{
+	SiteNotInitialised:        "Skupper not initialised in %s",
+	SiteNotInstalled:          "Skupper is not installed in Namespace: '%s`",
+	SiteNotEnabled:            "Skupper is not enabled in namespace '%s'",
+	SiteInstalled:             "Skupper is now installed in namespace '%s'.  Use 'skupper status' to get more information.",
+	SiteRemoved:               "Skupper is now removed from '%s'.",
+	SiteUpdated:               "Skupper is now updated in '%s'.",
+	StatusEnabled:             "Skupper is enabled for namespace %q%s%s.",
+	StatusSiteName:            " with site name %q",
+	StatusInteriorMode:        " in interior mode",
+	StatusEdgeMode:            " in edge mode",
+	StatusPending:             " Status pending...",
+	StatusNotConnected:        " It is not connected to any other sites.",
+	StatusConnectedOne:        " It is connected to 1 other site.",
+	StatusConnected:           " It is connected to %d other sites.",
+	StatusConnectedIndirectly: " It is connected to %d other sites (%d indirectly).",
+	StatusNoServices:          " It has no exposed services.",
+	StatusOneService:          " It has 1 exposed service.",
+	StatusServices:            " It has %d exposed services.",
+	StatusConsoleUrl:          "The site console url is:  %s",
+	StatusConsoleCredentials:  "The credentials for internal console-auth mode are held in secret: 'skupper-console-users'",
+	StatusEgressIPs:           "Links from this site leave the cluster from: %s",
+	LinkConfigured:            "Skupper configured to connect to %s:%s (name=%s)",
+	LinkConfiguredBySite:      "Skupper site-controller configured to connect to %s:%s (name=%s)",
+	LinkRemoved:               "Link '%s' has been removed",
+	LinkActive:                "Connection for %s is active",
+	LinkNotActive:             "Connection for %s not active",
+	LinkNoneConfigured:        "There are no connectors configured or active",
+	LinkCertificateExpiry:     "Certificates for %s expire in %d days",
+	LinkCertificateExpired:    "Certificates for %s have expired",
+	LinkCost:                  "Link %s has a cost of %d",
+	GatewayNoneDefined:        "There are no gateways defined",
+	GatewayConnected:          "Gateway %s (%s) is connected to %s",
+	GatewayNotConnected:       "Gateway %s (%s) is not connected to %s",
+	ServiceProtected:          "Service %s is protected, and can only be removed with --force",
+	ServiceExposed:            "%s %s exposed as %s",
+	ServiceUnexposed:          "%s %s unexposed",
+	TokenWritten:              "Connection token written to %s %s",
+	TokenLocalOnly:            "(Note: token will only be valid for local cluster)",
+	TokenLimitsAdvisory:       "(Note: the expiry and uses of a %s token are only checked by the site that uses it; create a %s token to have this site enforce them)",
+	TokenBundleWritten:        "Offline bundle written to %s",
+	MetricsApiRegistered:      "The custom metrics API is now served by '%s'.",
+}
+
+var (
+	lock    sync.RWMutex
+	catalog = map[ID]string{}
+)
+
+// LoadCatalog reads a yaml or json file mapping message ids to format
+// strings. Messages not in the file keep their default text.
+func LoadCatalog(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not read message catalog: %w", err)
+	}
+	loaded := map[ID]string{}
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("Could not parse message catalog %s: %w", path, err)
+	}
+	for id := range loaded {
+		if _, ok := defaults[id]; !ok {
+			return fmt.Errorf("Unknown message id %q in catalog %s", id, path)
+		}
+	}
+	lock.Lock()
+	catalog = loaded
+	lock.Unlock()
+	return nil
+}
+
+func format(id ID) string {
+	lock.RLock()
+	defer lock.RUnlock()
+	if text, ok := catalog[id]; ok {
+		return text
+	}
+	if text, ok := defaults[id]; ok {
+		return text
+	}
+	return string(id)
+}
+
+func Sprintf(id ID, args ...interface{}) string {
+	return fmt.Sprintf(format(id), args...)
+}
+
+// Error is an error whose text comes from the catalog
+type Error struct {
+	ID   ID
+	Args []interface{}
+}
+
+func (e *Error) Error() string {
+	return Sprintf(e.ID, e.Args...)
+}
+
+func Errorf(id ID, args ...interface{}) error {
+	return &Error{ID: id, Args: args}
+}
+
+// Is returns true if the error, or any error it wraps, is the message
+// with the given id
+func Is(err error, id ID) bool {
+	var e *Error
+	return errors.As(err, &e) && e.ID == id
+}
@@ -0,0 +1,38 @@
+package messages
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	assert.Equal(t, Sprintf(LinkRemoved, "conn1"), "Link 'conn1' has been removed")
+
+	dir, err := ioutil.TempDir("", "skupper-messages")
+	assert.Assert(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "catalog.yaml")
+	assert.Assert(t, ioutil.WriteFile(path, []byte("link.removed: \"Verbindung '%s' wurde entfernt\"\n"), 0644))
+	assert.Assert(t, LoadCatalog(path))
+	defer LoadCatalog(os.DevNull)
+
+	assert.Equal(t, Sprintf(LinkRemoved, "conn1"), "Verbindung 'conn1' wurde entfernt")
+	assert.Equal(t, Sprintf(LinkActive, "conn1"), "Connection for conn1 is active")
+
+	assert.Assert(t, ioutil.WriteFile(path, []byte("link.unknown: \"nope\"\n"), 0644))
+	assert.ErrorContains(t, LoadCatalog(path), `Unknown message id "link.unknown"`)
+}
+
+func TestIs(t *testing.T) {
+	err := Errorf(SiteNotInitialised, "east")
+	assert.Error(t, err, "Skupper not initialised in east")
+	assert.Assert(t, Is(err, SiteNotInitialised))
+	assert.Assert(t, Is(fmt.Errorf("Wrapped: %w", err), SiteNotInitialised))
+	assert.Assert(t, !Is(err, SiteNotInstalled))
+	assert.Assert(t, !Is(fmt.Errorf("Skupper not initialised in east"), SiteNotInitialised))
+}
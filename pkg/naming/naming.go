@@ -0,0 +1,161 @@
+// Package naming constructs the names of the kubernetes resources
+// skupper creates, validating them against kubernetes naming rules so
+// that problems are reported rather than silently truncated.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+const (
+	connectorPrefix    string = "conn"
+	proxySuffix        string = "-proxy"
+	sslProfileSuffix   string = "-profile"
+	servicePrefix      string = "service-"
+	hashLength         int    = 8
+	MaxLabelLength     int    = validation.DNS1035LabelMaxLength
+	MaxSubdomainLength int    = validation.DNS1123SubdomainMaxLength
+)
+
+// ValidateLabel checks that the name can be used for resources, such
+// as services, whose names must be DNS-1035 labels
+func ValidateLabel(kind string, name string) error {
+	if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+		return fmt.Errorf("Invalid %s name %q: %s", kind, name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateSubdomain checks that the name can be used for resources,
+// such as secrets, whose names must be DNS-1123 subdomains
+func ValidateSubdomain(kind string, name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("Invalid %s name %q: %s", kind, name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateLinkName checks that the name can be used for both the secret
+// holding a link's token and the volume mounting it in the router
+func ValidateLinkName(name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("Invalid link name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+var invalidLabelCharacters = regexp.MustCompile("[^a-z0-9-]+")
+
+// ServiceName returns the name of the service for an address. Where the
+// address is not itself a valid name, a name is derived from it and
+// suffixed with a hash of the full address. The full address is then
+// carried on the service in the ServiceAddressAnnotation.
+func ServiceName(address string) string {
+	if len(validation.IsDNS1035Label(address)) == 0 {
+		return address
+	}
+	sum := sha256.Sum256([]byte(address))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	prefix := invalidLabelCharacters.ReplaceAllString(strings.ToLower(address), "-")
+	if prefix == "" || prefix[0] < 'a' || prefix[0] > 'z' {
+		prefix = "s" + prefix
+	}
+	if len(prefix) > MaxLabelLength-hashLength-1 {
+		prefix = prefix[:MaxLabelLength-hashLength-1]
+	}
+	return strings.TrimRight(prefix, "-") + "-" + hash
+}
+
+// IsHashedServiceName returns true if the service for the address does
+// not have the address as its name
+func IsHashedServiceName(address string) bool {
+	return ServiceName(address) != address
+}
+
+// AddressForService returns the address a service was created for
+func AddressForService(service metav1.Object) string {
+	if address, ok := service.GetAnnotations()[types.ServiceAddressAnnotation]; ok {
+		return address
+	}
+	return service.GetName()
+}
+
+// ProxyStatefulSetName returns the name of the statefulset proxying a
+// headless service in the site where it was exposed
+func ProxyStatefulSetName(address string) string {
+	return address + proxySuffix
+}
+
+// ValidateAddress checks that the resources needed to expose the
+// address can be created with valid names. Headless services must be
+// named for their address, so the address must be a valid name.
+func ValidateAddress(address string, headless bool) error {
+	if address == "" {
+		return fmt.Errorf("Address must be specified")
+	}
+	if headless {
+		if err := ValidateLabel("service", address); err != nil {
+			return err
+		}
+		if err := ValidateLabel("proxy statefulset", ProxyStatefulSetName(address)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProxyServiceName returns the address a proxy statefulset was created for
+func ProxyServiceName(name string) string {
+	return strings.TrimSuffix(name, proxySuffix)
+}
+
+func SslProfileName(connector string) string {
+	return connector + sslProfileSuffix
+}
+
+// ServiceSslProfileName returns the name of the ssl profile through
+// which the router uses a secret named for TLS by a service
+func ServiceSslProfileName(secret string) string {
+	return servicePrefix + secret + sslProfileSuffix
+}
+
+var connectorNamePattern = regexp.MustCompile("^" + connectorPrefix + "([0-9]+)$")
+
+// NextConnectorName returns the first generated link name that follows
+// all of those already in use
+func NextConnectorName(existing []string) string {
+	max := 1
+	for _, name := range existing {
+		count := connectorNamePattern.FindStringSubmatch(name)
+		if len(count) > 1 {
+			v, _ := strconv.Atoi(count[1])
+			if v >= max {
+				max = v + 1
+			}
+		}
+	}
+	return connectorPrefix + strconv.Itoa(max)
+}
+
+// CheckCollision returns an error if a resource already exists with
+// the intended name but was not created by skupper for the same
+// purpose, as identified by the value of the given label
+func CheckCollision(kind string, existing metav1.Object, label string, value string) error {
+	if existing == nil {
+		return nil
+	}
+	if actual, ok := existing.GetLabels()[label]; ok && actual == value {
+		return nil
+	}
+	return fmt.Errorf("A %s named %q already exists and is not managed by skupper", kind, existing.GetName())
+}
@@ -0,0 +1,90 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestValidateAddress(t *testing.T) {
+	testTable := []struct {
+		name     string
+		address  string
+		headless bool
+		err      string
+	}{
+		{name: "simple", address: "tcp-go-echo"},
+		{name: "empty", address: "", err: "Address must be specified"},
+		{name: "upper-case", address: "TheService"},
+		{name: "too-long", address: strings.Repeat("a", 64)},
+		{name: "headless-upper-case", address: "TheService", headless: true, err: "Invalid service name"},
+		{name: "headless-dotted", address: "my.service", headless: true, err: "Invalid service name"},
+		{name: "headless-too-long", address: strings.Repeat("a", 60), headless: true, err: "Invalid proxy statefulset name"},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateAddress(test.address, test.headless)
+			if test.err == "" {
+				assert.Assert(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	assert.Equal(t, ServiceName("tcp-go-echo"), "tcp-go-echo")
+	assert.Assert(t, !IsHashedServiceName("tcp-go-echo"))
+
+	for _, address := range []string{"TheService", "my.service", "1-service", strings.Repeat("a", 100), strings.Repeat("a", 100) + "b"} {
+		name := ServiceName(address)
+		assert.Assert(t, IsHashedServiceName(address))
+		assert.Assert(t, ValidateLabel("service", name), "invalid name %q for %q", name, address)
+		assert.Equal(t, name, ServiceName(address), "name is not deterministic")
+	}
+	assert.Assert(t, ServiceName(strings.Repeat("a", 100)) != ServiceName(strings.Repeat("a", 100)+"b"))
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ServiceName("my.service"),
+			Annotations: map[string]string{types.ServiceAddressAnnotation: "my.service"},
+		},
+	}
+	assert.Equal(t, AddressForService(service), "my.service")
+	service.ObjectMeta.Annotations = nil
+	assert.Equal(t, AddressForService(service), service.ObjectMeta.Name)
+}
+
+func TestNextConnectorName(t *testing.T) {
+	assert.Equal(t, NextConnectorName(nil), "conn1")
+	assert.Equal(t, NextConnectorName([]string{"conn1", "other", "conn12", "conn3"}), "conn13")
+	assert.Equal(t, NextConnectorName([]string{"myconn7"}), "conn1")
+}
+
+func TestProxyNames(t *testing.T) {
+	assert.Equal(t, ProxyStatefulSetName("db"), "db-proxy")
+	assert.Equal(t, ProxyServiceName(ProxyStatefulSetName("db")), "db")
+}
+
+func TestCheckCollision(t *testing.T) {
+	assert.Assert(t, CheckCollision("secret", nil, "skupper.io/type", "connection-token"))
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "conn1",
+			Labels: map[string]string{"skupper.io/type": "connection-token"},
+		},
+	}
+	assert.Assert(t, CheckCollision("secret", token, "skupper.io/type", "connection-token"))
+	other := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "conn1",
+		},
+	}
+	assert.ErrorContains(t, CheckCollision("secret", other, "skupper.io/type", "connection-token"), "not managed by skupper")
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -94,11 +95,12 @@ func (r Record) AsRecord(field string) Record {
 
 func asTcpEndpoint(record Record) TcpEndpoint {
 	return TcpEndpoint{
-		Name:    record.AsString("name"),
-		Host:    record.AsString("host"),
-		Port:    record.AsString("port"),
-		Address: record.AsString("address"),
-		SiteId:  record.AsString("siteId"),
+		Name:       record.AsString("name"),
+		Host:       record.AsString("host"),
+		Port:       record.AsString("port"),
+		Address:    record.AsString("address"),
+		SiteId:     record.AsString("siteId"),
+		SslProfile: record.AsString("sslProfile"),
 	}
 }
 
@@ -113,11 +115,13 @@ func asHttpEndpoint(record Record) HttpEndpoint {
 		Aggregation:     record.AsString("aggregation"),
 		EventChannel:    record.AsBool("eventChannel"),
 		HostOverride:    record.AsString("hostOverride"),
+		SslProfile:      record.AsString("sslProfile"),
 	}
 }
 
 func asConnection(record Record) Connection {
 	return Connection{
+		Identity:   record.AsString("identity"),
 		Role:       record.AsString("role"),
 		Container:  record.AsString("container"),
 		Host:       record.AsString("host"),
@@ -164,6 +168,8 @@ type AgentPool struct {
 	url    string
 	config *tls.Config
 	pool   chan *Agent
+	lock   sync.Mutex
+	closed bool
 }
 
 func NewAgentPool(url string, config *tls.Config) *AgentPool {
@@ -186,7 +192,11 @@ func (p *AgentPool) Get() (*Agent, error) {
 }
 
 func (p *AgentPool) Put(a *Agent) {
-	if !a.closed {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed && !a.closed {
+		a.Close()
+	} else if !a.closed {
 		select {
 		case p.pool <- a:
 		default:
@@ -195,6 +205,22 @@ func (p *AgentPool) Put(a *Agent) {
 	}
 }
 
+// Close closes the agents in the pool, and any put back in it later, for
+// when the router it connects to is gone
+func (p *AgentPool) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closed = true
+	for {
+		select {
+		case a := <-p.pool:
+			a.Close()
+		default:
+			return
+		}
+	}
+}
+
 func Connect(url string, config *tls.Config) (*Agent, error) {
 	var connection *amqp.Client
 	var err error
@@ -818,6 +844,59 @@ func (a *Agent) GetLocalBridgeConfig() (*BridgeConfig, error) {
 	return &config, nil
 }
 
+// BridgeStatus is the state of a listener or connector of the bridges,
+// as far as the router reports it. Routers that do not count the
+// connections of the bridges report them as zero.
+type BridgeStatus struct {
+	Entity            string
+	Name              string
+	Address           string
+	OperStatus        string
+	ConnectionsOpened int
+	ConnectionErrors  int
+	BytesIn           int
+	BytesOut          int
+}
+
+const (
+	BridgeOperStatusUp   string = "up"
+	BridgeOperStatusDown string = "down"
+)
+
+func asBridgeStatus(entity string, record Record) BridgeStatus {
+	return BridgeStatus{
+		Entity:            entity,
+		Name:              record.AsString("name"),
+		Address:           record.AsString("address"),
+		OperStatus:        record.AsString("operStatus"),
+		ConnectionsOpened: record.AsInt("connectionsOpened"),
+		ConnectionErrors:  record.AsInt("connectionErrors"),
+		BytesIn:           record.AsInt("bytesIn"),
+		BytesOut:          record.AsInt("bytesOut"),
+	}
+}
+
+// IsListener returns true for the tcp and http listeners
+func (s BridgeStatus) IsListener() bool {
+	return strings.HasSuffix(s.Entity, "Listener")
+}
+
+// GetLocalBridgeStatus returns the state of each listener and connector
+// of the bridges on the router
+func (a *Agent) GetLocalBridgeStatus() ([]BridgeStatus, error) {
+	statuses := []BridgeStatus{}
+	for _, entity := range getBridgeTypes() {
+		results, err := a.Query(entity, []string{})
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range results {
+			statuses = append(statuses, asBridgeStatus(entity, record))
+		}
+	}
+	return statuses, nil
+}
+
 func (a *Agent) UpdateLocalBridgeConfig(changes *BridgeConfigDifference) error {
 	for _, deleted := range changes.TcpConnectors.Deleted {
 		if err := a.Delete("org.apache.qpid.dispatch.tcpConnector", deleted); err != nil {
@@ -1068,6 +1147,38 @@ func (a *Agent) GetLocalRouter() (*Router, error) {
 	}
 }
 
+// RouterUsage reports the resources in use by a router. MemoryUsage is
+// zero if the router does not report it.
+type RouterUsage struct {
+	MemoryUsage uint64
+	Connections int
+	Undelivered int
+}
+
+func (a *Agent) GetLocalRouterUsage() (*RouterUsage, error) {
+	usage := &RouterUsage{}
+	routers, err := a.Query("org.apache.qpid.dispatch.router", []string{"memoryUsage"})
+	if err != nil {
+		return nil, err
+	}
+	if len(routers) == 1 {
+		usage.MemoryUsage = routers[0].AsUint64("memoryUsage")
+	}
+	connections, err := a.Query("org.apache.qpid.dispatch.connection", []string{"identity"})
+	if err != nil {
+		return nil, err
+	}
+	usage.Connections = len(connections)
+	links, err := a.Query("org.apache.qpid.dispatch.router.link", []string{"undeliveredCount"})
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		usage.Undelivered += link.AsInt("undeliveredCount")
+	}
+	return usage, nil
+}
+
 func (a *Agent) isEdgeRouter() bool {
 	return a.local.Edge
 }
@@ -35,6 +35,7 @@ type RouterNode struct {
 }
 
 type Connection struct {
+	Identity   string `json:"identity"`
 	Container  string `json:"container"`
 	OperStatus string `json:"operStatus"`
 	Host       string `json:"host"`
@@ -313,6 +314,44 @@ func getLocalRouterId(namespace string, clientset kubernetes.Interface, config *
 	}
 }
 
+// GetConnectionsForPod returns the connections of the router running in
+// the named pod, rather than in whichever router pod is ready
+func GetConnectionsForPod(podName string, namespace string, clientset kubernetes.Interface, config *restclient.Config) ([]Connection, error) {
+	buffer, err := kube.ExecCommandInContainer(get_query("connection"), podName, "router", namespace, clientset, config)
+	if err != nil {
+		return nil, err
+	}
+	results := []Connection{}
+	err = json.Unmarshal(buffer.Bytes(), &results)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse JSON: %s %q", err, buffer.String())
+	}
+	return results, nil
+}
+
+// GetBridgeNamesForPod returns the names of the tcp and http listeners
+// and connectors configured on the router running in the named pod
+func GetBridgeNamesForPod(podName string, namespace string, clientset kubernetes.Interface, config *restclient.Config) ([]string, error) {
+	names := []string{}
+	for _, typename := range []string{"tcpListener", "tcpConnector", "httpListener", "httpConnector"} {
+		buffer, err := kube.ExecCommandInContainer(get_query(typename), podName, "router", namespace, clientset, config)
+		if err != nil {
+			return nil, err
+		}
+		results := []map[string]interface{}{}
+		err = json.Unmarshal(buffer.Bytes(), &results)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse JSON: %s %q", err, buffer.String())
+		}
+		for _, r := range results {
+			if name, ok := r["name"].(string); ok {
+				names = append(names, typename+"/"+name)
+			}
+		}
+	}
+	return names, nil
+}
+
 func router_exec(command []string, namespace string, clientset kubernetes.Interface, config *restclient.Config) (*bytes.Buffer, error) {
 	pod, err := kube.GetReadyPod(namespace, clientset, "router")
 	if err != nil {
@@ -320,3 +359,121 @@ func router_exec(command []string, namespace string, clientset kubernetes.Interf
 	}
 	return kube.ExecCommandInContainer(command, pod.Name, "router", namespace, clientset, config)
 }
+
+type RouterAddress struct {
+	Name                    string `json:"name"`
+	Distribution            string `json:"distribution"`
+	SubscriberCount         int    `json:"subscriberCount"`
+	RemoteCount             int    `json:"remoteCount"`
+	DeliveriesIngress       int    `json:"deliveriesIngress"`
+	DeliveriesEgress        int    `json:"deliveriesEgress"`
+	DeliveriesTransit       int    `json:"deliveriesTransit"`
+	DeliveriesToContainer   int    `json:"deliveriesToContainer"`
+	DeliveriesFromContainer int    `json:"deliveriesFromContainer"`
+}
+
+type RouterLink struct {
+	Identity         string `json:"identity"`
+	LinkName         string `json:"linkName"`
+	LinkType         string `json:"linkType"`
+	LinkDir          string `json:"linkDir"`
+	OwningAddr       string `json:"owningAddr"`
+	Capacity         int    `json:"capacity"`
+	CreditAvailable  int    `json:"creditAvailable"`
+	DeliveryCount    int    `json:"deliveryCount"`
+	UndeliveredCount int    `json:"undeliveredCount"`
+	UnsettledCount   int    `json:"unsettledCount"`
+	PresettledCount  int    `json:"presettledCount"`
+	AcceptedCount    int    `json:"acceptedCount"`
+	RejectedCount    int    `json:"rejectedCount"`
+	ReleasedCount    int    `json:"releasedCount"`
+	ModifiedCount    int    `json:"modifiedCount"`
+}
+
+// isAddress reports whether the router's key for an address, which is
+// prefixed with the address class and for mobile addresses a phase,
+// refers to the given service address
+func isAddress(key string, address string) bool {
+	if key == address {
+		return true
+	}
+	if !strings.HasPrefix(key, "M") {
+		return false
+	}
+	key = key[1:]
+	if key == address {
+		return true
+	}
+	return len(key) > 0 && key[0] >= '0' && key[0] <= '9' && key[1:] == address
+}
+
+func getAddressStats(address string, addresses []RouterAddress, links []RouterLink) *types.AddressStats {
+	var stats *types.AddressStats
+	for _, a := range addresses {
+		if !isAddress(a.Name, address) {
+			continue
+		}
+		if stats == nil {
+			stats = &types.AddressStats{
+				Address:      address,
+				Distribution: a.Distribution,
+				Links:        []types.LinkStats{},
+			}
+		}
+		// a mobile address may be known to the router in more than one phase
+		stats.LocalConsumers += a.SubscriberCount
+		stats.RemoteConsumers += a.RemoteCount
+		stats.DeliveriesIngress += a.DeliveriesIngress
+		stats.DeliveriesEgress += a.DeliveriesEgress
+		stats.DeliveriesTransit += a.DeliveriesTransit
+		stats.DeliveriesToContainer += a.DeliveriesToContainer
+		stats.DeliveriesFromContainer += a.DeliveriesFromContainer
+	}
+	if stats == nil {
+		return nil
+	}
+	for _, l := range links {
+		if l.LinkType != "endpoint" || !isAddress(l.OwningAddr, address) {
+			continue
+		}
+		name := l.LinkName
+		if name == "" {
+			name = l.Identity
+		}
+		stats.Links = append(stats.Links, types.LinkStats{
+			Name:            name,
+			Direction:       l.LinkDir,
+			Capacity:        l.Capacity,
+			CreditAvailable: l.CreditAvailable,
+			Deliveries:      l.DeliveryCount,
+			Settled:         l.PresettledCount + l.AcceptedCount + l.RejectedCount + l.ReleasedCount + l.ModifiedCount,
+			Unsettled:       l.UnsettledCount,
+			Undelivered:     l.UndeliveredCount,
+		})
+	}
+	return stats
+}
+
+// GetAddressStats returns the router's statistics for a service
+// address, or nil if the router does not know the address
+func GetAddressStats(address string, namespace string, clientset kubernetes.Interface, config *restclient.Config) (*types.AddressStats, error) {
+	buffer, err := router_exec(get_query("router.address"), namespace, clientset, config)
+	if err != nil {
+		return nil, err
+	}
+	addresses := []RouterAddress{}
+	err = json.Unmarshal(buffer.Bytes(), &addresses)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse JSON: %s %q", err, buffer.String())
+	}
+	buffer, err = router_exec(get_query("router.link"), namespace, clientset, config)
+	if err != nil {
+		return nil, err
+	}
+	links := []RouterLink{}
+	err = json.Unmarshal(buffer.Bytes(), &links)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse JSON: %s %q", err, buffer.String())
+	}
+	return getAddressStats(address, addresses, links), nil
+}
@@ -0,0 +1,43 @@
+package qdr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestGetAddressStats(t *testing.T) {
+	addresses := []RouterAddress{
+		{Name: "M0cart", Distribution: "balanced", SubscriberCount: 1, RemoteCount: 2, DeliveriesIngress: 10, DeliveriesEgress: 8},
+		{Name: "M1cart", Distribution: "balanced", DeliveriesTransit: 3},
+		{Name: "Mcartography", Distribution: "balanced", DeliveriesIngress: 100},
+		{Name: "Lqdrouter", Distribution: "flood"},
+	}
+	links := []RouterLink{
+		{Identity: "7", LinkName: "cart-in", LinkType: "endpoint", LinkDir: "in", OwningAddr: "M0cart", Capacity: 250, DeliveryCount: 10, AcceptedCount: 6, ReleasedCount: 1, UnsettledCount: 3},
+		{Identity: "8", LinkType: "endpoint", LinkDir: "out", OwningAddr: "M0cart", Capacity: 250, CreditAvailable: 0, DeliveryCount: 8, PresettledCount: 2, UndeliveredCount: 5},
+		{Identity: "9", LinkType: "router-control", LinkDir: "out", OwningAddr: "M0cart"},
+		{Identity: "10", LinkType: "endpoint", LinkDir: "in", OwningAddr: "Mcartography"},
+	}
+	expected := &types.AddressStats{
+		Address:           "cart",
+		Distribution:      "balanced",
+		LocalConsumers:    1,
+		RemoteConsumers:   2,
+		DeliveriesIngress: 10,
+		DeliveriesEgress:  8,
+		DeliveriesTransit: 3,
+		Links: []types.LinkStats{
+			{Name: "cart-in", Direction: "in", Capacity: 250, Deliveries: 10, Settled: 7, Unsettled: 3},
+			{Name: "8", Direction: "out", Capacity: 250, Deliveries: 8, Settled: 2, Undelivered: 5},
+		},
+	}
+	actual := getAddressStats("cart", addresses, links)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+	if actual := getAddressStats("orders", addresses, links); actual != nil {
+		t.Errorf("Expected no stats for unknown address, got %v", actual)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -345,11 +346,12 @@ type Address struct {
 }
 
 type TcpEndpoint struct {
-	Name    string `json:"name,omitempty"`
-	Host    string `json:"host,omitempty"`
-	Port    string `json:"port,omitempty"`
-	Address string `json:"address,omitempty"`
-	SiteId  string `json:"siteId,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Host       string `json:"host,omitempty"`
+	Port       string `json:"port,omitempty"`
+	Address    string `json:"address,omitempty"`
+	SiteId     string `json:"siteId,omitempty"`
+	SslProfile string `json:"sslProfile,omitempty"`
 }
 
 type HttpEndpoint struct {
@@ -362,6 +364,7 @@ type HttpEndpoint struct {
 	Aggregation     string `json:"aggregation,omitempty"`
 	EventChannel    bool   `json:"eventChannel,omitempty"`
 	HostOverride    string `json:"hostOverride,omitempty"`
+	SslProfile      string `json:"sslProfile,omitempty"`
 }
 
 func convert(from interface{}, to interface{}) error {
@@ -391,93 +394,95 @@ func UnmarshalRouterConfig(config string) (RouterConfig, error) {
 			HttpConnectors: map[string]HttpEndpoint{},
 		},
 	}
-	var obj interface{}
-	err := json.Unmarshal([]byte(config), &obj)
+	// the elements are decoded straight into their entity types, rather
+	// than through a generic representation, as the configuration of a
+	// large site can hold thousands of them
+	var elements []json.RawMessage
+	err := json.Unmarshal([]byte(config), &elements)
 	if err != nil {
 		return result, err
 	}
-	elements, ok := obj.([]interface{})
-	if !ok {
-		return result, fmt.Errorf("Invalid JSON for router configuration, expected array at top level got %#v", obj)
+	if elements == nil {
+		return result, fmt.Errorf("Invalid JSON for router configuration, expected array at top level got %s", config)
 	}
 	for _, e := range elements {
-		element, ok := e.([]interface{})
-		if !ok || len(element) != 2 {
-			return result, fmt.Errorf("Invalid JSON for router configuration, expected array with type and value got %#v", e)
+		var element []json.RawMessage
+		if err := json.Unmarshal(e, &element); err != nil || len(element) != 2 {
+			return result, fmt.Errorf("Invalid JSON for router configuration, expected array with type and value got %s", e)
 		}
-		entityType, ok := element[0].(string)
-		if !ok {
-			return result, fmt.Errorf("Invalid JSON for router configuration, expected entity type as string got %#v", element[0])
+		var entityType string
+		if len(element[0]) == 0 || element[0][0] != '"' || json.Unmarshal(element[0], &entityType) != nil {
+			return result, fmt.Errorf("Invalid JSON for router configuration, expected entity type as string got %s", element[0])
 		}
 		switch entityType {
 		case "router":
 			metadata := RouterMetadata{}
-			err = convert(element[1], &metadata)
+			err = json.Unmarshal(element[1], &metadata)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Metadata = metadata
 		case "address":
 			address := Address{}
-			err = convert(element[1], &address)
+			err = json.Unmarshal(element[1], &address)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Addresses[address.Prefix] = address
 		case "connector":
 			connector := Connector{}
-			err = convert(element[1], &connector)
+			err = json.Unmarshal(element[1], &connector)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Connectors[connector.Name] = connector
 		case "listener":
 			listener := Listener{}
-			err = convert(element[1], &listener)
+			err = json.Unmarshal(element[1], &listener)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Listeners[listener.Name] = listener
 		case "sslProfile":
 			sslProfile := SslProfile{}
-			err = convert(element[1], &sslProfile)
+			err = json.Unmarshal(element[1], &sslProfile)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.SslProfiles[sslProfile.Name] = sslProfile
 		case "log":
 			logConfig := LogConfig{}
-			err = convert(element[1], &logConfig)
+			err = json.Unmarshal(element[1], &logConfig)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.LogConfig[logConfig.Module] = logConfig
 		case "tcpConnector":
 			connector := TcpEndpoint{}
-			err = convert(element[1], &connector)
+			err = json.Unmarshal(element[1], &connector)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Bridges.TcpConnectors[connector.Name] = connector
 		case "tcpListener":
 			listener := TcpEndpoint{}
-			err = convert(element[1], &listener)
+			err = json.Unmarshal(element[1], &listener)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Bridges.TcpListeners[listener.Name] = listener
 		case "httpConnector":
 			connector := HttpEndpoint{}
-			err = convert(element[1], &connector)
+			err = json.Unmarshal(element[1], &connector)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Bridges.HttpConnectors[connector.Name] = connector
 		case "httpListener":
 			listener := HttpEndpoint{}
-			err = convert(element[1], &listener)
+			err = json.Unmarshal(element[1], &listener)
 			if err != nil {
-				return result, fmt.Errorf("Invalid %s element got %#v", entityType, element[1])
+				return result, fmt.Errorf("Invalid %s element got %s", entityType, element[1])
 			}
 			result.Bridges.HttpListeners[listener.Name] = listener
 		default:
@@ -487,69 +492,80 @@ func UnmarshalRouterConfig(config string) (RouterConfig, error) {
 }
 
 func MarshalRouterConfig(config RouterConfig) (string, error) {
-	elements := [][]interface{}{}
+	size := 1 + len(config.SslProfiles) + len(config.Connectors) + len(config.Listeners) + len(config.Addresses) + len(config.LogConfig) +
+		len(config.Bridges.TcpConnectors) + len(config.Bridges.TcpListeners) + len(config.Bridges.HttpConnectors) + len(config.Bridges.HttpListeners)
+	elements := make([][]interface{}, 0, size)
 	tuple := []interface{}{
 		"router",
 		config.Metadata,
 	}
 	elements = append(elements, tuple)
-	for _, e := range config.SslProfiles {
+	for _, key := range sortedKeys(config.SslProfiles) {
+		e := config.SslProfiles[key]
 		tuple := []interface{}{
 			"sslProfile",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Connectors {
+	for _, key := range sortedKeys(config.Connectors) {
+		e := config.Connectors[key]
 		tuple := []interface{}{
 			"connector",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Listeners {
+	for _, key := range sortedKeys(config.Listeners) {
+		e := config.Listeners[key]
 		tuple := []interface{}{
 			"listener",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Addresses {
+	for _, key := range sortedKeys(config.Addresses) {
+		e := config.Addresses[key]
 		tuple := []interface{}{
 			"address",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Bridges.TcpConnectors {
+	for _, key := range sortedKeys(config.Bridges.TcpConnectors) {
+		e := config.Bridges.TcpConnectors[key]
 		tuple := []interface{}{
 			"tcpConnector",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Bridges.TcpListeners {
+	for _, key := range sortedKeys(config.Bridges.TcpListeners) {
+		e := config.Bridges.TcpListeners[key]
 		tuple := []interface{}{
 			"tcpListener",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Bridges.HttpConnectors {
+	for _, key := range sortedKeys(config.Bridges.HttpConnectors) {
+		e := config.Bridges.HttpConnectors[key]
 		tuple := []interface{}{
 			"httpConnector",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.Bridges.HttpListeners {
+	for _, key := range sortedKeys(config.Bridges.HttpListeners) {
+		e := config.Bridges.HttpListeners[key]
 		tuple := []interface{}{
 			"httpListener",
 			e,
 		}
 		elements = append(elements, tuple)
 	}
-	for _, e := range config.LogConfig {
+	for _, key := range sortedKeys(config.LogConfig) {
+		e := config.LogConfig[key]
 		tuple := []interface{}{
 			"log",
 			e,
@@ -563,6 +579,18 @@ func MarshalRouterConfig(config RouterConfig) (string, error) {
 	return string(data), nil
 }
 
+// sortedKeys returns the keys of a map indexed by string in order, so
+// that the marshalled configuration is stable across calls
+func sortedKeys(m interface{}) []string {
+	value := reflect.ValueOf(m)
+	keys := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func AsConfigMapData(config string) map[string]string {
 	return map[string]string{
 		types.TransportConfigFile: config,
@@ -591,7 +619,7 @@ func (r *RouterConfig) UpdateConfigMap(configmap *corev1.ConfigMap) (bool, error
 		if err != nil {
 			return false, err
 		}
-		if reflect.DeepEqual(existing, r) {
+		if reflect.DeepEqual(existing, *r) {
 			return false, nil
 		}
 	}
@@ -608,7 +636,7 @@ func (b *BridgeConfig) UpdateConfigMap(configmap *corev1.ConfigMap) (bool, error
 		if err != nil {
 			return false, err
 		}
-		if reflect.DeepEqual(existing.Bridges, b) {
+		if reflect.DeepEqual(existing.Bridges, *b) {
 			return false, nil
 		} else {
 			existing.Bridges = *b
@@ -624,8 +652,10 @@ func (b *BridgeConfig) UpdateConfigMap(configmap *corev1.ConfigMap) (bool, error
 }
 
 func GetRouterConfigFromConfigMap(configmap *corev1.ConfigMap) (*RouterConfig, error) {
-	if configmap.Data == nil || configmap.Data[types.TransportConfigFile] == "" {
-		return nil, nil
+	if configmap == nil {
+		return nil, fmt.Errorf("No router configuration found")
+	} else if configmap.Data == nil || configmap.Data[types.TransportConfigFile] == "" {
+		return nil, fmt.Errorf("No router configuration found in %s", configmap.ObjectMeta.Name)
 	} else {
 		routerConfig, err := UnmarshalRouterConfig(configmap.Data[types.TransportConfigFile])
 		if err != nil {
@@ -683,7 +713,8 @@ func (a TcpEndpointMap) Difference(b TcpEndpointMap) TcpEndpointDifference {
 func (a HttpEndpoint) Equivalent(b HttpEndpoint) bool {
 	if a.Host != b.Host || a.Port != b.Port || a.Address != b.Address ||
 		a.SiteId != b.SiteId || a.Aggregation != b.Aggregation ||
-		a.EventChannel != b.EventChannel || a.HostOverride != b.HostOverride {
+		a.EventChannel != b.EventChannel || a.HostOverride != b.HostOverride ||
+		a.SslProfile != b.SslProfile {
 		return false
 	}
 	if a.ProtocolVersion == HttpVersion2 && b.ProtocolVersion != HttpVersion2 {
@@ -759,9 +790,10 @@ func GetRouterConfigForHeadlessProxy(definition types.ServiceInterface, siteId s
 		Host: "localhost",
 		Port: 5672,
 	})
-	port := definition.Port
-	if len(definition.Targets) == 1 && definition.Targets[0].TargetPort != 0 {
-		port = definition.Targets[0].TargetPort
+	// headless services are only exposed on their primary port
+	port := definition.PrimaryPort()
+	if len(definition.Targets) == 1 {
+		port = definition.Targets[0].TargetPortFor(port)
 	}
 	if definition.Origin == "" {
 		host := definition.Headless.Name + "-${POD_ID}." + definition.Address + "." + namespace
@@ -784,7 +816,7 @@ func GetRouterConfigForHeadlessProxy(definition types.ServiceInterface, siteId s
 				Address: address,
 				SiteId:  siteId,
 			})
-		case "http2":
+		case "http2", "grpc":
 			config.AddHttpConnector(HttpEndpoint{
 				Name:            "egress",
 				Host:            host,
@@ -816,7 +848,7 @@ func GetRouterConfigForHeadlessProxy(definition types.ServiceInterface, siteId s
 				Address: address,
 				SiteId:  siteId,
 			})
-		case "http2":
+		case "http2", "grpc":
 			config.AddHttpListener(HttpEndpoint{
 				Name:            "ingress",
 				Host:            host,
@@ -0,0 +1,43 @@
+//go:build go1.18
+// +build go1.18
+
+package qdr
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// FuzzGetRouterConfigFromConfigMap checks that no router configuration,
+// however malformed, panics and that anything accepted can be written
+// back out and read again
+func FuzzGetRouterConfigFromConfigMap(f *testing.F) {
+	initial, _ := MarshalRouterConfig(InitialConfig("foo", "bar", "1.2.3", false, 3))
+	f.Add(initial)
+	f.Add(`[["router", {"id": "foo", "mode": "edge"}], ["tcpListener", {"name": "l1", "port": "8080"}]]`)
+	f.Add(`[["address", ["wrong"]]]`)
+	f.Add(`[["log", null], ["sslProfile", {}]]`)
+	f.Add(`{"foo":"bar"}`)
+	f.Fuzz(func(t *testing.T, data string) {
+		configmap := &corev1.ConfigMap{
+			Data: map[string]string{
+				types.TransportConfigFile: data,
+			},
+		}
+		config, err := GetRouterConfigFromConfigMap(configmap)
+		if err != nil {
+			return
+		}
+		config.GetSiteMetadata()
+		marshalled, err := MarshalRouterConfig(*config)
+		if err != nil {
+			t.Fatalf("Failed to marshal accepted config %q: %v", data, err)
+		}
+		if _, err := UnmarshalRouterConfig(marshalled); err != nil {
+			t.Fatalf("Failed to unmarshal %q, marshalled from %q: %v", marshalled, data, err)
+		}
+	})
+}
@@ -1,8 +1,12 @@
 package qdr
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestInitialConfig(t *testing.T) {
@@ -416,6 +420,18 @@ func TestMarshalUnmarshalRouterConfigWithLogging(t *testing.T) {
 	checkLevel(t, &input, "DEFAULT", "debug+")
 }
 
+func TestGetRouterConfigFromConfigMapMissing(t *testing.T) {
+	if _, err := GetRouterConfigFromConfigMap(nil); err == nil {
+		t.Errorf("Expected error for nil configmap")
+	}
+	if _, err := GetRouterConfigFromConfigMap(&corev1.ConfigMap{}); err == nil {
+		t.Errorf("Expected error for configmap without router configuration")
+	}
+	if _, err := GetBridgeConfigFromConfigMap(&corev1.ConfigMap{}); err == nil {
+		t.Errorf("Expected error for configmap without bridge configuration")
+	}
+}
+
 func TestFailedConvert(t *testing.T) {
 	a := []string{"random"}
 	b := SslProfile{}
@@ -424,3 +440,129 @@ func TestFailedConvert(t *testing.T) {
 		t.Errorf("Expected error for invalid conversion")
 	}
 }
+
+// largeRouterConfig returns the configuration of a site with the given
+// number of tcp and http services, each with a listener and a connector
+func largeRouterConfig(services int) RouterConfig {
+	config := InitialConfig("router", "site", "1.2.3", false, 3)
+	for i := 0; i < services; i++ {
+		address := fmt.Sprintf("service-%d", i)
+		config.AddTcpListener(TcpEndpoint{
+			Name:    address + "-listener",
+			Host:    "0.0.0.0",
+			Port:    strconv.Itoa(1024 + i),
+			Address: address,
+			SiteId:  "site",
+		})
+		config.AddTcpConnector(TcpEndpoint{
+			Name:    address + "-connector",
+			Host:    "10.0.0.1",
+			Port:    "8080",
+			Address: address,
+			SiteId:  "site",
+		})
+		config.AddHttpListener(HttpEndpoint{
+			Name:    address + "-http-listener",
+			Host:    "0.0.0.0",
+			Port:    strconv.Itoa(20000 + i),
+			Address: address + "-http",
+			SiteId:  "site",
+		})
+		config.AddHttpConnector(HttpEndpoint{
+			Name:    address + "-http-connector",
+			Host:    "10.0.0.2",
+			Port:    "8080",
+			Address: address + "-http",
+			SiteId:  "site",
+		})
+	}
+	return config
+}
+
+func TestUpdateConfigMapUnchanged(t *testing.T) {
+	config := largeRouterConfig(2)
+	configmap := &corev1.ConfigMap{}
+	if err := config.WriteToConfigMap(configmap); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := config.UpdateConfigMap(configmap); err != nil || updated {
+		t.Errorf("Expected no update for unchanged router config, got %v (%v)", updated, err)
+	}
+	if updated, err := config.Bridges.UpdateConfigMap(configmap); err != nil || updated {
+		t.Errorf("Expected no update for unchanged bridge config, got %v (%v)", updated, err)
+	}
+	config.AddTcpListener(TcpEndpoint{Name: "extra", Port: "9999", Address: "extra"})
+	if updated, err := config.Bridges.UpdateConfigMap(configmap); err != nil || !updated {
+		t.Errorf("Expected update for changed bridge config, got %v (%v)", updated, err)
+	}
+}
+
+// The allocation budgets are per bridge endpoint for a site with 1000
+// services. Reading the configuration back to check for changes must
+// stay well below the cost of marshalling it again.
+func TestRouterConfigAllocationBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation budget in short mode")
+	}
+	config := largeRouterConfig(1000)
+	endpoints := float64(4000)
+	data, err := MarshalRouterConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configmap := &corev1.ConfigMap{Data: AsConfigMapData(data)}
+	budgets := []struct {
+		name   string
+		budget float64
+		run    func()
+	}{
+		{"marshal", 10, func() { MarshalRouterConfig(config) }},
+		{"unmarshal", 15, func() { UnmarshalRouterConfig(data) }},
+		{"unchanged bridge update", 18, func() { config.Bridges.UpdateConfigMap(configmap) }},
+	}
+	for _, b := range budgets {
+		allocs := testing.AllocsPerRun(3, b.run) / endpoints
+		if allocs > b.budget {
+			t.Errorf("%s: %.1f allocations per bridge endpoint, budget is %.0f", b.name, allocs, b.budget)
+		}
+	}
+}
+
+func BenchmarkMarshalRouterConfig(b *testing.B) {
+	config := largeRouterConfig(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := MarshalRouterConfig(config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalRouterConfig(b *testing.B) {
+	data, err := MarshalRouterConfig(largeRouterConfig(1000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalRouterConfig(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBridgeConfigUpdateConfigMapUnchanged(b *testing.B) {
+	config := largeRouterConfig(1000)
+	configmap := &corev1.ConfigMap{}
+	if err := config.WriteToConfigMap(configmap); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := config.Bridges.UpdateConfigMap(configmap); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
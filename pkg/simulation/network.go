@@ -0,0 +1,188 @@
+// Package simulation models a network of skupper sites in memory, so
+// that logic spanning several sites (service sync, links, topology)
+// can be tested without clusters. Each site has a VanClient backed by a
+// fake clientset; messages published on the service sync address reach
+// every site connected to the publisher through any chain of links.
+package simulation
+
+import (
+	jsonencoding "encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// A SyncHandler receives the service definitions published by a site
+type SyncHandler func(origin string, value string)
+
+type Site struct {
+	Name     string
+	Client   *client.VanClient
+	network  *Network
+	handlers []SyncHandler
+}
+
+type Network struct {
+	sites map[string]*Site
+	links map[string]map[string]bool
+	// Delivered counts the service sync messages delivered to sites
+	Delivered int
+}
+
+func NewNetwork() *Network {
+	return &Network{
+		sites: map[string]*Site{},
+		links: map[string]map[string]bool{},
+	}
+}
+
+// AddSite creates a site with an empty skupper-services ConfigMap in a
+// namespace of the same name
+func (n *Network) AddSite(name string) (*Site, error) {
+	if _, ok := n.sites[name]; ok {
+		return nil, fmt.Errorf("Site %s already exists", name)
+	}
+	site := &Site{
+		Name: name,
+		Client: &client.VanClient{
+			Namespace: name,
+			KubeClient: fake.NewSimpleClientset(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: types.ServiceInterfaceConfigMap, Namespace: name},
+				Data:       map[string]string{},
+			}),
+		},
+		network: n,
+	}
+	n.sites[name] = site
+	n.links[name] = map[string]bool{}
+	return site, nil
+}
+
+func (n *Network) Site(name string) *Site {
+	return n.sites[name]
+}
+
+// Sites returns all sites in name order
+func (n *Network) Sites() []*Site {
+	names := []string{}
+	for name := range n.sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sites := []*Site{}
+	for _, name := range names {
+		sites = append(sites, n.sites[name])
+	}
+	return sites
+}
+
+// Link connects two sites. Links carry traffic in both directions
+// regardless of which site created them.
+func (n *Network) Link(a string, b string) error {
+	if _, ok := n.sites[a]; !ok {
+		return fmt.Errorf("No such site %s", a)
+	}
+	if _, ok := n.sites[b]; !ok {
+		return fmt.Errorf("No such site %s", b)
+	}
+	if a == b {
+		return fmt.Errorf("Site %s cannot link to itself", a)
+	}
+	n.links[a][b] = true
+	n.links[b][a] = true
+	return nil
+}
+
+func (n *Network) Unlink(a string, b string) {
+	delete(n.links[a], b)
+	delete(n.links[b], a)
+}
+
+func (n *Network) Linked(a string, b string) bool {
+	return n.links[a][b]
+}
+
+// Reachable returns the names of the sites that can be reached from the
+// named site, including the site itself
+func (n *Network) Reachable(name string) map[string]bool {
+	reached := map[string]bool{}
+	if _, ok := n.sites[name]; !ok {
+		return reached
+	}
+	pending := []string{name}
+	reached[name] = true
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		for next := range n.links[current] {
+			if !reached[next] {
+				reached[next] = true
+				pending = append(pending, next)
+			}
+		}
+	}
+	return reached
+}
+
+// Subscribe registers a handler for the service sync address. As with
+// the router, a site receives the messages it publishes itself.
+func (s *Site) Subscribe(handler SyncHandler) {
+	s.handlers = append(s.handlers, handler)
+}
+
+// Publish delivers a message on the service sync address to every
+// reachable site
+func (s *Site) Publish(value string) {
+	reachable := s.network.Reachable(s.Name)
+	for _, site := range s.network.Sites() {
+		if !reachable[site.Name] {
+			continue
+		}
+		for _, handler := range site.handlers {
+			handler(s.Name, value)
+			s.network.Delivered++
+		}
+	}
+}
+
+// Services returns the service definitions in the site's
+// skupper-services ConfigMap
+func (s *Site) Services() (map[string]types.ServiceInterface, error) {
+	cm, err := s.Client.KubeClient.CoreV1().ConfigMaps(s.Name).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	services := map[string]types.ServiceInterface{}
+	for address, data := range cm.Data {
+		service := types.ServiceInterface{}
+		if err := jsonencoding.Unmarshal([]byte(data), &service); err != nil {
+			return nil, fmt.Errorf("Invalid definition for %s in site %s: %s", address, s.Name, err)
+		}
+		services[address] = service
+	}
+	return services, nil
+}
+
+// ServiceConfigMap returns the site's skupper-services ConfigMap
+func (s *Site) ServiceConfigMap() (*corev1.ConfigMap, error) {
+	return s.Client.KubeClient.CoreV1().ConfigMaps(s.Name).Get(types.ServiceInterfaceConfigMap, metav1.GetOptions{})
+}
+
+// Expose defines a service at the site, as 'skupper service create'
+// would
+func (s *Site) Expose(service types.ServiceInterface) error {
+	service.Origin = ""
+	return kube.UpdateSkupperServices([]types.ServiceInterface{service}, nil, "", s.Name, s.Client.KubeClient)
+}
+
+// Unexpose removes a service defined at the site
+func (s *Site) Unexpose(address string) error {
+	return kube.UpdateSkupperServices(nil, []string{address}, "", s.Name, s.Client.KubeClient)
+}
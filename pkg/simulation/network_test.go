@@ -0,0 +1,57 @@
+package simulation
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestPublishReachesLinkedSites(t *testing.T) {
+	network := NewNetwork()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		_, err := network.AddSite(name)
+		assert.NilError(t, err)
+	}
+	received := map[string][]string{}
+	for _, site := range network.Sites() {
+		name := site.Name
+		site.Subscribe(func(origin string, value string) {
+			received[name] = append(received[name], origin+":"+value)
+		})
+	}
+	assert.NilError(t, network.Link("a", "b"))
+	assert.NilError(t, network.Link("c", "b"))
+	assert.Error(t, network.Link("a", "a"), "Site a cannot link to itself")
+	assert.Error(t, network.Link("a", "e"), "No such site e")
+
+	network.Site("a").Publish("hello")
+	assert.DeepEqual(t, received, map[string][]string{
+		"a": {"a:hello"},
+		"b": {"a:hello"},
+		"c": {"a:hello"},
+	})
+
+	network.Unlink("b", "c")
+	network.Site("c").Publish("bye")
+	assert.DeepEqual(t, received["c"], []string{"a:hello", "c:bye"})
+	assert.Equal(t, len(received["a"]), 1)
+	assert.Equal(t, network.Delivered, 4)
+}
+
+func TestExposeAndUnexpose(t *testing.T) {
+	network := NewNetwork()
+	site, err := network.AddSite("a")
+	assert.NilError(t, err)
+	assert.NilError(t, site.Expose(types.ServiceInterface{Address: "db", Protocol: "tcp", Ports: []types.ServicePort{{Port: 5432}}, Origin: "elsewhere"}))
+	services, err := site.Services()
+	assert.NilError(t, err)
+	assert.Equal(t, services["db"].PrimaryPort(), 5432)
+	assert.Equal(t, services["db"].Origin, "")
+
+	assert.NilError(t, site.Unexpose("db"))
+	services, err = site.Services()
+	assert.NilError(t, err)
+	assert.Equal(t, len(services), 0)
+}
@@ -0,0 +1,124 @@
+package statestore
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+const (
+	SchemaLabel             string = types.InternalQualifier + "/state-schema"
+	SchemaVersionAnnotation string = types.InternalQualifier + "/state-schema-version"
+)
+
+// configMapStore keeps each record in a ConfigMap of the same name,
+// relying on the resource version of the ConfigMap to detect concurrent
+// writes
+type configMapStore struct {
+	namespace string
+	client    kubernetes.Interface
+	owners    []metav1.OwnerReference
+}
+
+// NewConfigMapStore returns a store keeping records in ConfigMaps in the
+// namespace. ConfigMaps created by the store have the given owners.
+func NewConfigMapStore(namespace string, client kubernetes.Interface, owners []metav1.OwnerReference) Store {
+	return &configMapStore{
+		namespace: namespace,
+		client:    client,
+		owners:    owners,
+	}
+}
+
+func recordFromConfigMap(cm *corev1.ConfigMap) *Record {
+	record := &Record{
+		Name:     cm.ObjectMeta.Name,
+		Schema:   cm.ObjectMeta.Labels[SchemaLabel],
+		Data:     cm.Data,
+		Revision: cm.ObjectMeta.ResourceVersion,
+	}
+	record.Version, _ = strconv.Atoi(cm.ObjectMeta.Annotations[SchemaVersionAnnotation])
+	return record
+}
+
+func setSchema(cm *corev1.ConfigMap, record *Record) {
+	if record.Schema == "" {
+		return
+	}
+	if cm.ObjectMeta.Labels == nil {
+		cm.ObjectMeta.Labels = map[string]string{}
+	}
+	if cm.ObjectMeta.Annotations == nil {
+		cm.ObjectMeta.Annotations = map[string]string{}
+	}
+	cm.ObjectMeta.Labels[SchemaLabel] = record.Schema
+	cm.ObjectMeta.Annotations[SchemaVersionAnnotation] = strconv.Itoa(record.Version)
+}
+
+func (s *configMapStore) Get(name string) (*Record, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return recordFromConfigMap(cm), nil
+}
+
+func (s *configMapStore) Create(record *Record) error {
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            record.Name,
+			OwnerReferences: s.owners,
+		},
+		Data: record.Data,
+	}
+	setSchema(cm, record)
+	created, err := s.client.CoreV1().ConfigMaps(s.namespace).Create(cm)
+	if errors.IsAlreadyExists(err) {
+		return ErrExists
+	} else if err != nil {
+		return err
+	}
+	record.Revision = created.ObjectMeta.ResourceVersion
+	return nil
+}
+
+func (s *configMapStore) Update(record *Record) error {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(record.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if cm.ObjectMeta.ResourceVersion != record.Revision {
+		return ErrConflict
+	}
+	cm.Data = record.Data
+	setSchema(cm, record)
+	updated, err := s.client.CoreV1().ConfigMaps(s.namespace).Update(cm)
+	if errors.IsConflict(err) {
+		return ErrConflict
+	} else if err != nil {
+		return err
+	}
+	record.Revision = updated.ObjectMeta.ResourceVersion
+	return nil
+}
+
+func (s *configMapStore) Delete(name string) error {
+	err := s.client.CoreV1().ConfigMaps(s.namespace).Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package statestore
+
+import (
+	"strconv"
+	"sync"
+)
+
+// memoryStore keeps records for the life of the process only
+type memoryStore struct {
+	lock     sync.Mutex
+	records  map[string]Record
+	revision int
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records: map[string]Record{},
+	}
+}
+
+func copyData(data map[string]string) map[string]string {
+	if data == nil {
+		return nil
+	}
+	result := map[string]string{}
+	for key, value := range data {
+		result[key] = value
+	}
+	return result
+}
+
+func (s *memoryStore) store(record *Record) {
+	s.revision++
+	record.Revision = strconv.Itoa(s.revision)
+	stored := *record
+	stored.Data = copyData(record.Data)
+	s.records[record.Name] = stored
+}
+
+func (s *memoryStore) Get(name string) (*Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored, ok := s.records[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	record := stored
+	record.Data = copyData(stored.Data)
+	return &record, nil
+}
+
+func (s *memoryStore) Create(record *Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.records[record.Name]; ok {
+		return ErrExists
+	}
+	s.store(record)
+	return nil
+}
+
+func (s *memoryStore) Update(record *Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored, ok := s.records[record.Name]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Revision != record.Revision {
+		return ErrConflict
+	}
+	s.store(record)
+	return nil
+}
+
+func (s *memoryStore) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.records[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records, name)
+	return nil
+}
@@ -0,0 +1,80 @@
+// Package statestore holds the records a site keeps for its own
+// bookkeeping, such as the progress of an update, behind an interface so
+// that each kind of record need not invent its own storage format.
+package statestore
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound = errors.New("state record not found")
+	ErrExists   = errors.New("state record already exists")
+	// ErrConflict is returned when a record is written after it was
+	// changed by someone else since it was read
+	ErrConflict = errors.New("state record was changed concurrently")
+)
+
+// Record is a named set of values, of a given schema and version of that
+// schema. The revision is set by the store, and is used to refuse writes
+// based on a stale read.
+type Record struct {
+	Name     string
+	Schema   string
+	Version  int
+	Data     map[string]string
+	Revision string
+}
+
+// Check returns an error if the record is not of the given schema or is
+// of a later version of it than the caller understands. Records written
+// before their schema was recorded are accepted as the first version.
+func (r *Record) Check(schema string, version int) error {
+	if r.Schema != "" && r.Schema != schema {
+		return fmt.Errorf("State record %s has schema %s, expected %s", r.Name, r.Schema, schema)
+	}
+	if r.Version > version {
+		return fmt.Errorf("State record %s has version %d of schema %s, only up to %d is supported", r.Name, r.Version, schema, version)
+	}
+	return nil
+}
+
+type Store interface {
+	// Get returns the named record, or ErrNotFound
+	Get(name string) (*Record, error)
+	// Create stores a new record, or returns ErrExists
+	Create(record *Record) error
+	// Update replaces a record read from the store, or returns
+	// ErrConflict if it has changed since
+	Update(record *Record) error
+	// Delete removes the named record, or returns ErrNotFound
+	Delete(name string) error
+}
+
+const maxModifyAttempts = 5
+
+// Modify reads the named record, applies the change and writes it back,
+// retrying with a fresh read if the record was changed concurrently, so
+// that writers in different processes do not overwrite each other
+func Modify(store Store, name string, change func(record *Record) error) error {
+	var err error
+	for i := 0; i < maxModifyAttempts; i++ {
+		var record *Record
+		record, err = store.Get(name)
+		if err != nil {
+			return err
+		}
+		if record.Data == nil {
+			record.Data = map[string]string{}
+		}
+		if err = change(record); err != nil {
+			return err
+		}
+		err = store.Update(record)
+		if err != ErrConflict {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package statestore
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testStore(t *testing.T, store Store) {
+	_, err := store.Get("progress")
+	assert.Equal(t, err, ErrNotFound)
+
+	record := &Record{
+		Name:    "progress",
+		Schema:  "test",
+		Version: 2,
+		Data:    map[string]string{"step": "1"},
+	}
+	assert.NilError(t, store.Create(record))
+	assert.Equal(t, store.Create(&Record{Name: "progress"}), ErrExists)
+
+	first, err := store.Get("progress")
+	assert.NilError(t, err)
+	assert.Equal(t, first.Schema, "test")
+	assert.Equal(t, first.Version, 2)
+	assert.DeepEqual(t, first.Data, map[string]string{"step": "1"})
+	assert.NilError(t, first.Check("test", 2))
+	assert.ErrorContains(t, first.Check("test", 1), "only up to 1 is supported")
+	assert.ErrorContains(t, first.Check("other", 2), "expected other")
+
+	err = Modify(store, "progress", func(record *Record) error {
+		record.Data["step"] = "2"
+		return nil
+	})
+	assert.NilError(t, err)
+	current, err := store.Get("progress")
+	assert.NilError(t, err)
+	assert.Equal(t, current.Data["step"], "2")
+
+	assert.NilError(t, store.Delete("progress"))
+	assert.Equal(t, store.Delete("progress"), ErrNotFound)
+	assert.Equal(t, Modify(store, "progress", func(record *Record) error { return nil }), ErrNotFound)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	testStore(t, store)
+
+	// a write based on a stale read is refused
+	assert.NilError(t, store.Create(&Record{Name: "progress"}))
+	stale, err := store.Get("progress")
+	assert.NilError(t, err)
+	assert.NilError(t, Modify(store, "progress", func(record *Record) error {
+		record.Data["step"] = "1"
+		return nil
+	}))
+	stale.Data = map[string]string{"step": "0"}
+	assert.Equal(t, store.Update(stale), ErrConflict)
+
+	// concurrent changes are retried on a fresh read
+	attempts := 0
+	err = Modify(store, "progress", func(record *Record) error {
+		attempts++
+		if attempts == 1 {
+			assert.NilError(t, Modify(store, "progress", func(record *Record) error {
+				record.Data["other"] = "true"
+				return nil
+			}))
+		}
+		record.Data["step"] = "2"
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, attempts, 2)
+	current, err := store.Get("progress")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, current.Data, map[string]string{"step": "2", "other": "true"})
+}
+
+func TestConfigMapStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	owners := []metav1.OwnerReference{{Kind: "Deployment", Name: "skupper-router"}}
+	testStore(t, NewConfigMapStore("skupper", client, owners))
+
+	store := NewConfigMapStore("skupper", client, owners)
+	assert.NilError(t, store.Create(&Record{Name: "progress", Schema: "test", Version: 1}))
+	cm, err := client.CoreV1().ConfigMaps("skupper").Get("progress", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, cm.ObjectMeta.Labels[SchemaLabel], "test")
+	assert.Equal(t, cm.ObjectMeta.Annotations[SchemaVersionAnnotation], "1")
+	assert.DeepEqual(t, cm.ObjectMeta.OwnerReferences, owners)
+
+	// ConfigMaps written before the store existed are read as records
+	// without a schema
+	_, err = client.CoreV1().ConfigMaps("skupper").Create(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy"},
+		Data:       map[string]string{"from": "0.7.0"},
+	})
+	assert.NilError(t, err)
+	legacy, err := store.Get("legacy")
+	assert.NilError(t, err)
+	assert.Equal(t, legacy.Version, 0)
+	assert.NilError(t, legacy.Check("test", 1))
+	assert.Equal(t, legacy.Data["from"], "0.7.0")
+}
@@ -0,0 +1,117 @@
+// Package tunnel makes TCP connections through an HTTP proxy using the
+// CONNECT method, for sites that can only reach others through one
+package tunnel
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Proxy is an HTTP proxy at Address, with the credentials for it if it
+// requires basic authentication
+type Proxy struct {
+	Address  string
+	User     string
+	Password string
+	Timeout  time.Duration
+}
+
+// bufferedConn returns data the proxy sent after its response before
+// reading from the connection
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *bufferedConn) CloseWrite() error {
+	if tcp, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return tcp.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// Dial connects to the target, given as host:port, through the proxy
+func (p *Proxy) Dial(target string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", p.Address, p.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("Could not connect to proxy %s: %s", p.Address, err)
+	}
+	if p.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(p.Timeout))
+	}
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: http.Header{},
+	}
+	if p.User != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(p.User + ":" + p.Password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Could not send request to proxy %s: %s", p.Address, err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Could not read response from proxy %s: %s", p.Address, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("Proxy %s refused connection to %s: %s", p.Address, target, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// Forward connects each connection accepted on the listener to the
+// target through the proxy, until the listener is closed
+func (p *Proxy) Forward(listener net.Listener, target string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.forward(conn, target)
+	}
+}
+
+func (p *Proxy) forward(conn net.Conn, target string) {
+	defer conn.Close()
+	upstream, err := p.Dial(target)
+	if err != nil {
+		log.Printf("Could not forward connection to %s: %s", target, err)
+		return
+	}
+	defer upstream.Close()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	copyAndClose := func(to net.Conn, from net.Conn) {
+		defer wg.Done()
+		io.Copy(to, from)
+		if tcp, ok := to.(interface{ CloseWrite() error }); ok {
+			tcp.CloseWrite()
+		} else {
+			to.Close()
+		}
+	}
+	go copyAndClose(upstream, conn)
+	go copyAndClose(conn, upstream)
+	wg.Wait()
+}
@@ -0,0 +1,115 @@
+package tunnel
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// echo accepts connections and writes back whatever they send
+func echo(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+// connectProxy handles CONNECT requests, requiring the credentials if
+// given
+func connectProxy(t *testing.T, user string, password string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				request, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || request.Method != http.MethodConnect {
+					return
+				}
+				if user != "" {
+					expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+					if request.Header.Get("Proxy-Authorization") != expected {
+						conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+						return
+					}
+				}
+				upstream, err := net.Dial("tcp", request.Host)
+				if err != nil {
+					conn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return listener
+}
+
+func roundTrip(t *testing.T, conn net.Conn, message string) string {
+	_, err := conn.Write([]byte(message))
+	assert.Assert(t, err)
+	reply := make([]byte, len(message))
+	_, err = io.ReadFull(conn, reply)
+	assert.Assert(t, err)
+	return string(reply)
+}
+
+func TestDial(t *testing.T) {
+	target := echo(t)
+	defer target.Close()
+	server := connectProxy(t, "alice", "secret")
+	defer server.Close()
+
+	proxy := &Proxy{Address: server.Addr().String(), User: "alice", Password: "secret", Timeout: 5 * time.Second}
+	conn, err := proxy.Dial(target.Addr().String())
+	assert.Assert(t, err)
+	defer conn.Close()
+	assert.Equal(t, roundTrip(t, conn, "hello"), "hello")
+
+	proxy.Password = "wrong"
+	_, err = proxy.Dial(target.Addr().String())
+	assert.ErrorContains(t, err, "407")
+}
+
+func TestForward(t *testing.T) {
+	target := echo(t)
+	defer target.Close()
+	server := connectProxy(t, "", "")
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	proxy := &Proxy{Address: server.Addr().String(), Timeout: 5 * time.Second}
+	go proxy.Forward(listener, target.Addr().String())
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Assert(t, err)
+	defer conn.Close()
+	assert.Equal(t, roundTrip(t, conn, "over the proxy"), "over the proxy")
+}
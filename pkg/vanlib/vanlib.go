@@ -0,0 +1,246 @@
+// Package vanlib lets a Go application send to and receive from
+// addresses on a skupper network directly over AMQP, using a
+// connection token in place of a local router.
+//
+// The messages are plain AMQP, sent through the edge listener named in
+// the token, so they only reach other AMQP clients attached to the same
+// address, such as another application using this package. They do not
+// go through the tcp and http bridges of the router, so an address of a
+// service exposed with 'skupper expose' or 'skupper service create'
+// cannot be dialled or served this way.
+package vanlib
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	amqp "github.com/interconnectedcloud/go-amqp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ReadToken loads a connection token as written by 'skupper token create'
+func ReadToken(tokenFile string) (*corev1.Secret, error) {
+	yaml, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read connection token: %w", err)
+	}
+	return ParseToken(yaml)
+}
+
+func ParseToken(data []byte) (*corev1.Secret, error) {
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	var secret corev1.Secret
+	_, _, err := s.Decode(data, nil, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse connection token: %w", err)
+	}
+	return &secret, nil
+}
+
+// TokenAddress returns the url of the edge listener named in the token
+func TokenAddress(token *corev1.Secret) (string, error) {
+	host := token.ObjectMeta.Annotations["edge-host"]
+	port := token.ObjectMeta.Annotations["edge-port"]
+	if host == "" || port == "" {
+		return "", fmt.Errorf("Connection token %s does not specify an edge host and port", token.ObjectMeta.Name)
+	}
+	return "amqps://" + net.JoinHostPort(host, port), nil
+}
+
+// TokenTLSConfig builds the client tls configuration from the
+// certificates held in the token
+func TokenTLSConfig(token *corev1.Secret) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(token.Data["tls.crt"], token.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("Could not load certificate from connection token: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(token.Data["ca.crt"]) {
+		return nil, fmt.Errorf("Could not load CA from connection token")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      certPool,
+		MinVersion:   tls.VersionTLS10,
+	}, nil
+}
+
+// A Client is a single AMQP connection to a skupper site
+type Client struct {
+	connection *amqp.Client
+	session    *amqp.Session
+}
+
+// Connect opens a connection to the site that issued the token
+func Connect(token *corev1.Secret) (*Client, error) {
+	url, err := TokenAddress(token)
+	if err != nil {
+		return nil, err
+	}
+	config, err := TokenTLSConfig(token)
+	if err != nil {
+		return nil, err
+	}
+	config.ServerName = token.ObjectMeta.Annotations["edge-host"]
+	connection, err := amqp.Dial(url, amqp.ConnSASLExternal(), amqp.ConnMaxFrameSize(4294967295), amqp.ConnTLSConfig(config))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create connection: %s", err)
+	}
+	session, err := connection.NewSession()
+	if err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("Failed to create session: %s", err)
+	}
+	return &Client{
+		connection: connection,
+		session:    session,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.connection.Close()
+}
+
+// Dial returns a Conn for sending to, and making requests of, the
+// given address. The address must be served by an AMQP receiver, such
+// as a Listener, not by the targets of an exposed service.
+func (c *Client) Dial(address string) (*Conn, error) {
+	sender, err := c.session.NewSender(
+		amqp.LinkTargetAddress(address),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create sender for %s: %s", address, err)
+	}
+	receiver, err := c.session.NewReceiver(
+		amqp.LinkSourceAddress(""),
+		amqp.LinkAddressDynamic(),
+		amqp.LinkCredit(10),
+	)
+	if err != nil {
+		sender.Close(context.Background())
+		return nil, fmt.Errorf("Failed to create receiver: %s", err)
+	}
+	return &Conn{
+		address:  address,
+		sender:   sender,
+		receiver: receiver,
+	}, nil
+}
+
+// Listen returns a Listener receiving messages sent to the given
+// address by AMQP senders. It does not receive the traffic of an
+// exposed service with the same address.
+func (c *Client) Listen(address string) (*Listener, error) {
+	receiver, err := c.session.NewReceiver(
+		amqp.LinkSourceAddress(address),
+		amqp.LinkCredit(10),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create receiver for %s: %s", address, err)
+	}
+	anonymous, err := c.session.NewSender()
+	if err != nil {
+		receiver.Close(context.Background())
+		return nil, fmt.Errorf("Failed to create anonymous sender: %s", err)
+	}
+	return &Listener{
+		address:   address,
+		receiver:  receiver,
+		anonymous: anonymous,
+	}, nil
+}
+
+type Conn struct {
+	address  string
+	sender   *amqp.Sender
+	receiver *amqp.Receiver
+	next     uint64
+}
+
+// Send delivers a message to the address without waiting for a reply
+func (c *Conn) Send(ctx context.Context, body []byte) error {
+	return c.sender.Send(ctx, amqp.NewMessage(body))
+}
+
+// Request sends a message to the address and waits for the reply
+func (c *Conn) Request(ctx context.Context, body []byte) ([]byte, error) {
+	c.next++
+	request := amqp.NewMessage(body)
+	request.Properties = &amqp.MessageProperties{
+		ReplyTo:       c.receiver.Address(),
+		CorrelationID: c.next,
+	}
+	if err := c.sender.Send(ctx, request); err != nil {
+		return nil, fmt.Errorf("Could not send request to %s: %s", c.address, err)
+	}
+	for {
+		response, err := c.receiver.Receive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to receive reply from %s: %s", c.address, err)
+		}
+		response.Accept()
+		if response.Properties != nil && response.Properties.CorrelationID != c.next {
+			continue
+		}
+		return response.GetData(), nil
+	}
+}
+
+func (c *Conn) Close() error {
+	ctx := context.Background()
+	c.receiver.Close(ctx)
+	return c.sender.Close(ctx)
+}
+
+type Listener struct {
+	address   string
+	receiver  *amqp.Receiver
+	anonymous *amqp.Sender
+}
+
+// A Message is a delivery received by a Listener
+type Message struct {
+	Body     []byte
+	message  *amqp.Message
+	listener *Listener
+}
+
+// Accept waits for the next message sent to the address
+func (l *Listener) Accept(ctx context.Context) (*Message, error) {
+	msg, err := l.receiver.Receive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading message from %s: %s", l.address, err)
+	}
+	msg.Accept()
+	return &Message{
+		Body:     msg.GetData(),
+		message:  msg,
+		listener: l,
+	}, nil
+}
+
+func (l *Listener) Close() error {
+	ctx := context.Background()
+	l.anonymous.Close(ctx)
+	return l.receiver.Close(ctx)
+}
+
+// Reply sends a response to the originator of a request. It fails if
+// the message did not specify where replies should be sent.
+func (m *Message) Reply(ctx context.Context, body []byte) error {
+	if m.message.Properties == nil || m.message.Properties.ReplyTo == "" {
+		return fmt.Errorf("Message received on %s has no reply-to address", m.listener.address)
+	}
+	response := amqp.NewMessage(body)
+	response.Properties = &amqp.MessageProperties{
+		To:            m.message.Properties.ReplyTo,
+		CorrelationID: m.message.Properties.CorrelationID,
+	}
+	return m.listener.anonymous.Send(ctx, response)
+}
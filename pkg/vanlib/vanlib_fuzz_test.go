@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package vanlib
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"sigs.k8s.io/yaml"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// FuzzParseToken checks that a token, which is usually handed over from
+// another cluster, cannot panic the code that reads it
+func FuzzParseToken(f *testing.F) {
+	ca := certs.GenerateCASecret("test-ca", "test-ca")
+	token := certs.GenerateSecret("test-token", "test-token", "", &ca)
+	token.APIVersion = "v1"
+	token.Kind = "Secret"
+	token.ObjectMeta.Annotations = map[string]string{
+		"edge-host":                 "skupper-edge.example.com",
+		"edge-port":                 "443",
+		types.HostAliasesAnnotation: "skupper-edge.example.com=10.0.0.1",
+	}
+	data, err := yaml.Marshal(token)
+	assert.Assert(f, err)
+	f.Add(data)
+	f.Add([]byte("apiVersion: v1\nkind: Secret\ndata:\n  tls.crt: Zm9v\n"))
+	f.Add([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	f.Add([]byte("{}"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		token, err := ParseToken(data)
+		if err != nil {
+			return
+		}
+		TokenAddress(token)
+		TokenTLSConfig(token)
+		kube.ParseHostAliases(token.ObjectMeta.Annotations[types.HostAliasesAnnotation])
+	})
+}
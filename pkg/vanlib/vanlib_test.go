@@ -0,0 +1,92 @@
+package vanlib
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/pkg/certs"
+)
+
+func TestTokenAddress(t *testing.T) {
+	ca := certs.GenerateCASecret("test-ca", "test-ca")
+	token := certs.GenerateSecret("test-token", "test-token", "", &ca)
+
+	_, err := TokenAddress(&token)
+	assert.ErrorContains(t, err, "does not specify an edge host and port")
+
+	token.ObjectMeta.Annotations = map[string]string{
+		"edge-host": "skupper-edge.example.com",
+		"edge-port": "443",
+	}
+	url, err := TokenAddress(&token)
+	assert.Assert(t, err)
+	assert.Equal(t, url, "amqps://skupper-edge.example.com:443")
+}
+
+func TestTokenTLSConfig(t *testing.T) {
+	ca := certs.GenerateCASecret("test-ca", "test-ca")
+	token := certs.GenerateSecret("test-token", "test-token", "", &ca)
+
+	config, err := TokenTLSConfig(&token)
+	assert.Assert(t, err)
+	assert.Equal(t, len(config.Certificates), 1)
+	assert.Assert(t, config.RootCAs != nil)
+
+	delete(token.Data, "ca.crt")
+	_, err = TokenTLSConfig(&token)
+	assert.ErrorContains(t, err, "Could not load CA")
+
+	delete(token.Data, "tls.key")
+	_, err = TokenTLSConfig(&token)
+	assert.ErrorContains(t, err, "Could not load certificate")
+}
+
+// Two applications holding tokens for sites on the same network
+// exchange requests over an address served by a Listener. The address
+// must not be that of an exposed service, whose traffic is carried by
+// the tcp and http bridges of the router rather than as AMQP messages.
+func Example() {
+	token, err := ReadToken("token.yaml")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	client, err := Connect(token)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer client.Close()
+
+	listener, err := client.Listen("inventory-requests")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			request, err := listener.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			request.Reply(context.Background(), []byte("in stock"))
+		}
+	}()
+
+	conn, err := client.Dial("inventory-requests")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer conn.Close()
+	reply, err := conn.Request(context.Background(), []byte("widget"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(reply))
+}
@@ -196,13 +196,13 @@ func (r *BasicTestRunner) Setup(ctx context.Context, t *testing.T) {
 	backsvc := types.ServiceInterface{
 		Address:  "hello-world-backend",
 		Protocol: "http",
-		Port:     8080,
+		Ports:    []types.ServicePort{{Port: 8080}},
 	}
 
 	err = privateCluster.VanClient.ServiceInterfaceCreate(ctx, &backsvc)
 	assert.Assert(t, err)
 
-	err = privateCluster.VanClient.ServiceInterfaceBind(ctx, &backsvc, "deployment", "hello-world-backend", "http", 8080)
+	err = privateCluster.VanClient.ServiceInterfaceBind(ctx, &backsvc, "deployment", "hello-world-backend", "http", []int{8080})
 	assert.Assert(t, err)
 
 	_, err = k8s.WaitForSkupperServiceToBeCreatedAndReadyToUse(publicCluster.Namespace, publicCluster.VanClient.KubeClient, "hello-world-backend")
@@ -211,13 +211,13 @@ func (r *BasicTestRunner) Setup(ctx context.Context, t *testing.T) {
 	frontsvc := types.ServiceInterface{
 		Address:  "hello-world-frontend",
 		Protocol: "http",
-		Port:     8080,
+		Ports:    []types.ServicePort{{Port: 8080}},
 	}
 
 	err = publicCluster.VanClient.ServiceInterfaceCreate(ctx, &frontsvc)
 	assert.Assert(t, err)
 
-	err = publicCluster.VanClient.ServiceInterfaceBind(ctx, &frontsvc, "deployment", "hello-world-frontend", "http", 8080)
+	err = publicCluster.VanClient.ServiceInterfaceBind(ctx, &frontsvc, "deployment", "hello-world-frontend", "http", []int{8080})
 	assert.Assert(t, err)
 
 	_, err = k8s.WaitForSkupperServiceToBeCreatedAndReadyToUse(publicCluster.Namespace, publicCluster.VanClient.KubeClient, "hello-world-frontend")
@@ -186,7 +186,7 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - verify frontend service is exposed
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-frontend", Protocol: "http", Port: 8080},
+							{Address: "hello-world-frontend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
 						},
 					},
 					// skupper status - verify frontend service is exposed
@@ -208,8 +208,8 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - validate status of the two created services without targets
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-frontend", Protocol: "http", Port: 8080},
-							{Address: "hello-world-backend", Protocol: "http", Port: 8080},
+							{Address: "hello-world-frontend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
+							{Address: "hello-world-backend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
 						},
 					},
 					// skupper status - verify two services are now exposed
@@ -233,10 +233,10 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - validate status expecting frontend now has a target
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-frontend", Protocol: "http", Port: 8080, Targets: []types.ServiceInterfaceTarget{
-								{Name: "hello-world-frontend", TargetPort: 8080, Service: "hello-world-frontend"},
+							{Address: "hello-world-frontend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}, Targets: []types.ServiceInterfaceTarget{
+								{Name: "hello-world-frontend", TargetPorts: map[int]int{8080: 8080}, Service: "hello-world-frontend"},
 							}},
-							{Address: "hello-world-backend", Protocol: "http", Port: 8080},
+							{Address: "hello-world-backend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
 						},
 					},
 				}},
@@ -252,9 +252,9 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - validate backend service now has a target
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-frontend", Protocol: "http", Port: 8080},
-							{Address: "hello-world-backend", Protocol: "http", Port: 8080, Targets: []types.ServiceInterfaceTarget{
-								{Name: "hello-world-backend", TargetPort: 8080, Service: "hello-world-backend"},
+							{Address: "hello-world-frontend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
+							{Address: "hello-world-backend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}, Targets: []types.ServiceInterfaceTarget{
+								{Name: "hello-world-backend", TargetPorts: map[int]int{8080: 8080}, Service: "hello-world-backend"},
 							}},
 						},
 					},
@@ -274,8 +274,8 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - validates no more target for frontend service
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-frontend", Protocol: "http", Port: 8080},
-							{Address: "hello-world-backend", Protocol: "http", Port: 8080},
+							{Address: "hello-world-frontend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
+							{Address: "hello-world-backend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
 						},
 					},
 				}},
@@ -289,8 +289,8 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - validates no more target for frontend service
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-frontend", Protocol: "http", Port: 8080},
-							{Address: "hello-world-backend", Protocol: "http", Port: 8080},
+							{Address: "hello-world-frontend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
+							{Address: "hello-world-backend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
 						},
 					},
 				}},
@@ -302,7 +302,7 @@ func TestHelloWorldCLI(t *testing.T) {
 					// skupper service status - verify only backend is available
 					&service.StatusTester{
 						[]types.ServiceInterface{
-							{Address: "hello-world-backend", Protocol: "http", Port: 8080},
+							{Address: "hello-world-backend", Protocol: "http", Ports: []types.ServicePort{{Port: 8080}}},
 						},
 					},
 				}},
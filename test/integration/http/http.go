@@ -356,43 +356,47 @@ func (r *HttpClusterTestRunner) Setup(ctx context.Context, t *testing.T) {
 	service := types.ServiceInterface{
 		Address:  "httpbin",
 		Protocol: "http",
-		Port:     8080,
+		Ports:    []types.ServicePort{{Port: 8080}},
 	}
 
 	err = prv1Cluster.VanClient.ServiceInterfaceCreate(ctx, &service)
 	assert.Assert(t, err)
 
-	err = prv1Cluster.VanClient.ServiceInterfaceBind(ctx, &service, "deployment", "httpbin", "http", 0)
+	err = prv1Cluster.VanClient.ServiceInterfaceBind(ctx, &service, "deployment", "httpbin", "http", nil)
 	assert.Assert(t, err)
 
 	http2service := types.ServiceInterface{
 		Address:  "nghttp2",
 		Protocol: "http2",
-		Port:     8443,
+		Ports:    []types.ServicePort{{Port: 8443}},
 	}
 
 	err = prv1Cluster.VanClient.ServiceInterfaceCreate(ctx, &http2service)
 	assert.Assert(t, err)
 
-	err = prv1Cluster.VanClient.ServiceInterfaceBind(ctx, &http2service, "deployment", "nghttp2", "http2", 0)
+	err = prv1Cluster.VanClient.ServiceInterfaceBind(ctx, &http2service, "deployment", "nghttp2", "http2", nil)
 	assert.Assert(t, err)
 
 	http21service := types.ServiceInterface{
 		Address:  "nghttp1",
 		Protocol: "http",
-		Port:     8443,
+		Ports:    []types.ServicePort{{Port: 8443}},
 	}
 
 	err = prv1Cluster.VanClient.ServiceInterfaceCreate(ctx, &http21service)
 	assert.Assert(t, err)
 
-	err = prv1Cluster.VanClient.ServiceInterfaceBind(ctx, &http21service, "deployment", "nghttp2", "http", 0)
+	err = prv1Cluster.VanClient.ServiceInterfaceBind(ctx, &http21service, "deployment", "nghttp2", "http", nil)
 	assert.Assert(t, err)
 
 }
 
 func (r *HttpClusterTestRunner) Run(ctx context.Context, t *testing.T) {
-	defer base.TearDownSimplePublicAndPrivate(&r.ClusterTestRunnerBase)
+	defer func() {
+		base.RemoveSites(&r.ClusterTestRunnerBase)
+		r.AssertNoLeakedResources(t)
+		base.TearDownSimplePublicAndPrivate(&r.ClusterTestRunnerBase)
+	}()
 	r.Setup(ctx, t)
 	r.RunTests(t)
 }
@@ -62,13 +62,13 @@ func Setup(ctx context.Context, t *testing.T, r *base.ClusterTestRunnerBase) {
 		service := types.ServiceInterface{
 			Address:  name,
 			Protocol: "tcp",
-			Port:     27017,
+			Ports:    []types.ServicePort{{Port: 27017}},
 		}
 
 		err = cli.ServiceInterfaceCreate(ctx, &service)
 		assert.Assert(t, err)
 
-		err = cli.ServiceInterfaceBind(ctx, &service, "deployment", name, "tcp", 0)
+		err = cli.ServiceInterfaceBind(ctx, &service, "deployment", name, "tcp", nil)
 		assert.Assert(t, err)
 
 	}
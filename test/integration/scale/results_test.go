@@ -0,0 +1,113 @@
+package scale
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	testcases := []struct {
+		name     string
+		env      map[string]string
+		expected Config
+		err      string
+	}{
+		{
+			name:     "defaults",
+			expected: DefaultConfig,
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"SKUPPER_SCALE_SITES":               "10",
+				"SKUPPER_SCALE_SERVICES":            "200",
+				"SKUPPER_SCALE_CONSOLE_REQUESTS":    "5",
+				"SKUPPER_SCALE_MAX_SYNC_TIME":       "10m",
+				"SKUPPER_SCALE_MAX_CONSOLE_LATENCY": "500ms",
+				"SKUPPER_SCALE_MAX_ROUTER_MEMORY":   "1Gi",
+			},
+			expected: Config{
+				Sites:             10,
+				Services:          200,
+				MaxSyncTime:       10 * time.Minute,
+				MaxRouterMemory:   1024 * 1024 * 1024,
+				MaxConsoleLatency: 500 * time.Millisecond,
+				ConsoleRequests:   5,
+			},
+		},
+		{
+			name: "bad sites",
+			env:  map[string]string{"SKUPPER_SCALE_SITES": "0"},
+			err:  "Invalid value for SKUPPER_SCALE_SITES: \"0\"",
+		},
+		{
+			name: "bad duration",
+			env:  map[string]string{"SKUPPER_SCALE_MAX_SYNC_TIME": "soon"},
+			err:  "Invalid value for SKUPPER_SCALE_MAX_SYNC_TIME: \"soon\"",
+		},
+		{
+			name: "bad memory",
+			env:  map[string]string{"SKUPPER_SCALE_MAX_ROUTER_MEMORY": "lots"},
+			err:  "Invalid value for SKUPPER_SCALE_MAX_ROUTER_MEMORY: \"lots\"",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.name, func(t *testing.T) {
+			for name, value := range c.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+			config, err := ConfigFromEnv()
+			if c.err != "" {
+				assert.Error(t, err, c.err)
+			} else {
+				assert.Assert(t, err)
+				assert.DeepEqual(t, config, c.expected)
+			}
+		})
+	}
+}
+
+func TestResultsCheck(t *testing.T) {
+	config := Config{
+		MaxSyncTime:       time.Minute,
+		MaxRouterMemory:   1000,
+		MaxConsoleLatency: time.Second,
+	}
+	latencies := []time.Duration{}
+	for i := 1; i <= 20; i++ {
+		latencies = append(latencies, time.Duration(i)*50*time.Millisecond)
+	}
+	results := Results{
+		SyncTime:       30 * time.Second,
+		RouterMemory:   map[string]int64{"a": 500, "b": 900},
+		ConsoleLatency: latencies,
+	}
+	assert.Equal(t, results.ConsoleLatencyPercentile(50), 500*time.Millisecond)
+	assert.Equal(t, results.ConsoleLatencyPercentile(95), 950*time.Millisecond)
+	site, memory := results.MaxRouterMemory()
+	assert.Equal(t, site, "b")
+	assert.Equal(t, memory, int64(900))
+	assert.Equal(t, len(results.Check(config)), 0)
+
+	results.SyncTime = 2 * time.Minute
+	results.RouterMemory["c"] = 2000
+	results.ConsoleLatency = append(results.ConsoleLatency, 3*time.Second, 3*time.Second)
+	assert.DeepEqual(t, results.Check(config), []string{
+		"services took 2m0s to reach every site, more than 1m0s",
+		"router of c used 2000 bytes, more than 1000",
+		"95th percentile console latency was 3s, more than 1s",
+	})
+}
+
+func TestServiceSite(t *testing.T) {
+	config := Config{Sites: 3}
+	sites := []int{}
+	for i := 0; i < 6; i++ {
+		sites = append(sites, config.ServiceSite(i))
+	}
+	assert.DeepEqual(t, sites, []int{1, 2, 3, 1, 2, 3})
+}
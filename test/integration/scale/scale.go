@@ -0,0 +1,362 @@
+package scale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/log"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/test/utils/base"
+	"github.com/skupperproject/skupper/test/utils/constants"
+)
+
+// Config is the size of the network the scale test seeds, and the
+// thresholds its measurements must meet. Each can be set through the
+// environment variable named in its parse function.
+type Config struct {
+	// Sites is the number of edge sites linked to a single hub
+	Sites int
+	// Services is the number of services, spread across the edge
+	// sites, that every site must learn of
+	Services int
+	// MaxSyncTime is how long the services may take to reach every site
+	MaxSyncTime time.Duration
+	// MaxRouterMemory is the most memory, in bytes, any router may use
+	// once the services have been synchronised
+	MaxRouterMemory int64
+	// MaxConsoleLatency is the longest the console API of the hub may
+	// take to list the services
+	MaxConsoleLatency time.Duration
+	// ConsoleRequests is the number of console API requests timed
+	ConsoleRequests int
+}
+
+var DefaultConfig = Config{
+	Sites:             5,
+	Services:          50,
+	MaxSyncTime:       5 * time.Minute,
+	MaxRouterMemory:   256 * 1024 * 1024,
+	MaxConsoleLatency: 2 * time.Second,
+	ConsoleRequests:   20,
+}
+
+func intFromEnv(name string, value *int) error {
+	if s := os.Getenv(name); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 1 {
+			return fmt.Errorf("Invalid value for %s: %q", name, s)
+		}
+		*value = i
+	}
+	return nil
+}
+
+func durationFromEnv(name string, value *time.Duration) error {
+	if s := os.Getenv(name); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("Invalid value for %s: %q", name, s)
+		}
+		*value = d
+	}
+	return nil
+}
+
+// ConfigFromEnv returns DefaultConfig with any values overridden by the
+// SKUPPER_SCALE_* environment variables
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	if err := intFromEnv("SKUPPER_SCALE_SITES", &config.Sites); err != nil {
+		return config, err
+	}
+	if err := intFromEnv("SKUPPER_SCALE_SERVICES", &config.Services); err != nil {
+		return config, err
+	}
+	if err := intFromEnv("SKUPPER_SCALE_CONSOLE_REQUESTS", &config.ConsoleRequests); err != nil {
+		return config, err
+	}
+	if err := durationFromEnv("SKUPPER_SCALE_MAX_SYNC_TIME", &config.MaxSyncTime); err != nil {
+		return config, err
+	}
+	if err := durationFromEnv("SKUPPER_SCALE_MAX_CONSOLE_LATENCY", &config.MaxConsoleLatency); err != nil {
+		return config, err
+	}
+	if s := os.Getenv("SKUPPER_SCALE_MAX_ROUTER_MEMORY"); s != "" {
+		quantity, err := resource.ParseQuantity(s)
+		if err != nil || quantity.Value() <= 0 {
+			return config, fmt.Errorf("Invalid value for SKUPPER_SCALE_MAX_ROUTER_MEMORY: %q", s)
+		}
+		config.MaxRouterMemory = quantity.Value()
+	}
+	return config, nil
+}
+
+// ServiceAddress returns the address of the i'th service seeded
+func ServiceAddress(i int) string {
+	return fmt.Sprintf("scale-%d", i)
+}
+
+// ServiceSite returns which of the edge sites, numbered from 1, the i'th
+// service is defined at
+func (c *Config) ServiceSite(i int) int {
+	return i%c.Sites + 1
+}
+
+// Results are the measurements taken of the seeded network
+type Results struct {
+	SyncTime       time.Duration
+	RouterMemory   map[string]int64
+	ConsoleLatency []time.Duration
+}
+
+// MaxRouterMemory returns the site whose router used the most memory,
+// and how much it used
+func (r *Results) MaxRouterMemory() (string, int64) {
+	site := ""
+	max := int64(0)
+	for name, memory := range r.RouterMemory {
+		if memory > max || site == "" {
+			site = name
+			max = memory
+		}
+	}
+	return site, max
+}
+
+// ConsoleLatencyPercentile returns the latency that the given percentage
+// of console requests completed within
+func (r *Results) ConsoleLatencyPercentile(percent int) time.Duration {
+	if len(r.ConsoleLatency) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, r.ConsoleLatency...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := (len(sorted)*percent+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// Check returns a description of each threshold the results do not meet
+func (r *Results) Check(config Config) []string {
+	failures := []string{}
+	if r.SyncTime > config.MaxSyncTime {
+		failures = append(failures, fmt.Sprintf("services took %s to reach every site, more than %s", r.SyncTime, config.MaxSyncTime))
+	}
+	if site, memory := r.MaxRouterMemory(); memory > config.MaxRouterMemory {
+		failures = append(failures, fmt.Sprintf("router of %s used %d bytes, more than %d", site, memory, config.MaxRouterMemory))
+	}
+	if latency := r.ConsoleLatencyPercentile(95); latency > config.MaxConsoleLatency {
+		failures = append(failures, fmt.Sprintf("95th percentile console latency was %s, more than %s", latency, config.MaxConsoleLatency))
+	}
+	return failures
+}
+
+type ScaleTestRunner struct {
+	base.ClusterTestRunnerBase
+	Config Config
+}
+
+func (r *ScaleTestRunner) sites() []*base.ClusterContext {
+	return r.ClusterContexts
+}
+
+func siteConfigSpec(cc *base.ClusterContext, mode types.TransportMode, console bool) types.SiteConfigSpec {
+	return types.SiteConfigSpec{
+		SkupperNamespace:  cc.Namespace,
+		RouterMode:        string(mode),
+		EnableController:  true,
+		EnableServiceSync: true,
+		EnableConsole:     console,
+		AuthMode:          types.ConsoleAuthModeUnsecured,
+		Ingress:           types.IngressNoneString,
+		Replicas:          1,
+	}
+}
+
+// Setup creates the hub, as the single public context, and links each
+// edge site, the private contexts, to it
+func (r *ScaleTestRunner) Setup(ctx context.Context, t *testing.T) {
+	hub, err := r.GetPublicContext(1)
+	assert.Assert(t, err)
+	assert.Assert(t, hub.CreateNamespace())
+	hubConfig := siteConfigSpec(hub, types.TransportModeInterior, true)
+	if base.MultipleClusters(t) {
+		hubConfig.Ingress = hub.VanClient.GetIngressDefault()
+	}
+	siteConfig, err := hub.VanClient.SiteConfigCreate(ctx, hubConfig)
+	assert.Assert(t, err)
+	assert.Assert(t, hub.VanClient.RouterCreate(ctx, *siteConfig))
+	tokenFile := "/tmp/public_scale_secret.yaml"
+	assert.Assert(t, hub.VanClient.ConnectorTokenCreateFile(ctx, types.DefaultVanName, tokenFile))
+
+	for i := 1; i <= r.Config.Sites; i++ {
+		edge, err := r.GetPrivateContext(i)
+		assert.Assert(t, err)
+		assert.Assert(t, edge.CreateNamespace())
+		siteConfig, err := edge.VanClient.SiteConfigCreate(ctx, siteConfigSpec(edge, types.TransportModeEdge, false))
+		assert.Assert(t, err)
+		assert.Assert(t, edge.VanClient.RouterCreate(ctx, *siteConfig))
+		_, err = edge.VanClient.ConnectorCreateFromFile(ctx, tokenFile, types.ConnectorCreateOptions{SkupperNamespace: edge.Namespace})
+		assert.Assert(t, err)
+	}
+	r.waitForLinks(ctx, t)
+}
+
+func (r *ScaleTestRunner) waitForLinks(ctx context.Context, t *testing.T) {
+	hub, err := r.GetPublicContext(1)
+	assert.Assert(t, err)
+	deadline := time.Now().Add(constants.ImagePullingAndResourceCreationTimeout)
+	for {
+		inspect, err := hub.VanClient.RouterInspect(ctx)
+		if err == nil && inspect.Status.ConnectedSites.Total >= r.Config.Sites {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d edge sites to link to the hub", r.Config.Sites)
+		}
+		time.Sleep(constants.DefaultTick)
+	}
+}
+
+// Seed defines the services at the edge sites
+func (r *ScaleTestRunner) Seed(ctx context.Context, t *testing.T) {
+	for i := 0; i < r.Config.Services; i++ {
+		edge, err := r.GetPrivateContext(r.Config.ServiceSite(i))
+		assert.Assert(t, err)
+		service := types.ServiceInterface{
+			Address:  ServiceAddress(i),
+			Protocol: "tcp",
+			Ports:    []types.ServicePort{{Port: 8080}},
+		}
+		assert.Assert(t, edge.VanClient.ServiceInterfaceCreate(ctx, &service))
+	}
+}
+
+// missingServices returns how many of the seeded services the site does
+// not yet know of
+func (r *ScaleTestRunner) missingServices(ctx context.Context, cc *base.ClusterContext) (int, error) {
+	services, err := cc.VanClient.ServiceInterfaceList(ctx)
+	if err != nil {
+		return 0, err
+	}
+	known := map[string]bool{}
+	for _, service := range services {
+		known[service.Address] = true
+	}
+	missing := 0
+	for i := 0; i < r.Config.Services; i++ {
+		if !known[ServiceAddress(i)] {
+			missing++
+		}
+	}
+	return missing, nil
+}
+
+// MeasureSync returns how long after started every site knew of every
+// service, failing once the threshold has been well exceeded
+func (r *ScaleTestRunner) MeasureSync(ctx context.Context, t *testing.T, started time.Time) time.Duration {
+	deadline := started.Add(2 * r.Config.MaxSyncTime)
+	for {
+		pending := 0
+		for _, cc := range r.sites() {
+			missing, err := r.missingServices(ctx, cc)
+			if err != nil {
+				log.Warnf("unable to list services in %s: %s", cc.Namespace, err)
+				pending++
+			} else if missing > 0 {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return time.Since(started)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sites still missing services after %s", pending, time.Since(started))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// RouterMemory returns the memory reported by the router of the site
+func RouterMemory(cc *base.ClusterContext) (int64, error) {
+	pod, err := kube.GetReadyPod(cc.Namespace, cc.VanClient.KubeClient, types.TransportComponentName)
+	if err != nil {
+		return 0, err
+	}
+	output, err := kube.ExecCommandInContainer([]string{"qdmanage", "query", "--type", "router"}, pod.Name, types.TransportContainerName, cc.Namespace, cc.VanClient.KubeClient, cc.VanClient.RestConfig)
+	if err != nil {
+		return 0, err
+	}
+	routers := []struct {
+		MemoryUsage int64 `json:"memoryUsage"`
+	}{}
+	if err := json.Unmarshal(output.Bytes(), &routers); err != nil {
+		return 0, fmt.Errorf("unable to parse router query: %s", err)
+	}
+	if len(routers) != 1 {
+		return 0, fmt.Errorf("expected one router, found %d", len(routers))
+	}
+	return routers[0].MemoryUsage, nil
+}
+
+// MeasureConsole times requests for the services known to the hub,
+// through the API server's proxy to its console
+func (r *ScaleTestRunner) MeasureConsole(t *testing.T) []time.Duration {
+	hub, err := r.GetPublicContext(1)
+	assert.Assert(t, err)
+	port := strconv.Itoa(int(types.ConsoleDefaultServicePort))
+	latencies := []time.Duration{}
+	for i := 0; i < r.Config.ConsoleRequests; i++ {
+		started := time.Now()
+		_, err := hub.VanClient.KubeClient.CoreV1().Services(hub.Namespace).ProxyGet("http", types.ControllerServiceName, port, "/DATA", nil).DoRaw()
+		assert.Assert(t, err, "console request failed")
+		latencies = append(latencies, time.Since(started))
+	}
+	return latencies
+}
+
+func (r *ScaleTestRunner) TearDown() {
+	for _, cc := range r.sites() {
+		if err := cc.DeleteNamespace(); err != nil {
+			log.Warnf("unable to delete %s: %s", cc.Namespace, err)
+		}
+	}
+}
+
+func (r *ScaleTestRunner) Run(ctx context.Context, t *testing.T) {
+	r.Setup(ctx, t)
+	defer r.TearDown()
+
+	started := time.Now()
+	r.Seed(ctx, t)
+	results := &Results{RouterMemory: map[string]int64{}}
+	results.SyncTime = r.MeasureSync(ctx, t, started)
+	t.Logf("%d services reached %d sites in %s", r.Config.Services, len(r.sites()), results.SyncTime)
+
+	for _, cc := range r.sites() {
+		memory, err := RouterMemory(cc)
+		assert.Assert(t, err, "unable to query router of %s", cc.Namespace)
+		results.RouterMemory[cc.Namespace] = memory
+	}
+	site, memory := results.MaxRouterMemory()
+	t.Logf("most router memory used was %d bytes, by %s", memory, site)
+
+	results.ConsoleLatency = r.MeasureConsole(t)
+	t.Logf("console latency: median %s, 95th percentile %s", results.ConsoleLatencyPercentile(50), results.ConsoleLatencyPercentile(95))
+
+	for _, failure := range results.Check(r.Config) {
+		t.Error(failure)
+	}
+}
@@ -0,0 +1,40 @@
+// +build integration
+
+package scale
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/test/utils/base"
+)
+
+func TestMain(m *testing.M) {
+	base.ParseFlags()
+	os.Exit(m.Run())
+}
+
+// TestScale seeds a hub with SKUPPER_SCALE_SITES edge sites and
+// SKUPPER_SCALE_SERVICES services, then checks how long the services take
+// to reach every site, how much memory the routers use and how quickly
+// the console responds against the configured thresholds
+func TestScale(t *testing.T) {
+	config, err := ConfigFromEnv()
+	assert.Assert(t, err)
+	needs := base.ClusterNeeds{
+		NamespaceId:     "scale",
+		PublicClusters:  1,
+		PrivateClusters: config.Sites,
+	}
+	testRunner := &ScaleTestRunner{Config: config}
+	testRunner.BuildOrSkip(t, needs, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	base.HandleInterruptSignal(t, func(t *testing.T) {
+		testRunner.TearDown()
+		cancel()
+	})
+	testRunner.Run(ctx, t)
+}
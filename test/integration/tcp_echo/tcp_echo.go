@@ -133,17 +133,21 @@ func (r *TcpEchoClusterTestRunner) Setup(ctx context.Context, t *testing.T) {
 	service := types.ServiceInterface{
 		Address:  "tcp-go-echo",
 		Protocol: "tcp",
-		Port:     9090,
+		Ports:    []types.ServicePort{{Port: 9090}},
 	}
 	err = pub1Cluster.VanClient.ServiceInterfaceCreate(ctx, &service)
 	assert.Assert(t, err)
 
-	err = pub1Cluster.VanClient.ServiceInterfaceBind(ctx, &service, "deployment", "tcp-go-echo", "tcp", 0)
+	err = pub1Cluster.VanClient.ServiceInterfaceBind(ctx, &service, "deployment", "tcp-go-echo", "tcp", nil)
 	assert.Assert(t, err)
 }
 
 func (r *TcpEchoClusterTestRunner) Run(ctx context.Context, t *testing.T) {
-	defer base.TearDownSimplePublicAndPrivate(&r.ClusterTestRunnerBase)
+	defer func() {
+		base.RemoveSites(&r.ClusterTestRunnerBase)
+		r.AssertNoLeakedResources(t)
+		base.TearDownSimplePublicAndPrivate(&r.ClusterTestRunnerBase)
+	}()
 	r.Setup(ctx, t)
 	r.RunTests(ctx, t)
 }
@@ -11,6 +11,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"os/exec"
+	"testing"
+	"time"
 )
 
 // ClusterContext represents a cluster that is available for testing
@@ -62,6 +64,20 @@ func (cc *ClusterContext) DeleteNamespace() error {
 	return nil
 }
 
+// AssertEventEmitted fails the test unless an event with the given reason
+// is emitted in the cluster context's namespace within the given time
+func (cc *ClusterContext) AssertEventEmitted(t *testing.T, reason string, within time.Duration) *apiv1.Event {
+	t.Helper()
+	return k8s.AssertEventEmitted(t, cc.Namespace, cc.VanClient.KubeClient, reason, within)
+}
+
+// AssertEventNotEmitted fails the test if an event with the given reason
+// is emitted in the cluster context's namespace within the given time
+func (cc *ClusterContext) AssertEventNotEmitted(t *testing.T, reason string, within time.Duration) {
+	t.Helper()
+	k8s.AssertEventNotEmitted(t, cc.Namespace, cc.VanClient.KubeClient, reason, within)
+}
+
 func (cc *ClusterContext) waitForSkupperServiceToBeCreated(name string, retryFn func() (*apiv1.Service, error), backoff wait.Backoff) (*apiv1.Service, error) {
 	var service *apiv1.Service = nil
 	var err error
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/log"
 	"github.com/skupperproject/skupper/api/types"
 	vanClient "github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/test/utils/k8s"
 	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 // ClusterNeeds enable customization of expected number of
@@ -249,3 +252,43 @@ func RemoveNamespacesForContexts(r *ClusterTestRunnerBase, public []int, priv []
 	}
 	return removeNamespaces(false, public)
 }
+
+// RemoveSites removes skupper from the namespaces of the cluster
+// contexts, leaving the namespaces themselves in place
+func RemoveSites(r *ClusterTestRunnerBase) {
+	for _, cc := range r.ClusterContexts {
+		err := cc.VanClient.SiteConfigRemove(context.Background())
+		if err != nil {
+			err = cc.VanClient.RouterRemove(context.Background())
+		}
+		if err != nil {
+			log.Warnf("unable to remove skupper from %s: %s", cc.Namespace, err)
+		}
+	}
+}
+
+// leakTimeout is how long the garbage collector is given to remove the
+// resources owned by a site once it has been removed
+var leakTimeout = 2 * time.Minute
+
+// AssertNoLeakedResources fails the test if any skupper labeled resources
+// remain in the namespaces of the cluster contexts once the sites in them
+// have been removed (see RemoveSites). It must be called before the
+// namespaces are torn down, and changes nothing, so that any leaked
+// resources can be inspected until they are.
+func (c *ClusterTestRunnerBase) AssertNoLeakedResources(t *testing.T) {
+	t.Helper()
+	for _, cc := range c.ClusterContexts {
+		var leaked []k8s.LeakedResource
+		err := wait.PollImmediate(time.Second, leakTimeout, func() (bool, error) {
+			var err error
+			leaked, err = k8s.FindLeakedResources(cc.VanClient.KubeClient, []string{cc.Namespace})
+			return err != nil || len(leaked) == 0, err
+		})
+		if err != nil && err != wait.ErrWaitTimeout {
+			t.Errorf("unable to check for leaked resources in %s: %s", cc.Namespace, err)
+		} else if len(leaked) > 0 {
+			t.Errorf("skupper resources leaked in %s after the site was removed:\n%s", cc.Namespace, k8s.LeakReport(leaked))
+		}
+	}
+}
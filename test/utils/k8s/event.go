@@ -0,0 +1,93 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EventPollInterval is how often the events in a namespace are listed
+// while waiting for one to be emitted
+var EventPollInterval = time.Second
+
+// eventTime returns the most recent time the event was recorded, whichever
+// of the event fields the recorder populated
+func eventTime(event *apiv1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	if !event.FirstTimestamp.IsZero() {
+		return event.FirstTimestamp.Time
+	}
+	return event.ObjectMeta.CreationTimestamp.Time
+}
+
+// FindEvent returns the most recent event in the namespace with the given
+// reason that was recorded at or after since, or nil if there is none.
+func FindEvent(ns string, kubeClient kubernetes.Interface, reason string, since time.Time) (*apiv1.Event, error) {
+	events, err := kubeClient.CoreV1().Events(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var found *apiv1.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.Reason != reason || eventTime(event).Before(since) {
+			continue
+		}
+		if found == nil || eventTime(event).After(eventTime(found)) {
+			found = event
+		}
+	}
+	return found, nil
+}
+
+// WaitForEvent polls the events in the namespace until one with the given
+// reason, recorded at or after since, is found or the timeout expires.
+func WaitForEvent(ns string, kubeClient kubernetes.Interface, reason string, since time.Time, timeout time.Duration) (*apiv1.Event, error) {
+	var event *apiv1.Event
+	err := wait.PollImmediate(EventPollInterval, timeout, func() (bool, error) {
+		var err error
+		event, err = FindEvent(ns, kubeClient, reason, since)
+		if err != nil {
+			return false, err
+		}
+		return event != nil, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return nil, fmt.Errorf("timed out waiting for event %s in %s: %w", reason, ns, err)
+	}
+	return event, err
+}
+
+// AssertEventEmitted fails the test unless an event with the given reason
+// is, or has already been, emitted in the namespace within the given time
+func AssertEventEmitted(t *testing.T, ns string, kubeClient kubernetes.Interface, reason string, within time.Duration) *apiv1.Event {
+	t.Helper()
+	event, err := WaitForEvent(ns, kubeClient, reason, time.Time{}, within)
+	assert.Assert(t, err)
+	return event
+}
+
+// AssertEventNotEmitted fails the test if an event with the given reason
+// is emitted in the namespace, from now until the given time has passed
+func AssertEventNotEmitted(t *testing.T, ns string, kubeClient kubernetes.Interface, reason string, within time.Duration) {
+	t.Helper()
+	since := time.Now().Truncate(time.Second)
+	event, err := WaitForEvent(ns, kubeClient, reason, since, within)
+	if err == nil {
+		t.Fatalf("unexpected event %s in %s: %s", reason, ns, event.Message)
+	} else if !errors.Is(err, wait.ErrWaitTimeout) {
+		t.Fatal(err)
+	}
+}
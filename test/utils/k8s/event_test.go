@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForEvent(t *testing.T) {
+	defer func(interval time.Duration) { EventPollInterval = interval }(EventPollInterval)
+	EventPollInterval = 10 * time.Millisecond
+	client := fake.NewSimpleClientset()
+	emit := func(name string, reason string, at time.Time) {
+		_, err := client.CoreV1().Events(ns).Create(&apiv1.Event{
+			ObjectMeta:    v1.ObjectMeta{Name: name},
+			Reason:        reason,
+			Message:       name,
+			LastTimestamp: v1.NewTime(at),
+		})
+		assert.Assert(t, err)
+	}
+	earlier := time.Now().Add(-time.Hour)
+	emit("old", "ServiceSyncEvent", earlier)
+
+	event := AssertEventEmitted(t, ns, client, "ServiceSyncEvent", time.Second)
+	assert.Equal(t, event.Message, "old")
+
+	_, err := WaitForEvent(ns, client, "ServiceSyncEvent", time.Now(), 50*time.Millisecond)
+	assert.ErrorContains(t, err, "timed out waiting for event ServiceSyncEvent")
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		emit("new", "ServiceSyncEvent", time.Now().Add(time.Second))
+	}()
+	event, err = WaitForEvent(ns, client, "ServiceSyncEvent", time.Now(), time.Second)
+	assert.Assert(t, err)
+	assert.Equal(t, event.Message, "new")
+
+	AssertEventNotEmitted(t, ns, client, "ServiceSyncError", 50*time.Millisecond)
+}
@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SkupperLabelSelectors select the resources created by skupper; a
+// resource matching any of them that remains after the site is removed
+// is a leak
+var SkupperLabelSelectors = []string{
+	types.SkupperTypeQualifier,
+	types.InternalTypeQualifier,
+	"skupper.io/component",
+	"application in (skupper,skupper-router)",
+}
+
+// LeakedResource identifies a skupper resource that remained after the
+// namespace or site it belonged to was removed
+type LeakedResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r LeakedResource) String() string {
+	return fmt.Sprintf("%s/%s in %s", r.Kind, r.Name, r.Namespace)
+}
+
+type resourceLister func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error)
+
+func names(items int, name func(i int) string) []string {
+	result := make([]string, items)
+	for i := range result {
+		result[i] = name(i)
+	}
+	return result
+}
+
+var namespacedListers = []struct {
+	kind string
+	list resourceLister
+}{
+	{"deployment", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.AppsV1().Deployments(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"statefulset", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.AppsV1().StatefulSets(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"pod", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.CoreV1().Pods(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"service", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.CoreV1().Services(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"configmap", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.CoreV1().ConfigMaps(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"secret", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.CoreV1().Secrets(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"serviceaccount", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.CoreV1().ServiceAccounts(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"role", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.RbacV1().Roles(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+	{"rolebinding", func(kubeClient kubernetes.Interface, namespace string, options metav1.ListOptions) ([]string, error) {
+		list, err := kubeClient.RbacV1().RoleBindings(namespace).List(options)
+		if err != nil {
+			return nil, err
+		}
+		return names(len(list.Items), func(i int) string { return list.Items[i].Name }), nil
+	}},
+}
+
+// FindLeakedResources returns the skupper labeled resources remaining in
+// the given namespaces, sorted by kind, namespace and name
+func FindLeakedResources(kubeClient kubernetes.Interface, namespaces []string) ([]LeakedResource, error) {
+	found := map[LeakedResource]bool{}
+	for _, namespace := range namespaces {
+		for _, lister := range namespacedListers {
+			for _, selector := range SkupperLabelSelectors {
+				resources, err := lister.list(kubeClient, namespace, metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					return nil, fmt.Errorf("failed to list %s resources in %q: %w", lister.kind, namespace, err)
+				}
+				for _, name := range resources {
+					found[LeakedResource{Kind: lister.kind, Namespace: namespace, Name: name}] = true
+				}
+			}
+		}
+	}
+	leaked := []LeakedResource{}
+	for resource := range found {
+		leaked = append(leaked, resource)
+	}
+	sort.Slice(leaked, func(i, j int) bool {
+		if leaked[i].Kind != leaked[j].Kind {
+			return leaked[i].Kind < leaked[j].Kind
+		}
+		if leaked[i].Namespace != leaked[j].Namespace {
+			return leaked[i].Namespace < leaked[j].Namespace
+		}
+		return leaked[i].Name < leaked[j].Name
+	})
+	return leaked, nil
+}
+
+// LeakReport describes the leaked resources, one per line
+func LeakReport(leaked []LeakedResource) string {
+	lines := []string{}
+	for _, resource := range leaked {
+		lines = append(lines, "  "+resource.String())
+	}
+	return strings.Join(lines, "\n")
+}
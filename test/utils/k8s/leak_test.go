@@ -0,0 +1,29 @@
+package k8s
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindLeakedResources(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&apiv1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "unrelated", Namespace: ns}},
+		&apiv1.Secret{ObjectMeta: v1.ObjectMeta{Name: "link1", Namespace: ns, Labels: map[string]string{"skupper.io/type": "connection-token"}}},
+		&apiv1.Service{ObjectMeta: v1.ObjectMeta{Name: "skupper-router", Namespace: ns, Labels: map[string]string{"application": "skupper-router", "skupper.io/component": "router"}}},
+		&apiv1.Service{ObjectMeta: v1.ObjectMeta{Name: "skupper-router", Namespace: "other", Labels: map[string]string{"application": "skupper-router"}}},
+		&rbacv1.ClusterRole{ObjectMeta: v1.ObjectMeta{Name: "skupper-site-controller", Labels: map[string]string{"application": "skupper"}}},
+	)
+
+	leaked, err := FindLeakedResources(client, []string{ns})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, leaked, []LeakedResource{
+		{Kind: "secret", Namespace: ns, Name: "link1"},
+		{Kind: "service", Namespace: ns, Name: "skupper-router"},
+	})
+	assert.Equal(t, LeakReport(leaked), "  secret/link1 in default\n  service/skupper-router in default")
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/messages"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/skupperproject/skupper/test/utils/base"
 	"github.com/skupperproject/skupper/test/utils/constants"
@@ -32,7 +33,7 @@ func (d *DeleteTester) Run(cluster *base.ClusterContext) (stdout string, stderr
 
 	// expected output: Skupper is now removed from '<NAMESPACE>'.
 	log.Printf("Validating 'skupper delete'")
-	expectedOutput := fmt.Sprintf("Skupper is now removed from '%s'.", cluster.Namespace)
+	expectedOutput := messages.Sprintf(messages.SiteRemoved, cluster.Namespace)
 	if !strings.Contains(stdout, expectedOutput) {
 		err = fmt.Errorf("expected: %s - found: %s", expectedOutput, stdout)
 		return
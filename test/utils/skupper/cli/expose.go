@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 	utils2 "github.com/skupperproject/skupper/pkg/utils"
 	"github.com/skupperproject/skupper/test/utils"
 	"github.com/skupperproject/skupper/test/utils/base"
@@ -60,7 +61,7 @@ func (e *ExposeTester) Run(cluster *base.ClusterContext) (stdout string, stderr
 
 	// Validating stdout contains expected data
 	log.Printf("Validating 'skupper expose'")
-	expectedOut := fmt.Sprintf("%s %s exposed as %s", e.TargetType, e.TargetName, utils.StrDefault(e.Address, e.TargetName))
+	expectedOut := messages.Sprintf(messages.ServiceExposed, e.TargetType, e.TargetName, utils.StrDefault(e.Address, e.TargetName))
 	if !strings.Contains(stdout, expectedOut) {
 		err = fmt.Errorf("expected: %s - found: %s", expectedOut, stdout)
 		return
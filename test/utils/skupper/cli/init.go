@@ -9,6 +9,7 @@ import (
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/messages"
 	"github.com/skupperproject/skupper/pkg/qdr"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/skupperproject/skupper/test/utils/base"
@@ -80,7 +81,7 @@ func (s *InitTester) Run(cluster *base.ClusterContext) (stdout string, stderr st
 
 	// Validate if init output contains the namespace where skupper was installed
 	log.Println("Validating 'skupper init'")
-	if !strings.Contains(stdout, fmt.Sprintf("Skupper is now installed in namespace '%s'.", cluster.Namespace)) {
+	if !strings.Contains(stdout, messages.Sprintf(messages.SiteInstalled, cluster.Namespace)) {
 		err = fmt.Errorf("init output is valid - missing namespace info")
 	}
 
@@ -57,7 +57,7 @@ func (s *StatusTester) run(cluster *base.ClusterContext) (stdout string, stderr
 
 	// Iterating through provided service interfaces to validate stdout matches
 	for _, svc := range s.ServiceInterfaces {
-		serviceEntry := fmt.Sprintf("%s (%s port %d)", svc.Address, svc.Protocol, svc.Port)
+		serviceEntry := fmt.Sprintf("%s (%s port %d)", svc.Address, svc.Protocol, svc.PrimaryPort())
 		if len(svc.Targets) > 0 {
 			serviceEntry += " with targets"
 		}
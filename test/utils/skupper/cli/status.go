@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/skupperproject/skupper/pkg/messages"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/skupperproject/skupper/test/utils/base"
 	"github.com/skupperproject/skupper/test/utils/constants"
@@ -86,7 +87,7 @@ func (s *StatusTester) validateMainContent(cluster *base.ClusterContext, stdout
 	// Main info
 	mainContent = append(mainContent, fmt.Sprintf("Skupper is enabled for namespace \"%s\"", cluster.Namespace))
 	if s.NotEnabled {
-		notEnabledContent := fmt.Sprintf("Skupper is not enabled in namespace '%s'", cluster.Namespace)
+		notEnabledContent := messages.Sprintf(messages.SiteNotEnabled, cluster.Namespace)
 		if !strings.Contains(stdout, notEnabledContent) {
 			return fmt.Errorf("error validating not enabled message - expected: %s - stdout: %s", notEnabledContent, stdout)
 		}
@@ -153,7 +154,7 @@ func (s *StatusTester) validateConsoleAuthInternal(cluster *base.ClusterContext,
 	}
 
 	log.Println("Validating console auth internal info")
-	if !strings.Contains(stdout, "The credentials for internal console-auth mode are held in secret: 'skupper-console-users'") {
+	if !strings.Contains(stdout, messages.Sprintf(messages.StatusConsoleCredentials)) {
 		return fmt.Errorf("credentials info for internal console-auth mode is missing")
 	}
 
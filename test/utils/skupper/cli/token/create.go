@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/skupperproject/skupper/pkg/messages"
 	"github.com/skupperproject/skupper/test/utils/base"
 	"github.com/skupperproject/skupper/test/utils/skupper/cli"
 )
@@ -39,7 +40,7 @@ func (t *CreateTester) Run(cluster *base.ClusterContext) (stdout string, stderr
 	log.Printf("Validating 'skupper token create'")
 
 	log.Println("validating stdout")
-	expectedOutput := fmt.Sprintf("Connection token written to %s", t.FileName)
+	expectedOutput := strings.TrimSpace(messages.Sprintf(messages.TokenWritten, t.FileName, ""))
 	if !strings.Contains(stdout, expectedOutput) {
 		err = fmt.Errorf("output did not match - expected: %s - found: %s", expectedOutput, stdout)
 		return
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/messages"
 	utils2 "github.com/skupperproject/skupper/pkg/utils"
 	"github.com/skupperproject/skupper/test/utils"
 	"github.com/skupperproject/skupper/test/utils/base"
@@ -42,7 +43,7 @@ func (e *UnexposeTester) Run(cluster *base.ClusterContext) (stdout string, stder
 
 	// Validating stdout contains expected data
 	log.Printf("Validating 'skupper unexpose'")
-	expectedOut := fmt.Sprintf("%s %s unexposed", e.TargetType, e.TargetName)
+	expectedOut := messages.Sprintf(messages.ServiceUnexposed, e.TargetType, e.TargetName)
 	if !strings.Contains(stdout, expectedOut) {
 		err = fmt.Errorf("expected: %s - found: %s", expectedOut, stdout)
 		return
@@ -29,7 +29,7 @@ func IsConsoleEnabled(cluster *base.ClusterContext) bool {
 }
 
 func GetInternalCredentials(cluster *base.ClusterContext) (error, string, string) {
-	secret, err := cluster.VanClient.KubeClient.CoreV1().Secrets(cluster.Namespace).Get("skupper-console-users", v1.GetOptions{})
+	secret, err := cluster.VanClient.KubeClient.CoreV1().Secrets(cluster.Namespace).Get("skupper-router-console-users", v1.GetOptions{})
 	if err != nil {
 		return err, "", ""
 	}